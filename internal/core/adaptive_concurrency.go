@@ -0,0 +1,265 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============ Adaptive Concurrency (AIMD) ============
+//
+// With LLM_ADAPTIVE_CONCURRENCY each provider gets its own limiter in place
+// of the static LLM_MAX_CONCURRENT one. The limit grows by one per window of
+// fast successful requests and halves when a request is rate limited (429)
+// or the smoothed latency exceeds the target.
+
+const (
+	adaptiveIncrease   = 1.0             // Added per window of fast requests
+	adaptiveDecrease   = 0.5             // Limit multiplier on congestion
+	adaptiveCooldown   = 2 * time.Second // Minimum time between decreases
+	adaptiveEWMAWeight = 0.2             // Weight of the newest latency sample
+
+	defaultAdaptiveMinConcurrent = 1
+	defaultAdaptiveTargetLatency = 30 * time.Second
+)
+
+// adaptiveLimiter is a FIFO limiter whose limit follows AIMD
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	provider string
+	min, max float64
+	target   time.Duration
+
+	limit        float64
+	inFlight     int
+	waiters      *list.List // chan struct{} per waiter, in arrival order
+	latency      time.Duration
+	lastDecrease time.Time
+
+	successes  int64
+	rateLimits int64
+	slow       int64
+	increases  int64
+	decreases  int64
+}
+
+func newAdaptiveLimiter(provider string, initial, min, max int, target time.Duration) *adaptiveLimiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	if initial < min {
+		initial = min
+	}
+	if initial > max {
+		initial = max
+	}
+	return &adaptiveLimiter{
+		provider: provider,
+		min:      float64(min),
+		max:      float64(max),
+		target:   target,
+		limit:    float64(initial),
+		waiters:  list.New(),
+	}
+}
+
+// capacityLocked is the whole number of requests allowed in flight
+func (l *adaptiveLimiter) capacityLocked() int {
+	return int(math.Floor(l.limit))
+}
+
+// acquire waits in FIFO order for a slot; the returned release records the
+// request latency and must be called exactly once
+func (l *adaptiveLimiter) acquire(ctx context.Context) (func(), error) {
+	l.mu.Lock()
+	if l.waiters.Len() == 0 && l.inFlight < l.capacityLocked() {
+		l.inFlight++
+		l.mu.Unlock()
+		return l.releaser(), nil
+	}
+	ready := make(chan struct{})
+	elem := l.waiters.PushBack(ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return l.releaser(), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		select {
+		case <-ready:
+			// Granted while cancelling; hand the slot on
+			l.inFlight--
+			l.wakeLocked()
+		default:
+			l.waiters.Remove(elem)
+		}
+		l.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (l *adaptiveLimiter) releaser() func() {
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(time.Since(start)) })
+	}
+}
+
+func (l *adaptiveLimiter) release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+
+	if l.latency == 0 {
+		l.latency = latency
+	} else {
+		l.latency = time.Duration(adaptiveEWMAWeight*float64(latency) + (1-adaptiveEWMAWeight)*float64(l.latency))
+	}
+
+	if l.target > 0 && l.latency > l.target {
+		l.slow++
+		l.decreaseLocked()
+	} else {
+		l.successes++
+		// One step per window of limit requests
+		if l.limit < l.max {
+			before := l.capacityLocked()
+			l.limit = math.Min(l.max, l.limit+adaptiveIncrease/l.limit)
+			if l.capacityLocked() > before {
+				l.increases++
+			}
+		}
+	}
+	l.wakeLocked()
+}
+
+// rateLimited records a 429 from the provider
+func (l *adaptiveLimiter) rateLimited() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rateLimits++
+	l.decreaseLocked()
+}
+
+func (l *adaptiveLimiter) decreaseLocked() {
+	now := time.Now()
+	if now.Sub(l.lastDecrease) < adaptiveCooldown || l.limit <= l.min {
+		return
+	}
+	l.lastDecrease = now
+	l.limit = math.Max(l.min, l.limit*adaptiveDecrease)
+	l.decreases++
+	log.Printf("[RATE-LIMIT] %s concurrency reduced to %d", l.provider, l.capacityLocked())
+}
+
+// wakeLocked grants slots to waiters while capacity allows
+func (l *adaptiveLimiter) wakeLocked() {
+	for l.waiters.Len() > 0 && l.inFlight < l.capacityLocked() {
+		front := l.waiters.Front()
+		l.waiters.Remove(front)
+		l.inFlight++
+		close(front.Value.(chan struct{}))
+	}
+}
+
+// AdaptiveLimitStatus reports one provider's adaptive limiter
+type AdaptiveLimitStatus struct {
+	Provider     string  `json:"provider"`
+	Limit        int     `json:"limit"`
+	LimitExact   float64 `json:"limit_exact"`
+	InFlight     int     `json:"in_flight"`
+	Queued       int     `json:"queued"`
+	LatencyMs    int64   `json:"latency_ms"` // Smoothed request latency
+	TargetMs     int64   `json:"target_latency_ms"`
+	Successes    int64   `json:"successes"`
+	RateLimited  int64   `json:"rate_limited"`
+	SlowRequests int64   `json:"slow_requests"`
+	Increases    int64   `json:"increases"`
+	Decreases    int64   `json:"decreases"`
+}
+
+func (l *adaptiveLimiter) status() AdaptiveLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return AdaptiveLimitStatus{
+		Provider:     l.provider,
+		Limit:        l.capacityLocked(),
+		LimitExact:   math.Round(l.limit*100) / 100,
+		InFlight:     l.inFlight,
+		Queued:       l.waiters.Len(),
+		LatencyMs:    l.latency.Milliseconds(),
+		TargetMs:     l.target.Milliseconds(),
+		Successes:    l.successes,
+		RateLimited:  l.rateLimits,
+		SlowRequests: l.slow,
+		Increases:    l.increases,
+		Decreases:    l.decreases,
+	}
+}
+
+// Per-provider adaptive limiters, created on first use
+var (
+	adaptiveLimiters     = make(map[string]*adaptiveLimiter)
+	adaptiveLimitersLock sync.Mutex
+)
+
+// getAdaptiveLimiter returns the provider's limiter, or nil when adaptive
+// concurrency is off
+func getAdaptiveLimiter(provider string) *adaptiveLimiter {
+	cfg := GetConfig()
+	if !cfg.AdaptiveConcurrency {
+		return nil
+	}
+
+	adaptiveLimitersLock.Lock()
+	defer adaptiveLimitersLock.Unlock()
+	if l, ok := adaptiveLimiters[provider]; ok {
+		return l
+	}
+	initial := cfg.MaxConcurrentLLMRequests
+	if initial <= 0 {
+		initial = cfg.AdaptiveMaxConcurrent
+	}
+	l := newAdaptiveLimiter(provider, initial, cfg.AdaptiveMinConcurrent, cfg.AdaptiveMaxConcurrent, cfg.AdaptiveTargetLatency)
+	adaptiveLimiters[provider] = l
+	return l
+}
+
+// NoteRateLimited tells the provider's adaptive limiter about a 429
+func NoteRateLimited(provider string) {
+	if l := getAdaptiveLimiter(provider); l != nil {
+		l.rateLimited()
+	}
+}
+
+// adaptiveLimitStatuses reports every adaptive limiter, sorted by provider
+func adaptiveLimitStatuses() []AdaptiveLimitStatus {
+	adaptiveLimitersLock.Lock()
+	limiters := make([]*adaptiveLimiter, 0, len(adaptiveLimiters))
+	for _, l := range adaptiveLimiters {
+		limiters = append(limiters, l)
+	}
+	adaptiveLimitersLock.Unlock()
+
+	statuses := make([]AdaptiveLimitStatus, 0, len(limiters))
+	for _, l := range limiters {
+		statuses = append(statuses, l.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Provider < statuses[j].Provider })
+	return statuses
+}
+
+func resetAdaptiveLimiters() {
+	adaptiveLimitersLock.Lock()
+	adaptiveLimiters = make(map[string]*adaptiveLimiter)
+	adaptiveLimitersLock.Unlock()
+}
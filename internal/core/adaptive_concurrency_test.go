@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiter_AIMD(t *testing.T) {
+	l := newAdaptiveLimiter("openai", 1, 1, 3, time.Hour)
+
+	for i := 0; i < 4; i++ {
+		release, err := l.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("Acquire %d failed: %v", i, err)
+		}
+		release()
+		release() // Second call is a no-op
+	}
+	if s := l.status(); s.Limit != 3 || s.InFlight != 0 || s.Successes != 4 || s.Increases != 2 {
+		t.Fatalf("Expected the limit to grow to the maximum, got %+v", s)
+	}
+
+	l.rateLimited()
+	l.rateLimited() // Within the cooldown
+	if s := l.status(); s.Limit != 1 || s.RateLimited != 2 || s.Decreases != 1 {
+		t.Errorf("Expected one multiplicative decrease, got %+v", s)
+	}
+
+	slow := newAdaptiveLimiter("anthropic", 4, 1, 8, time.Nanosecond)
+	release, _ := slow.acquire(context.Background())
+	time.Sleep(time.Millisecond)
+	release()
+	if s := slow.status(); s.Limit != 2 || s.SlowRequests != 1 {
+		t.Errorf("Expected slow requests to back off, got %+v", s)
+	}
+}
+
+func TestAdaptiveLimiter_FIFOAndCancellation(t *testing.T) {
+	l := newAdaptiveLimiter("groq", 1, 1, 1, time.Hour)
+	hold, _ := l.acquire(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := l.acquire(ctx)
+		cancelled <- err
+	}()
+	for l.status().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	granted := make(chan func(), 1)
+	go func() {
+		r, _ := l.acquire(context.Background())
+		granted <- r
+	}()
+	for l.status().Queued != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-cancelled; err == nil {
+		t.Fatal("Expected the cancelled waiter to fail")
+	}
+	hold()
+	select {
+	case r := <-granted:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("Expected the remaining waiter to get the slot")
+	}
+	if s := l.status(); s.InFlight != 0 || s.Queued != 0 {
+		t.Errorf("Expected an idle limiter, got %+v", s)
+	}
+}
+
+func TestAdaptiveConcurrency_Config(t *testing.T) {
+	ResetConfig()
+	defer ResetConfig()
+	if getAdaptiveLimiter("openai") != nil {
+		t.Fatal("Expected no adaptive limiter by default")
+	}
+
+	os.Setenv("LLM_ADAPTIVE_CONCURRENCY", "true")
+	os.Setenv("LLM_ADAPTIVE_MIN", "2")
+	os.Setenv("LLM_ADAPTIVE_MAX", "50")
+	os.Setenv("LLM_ADAPTIVE_TARGET_LATENCY", "5")
+	defer func() {
+		for _, k := range []string{"LLM_ADAPTIVE_CONCURRENCY", "LLM_ADAPTIVE_MIN", "LLM_ADAPTIVE_MAX", "LLM_ADAPTIVE_TARGET_LATENCY"} {
+			os.Unsetenv(k)
+		}
+	}()
+	ResetConfig()
+
+	l := getAdaptiveLimiter("openai")
+	if l == nil || getAdaptiveLimiter("openai") != l {
+		t.Fatal("Expected one adaptive limiter per provider")
+	}
+	s := l.status()
+	if s.Limit != 2 || l.max != maxConcurrentLLMRequests || s.TargetMs != 5000 {
+		t.Errorf("Expected the default start clamped to the minimum and the max clamped, got %+v (max %v)", s, l.max)
+	}
+
+	NoteRateLimited("openai")
+	if statuses := adaptiveLimitStatuses(); len(statuses) != 1 || statuses[0].RateLimited != 1 {
+		t.Errorf("Expected the 429 recorded, got %+v", statuses)
+	}
+}
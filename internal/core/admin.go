@@ -0,0 +1,276 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Runtime Administration ============
+//
+// admin_config changes settings of a running server: it disables and
+// re-enables MCP tools (clients get notifications/tools/list_changed) and
+// built-in tool executors, switches the default provider and sets the LLM
+// concurrency limit. It is only registered when MCP_ADMIN_TOKEN is set, and
+// callers other than the stdio client must present one of those tokens.
+
+const adminToolName = "admin_config"
+
+type requestTokenKey struct{}
+
+// withRequestToken keeps the HTTP request's token for admin_config
+func withRequestToken(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestTokenKey{}, requestToken(r))
+}
+
+// adminAuthFromEnv reads the comma-separated admin tokens of MCP_ADMIN_TOKEN
+func adminAuthFromEnv() AuthConfig {
+	var config AuthConfig
+	for _, token := range strings.Split(os.Getenv("MCP_ADMIN_TOKEN"), ",") {
+		config.add(token)
+	}
+	return config
+}
+
+// adminAuthorized reports whether the caller may use admin_config. The stdio
+// client started the server process, so it needs no token.
+func adminAuthorized(ctx context.Context, auth AuthConfig) bool {
+	if sessionTransportName(server.ClientSessionFromContext(ctx)) == "stdio" {
+		return true
+	}
+	token, _ := ctx.Value(requestTokenKey{}).(string)
+	return auth.valid(token)
+}
+
+// disabledToolSet holds the MCP tools taken off the server, so they can be
+// registered again
+type disabledToolSet struct {
+	mu    sync.Mutex
+	tools map[string]server.ServerTool
+}
+
+var disabledMCPTools = &disabledToolSet{tools: make(map[string]server.ServerTool)}
+
+// disable removes the named tools from s and reports the ones it removed
+func (d *disabledToolSet) disable(s *server.MCPServer, names ...string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var removed []string
+	for _, name := range names {
+		if name == adminToolName {
+			continue
+		}
+		if tool := s.GetTool(name); tool != nil {
+			d.tools[name] = *tool
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) > 0 {
+		s.DeleteTools(removed...)
+	}
+	return removed
+}
+
+// enable registers the named disabled tools again and reports them
+func (d *disabledToolSet) enable(s *server.MCPServer, names ...string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var tools []server.ServerTool
+	var restored []string
+	for _, name := range names {
+		if tool, ok := d.tools[name]; ok {
+			tools = append(tools, tool)
+			restored = append(restored, name)
+			delete(d.tools, name)
+		}
+	}
+	if len(tools) > 0 {
+		s.AddTools(tools...)
+	}
+	return restored
+}
+
+func (d *disabledToolSet) has(name string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.tools[name]
+	return ok
+}
+
+func (d *disabledToolSet) names() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	names := make([]string, 0, len(d.tools))
+	for name := range d.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AdminConfigResult is what admin_config changed and the resulting settings
+type AdminConfigResult struct {
+	Changes           []string `json:"changes,omitempty"`
+	DisabledTools     []string `json:"disabled_tools"`
+	DisabledExecutors []string `json:"disabled_executors"`
+	DefaultProvider   string   `json:"default_provider"`
+	MaxConcurrent     int      `json:"max_concurrent"` // 0 = unlimited
+}
+
+func adminStatus(changes []string) AdminConfigResult {
+	disabledExecutors.RLock()
+	executors := make([]string, 0, len(disabledExecutors.names))
+	for name := range disabledExecutors.names {
+		executors = append(executors, name)
+	}
+	disabledExecutors.RUnlock()
+	sort.Strings(executors)
+
+	return AdminConfigResult{
+		Changes:           changes,
+		DisabledTools:     disabledMCPTools.names(),
+		DisabledExecutors: executors,
+		DefaultProvider:   withDefault(os.Getenv("LLM_PROVIDER"), detectProviderFromEnv()),
+		MaxConcurrent:     GetConfig().MaxConcurrentLLMRequests,
+	}
+}
+
+// adminNames splits a comma-separated list argument
+func adminNames(args map[string]interface{}, key string) []string {
+	raw, _ := args[key].(string)
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// newAdminConfigHandler changes the settings of s
+func newAdminConfigHandler(s *server.MCPServer, auth AuthConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !adminAuthorized(ctx, auth) {
+			return mcp.NewToolResultError("admin_config needs an MCP_ADMIN_TOKEN bearer token"), nil
+		}
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			args = map[string]interface{}{}
+		}
+
+		// Validate everything before changing anything
+		executors := make(map[string]bool)
+		for _, name := range getAvailableToolNames() {
+			executors[name] = true
+		}
+		for _, key := range []string{"enable_executors", "disable_executors"} {
+			for _, name := range adminNames(args, key) {
+				if !executors[name] {
+					return mcp.NewToolResultError(fmt.Sprintf("unknown tool executor %q", name)), nil
+				}
+			}
+		}
+		for _, name := range adminNames(args, "disable_tools") {
+			if name == adminToolName {
+				return mcp.NewToolResultError("admin_config cannot disable itself"), nil
+			}
+			if s.GetTool(name) == nil && !disabledMCPTools.has(name) {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown tool %q", name)), nil
+			}
+		}
+		for _, name := range adminNames(args, "enable_tools") {
+			if !disabledMCPTools.has(name) && s.GetTool(name) == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown tool %q", name)), nil
+			}
+		}
+		provider, _ := args["default_provider"].(string)
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider != "" && !isProviderConfigured(provider) {
+			return mcp.NewToolResultError(fmt.Sprintf("provider %q is unknown or has no API key", provider)), nil
+		}
+		maxConcurrent, setConcurrency := args["max_concurrent"].(float64)
+		if setConcurrency && maxConcurrent < 0 {
+			return mcp.NewToolResultError("max_concurrent must be 0 (unlimited) or more"), nil
+		}
+
+		var changes []string
+		if enabled := disabledMCPTools.enable(s, adminNames(args, "enable_tools")...); len(enabled) > 0 {
+			changes = append(changes, "enabled tools "+strings.Join(enabled, ", "))
+		}
+		if disabled := disabledMCPTools.disable(s, adminNames(args, "disable_tools")...); len(disabled) > 0 {
+			changes = append(changes, "disabled tools "+strings.Join(disabled, ", "))
+		}
+		if names := adminNames(args, "enable_executors"); len(names) > 0 {
+			for _, name := range names {
+				setExecutorDisabled(name, false)
+			}
+			changes = append(changes, "enabled executors "+strings.Join(names, ", "))
+		}
+		if names := adminNames(args, "disable_executors"); len(names) > 0 {
+			for _, name := range names {
+				setExecutorDisabled(name, true)
+			}
+			changes = append(changes, "disabled executors "+strings.Join(names, ", "))
+		}
+		if provider != "" {
+			os.Setenv("LLM_PROVIDER", provider)
+			changes = append(changes, "default provider "+provider)
+		}
+		if setConcurrency {
+			setLLMMaxConcurrent(int(maxConcurrent))
+			changes = append(changes, fmt.Sprintf("max concurrent LLM requests %d", GetConfig().MaxConcurrentLLMRequests))
+		}
+		if len(changes) > 0 {
+			log.Printf("[ADMIN] %s", strings.Join(changes, "; "))
+		}
+
+		output, err := json.MarshalIndent(adminStatus(changes), "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize admin config: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(output)), nil
+	}
+}
+
+// registerAdminTool adds admin_config when MCP_ADMIN_TOKEN is set
+func registerAdminTool(s *server.MCPServer) {
+	auth := adminAuthFromEnv()
+	if !auth.Enabled() {
+		return
+	}
+	adminTool := mcp.NewTool(adminToolName,
+		updateToolHints("Admin Config", true),
+		outputSchema(map[string]string{"changes": "array", "disabled_tools": "array", "disabled_executors": "array", "default_provider": "string", "max_concurrent": "integer"}),
+		mcp.WithDescription("Change server settings at runtime: disable or re-enable MCP tools and built-in tool executors, "+
+			"switch the default provider and set the LLM concurrency limit. Needs an MCP_ADMIN_TOKEN bearer token over HTTP. "+
+			"Call with no parameters to show the current settings."),
+		mcp.WithString("disable_tools",
+			mcp.Description("Comma-separated MCP tools to remove from the tool list"),
+		),
+		mcp.WithString("enable_tools",
+			mcp.Description("Comma-separated disabled MCP tools to register again"),
+		),
+		mcp.WithString("disable_executors",
+			mcp.Description("Comma-separated built-in tools (calculator, web_fetch, ...) reasoning runs may no longer call"),
+		),
+		mcp.WithString("enable_executors",
+			mcp.Description("Comma-separated built-in tools to allow again (code_exec still needs CODE_EXEC_ENABLED)"),
+		),
+		mcp.WithString("default_provider",
+			mcp.Description("Provider for calls that don't name one, like LLM_PROVIDER"),
+		),
+		mcp.WithNumber("max_concurrent",
+			mcp.Description("Concurrent LLM requests across all runs, like LLM_MAX_CONCURRENT (0 = unlimited)"),
+		),
+	)
+	s.AddTool(adminTool, newAdminConfigHandler(s, auth))
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func adminServer(t *testing.T) *server.MCPServer {
+	t.Helper()
+	t.Setenv("MCP_ADMIN_TOKEN", "admin-secret")
+	s := server.NewMCPServer(serverName, serverVersion, server.WithToolCapabilities(true))
+	s.AddTool(mcp.NewTool("list_providers"), handleListProviders)
+	registerAdminTool(s)
+	return s
+}
+
+// callAdmin calls admin_config with token as the request's bearer token
+func callAdmin(t *testing.T, s *server.MCPServer, token string, args map[string]interface{}) (AdminConfigResult, string) {
+	t.Helper()
+	ctx := context.WithValue(context.Background(), requestTokenKey{}, token)
+	params, _ := json.Marshal(map[string]interface{}{"name": adminToolName, "arguments": args})
+	raw, _ := json.Marshal(s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": `+string(params)+`}`)))
+	var resp struct {
+		Result mcp.CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		t.Fatal(err)
+	}
+	text := resp.Result.Content[0].(mcp.TextContent).Text
+	if resp.Result.IsError {
+		return AdminConfigResult{}, text
+	}
+	var result AdminConfigResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("Invalid admin_config output %s: %v", text, err)
+	}
+	return result, ""
+}
+
+func TestAdminConfig_NotRegisteredWithoutToken(t *testing.T) {
+	t.Setenv("MCP_ADMIN_TOKEN", "")
+	s := server.NewMCPServer(serverName, serverVersion)
+	registerAdminTool(s)
+	if s.GetTool(adminToolName) != nil {
+		t.Error("Expected no admin_config without MCP_ADMIN_TOKEN")
+	}
+}
+
+func TestAdminConfig_RequiresAdminToken(t *testing.T) {
+	s := adminServer(t)
+	for _, token := range []string{"", "wrong"} {
+		if _, errText := callAdmin(t, s, token, nil); !strings.Contains(errText, "MCP_ADMIN_TOKEN") {
+			t.Errorf("Expected token %q to be rejected, got %q", token, errText)
+		}
+	}
+	if _, errText := callAdmin(t, s, "admin-secret", nil); errText != "" {
+		t.Errorf("Expected the admin token to be accepted, got %q", errText)
+	}
+}
+
+func TestAdminConfig_DisableAndEnableTools(t *testing.T) {
+	s := adminServer(t)
+	t.Cleanup(func() { disabledMCPTools.enable(s, "list_providers") })
+
+	result, errText := callAdmin(t, s, "admin-secret", map[string]interface{}{"disable_tools": "list_providers"})
+	if errText != "" {
+		t.Fatal(errText)
+	}
+	if s.GetTool("list_providers") != nil || len(result.DisabledTools) != 1 || result.DisabledTools[0] != "list_providers" {
+		t.Errorf("Expected list_providers to be disabled, got %+v", result)
+	}
+
+	if _, errText := callAdmin(t, s, "admin-secret", map[string]interface{}{"disable_tools": adminToolName}); errText == "" {
+		t.Error("Expected admin_config to refuse disabling itself")
+	}
+	if _, errText := callAdmin(t, s, "admin-secret", map[string]interface{}{"enable_tools": "no_such_tool"}); !strings.Contains(errText, "unknown tool") {
+		t.Errorf("Expected an unknown tool error, got %q", errText)
+	}
+
+	result, errText = callAdmin(t, s, "admin-secret", map[string]interface{}{"enable_tools": "list_providers"})
+	if errText != "" {
+		t.Fatal(errText)
+	}
+	if s.GetTool("list_providers") == nil || len(result.DisabledTools) != 0 {
+		t.Errorf("Expected list_providers to be registered again, got %+v", result)
+	}
+}
+
+func TestAdminConfig_ExecutorsProviderAndConcurrency(t *testing.T) {
+	s := adminServer(t)
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("LLM_MAX_CONCURRENT", "")
+	ResetConfig()
+	t.Cleanup(func() {
+		setExecutorDisabled("calculator", false)
+		ResetConfig()
+	})
+
+	result, errText := callAdmin(t, s, "admin-secret", map[string]interface{}{
+		"disable_executors": "calculator",
+		"default_provider":  "mock",
+		"max_concurrent":    3,
+	})
+	if errText != "" {
+		t.Fatal(errText)
+	}
+	if len(result.Changes) != 3 || result.DefaultProvider != "mock" || result.MaxConcurrent != 3 {
+		t.Errorf("Unexpected admin_config result %+v", result)
+	}
+	if os.Getenv("LLM_PROVIDER") != "mock" || GetConfig().MaxConcurrentLLMRequests != 3 {
+		t.Errorf("Expected the provider and limit to change, got %q and %d", os.Getenv("LLM_PROVIDER"), GetConfig().MaxConcurrentLLMRequests)
+	}
+
+	registry := NewToolRegistry()
+	if registry.enabled["calculator"] {
+		t.Error("Expected new registries to leave calculator disabled")
+	}
+	registry.SetEnabled([]string{"calculator", "string_ops"})
+	if registry.enabled["calculator"] || !registry.enabled["string_ops"] {
+		t.Errorf("Expected SetEnabled to keep calculator disabled, got %v", registry.enabled)
+	}
+
+	if _, errText := callAdmin(t, s, "admin-secret", map[string]interface{}{"enable_executors": "calculator"}); errText != "" {
+		t.Fatal(errText)
+	}
+	if !NewToolRegistry().enabled["calculator"] {
+		t.Error("Expected calculator to be enabled again")
+	}
+
+	for want, args := range map[string]map[string]interface{}{
+		"unknown tool executor": {"disable_executors": "teleport"},
+		"no API key":            {"default_provider": "no-such-provider"},
+		"max_concurrent":        {"max_concurrent": -1},
+	} {
+		if _, errText := callAdmin(t, s, "admin-secret", args); !strings.Contains(errText, want) {
+			t.Errorf("Expected %q in the error for %v, got %q", want, args, errText)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ============ Tool Argument Schemas ============
+//
+// The strategy tools declare their numeric and boolean arguments as argSpecs
+// (strategyArgSpecs). parseArgs checks the type and range of each one, lists
+// every invalid field in a single error, and fills in the defaults, so an
+// out-of-range branching_factor=0 is rejected instead of producing nonsense.
+
+type argKind int
+
+const (
+	argInt argKind = iota
+	argNumber
+	argBool
+)
+
+// noArgLimit is the Max of arguments without an upper bound
+const noArgLimit = math.MaxInt32
+
+// argSpec declares one tool argument
+type argSpec struct {
+	Name     string
+	Kind     argKind
+	Default  interface{} // nil leaves the argument unset when omitted
+	Min, Max float64     // Allowed range of argInt and argNumber
+}
+
+// parsedArgs holds validated arguments, with defaults filled in
+type parsedArgs map[string]interface{}
+
+// Has reports whether name was passed or has a default
+func (p parsedArgs) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+func (p parsedArgs) Int(name string) int {
+	n, _ := p[name].(float64)
+	return int(n)
+}
+
+func (p parsedArgs) Float(name string) float64 {
+	n, _ := p[name].(float64)
+	return n
+}
+
+func (p parsedArgs) Bool(name string) bool {
+	b, _ := p[name].(bool)
+	return b
+}
+
+// SetInt, SetFloat and SetBool overwrite *dst only when name was passed or
+// has a default, so omitted arguments keep a config's own default
+func (p parsedArgs) SetInt(dst *int, name string) {
+	if p.Has(name) {
+		*dst = p.Int(name)
+	}
+}
+
+func (p parsedArgs) SetFloat(dst *float64, name string) {
+	if p.Has(name) {
+		*dst = p.Float(name)
+	}
+}
+
+func (p parsedArgs) SetBool(dst *bool, name string) {
+	if p.Has(name) {
+		*dst = p.Bool(name)
+	}
+}
+
+// parseArgs validates args against specs. A JSON null counts as omitted. The
+// error is an E_INVALID_ARGUMENT toolError naming every invalid field.
+func parseArgs(args map[string]interface{}, specs []argSpec) (parsedArgs, error) {
+	parsed := make(parsedArgs, len(specs))
+	var problems, fields []string
+	for _, spec := range specs {
+		raw, ok := args[spec.Name]
+		if !ok || raw == nil {
+			if spec.Default != nil {
+				parsed[spec.Name] = normalizeArgValue(spec.Default)
+			}
+			continue
+		}
+		value, err := spec.check(raw)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", spec.Name, err))
+			fields = append(fields, spec.Name)
+			continue
+		}
+		parsed[spec.Name] = value
+	}
+	if len(problems) > 0 {
+		return nil, &toolError{Code: errCodeInvalidArgument, Message: "invalid arguments: " + strings.Join(problems, "; "), Fields: fields}
+	}
+	return parsed, nil
+}
+
+// check converts an argument value to the spec's type and range
+func (spec argSpec) check(raw interface{}) (interface{}, error) {
+	if spec.Kind == argBool {
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be true or false, got %s", describeArgValue(raw))
+		}
+		return b, nil
+	}
+	n, ok := normalizeArgValue(raw).(float64)
+	if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+		return nil, fmt.Errorf("must be a number, got %s", describeArgValue(raw))
+	}
+	if spec.Kind == argInt && n != math.Trunc(n) {
+		return nil, fmt.Errorf("must be a whole number, got %v", n)
+	}
+	if n < spec.Min || n > spec.Max {
+		if spec.Max == noArgLimit {
+			return nil, fmt.Errorf("must be at least %v, got %v", spec.Min, n)
+		}
+		return nil, fmt.Errorf("must be between %v and %v, got %v", spec.Min, spec.Max, n)
+	}
+	return n, nil
+}
+
+// normalizeArgValue turns the numbers of Go callers into JSON's float64
+func normalizeArgValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case json.Number:
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+func describeArgValue(v interface{}) string {
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseArgs_DefaultsAndTypes(t *testing.T) {
+	specs := []argSpec{
+		{Name: "count", Kind: argInt, Default: 3, Min: 1, Max: 10},
+		{Name: "ratio", Kind: argNumber, Min: 0, Max: 1},
+		{Name: "enabled", Kind: argBool, Default: true},
+	}
+	parsed, err := parseArgs(map[string]interface{}{"ratio": 0.5, "enabled": nil}, specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Int("count") != 3 || parsed.Float("ratio") != 0.5 || !parsed.Bool("enabled") {
+		t.Errorf("Expected defaults and values, got %v", parsed)
+	}
+
+	parsed, err = parseArgs(map[string]interface{}{"count": 7}, specs)
+	if err != nil || parsed.Int("count") != 7 || parsed.Has("ratio") {
+		t.Errorf("Expected Go ints to be accepted and ratio to stay unset, got %v, %v", parsed, err)
+	}
+}
+
+func TestParseArgs_ListsEveryInvalidField(t *testing.T) {
+	_, err := parseArgs(map[string]interface{}{
+		"branching_factor": 0.0,
+		"max_nodes":        "lots",
+		"max_depth":        2.5,
+		"enable_merging":   "yes",
+		"max_refinements":  -1.0,
+	}, gotArgSpecs)
+	if err == nil {
+		t.Fatal("Expected invalid arguments to be rejected")
+	}
+	for _, want := range []string{
+		"branching_factor must be between 1 and 10, got 0",
+		`max_nodes must be a number, got "lots"`,
+		"max_depth must be a whole number, got 2.5",
+		`enable_merging must be true or false, got "yes"`,
+		"max_refinements must be between 0 and 100, got -1",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %q", want, err)
+		}
+	}
+
+	_, err = parseArgs(map[string]interface{}{"attempt_max_tokens": -5.0}, reflexionArgSpecs)
+	if err == nil || !strings.Contains(err.Error(), "attempt_max_tokens must be at least 0, got -5") {
+		t.Errorf("Expected an unbounded range message, got %v", err)
+	}
+}
+
+func TestArgSpecs_DefaultsMatchConfigs(t *testing.T) {
+	got, err := gotConfigFromArgs(map[string]interface{}{})
+	want := DefaultGoTConfig()
+	if err != nil || got.BranchingFactor != want.BranchingFactor || got.MaxNodes != want.MaxNodes || got.MaxDepth != want.MaxDepth ||
+		got.EnableMerging != want.EnableMerging || got.MaxToolCalls != want.MaxToolCalls ||
+		got.SimilarityPrefilter != want.SimilarityPrefilter || got.SimilarityBand != want.SimilarityBand {
+		t.Errorf("GoT defaults drifted from DefaultGoTConfig: %+v, %v", got, err)
+	}
+
+	reflexion, err := reflexionConfigFromArgs(map[string]interface{}{}, nil)
+	defaultReflexion := DefaultReflexionConfig()
+	if err != nil || reflexion.MaxAttempts != defaultReflexion.MaxAttempts || reflexion.LearnFromPast != defaultReflexion.LearnFromPast ||
+		reflexion.ForceDiversity != defaultReflexion.ForceDiversity || reflexion.MaxToolCalls != defaultReflexion.MaxToolCalls {
+		t.Errorf("Reflexion defaults drifted from DefaultReflexionConfig: %+v, %v", reflexion, err)
+	}
+
+	dialectic, err := dialecticConfigFromArgs(map[string]interface{}{})
+	defaultDialectic := DefaultDialecticConfig()
+	if err != nil || dialectic.MaxRounds != defaultDialectic.MaxRounds || dialectic.ConfidenceTarget != defaultDialectic.ConfidenceTarget ||
+		dialectic.NumChallengers != defaultDialectic.NumChallengers || dialectic.MaxToolCalls != defaultDialectic.MaxToolCalls ||
+		dialectic.CacheVerifications != defaultDialectic.CacheVerifications || dialectic.OpenQuestions != defaultDialectic.OpenQuestions ||
+		dialectic.EarlyStop != defaultDialectic.EarlyStop || dialectic.MaxTokens != defaultDialectic.MaxTokens {
+		t.Errorf("Dialectic defaults drifted from DefaultDialecticConfig: %+v, %v", dialectic, err)
+	}
+
+	if decompose, err := decomposeConfigFromArgs(nil); err != nil || decompose != DefaultDecomposeConfig() {
+		t.Errorf("Decompose defaults drifted from DefaultDecomposeConfig: %+v, %v", decompose, err)
+	}
+	plan, err := planExecuteConfigFromArgs(nil)
+	defaultPlan := DefaultPlanExecuteConfig()
+	if err != nil || plan.MaxSteps != defaultPlan.MaxSteps || plan.MaxReplans != defaultPlan.MaxReplans ||
+		plan.EnableTools != defaultPlan.EnableTools || plan.MaxToolCalls != defaultPlan.MaxToolCalls {
+		t.Errorf("Plan defaults drifted from DefaultPlanExecuteConfig: %+v, %v", plan, err)
+	}
+}
+
+func TestStrategyHandlers_RejectInvalidArguments(t *testing.T) {
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t)
+
+	for name, args := range map[string]map[string]interface{}{
+		"sequential_thinking": {"max_thoughts": 0},
+		"graph_of_thoughts":   {"branching_factor": 0},
+		"reflexion":           {"max_attempts": "three"},
+		"dialectic_reason":    {"num_challengers": 9},
+		"decompose_solve":     {"max_subproblems": -2},
+		"plan_execute":        {"enable_tools": "true"},
+	} {
+		args["problem"] = "What is 17 * 23?"
+		if msg := callToolError(t, s, name, args); !strings.HasPrefix(msg, "[E_INVALID_ARGUMENT] invalid arguments: ") {
+			t.Errorf("%s: expected an invalid arguments error, got %q", name, msg)
+		}
+	}
+
+	if _, err := parsePipelineSpec(`[{"strategy": "graph_of_thoughts", "params": {"max_nodes": 0}}]`); err == nil ||
+		!strings.Contains(err.Error(), "stage 1: [E_INVALID_ARGUMENT] invalid arguments: max_nodes") {
+		t.Errorf("Expected the pipeline spec to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ============ API Authentication ============
+
+const authRealm = "reasoning-tools"
+
+// AuthConfig holds the tokens accepted by the HTTP transports
+type AuthConfig struct {
+	tokens [][sha256.Size]byte // Hashed so comparisons don't depend on token length
+}
+
+// Enabled reports whether any token is configured
+func (c AuthConfig) Enabled() bool {
+	return len(c.tokens) > 0
+}
+
+// authConfigFromEnv reads comma-separated tokens from MCP_AUTH_TOKEN and one
+// token per line from MCP_AUTH_TOKEN_FILE (blank lines and # comments are
+// skipped). Without tokens authentication is off.
+func authConfigFromEnv() (AuthConfig, error) {
+	var config AuthConfig
+	for _, token := range strings.Split(os.Getenv("MCP_AUTH_TOKEN"), ",") {
+		config.add(token)
+	}
+
+	path := strings.TrimSpace(os.Getenv("MCP_AUTH_TOKEN_FILE"))
+	if path == "" {
+		return config, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to open MCP_AUTH_TOKEN_FILE: %w", err)
+	}
+	defer f.Close()
+
+	before := len(config.tokens)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); !strings.HasPrefix(line, "#") {
+			config.add(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return config, fmt.Errorf("failed to read MCP_AUTH_TOKEN_FILE: %w", err)
+	}
+	if len(config.tokens) == before {
+		return config, fmt.Errorf("MCP_AUTH_TOKEN_FILE %s contains no tokens", path)
+	}
+	return config, nil
+}
+
+func (c *AuthConfig) add(token string) {
+	if token = strings.TrimSpace(token); token != "" {
+		c.tokens = append(c.tokens, sha256.Sum256([]byte(token)))
+	}
+}
+
+// valid compares a presented token against every configured token in
+// constant time
+func (c AuthConfig) valid(token string) bool {
+	if token == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(token))
+	match := 0
+	for _, t := range c.tokens {
+		match |= subtle.ConstantTimeCompare(sum[:], t[:])
+	}
+	return match == 1
+}
+
+// requestToken returns the token from "Authorization: Bearer <token>" or
+// the X-API-Key header
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// withAuth rejects requests without a valid token with 401. Without
+// configured tokens the handler is returned unchanged.
+func withAuth(next http.Handler, config AuthConfig) http.Handler {
+	if !config.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !config.valid(requestToken(r)) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q`, authRealm))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithAuth(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens")
+	os.WriteFile(tokenFile, []byte("# dashboard\nfile-token\n\n"), 0600)
+	t.Setenv("MCP_AUTH_TOKEN", "secret-1, secret-2")
+	t.Setenv("MCP_AUTH_TOKEN_FILE", tokenFile)
+
+	config, err := authConfigFromEnv()
+	if err != nil || len(config.tokens) != 3 {
+		t.Fatalf("Expected three tokens, got %d (%v)", len(config.tokens), err)
+	}
+
+	handler := withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), config)
+
+	for _, tc := range []struct {
+		header, value string
+		want          int
+	}{
+		{"Authorization", "Bearer secret-1", http.StatusOK},
+		{"Authorization", "bearer secret-2", http.StatusOK},
+		{"X-API-Key", "file-token", http.StatusOK},
+		{"Authorization", "Bearer secret-3", http.StatusUnauthorized},
+		{"Authorization", "Basic secret-1", http.StatusUnauthorized},
+		{"X-API-Key", "secret", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: %q: expected %d, got %d", tc.header, tc.value, tc.want, rec.Code)
+		}
+		if tc.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Expected a WWW-Authenticate challenge for %q", tc.value)
+		}
+	}
+
+	os.WriteFile(tokenFile, []byte("# empty\n"), 0600)
+	if _, err := authConfigFromEnv(); err == nil {
+		t.Error("Expected an error for a token file without tokens")
+	}
+
+	t.Setenv("MCP_AUTH_TOKEN", "")
+	t.Setenv("MCP_AUTH_TOKEN_FILE", "")
+	config, _ = authConfigFromEnv()
+	if config.Enabled() {
+		t.Error("Expected authentication off without tokens")
+	}
+}
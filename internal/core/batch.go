@@ -0,0 +1,265 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Batch Mode ============
+//
+// `reasoning-tools batch --input problems.jsonl --tool reflexion` runs every
+// problem in a JSONL file through one tool, a few at a time, and appends one
+// JSONL result per problem to the output file as it finishes. Calls go
+// through the full server stack, so the LLM limiter and provider slots cap
+// the load. Rerunning the same command resumes: problems that already have
+// a successful result in the output are skipped, failed ones run again.
+
+// BatchProblem is a line of the input: the problem and any other tool
+// arguments, which override --args. A line may also be a bare JSON string.
+type BatchProblem struct {
+	ID        string                 // "id" field, or the line number
+	Line      int                    // 1-based line in the input
+	Arguments map[string]interface{} // The line without its id
+}
+
+// BatchResult is a line of the output
+type BatchResult struct {
+	ID         string          `json:"id"`
+	Line       int             `json:"line"`
+	Tool       string          `json:"tool"`
+	Problem    string          `json:"problem,omitempty"`
+	Success    bool            `json:"success"`          // The call returned a result; whether it solved the problem is up to the result
+	Result     json.RawMessage `json:"result,omitempty"` // The tool's result, when it is JSON
+	Text       string          `json:"text,omitempty"`   // The tool's result, when it is not
+	Error      string          `json:"error,omitempty"`
+	DurationMS int64           `json:"duration_ms"`
+}
+
+// runBatch runs the batch subcommand on s and returns the exit code: 0 when
+// every problem succeeded, 1 when some failed and 2 on a usage error
+func runBatch(ctx context.Context, s *server.MCPServer, args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "JSONL file of problems: {\"id\": ..., \"problem\": ..., other tool arguments} per line")
+	output := fs.String("output", "", "JSONL file results are appended to (default: <input>.results.jsonl)")
+	tool := fs.String("tool", "", "Tool to run each problem through")
+	concurrency := fs.Int("concurrency", 4, "Problems run at once")
+	common := fs.String("args", "", "JSON object of tool arguments for every problem, e.g. {\"provider\": \"groq\"}")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" || *tool == "" {
+		fmt.Fprintln(stderr, "Usage: reasoning-tools [flags] batch --input problems.jsonl --tool <tool> [--output results.jsonl] [--concurrency 4] [--args '{...}']")
+		return 2
+	}
+	if s.GetTool(*tool) == nil {
+		fmt.Fprintf(stderr, "Unknown tool %q\n", *tool)
+		printCLITools(s, stderr)
+		return 2
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(*input, ".jsonl") + ".results.jsonl"
+	}
+	var commonArgs map[string]interface{}
+	if *common != "" {
+		if err := json.Unmarshal([]byte(*common), &commonArgs); err != nil {
+			fmt.Fprintf(stderr, "--args must be a JSON object: %v\n", err)
+			return 2
+		}
+	}
+
+	problems, err := readBatchProblems(*input)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+	done, err := completedBatchIDs(*output)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+	var pending []BatchProblem
+	for _, p := range problems {
+		if !done[p.ID] {
+			pending = append(pending, p)
+		}
+	}
+	if skipped := len(problems) - len(pending); skipped > 0 {
+		fmt.Fprintf(stderr, "[BATCH] Resuming: %d of %d problems already done\n", skipped, len(problems))
+	}
+
+	out, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to open output: %v\n", err)
+		return 2
+	}
+	defer out.Close()
+	if info, err := out.Stat(); err == nil && info.Size() > 0 {
+		// Start on a new line after a line cut short by a killed run
+		last := make([]byte, 1)
+		if f, err := os.Open(*output); err == nil {
+			f.ReadAt(last, info.Size()-1)
+			f.Close()
+		}
+		if last[0] != '\n' {
+			out.Write([]byte("\n"))
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		finished int
+		failed   int
+		wg       sync.WaitGroup
+	)
+	work := make(chan BatchProblem)
+	for i := 0; i < max(*concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range work {
+				result := runBatchProblem(ctx, s, *tool, p, commonArgs)
+				if ctx.Err() != nil {
+					return // Interrupted runs are left for the resume
+				}
+				line, _ := json.Marshal(result)
+				mu.Lock()
+				out.Write(append(line, '\n'))
+				finished++
+				if !result.Success {
+					failed++
+				}
+				fmt.Fprintf(stderr, "[BATCH] %d/%d done (%d failed): %s in %s\n", finished, len(pending), failed, p.ID, time.Duration(result.DurationMS)*time.Millisecond)
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, p := range pending {
+		select {
+		case work <- p:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		fmt.Fprintf(stderr, "[BATCH] Interrupted after %d of %d problems; run the same command again to resume\n", finished, len(pending))
+		return 1
+	}
+	fmt.Fprintf(stderr, "[BATCH] Finished %s (%d failed); results in %s\n", plural(finished, "problem"), failed, *output)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runBatchProblem runs one problem through tool
+func runBatchProblem(ctx context.Context, s *server.MCPServer, tool string, p BatchProblem, common map[string]interface{}) BatchResult {
+	args := make(map[string]interface{}, len(common)+len(p.Arguments))
+	for k, v := range common {
+		args[k] = v
+	}
+	for k, v := range p.Arguments {
+		args[k] = v
+	}
+	result := BatchResult{ID: p.ID, Line: p.Line, Tool: tool}
+	result.Problem, _ = args["problem"].(string)
+
+	start := time.Now()
+	call := callToolOn(ctx, s, tool, args)
+	result.DurationMS = time.Since(start).Milliseconds()
+	switch {
+	case call.IsError:
+		result.Error = call.Text
+	case json.Valid([]byte(call.Text)):
+		result.Result = json.RawMessage(call.Text)
+	default:
+		result.Text = call.Text
+	}
+	result.Success = !call.IsError
+	return result
+}
+
+// readBatchProblems reads the input file
+func readBatchProblems(path string) ([]BatchProblem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input: %w", err)
+	}
+	defer f.Close()
+	return parseBatchProblems(f, path)
+}
+
+// parseBatchProblems reads problems from JSONL, naming the input name in
+// errors. IDs must be unique.
+func parseBatchProblems(r io.Reader, name string) ([]BatchProblem, error) {
+	var problems []BatchProblem
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := BatchProblem{ID: strconv.Itoa(n), Line: n}
+		var problem string
+		if json.Unmarshal([]byte(line), &problem) == nil {
+			p.Arguments = map[string]interface{}{"problem": problem}
+		} else if err := json.Unmarshal([]byte(line), &p.Arguments); err != nil {
+			return nil, fmt.Errorf("%s:%d: expected a JSON object or string: %v", name, n, err)
+		}
+		if id, ok := p.Arguments["id"]; ok {
+			p.ID = fmt.Sprint(id)
+			delete(p.Arguments, "id")
+		}
+		if first, dup := seen[p.ID]; dup {
+			return nil, fmt.Errorf("%s:%d: id %q is already used on line %d", name, n, p.ID, first)
+		}
+		seen[p.ID] = n
+		problems = append(problems, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	return problems, nil
+}
+
+// completedBatchIDs returns the IDs with a successful result in an earlier
+// run's output. A missing output is an empty one; a truncated last line,
+// from a run that was killed mid-write, is ignored.
+func completedBatchIDs(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var result BatchResult
+		if json.Unmarshal(scanner.Bytes(), &result) == nil && result.Success {
+			done[result.ID] = true
+		}
+	}
+	return done, scanner.Err()
+}
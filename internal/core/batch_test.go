@@ -0,0 +1,53 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBatch_Resume(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	s := integrationServer(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "problems.jsonl")
+	os.WriteFile(input, []byte(`{"id": "sum", "problem": "What is 2 + 2?"}
+"What is 3 + 3?"
+
+{"problem": "What is 4 + 4?", "provider": "nonexistent"}
+`), 0644)
+	output := filepath.Join(dir, "problems.results.jsonl")
+	run := func() (int, string) {
+		var stderr bytes.Buffer
+		code := runBatch(context.Background(), s, []string{"--input", input, "--tool", "sequential_thinking", "--args", `{"provider": "mock"}`}, &stderr)
+		return code, stderr.String()
+	}
+
+	if code, log := run(); code != 1 || !strings.Contains(log, "Finished 3 problems (1 failed)") {
+		t.Fatalf("Expected one failure out of 3, got %d:\n%s", code, log)
+	}
+	ids, _ := completedBatchIDs(output)
+	if !ids["sum"] || !ids["2"] || ids["4"] {
+		t.Errorf("Expected sum and line 2 done, got %v", ids)
+	}
+
+	// Resuming reruns only the failed problem
+	if code, log := run(); code != 1 || !strings.Contains(log, "2 of 3 problems already done") || !strings.Contains(log, "Finished 1 problem (1 failed)") {
+		t.Errorf("Expected a resume of the failed problem, got %d:\n%s", code, log)
+	}
+	data, _ := os.ReadFile(output)
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("Expected 4 result lines, got %d", lines)
+	}
+}
+
+func TestReadBatchProblems_DuplicateID(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "problems.jsonl")
+	os.WriteFile(input, []byte("{\"id\": 1, \"problem\": \"a\"}\n{\"id\": 1, \"problem\": \"b\"}\n"), 0644)
+	if _, err := readBatchProblems(input); err == nil || !strings.Contains(err.Error(), "already used on line 1") {
+		t.Errorf("Expected a duplicate id error, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============ Confidence Calibration ============
+//
+// A single parsed evaluator score swings between runs. With calibration on,
+// each score comes from asking the evaluator several times at a non-zero
+// temperature; the mean is used, and the spread is reported per score and
+// summarized for the run.
+
+const (
+	defaultCalibrationTemperature = 0.7
+	maxCalibrationSamples         = 10
+	// Scores whose samples spread wider than this are counted as unstable
+	unstableScoreStdDev = 0.15
+)
+
+// CalibrationConfig controls evaluator sampling
+type CalibrationConfig struct {
+	Samples     int     `json:"samples"`     // Evaluator calls per score; 1 or less disables (default: 1)
+	Temperature float64 `json:"temperature"` // Sampling temperature (default: 0.7)
+}
+
+// DefaultCalibrationConfig returns calibration off, with the default
+// sampling temperature for when Samples is raised
+func DefaultCalibrationConfig() CalibrationConfig {
+	return CalibrationConfig{Samples: 1, Temperature: defaultCalibrationTemperature}
+}
+
+func (c CalibrationConfig) enabled() bool {
+	return c.Samples > 1
+}
+
+// temperature returns the evaluator temperature: the sampling temperature
+// when calibrating, otherwise the evaluator's usual one
+func (c CalibrationConfig) temperature(base float64) float64 {
+	if !c.enabled() {
+		return base
+	}
+	if c.Temperature > 0 {
+		return c.Temperature
+	}
+	return defaultCalibrationTemperature
+}
+
+// calibrationConfigFromArgs reads confidence_samples and confidence_temperature,
+// falling back to <TOOL>_CONFIDENCE_SAMPLES then CONFIDENCE_SAMPLES
+func calibrationConfigFromArgs(args map[string]interface{}, toolName string) CalibrationConfig {
+	config := DefaultCalibrationConfig()
+	config.Samples = parseEnvInt("CONFIDENCE_SAMPLES", config.Samples)
+	config.Samples = parseEnvInt(toolEnvKey(toolName, "CONFIDENCE_SAMPLES"), config.Samples)
+	if n, ok := args["confidence_samples"].(float64); ok {
+		config.Samples = int(n)
+	}
+	if v := strings.TrimSpace(os.Getenv("CONFIDENCE_TEMPERATURE")); v != "" {
+		if t, err := strconv.ParseFloat(v, 64); err == nil && t > 0 {
+			config.Temperature = t
+		}
+	}
+	if t, ok := args["confidence_temperature"].(float64); ok && t > 0 {
+		config.Temperature = t
+	}
+	if config.Samples > maxCalibrationSamples {
+		config.Samples = maxCalibrationSamples
+	}
+	config.Temperature = clampTemperature(config.Temperature)
+	return config
+}
+
+// ScoreSamples is the spread of one sampled score
+type ScoreSamples struct {
+	Scores   []float64 `json:"scores"`
+	Mean     float64   `json:"mean"`
+	Variance float64   `json:"variance"`
+	StdDev   float64   `json:"stddev"`
+}
+
+func summarizeScores(scores []float64) ScoreSamples {
+	s := ScoreSamples{Scores: scores}
+	if len(scores) == 0 {
+		return s
+	}
+	for _, v := range scores {
+		s.Mean += v
+	}
+	s.Mean /= float64(len(scores))
+	for _, v := range scores {
+		s.Variance += (v - s.Mean) * (v - s.Mean)
+	}
+	s.Variance /= float64(len(scores))
+	s.StdDev = math.Sqrt(s.Variance)
+	return s
+}
+
+// sampleEvaluations calls eval up to n times and returns the samples that
+// succeeded. It stops early once the LLM call budget runs out and only fails
+// when no sample succeeded.
+func sampleEvaluations[T any](ctx context.Context, n int, eval func(context.Context) (T, error)) ([]T, error) {
+	var samples []T
+	var lastErr error
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			lastErr = ctx.Err()
+			break
+		}
+		v, err := eval(ctx)
+		if err != nil {
+			lastErr = err
+			if errors.Is(err, ErrLLMCallBudgetExhausted) {
+				break
+			}
+			continue
+		}
+		samples = append(samples, v)
+	}
+	if len(samples) == 0 {
+		return nil, lastErr
+	}
+	return samples, nil
+}
+
+// CalibrationReport summarizes every sampled score of a run
+type CalibrationReport struct {
+	SamplesPerScore int     `json:"samples_per_score"`
+	Temperature     float64 `json:"temperature"`
+	Evaluations     int     `json:"evaluations"`
+	FailedSamples   int     `json:"failed_samples,omitempty"`
+	MeanScore       float64 `json:"mean_score"`
+	MeanStdDev      float64 `json:"mean_stddev"`
+	MaxStdDev       float64 `json:"max_stddev"`
+	MeanVariance    float64 `json:"mean_variance"`
+	Unstable        int     `json:"unstable_evaluations"` // Scores with a stddev above 0.15
+}
+
+// calibrationRecorder collects sampled scores; nil when calibration is off
+type calibrationRecorder struct {
+	mu      sync.Mutex
+	config  CalibrationConfig
+	samples []ScoreSamples
+	failed  int
+}
+
+func newCalibrationRecorder(config CalibrationConfig) *calibrationRecorder {
+	if !config.enabled() {
+		return nil
+	}
+	return &calibrationRecorder{config: config}
+}
+
+func (r *calibrationRecorder) record(s ScoreSamples) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, s)
+	r.failed += r.config.Samples - len(s.Scores)
+}
+
+func (r *calibrationRecorder) report() *CalibrationReport {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &CalibrationReport{
+		SamplesPerScore: r.config.Samples,
+		Temperature:     r.config.temperature(0),
+		Evaluations:     len(r.samples),
+		FailedSamples:   r.failed,
+	}
+	if len(r.samples) == 0 {
+		return report
+	}
+	for _, s := range r.samples {
+		report.MeanScore += s.Mean
+		report.MeanStdDev += s.StdDev
+		report.MeanVariance += s.Variance
+		report.MaxStdDev = math.Max(report.MaxStdDev, s.StdDev)
+		if s.StdDev > unstableScoreStdDev {
+			report.Unstable++
+		}
+	}
+	n := float64(len(r.samples))
+	report.MeanScore /= n
+	report.MeanStdDev /= n
+	report.MeanVariance /= n
+	return report
+}
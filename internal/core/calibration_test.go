@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+)
+
+// sampleProvider cycles through canned responses and records temperatures
+type sampleProvider struct {
+	mu           sync.Mutex
+	responses    []string
+	calls        int
+	temperatures []float64
+}
+
+func (p *sampleProvider) Name() string { return "sample" }
+
+func (p *sampleProvider) Chat(_ context.Context, _ []ChatMessage, opts ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	response := p.responses[p.calls%len(p.responses)]
+	p.calls++
+	p.temperatures = append(p.temperatures, opts.Temperature)
+	return response, nil
+}
+
+func TestSummarizeScores(t *testing.T) {
+	s := summarizeScores([]float64{0.9, 0.5, 0.7})
+	if math.Abs(s.Mean-0.7) > 1e-9 || math.Abs(s.Variance-0.08/3) > 1e-9 || math.Abs(s.StdDev-math.Sqrt(0.08/3)) > 1e-9 {
+		t.Errorf("Unexpected summary: %+v", s)
+	}
+	if s := summarizeScores(nil); s.Mean != 0 || s.StdDev != 0 {
+		t.Errorf("Expected zero summary for no scores, got %+v", s)
+	}
+}
+
+func TestCalibrationConfigFromArgs(t *testing.T) {
+	t.Setenv("CONFIDENCE_SAMPLES", "3")
+	t.Setenv("REFLEXION_CONFIDENCE_SAMPLES", "")
+
+	if c := calibrationConfigFromArgs(map[string]interface{}{}, "reflexion"); c.Samples != 3 || c.Temperature != defaultCalibrationTemperature {
+		t.Errorf("Expected global env default of 3 samples, got %+v", c)
+	}
+	t.Setenv("REFLEXION_CONFIDENCE_SAMPLES", "4")
+	if c := calibrationConfigFromArgs(map[string]interface{}{}, "reflexion"); c.Samples != 4 {
+		t.Errorf("Expected per-tool env of 4, got %+v", c)
+	}
+	c := calibrationConfigFromArgs(map[string]interface{}{"confidence_samples": float64(50), "confidence_temperature": 0.9}, "reflexion")
+	if c.Samples != maxCalibrationSamples || c.Temperature != 0.9 {
+		t.Errorf("Expected parameters to win with samples capped, got %+v", c)
+	}
+	if c := calibrationConfigFromArgs(map[string]interface{}{"confidence_samples": float64(1)}, "reflexion"); c.enabled() {
+		t.Error("Expected a single sample to disable calibration")
+	}
+}
+
+func TestDialecticVerify_SampledScores(t *testing.T) {
+	provider := &sampleProvider{responses: []string{
+		`{"is_valid": true, "score": 0.9, "issues": [], "strengths": ["clear"], "suggestion": ""}`,
+		`{"is_valid": false, "score": 0.5, "issues": ["gap"], "strengths": [], "suggestion": "fill the gap"}`,
+		`{"is_valid": true, "score": 0.7, "issues": [], "strengths": ["sound"], "suggestion": ""}`,
+	}}
+	config := DefaultDialecticConfig()
+	config.CacheVerifications = false
+	config.Calibration = CalibrationConfig{Samples: 3, Temperature: 0.8}
+	d := NewDialecticalReasoner(provider, config)
+	d.calibration = newCalibrationRecorder(config.Calibration)
+
+	v, err := d.verify(context.Background(), "Is it sound?", "It is sound.", "thesis")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider.calls != 3 || provider.temperatures[0] != 0.8 {
+		t.Fatalf("Expected 3 samples at temperature 0.8, got %d at %v", provider.calls, provider.temperatures)
+	}
+	if math.Abs(v.Score-0.7) > 1e-9 || !v.IsValid || v.Calibration == nil || len(v.Calibration.Scores) != 3 {
+		t.Errorf("Expected the mean score and majority validity, got %+v", v)
+	}
+	if len(v.Issues) != 0 {
+		t.Errorf("Expected issues from a sample agreeing with the majority, got %v", v.Issues)
+	}
+
+	report := d.calibration.report()
+	if report.Evaluations != 1 || report.SamplesPerScore != 3 || report.Unstable != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+func TestGoTEvaluate_SampledScores(t *testing.T) {
+	provider := &sampleProvider{responses: []string{
+		`{"score": 0.8, "is_solution": true, "answer": "42", "reasoning": "done"}`,
+		`{"score": 0.6, "is_solution": false, "answer": "", "reasoning": "maybe"}`,
+	}}
+	config := DefaultGoTConfig()
+	config.Calibration = CalibrationConfig{Samples: 4}
+	g := NewGraphOfThoughts(provider, config)
+	g.calibration = newCalibrationRecorder(config.Calibration)
+
+	score, isSolution, answer, err := g.evaluateThought(context.Background(), "The answer is 42", "What is 6*7?", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(score-0.7) > 1e-9 || provider.temperatures[0] != defaultCalibrationTemperature {
+		t.Errorf("Expected mean 0.7 at the default sampling temperature, got %v at %v", score, provider.temperatures)
+	}
+	if isSolution || answer != "" {
+		t.Errorf("Expected a tied vote not to count as a solution, got %v %q", isSolution, answer)
+	}
+	if r := g.calibration.report(); r.Evaluations != 1 || math.Abs(r.MaxStdDev-0.1) > 1e-9 {
+		t.Errorf("Unexpected report: %+v", r)
+	}
+}
+
+func TestReflexionEvaluate_SampledVerdicts(t *testing.T) {
+	provider := &sampleProvider{responses: []string{
+		`{"evaluation": "correct", "is_correct": true, "issues": []}`,
+		`{"evaluation": "wrong", "is_correct": false, "issues": ["off by one"]}`,
+		`{"evaluation": "correct again", "is_correct": true, "issues": []}`,
+	}}
+	config := DefaultReflexionConfig()
+	config.Calibration = CalibrationConfig{Samples: 3}
+	r := NewReflexion(provider, config)
+
+	evaluation, isCorrect, samples, _, err := r.evaluateAnswer(context.Background(), "What is 6*7?", []string{"6*7=42"}, "42")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !isCorrect || evaluation != "correct" || samples == nil || math.Abs(samples.Mean-2.0/3) > 1e-9 {
+		t.Errorf("Expected a 2/3 correct majority, got %v %q %+v", isCorrect, evaluation, samples)
+	}
+}
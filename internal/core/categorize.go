@@ -0,0 +1,97 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ============ Problem Categorization ============
+//
+// Problems are tagged with a coarse category by a keyword classifier. It
+// costs no LLM calls and is deterministic, so the same problem always lands
+// in the same bucket for memory retrieval, analytics and routing.
+
+// Problem categories
+const (
+	CategoryMath     = "math"
+	CategoryCoding   = "coding"
+	CategoryPlanning = "planning"
+	CategoryFactual  = "factual"
+	CategoryCreative = "creative"
+	CategoryGeneral  = "general" // No category signal
+)
+
+// ProblemCategories lists the categories the classifier assigns, in tie-break order
+var ProblemCategories = []string{CategoryCoding, CategoryMath, CategoryPlanning, CategoryCreative, CategoryFactual}
+
+type categorySignal struct {
+	re     *regexp.Regexp
+	weight int
+}
+
+var categorySignals = map[string][]categorySignal{
+	CategoryCoding: {
+		{regexp.MustCompile("```"), 3},
+		{regexp.MustCompile(`(?i)\b(function|algorithm|implement|code|program|script|compile|debug|bug|refactor|api|regex|sql|python|golang|javascript|typescript|rust|java|c\+\+|class|method|unit tests?|stack trace|exception)\b`), 2},
+		{regexp.MustCompile(`(?i)\b(array|string|linked list|hash ?map|binary tree|recursion|big-?o|complexity)\b`), 1},
+		{regexp.MustCompile(`\b[a-z_]+\([a-z_, ]*\)`), 1},
+	},
+	CategoryMath: {
+		{regexp.MustCompile(`\d+\s*[-+*/^×÷=]\s*\d+`), 2},
+		{regexp.MustCompile(`(?i)\b(solve|equation|integral|derivative|prove|proof|theorem|probability|expected value|prime|factor(ial)?|sum|product|dividend|divisible|modulo|matrix|vector|geometry|triangle|angle|fraction|percent(age)?|how many ways|combinations?|permutations?|calculate|compute)\b`), 2},
+		{regexp.MustCompile(`(?i)\b(number|digits?|average|ratio|square|root|logarithm|exponent)\b`), 1},
+	},
+	CategoryPlanning: {
+		{regexp.MustCompile(`(?i)\b(plan|schedule|roadmap|itinerary|milestones?|timeline|steps to|strategy for|prioriti[sz]e|migrat(e|ion)|rollout|launch|organi[sz]e|allocate|budget)\b`), 2},
+		{regexp.MustCompile(`(?i)\b(deadline|week|month|quarter|resources?|tasks?|team|project)\b`), 1},
+	},
+	CategoryCreative: {
+		{regexp.MustCompile(`(?i)\b(write|compose|poem|story|stories|lyrics|slogan|tagline|names? for|brainstorm|imagine|invent|fiction|novel|essay|haiku|joke|creative)\b`), 2},
+		{regexp.MustCompile(`(?i)\b(character|plot|tone|style|metaphor)\b`), 1},
+	},
+	CategoryFactual: {
+		{regexp.MustCompile(`(?i)^\s*(who|what|when|where|which)\b`), 2},
+		{regexp.MustCompile(`(?i)\b(capital of|history of|population|founded|invented by|discovered|define|definition of|meaning of|explain what|fact|is it true)\b`), 2},
+		{regexp.MustCompile(`(?i)\b(year|country|city|president|war|century)\b`), 1},
+	},
+}
+
+// ClassifyProblem tags a problem with its most likely category
+func ClassifyProblem(problem string) string {
+	best, bestScore := CategoryGeneral, 0
+	for _, category := range ProblemCategories {
+		score := 0
+		for _, signal := range categorySignals[category] {
+			score += len(signal.re.FindAllStringIndex(problem, 5)) * signal.weight
+		}
+		if score > bestScore {
+			best, bestScore = category, score
+		}
+	}
+	return best
+}
+
+// parseCategoryFilter validates a memory_category value: "" (no filter),
+// "auto" (the problem's own category) or a category name
+func parseCategoryFilter(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "auto" || value == CategoryGeneral {
+		return value, nil
+	}
+	for _, c := range ProblemCategories {
+		if value == c {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("unknown memory_category %q (use auto, %s or %s)", value, strings.Join(ProblemCategories, ", "), CategoryGeneral)
+}
+
+// episodeCategory returns an episode's category, classifying episodes
+// stored before categorization on the fly
+func episodeCategory(ep Episode) string {
+	if ep.Category != "" {
+		return ep.Category
+	}
+	return ClassifyProblem(ep.Problem)
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyProblem(t *testing.T) {
+	for problem, want := range map[string]string{
+		"What is 17 * 23?": CategoryMath,
+		"How many ways can you tile a 2x4 board with dominoes?":            CategoryMath,
+		"Prove that the square root of 2 is irrational":                    CategoryMath,
+		"Implement a function that reverses a linked list in Python":       CategoryCoding,
+		"Why does this code throw an exception?\n```go\nx := m[k]\n```":    CategoryCoding,
+		"Plan a three-week migration of our team's services to Kubernetes": CategoryPlanning,
+		"Create a schedule and milestones for launching the product":       CategoryPlanning,
+		"Write a haiku about autumn rain":                                  CategoryCreative,
+		"Brainstorm names for a coffee shop":                               CategoryCreative,
+		"What is the capital of Australia?":                                CategoryFactual,
+		"Who invented the telephone and in which year?":                    CategoryFactual,
+		"Hello there": CategoryGeneral,
+	} {
+		if got := ClassifyProblem(problem); got != want {
+			t.Errorf("ClassifyProblem(%q) = %s, want %s", problem, got, want)
+		}
+	}
+}
+
+func TestParseCategoryFilter(t *testing.T) {
+	for in, want := range map[string]string{"": "", "AUTO": "auto", " math ": CategoryMath, "general": CategoryGeneral} {
+		if got, err := parseCategoryFilter(in); err != nil || got != want {
+			t.Errorf("parseCategoryFilter(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := parseCategoryFilter("poetry"); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
+
+func TestReflexion_CategoryFilteredLessons(t *testing.T) {
+	config := DefaultReflexionConfig()
+	config.MemoryPath = filepath.Join(t.TempDir(), "memory.json")
+	r := NewReflexion(&countProvider{}, config)
+
+	r.storeEpisode("Compute the sum of the first 100 prime numbers", 1, nil, "a", false, "wrong", "Check the prime sieve bounds")
+	r.storeEpisode("Write a Python function for the sum of the first 100 prime numbers", 1, nil, "a", false, "wrong", "Handle n < 2 in the function")
+	// An episode stored before categorization is classified on the fly
+	r.memory.Episodes = append(r.memory.Episodes, Episode{Problem: "Calculate the product of the first 100 prime numbers", Reflection: "Use big integers"})
+
+	problem := "Calculate the sum of the first 100 prime numbers"
+	if lessons := r.getPastLessons(problem); len(lessons) != 3 {
+		t.Fatalf("Expected all similar lessons without a filter, got %v", lessons)
+	}
+
+	r.config.MemoryCategory = "auto"
+	lessons := r.getPastLessons(problem)
+	if len(lessons) != 2 || lessons[0] == "Handle n < 2 in the function" || lessons[1] == "Handle n < 2 in the function" {
+		t.Errorf("Expected only math lessons, got %v", lessons)
+	}
+
+	stats := r.GetMemoryStats()
+	if categories := stats["categories"].(map[string]int); categories[CategoryMath] != 2 || categories[CategoryCoding] != 1 {
+		t.Errorf("Unexpected category counts: %v", categories)
+	}
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ CLI Mode ============
+//
+// `reasoning-tools run <tool> --param value ...` calls one tool through the
+// same server stack as MCP clients get, prints the result to stdout and
+// exits, for shell pipelines and cron jobs. Parameters are the tool's own,
+// converted to the types in its schema.
+
+// cliUsage documents the run subcommand
+const cliUsage = `Usage: reasoning-tools [flags] run <tool> [--param value ...] [--json]
+
+Calls one tool and prints its result as markdown, or with --json as the
+tool's JSON. Array and object parameters take JSON; a value of - is read
+from stdin. "reasoning-tools run" lists the tools, and
+"reasoning-tools run <tool> --help" lists a tool's parameters.
+`
+
+// cliSubcommands run and exit instead of serving
+var cliSubcommands = map[string]bool{"run": true, "batch": true, "evaluate": true}
+
+// cliAnswerFields are printed first, in this order, when a result has them
+var cliAnswerFields = []string{"final_answer", "answer", "synthesis", "conclusion"}
+
+// runCLI runs the run subcommand on s and returns the exit code: 0 on
+// success, 1 when the tool fails and 2 on a usage error
+func runCLI(ctx context.Context, s *server.MCPServer, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stderr, cliUsage)
+		printCLITools(s, stderr)
+		return 2
+	}
+	name := args[0]
+	tool := s.GetTool(name)
+	if tool == nil {
+		fmt.Fprintf(stderr, "Unknown tool %q\n", name)
+		printCLITools(s, stderr)
+		return 2
+	}
+	params, asJSON, help, err := parseCLIArgs(tool.Tool.InputSchema.Properties, args[1:], stdin)
+	if help {
+		printCLIParams(tool.Tool.InputSchema.Properties, tool.Tool.InputSchema.Required, name, stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 2
+	}
+	for _, required := range tool.Tool.InputSchema.Required {
+		if _, ok := params[required]; !ok {
+			fmt.Fprintf(stderr, "%s: --%s is required\n", name, required)
+			return 2
+		}
+	}
+
+	result := callToolOn(ctx, s, name, params)
+	if result.IsError {
+		fmt.Fprintf(stderr, "%s: %s\n", name, result.Text)
+		return 1
+	}
+	if asJSON {
+		fmt.Fprintln(stdout, result.Text)
+		return 0
+	}
+	fmt.Fprint(stdout, formatCLIMarkdown(name, result.Text))
+	return 0
+}
+
+// parseCLIArgs turns --param value pairs into tool arguments typed by the
+// tool's schema. --json and --help are the CLI's own.
+func parseCLIArgs(properties map[string]any, args []string, stdin io.Reader) (params map[string]interface{}, asJSON, help bool, err error) {
+	params = make(map[string]interface{})
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return nil, false, false, fmt.Errorf("unexpected argument %q (parameters are --name value)", arg)
+		}
+		key, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch key {
+		case "json":
+			asJSON = true
+			continue
+		case "h", "help":
+			return nil, false, true, nil
+		}
+		key = strings.ReplaceAll(key, "-", "_")
+		schema, ok := properties[key].(map[string]any)
+		if !ok {
+			return nil, false, false, fmt.Errorf("unknown parameter --%s", key)
+		}
+		kind, _ := schema["type"].(string)
+		if !hasValue {
+			// A boolean given on its own is true
+			if kind == "boolean" && (i+1 == len(args) || strings.HasPrefix(args[i+1], "--")) {
+				params[key] = true
+				continue
+			}
+			if i+1 == len(args) {
+				return nil, false, false, fmt.Errorf("--%s needs a value", key)
+			}
+			i++
+			value = args[i]
+		}
+		if value == "-" {
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, false, false, fmt.Errorf("--%s: failed to read stdin: %w", key, err)
+			}
+			value = strings.TrimSpace(string(data))
+		}
+		if params[key], err = cliValue(kind, value); err != nil {
+			return nil, false, false, fmt.Errorf("--%s: %w", key, err)
+		}
+	}
+	return params, asJSON, false, nil
+}
+
+// cliValue converts a command-line value to a schema type
+func cliValue(kind, value string) (interface{}, error) {
+	switch kind {
+	case "number", "integer":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", value)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", value)
+		}
+		return b, nil
+	case "array", "object":
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("expected JSON: %v", err)
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// printCLITools lists the registered tools
+func printCLITools(s *server.MCPServer, w io.Writer) {
+	tools := s.ListTools()
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "\nTools:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-26s %s\n", name, firstLine(tools[name].Tool.Description))
+	}
+}
+
+// printCLIParams lists a tool's parameters
+func printCLIParams(properties map[string]any, required []string, name string, w io.Writer) {
+	names := make([]string, 0, len(properties))
+	for param := range properties {
+		names = append(names, param)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "Usage: reasoning-tools run %s [--param value ...] [--json]\n\nParameters:\n", name)
+	for _, param := range names {
+		schema, _ := properties[param].(map[string]any)
+		kind, _ := schema["type"].(string)
+		description, _ := schema["description"].(string)
+		marker := ""
+		for _, r := range required {
+			if r == param {
+				marker = " (required)"
+			}
+		}
+		fmt.Fprintf(w, "  --%s %s%s\n      %s\n", param, kind, marker, firstLine(description))
+	}
+}
+
+// firstLine is the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// formatCLIMarkdown renders a tool's JSON result as markdown: the answer
+// first, then the other scalar fields as a list and lists and objects as
+// sections. Text that is not a JSON object is printed as is.
+func formatCLIMarkdown(tool, text string) string {
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return strings.TrimRight(text, "\n") + "\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", tool)
+	for _, field := range cliAnswerFields {
+		if answer, ok := result[field].(string); ok && answer != "" {
+			fmt.Fprintf(&b, "## Answer\n\n%s\n\n", answer)
+			delete(result, field)
+			break
+		}
+	}
+
+	keys := make([]string, 0, len(result))
+	for key := range result {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var sections []string
+	for _, key := range keys {
+		switch v := result[key].(type) {
+		case nil:
+		case []interface{}, map[string]interface{}:
+			sections = append(sections, key)
+		case string:
+			if strings.Contains(v, "\n") {
+				sections = append(sections, key)
+			} else if v != "" {
+				fmt.Fprintf(&b, "- **%s**: %s\n", key, v)
+			}
+		default:
+			fmt.Fprintf(&b, "- **%s**: %v\n", key, v)
+		}
+	}
+	for _, key := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", cliTitle(key))
+		switch v := result[key].(type) {
+		case string:
+			fmt.Fprintf(&b, "%s\n", v)
+		case []interface{}:
+			for i, item := range v {
+				fmt.Fprintf(&b, "%d. %s\n", i+1, cliItem(item))
+			}
+		default:
+			data, _ := json.MarshalIndent(v, "", "  ")
+			fmt.Fprintf(&b, "```json\n%s\n```\n", data)
+		}
+	}
+	return b.String()
+}
+
+// cliTextFields name the field that best describes a list item
+var cliTextFields = []string{"thought", "content", "text", "description", "question", "claim", "step", "lesson", "answer", "synthesis"}
+
+// cliItem renders one list item on a line
+func cliItem(item interface{}) string {
+	switch v := item.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		for _, field := range cliTextFields {
+			text, ok := v[field].(string)
+			if part, isPart := v[field].(map[string]interface{}); isPart {
+				text, ok = part["content"].(string) // e.g. a dialectic round's synthesis
+			}
+			if ok && text != "" {
+				return strings.Join(strings.Fields(text), " ")
+			}
+		}
+	}
+	data, _ := json.Marshal(item)
+	return string(data)
+}
+
+// cliTitle turns a field name into a section title
+func cliTitle(key string) string {
+	title := strings.ReplaceAll(key, "_", " ")
+	return strings.ToUpper(title[:1]) + title[1:]
+}
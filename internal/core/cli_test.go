@@ -0,0 +1,79 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseCLIArgs(t *testing.T) {
+	properties := map[string]any{
+		"problem":        map[string]any{"type": "string"},
+		"max_depth":      map[string]any{"type": "number"},
+		"enable_tools":   map[string]any{"type": "boolean"},
+		"enable_merging": map[string]any{"type": "boolean"},
+		"stages":         map[string]any{"type": "array"},
+	}
+	args := []string{"--problem", "-", "--max-depth=4", "--enable_tools", "--enable_merging", "false", "--stages", `["a","b"]`, "--json"}
+	params, asJSON, _, err := parseCLIArgs(properties, args, strings.NewReader("What is 2 + 2?\n"))
+	if err != nil || !asJSON {
+		t.Fatalf("Unexpected result: %v, %v", asJSON, err)
+	}
+	want := map[string]interface{}{
+		"problem":        "What is 2 + 2?",
+		"max_depth":      4.0,
+		"enable_tools":   true,
+		"enable_merging": false,
+		"stages":         []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("Expected %v, got %v", want, params)
+	}
+
+	for _, bad := range [][]string{{"--unknown", "x"}, {"--max_depth", "deep"}, {"--problem"}, {"stray"}} {
+		if _, _, _, err := parseCLIArgs(properties, bad, nil); err == nil {
+			t.Errorf("%v: expected an error", bad)
+		}
+	}
+}
+
+func TestRunCLI(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	s := integrationServer(t)
+	// The CLI types arguments by the schema, which integrationServer leaves out
+	s.AddTool(mcp.NewTool("sequential_thinking",
+		mcp.WithString("problem", mcp.Required()),
+		mcp.WithString("provider"),
+	), handleSequentialThink)
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runCLI(context.Background(), s, args, strings.NewReader(""), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, _ := run("sequential_thinking", "--problem", "What is 2 + 2?", "--provider", "mock")
+	if code != 0 || !strings.HasPrefix(out, "# sequential_thinking\n\n## Answer\n\nmock answer\n") || !strings.Contains(out, "1. Mock reasoning about the problem.") {
+		t.Errorf("Unexpected markdown (exit %d):\n%s", code, out)
+	}
+
+	code, out, _ = run("sequential_thinking", "--problem", "What is 2 + 2?", "--provider", "mock", "--json")
+	var result map[string]interface{}
+	if code != 0 || json.Unmarshal([]byte(out), &result) != nil || result["final_answer"] != "mock answer" {
+		t.Errorf("Unexpected JSON (exit %d): %s", code, out)
+	}
+
+	if code, _, errOut := run("sequential_thinking", "--provider", "mock"); code != 2 || !strings.Contains(errOut, "--problem is required") {
+		t.Errorf("Expected a usage error, got %d: %s", code, errOut)
+	}
+	if code, _, errOut := run("no_such_tool"); code != 2 || !strings.Contains(errOut, "sequential_thinking") {
+		t.Errorf("Expected the tool list, got %d: %s", code, errOut)
+	}
+	if code, _, _ := run("sequential_thinking", "--problem", "x", "--provider", "nonexistent"); code != 1 {
+		t.Errorf("Expected exit 1 for a failed tool call, got %d", code)
+	}
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Code execution backends selected by CODE_EXEC_BACKEND
+const (
+	CodeExecBackendLocal  = "local"
+	CodeExecBackendDocker = "docker"
+	CodeExecBackendWASM   = "wasm"
+)
+
+// CodeExecBackend runs a Python snippet and returns its stdout and stderr
+type CodeExecBackend interface {
+	Name() string
+	// Sandboxed reports whether the backend isolates code from the host. Only
+	// unsandboxed backends apply the pattern-based validator.
+	Sandboxed() bool
+	Run(ctx context.Context, code string) (stdout, stderr string, err error)
+}
+
+// codeExecBackendFromEnv builds the backend named by CODE_EXEC_BACKEND (default: local)
+func codeExecBackendFromEnv() (CodeExecBackend, error) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("CODE_EXEC_BACKEND"))) {
+	case "", CodeExecBackendLocal:
+		return &localPythonBackend{}, nil
+	case CodeExecBackendDocker:
+		return &dockerPythonBackend{
+			image:    withDefault(os.Getenv("CODE_EXEC_DOCKER_IMAGE"), "python:3.12-alpine"),
+			memoryMB: parseEnvInt("CODE_EXEC_MEMORY_MB", 256),
+			cpus:     withDefault(os.Getenv("CODE_EXEC_CPUS"), "0.5"),
+		}, nil
+	case CodeExecBackendWASM:
+		module := os.Getenv("CODE_EXEC_WASM_MODULE")
+		if module == "" {
+			return nil, fmt.Errorf("CODE_EXEC_WASM_MODULE must point to a WASI python.wasm build")
+		}
+		return &wasmPythonBackend{
+			runtime:  withDefault(os.Getenv("CODE_EXEC_WASM_RUNTIME"), "wasmtime"),
+			module:   module,
+			libDir:   os.Getenv("CODE_EXEC_WASM_DIR"),
+			memoryMB: parseEnvInt("CODE_EXEC_MEMORY_MB", 256),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown CODE_EXEC_BACKEND %q (expected local, docker or wasm)", os.Getenv("CODE_EXEC_BACKEND"))
+	}
+}
+
+// runCommand runs cmd, capturing stdout and stderr
+func runCommand(cmd *exec.Cmd) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// ============ Local ============
+
+// localPythonBackend runs code with the host interpreter (no isolation)
+type localPythonBackend struct{}
+
+func (b *localPythonBackend) Name() string    { return CodeExecBackendLocal }
+func (b *localPythonBackend) Sandboxed() bool { return false }
+
+func (b *localPythonBackend) Run(ctx context.Context, code string) (string, string, error) {
+	// Try Python3 first, then Python (Python 3 is the modern standard)
+	pythonCmd := "python3"
+	if _, err := exec.LookPath("python3"); err != nil {
+		pythonCmd = "python"
+	}
+	return runCommand(exec.CommandContext(ctx, pythonCmd, "-c", code))
+}
+
+// ============ Docker ============
+
+// dockerPythonBackend runs code in a throwaway container with no network,
+// limited memory/CPU/pids, a read-only root filesystem and no capabilities
+type dockerPythonBackend struct {
+	image    string
+	memoryMB int
+	cpus     string
+}
+
+func (b *dockerPythonBackend) Name() string    { return CodeExecBackendDocker }
+func (b *dockerPythonBackend) Sandboxed() bool { return true }
+
+func (b *dockerPythonBackend) args(name string) []string {
+	return []string{
+		"run", "--rm", "-i",
+		"--name", name,
+		"--network", "none",
+		"--memory", fmt.Sprintf("%dm", b.memoryMB),
+		"--memory-swap", fmt.Sprintf("%dm", b.memoryMB),
+		"--cpus", b.cpus,
+		"--pids-limit", "64",
+		"--read-only",
+		"--tmpfs", "/tmp:rw,noexec,nosuid,size=16m",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--user", "65534:65534",
+		b.image,
+		"python3", "-",
+	}
+}
+
+func (b *dockerPythonBackend) Run(ctx context.Context, code string) (string, string, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", "", fmt.Errorf("failed to name container: %w", err)
+	}
+	name := "reasoning-code-exec-" + hex.EncodeToString(suffix)
+
+	// Code goes over stdin so it never appears in the host process list
+	cmd := exec.CommandContext(ctx, "docker", b.args(name)...)
+	cmd.Stdin = strings.NewReader(code)
+	stdout, stderr, err := runCommand(cmd)
+
+	if ctx.Err() != nil {
+		// Killing the CLI does not stop the container; remove it explicitly
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if rmErr := exec.CommandContext(cleanupCtx, "docker", "rm", "-f", name).Run(); rmErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove code_exec container %s: %v\n", name, rmErr)
+		}
+	}
+	return stdout, stderr, err
+}
+
+// ============ WASM ============
+
+// wasmPythonBackend runs code in a WASI CPython build under a WebAssembly
+// runtime. WASI provides no network or subprocess access and only sees the
+// preopened stdlib directory.
+type wasmPythonBackend struct {
+	runtime  string
+	module   string
+	libDir   string // Host directory exposing the Python stdlib, mapped to /
+	memoryMB int
+}
+
+func (b *wasmPythonBackend) Name() string    { return CodeExecBackendWASM }
+func (b *wasmPythonBackend) Sandboxed() bool { return true }
+
+func (b *wasmPythonBackend) args(code string) []string {
+	args := []string{"run", "-W", "max-memory-size=" + strconv.Itoa(b.memoryMB*1024*1024)}
+	if b.libDir != "" {
+		args = append(args, "--dir", b.libDir+"::/")
+	}
+	return append(args, b.module, "-c", code)
+}
+
+func (b *wasmPythonBackend) Run(ctx context.Context, code string) (string, string, error) {
+	return runCommand(exec.CommandContext(ctx, b.runtime, b.args(code)...))
+}
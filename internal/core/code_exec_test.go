@@ -0,0 +1,77 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodeExecBackendFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantName  string
+		sandboxed bool
+		wantErr   bool
+	}{
+		{"default", map[string]string{}, CodeExecBackendLocal, false, false},
+		{"docker", map[string]string{"CODE_EXEC_BACKEND": "Docker"}, CodeExecBackendDocker, true, false},
+		{"wasm", map[string]string{"CODE_EXEC_BACKEND": "wasm", "CODE_EXEC_WASM_MODULE": "/opt/python.wasm"}, CodeExecBackendWASM, true, false},
+		{"wasm without module", map[string]string{"CODE_EXEC_BACKEND": "wasm"}, "", false, true},
+		{"unknown", map[string]string{"CODE_EXEC_BACKEND": "chroot"}, "", false, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"CODE_EXEC_BACKEND", "CODE_EXEC_WASM_MODULE"} {
+				t.Setenv(key, tc.env[key])
+			}
+			backend, err := codeExecBackendFromEnv()
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got backend %s", backend.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if backend.Name() != tc.wantName || backend.Sandboxed() != tc.sandboxed {
+				t.Errorf("Got backend %s (sandboxed=%v), want %s (sandboxed=%v)",
+					backend.Name(), backend.Sandboxed(), tc.wantName, tc.sandboxed)
+			}
+		})
+	}
+}
+
+func TestDockerBackendArgs(t *testing.T) {
+	t.Setenv("CODE_EXEC_BACKEND", "docker")
+	t.Setenv("CODE_EXEC_MEMORY_MB", "128")
+	t.Setenv("CODE_EXEC_DOCKER_IMAGE", "")
+	backend, err := codeExecBackendFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	args := strings.Join(backend.(*dockerPythonBackend).args("c1"), " ")
+	for _, want := range []string{
+		"--network none",
+		"--memory 128m",
+		"--read-only",
+		"--cap-drop ALL",
+		"--name c1",
+		"python:3.12-alpine python3 -",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected docker args to contain %q, got %s", want, args)
+		}
+	}
+}
+
+func TestWASMBackendArgs(t *testing.T) {
+	b := &wasmPythonBackend{runtime: "wasmtime", module: "python.wasm", libDir: "/opt/lib", memoryMB: 64}
+	got := b.args("print(1)")
+	want := []string{"run", "-W", "max-memory-size=67108864", "--dir", "/opt/lib::/", "python.wasm", "-c", "print(1)"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("args = %v, want %v", got, want)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package core
 
 import (
 	"context"
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -29,8 +30,26 @@ type Config struct {
 	// Concurrency control
 	MaxConcurrentLLMRequests int // Maximum concurrent LLM API requests (0 = unlimited)
 
+	// Adaptive concurrency: per-provider AIMD limits replace the static limit,
+	// starting from MaxConcurrentLLMRequests
+	AdaptiveConcurrency   bool
+	AdaptiveMinConcurrent int
+	AdaptiveMaxConcurrent int
+	AdaptiveTargetLatency time.Duration // Smoothed latency above which limits back off
+
 	// LLM request limits
 	MaxTokensCap int // Max tokens allowed in a single LLM request (0 = default cap)
+
+	// Retry policy for provider requests, with per-provider overrides keyed
+	// by environment prefix (OPENAI, GROQ, ...)
+	Retry         retryConfig
+	ProviderRetry map[string]retryConfig
+
+	// Circuit breaker for providers in a fallback chain: open after
+	// BreakerThreshold consecutive failures (0 = never), probe again after
+	// BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 // Validation bounds for timeout values
@@ -47,8 +66,19 @@ const (
 	maxConcurrentLLMRequests        = 20 // Hard cap to prevent abuse
 
 	defaultMaxTokensCap = 8192
+
+	// Retry bounds
+	maxRetryAttempts          = 10
+	maxRetryRateLimitAttempts = 20
+	maxRetryDelay             = 5 * time.Minute
+
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = 30 * time.Second
 )
 
+// retryProviderPrefixes are the providers that accept <PREFIX>_RETRY_* overrides
+var retryProviderPrefixes = []string{"OPENAI", "ANTHROPIC", "GROQ", "OLLAMA", "DEEPSEEK", "OPENROUTER", "ZAI", "TOGETHER"}
+
 // DefaultConfig returns default configuration values
 func DefaultConfig() *Config {
 	return &Config{
@@ -63,7 +93,13 @@ func DefaultConfig() *Config {
 		CodeExecTimeout:          10 * time.Second,
 		WebFetchTimeout:          15 * time.Second,
 		MaxConcurrentLLMRequests: defaultMaxConcurrentLLMRequests,
+		AdaptiveMinConcurrent:    defaultAdaptiveMinConcurrent,
+		AdaptiveMaxConcurrent:    maxConcurrentLLMRequests,
+		AdaptiveTargetLatency:    defaultAdaptiveTargetLatency,
 		MaxTokensCap:             defaultMaxTokensCap,
+		Retry:                    defaultRetryConfig(),
+		BreakerThreshold:         defaultBreakerThreshold,
+		BreakerCooldown:          defaultBreakerCooldown,
 	}
 }
 
@@ -202,6 +238,33 @@ func LoadConfig() *Config {
 		}
 	}
 
+	// Adaptive concurrency
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_ADAPTIVE_CONCURRENCY"))); v == "true" || v == "1" {
+		cfg.AdaptiveConcurrency = true
+	}
+	if v := os.Getenv("LLM_ADAPTIVE_MIN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AdaptiveMinConcurrent = n
+		}
+	}
+	if v := os.Getenv("LLM_ADAPTIVE_MAX"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			if n > maxConcurrentLLMRequests {
+				log.Printf("[CONFIG] LLM_ADAPTIVE_MAX (%d) exceeds maximum (%d), clamping", n, maxConcurrentLLMRequests)
+				n = maxConcurrentLLMRequests
+			}
+			cfg.AdaptiveMaxConcurrent = n
+		}
+	}
+	if cfg.AdaptiveMinConcurrent > cfg.AdaptiveMaxConcurrent {
+		cfg.AdaptiveMinConcurrent = cfg.AdaptiveMaxConcurrent
+	}
+	if v := os.Getenv("LLM_ADAPTIVE_TARGET_LATENCY"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil && s > 0 {
+			cfg.AdaptiveTargetLatency = clampDuration("LLM_ADAPTIVE_TARGET_LATENCY", time.Duration(s)*time.Second, minTimeout, maxTimeout)
+		}
+	}
+
 	// Max tokens cap (per request)
 	if v := os.Getenv("LLM_MAX_TOKENS_CAP"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
@@ -218,9 +281,86 @@ func LoadConfig() *Config {
 		}
 	}
 
+	// Retry policy: LLM_RETRY_* for every provider, then <PROVIDER>_RETRY_*
+	cfg.Retry = loadRetryConfig("LLM_RETRY_", cfg.Retry)
+	for _, prefix := range retryProviderPrefixes {
+		if retry := loadRetryConfig(prefix+"_RETRY_", cfg.Retry); retry != cfg.Retry {
+			if cfg.ProviderRetry == nil {
+				cfg.ProviderRetry = make(map[string]retryConfig)
+			}
+			cfg.ProviderRetry[prefix] = retry
+		}
+	}
+
+	// Provider circuit breaker
+	if v := os.Getenv("PROVIDER_BREAKER_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.BreakerThreshold = n
+		}
+	}
+	if v := os.Getenv("PROVIDER_BREAKER_COOLDOWN"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil && s > 0 {
+			cfg.BreakerCooldown = clampDuration("PROVIDER_BREAKER_COOLDOWN", time.Duration(s)*time.Second, minTimeout, maxTimeout)
+		}
+	}
+
 	return cfg
 }
 
+// loadRetryConfig overrides base with the retry settings under an
+// environment prefix: MAX_ATTEMPTS, BASE_DELAY_MS, MAX_DELAY_MS,
+// RATE_LIMIT_ATTEMPTS and JITTER (a fraction of each delay, 0-1)
+func loadRetryConfig(prefix string, base retryConfig) retryConfig {
+	cfg := base
+	if v := os.Getenv(prefix + "MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			if n > maxRetryAttempts {
+				log.Printf("[CONFIG] %sMAX_ATTEMPTS (%d) exceeds maximum (%d), clamping", prefix, n, maxRetryAttempts)
+				n = maxRetryAttempts
+			}
+			cfg.maxAttempts = n
+		}
+	}
+	if v := os.Getenv(prefix + "RATE_LIMIT_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			if n > maxRetryRateLimitAttempts {
+				log.Printf("[CONFIG] %sRATE_LIMIT_ATTEMPTS (%d) exceeds maximum (%d), clamping", prefix, n, maxRetryRateLimitAttempts)
+				n = maxRetryRateLimitAttempts
+			}
+			cfg.rateLimitAttempts = n
+		}
+	}
+	if v := os.Getenv(prefix + "BASE_DELAY_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.baseDelay = min(time.Duration(ms)*time.Millisecond, maxRetryDelay)
+		}
+	}
+	if v := os.Getenv(prefix + "MAX_DELAY_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+			cfg.maxDelay = min(time.Duration(ms)*time.Millisecond, maxRetryDelay)
+		}
+	}
+	if cfg.maxDelay < cfg.baseDelay {
+		log.Printf("[CONFIG] %sMAX_DELAY_MS is below the base delay, raising it to %v", prefix, cfg.baseDelay)
+		cfg.maxDelay = cfg.baseDelay
+	}
+	if v := os.Getenv(prefix + "JITTER"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			cfg.jitter = min(f, 1)
+		}
+	}
+	return cfg
+}
+
+// retryConfigFor returns the retry policy of a provider
+func retryConfigFor(providerName string) retryConfig {
+	cfg := GetConfig()
+	if retry, ok := cfg.ProviderRetry[providerEnvPrefix(providerName)]; ok {
+		return retry
+	}
+	return cfg.Retry
+}
+
 // Global config instance (initialized lazily)
 var (
 	globalConfig *Config
@@ -274,6 +414,8 @@ func ResetConfig() {
 		llmLimiter = nil
 	}
 	llmLimiterLock.Unlock()
+	resetAdaptiveLimiters()
+	resetProviderBreakers()
 }
 
 // ============ LLM Request Rate Limiting (FIFO Queue) ============
@@ -372,6 +514,21 @@ func getLLMLimiter() *FIFOLimiter {
 	return llmLimiter
 }
 
+// setLLMMaxConcurrent changes the LLM request limit at runtime (0 =
+// unlimited). Requests already waiting on the old limiter are still served
+// by it; it then sits idle.
+func setLLMMaxConcurrent(n int) {
+	cfg := *GetConfig()
+	cfg.MaxConcurrentLLMRequests = min(max(n, 0), maxConcurrentLLMRequests)
+	configLock.Lock()
+	globalConfig = &cfg
+	configLock.Unlock()
+
+	llmLimiterLock.Lock()
+	llmLimiter = nil
+	llmLimiterLock.Unlock()
+}
+
 // AcquireLLMSlot acquires a slot for making an LLM request in FIFO order.
 // Blocks until a slot is available or context is cancelled.
 // Returns a release function that MUST be called when done, or an error if context was cancelled.
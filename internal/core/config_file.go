@@ -0,0 +1,441 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============ Configuration File ============
+//
+// A config file (-config reasoning.yaml) collects the environment knobs in
+// one place. Each setting maps onto the environment variable the code
+// already reads; a variable that is set in the environment wins over the
+// file, so the file only fills gaps.
+
+// configValue is a scalar or a list of scalars; lists become comma-separated
+type configValue string
+
+func (v *configValue) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*v = configValue(node.Value)
+	case yaml.SequenceNode:
+		var parts []string
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: list items must be scalars", item.Line)
+			}
+			parts = append(parts, item.Value)
+		}
+		*v = configValue(strings.Join(parts, ","))
+	default:
+		return fmt.Errorf("line %d: expected a value or a list", node.Line)
+	}
+	return nil
+}
+
+// RetryFileConfig is the retry policy of provider requests
+type RetryFileConfig struct {
+	MaxAttempts       configValue `yaml:"max_attempts"`
+	BaseDelayMs       configValue `yaml:"base_delay_ms"`
+	MaxDelayMs        configValue `yaml:"max_delay_ms"`
+	RateLimitAttempts configValue `yaml:"rate_limit_attempts"` // Extra attempts after a 429
+	Jitter            configValue `yaml:"jitter"`              // Fraction of each delay, 0-1
+}
+
+// env sets the retry variables under prefix
+func (r RetryFileConfig) env(prefix string, set func(string, configValue)) {
+	set(prefix+"MAX_ATTEMPTS", r.MaxAttempts)
+	set(prefix+"BASE_DELAY_MS", r.BaseDelayMs)
+	set(prefix+"MAX_DELAY_MS", r.MaxDelayMs)
+	set(prefix+"RATE_LIMIT_ATTEMPTS", r.RateLimitAttempts)
+	set(prefix+"JITTER", r.Jitter)
+}
+
+// ProviderFileConfig configures one LLM provider
+type ProviderFileConfig struct {
+	APIKey  configValue     `yaml:"api_key"`
+	BaseURL configValue     `yaml:"base_url"`
+	Model   configValue     `yaml:"model"`   // Default model
+	Timeout configValue     `yaml:"timeout"` // Seconds
+	Retry   RetryFileConfig `yaml:"retry"`   // Overrides the top-level retry policy
+}
+
+// ToolFileConfig configures one MCP tool
+type ToolFileConfig struct {
+	Enabled   configValue `yaml:"enabled"`
+	Provider  configValue `yaml:"provider"`
+	Model     configValue `yaml:"model"`
+	Fallbacks configValue `yaml:"fallbacks"`
+	Timeout   configValue `yaml:"timeout"` // Seconds; built-in tools only (code_exec, web_fetch)
+}
+
+// FileConfig is the layout of a config file
+type FileConfig struct {
+	Server struct {
+		Transport configValue `yaml:"transport"`
+		Port      configValue `yaml:"port"`
+		BaseURL   configValue `yaml:"base_url"`
+		HTTPPath  configValue `yaml:"http_path"`
+		AuthToken configValue `yaml:"auth_token"`
+		AuthFile  configValue `yaml:"auth_token_file"`
+		CORS      configValue `yaml:"cors_origins"`
+	} `yaml:"server"`
+	Provider  configValue                   `yaml:"provider"`  // Default provider
+	Fallbacks configValue                   `yaml:"fallbacks"` // Default fallback chain
+	Providers map[string]ProviderFileConfig `yaml:"providers"`
+	Retry     RetryFileConfig               `yaml:"retry"`
+	Tools     map[string]ToolFileConfig     `yaml:"tools"`
+	Limits    struct {
+		MaxConcurrent     configValue `yaml:"max_concurrent"`
+		Adaptive          configValue `yaml:"adaptive"`
+		AdaptiveMin       configValue `yaml:"adaptive_min"`
+		AdaptiveMax       configValue `yaml:"adaptive_max"`
+		AdaptiveTarget    configValue `yaml:"adaptive_target_latency"` // Seconds
+		MaxTokensCap      configValue `yaml:"max_tokens_cap"`
+		MaxLLMCalls       configValue `yaml:"max_llm_calls"`
+		BreakerThreshold  configValue `yaml:"breaker_threshold"`
+		BreakerCooldown   configValue `yaml:"breaker_cooldown"` // Seconds
+		EvaluatorProvider configValue `yaml:"evaluator_provider"`
+		EvaluatorModel    configValue `yaml:"evaluator_model"`
+	} `yaml:"limits"`
+	Cache struct {
+		TTL               configValue `yaml:"ttl"` // Seconds; 0 disables the tool cache
+		Max               configValue `yaml:"max"`
+		Disk              configValue `yaml:"disk"`
+		Dir               configValue `yaml:"dir"`
+		DiskMaxMB         configValue `yaml:"disk_max_mb"`
+		Semantic          configValue `yaml:"semantic"`
+		SemanticThreshold configValue `yaml:"semantic_threshold"`
+		SemanticTTL       configValue `yaml:"semantic_ttl"`
+		SemanticMax       configValue `yaml:"semantic_max"`
+	} `yaml:"cache"`
+	Memory struct {
+		Path           configValue `yaml:"path"` // Reflexion episodic memory file
+		SeedLessons    configValue `yaml:"seed_lessons"`
+		GoTRunsDir     configValue `yaml:"got_runs_dir"`
+		GoTPersistRuns configValue `yaml:"got_persist_runs"`
+		RunHistory     configValue `yaml:"run_history"`
+		RunHistoryPath configValue `yaml:"run_history_path"`
+	} `yaml:"memory"`
+	Profiles map[string]Profile     `yaml:"profiles"`
+	Env      map[string]configValue `yaml:"env"` // Any other environment variable
+}
+
+// builtinToolEnv maps built-in tool settings to their environment variables
+var builtinToolEnv = map[string]map[string]string{
+	"code_exec": {"enabled": "CODE_EXEC_ENABLED", "timeout": "CODE_EXEC_TIMEOUT"},
+	"web_fetch": {"timeout": "WEB_FETCH_TIMEOUT"},
+}
+
+// loadConfigFile reads a YAML, JSON or TOML config file, chosen by extension
+func loadConfigFile(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		tree, err := parseTOML(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = yaml.Marshal(tree); err != nil {
+			return nil, err
+		}
+	}
+
+	var cfg FileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// env flattens the config into environment variables
+func (c *FileConfig) env() (map[string]string, error) {
+	env := make(map[string]string)
+	set := func(key string, value configValue) {
+		if value != "" {
+			env[key] = string(value)
+		}
+	}
+
+	set("MCP_TRANSPORT", c.Server.Transport)
+	set("MCP_PORT", c.Server.Port)
+	set("MCP_BASE_URL", c.Server.BaseURL)
+	set("MCP_HTTP_PATH", c.Server.HTTPPath)
+	set("MCP_AUTH_TOKEN", c.Server.AuthToken)
+	set("MCP_AUTH_TOKEN_FILE", c.Server.AuthFile)
+	set("MCP_CORS_ORIGINS", c.Server.CORS)
+	set("LLM_PROVIDER", c.Provider)
+	set("LLM_FALLBACKS", c.Fallbacks)
+
+	for name, p := range c.Providers {
+		prefix := providerEnvPrefix(name)
+		if prefix == "" {
+			return nil, fmt.Errorf("providers: unknown provider %q", name)
+		}
+		set(prefix+"_API_KEY", p.APIKey)
+		set(prefix+"_BASE_URL", p.BaseURL)
+		set(prefix+"_MODEL", p.Model)
+		set(prefix+"_TIMEOUT", p.Timeout)
+		p.Retry.env(prefix+"_RETRY_", set)
+	}
+	c.Retry.env("LLM_RETRY_", set)
+
+	var disabled []string
+	for name, t := range c.Tools {
+		if builtin, ok := builtinToolEnv[name]; ok {
+			if t.Provider != "" || t.Model != "" || t.Fallbacks != "" {
+				return nil, fmt.Errorf("tools.%s: built-in tools take only enabled and timeout", name)
+			}
+			for field, value := range map[string]configValue{"enabled": t.Enabled, "timeout": t.Timeout} {
+				if value == "" {
+					continue
+				}
+				key, ok := builtin[field]
+				if !ok {
+					return nil, fmt.Errorf("tools.%s: %s is not configurable", name, field)
+				}
+				set(key, value)
+			}
+			continue
+		}
+		if t.Timeout != "" {
+			return nil, fmt.Errorf("tools.%s: timeout is only supported for code_exec and web_fetch", name)
+		}
+		if t.Enabled != "" {
+			enabled, err := strconv.ParseBool(string(t.Enabled))
+			if err != nil {
+				return nil, fmt.Errorf("tools.%s.enabled: %v", name, err)
+			}
+			if !enabled {
+				disabled = append(disabled, name)
+			}
+		}
+		set(toolEnvKey(name, "PROVIDER"), t.Provider)
+		set(toolEnvKey(name, "MODEL"), t.Model)
+		set(toolEnvKey(name, "FALLBACKS"), t.Fallbacks)
+	}
+	sort.Strings(disabled)
+	set("DISABLED_TOOLS", configValue(strings.Join(disabled, ",")))
+
+	set("LLM_MAX_CONCURRENT", c.Limits.MaxConcurrent)
+	set("LLM_ADAPTIVE_CONCURRENCY", c.Limits.Adaptive)
+	set("LLM_ADAPTIVE_MIN", c.Limits.AdaptiveMin)
+	set("LLM_ADAPTIVE_MAX", c.Limits.AdaptiveMax)
+	set("LLM_ADAPTIVE_TARGET_LATENCY", c.Limits.AdaptiveTarget)
+	set("LLM_MAX_TOKENS_CAP", c.Limits.MaxTokensCap)
+	set("MAX_LLM_CALLS", c.Limits.MaxLLMCalls)
+	set("PROVIDER_BREAKER_THRESHOLD", c.Limits.BreakerThreshold)
+	set("PROVIDER_BREAKER_COOLDOWN", c.Limits.BreakerCooldown)
+	set("EVALUATOR_PROVIDER", c.Limits.EvaluatorProvider)
+	set("EVALUATOR_MODEL", c.Limits.EvaluatorModel)
+
+	set("TOOL_CACHE_TTL", c.Cache.TTL)
+	set("TOOL_CACHE_MAX", c.Cache.Max)
+	set("TOOL_CACHE_DISK", c.Cache.Disk)
+	set("TOOL_CACHE_DIR", c.Cache.Dir)
+	set("TOOL_CACHE_DISK_MAX_MB", c.Cache.DiskMaxMB)
+	set("SEMANTIC_CACHE", c.Cache.Semantic)
+	set("SEMANTIC_CACHE_THRESHOLD", c.Cache.SemanticThreshold)
+	set("SEMANTIC_CACHE_TTL", c.Cache.SemanticTTL)
+	set("SEMANTIC_CACHE_MAX", c.Cache.SemanticMax)
+
+	set("REFLEXION_MEMORY_PATH", c.Memory.Path)
+	set("REFLEXION_SEED_LESSONS", c.Memory.SeedLessons)
+	set("GOT_RUNS_DIR", c.Memory.GoTRunsDir)
+	set("GOT_PERSIST_RUNS", c.Memory.GoTPersistRuns)
+	set("RUN_HISTORY", c.Memory.RunHistory)
+	set("RUN_HISTORY_PATH", c.Memory.RunHistoryPath)
+
+	if len(c.Profiles) > 0 {
+		data, err := json.Marshal(c.Profiles)
+		if err != nil {
+			return nil, fmt.Errorf("profiles: %w", err)
+		}
+		set("REASONING_PROFILES", configValue(data))
+	}
+
+	for key, value := range c.Env {
+		set(strings.ToUpper(key), value)
+	}
+	return env, nil
+}
+
+// applyConfigFile loads path and exports its settings to the environment,
+// leaving variables that are already set alone. It returns how many
+// settings were applied.
+func applyConfigFile(path string) (int, error) {
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	env, err := cfg.env()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	applied := 0
+	for key, value := range env {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	ResetConfig()
+	return applied, nil
+}
+
+// disabledTools returns the tools listed in DISABLED_TOOLS
+func disabledTools() []string {
+	return seedLessonPaths(os.Getenv("DISABLED_TOOLS"))
+}
+
+// parseTOML parses the TOML subset config files need: [table] and
+// [table.sub] headers and key = value pairs with strings, numbers, booleans
+// and arrays of those
+func parseTOML(data []byte) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") || strings.HasPrefix(text, "[[") {
+				return nil, fmt.Errorf("line %d: unsupported table header %q", line, text)
+			}
+			table = root
+			for _, part := range strings.Split(strings.Trim(text, "[]"), ".") {
+				key := unquoteTOMLKey(part)
+				next, ok := table[key].(map[string]interface{})
+				if !ok {
+					if _, exists := table[key]; exists {
+						return nil, fmt.Errorf("line %d: %s is not a table", line, key)
+					}
+					next = make(map[string]interface{})
+					table[key] = next
+				}
+				table = next
+			}
+			continue
+		}
+
+		key, raw, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		table[unquoteTOMLKey(key)] = value
+	}
+	return root, scanner.Err()
+}
+
+func unquoteTOMLKey(key string) string {
+	key = strings.TrimSpace(key)
+	if s, err := strconv.Unquote(key); err == nil {
+		return s
+	}
+	return strings.Trim(key, "'")
+}
+
+// stripTOMLComment drops a # comment that is not inside a string
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseTOMLValue(raw string) (interface{}, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return nil, fmt.Errorf("unterminated string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return nil, fmt.Errorf("arrays must be on one line")
+		}
+		var items []interface{}
+		for _, part := range splitTOMLArray(raw[1 : len(raw)-1]) {
+			item, err := parseTOMLValue(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	}
+	if n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", raw)
+}
+
+// splitTOMLArray splits array items on commas outside strings
+func splitTOMLArray(body string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range body {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			parts = append(parts, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(body[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
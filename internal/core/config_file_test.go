@@ -0,0 +1,179 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfigYAML = `
+provider: groq
+fallbacks: [groq, openai]
+providers:
+  openai:
+    api_key: sk-file
+    base_url: https://proxy.example.com/v1
+    model: gpt-4o-mini
+    timeout: 60
+    retry: {max_attempts: 5}
+retry:
+  base_delay_ms: 500
+tools:
+  reflexion:
+    provider: anthropic
+  plan_execute:
+    enabled: false
+  code_exec:
+    enabled: true
+    timeout: 20
+limits:
+  max_concurrent: 4
+cache:
+  ttl: 300
+memory:
+  path: /tmp/memory.json
+profiles:
+  quick:
+    provider: groq
+    params: {max_nodes: 10}
+env:
+  searxng_url: http://localhost:8888
+`
+
+const testConfigTOML = `
+provider = "groq"
+fallbacks = ["groq", "openai"] # Tried in order
+
+[providers.openai]
+api_key = "sk-file"
+base_url = 'https://proxy.example.com/v1'
+model = "gpt-4o-mini"
+timeout = 60
+
+[providers.openai.retry]
+max_attempts = 5
+
+[retry]
+base_delay_ms = 500
+
+[tools.reflexion]
+provider = "anthropic"
+
+[tools.plan_execute]
+enabled = false
+
+[tools.code_exec]
+enabled = true
+timeout = 20
+
+[limits]
+max_concurrent = 4
+
+[cache]
+ttl = 300
+
+[memory]
+path = "/tmp/memory.json"
+
+[profiles.quick]
+provider = "groq"
+
+[profiles.quick.params]
+max_nodes = 10
+
+[env]
+SEARXNG_URL = "http://localhost:8888"
+`
+
+func TestLoadConfigFile_Formats(t *testing.T) {
+	want := map[string]string{
+		"LLM_PROVIDER":              "groq",
+		"LLM_FALLBACKS":             "groq,openai",
+		"OPENAI_API_KEY":            "sk-file",
+		"OPENAI_BASE_URL":           "https://proxy.example.com/v1",
+		"OPENAI_MODEL":              "gpt-4o-mini",
+		"OPENAI_TIMEOUT":            "60",
+		"OPENAI_RETRY_MAX_ATTEMPTS": "5",
+		"LLM_RETRY_BASE_DELAY_MS":   "500",
+		"REFLEXION_PROVIDER":        "anthropic",
+		"DISABLED_TOOLS":            "plan_execute",
+		"CODE_EXEC_ENABLED":         "true",
+		"CODE_EXEC_TIMEOUT":         "20",
+		"LLM_MAX_CONCURRENT":        "4",
+		"TOOL_CACHE_TTL":            "300",
+		"REFLEXION_MEMORY_PATH":     "/tmp/memory.json",
+		"SEARXNG_URL":               "http://localhost:8888",
+		"REASONING_PROFILES":        `{"quick":{"provider":"groq","params":{"max_nodes":10}}}`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"reasoning.yaml": testConfigYAML, "reasoning.toml": testConfigTOML} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0600)
+		cfg, err := loadConfigFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		env, err := cfg.env()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(env) != len(want) {
+			t.Errorf("%s: expected %d settings, got %v", name, len(want), env)
+		}
+		for key, value := range want {
+			if env[key] != value {
+				t.Errorf("%s: %s = %q, want %q", name, key, env[key], value)
+			}
+		}
+	}
+
+	for name, content := range map[string]string{
+		"typo.yaml":     "provder: groq\n",
+		"provider.yaml": "providers:\n  acme:\n    api_key: x\n",
+		"builtin.yaml":  "tools:\n  web_fetch:\n    enabled: false\n",
+		"table.toml":    "[[tools]]\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0600)
+		if _, err := applyConfigFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestApplyConfigFile_EnvWins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reasoning.yaml")
+	os.WriteFile(path, []byte(testConfigYAML), 0600)
+	cfg, _ := loadConfigFile(path)
+	env, _ := cfg.env()
+	for key := range env {
+		t.Setenv(key, "") // Restored after the test
+		os.Unsetenv(key)
+	}
+	t.Setenv("LLM_PROVIDER", "deepseek")
+	t.Setenv("LLM_MAX_CONCURRENT", "1")
+	defer ResetConfig()
+
+	n, err := applyConfigFile(path)
+	if err != nil || n != len(env)-2 {
+		t.Fatalf("Expected %d applied settings, got %d (%v)", len(env)-2, n, err)
+	}
+	if os.Getenv("LLM_PROVIDER") != "deepseek" || GetConfig().MaxConcurrentLLMRequests != 1 {
+		t.Error("Expected environment variables to win over the file")
+	}
+	if GetConfig().OpenAITimeout.Seconds() != 60 || DefaultReflexionConfig().MemoryPath != "/tmp/memory.json" {
+		t.Error("Expected file settings to fill unset variables")
+	}
+	if tools := disabledTools(); len(tools) != 1 || tools[0] != "plan_execute" {
+		t.Errorf("Expected plan_execute disabled, got %v", tools)
+	}
+
+	p, err := buildProvider("openai", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if openai := unwrapProvider(p).(*OpenAIProvider); openai.baseURL != "https://proxy.example.com/v1" || openai.model != "gpt-4o-mini" {
+		t.Errorf("Expected the file's base URL and model, got %s %s", openai.baseURL, openai.model)
+	}
+}
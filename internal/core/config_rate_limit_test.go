@@ -1,4 +1,4 @@
-package main
+package core
 
 import (
 	"context"
@@ -326,6 +326,18 @@ func TestResetConfig_ClearsSemaphore(t *testing.T) {
 	}
 }
 
+// waitForQueueLen waits until n requests are queued behind the dispatcher
+func waitForQueueLen(t *testing.T, l *FIFOLimiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(l.queue) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d queued requests, got %d", n, len(l.queue))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func TestFIFOLimiter_Ordering(t *testing.T) {
 	ResetConfig()
 
@@ -342,21 +354,25 @@ func TestFIFOLimiter_Ordering(t *testing.T) {
 		completionOrder []int
 		orderMu         sync.Mutex
 		wg              sync.WaitGroup
-		startGate       = make(chan struct{}) // ensures all goroutines start together
 	)
 
-	// Launch requests in order 0, 1, 2, ...
+	// Hold the only slot, and park the dispatcher on a filler entry so every
+	// request stays in the queue until the slot is released
+	limiter := getLLMLimiter()
+	hold, err := AcquireLLMSlot(ctx)
+	if err != nil {
+		t.Fatalf("Failed to acquire the first slot: %v", err)
+	}
+	filler := make(chan func(), 1)
+	limiter.queue <- filler
+	waitForQueueLen(t, limiter, 0)
+
+	// Launch requests in order 0, 1, 2, ..., each once the previous is queued
 	for i := 0; i < numRequests; i++ {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
 
-			// Wait for start signal
-			<-startGate
-
-			// Small stagger to ensure enqueue order matches id order
-			time.Sleep(time.Duration(id) * time.Millisecond)
-
 			release, err := AcquireLLMSlot(ctx)
 			if err != nil {
 				t.Errorf("Request %d failed to acquire: %v", id, err)
@@ -372,10 +388,11 @@ func TestFIFOLimiter_Ordering(t *testing.T) {
 			time.Sleep(5 * time.Millisecond)
 			release()
 		}(i)
+		waitForQueueLen(t, limiter, i+1)
 	}
 
-	// Start all goroutines
-	close(startGate)
+	hold()
+	(<-filler)()
 	wg.Wait()
 
 	// Verify FIFO ordering
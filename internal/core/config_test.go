@@ -1,4 +1,4 @@
-package main
+package core
 
 import (
 	"os"
@@ -227,6 +227,39 @@ func TestLoadConfigClampsOutOfRangeValues(t *testing.T) {
 	}
 }
 
+func TestLoadConfigRetryPolicy(t *testing.T) {
+	t.Setenv("LLM_RETRY_MAX_ATTEMPTS", "4")
+	t.Setenv("LLM_RETRY_BASE_DELAY_MS", "100")
+	t.Setenv("LLM_RETRY_JITTER", "0")
+	t.Setenv("GROQ_RETRY_RATE_LIMIT_ATTEMPTS", "50")
+	t.Setenv("GROQ_RETRY_MAX_DELAY_MS", "50")
+
+	cfg := LoadConfig()
+	want := retryConfig{maxAttempts: 4, baseDelay: 100 * time.Millisecond, maxDelay: 30 * time.Second, rateLimitAttempts: 5}
+	if cfg.Retry != want {
+		t.Errorf("Retry = %+v, want %+v", cfg.Retry, want)
+	}
+
+	// Provider overrides start from the global policy and are clamped
+	want.rateLimitAttempts = maxRetryRateLimitAttempts
+	want.maxDelay = want.baseDelay
+	if got := cfg.ProviderRetry["GROQ"]; got != want {
+		t.Errorf("GROQ retry = %+v, want %+v", got, want)
+	}
+	if _, ok := cfg.ProviderRetry["OPENAI"]; ok {
+		t.Error("Expected no OPENAI override")
+	}
+
+	ResetConfig()
+	t.Cleanup(ResetConfig)
+	if got := retryConfigFor("groq"); got.rateLimitAttempts != maxRetryRateLimitAttempts {
+		t.Errorf("retryConfigFor(groq) = %+v", got)
+	}
+	if got := retryConfigFor("openai"); got.maxAttempts != 4 {
+		t.Errorf("retryConfigFor(openai) = %+v", got)
+	}
+}
+
 func TestGetConfig(t *testing.T) {
 	cfg := GetConfig()
 	if cfg == nil {
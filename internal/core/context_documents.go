@@ -0,0 +1,412 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Context Documents ============
+//
+// context_documents hands a strategy material to reason over: texts, or
+// URLs that are fetched first. The documents are split into chunks with IDs
+// such as D2.3 (the third chunk of the second document), and each LLM call
+// of the run gets the chunks that best match its prompt by keyword
+// retrieval, with an instruction to cite the supporting chunk after each
+// claim. The result gains "grounding": the documents, and each claim of the
+// result that cites chunks, with the cited chunks' text.
+
+const (
+	maxContextDocuments       = 20
+	defaultContextChunkChars  = 1200
+	defaultContextTopK        = 4
+	maxContextDocumentChars   = 50000 // Text kept from a fetched URL
+	maxGroundingExcerptChars  = 300
+	contextDocumentWordMinLen = 3 // Shorter words are ignored by retrieval
+)
+
+var (
+	citationPattern = regexp.MustCompile(`\[D\d+\.\d+(?:\s*[,;]\s*D\d+\.\d+)*\]`)
+	chunkIDPattern  = regexp.MustCompile(`D\d+\.\d+`)
+)
+
+func contextDocumentsOption() mcp.ToolOption {
+	return mcp.WithString("context_documents",
+		mcp.Description(`JSON array of documents to reason over: texts, or http(s) URLs to fetch, e.g. ["https://example.com/policy.html", "Refunds are issued within 14 days."]. Each LLM call sees the most relevant chunks and the result cites the chunks behind each claim under grounding`),
+	)
+}
+
+// ContextDocument is one supplied document
+type ContextDocument struct {
+	ID     string `json:"id"`
+	Source string `json:"source"` // URL, or "text"
+	Chunks int    `json:"chunks"`
+	Error  string `json:"error,omitempty"` // Why a URL could not be fetched
+}
+
+// DocumentChunk is a retrievable piece of a document
+type DocumentChunk struct {
+	ID       string `json:"id"`
+	Document string `json:"document"`
+	Text     string `json:"text"`
+	words    map[string]int
+}
+
+// DocumentSet indexes one run's documents for retrieval
+type DocumentSet struct {
+	Documents []ContextDocument
+	chunks    []*DocumentChunk
+	byID      map[string]*DocumentChunk
+	df        map[string]int
+	topK      int
+
+	mu        sync.Mutex
+	retrieved map[string]int // Chunk ID -> LLM calls it was shown to
+}
+
+type contextDocumentsKey struct{}
+
+func documentsFromContext(ctx context.Context) *DocumentSet {
+	docs, _ := ctx.Value(contextDocumentsKey{}).(*DocumentSet)
+	return docs
+}
+
+// parseContextDocuments reads context_documents: a JSON array string, an
+// array, or a single document as a plain string
+func parseContextDocuments(value interface{}) ([]string, error) {
+	var raw []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("context_documents[%d] must be a string", i)
+			}
+			raw = append(raw, s)
+		}
+	case string:
+		v = strings.TrimSpace(v)
+		if strings.HasPrefix(v, "[") {
+			if err := json.Unmarshal([]byte(v), &raw); err != nil {
+				return nil, fmt.Errorf("invalid context_documents: %w", err)
+			}
+		} else if v != "" {
+			raw = []string{v}
+		}
+	default:
+		return nil, fmt.Errorf("context_documents must be a JSON array of strings")
+	}
+	var docs []string
+	for _, d := range raw {
+		if d = strings.TrimSpace(d); d != "" {
+			docs = append(docs, d)
+		}
+	}
+	if len(docs) > maxContextDocuments {
+		return nil, fmt.Errorf("too many context_documents: %d (max %d)", len(docs), maxContextDocuments)
+	}
+	return docs, nil
+}
+
+func isDocumentURL(doc string) bool {
+	return !strings.ContainsAny(doc, " \n\t") && (strings.HasPrefix(doc, "http://") || strings.HasPrefix(doc, "https://"))
+}
+
+// loadDocuments fetches URL documents and chunks everything. A URL that
+// cannot be fetched is reported on its document; it is an error only when
+// no document has any text.
+func loadDocuments(ctx context.Context, docs []string, chunkChars, topK int) (*DocumentSet, error) {
+	set := &DocumentSet{
+		byID:      make(map[string]*DocumentChunk),
+		df:        make(map[string]int),
+		topK:      topK,
+		retrieved: make(map[string]int),
+	}
+	fetcher := &WebFetchTool{client: &http.Client{Timeout: GetConfig().WebFetchTimeout}}
+	var fetchErrors []string
+	for i, doc := range docs {
+		cd := ContextDocument{ID: fmt.Sprintf("D%d", i+1), Source: "text"}
+		text := doc
+		if isDocumentURL(doc) {
+			cd.Source = doc
+			fetched, err := fetcher.fetchText(ctx, doc, maxContextDocumentChars)
+			if err != nil {
+				cd.Error = err.Error()
+				fetchErrors = append(fetchErrors, fmt.Sprintf("%s: %v", doc, err))
+				fmt.Fprintf(os.Stderr, "Warning: context document %s (%s) could not be fetched: %v\n", cd.ID, doc, err)
+				set.Documents = append(set.Documents, cd)
+				continue
+			}
+			text = fetched
+		}
+		for j, piece := range chunkProblem(text, chunkChars) {
+			chunk := &DocumentChunk{ID: fmt.Sprintf("%s.%d", cd.ID, j+1), Document: cd.ID, Text: piece, words: make(map[string]int)}
+			for _, w := range normalizedWords(piece) {
+				if len(w) >= contextDocumentWordMinLen {
+					chunk.words[w]++
+				}
+			}
+			for w := range chunk.words {
+				set.df[w]++
+			}
+			set.chunks = append(set.chunks, chunk)
+			set.byID[chunk.ID] = chunk
+			cd.Chunks++
+		}
+		set.Documents = append(set.Documents, cd)
+	}
+	if len(set.chunks) == 0 {
+		if len(fetchErrors) > 0 {
+			return nil, fmt.Errorf("no context document could be loaded: %s", strings.Join(fetchErrors, "; "))
+		}
+		return nil, fmt.Errorf("context_documents are empty")
+	}
+	return set, nil
+}
+
+// retrieve returns the topK chunks that best match query by the summed
+// inverse document frequency of shared words, in document order. When the
+// documents have no more than topK chunks, all of them are returned.
+func (s *DocumentSet) retrieve(query string) []*DocumentChunk {
+	selected := s.chunks
+	if len(s.chunks) > s.topK {
+		terms := make(map[string]bool)
+		for _, w := range normalizedWords(query) {
+			if len(w) >= contextDocumentWordMinLen {
+				terms[w] = true
+			}
+		}
+		type scored struct {
+			index int
+			score float64
+		}
+		var ranked []scored
+		for i, chunk := range s.chunks {
+			score := 0.0
+			for w := range terms {
+				if tf := chunk.words[w]; tf > 0 {
+					score += (1 + math.Log(float64(tf))) * math.Log(1+float64(len(s.chunks))/float64(s.df[w]))
+				}
+			}
+			if score > 0 {
+				ranked = append(ranked, scored{i, score})
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+		ranked = ranked[:min(s.topK, len(ranked))]
+		sort.Slice(ranked, func(i, j int) bool { return ranked[i].index < ranked[j].index })
+		selected = make([]*DocumentChunk, len(ranked))
+		for i, r := range ranked {
+			selected[i] = s.chunks[r.index]
+		}
+	}
+
+	s.mu.Lock()
+	for _, chunk := range selected {
+		s.retrieved[chunk.ID]++
+	}
+	s.mu.Unlock()
+	return selected
+}
+
+// documentInstruction is the system prompt section carrying the chunks
+func documentInstruction(chunks []*DocumentChunk) string {
+	var sb strings.Builder
+	sb.WriteString("Reference documents were supplied with this problem. Base your claims on them where they apply, and cite the chunk that supports a claim right after it, e.g. [D1.2], or [D1.2, D3.1] for several. Do not cite chunks for claims they do not support.\n")
+	for _, chunk := range chunks {
+		fmt.Fprintf(&sb, "\n[%s]\n%s\n", chunk.ID, chunk.Text)
+	}
+	return sb.String()
+}
+
+// WithDocuments adds the chunks relevant to each chat, stream and tool call
+// of a run with context_documents to its system message
+func WithDocuments() ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		docs := documentsFromContext(ctx)
+		if docs == nil || call.Kind == CallEmbed {
+			return next(ctx)
+		}
+		var query strings.Builder
+		for _, m := range call.Messages {
+			if m.Role == "user" {
+				query.WriteString(m.Content + "\n")
+			}
+		}
+		chunks := docs.retrieve(query.String())
+		if len(chunks) == 0 {
+			return next(ctx)
+		}
+		instruction := documentInstruction(chunks)
+		messages := make([]ChatMessage, 0, len(call.Messages)+1)
+		if len(call.Messages) > 0 && call.Messages[0].Role == "system" {
+			system := call.Messages[0]
+			system.Content += "\n\n" + instruction
+			messages = append(append(messages, system), call.Messages[1:]...)
+		} else {
+			messages = append(append(messages, ChatMessage{Role: "system", Content: instruction}), call.Messages...)
+		}
+		call.Messages = messages
+		return next(ctx)
+	})
+}
+
+// DocumentCitation is a claim of the result and the chunks it cites
+type DocumentCitation struct {
+	Claim  string   `json:"claim"`
+	Field  string   `json:"field"` // Where the claim appears, e.g. steps[1].synthesis.content
+	Chunks []string `json:"chunks"`
+}
+
+// CitedChunk is the text of a chunk some claim cites
+type CitedChunk struct {
+	Document string `json:"document"`
+	Text     string `json:"text"`
+}
+
+// GroundingReport is the grounding appendix of a result
+type GroundingReport struct {
+	Documents   []ContextDocument     `json:"documents"`
+	Citations   []DocumentCitation    `json:"citations"`
+	CitedChunks map[string]CitedChunk `json:"cited_chunks,omitempty"`
+	Retrieved   map[string]int        `json:"retrieved"` // LLM calls each chunk was shown to
+}
+
+// grounding collects the citations in a result's string fields
+func (s *DocumentSet) grounding(fields map[string]interface{}) GroundingReport {
+	report := GroundingReport{Documents: s.Documents, Citations: []DocumentCitation{}, CitedChunks: make(map[string]CitedChunk)}
+	seen := make(map[string]bool)
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if path == "" && k == "problem" {
+					continue
+				}
+				walk(strings.TrimPrefix(path+"."+k, "."), v[k])
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		case string:
+			for _, sentence := range splitSentences(v) {
+				var ids []string
+				for _, ref := range citationPattern.FindAllString(sentence, -1) {
+					for _, id := range chunkIDPattern.FindAllString(ref, -1) {
+						if chunk, ok := s.byID[id]; ok {
+							ids = append(ids, id)
+							report.CitedChunks[id] = CitedChunk{Document: chunk.Document, Text: utils.TruncateStr(chunk.Text, maxGroundingExcerptChars)}
+						}
+					}
+				}
+				if len(ids) == 0 {
+					continue
+				}
+				claim := strings.TrimSpace(citationPattern.ReplaceAllString(sentence, ""))
+				if key := path + "\x00" + claim; !seen[key] {
+					seen[key] = true
+					report.Citations = append(report.Citations, DocumentCitation{Claim: utils.TruncateStr(claim, maxGroundingExcerptChars), Field: path, Chunks: ids})
+				}
+			}
+		}
+	}
+	walk("", fields)
+
+	s.mu.Lock()
+	report.Retrieved = make(map[string]int, len(s.retrieved))
+	for id, n := range s.retrieved {
+		report.Retrieved[id] = n
+	}
+	s.mu.Unlock()
+	return report
+}
+
+// splitSentences splits text into claims: after '.', '!' or '?' followed by
+// a space, after a citation followed by a space, and at line breaks. A
+// citation after the full stop stays with its sentence.
+func splitSentences(text string) []string {
+	var sentences []string
+	for _, line := range strings.Split(text, "\n") {
+		start := 0
+		for i := 0; i < len(line)-1; i++ {
+			if line[i+1] != ' ' {
+				continue
+			}
+			end := false
+			switch line[i] {
+			case '.', '!', '?':
+				end = !strings.HasPrefix(line[i+1:], " [D")
+			case ']':
+				open := strings.LastIndexByte(line[:i], '[')
+				end = open >= start && citationPattern.MatchString(line[open:i+1])
+			}
+			if end {
+				sentences = append(sentences, line[start:i+1])
+				start = i + 2
+			}
+		}
+		if rest := strings.TrimSpace(line[start:]); rest != "" {
+			sentences = append(sentences, rest)
+		}
+	}
+	return sentences
+}
+
+// contextDocumentsMiddleware loads a strategy call's context_documents for
+// its LLM calls and appends grounding to the result
+func contextDocumentsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		if !runHistoryTools[request.Params.Name] || args["context_documents"] == nil || documentsFromContext(ctx) != nil {
+			return next(ctx, request)
+		}
+		docs, err := parseContextDocuments(args["context_documents"])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(docs) == 0 {
+			return next(ctx, request)
+		}
+		chunkChars := max(parseEnvInt("CONTEXT_CHUNK_CHARS", defaultContextChunkChars), 200)
+		topK := max(parseEnvInt("CONTEXT_TOP_K", defaultContextTopK), 1)
+		set, err := loadDocuments(ctx, docs, chunkChars, topK)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result, err := next(context.WithValue(ctx, contextDocumentsKey{}, set), request)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+		text := resultText(result)
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(text), &fields) != nil {
+			return result, err
+		}
+		if text, ok := appendResultField(text, "grounding", set.grounding(fields)); ok {
+			return mcp.NewToolResultText(text), nil
+		}
+		return result, err
+	}
+}
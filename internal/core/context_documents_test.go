@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseContextDocuments(t *testing.T) {
+	for _, tc := range []struct {
+		in   interface{}
+		want int
+	}{
+		{`["a", " ", "https://example.com"]`, 2},
+		{[]interface{}{"a", "b"}, 2},
+		{"A single document.", 1},
+		{nil, 0},
+	} {
+		if docs, err := parseContextDocuments(tc.in); err != nil || len(docs) != tc.want {
+			t.Errorf("parseContextDocuments(%v) = %v, %v; want %d documents", tc.in, docs, err, tc.want)
+		}
+	}
+	if _, err := parseContextDocuments([]interface{}{"a", 1.0}); err == nil {
+		t.Error("Expected a non-string document to be rejected")
+	}
+}
+
+func TestSplitSentences_KeepsCitations(t *testing.T) {
+	got := splitSentences("The warranty lasts two years. [D1.1] Shipping is free [D2.1] for members.\nNo citation here.")
+	want := []string{"The warranty lasts two years. [D1.1]", "Shipping is free [D2.1]", "for members.", "No citation here."}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("splitSentences = %q, want %q", got, want)
+	}
+}
+
+func TestContextDocuments_RetrievesAndCites(t *testing.T) {
+	t.Setenv("CONTEXT_TOP_K", "1")
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><p>Returns are accepted within thirty days.</p></body></html>")
+	}))
+	defer page.Close()
+
+	var (
+		mu      sync.Mutex
+		systems []string
+	)
+	fake := newFakeOpenAI(t, func(req fakeChatRequest) string {
+		mu.Lock()
+		systems = append(systems, req.system())
+		mu.Unlock()
+		return `{"thought_number": 1, "total_thoughts": 1, "thought": "The policy covers it.", "next_thought_needed": false, "final_answer": "The warranty lasts two years. [D1.1] Keep the receipt."}`
+	})
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t, contextDocumentsMiddleware)
+
+	result := callTool(t, s, "sequential_thinking", map[string]interface{}{
+		"problem":           "How long does the warranty last?",
+		"context_documents": fmt.Sprintf(`["The warranty lasts two years from purchase.", "Shipping takes five business days.", %q]`, page.URL),
+	})
+	if len(systems) == 0 || !strings.Contains(systems[0], "[D1.1]\nThe warranty lasts two years from purchase.") || strings.Contains(systems[0], "Shipping") {
+		t.Errorf("Expected only the warranty chunk in the prompt, got %q", systems)
+	}
+
+	grounding := result["grounding"].(map[string]interface{})
+	documents := grounding["documents"].([]interface{})
+	if len(documents) != 3 || documents[2].(map[string]interface{})["source"] != page.URL || documents[2].(map[string]interface{})["chunks"] != 1.0 {
+		t.Errorf("Unexpected documents: %v", documents)
+	}
+	citations := grounding["citations"].([]interface{})
+	if len(citations) != 1 {
+		t.Fatalf("Expected one cited claim, got %v", citations)
+	}
+	c := citations[0].(map[string]interface{})
+	if c["claim"] != "The warranty lasts two years." || c["field"] != "final_answer" || fmt.Sprint(c["chunks"]) != "[D1.1]" {
+		t.Errorf("Unexpected citation: %v", c)
+	}
+	if _, ok := grounding["cited_chunks"].(map[string]interface{})["D1.1"]; !ok {
+		t.Errorf("Expected the cited chunk's text, got %v", grounding["cited_chunks"])
+	}
+}
@@ -0,0 +1,358 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Context Windows ============
+//
+// Reflexion conversations and GoT paths grow with the run, and past the
+// model's context window the provider rejects the request. WithContextWindow
+// estimates each request's tokens against the model's window (see
+// contextWindowFor) and, by the run's context_strategy, shortens it first:
+//
+//	truncate  drop the oldest turns, keeping the system prompt, the task and
+//	          the latest turns, then cut the middle of a message still too long
+//	summarize the same, with the dropped text replaced by a summary
+//	fail      refuse the request with ErrContextWindowExceeded
+//
+// A request the provider still rejects for its length is shortened once more
+// and resent: to the window the error states, or else to three quarters of
+// its size.
+
+// Values of context_strategy
+const (
+	ContextStrategyTruncate  = "truncate"
+	ContextStrategySummarize = "summarize"
+	ContextStrategyFail      = "fail"
+)
+
+// ErrContextWindowExceeded is returned for a request longer than the model's
+// context window when context_strategy is fail
+var ErrContextWindowExceeded = errors.New("context window exceeded")
+
+const (
+	// Share of the window requests may fill, leaving room for the error of
+	// estimating tokens from characters
+	contextWindowUsable = 0.9
+	// Tokens kept for the reply of a call that sets no MaxTokens
+	contextReplyReserve = 1024
+	// Estimated tokens of a message's role and framing
+	contextMessageOverhead = 4
+)
+
+// modelContextWindows are the context windows in tokens of known models, by
+// model name prefix; the longest matching prefix wins
+var modelContextWindows = map[string]int{
+	"gpt-5":         400000,
+	"gpt-4.1":       1047576,
+	"gpt-4o":        128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1":            200000,
+	"o3":            200000,
+	"o4":            200000,
+	"claude":        200000,
+	"llama-3.1":     131072,
+	"llama-3.2":     131072,
+	"llama-3.3":     131072,
+	"llama3.1":      131072,
+	"llama3.2":      131072,
+	"llama3.3":      131072,
+	"llama-3":       8192,
+	"llama3":        8192,
+	"llama2":        4096,
+	"mixtral":       32768,
+	"mistral":       32768,
+	"gemma":         8192,
+	"qwen":          32768,
+	"deepseek":      65536,
+	"glm-4":         128000,
+}
+
+// contextWindowFor returns the context window of model in tokens, 0 when
+// unknown. CONTEXT_WINDOWS ("model=tokens,...", by prefix like the built-in
+// table) takes precedence, and CONTEXT_WINDOW_TOKENS covers unknown models.
+func contextWindowFor(model string) int {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:] // OpenRouter and Together prefix the vendor
+	}
+	if name == "" {
+		return parseEnvInt("CONTEXT_WINDOW_TOKENS", 0)
+	}
+	overrides := make(map[string]int)
+	for _, entry := range strings.Split(os.Getenv("CONTEXT_WINDOWS"), ",") {
+		prefix, tokens, ok := strings.Cut(entry, "=")
+		if n, err := strconv.Atoi(strings.TrimSpace(tokens)); ok && err == nil && n > 0 {
+			overrides[strings.ToLower(strings.TrimSpace(prefix))] = n
+		}
+	}
+	for _, table := range []map[string]int{overrides, modelContextWindows} {
+		best := ""
+		for prefix := range table {
+			if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+				best = prefix
+			}
+		}
+		if best != "" {
+			return table[best]
+		}
+	}
+	return parseEnvInt("CONTEXT_WINDOW_TOKENS", 0)
+}
+
+// estimateMessageTokens approximates the prompt tokens of messages
+func estimateMessageTokens(messages []ChatMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += estimateTokens(m.Content) + contextMessageOverhead
+		for _, tc := range m.ToolCalls {
+			total += estimateTokens(tc.Function.Name) + estimateTokens(tc.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// isContextLengthError reports whether a provider rejected a request for its length
+func isContextLengthError(err error) bool {
+	text := strings.ToLower(err.Error())
+	for _, marker := range []string{"context length", "context_length", "context window", "maximum context", "prompt is too long", "too many tokens"} {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// contextLimitPattern finds the window in provider errors such as OpenAI's
+// "maximum context length is 8192 tokens" and Anthropic's "210000 tokens >
+// 200000 maximum"
+var contextLimitPattern = regexp.MustCompile(`(?i)(?:context length is|>) *(\d+)`)
+
+// contextLimitFromError returns the context window a length error states, 0
+// when it states none
+func contextLimitFromError(err error) int {
+	m := contextLimitPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// ============ Context Strategy ============
+
+type contextStrategyKey struct{}
+
+func contextStrategyOption() mcp.ToolOption {
+	return mcp.WithString("context_strategy",
+		mcp.Description("What to do when the conversation or reasoning path outgrows the model's context window: truncate (drop the oldest turns), summarize (replace them with a summary) or fail (default: CONTEXT_STRATEGY or truncate)"),
+	)
+}
+
+// parseContextStrategy validates a context_strategy, "" for the default
+func parseContextStrategy(raw interface{}) (string, error) {
+	s, ok := raw.(string)
+	if raw != nil && !ok {
+		return "", fmt.Errorf("must be a string, got %s", describeArgValue(raw))
+	}
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "", ContextStrategyTruncate, ContextStrategySummarize, ContextStrategyFail:
+		return s, nil
+	case "truncate-oldest", "truncate_oldest":
+		return ContextStrategyTruncate, nil
+	}
+	return "", fmt.Errorf("must be truncate, summarize or fail, got %q", s)
+}
+
+// contextStrategyFromContext returns the run's context_strategy, then
+// CONTEXT_STRATEGY, then truncate
+func contextStrategyFromContext(ctx context.Context) string {
+	if s, _ := ctx.Value(contextStrategyKey{}).(string); s != "" {
+		return s
+	}
+	if s, err := parseContextStrategy(os.Getenv("CONTEXT_STRATEGY")); err == nil && s != "" {
+		return s
+	}
+	return ContextStrategyTruncate
+}
+
+// contextStrategyMiddleware validates the context_strategy argument of
+// strategy calls and puts it in the context for WithContextWindow
+func contextStrategyMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		if !runHistoryTools[request.Params.Name] || args["context_strategy"] == nil {
+			return next(ctx, request)
+		}
+		strategy, err := parseContextStrategy(args["context_strategy"])
+		if err != nil {
+			return toolErrorResult(&toolError{Code: errCodeInvalidArgument, Message: "invalid arguments: context_strategy " + err.Error(), Fields: []string{"context_strategy"}}), nil
+		}
+		return next(context.WithValue(ctx, contextStrategyKey{}, strategy), request)
+	}
+}
+
+// ============ Middleware ============
+
+// WithContextWindow keeps chat, stream and tool calls within the model's
+// context window by the run's context_strategy. Summaries are written by the
+// wrapped provider, charged to the run's max_llm_calls and sampled like the
+// run (see chargedSummaryProvider).
+func WithContextWindow() ProviderMiddleware {
+	return func(p Provider) Provider {
+		return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+			if call.Kind == CallEmbed {
+				return next(ctx)
+			}
+			strategy := contextStrategyFromContext(ctx)
+			reserve := call.Opts.MaxTokens
+			if reserve <= 0 {
+				reserve = contextReplyReserve
+			}
+			if window := contextWindowFor(call.Model); window > 0 {
+				budget := int(float64(window)*contextWindowUsable) - reserve
+				if estimated := estimateMessageTokens(call.Messages); estimated > budget {
+					if strategy == ContextStrategyFail {
+						return fmt.Errorf("%w: the request to %s/%s needs about %d tokens plus %d for the reply, and the model's window is %d; pass context_strategy truncate or summarize, or use a model with a larger window",
+							ErrContextWindowExceeded, call.Provider, call.Model, estimated, reserve, window)
+					}
+					call.Messages = fitContextWindow(ctx, p, call.Messages, max(budget, 0), strategy)
+				}
+			}
+
+			err := next(ctx)
+			if err == nil || call.Streamed || !isContextLengthError(err) {
+				return err
+			}
+			if strategy == ContextStrategyFail {
+				return fmt.Errorf("%w: %v", ErrContextWindowExceeded, err)
+			}
+			budget := estimateMessageTokens(call.Messages) * 3 / 4
+			if window := contextLimitFromError(err); window > 0 {
+				budget = min(budget, int(float64(window)*contextWindowUsable)-reserve)
+			}
+			call.Messages = fitContextWindow(ctx, p, call.Messages, max(budget, 0), strategy)
+			return next(ctx)
+		})(p)
+	}
+}
+
+// Characters of a message always kept by the middle cut, so the task it
+// states survives however far over the window the request is
+const contextCutMinKeep = 512
+
+// fitContextWindow shortens messages to about budget tokens for strategy
+// truncate or summarize. It keeps the leading system messages, the first
+// message after them (the task) and the last message, and drops whole turns
+// from the oldest, so a tool reply never loses its call. When that is not
+// enough, the middle of the longest message is cut.
+func fitContextWindow(ctx context.Context, p Provider, messages []ChatMessage, budget int, strategy string) []ChatMessage {
+	head := 0
+	for head < len(messages) && messages[head].Role == "system" {
+		head++
+	}
+	if head < len(messages)-1 {
+		head++ // The task
+	}
+	kept := append([]ChatMessage(nil), messages[:head]...)
+	tail := messages[head:]
+
+	// Drop the oldest turns. The kept history restarts at an assistant turn,
+	// or at a final user turn that is joined to the task.
+	cut := 0
+	for i := 1; i < len(tail) && estimateMessageTokens(kept)+estimateMessageTokens(tail[cut:]) > budget; i++ {
+		if tail[i].Role == "assistant" || (i == len(tail)-1 && tail[i].Role == "user") {
+			cut = i
+		}
+	}
+	if cut > 0 {
+		note := fmt.Sprintf("[%d earlier messages were dropped to fit the context window]", cut)
+		if strategy == ContextStrategySummarize {
+			note = summarizeDropped(ctx, p, tail[:cut], budget, note)
+		}
+		rest := tail[cut:]
+		last := len(kept) - 1
+		switch {
+		case last < 0:
+			kept = append(kept, ChatMessage{Role: "user", Content: note})
+		case kept[last].Role == rest[0].Role && len(rest[0].ToolCalls) == 0:
+			// Everything between was dropped: join the two turns
+			kept[last].Content += "\n\n" + note + "\n\n" + rest[0].Content
+			rest = rest[1:]
+		default:
+			kept[last].Content += "\n\n" + note
+		}
+		tail = rest
+	}
+	kept = append(kept, tail...)
+	trimmed := cut > 0
+
+	// Cut the middle of the longest message, keeping its start and its end
+	if over := estimateMessageTokens(kept) - budget; over > 0 {
+		longest := 0
+		for i := range kept {
+			if len(kept[i].Content) > len(kept[longest].Content) {
+				longest = i
+			}
+		}
+		content := []rune(kept[longest].Content)
+		keep := max(len(content)-over*4-128, min(len(content), contextCutMinKeep)) // 128 for the note
+		if keep < len(content) {
+			start, end := keep/3, len(content)-(keep-keep/3)
+			note := fmt.Sprintf("[... %d characters were cut to fit the context window ...]", end-start)
+			if strategy == ContextStrategySummarize {
+				note = summarizeDropped(ctx, p, []ChatMessage{{Role: kept[longest].Role, Content: string(content[start:end])}}, budget, note)
+			}
+			msg := kept[longest]
+			msg.Content = string(content[:start]) + "\n" + note + "\n" + string(content[end:])
+			kept[longest] = msg
+			trimmed = true
+		}
+	}
+	if run := queueRunFromContext(ctx); run != nil && trimmed {
+		run.contextTrims.Add(1)
+	}
+	return kept
+}
+
+// summarizeDropped returns a summary of dropped messages to stand in for
+// them, or fallback when the summary cannot be written
+func summarizeDropped(ctx context.Context, p Provider, dropped []ChatMessage, budget int, fallback string) string {
+	var sb strings.Builder
+	for _, m := range dropped {
+		fmt.Fprintf(&sb, "%s: %s\n\n", m.Role, m.Content)
+	}
+	// The summary request has to fit the window too
+	transcript := utils.TruncateStr(sb.String(), max(budget, contextReplyReserve)*4/2)
+	summary, err := summarizeHistory(ctx, chargedSummaryProvider(ctx, p), "", []string{transcript})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARNING] context window: could not summarize %d dropped messages: %v\n", len(dropped), err)
+		return fallback
+	}
+	return "[Summary of earlier reasoning dropped to fit the context window]\n" + summary
+}
+
+// chargedSummaryProvider returns p, the provider inside WithContextWindow, with the
+// call limit and deterministic sampling the stack applies outside it, so a
+// summary counts against max_llm_calls and follows the run's seed
+func chargedSummaryProvider(ctx context.Context, p Provider) Provider {
+	middleware := []ProviderMiddleware{WithDeterminism()}
+	if counter := llmCallCounterFromContext(ctx); counter != nil {
+		middleware = append(middleware, WithCallLimit(counter))
+	}
+	return WrapProvider(p, middleware...)
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// windowProvider records the requests it gets and rejects those over limit
+// estimated tokens the way OpenAI does (0 = no limit)
+type windowProvider struct {
+	mu       sync.Mutex
+	limit    int
+	requests [][]ChatMessage
+	opts     []ChatOptions
+}
+
+func (p *windowProvider) Name() string { return "window" }
+
+func (p *windowProvider) Chat(_ context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, messages)
+	p.opts = append(p.opts, opts)
+	if p.limit > 0 && estimateMessageTokens(messages) > p.limit {
+		return "", fmt.Errorf("API error (status 400): This model's maximum context length is %d tokens", p.limit)
+	}
+	if messages[0].Content == historySummaryPrompt {
+		return "- tried 17 * 23 = 391", nil
+	}
+	return "ok", nil
+}
+
+// reflexionConversation is a task followed by turns of long thoughts
+func reflexionConversation(turns int) []ChatMessage {
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a careful reasoner."},
+		{Role: "user", Content: "Problem: What is 17 * 23?"},
+	}
+	for i := 0; i < turns; i++ {
+		messages = append(messages,
+			ChatMessage{Role: "assistant", Content: fmt.Sprintf("Thought %d: %s", i, strings.Repeat("reasoning ", 200))},
+			ChatMessage{Role: "user", Content: "Continue your reasoning."},
+		)
+	}
+	return messages
+}
+
+func TestContextWindowFor(t *testing.T) {
+	for model, want := range map[string]int{
+		"gpt-4o-mini":                       128000,
+		"openai/gpt-4":                      8192,
+		"meta-llama/llama-3.1-70b-instruct": 131072,
+		"claude-3-haiku-20240307":           200000,
+		"some-local-model":                  0,
+	} {
+		if got := contextWindowFor(model); got != want {
+			t.Errorf("contextWindowFor(%q) = %d, want %d", model, got, want)
+		}
+	}
+
+	t.Setenv("CONTEXT_WINDOWS", "some-local=2048, gpt-4o=64000")
+	t.Setenv("CONTEXT_WINDOW_TOKENS", "4096")
+	for model, want := range map[string]int{"some-local-model": 2048, "gpt-4o-mini": 64000, "unknown": 4096} {
+		if got := contextWindowFor(model); got != want {
+			t.Errorf("With overrides, contextWindowFor(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestFitContextWindow_DropsOldestTurns(t *testing.T) {
+	messages := reflexionConversation(20)
+	messages = append(messages,
+		ChatMessage{Role: "assistant", ToolCalls: []NativeToolCall{{ID: "c1"}}},
+		ChatMessage{Role: "tool", ToolCallID: "c1", Content: "391"},
+	)
+	run := &queueRun{}
+	ctx := context.WithValue(context.Background(), queueRunKey{}, run)
+
+	fitted := fitContextWindow(ctx, nil, messages, 1500, ContextStrategyTruncate)
+	if n := estimateMessageTokens(fitted); n > 1500 {
+		t.Fatalf("Expected at most 1500 tokens, got %d", n)
+	}
+	if fitted[0].Role != "system" || !strings.HasPrefix(fitted[1].Content, "Problem: What is 17 * 23?") || !strings.Contains(fitted[1].Content, "earlier messages were dropped") {
+		t.Errorf("Expected the system prompt and the task to stay, with a note, got %+v", fitted[:2])
+	}
+	if fitted[2].Role != "assistant" || fitted[len(fitted)-1].ToolCallID != "c1" || len(fitted[len(fitted)-2].ToolCalls) != 1 {
+		t.Errorf("Expected the history to restart at an assistant turn and keep the last tool call, got %+v", fitted[2:])
+	}
+	if run.contextTrims.Load() != 1 {
+		t.Errorf("Expected the trim to be counted, got %d", run.contextTrims.Load())
+	}
+
+	// A request that fits is left alone
+	short := reflexionConversation(1)
+	if fitted := fitContextWindow(ctx, nil, short, 100000, ContextStrategyTruncate); len(fitted) != len(short) || run.contextTrims.Load() != 1 {
+		t.Errorf("Expected a short request to be unchanged, got %d messages", len(fitted))
+	}
+}
+
+func TestFitContextWindow_CutsLongPath(t *testing.T) {
+	// A GoT prompt is one message holding the whole path
+	prompt := "Problem: What is 17 * 23?\n\nPath:\n" + strings.Repeat("Step: multiply the tens.\n", 400) + "Respond with JSON."
+	fitted := fitContextWindow(context.Background(), nil, []ChatMessage{{Role: "user", Content: prompt}}, 500, ContextStrategyTruncate)
+	content := fitted[0].Content
+	if estimateMessageTokens(fitted) > 500 || !strings.HasPrefix(content, "Problem: What is 17 * 23?") || !strings.HasSuffix(content, "Respond with JSON.") || !strings.Contains(content, "characters were cut") {
+		t.Errorf("Expected the middle of the path to be cut, got %d tokens: %q", estimateMessageTokens(fitted), content)
+	}
+
+	// Far over the window, the start and end of the task are still kept
+	fitted = fitContextWindow(context.Background(), nil, []ChatMessage{{Role: "user", Content: prompt}}, 10, ContextStrategyTruncate)
+	if content := fitted[0].Content; !strings.HasPrefix(content, "Problem: What is 17 * 23?") || !strings.HasSuffix(content, "Respond with JSON.") {
+		t.Errorf("Expected the head and tail of the task to survive, got %q", content)
+	}
+}
+
+func TestWithContextWindow_Strategies(t *testing.T) {
+	t.Setenv("CONTEXT_WINDOWS", "tiny=4000")
+	opts := ChatOptions{Model: "tiny", MaxTokens: 500}
+	messages := reflexionConversation(20)
+
+	inner := &windowProvider{}
+	p := WrapProvider(inner, WithContextWindow())
+	ctx := context.WithValue(context.Background(), contextStrategyKey{}, ContextStrategySummarize)
+	if _, err := p.Chat(ctx, messages, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.requests) != 2 || inner.requests[0][0].Content != historySummaryPrompt {
+		t.Fatalf("Expected a summary request before the call, got %d requests", len(inner.requests))
+	}
+	if sent := inner.requests[1]; !strings.Contains(sent[1].Content, "17 * 23 = 391") || estimateMessageTokens(sent) > 4000 {
+		t.Errorf("Expected the summary in place of the dropped turns, got %q", sent[1].Content)
+	}
+
+	// The summary is charged to the run's max_llm_calls and sampled like the run
+	inner = &windowProvider{}
+	counter := NewLLMCallCounter(0)
+	p = counter.Wrap(WrapProvider(inner, WithDeterminism(), WithContextWindow()))
+	seeded := context.WithValue(ctx, determinismKey{}, 7)
+	if _, err := p.Chat(seeded, messages, opts); err != nil {
+		t.Fatal(err)
+	}
+	if calls := counter.Usage().LLMCalls; calls != 2 || len(inner.requests) != 2 {
+		t.Errorf("Expected the summary and the call to be counted, got %d calls for %d requests", calls, len(inner.requests))
+	}
+	if o := inner.opts[0]; !o.Deterministic || o.Seed != 7 || o.Temperature != 0 {
+		t.Errorf("Expected the summary to be sent deterministically, got %+v", o)
+	}
+	limited := NewLLMCallCounter(1)
+	inner = &windowProvider{}
+	p = limited.Wrap(WrapProvider(inner, WithContextWindow()))
+	if _, err := p.Chat(ctx, messages, opts); err != nil || len(inner.requests) != 1 {
+		t.Errorf("Expected the summary to be refused past max_llm_calls and the turns truncated, got %v after %d requests", err, len(inner.requests))
+	}
+
+	inner = &windowProvider{}
+	p = WrapProvider(inner, WithContextWindow())
+	ctx = context.WithValue(context.Background(), contextStrategyKey{}, ContextStrategyFail)
+	if _, err := p.Chat(ctx, messages, opts); !errors.Is(err, ErrContextWindowExceeded) || len(inner.requests) != 0 {
+		t.Errorf("Expected fail to refuse the request, got %v after %d requests", err, len(inner.requests))
+	}
+	if kind := failureKind(fmt.Sprintf("reflexion failed: %v", ErrContextWindowExceeded)); kind != "context_window" {
+		t.Errorf("Expected a context_window failure, got %s", kind)
+	}
+}
+
+func TestWithContextWindow_RetriesRejectedRequest(t *testing.T) {
+	// The model is unknown, so only the provider's error reveals its window
+	inner := &windowProvider{limit: 2000}
+	p := WrapProvider(inner, WithContextWindow())
+	messages := reflexionConversation(8)
+
+	if _, err := p.Chat(context.Background(), messages, ChatOptions{Model: "some-local-model"}); err != nil {
+		t.Fatalf("Expected the shortened retry to succeed, got %v", err)
+	}
+	if len(inner.requests) != 2 || estimateMessageTokens(inner.requests[1]) >= estimateMessageTokens(messages) {
+		t.Errorf("Expected one shorter retry, got %d requests", len(inner.requests))
+	}
+}
+
+func TestContextStrategyMiddleware_RejectsUnknown(t *testing.T) {
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t, contextStrategyMiddleware)
+	text := callToolError(t, s, "reflexion", map[string]interface{}{"problem": "What is 17 * 23?", "context_strategy": "shrink"})
+	if !strings.Contains(text, errCodeInvalidArgument) || !strings.Contains(text, "context_strategy") {
+		t.Errorf("Expected an invalid argument error, got %q", text)
+	}
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ============ CORS ============
+
+const (
+	defaultCORSMethods = "GET, POST, DELETE, OPTIONS"
+	defaultCORSHeaders = "Content-Type, Accept, Authorization, X-API-Key, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID"
+	// Response headers browser clients need to read
+	corsExposeHeaders = "Mcp-Session-Id, Mcp-Protocol-Version"
+)
+
+// CORSConfig controls cross-origin access to the HTTP transports
+type CORSConfig struct {
+	Origins []string // Allowed origins; "*" allows any, empty disables CORS
+	Methods string
+	Headers string
+}
+
+// corsConfigFromEnv reads MCP_CORS_ORIGINS, MCP_CORS_METHODS and MCP_CORS_HEADERS
+func corsConfigFromEnv() CORSConfig {
+	config := CORSConfig{
+		Methods: defaultCORSMethods,
+		Headers: defaultCORSHeaders,
+	}
+	for _, origin := range strings.Split(os.Getenv("MCP_CORS_ORIGINS"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			config.Origins = append(config.Origins, origin)
+		}
+	}
+	if methods := strings.TrimSpace(os.Getenv("MCP_CORS_METHODS")); methods != "" {
+		config.Methods = methods
+	}
+	if headers := strings.TrimSpace(os.Getenv("MCP_CORS_HEADERS")); headers != "" {
+		config.Headers = headers
+	}
+	return config
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request
+// origin, or "" when the origin is not allowed
+func (c CORSConfig) allowedOrigin(origin string) string {
+	for _, allowed := range c.Origins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// withCORS adds CORS headers for allowed origins and answers preflight
+// requests. Without configured origins the handler is returned unchanged.
+func withCORS(next http.Handler, config CORSConfig) http.Handler {
+	if len(config.Origins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := ""
+		if origin != "" {
+			allowed = config.allowedOrigin(origin)
+		}
+		if allowed != "" {
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", allowed)
+			h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			if allowed != "*" {
+				h.Add("Vary", "Origin")
+			}
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed == "" {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			h := w.Header()
+			h.Set("Access-Control-Allow-Methods", config.Methods)
+			h.Set("Access-Control-Allow-Headers", config.Headers)
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithCORS(t *testing.T) {
+	t.Setenv("MCP_CORS_ORIGINS", "https://app.example.com/, http://localhost:3000")
+	t.Setenv("MCP_CORS_METHODS", "")
+	t.Setenv("MCP_CORS_HEADERS", "")
+	config := corsConfigFromEnv()
+
+	var served int
+	handler := withCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	}), config)
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/mcp", nil)
+	preflight.Header.Set("Origin", "https://app.example.com")
+	preflight.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, preflight)
+	if rec.Code != http.StatusNoContent || served != 0 {
+		t.Fatalf("Expected preflight answered with 204, got %d (served=%d)", rec.Code, served)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		rec.Header().Get("Access-Control-Allow-Methods") != defaultCORSMethods ||
+		rec.Header().Get("Access-Control-Allow-Headers") != defaultCORSHeaders {
+		t.Errorf("Unexpected preflight headers: %v", rec.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if served != 1 || rec.Header().Get("Access-Control-Allow-Origin") != "http://localhost:3000" || rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("Expected the request served with CORS headers, got %v", rec.Header())
+	}
+
+	preflight.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, preflight)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected a disallowed origin to be refused, got %d %v", rec.Code, rec.Header())
+	}
+
+	t.Setenv("MCP_CORS_ORIGINS", "*")
+	rec = httptest.NewRecorder()
+	withCORS(http.NotFoundHandler(), corsConfigFromEnv()).ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" || rec.Header().Get("Vary") != "" {
+		t.Errorf("Expected a wildcard origin, got %v", rec.Header())
+	}
+
+	t.Setenv("MCP_CORS_ORIGINS", "")
+	rec = httptest.NewRecorder()
+	withCORS(http.NotFoundHandler(), corsConfigFromEnv()).ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected no CORS headers without configured origins")
+	}
+}
@@ -0,0 +1,281 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// DecomposeSolver implements Least-to-Most prompting: it splits a problem into
+// ordered sub-problems, solves them simplest first with earlier answers fed
+// forward, then composes the final answer from the solved parts
+type DecomposeSolver struct {
+	ReasonerBase
+
+	provider Provider
+	config   DecomposeConfig
+}
+
+// DecomposeConfig configures decomposition
+type DecomposeConfig struct {
+	MaxSubproblems int     // Maximum sub-problems to solve (default: 6)
+	Temperature    float64 // LLM temperature (default: 0.3)
+}
+
+// DefaultDecomposeConfig returns sensible defaults
+func DefaultDecomposeConfig() DecomposeConfig {
+	return DecomposeConfig{
+		MaxSubproblems: 6,
+		Temperature:    0.3,
+	}
+}
+
+// Subproblem is one step of the decomposition
+type Subproblem struct {
+	Number    int    `json:"number"`
+	Question  string `json:"question"`
+	DependsOn []int  `json:"depends_on,omitempty"` // Earlier sub-problems whose answers this one uses
+	Reasoning string `json:"reasoning,omitempty"`
+	Answer    string `json:"answer"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DecomposeResult represents the complete result of decompose-and-solve
+type DecomposeResult struct {
+	Problem          string       `json:"problem"`
+	Subproblems      []Subproblem `json:"subproblems"`
+	FinalAnswer      string       `json:"final_answer"`
+	TotalSubproblems int          `json:"total_subproblems"`
+	Decomposed       bool         `json:"decomposed"` // False when the problem was solved as a single step
+	Success          bool         `json:"success"`
+	Provider         string       `json:"provider"`
+	LLMCallUsage
+}
+
+// NewDecomposeSolver creates a new Least-to-Most solver
+func NewDecomposeSolver(provider Provider, config DecomposeConfig) *DecomposeSolver {
+	if config.MaxSubproblems <= 0 {
+		config.MaxSubproblems = DefaultDecomposeConfig().MaxSubproblems
+	}
+	return &DecomposeSolver{provider: provider, config: config}
+}
+
+func (d *DecomposeSolver) chat(ctx context.Context, messages []ChatMessage, maxTokens int) (string, error) {
+	opts := ChatOptions{Temperature: d.config.Temperature, MaxTokens: maxTokens}
+	return d.callProvider(ctx, d.provider, messages, opts, d.enableStreams)
+}
+
+const decomposeSystemPrompt = `You break problems into simpler sub-problems (Least-to-Most prompting).
+List the sub-problems in the order they should be solved, simplest first, so each one can use the answers to the ones before it. The last sub-problem should be the original question, or the step that answers it.
+
+Respond with ONLY a JSON object:
+{
+  "subproblems": [
+    {"question": "<sub-problem>", "depends_on": [<numbers of earlier sub-problems it needs, 1-based>]}
+  ]
+}`
+
+// Solve decomposes the problem and solves each sub-problem in order
+func (d *DecomposeSolver) Solve(ctx context.Context, problem string) (*DecomposeResult, error) {
+	result := &DecomposeResult{
+		Problem:     problem,
+		Subproblems: []Subproblem{},
+		Provider:    d.provider.Name(),
+	}
+
+	d.emitProgress(ProgressUpdate{Type: EventTypeProgress, Message: "Decomposing problem into sub-problems..."})
+	subproblems, err := d.decompose(ctx, problem)
+	if err != nil {
+		return nil, fmt.Errorf("decomposition failed: %w", err)
+	}
+	result.Decomposed = len(subproblems) > 1
+	d.emitProgress(ProgressUpdate{
+		Type:       EventTypeProgress,
+		Message:    fmt.Sprintf("Decomposed into %d sub-problems", len(subproblems)),
+		TotalNodes: len(subproblems),
+	})
+
+	for i := range subproblems {
+		sp := &subproblems[i]
+		d.emitProgress(ProgressUpdate{
+			Type:    EventTypeProgress,
+			NodeID:  fmt.Sprintf("s%d", sp.Number),
+			Message: fmt.Sprintf("Solving sub-problem %d/%d: %s", sp.Number, len(subproblems), utils.TruncateStr(sp.Question, 80)),
+			Depth:   sp.Number,
+		})
+
+		err := d.solveSubproblem(ctx, problem, subproblems[:i], sp)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			sp.Error = err.Error()
+		}
+		result.Subproblems = append(result.Subproblems, *sp)
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			// Return what was solved rather than failing every remaining step
+			result.TotalSubproblems = len(result.Subproblems)
+			return result, nil
+		}
+
+		d.emitProgress(ProgressUpdate{
+			Type:    EventTypeThought,
+			NodeID:  fmt.Sprintf("s%d", sp.Number),
+			Thought: utils.TruncateStr(sp.Answer, 100),
+			Depth:   sp.Number,
+		})
+	}
+	result.TotalSubproblems = len(result.Subproblems)
+
+	finalAnswer, err := d.compose(ctx, problem, result.Subproblems)
+	if err != nil {
+		return nil, fmt.Errorf("composing final answer failed: %w", err)
+	}
+	result.FinalAnswer = finalAnswer
+	result.Success = finalAnswer != ""
+
+	d.emitProgress(ProgressUpdate{
+		Type:        EventTypeSolution,
+		FinalAnswer: finalAnswer,
+		IsSolution:  result.Success,
+	})
+	return result, nil
+}
+
+// decompose asks for the ordered sub-problems. A response that cannot be
+// parsed falls back to solving the problem as a single step.
+func (d *DecomposeSolver) decompose(ctx context.Context, problem string) ([]Subproblem, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: decomposeSystemPrompt},
+		{Role: "user", Content: fmt.Sprintf("Problem:\n\n%s\n\nList at most %d sub-problems.", problem, d.config.MaxSubproblems)},
+	}
+	response, err := d.chat(ctx, messages, 1024)
+	if err != nil {
+		return nil, err
+	}
+
+	subproblems := parseSubproblems(response, d.config.MaxSubproblems)
+	if len(subproblems) == 0 {
+		fmt.Fprintf(os.Stderr, "[WARNING] decompose_solve: failed to parse decomposition, solving as a single step. Response preview: %s\n",
+			utils.TruncateStr(response, 100))
+		subproblems = []Subproblem{{Number: 1, Question: problem}}
+	}
+	return subproblems, nil
+}
+
+// parseSubproblems reads the decomposition, numbering sub-problems from 1 and
+// dropping dependencies that do not point at an earlier sub-problem
+func parseSubproblems(response string, limit int) []Subproblem {
+	var parsed struct {
+		Subproblems []struct {
+			Question  string `json:"question"`
+			DependsOn []int  `json:"depends_on"`
+		} `json:"subproblems"`
+	}
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr == "" || json.Unmarshal([]byte(jsonStr), &parsed) != nil {
+		return nil
+	}
+
+	var subproblems []Subproblem
+	for _, p := range parsed.Subproblems {
+		question := strings.TrimSpace(p.Question)
+		if question == "" {
+			continue
+		}
+		if len(subproblems) >= limit {
+			break
+		}
+		number := len(subproblems) + 1
+		var deps []int
+		for _, dep := range p.DependsOn {
+			if dep >= 1 && dep < number {
+				deps = append(deps, dep)
+			}
+		}
+		subproblems = append(subproblems, Subproblem{Number: number, Question: question, DependsOn: deps})
+	}
+	return subproblems
+}
+
+// solveSubproblem answers one sub-problem given the answers solved so far
+func (d *DecomposeSolver) solveSubproblem(ctx context.Context, problem string, solved []Subproblem, sp *Subproblem) error {
+	prompt := fmt.Sprintf("Original problem:\n%s\n\n", problem)
+	if len(solved) > 0 {
+		prompt += "Sub-problems solved so far:\n" + formatSolvedSubproblems(solved) + "\n"
+	}
+	if len(sp.DependsOn) > 0 {
+		prompt += fmt.Sprintf("This sub-problem builds on sub-problems %s.\n\n", joinInts(sp.DependsOn))
+	}
+	prompt += fmt.Sprintf(`Now solve sub-problem %d: %s
+
+Respond with ONLY a JSON object:
+{"reasoning": "<brief reasoning>", "answer": "<answer to this sub-problem>"}`, sp.Number, sp.Question)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You solve one sub-problem at a time, using the answers to earlier sub-problems."},
+		{Role: "user", Content: prompt},
+	}
+	response, err := d.chat(ctx, messages, 1024)
+	if err != nil {
+		return err
+	}
+
+	var step struct {
+		Reasoning string `json:"reasoning"`
+		Answer    string `json:"answer"`
+	}
+	if jsonStr := utils.ExtractJSON(response); jsonStr != "" && json.Unmarshal([]byte(jsonStr), &step) == nil && step.Answer != "" {
+		sp.Reasoning = strings.TrimSpace(step.Reasoning)
+		sp.Answer = strings.TrimSpace(step.Answer)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "[WARNING] decompose_solve: failed to extract JSON for sub-problem %d, using plain text. Response preview: %s\n",
+		sp.Number, utils.TruncateStr(response, 100))
+	sp.Answer = strings.TrimSpace(response)
+	return nil
+}
+
+// compose combines the sub-problem answers into the final answer
+func (d *DecomposeSolver) compose(ctx context.Context, problem string, solved []Subproblem) (string, error) {
+	// With a single step there is nothing to compose
+	if len(solved) == 1 && solved[0].Error == "" {
+		return solved[0].Answer, nil
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You compose a final answer from solved sub-problems. Be direct and complete."},
+		{Role: "user", Content: fmt.Sprintf("Original problem:\n%s\n\nSolved sub-problems:\n%s\nUsing these results, give the final answer to the original problem.",
+			problem, formatSolvedSubproblems(solved))},
+	}
+	response, err := d.chat(ctx, messages, 1024)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
+}
+
+func formatSolvedSubproblems(solved []Subproblem) string {
+	var b strings.Builder
+	for _, sp := range solved {
+		answer := sp.Answer
+		if sp.Error != "" {
+			answer = "(unsolved: " + sp.Error + ")"
+		}
+		b.WriteString(fmt.Sprintf("%d. %s\n   Answer: %s\n", sp.Number, sp.Question, answer))
+	}
+	return b.String()
+}
+
+func joinInts(nums []int) string {
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = fmt.Sprintf("%d", n)
+	}
+	return strings.Join(parts, ", ")
+}
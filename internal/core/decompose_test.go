@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// decomposeProvider answers the decomposition with a fixed plan, solves each
+// sub-problem with a numbered answer, and echoes the composition prompt
+type decomposeProvider struct {
+	mu            sync.Mutex
+	decomposition string
+	prompts       []string
+}
+
+func (p *decomposeProvider) Name() string { return "decompose" }
+
+func (p *decomposeProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	user := messages[len(messages)-1].Content
+	p.mu.Lock()
+	p.prompts = append(p.prompts, user)
+	n := len(p.prompts)
+	p.mu.Unlock()
+
+	switch {
+	case strings.Contains(messages[0].Content, "Least-to-Most"):
+		return p.decomposition, nil
+	case strings.Contains(user, "Now solve sub-problem"):
+		return fmt.Sprintf(`{"reasoning": "step", "answer": "answer-%d"}`, n-1), nil
+	default:
+		return "composed final answer", nil
+	}
+}
+
+func TestDecomposeSolver_FeedsAnswersForward(t *testing.T) {
+	provider := &decomposeProvider{decomposition: `{"subproblems": [
+		{"question": "How long is the trip?", "depends_on": []},
+		{"question": "When does it leave the station?", "depends_on": [1, 5]},
+		{"question": "When does it arrive?", "depends_on": [1, 2]}
+	]}`}
+
+	result, err := NewDecomposeSolver(provider, DefaultDecomposeConfig()).Solve(context.Background(), "When does the train arrive?")
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if !result.Decomposed || result.TotalSubproblems != 3 {
+		t.Fatalf("Expected 3 sub-problems, got %+v", result)
+	}
+	if deps := result.Subproblems[1].DependsOn; len(deps) != 1 || deps[0] != 1 {
+		t.Errorf("Expected forward dependency to be dropped, got %v", deps)
+	}
+	if result.Subproblems[2].Answer != "answer-3" {
+		t.Errorf("Unexpected sub-problem answer %q", result.Subproblems[2].Answer)
+	}
+	if result.FinalAnswer != "composed final answer" || !result.Success {
+		t.Errorf("Expected composed final answer, got %q", result.FinalAnswer)
+	}
+
+	// The third sub-problem must see both earlier answers
+	third := provider.prompts[3]
+	if !strings.Contains(third, "answer-1") || !strings.Contains(third, "answer-2") {
+		t.Errorf("Expected earlier answers fed forward, got prompt:\n%s", third)
+	}
+	compose := provider.prompts[len(provider.prompts)-1]
+	if !strings.Contains(compose, "answer-3") {
+		t.Errorf("Expected composition prompt to include every answer, got:\n%s", compose)
+	}
+}
+
+func TestDecomposeSolver_UnparseableDecomposition(t *testing.T) {
+	provider := &decomposeProvider{decomposition: "I would start by thinking about it."}
+
+	result, err := NewDecomposeSolver(provider, DefaultDecomposeConfig()).Solve(context.Background(), "What is 2+2?")
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if result.Decomposed || result.TotalSubproblems != 1 || result.Subproblems[0].Question != "What is 2+2?" {
+		t.Fatalf("Expected single-step fallback, got %+v", result)
+	}
+	if result.FinalAnswer != result.Subproblems[0].Answer {
+		t.Errorf("Expected single step answer to be final, got %q", result.FinalAnswer)
+	}
+}
+
+func TestParseSubproblems_Limit(t *testing.T) {
+	got := parseSubproblems(`{"subproblems": [{"question": "a"}, {"question": " "}, {"question": "b"}, {"question": "c"}]}`, 2)
+	if len(got) != 2 || got[0].Question != "a" || got[1].Question != "b" || got[1].Number != 2 {
+		t.Errorf("Unexpected sub-problems: %+v", got)
+	}
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Degraded Results ============
+//
+// When a strategy run fails after reaching its providers (outages, rate
+// limits, timeouts), the error is replaced by a degraded result: the closest
+// cached answer to a similar problem, reflexion's lessons for the problem,
+// and a failure report saying what broke and when to retry. The result is
+// still an error, so clients and the run history see the failure.
+
+// degradedSimilarity is the minimum word overlap for a cached result to be
+// offered for a different problem
+const degradedSimilarity = 0.5
+
+// DegradedResult replaces the error of a failed strategy run
+type DegradedResult struct {
+	Degraded     bool                 `json:"degraded"`
+	Tool         string               `json:"tool"`
+	Problem      string               `json:"problem"`
+	Error        string               `json:"error"`
+	CachedResult *CachedSimilarResult `json:"cached_result,omitempty"`
+	Lessons      []string             `json:"lessons,omitempty"` // From earlier failed attempts at similar problems
+	Failure      FailureReport        `json:"failure"`
+}
+
+// CachedSimilarResult is a cached result for a similar, possibly different, problem
+type CachedSimilarResult struct {
+	Source          string          `json:"source"` // tool_cache or semantic_cache
+	Similarity      float64         `json:"similarity"`
+	OriginalProblem string          `json:"original_problem,omitempty"`
+	CachedAt        time.Time       `json:"cached_at"`
+	Result          json.RawMessage `json:"result"`
+}
+
+// FailureReport says why a run failed and how to retry it
+type FailureReport struct {
+	Kind              string                 `json:"kind"` // rate_limited, provider_unavailable, timeout, budget_exhausted, context_window or error
+	LLMCalls          int                    `json:"llm_calls"`
+	Providers         []ProviderFailureState `json:"providers,omitempty"`
+	RetryAfterSeconds int                    `json:"retry_after_seconds,omitempty"`
+	Guidance          []string               `json:"guidance"`
+}
+
+// ProviderFailureState is a provider that is rate limited or has an open circuit
+type ProviderFailureState struct {
+	Provider         string     `json:"provider"`
+	Circuit          string     `json:"circuit,omitempty"`
+	RateLimitedUntil *time.Time `json:"rate_limited_until,omitempty"`
+}
+
+// degradedResultMiddleware turns failed strategy runs into degraded results.
+// Disable it with DEGRADED_RESULTS=false.
+func degradedResultMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || !result.IsError || !runHistoryTools[request.Params.Name] {
+			return result, err
+		}
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("DEGRADED_RESULTS"))) {
+		case "false", "0", "off":
+			return result, err
+		}
+		// Runs that failed before any LLM request (bad arguments, missing
+		// API keys) keep their plain error
+		run := queueRunFromContext(ctx)
+		if run == nil || run.llmCalls.Load() == 0 {
+			return result, err
+		}
+
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		problem, _ := args["problem"].(string)
+		namespace := defaultMemoryNamespace()
+		if ns, ok := args["memory_namespace"].(string); ok {
+			namespace, _ = parseMemoryNamespace(ns)
+		}
+		namespace = resolveMemoryNamespace(ctx, namespace)
+		degraded := buildDegradedResult(request.Params.Name, problem, namespace, resultText(result), int(run.llmCalls.Load()), time.Now())
+		output, marshalErr := json.MarshalIndent(degraded, "", "  ")
+		if marshalErr != nil {
+			return result, err
+		}
+		fmt.Fprintf(os.Stderr, "[DEGRADED] %s failed (%s); returning a degraded result\n", request.Params.Name, degraded.Failure.Kind)
+		return mcp.NewToolResultError(string(output)), nil
+	}
+}
+
+// resultText returns the first text content of a result
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
+
+// buildDegradedResult gathers what is still known about a failed run's
+// problem, with lessons from the memory namespace
+func buildDegradedResult(tool, problem, namespace, errText string, llmCalls int, now time.Time) *DegradedResult {
+	degraded := &DegradedResult{
+		Degraded: true,
+		Tool:     tool,
+		Problem:  problem,
+		Error:    errText,
+		Failure:  failureReport(errText, llmCalls, now),
+	}
+	if problem == "" {
+		return degraded
+	}
+
+	degraded.CachedResult = similarCachedResult(tool, problem)
+	degraded.Lessons = loadOrCreateMemory(DefaultReflexionConfig().MemoryPath).lessonsFor(problem, "", namespace)
+
+	if degraded.CachedResult != nil {
+		degraded.Failure.Guidance = append(degraded.Failure.Guidance,
+			"cached_result answers a similar problem; check that it applies before relying on it.")
+	}
+	if len(degraded.Lessons) > 0 {
+		degraded.Failure.Guidance = append(degraded.Failure.Guidance,
+			"lessons come from earlier failed attempts at similar problems and apply to a retry.")
+	}
+	return degraded
+}
+
+// similarCachedResult returns the closest cached result, preferring the
+// semantic cache, which is scoped to the tool
+func similarCachedResult(tool, problem string) *CachedSimilarResult {
+	if semantic := getSemanticCache(); semantic != nil {
+		if value, match, ok := semantic.Similar(tool, problem, degradedSimilarity); ok && json.Valid([]byte(value)) {
+			return &CachedSimilarResult{
+				Source:          "semantic_cache",
+				Similarity:      roundTo(match.Similarity, 3),
+				OriginalProblem: match.OriginalProblem,
+				CachedAt:        match.CachedAt,
+				Result:          json.RawMessage(value),
+			}
+		}
+	}
+	if cache := getToolCache(); cache != nil {
+		if value, score, cachedAt, ok := cache.Similar(problem, degradedSimilarity); ok && json.Valid([]byte(value)) {
+			original, _ := runResultFields(value)["problem"].(string)
+			return &CachedSimilarResult{
+				Source:          "tool_cache",
+				Similarity:      roundTo(score, 3),
+				OriginalProblem: original,
+				CachedAt:        cachedAt,
+				Result:          json.RawMessage(value),
+			}
+		}
+	}
+	return nil
+}
+
+// failureReport classifies a run's error and reports the providers that
+// are rate limited or have an open circuit
+func failureReport(errText string, llmCalls int, now time.Time) FailureReport {
+	report := FailureReport{Kind: failureKind(errText), LLMCalls: llmCalls}
+
+	states := make(map[string]*ProviderFailureState)
+	state := func(name string) *ProviderFailureState {
+		if s, ok := states[name]; ok {
+			return s
+		}
+		s := &ProviderFailureState{Provider: name}
+		states[name] = s
+		return s
+	}
+	var retryAfter time.Duration
+	for _, limit := range rateLimitStatuses() {
+		if limit.RateLimitedUntil != nil && limit.RateLimitedUntil.After(now) {
+			state(limit.Provider).RateLimitedUntil = limit.RateLimitedUntil
+			retryAfter = max(retryAfter, limit.RateLimitedUntil.Sub(now))
+		}
+	}
+	for name, cooldown := range openProviderCircuits(now) {
+		state(name).Circuit = providerCircuitState(name)
+		retryAfter = max(retryAfter, cooldown)
+	}
+	for _, s := range states {
+		report.Providers = append(report.Providers, *s)
+	}
+	sort.Slice(report.Providers, func(i, j int) bool { return report.Providers[i].Provider < report.Providers[j].Provider })
+	if retryAfter > 0 {
+		report.RetryAfterSeconds = int((retryAfter + time.Second - 1) / time.Second)
+	}
+
+	switch report.Kind {
+	case "rate_limited":
+		report.Guidance = append(report.Guidance, "Providers are rate limiting requests; wait retry_after_seconds, or pass fallback_providers to spread the load.")
+	case "provider_unavailable":
+		report.Guidance = append(report.Guidance, "The providers are failing; retry later or pass fallback_providers with another provider (list_providers shows circuit states).")
+	case "timeout":
+		report.Guidance = append(report.Guidance, "The run timed out; raise timeout_seconds, retry with a faster strategy such as sequential_thinking, or a smaller problem.")
+	case "budget_exhausted":
+		report.Guidance = append(report.Guidance, "The run used all of its LLM calls; raise max_llm_calls or simplify the problem.")
+	case "context_window":
+		report.Guidance = append(report.Guidance, "The run outgrew the model's context window; pass context_strategy truncate or summarize, or use a model with a larger window.")
+	default:
+		report.Guidance = append(report.Guidance, "Retry the run; if it keeps failing, try another strategy or provider.")
+	}
+	return report
+}
+
+// failureKind classifies a run's error text
+func failureKind(errText string) string {
+	text := strings.ToLower(errText)
+	switch {
+	case strings.Contains(text, "run timed out"):
+		return "timeout"
+	case strings.Contains(text, strings.ToLower(ErrLLMCallBudgetExhausted.Error())):
+		return "budget_exhausted"
+	case strings.Contains(text, ErrContextWindowExceeded.Error()):
+		return "context_window"
+	case strings.Contains(text, "status 429") || strings.Contains(text, "rate limited"):
+		return "rate_limited"
+	case strings.Contains(text, "deadline exceeded") || strings.Contains(text, "timeout") || strings.Contains(text, "timed out"):
+		return "timeout"
+	case strings.Contains(text, "all providers failed") || strings.Contains(text, "status 5") ||
+		strings.Contains(text, "connection refused") || strings.Contains(text, "request failed"):
+		return "provider_unavailable"
+	}
+	return "error"
+}
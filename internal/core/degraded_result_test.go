@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callToolError calls a tool that is expected to fail and returns its error text
+func callToolError(t *testing.T, s *server.MCPServer, name string, args map[string]interface{}) string {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+	raw, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": `+string(params)+`}`)))
+	var resp struct {
+		Result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Content) == 0 || !resp.Result.IsError {
+		t.Fatalf("%s: expected an error result, got %s", name, raw)
+	}
+	return resp.Result.Content[0].Text
+}
+
+func TestDegradedResultMiddleware(t *testing.T) {
+	fake := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t, degradedResultMiddleware)
+
+	toolCache = NewToolCache(time.Minute, 10)
+	toolCache.Set("earlier", `{"problem": "What is 17 * 23 exactly?", "final_answer": "391"}`)
+	memory := loadOrCreateMemory(os.Getenv("REFLEXION_MEMORY_PATH"))
+	memory.Episodes = append(memory.Episodes, Episode{
+		Problem:    "What is 17 * 23?",
+		Reflection: "Multiply the tens and units separately.",
+		Timestamp:  time.Now(),
+	})
+	memory.save()
+
+	fake.failNext(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	text := callToolError(t, s, "sequential_thinking", map[string]interface{}{"problem": "What is 17 * 23?"})
+	var degraded DegradedResult
+	if err := json.Unmarshal([]byte(text), &degraded); err != nil {
+		t.Fatalf("Expected a degraded result, got %s", text)
+	}
+	if !degraded.Degraded || degraded.Failure.Kind != "provider_unavailable" || degraded.Failure.LLMCalls != 3 {
+		t.Errorf("Unexpected failure report: %+v", degraded.Failure)
+	}
+	if degraded.CachedResult == nil || degraded.CachedResult.Source != "tool_cache" || !strings.Contains(string(degraded.CachedResult.Result), "391") {
+		t.Errorf("Expected the similar cached result, got %+v", degraded.CachedResult)
+	}
+	if len(degraded.Lessons) != 1 || len(degraded.Failure.Guidance) != 3 {
+		t.Errorf("Expected the lesson and guidance for each part, got %q and %q", degraded.Lessons, degraded.Failure.Guidance)
+	}
+
+	// Errors before any LLM request stay plain
+	if text := callToolError(t, s, "sequential_thinking", map[string]interface{}{}); strings.Contains(text, "degraded") {
+		t.Errorf("Expected a plain argument error, got %s", text)
+	}
+}
+
+func TestFailureKind(t *testing.T) {
+	for text, want := range map[string]string{
+		"Reasoning failed: LLM call budget exhausted (max_llm_calls=3)":   "budget_exhausted",
+		"request failed after retries: API error (status 429): slow down": "rate_limited",
+		"Reasoning failed: context deadline exceeded":                     "timeout",
+		"Reasoning failed: run timed out: LLM call budget exhausted":      "timeout",
+		"all providers failed: openai: API error (status 503): down":      "provider_unavailable",
+		"failed to parse response":                                        "error",
+	} {
+		if got := failureKind(text); got != want {
+			t.Errorf("failureKind(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
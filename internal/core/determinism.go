@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Deterministic Runs ============
+//
+// deterministic makes a strategy run reproducible for regression testing:
+// every LLM call of the run is sent with temperature 0, and with a sampling
+// seed to the providers that accept one (OpenAI-compatible APIs and
+// Ollama). GoT breaks every selection, merge and best-path tie by node ID,
+// so the search itself never depends on map order. The seed is reported in
+// the result; replaying a recorded run (LLM_REPLAY_DIR) with it gives the
+// same reasoning every time.
+
+// defaultDeterministicSeed is the seed of deterministic runs without one
+const defaultDeterministicSeed = 42
+
+type determinismKey struct{}
+
+var determinismArgSpecs = []argSpec{
+	{Name: "deterministic", Kind: argBool},
+	{Name: "seed", Kind: argInt, Min: 0, Max: noArgLimit},
+}
+
+func deterministicOption() mcp.ToolOption {
+	return mcp.WithBoolean("deterministic",
+		mcp.Description("Reproducible run: temperature 0 for every LLM call and a fixed sampling seed for providers that support one (default: false)"),
+	)
+}
+
+func seedOption() mcp.ToolOption {
+	return mcp.WithNumber("seed",
+		mcp.Description(fmt.Sprintf("Sampling seed of a deterministic run; implies deterministic unless it is false (default: %d)", defaultDeterministicSeed)),
+	)
+}
+
+// deterministicSeed returns the seed of a deterministic run, ok false for
+// other runs
+func deterministicSeed(ctx context.Context) (seed int, ok bool) {
+	seed, ok = ctx.Value(determinismKey{}).(int)
+	return seed, ok
+}
+
+// deterministicMiddleware validates the deterministic and seed arguments of
+// strategy calls and puts the seed of deterministic runs in the context for
+// WithDeterminism
+func deterministicMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		if !runHistoryTools[request.Params.Name] || args["deterministic"] == nil && args["seed"] == nil {
+			return next(ctx, request)
+		}
+		parsed, err := parseArgs(args, determinismArgSpecs)
+		if err != nil {
+			return toolErrorResult(err), nil
+		}
+		// A seed implies deterministic unless it is turned off explicitly
+		on := parsed.Has("seed")
+		if parsed.Has("deterministic") {
+			on = parsed.Bool("deterministic")
+		}
+		if !on {
+			return next(ctx, request)
+		}
+		seed := defaultDeterministicSeed
+		if parsed.Has("seed") {
+			seed = parsed.Int("seed")
+		}
+		return next(context.WithValue(ctx, determinismKey{}, seed), request)
+	}
+}
+
+// WithDeterminism sends the chat, stream and tool calls of deterministic
+// runs with temperature 0 and the run's seed
+func WithDeterminism() ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		if seed, ok := deterministicSeed(ctx); ok && call.Kind != CallEmbed {
+			call.Opts.Temperature = 0
+			call.Opts.Deterministic = true
+			call.Opts.Seed = seed
+		}
+		return next(ctx)
+	})
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeterministicRun(t *testing.T) {
+	fake := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t, deterministicMiddleware)
+	problem := "What is 17 * 23?"
+
+	result := callTool(t, s, "reflexion", map[string]interface{}{"problem": problem, "learn_from_past": false, "seed": 7})
+	if result["deterministic"] != true || result["seed"] != float64(7) {
+		t.Errorf("Expected the seed in the result, got deterministic=%v seed=%v", result["deterministic"], result["seed"])
+	}
+	requests := fake.received()
+	if len(requests) == 0 {
+		t.Fatal("Expected LLM calls")
+	}
+	for i, req := range requests {
+		if req.Temperature == nil || *req.Temperature != 0 || req.Seed == nil || *req.Seed != 7 {
+			t.Errorf("Request %d: expected temperature 0 and seed 7, got %v and %v", i, req.Temperature, req.Seed)
+		}
+	}
+
+	result = callTool(t, s, "graph_of_thoughts", map[string]interface{}{"problem": problem, "deterministic": true})
+	if result["seed"] != float64(defaultDeterministicSeed) {
+		t.Errorf("Expected the default seed, got %v", result["seed"])
+	}
+
+	before := len(fake.received())
+	result = callTool(t, s, "reflexion", map[string]interface{}{"problem": problem, "learn_from_past": false, "deterministic": false, "seed": 7})
+	if result["seed"] != nil {
+		t.Errorf("Expected deterministic false to win over the seed, got %v", result["seed"])
+	}
+	for _, req := range fake.received()[before:] {
+		if req.Seed != nil {
+			t.Fatalf("Expected no seed outside deterministic runs, got %d", *req.Seed)
+		}
+	}
+
+	text := callToolError(t, s, "reflexion", map[string]interface{}{"problem": problem, "seed": -1})
+	if !strings.Contains(text, errCodeInvalidArgument) || !strings.Contains(text, "seed") {
+		t.Errorf("Expected an invalid argument error, got %q", text)
+	}
+}
+
+func TestUCB1Policy_TiesIgnoreCandidateOrder(t *testing.T) {
+	g := policyGraph(GoTConfig{},
+		&GoTNode{ID: "root", Score: 0.5, Visits: 2},
+		&GoTNode{ID: "b", Depth: 1, Score: 0.5, Parents: []string{"root"}},
+		&GoTNode{ID: "a", Depth: 1, Score: 0.5, Parents: []string{"root"}},
+	)
+	for _, candidates := range [][]*GoTNode{{g.nodes["a"], g.nodes["b"]}, {g.nodes["b"], g.nodes["a"]}} {
+		if got := (ucb1Policy{}).Select(g, candidates); got.ID != "a" {
+			t.Errorf("Expected the tie to go to a, got %s", got.ID)
+		}
+	}
+}
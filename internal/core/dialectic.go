@@ -0,0 +1,1734 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"reasoning-tools/utils"
+)
+
+// DialecticalReasoner implements Debate + Chain of Verification
+type DialecticalReasoner struct {
+	ReasonerBase
+
+	provider    Provider
+	config      DialecticConfig
+	tools       *ToolRegistry
+	toolBudget  *ToolBudget
+	cacheMu     sync.Mutex              // Guards verifyCache and cacheHits; challenges verify concurrently
+	verifyCache map[string]Verification // Verifications by problem, claim type and claim hash, reset per run
+	cacheHits   int
+	calibration *calibrationRecorder
+	jsonRepair  *jsonRepairer
+	history     *historySummarizer
+	lessons     []string          // Past lessons for the first thesis
+	checkpoint  CheckpointHandler // Asked after each synthesis when Checkpoints is on
+	resume      *dialecticResume  // Earlier rounds to continue from
+	guidance    string            // Human guidance for the next round
+}
+
+// DialecticConfig configures the dialectical reasoning process
+type DialecticConfig struct {
+	MaxRounds        int      // Maximum debate rounds (default: 5)
+	NumChallengers   int      // Antitheses per round, each from a different perspective (default: 1, max: 5)
+	Rebuttals        bool     // Thesis answers each antithesis before the synthesis (default: false)
+	VerifyThreshold  float64  // Minimum verification score to accept (default: 0.7)
+	ConfidenceTarget float64  // Stop when synthesis reaches this confidence (default: 0.85)
+	Temperature      float64  // LLM temperature (default: 0.7)
+	MaxTokens        int      // Maximum tokens per LLM call (default: 1024)
+	FastMode         bool     // Run a single-pass dialectic (default: false)
+	ThesisModel      string   // Override model for thesis generation (optional)
+	AntithesisModel  string   // Override model for antithesis generation (optional)
+	SynthesisModel   string   // Override model for synthesis generation (optional)
+	EnableTools      bool     // Whether to use tools during verification (default: false)
+	MaxToolCalls     int      // Maximum tool calls total (default: 10)
+	EnabledTools     []string // Which tools to enable (empty = all)
+	// Per-phase caps within MaxToolCalls, keyed by "thesis", "antithesis", "rebuttal", "synthesis" or "claim" (empty = total only)
+	PhaseToolBudgets map[string]int
+	// Reuse verification results for claims repeated within a run (default: true)
+	CacheVerifications bool
+	// Stop before MaxRounds once the debate stalls (default: true)
+	EarlyStop bool
+	// Consecutive syntheses with at least this word overlap count as converged (default: 0.9)
+	StallSimilarity float64
+	// Stop after this many consecutive rounds without a confidence trend (default: 2)
+	PlateauRounds int
+	// List unresolved questions when the confidence target is not reached (default: true)
+	OpenQuestions bool
+	// Critic for claim verification (default: nil, the generator verifies its own claims)
+	Evaluator Provider
+	// Sample each verification score several times and use the mean (default: off; not used in fast mode)
+	Calibration CalibrationConfig
+	// Record the run in episodic memory and learn from past ones (default: off)
+	Memory SharedMemoryConfig
+	// Pause after each synthesis so a human can steer or stop the debate (default: false; not used in fast mode)
+	Checkpoints bool
+	// Summarize the older rounds shown to later ones past this many estimated tokens (default: 6000, 0 = never)
+	MaxHistoryTokens int
+}
+
+// DefaultDialecticConfig returns sensible defaults
+func DefaultDialecticConfig() DialecticConfig {
+	return DialecticConfig{
+		MaxRounds:        5,
+		NumChallengers:   1,
+		Rebuttals:        false,
+		VerifyThreshold:  0.7,
+		ConfidenceTarget: 0.85,
+		Temperature:      0.7,
+		MaxTokens:        1024,
+		FastMode:         false,
+		ThesisModel:      "",
+		AntithesisModel:  "",
+		SynthesisModel:   "",
+		EnableTools:      false,
+		MaxToolCalls:     10,
+		EnabledTools:     []string{},
+
+		CacheVerifications: true,
+		EarlyStop:          true,
+		StallSimilarity:    0.9,
+		PlateauRounds:      2,
+		OpenQuestions:      true,
+		MaxHistoryTokens:   defaultMaxHistoryTokens,
+	}
+}
+
+// DialecticStep represents one round of thesis-antithesis-synthesis
+type DialecticStep struct {
+	Round      int   `json:"round"`
+	Thesis     Claim `json:"thesis"`
+	Antithesis Claim `json:"antithesis"` // The first challenge
+	// Every challenge of the round with the thesis's rebuttal, when there are
+	// several challengers or rebuttals are on
+	Challenges []Challenge `json:"challenges,omitempty"`
+	Synthesis  Claim       `json:"synthesis"`
+	Resolved   bool        `json:"resolved"`
+	Guidance   string      `json:"guidance,omitempty"` // Human guidance given at the checkpoint before this round
+	Error      string      `json:"error,omitempty"`    // Why the round was cut short; its later stages are empty
+}
+
+// Challenge is one challenger's antithesis and the thesis's answer to it
+type Challenge struct {
+	Perspective string `json:"perspective"`
+	Antithesis  Claim  `json:"antithesis"`
+	Rebuttal    *Claim `json:"rebuttal,omitempty"`
+}
+
+// debateClaims returns the thesis, every antithesis and every rebuttal of the
+// round, in the order they were made
+func (s DialecticStep) debateClaims() []Claim {
+	if len(s.Challenges) == 0 {
+		return []Claim{s.Thesis, s.Antithesis}
+	}
+	claims := []Claim{s.Thesis}
+	for _, c := range s.Challenges {
+		claims = append(claims, c.Antithesis)
+		if c.Rebuttal != nil {
+			claims = append(claims, *c.Rebuttal)
+		}
+	}
+	return claims
+}
+
+// challengerPerspective is the angle one challenger argues from
+type challengerPerspective struct {
+	Name  string
+	Focus string // Prompt description; "" for the default devil's advocate
+}
+
+// challengerPerspectives are assigned to challengers in order
+var challengerPerspectives = []challengerPerspective{
+	{Name: "devil's advocate"},
+	{Name: "feasibility", Focus: "practical feasibility: costs, implementation obstacles and unintended consequences"},
+	{Name: "stakeholders", Focus: "the people affected: fairness, ethics and who bears the costs"},
+	{Name: "evidence", Focus: "the evidence: whether data and precedent actually support the claim"},
+	{Name: "long term", Focus: "the long term: second-order effects, incentives and how circumstances could change"},
+}
+
+// Claim represents a reasoned claim with verification
+type Claim struct {
+	Content      string       `json:"content"`
+	Verification Verification `json:"verification"`
+	Model        string       `json:"model,omitempty"`      // Provider/model that wrote the claim
+	Provenance   *Provenance  `json:"provenance,omitempty"` // Provider/models asked and answering
+}
+
+// VerificationStatus represents the explicit status of verification
+type VerificationStatus string
+
+const (
+	StatusVerified   VerificationStatus = "verified"   // Successfully verified
+	StatusUnverified VerificationStatus = "unverified" // Verification attempted but failed
+	StatusSkipped    VerificationStatus = "skipped"    // Verification not attempted
+)
+
+// Verification represents the result of verifying a claim
+type Verification struct {
+	IsValid     bool               `json:"is_valid"`
+	Score       float64            `json:"score"`                  // 0-1 confidence
+	Status      VerificationStatus `json:"status"`                 // Explicit verification status
+	Issues      []string           `json:"issues"`                 // Identified problems
+	Strengths   []string           `json:"strengths"`              // What's good about it
+	Suggestion  string             `json:"suggestion"`             // How to improve
+	ToolResults []ToolResult       `json:"tool_results,omitempty"` // Results from tool-based verification
+	ErrorReason string             `json:"error_reason,omitempty"` // Why verification failed (if applicable)
+	Cached      bool               `json:"cached,omitempty"`       // Reused from an earlier verification of the same claim
+	Calibration *ScoreSamples      `json:"calibration,omitempty"`  // Sampled scores behind Score
+}
+
+// DialecticResult represents the complete reasoning result
+type DialecticResult struct {
+	Problem         string          `json:"problem"`
+	Steps           []DialecticStep `json:"steps"`
+	FinalAnswer     string          `json:"final_answer"`
+	Confidence      float64         `json:"confidence"`
+	TotalRounds     int             `json:"total_rounds"`
+	TotalToolCalls  int             `json:"total_tool_calls,omitempty"`
+	VerifyCacheHits int             `json:"verify_cache_hits,omitempty"`
+	// Per-round synthesis scores and the running aggregate, to show convergence
+	ConfidenceTrajectory []RoundConfidence `json:"confidence_trajectory,omitempty"`
+	ConfidenceTrend      string            `json:"confidence_trend,omitempty"` // improving, stable or declining
+	StoppedReason        string            `json:"stopped_reason,omitempty"`   // resolved, converged, max_rounds, llm_call_budget_exhausted or provider_error
+	// What would unblock a confident answer, when the confidence target was not reached
+	OpenQuestions    []OpenQuestion     `json:"open_questions,omitempty"`
+	ToolCallsByPhase map[string]int     `json:"tool_calls_by_phase,omitempty"`
+	ToolsUsed        map[string]int     `json:"tools_used,omitempty"`
+	Success          bool               `json:"success"`
+	Error            string             `json:"error,omitempty"` // Provider failure that ended the debate early
+	Provider         string             `json:"provider"`
+	Evaluator        string             `json:"evaluator,omitempty"` // Set when a separate critic verified the claims
+	Language         string             `json:"language,omitempty"`  // Requested language of the reasoning and answer
+	LessonsLearned   []string           `json:"lessons_learned,omitempty"`
+	Calibration      *CalibrationReport `json:"calibration,omitempty"`
+	JSONRepairUsage
+	HistorySummaryUsage
+	// Set when the run stopped at a checkpoint for human input; pass
+	// ResumeToken back to dialectic_reason to continue
+	Paused      bool                 `json:"paused,omitempty"`
+	ResumeToken string               `json:"resume_token,omitempty"`
+	Checkpoint  *DialecticCheckpoint `json:"checkpoint,omitempty"`
+	LLMCallUsage
+}
+
+// OpenQuestion is an unresolved uncertainty left at the end of a debate
+type OpenQuestion struct {
+	Question          string `json:"question"`
+	NeededInfo        string `json:"needed_information,omitempty"` // What evidence or input would resolve it
+	Issue             string `json:"issue"`                        // The verifier issue it was derived from
+	Rounds            []int  `json:"rounds"`                       // Rounds in which the issue was raised
+	RaisedInSynthesis bool   `json:"raised_in_synthesis"`          // Still open against the final synthesis
+}
+
+// maxOpenQuestions caps how many unresolved issues are reported
+const maxOpenQuestions = 8
+
+// Reasons a dialectic run stopped
+const (
+	StopResolved  = "resolved"
+	StopConverged = "converged"
+	StopMaxRounds = "max_rounds"
+	StopLLMBudget = "llm_call_budget_exhausted"
+	StopPaused    = "paused"          // Waiting at a checkpoint for human input
+	StopByUser    = "stopped_by_user" // A human ended the debate at a checkpoint
+	StopProvider  = "provider_error"  // An LLM call failed; the rounds so far are kept
+)
+
+// RoundConfidence is one point of the confidence trajectory
+type RoundConfidence struct {
+	Round      int     `json:"round"`
+	Score      float64 `json:"score"`      // Synthesis verification score for this round
+	Weight     float64 `json:"weight"`     // Verification quality weight applied to the score
+	Aggregated float64 `json:"aggregated"` // Aggregated confidence through this round
+}
+
+// Confidence aggregation parameters
+const (
+	confidenceRecencyDecay = 0.6  // Weight multiplier per round of age
+	confidenceTrendEpsilon = 0.02 // Minimum change in aggregate to count as a trend
+)
+
+type fastPayload struct {
+	Thesis     string  `json:"thesis"`
+	Antithesis string  `json:"antithesis"`
+	Synthesis  string  `json:"synthesis"`
+	Confidence float64 `json:"confidence"`
+}
+
+// NewDialecticalReasoner creates a new dialectical reasoner
+func NewDialecticalReasoner(provider Provider, config DialecticConfig) *DialecticalReasoner {
+	d := &DialecticalReasoner{
+		provider: provider,
+		config:   config,
+	}
+	d.resetToolBudget()
+
+	// Initialize tools if enabled
+	if config.EnableTools {
+		d.tools = NewToolRegistry()
+		if len(config.EnabledTools) > 0 {
+			d.tools.SetEnabled(config.EnabledTools)
+		}
+	}
+
+	return d
+}
+
+// evaluator returns the provider that verifies claims
+func (d *DialecticalReasoner) evaluator() Provider {
+	if d.config.Evaluator != nil {
+		return d.config.Evaluator
+	}
+	return d.provider
+}
+
+// resetToolBudget starts a fresh tool budget with the configured phase caps
+func (d *DialecticalReasoner) resetToolBudget() {
+	d.toolBudget = NewToolBudget(d.config.MaxToolCalls)
+	for phase, limit := range d.config.PhaseToolBudgets {
+		d.toolBudget.SetPhaseLimit(phase, limit)
+	}
+}
+
+// Reason performs dialectical reasoning on a problem
+func (d *DialecticalReasoner) Reason(ctx context.Context, problem string) (result *DialecticResult, err error) {
+	// A compressed problem brings problem_lookup for reading the original
+	if src := problemSourceFromContext(ctx); src != nil {
+		d.tools = src.attach(d.tools)
+		d.config.EnableTools = true
+	}
+	if pad := scratchpadFromContext(ctx); pad != nil {
+		d.tools = pad.attach(d.tools)
+	}
+	memory := openSharedMemory(ctx, d.config.Memory)
+	d.lessons = memory.lessons(problem)
+	defer func() {
+		if err == nil && result != nil {
+			result.LessonsLearned = d.lessons
+			if !result.Paused {
+				memory.record(dialecticEpisode(result))
+			}
+		}
+	}()
+	if d.config.FastMode {
+		return d.reasonFast(ctx, problem)
+	}
+
+	result = &DialecticResult{
+		Problem:   problem,
+		Steps:     []DialecticStep{},
+		Provider:  d.provider.Name(),
+		Evaluator: providerName(d.config.Evaluator),
+		Language:  languageFromContext(ctx),
+		ToolsUsed: make(map[string]int),
+	}
+	d.resetToolBudget()
+	d.verifyCache = make(map[string]Verification)
+	d.cacheHits = 0
+	d.calibration = newCalibrationRecorder(d.config.Calibration)
+	d.jsonRepair = newJSONRepairer()
+	d.history = newHistorySummarizer(d.provider, d.config.MaxHistoryTokens)
+
+	var currentContext string
+	var lastSynthesis string
+	// Success is judged on the round whose synthesis is the final answer,
+	// not on the aggregate confidence
+	var answerScore float64
+	start := 1
+	if d.resume != nil {
+		result.Steps = append(result.Steps, d.resume.Steps...)
+		currentContext = d.buildContext(ctx, result.Steps)
+		lastSynthesis = result.Steps[len(result.Steps)-1].Synthesis.Content
+		start = len(result.Steps) + 1
+		for _, step := range result.Steps {
+			if step.Synthesis.Verification.Score >= d.config.VerifyThreshold {
+				result.FinalAnswer = step.Synthesis.Content
+				answerScore = step.Synthesis.Verification.Score
+			}
+		}
+		d.guidance = d.resume.Guidance
+		if d.resume.Stop {
+			result.StoppedReason = StopByUser
+		}
+	}
+
+	// failRound keeps the rounds so far when an LLM call of this one fails
+	failRound := func(step DialecticStep, err error) {
+		step.Error = err.Error()
+		result.Steps = append(result.Steps, step)
+		result.Error = err.Error()
+		result.StoppedReason = StopProvider
+	}
+
+rounds:
+	for round := start; round <= d.config.MaxRounds && result.StoppedReason == ""; round++ {
+		step := DialecticStep{Round: round, Guidance: d.guidance}
+
+		// === THESIS: Propose a solution/claim ===
+		thesisCtx, thesisBy := withModelCalls(ctx)
+		thesis, err := d.generateThesis(thesisCtx, problem, currentContext, lastSynthesis, step.Guidance)
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			result.StoppedReason = StopLLMBudget
+			break
+		}
+		if err != nil {
+			err = fmt.Errorf("thesis generation failed at round %d: %w", round, err)
+			if len(result.Steps) == 0 {
+				return result, err
+			}
+			failRound(step, err)
+			break
+		}
+
+		// Verify thesis
+		thesisVerification, err := d.verify(ctx, problem, thesis, "thesis")
+		if err != nil {
+			// Verification failed - mark as invalid to prevent incorrect conclusions
+			// Use Score: 0.5 as a neutral/unknown confidence indicator
+			thesisVerification = Verification{
+				IsValid:     false,
+				Score:       0.5,
+				Status:      StatusUnverified,
+				ErrorReason: fmt.Sprintf("verification error: %v", err),
+			}
+		}
+		step.Thesis = Claim{
+			Content:      thesis,
+			Verification: thesisVerification,
+			Model:        thesisBy.label(),
+			Provenance:   thesisBy.provenance(requestedModel(d.provider, d.config.ThesisModel)),
+		}
+
+		// === ANTITHESIS: Challenge the thesis, then let it answer ===
+		challenges, err := d.challengeThesis(ctx, problem, round, step.Thesis)
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			result.StoppedReason = StopLLMBudget
+			break
+		}
+		if err != nil {
+			failRound(step, err)
+			break
+		}
+		step.Antithesis = challenges[0].Antithesis
+		if len(challenges) > 1 || d.config.Rebuttals {
+			step.Challenges = challenges
+		}
+
+		// === SYNTHESIS: Resolve the debate ===
+		synthesisCtx, synthesisBy := withModelCalls(ctx)
+		synthesis, err := d.generateSynthesis(synthesisCtx, problem, step.Thesis, challenges, step.Guidance)
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			result.StoppedReason = StopLLMBudget
+			break
+		}
+		if err != nil {
+			failRound(step, fmt.Errorf("synthesis generation failed at round %d: %w", round, err))
+			break
+		}
+
+		// Verify synthesis
+		synthesisVerification, err := d.verify(ctx, problem, synthesis, "synthesis")
+		if err != nil {
+			// Verification failed - mark as invalid to prevent incorrect conclusions
+			synthesisVerification = Verification{
+				IsValid:     false,
+				Score:       0.5,
+				Status:      StatusUnverified,
+				ErrorReason: fmt.Sprintf("verification error: %v", err),
+			}
+		}
+		step.Synthesis = Claim{
+			Content:      synthesis,
+			Verification: synthesisVerification,
+			Model:        synthesisBy.label(),
+			Provenance:   synthesisBy.provenance(requestedModel(d.provider, d.config.SynthesisModel)),
+		}
+
+		// Check if we've reached resolution
+		step.Resolved = synthesisVerification.Score >= d.config.ConfidenceTarget &&
+			synthesisVerification.IsValid &&
+			len(synthesisVerification.Issues) == 0
+
+		result.Steps = append(result.Steps, step)
+		d.publish(RoundCompleted{Round: round, Score: synthesisVerification.Score, Resolved: step.Resolved})
+
+		// Update context for next round
+		currentContext = d.buildContext(ctx, result.Steps)
+		lastSynthesis = synthesis
+
+		// Check termination conditions
+		if step.Resolved {
+			result.FinalAnswer = synthesis
+			aggregateConfidence(result)
+			result.Success = synthesisVerification.Score >= d.config.VerifyThreshold
+			result.StoppedReason = StopResolved
+			result.TotalRounds = round
+			result.TotalToolCalls = d.toolBudget.Used()
+			result.ToolCallsByPhase = d.toolBudget.PhaseUsage()
+			result.VerifyCacheHits = d.cacheHits
+			result.Calibration = d.calibration.report()
+			result.JSONRepairUsage = d.jsonRepair.report()
+			result.HistorySummaryUsage = d.history.report()
+			d.countToolsUsed(result)
+			return result, nil
+		}
+
+		// If synthesis is good enough but not perfect, continue refining
+		if synthesisVerification.Score >= d.config.VerifyThreshold {
+			result.FinalAnswer = synthesis
+			answerScore = synthesisVerification.Score
+		}
+
+		// Stop early if further rounds are unlikely to change the outcome
+		if round < d.config.MaxRounds {
+			if why := d.stallReason(result); why != "" {
+				result.StoppedReason = StopConverged
+				d.emitProgress(ProgressUpdate{
+					Type:    "evaluation",
+					Message: fmt.Sprintf("Debate converged after %d rounds: %s", round, why),
+				})
+				break
+			}
+		}
+
+		// Let a human steer the next round
+		d.guidance = ""
+		if d.config.Checkpoints && d.checkpoint != nil && round < d.config.MaxRounds {
+			checkpoint := newDialecticCheckpoint(step, d.config.MaxRounds)
+			decision := d.checkpoint(ctx, checkpoint)
+			switch decision.Action {
+			case CheckpointPause:
+				result.Paused = true
+				result.Checkpoint = &checkpoint
+				result.StoppedReason = StopPaused
+				break rounds
+			case CheckpointStop:
+				result.StoppedReason = StopByUser
+				break rounds
+			}
+			d.guidance = strings.TrimSpace(decision.Guidance)
+		}
+	}
+
+	// Max rounds reached, debate converged, out of LLM calls, paused or failed
+	result.TotalRounds = len(result.Steps)
+	if result.StoppedReason == "" {
+		result.StoppedReason = StopMaxRounds
+	}
+	if result.FinalAnswer == "" {
+		for i := len(result.Steps) - 1; i >= 0; i-- {
+			if result.Steps[i].Error == "" {
+				result.FinalAnswer = result.Steps[i].Synthesis.Content
+				answerScore = result.Steps[i].Synthesis.Verification.Score
+				break
+			}
+		}
+	}
+	aggregateConfidence(result)
+	result.Success = answerScore >= d.config.VerifyThreshold && result.Error == ""
+	// Open questions explain a miss, so a run that reached the confidence
+	// target spends no call on them
+	if d.config.OpenQuestions && !result.Paused && result.Error == "" && result.Confidence < d.config.ConfidenceTarget {
+		result.OpenQuestions = d.extractOpenQuestions(ctx, problem, result)
+	}
+	result.TotalToolCalls = d.toolBudget.Used()
+	result.ToolCallsByPhase = d.toolBudget.PhaseUsage()
+	result.VerifyCacheHits = d.cacheHits
+	result.Calibration = d.calibration.report()
+	result.JSONRepairUsage = d.jsonRepair.report()
+	result.HistorySummaryUsage = d.history.report()
+	d.countToolsUsed(result)
+
+	return result, nil
+}
+
+func (d *DialecticalReasoner) reasonFast(ctx context.Context, problem string) (*DialecticResult, error) {
+	result := &DialecticResult{
+		Problem:   problem,
+		Steps:     []DialecticStep{},
+		Provider:  d.provider.Name(),
+		Language:  languageFromContext(ctx),
+		ToolsUsed: make(map[string]int),
+	}
+
+	d.emitProgress(ProgressUpdate{
+		Type:    "thought",
+		Message: "Generating fast dialectic response...",
+	})
+
+	prompt := fmt.Sprintf(`You are a careful dialectical reasoner.
+
+Problem: %s
+
+%sProvide a concise thesis, antithesis, and synthesis. Respond with ONLY a JSON object:
+{
+  "thesis": "string",
+  "antithesis": "string",
+  "synthesis": "string",
+  "confidence": 0.0
+}
+
+Confidence is your 0-1 confidence in the synthesis. Keep each field concise.
+
+If you cannot produce JSON, respond in plain text using EXACT labels:
+THESIS: ...
+ANTITHESIS: ...
+SYNTHESIS: ...
+CONFIDENCE: 0.0`, problem, lessonsSection(d.lessons))
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You produce clean JSON and concise dialectical analysis."},
+		{Role: "user", Content: prompt},
+	}
+
+	var response string
+	var err error
+	callCtx, writtenBy := withModelCalls(ctx)
+	response, err = d.callProvider(callCtx, d.provider, messages, ChatOptions{
+		Temperature: clampTemperature(d.config.Temperature),
+		MaxTokens:   d.config.MaxTokens,
+	}, d.enableStreams)
+	if err != nil {
+		return result, err
+	}
+	model, provenance := writtenBy.label(), writtenBy.provenance(requestedModel(d.provider, ""))
+
+	jsonStr := utils.ExtractJSON(response)
+	var payload fastPayload
+	if jsonStr != "" {
+		if err := json.Unmarshal([]byte(jsonStr), &payload); err != nil {
+			return result, fmt.Errorf("failed to parse fast dialectic response: %w", err)
+		}
+	} else {
+		var ok bool
+		payload, ok = parseFastDialecticText(response)
+		if !ok {
+			return result, fmt.Errorf("failed to parse fast dialectic response")
+		}
+	}
+
+	confidence := payload.Confidence
+	if confidence < 0 {
+		confidence = 0
+	} else if confidence > 1 {
+		confidence = 1
+	}
+
+	step := DialecticStep{
+		Round: 1,
+		Thesis: Claim{
+			Content:    payload.Thesis,
+			Model:      model,
+			Provenance: provenance,
+			Verification: Verification{
+				IsValid: true,
+				Score:   0.5,
+				Status:  StatusSkipped,
+			},
+		},
+		Antithesis: Claim{
+			Content:    payload.Antithesis,
+			Model:      model,
+			Provenance: provenance,
+			Verification: Verification{
+				IsValid: true,
+				Score:   0.5,
+				Status:  StatusSkipped,
+			},
+		},
+		Synthesis: Claim{
+			Content:    payload.Synthesis,
+			Model:      model,
+			Provenance: provenance,
+			Verification: Verification{
+				IsValid: true,
+				Score:   confidence,
+				Status:  StatusSkipped,
+			},
+		},
+		Resolved: confidence >= d.config.VerifyThreshold,
+	}
+
+	result.Steps = append(result.Steps, step)
+	result.TotalRounds = 1
+	result.FinalAnswer = payload.Synthesis
+	aggregateConfidence(result)
+	result.Success = confidence >= d.config.VerifyThreshold
+
+	d.emitProgress(ProgressUpdate{
+		Type:        "solution",
+		Message:     "Fast dialectic response complete",
+		IsSolution:  true,
+		FinalAnswer: result.FinalAnswer,
+	})
+
+	return result, nil
+}
+
+func parseFastDialecticText(response string) (fastPayload, bool) {
+	response = strings.ReplaceAll(response, "\r\n", "\n")
+	response = strings.ReplaceAll(response, "\r", "\n")
+
+	sections := map[string][]string{}
+	current := ""
+
+	normalizeLine := func(line string) string {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*• \t")
+		return strings.TrimSpace(line)
+	}
+
+	detectLabel := func(line string) string {
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "thesis"):
+			return "thesis"
+		case strings.HasPrefix(lower, "antithesis"), strings.HasPrefix(lower, "anti-thesis"):
+			return "antithesis"
+		case strings.HasPrefix(lower, "synthesis"):
+			return "synthesis"
+		case strings.HasPrefix(lower, "confidence"):
+			return "confidence"
+		default:
+			return ""
+		}
+	}
+
+	lines := strings.Split(response, "\n")
+	for _, raw := range lines {
+		line := normalizeLine(raw)
+		if line == "" {
+			continue
+		}
+
+		if label := detectLabel(line); label != "" {
+			remainder := strings.TrimSpace(line[len(label):])
+			remainder = strings.TrimLeft(remainder, ":-–— \t")
+			current = label
+			if remainder != "" {
+				sections[label] = append(sections[label], remainder)
+			}
+			continue
+		}
+
+		if current != "" {
+			sections[current] = append(sections[current], line)
+		}
+	}
+
+	clean := func(parts []string) string {
+		if len(parts) == 0 {
+			return ""
+		}
+		combined := strings.Join(parts, " ")
+		combined = strings.TrimSpace(combined)
+		if strings.HasPrefix(combined, "1.") || strings.HasPrefix(combined, "2.") || strings.HasPrefix(combined, "3.") {
+			combined = strings.TrimSpace(strings.TrimLeft(combined[2:], " "))
+		}
+		return strings.TrimSpace(combined)
+	}
+
+	thesis := clean(sections["thesis"])
+	antithesis := clean(sections["antithesis"])
+	synthesis := clean(sections["synthesis"])
+
+	confidence := 0.0
+	confText := clean(sections["confidence"])
+	if confText == "" {
+		confText = response
+	}
+	confRe := regexp.MustCompile(`(?is)(?:^|\n)\s*confidence\s*[:\-]?\s*([0-9]*\.?[0-9]+)`)
+	if match := confRe.FindStringSubmatch(confText); len(match) > 1 {
+		if val, err := strconv.ParseFloat(match[1], 64); err == nil {
+			confidence = val
+		}
+	}
+
+	if thesis == "" || antithesis == "" || synthesis == "" {
+		var fallback []string
+		for _, raw := range lines {
+			line := normalizeLine(raw)
+			if line == "" {
+				continue
+			}
+			fallback = append(fallback, line)
+		}
+		if len(fallback) >= 3 {
+			if thesis == "" {
+				thesis = fallback[0]
+			}
+			if antithesis == "" {
+				antithesis = fallback[1]
+			}
+			if synthesis == "" {
+				synthesis = fallback[2]
+			}
+		}
+	}
+
+	if thesis == "" || antithesis == "" || synthesis == "" {
+		return fastPayload{}, false
+	}
+
+	return fastPayload{
+		Thesis:     thesis,
+		Antithesis: antithesis,
+		Synthesis:  synthesis,
+		Confidence: confidence,
+	}, true
+}
+
+// remainingIssues collects the issues still standing at the end of a debate:
+// those raised against the final synthesis, then any raised in more than one
+// round, deduplicated by normalized text
+func remainingIssues(steps []DialecticStep) []OpenQuestion {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	type tracked struct {
+		q     OpenQuestion
+		order int
+	}
+	byKey := make(map[string]*tracked)
+	add := func(issue string, round int, inSynthesis bool) {
+		issue = strings.TrimSpace(issue)
+		if issue == "" {
+			return
+		}
+		key := strings.Join(normalizedWords(issue), " ")
+		t, ok := byKey[key]
+		if !ok {
+			t = &tracked{q: OpenQuestion{Issue: issue}, order: len(byKey)}
+			byKey[key] = t
+		}
+		if n := len(t.q.Rounds); n == 0 || t.q.Rounds[n-1] != round {
+			t.q.Rounds = append(t.q.Rounds, round)
+		}
+		t.q.RaisedInSynthesis = t.q.RaisedInSynthesis || inSynthesis
+	}
+
+	last := len(steps) - 1
+	for i, step := range steps {
+		for _, claim := range step.debateClaims() {
+			for _, issue := range claim.Verification.Issues {
+				add(issue, step.Round, false)
+			}
+		}
+		for _, issue := range step.Synthesis.Verification.Issues {
+			add(issue, step.Round, i == last)
+		}
+	}
+
+	var open []*tracked
+	for _, t := range byKey {
+		if t.q.RaisedInSynthesis || len(t.q.Rounds) > 1 {
+			open = append(open, t)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		if open[i].q.RaisedInSynthesis != open[j].q.RaisedInSynthesis {
+			return open[i].q.RaisedInSynthesis
+		}
+		if len(open[i].q.Rounds) != len(open[j].q.Rounds) {
+			return len(open[i].q.Rounds) > len(open[j].q.Rounds)
+		}
+		return open[i].order < open[j].order
+	})
+
+	questions := make([]OpenQuestion, 0, min(len(open), maxOpenQuestions))
+	for _, t := range open {
+		if len(questions) == maxOpenQuestions {
+			break
+		}
+		questions = append(questions, t.q)
+	}
+	return questions
+}
+
+// extractOpenQuestions turns the remaining issues into concrete questions and
+// the information needed to answer them. If the LLM call fails the issues are
+// returned as-is so callers still see what is unresolved.
+func (d *DialecticalReasoner) extractOpenQuestions(ctx context.Context, problem string, result *DialecticResult) []OpenQuestion {
+	questions := remainingIssues(result.Steps)
+	if len(questions) == 0 {
+		return nil
+	}
+	for i := range questions {
+		questions[i].Question = questions[i].Issue
+	}
+
+	var issues []string
+	for i, q := range questions {
+		issues = append(issues, fmt.Sprintf("%d. %s", i+1, q.Issue))
+	}
+	prompt := fmt.Sprintf(`A debate on this problem ended without reaching a confident answer.
+
+Problem: %s
+
+Best answer so far: %s
+
+Unresolved issues:
+%s
+
+For each issue, write the specific open question that must be answered and the information that would answer it. Respond with ONLY a JSON array in the same order:
+[{"question": "...", "needed_information": "..."}]`, problem, result.FinalAnswer, strings.Join(issues, "\n"))
+
+	response, err := d.provider.Chat(ctx, []ChatMessage{
+		{Role: "user", Content: prompt},
+	}, ChatOptions{
+		Temperature: clampTemperature(0.2),
+		MaxTokens:   d.config.MaxTokens,
+	})
+	if err != nil {
+		return questions
+	}
+
+	var parsed []struct {
+		Question   string `json:"question"`
+		NeededInfo string `json:"needed_information"`
+	}
+	if err := json.Unmarshal([]byte(utils.ExtractJSONArray(response)), &parsed); err != nil {
+		return questions
+	}
+	for i := range questions {
+		if i >= len(parsed) {
+			break
+		}
+		if q := strings.TrimSpace(parsed[i].Question); q != "" {
+			questions[i].Question = q
+		}
+		questions[i].NeededInfo = strings.TrimSpace(parsed[i].NeededInfo)
+	}
+	return questions
+}
+
+// stallReason reports why the debate has stalled, or "" if it is still
+// moving: either the last two syntheses say nearly the same thing, or the
+// aggregated confidence has not trended for PlateauRounds rounds.
+func (d *DialecticalReasoner) stallReason(result *DialecticResult) string {
+	if !d.config.EarlyStop || len(result.Steps) < 2 {
+		return ""
+	}
+
+	n := len(result.Steps)
+	prev := result.Steps[n-2].Synthesis.Content
+	cur := result.Steps[n-1].Synthesis.Content
+	if d.config.StallSimilarity > 0 {
+		if overlap := lexicalOverlap(prev, cur); overlap >= d.config.StallSimilarity {
+			return fmt.Sprintf("consecutive syntheses are %.0f%% identical", overlap*100)
+		}
+	}
+
+	if d.config.PlateauRounds > 0 {
+		aggregateConfidence(result)
+		traj := result.ConfidenceTrajectory
+		if len(traj) <= d.config.PlateauRounds {
+			return ""
+		}
+		for i := len(traj) - d.config.PlateauRounds; i < len(traj); i++ {
+			if math.Abs(traj[i].Aggregated-traj[i-1].Aggregated) > confidenceTrendEpsilon {
+				return ""
+			}
+		}
+		return fmt.Sprintf("confidence plateaued at %.2f for %d rounds", traj[len(traj)-1].Aggregated, d.config.PlateauRounds)
+	}
+	return ""
+}
+
+// verificationQuality weights a verification score by how much it can be
+// trusted: a completed verification counts fully, a skipped one (fast mode)
+// half, and a failed verification call barely at all.
+func verificationQuality(v Verification) float64 {
+	switch v.Status {
+	case StatusUnverified:
+		return 0.2
+	case StatusSkipped:
+		return 0.5
+	default:
+		return 1.0
+	}
+}
+
+// aggregateConfidence sets result.Confidence from all rounds instead of only
+// the last one. Each round's synthesis score is weighted by verification
+// quality and by recency (decaying by confidenceRecencyDecay per round), so
+// one noisy round moves the estimate less than a sustained change. The
+// running aggregate is recorded per round in ConfidenceTrajectory.
+func aggregateConfidence(result *DialecticResult) {
+	result.ConfidenceTrajectory = make([]RoundConfidence, 0, len(result.Steps))
+	result.ConfidenceTrend = ""
+
+	var weighted, total float64
+	for _, step := range result.Steps {
+		if step.Error != "" {
+			continue // Never reached a synthesis
+		}
+		v := step.Synthesis.Verification
+		w := verificationQuality(v)
+
+		// Age existing rounds by one step before adding this one
+		weighted = weighted*confidenceRecencyDecay + w*v.Score
+		total = total*confidenceRecencyDecay + w
+
+		result.ConfidenceTrajectory = append(result.ConfidenceTrajectory, RoundConfidence{
+			Round:      step.Round,
+			Score:      v.Score,
+			Weight:     w,
+			Aggregated: weighted / total,
+		})
+	}
+
+	n := len(result.ConfidenceTrajectory)
+	if n == 0 {
+		result.Confidence = 0
+		return
+	}
+	result.Confidence = result.ConfidenceTrajectory[n-1].Aggregated
+
+	if n >= 2 {
+		delta := result.ConfidenceTrajectory[n-1].Aggregated - result.ConfidenceTrajectory[n-2].Aggregated
+		switch {
+		case delta > confidenceTrendEpsilon:
+			result.ConfidenceTrend = "improving"
+		case delta < -confidenceTrendEpsilon:
+			result.ConfidenceTrend = "declining"
+		default:
+			result.ConfidenceTrend = "stable"
+		}
+	}
+}
+
+// countToolsUsed counts which tools were used. Cached verifications carry the
+// tool results of the original call and are not counted again.
+func (d *DialecticalReasoner) countToolsUsed(result *DialecticResult) {
+	for _, step := range result.Steps {
+		for _, claim := range append(step.debateClaims(), step.Synthesis) {
+			if claim.Verification.Cached {
+				continue
+			}
+			for _, tr := range claim.Verification.ToolResults {
+				result.ToolsUsed[tr.Tool]++
+			}
+		}
+	}
+}
+
+// generateThesis proposes an initial solution or builds on previous synthesis
+func (d *DialecticalReasoner) generateThesis(ctx context.Context, problem, context, lastSynthesis, guidance string) (string, error) {
+	var prompt string
+	if lastSynthesis == "" {
+		prompt = fmt.Sprintf(`Problem: %s
+
+%sPropose a clear thesis (claim or solution). Be specific and concise.
+
+IMPORTANT: Output ONLY your thesis statement. Do NOT include:
+- Numbered analysis steps
+- "Let me think..." or similar phrases
+- Bullet points breaking down the problem
+- Meta-commentary about your reasoning process
+
+Your response should be 1-3 sentences containing just the thesis itself.`, problem, lessonsSection(d.lessons))
+	} else {
+		prompt = fmt.Sprintf(`Problem: %s
+
+Previous synthesis: %s
+
+%sPropose a refined thesis that advances the reasoning.
+
+IMPORTANT: Output ONLY your thesis statement (1-3 sentences). No analysis steps, no meta-commentary.`, problem, lastSynthesis, guidanceSection(guidance))
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a precise, analytical thinker. Propose clear, defensible claims."},
+		{Role: "user", Content: prompt},
+	}
+
+	result, err := d.callProvider(ctx, d.provider, messages, ChatOptions{
+		Temperature: clampTemperature(d.config.Temperature),
+		MaxTokens:   d.config.MaxTokens,
+		Model:       d.config.ThesisModel,
+	}, d.enableStreams)
+	return utils.StripChainOfThought(result), err
+}
+
+// challengeThesis runs the round's challengers against the thesis, each
+// verified, with the thesis's verified rebuttal to each when rebuttals are on
+func (d *DialecticalReasoner) challengeThesis(ctx context.Context, problem string, round int, thesis Claim) ([]Challenge, error) {
+	n := min(max(d.config.NumChallengers, 1), len(challengerPerspectives))
+	challenges := make([]Challenge, 0, n)
+	var earlier []string
+	for i := 0; i < n; i++ {
+		perspective := challengerPerspectives[i]
+		antithesisCtx, antithesisBy := withModelCalls(ctx)
+		antithesis, err := d.generateAntithesis(antithesisCtx, problem, thesis.Content, thesis.Verification, perspective, earlier)
+		if err != nil {
+			return nil, fmt.Errorf("antithesis generation failed at round %d: %w", round, err)
+		}
+		earlier = append(earlier, antithesis)
+
+		// Verify antithesis
+		antithesisVerification, err := d.verify(ctx, problem, antithesis, "antithesis")
+		if err != nil {
+			// Verification failed - mark as invalid to prevent incorrect conclusions
+			antithesisVerification = Verification{
+				IsValid:     false,
+				Score:       0.5,
+				Status:      StatusUnverified,
+				ErrorReason: fmt.Sprintf("verification error: %v", err),
+			}
+		}
+		challenge := Challenge{
+			Perspective: perspective.Name,
+			Antithesis: Claim{
+				Content:      antithesis,
+				Verification: antithesisVerification,
+				Model:        antithesisBy.label(),
+				Provenance:   antithesisBy.provenance(requestedModel(d.provider, d.config.AntithesisModel)),
+			},
+		}
+
+		if d.config.Rebuttals {
+			rebuttalCtx, rebuttalBy := withModelCalls(ctx)
+			rebuttal, err := d.generateRebuttal(rebuttalCtx, problem, thesis.Content, antithesis)
+			if err != nil {
+				return nil, fmt.Errorf("rebuttal generation failed at round %d: %w", round, err)
+			}
+			rebuttalVerification, err := d.verify(ctx, problem, rebuttal, "rebuttal")
+			if err != nil {
+				rebuttalVerification = Verification{
+					IsValid:     false,
+					Score:       0.5,
+					Status:      StatusUnverified,
+					ErrorReason: fmt.Sprintf("verification error: %v", err),
+				}
+			}
+			challenge.Rebuttal = &Claim{
+				Content:      rebuttal,
+				Verification: rebuttalVerification,
+				Model:        rebuttalBy.label(),
+				Provenance:   rebuttalBy.provenance(requestedModel(d.provider, d.config.ThesisModel)),
+			}
+		}
+		challenges = append(challenges, challenge)
+	}
+	return challenges, nil
+}
+
+// generateAntithesis challenges the thesis from perspective, making a point
+// the earlier challengers of the round did not
+func (d *DialecticalReasoner) generateAntithesis(ctx context.Context, problem, thesis string, thesisVerification Verification, perspective challengerPerspective, earlier []string) (string, error) {
+	issuesContext := ""
+	if len(thesisVerification.Issues) > 0 {
+		issuesContext = fmt.Sprintf("\nKnown issues: %s", strings.Join(thesisVerification.Issues, "; "))
+	}
+	angle := "Identify weaknesses or alternative perspectives."
+	if perspective.Focus != "" {
+		angle = fmt.Sprintf("Argue from %s.", perspective.Focus)
+	}
+	if len(earlier) > 0 {
+		issuesContext += fmt.Sprintf("\n\nOther challengers already argued:\n- %s\nMake a different point.", strings.Join(earlier, "\n- "))
+	}
+
+	prompt := fmt.Sprintf(`Problem: %s
+
+Thesis: %s%s
+
+Challenge this thesis with a strong counterargument. %s
+
+IMPORTANT: Output ONLY your antithesis statement (1-3 sentences). Do NOT include:
+- Numbered analysis steps like "1. Analyze..." or "**Step 1:**"
+- Meta-commentary about your reasoning process
+- Bullet points breaking down the problem
+
+Just state your counterargument directly.`, problem, thesis, issuesContext, angle)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a devil's advocate. Challenge ideas rigorously but fairly. Find real flaws, not nitpicks."},
+		{Role: "user", Content: prompt},
+	}
+
+	result, err := d.callProvider(ctx, d.provider, messages, ChatOptions{
+		Temperature: clampTemperature(d.config.Temperature + 0.1), // Slightly higher for creativity
+		MaxTokens:   d.config.MaxTokens,
+		Model:       d.config.AntithesisModel,
+	}, d.enableStreams)
+	return utils.StripChainOfThought(result), err
+}
+
+// generateRebuttal defends the thesis against one antithesis
+func (d *DialecticalReasoner) generateRebuttal(ctx context.Context, problem, thesis, antithesis string) (string, error) {
+	prompt := fmt.Sprintf(`Problem: %s
+
+Your thesis: %s
+
+Challenge: %s
+
+Defend your thesis against this challenge. Concede any point that is right and explain how the thesis still holds or how it must be qualified.
+
+IMPORTANT: Output ONLY your rebuttal (1-3 sentences). No analysis steps, no meta-commentary.`, problem, thesis, antithesis)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are the thesis's advocate. Defend it honestly: rebut weak objections and concede valid ones."},
+		{Role: "user", Content: prompt},
+	}
+
+	result, err := d.callProvider(ctx, d.provider, messages, ChatOptions{
+		Temperature: clampTemperature(d.config.Temperature),
+		MaxTokens:   d.config.MaxTokens,
+		Model:       d.config.ThesisModel,
+	}, d.enableStreams)
+	return utils.StripChainOfThought(result), err
+}
+
+// debatePositions lays out the thesis's challenges for the synthesis prompt
+func debatePositions(challenges []Challenge) string {
+	if len(challenges) == 1 && challenges[0].Rebuttal == nil {
+		return "ANTITHESIS: " + challenges[0].Antithesis.Content
+	}
+	var parts []string
+	for i, c := range challenges {
+		part := fmt.Sprintf("ANTITHESIS %d (%s): %s", i+1, c.Perspective, c.Antithesis.Content)
+		if c.Rebuttal != nil {
+			part += fmt.Sprintf("\nREBUTTAL %d: %s", i+1, c.Rebuttal.Content)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// guidanceSection is the prompt paragraph carrying a human's steer for the
+// round, ending in a blank line ("" for none)
+func guidanceSection(guidance string) string {
+	if guidance == "" {
+		return ""
+	}
+	return fmt.Sprintf("Guidance from the person overseeing this debate (follow it): %s\n\n", guidance)
+}
+
+// generateSynthesis resolves the thesis and its challenges
+func (d *DialecticalReasoner) generateSynthesis(ctx context.Context, problem string, thesis Claim, challenges []Challenge, guidance string) (string, error) {
+	sides := "both sides"
+	if len(challenges) > 1 || challenges[0].Rebuttal != nil {
+		sides = "every position, weighing each challenge against the thesis's rebuttal where there is one"
+	}
+	prompt := fmt.Sprintf(`Problem: %s
+
+THESIS: %s
+
+%s
+
+%sSynthesize these positions into a balanced conclusion that integrates valid points from %s.
+
+IMPORTANT: Output ONLY your synthesis statement (2-4 sentences). Do NOT include:
+- Numbered analysis steps like "1. Analyze..." or "**Step 1:**"
+- Meta-commentary or reasoning process
+- Bullet points breaking down the argument
+
+Just provide your synthesized conclusion directly.`,
+		problem, thesis.Content, debatePositions(challenges), guidanceSection(guidance), sides)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a balanced synthesizer. Find truth by integrating opposing views. Produce clear, actionable conclusions."},
+		{Role: "user", Content: prompt},
+	}
+
+	result, err := d.callProvider(ctx, d.provider, messages, ChatOptions{
+		Temperature: clampTemperature(d.config.Temperature - 0.1), // Slightly lower for precision
+		MaxTokens:   d.config.MaxTokens,
+		Model:       d.config.SynthesisModel,
+	}, d.enableStreams)
+	return utils.StripChainOfThought(result), err
+}
+
+// verify checks if a claim is valid and identifies issues
+func (d *DialecticalReasoner) verify(ctx context.Context, problem, claim, claimType string) (Verification, error) {
+	key := claimCacheKey(problem, claimType, claim)
+	if d.config.CacheVerifications {
+		d.cacheMu.Lock()
+		cached, ok := d.verifyCache[key]
+		if ok {
+			d.cacheHits++
+		}
+		d.cacheMu.Unlock()
+		if ok {
+			cached.Cached = true
+			return cached, nil
+		}
+	}
+
+	var toolResults []ToolResult
+
+	// If tools enabled, first ask what to verify and use tools
+	if d.config.EnableTools && d.tools != nil && d.toolBudget.Available(claimType) {
+		toolResults = d.gatherToolEvidence(ctx, problem, claim, claimType)
+	}
+
+	// Build verification prompt (with or without tool evidence)
+	toolContext := ""
+	if len(toolResults) > 0 {
+		var evidence []string
+		for _, tr := range toolResults {
+			if tr.Success {
+				evidence = append(evidence, fmt.Sprintf("- [%s] %s", tr.Tool, utils.TruncateStr(tr.Output, 200)))
+			}
+		}
+		if len(evidence) > 0 {
+			toolContext = fmt.Sprintf("\n\nTool-gathered evidence:\n%s\n", strings.Join(evidence, "\n"))
+		}
+	}
+
+	prompt := fmt.Sprintf(`You are a rigorous verifier. Evaluate this %s for the given problem.
+
+Problem: %s
+
+%s to verify:
+%s%s
+
+Analyze this claim and respond with ONLY a JSON object:
+{
+  "is_valid": <true if the reasoning is sound, false if fundamentally flawed>,
+  "score": <0.0 to 1.0 confidence score>,
+  "issues": ["list of specific problems, gaps, or weaknesses"],
+  "strengths": ["list of what's good about this claim"],
+  "suggestion": "how to improve or address the issues"
+}
+
+Be thorough but fair. Look for:
+- Logical fallacies or gaps
+- Unsupported assumptions
+- Missing considerations
+- Factual errors
+- Incomplete reasoning`, claimType, problem, cases.Title(language.English).String(claimType), claim, toolContext)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a careful verifier. Identify both strengths and weaknesses objectively."},
+		{Role: "user", Content: prompt},
+	}
+
+	var v Verification
+	var err error
+	if d.config.Calibration.enabled() {
+		v, err = d.verifySampled(ctx, messages)
+	} else {
+		v, err = d.verifyOnce(ctx, messages, 0.3, d.enableStreams) // Low temp for consistent verification
+	}
+	if err != nil && v.Status == "" {
+		// Provider errors leave nothing to report; parse errors still return the unverified result
+		return Verification{}, err
+	}
+	v.ToolResults = toolResults
+	if err == nil && d.config.CacheVerifications {
+		d.cacheMu.Lock()
+		if d.verifyCache != nil {
+			d.verifyCache[key] = v
+		}
+		d.cacheMu.Unlock()
+	}
+	return v, err
+}
+
+// verifySampled verifies a claim Calibration.Samples times. The mean score is
+// used, validity follows the majority, and the issues and suggestion come from
+// a sample that agrees with it.
+func (d *DialecticalReasoner) verifySampled(ctx context.Context, messages []ChatMessage) (Verification, error) {
+	// Samples are not streamed, so the tokens of several verifications don't interleave
+	samples, err := sampleEvaluations(ctx, d.config.Calibration.Samples, func(ctx context.Context) (Verification, error) {
+		return d.verifyOnce(ctx, messages, d.config.Calibration.temperature(0.3), false)
+	})
+	if err != nil {
+		return Verification{}, err
+	}
+
+	scores := make([]float64, len(samples))
+	var valid int
+	for i, s := range samples {
+		scores[i] = s.Score
+		if s.IsValid {
+			valid++
+		}
+	}
+	calibrated := summarizeScores(scores)
+	d.calibration.record(calibrated)
+
+	isValid := valid*2 > len(samples)
+	v := samples[0]
+	for _, s := range samples {
+		if s.IsValid == isValid {
+			v = s
+			break
+		}
+	}
+	v.IsValid = isValid
+	v.Score = calibrated.Mean
+	v.Calibration = &calibrated
+	return v, nil
+}
+
+// verifyOnce asks the evaluator for one verification
+func (d *DialecticalReasoner) verifyOnce(ctx context.Context, messages []ChatMessage, temperature float64, stream bool) (Verification, error) {
+	var response string
+	var err error
+
+	response, err = d.callProvider(ctx, d.evaluator(), messages, ChatOptions{
+		Temperature: clampTemperature(temperature),
+		MaxTokens:   d.config.MaxTokens,
+	}, stream)
+	if err != nil {
+		return Verification{}, err
+	}
+
+	response, err = d.jsonRepair.repair(ctx, d.evaluator(), messages, response, verificationSchema, ChatOptions{
+		Temperature: clampTemperature(0.1),
+		MaxTokens:   d.config.MaxTokens,
+	})
+	if err != nil {
+		return Verification{}, err
+	}
+	return parseVerification(response)
+}
+
+// verificationSchema is the reply verifyOnce asks for again when it gets no JSON
+const verificationSchema = `{"is_valid": true, "score": 0.0, "issues": ["..."], "strengths": ["..."], "suggestion": "..."}`
+
+// claimCacheKey hashes a claim after normalizing case and whitespace, so
+// trivially reformatted repeats share a verification. The problem and claim
+// type are part of the key because both change the verifier's prompt: a
+// synthesis repeating the thesis is judged again, as a synthesis.
+func claimCacheKey(problem, claimType, claim string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(claim)), " ")
+	sum := sha256.Sum256([]byte(problem + "\x00" + claimType + "\x00" + normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// gatherToolEvidence uses tools to gather evidence for verification
+func (d *DialecticalReasoner) gatherToolEvidence(ctx context.Context, problem, claim, claimType string) []ToolResult {
+	var results []ToolResult
+
+	// Ask LLM what to verify with tools, within what the budget still allows
+	maxCalls := 2
+	if remaining := d.toolBudget.Remaining(claimType); remaining >= 0 && remaining < maxCalls {
+		maxCalls = remaining
+	}
+	toolsPrompt := d.tools.GetToolsPrompt()
+	prompt := fmt.Sprintf(`You need to verify this %s. What tool calls would help fact-check or verify it?
+
+Problem: %s
+Claim: %s
+
+%s
+
+Respond with a JSON array of tool calls to make (max %d):
+[
+  {"tool": "calculator", "input": "expression to verify"},
+  {"tool": "web_fetch", "input": "search query for facts"}
+]
+
+Only suggest tools if they would genuinely help verify the claim. Respond with [] if no tools needed.`, claimType, problem, claim, toolsPrompt, maxCalls)
+
+	messages := []ChatMessage{
+		{Role: "user", Content: prompt},
+	}
+
+	var response string
+	var err error
+
+	response, err = d.callProvider(ctx, d.evaluator(), messages, ChatOptions{
+		Temperature: clampTemperature(0.3),
+		MaxTokens:   d.config.MaxTokens,
+	}, d.enableStreams)
+	if err != nil {
+		return results
+	}
+
+	// Parse tool calls
+	var toolCalls []struct {
+		Tool  string `json:"tool"`
+		Input string `json:"input"`
+	}
+
+	jsonStr := utils.ExtractJSONArray(response)
+	if jsonStr == "" {
+		return results
+	}
+
+	if err := json.Unmarshal([]byte(jsonStr), &toolCalls); err != nil {
+		return results
+	}
+
+	// Execute up to 2 tool calls
+	for i, tc := range toolCalls {
+		// At most 2 calls per verification, within the shared budget
+		if i >= maxCalls || !d.toolBudget.TryConsume(claimType) {
+			break
+		}
+
+		result := d.tools.Execute(ctx, tc.Tool, tc.Input)
+		results = append(results, result)
+
+		d.emitProgress(ProgressUpdate{
+			Type:       "tool",
+			ToolName:   tc.Tool,
+			ToolInput:  tc.Input,
+			ToolOutput: utils.TruncateStr(result.Output, 100),
+			Message:    fmt.Sprintf("Verification tool: %s", tc.Tool),
+
+			ToolBudgetRemaining: d.toolBudget.remainingPtr(claimType),
+		})
+	}
+
+	return results
+}
+
+// buildContext writes out the previous rounds, summarizing the older ones
+// when they are long
+func (d *DialecticalReasoner) buildContext(ctx context.Context, steps []DialecticStep) string {
+	if len(steps) == 0 {
+		return ""
+	}
+
+	var parts []string
+	for _, step := range steps {
+		antitheses := "- Antithesis: " + step.Antithesis.Content
+		if len(step.Challenges) > 1 {
+			var lines []string
+			for i, c := range step.Challenges {
+				lines = append(lines, fmt.Sprintf("- Antithesis %d (%s): %s", i+1, c.Perspective, c.Antithesis.Content))
+			}
+			antitheses = strings.Join(lines, "\n")
+		}
+		parts = append(parts, fmt.Sprintf("Round %d:\n- Thesis: %s\n%s\n- Synthesis: %s",
+			step.Round,
+			step.Thesis.Content,
+			antitheses,
+			step.Synthesis.Content))
+	}
+	return strings.Join(d.history.compactSteps(ctx, parts, "Rounds"), "\n\n")
+}
+
+// parseVerification extracts verification from LLM response
+func parseVerification(response string) (Verification, error) {
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr != "" {
+		// Successfully extracted JSON
+		var v Verification
+		if err := json.Unmarshal([]byte(jsonStr), &v); err != nil {
+			return Verification{IsValid: false, Score: 0, Status: StatusUnverified, ErrorReason: fmt.Sprintf("JSON parse error: %v", err)}, err
+		}
+
+		// Set status to verified for successful verifications
+		if v.Status == "" {
+			v.Status = StatusVerified
+		}
+
+		// Clamp score
+		if v.Score < 0 {
+			v.Score = 0
+		}
+		if v.Score > 1 {
+			v.Score = 1
+		}
+
+		return v, nil
+	}
+
+	// No JSON found even after repair - try to parse text response and convert to Verification
+	// This handles z.ai (glm-4.7) which often returns plain text instead of JSON.
+	// The result is a guess, so it is unverified and weighs little in the confidence.
+	v := parseTextToVerification(response)
+	v.Status = StatusUnverified
+
+	return v, nil
+}
+
+// parseTextToVerification attempts to convert a plain text response into a Verification object
+// This is a fallback for providers (like z.ai's glm-4.7) that don't always return JSON
+func parseTextToVerification(text string) Verification {
+	textLower := strings.ToLower(text)
+
+	v := Verification{
+		Issues:      []string{},
+		Strengths:   []string{},
+		Suggestion:  "",
+		ErrorReason: "Parsed from text response (no JSON provided)",
+	}
+
+	// Look for explicit validity indicators
+	if strings.Contains(textLower, "is_valid: true") || strings.Contains(textLower, "\"is_valid\":true") {
+		v.IsValid = true
+	} else if strings.Contains(textLower, "is_valid: false") || strings.Contains(textLower, "\"is_valid\":false") {
+		v.IsValid = false
+	} else {
+		// Default: assume valid if no explicit issues found
+		v.IsValid = true
+	}
+
+	// Extract score if present
+	scorePattern := regexp.MustCompile(`score["\s:=]*["\s=:]*(\d+\.?\d*)`)
+	if match := scorePattern.FindStringSubmatch(text); len(match) > 1 {
+		if score, err := strconv.ParseFloat(match[1], 64); err == nil {
+			v.Score = score
+		}
+	}
+	if v.Score == 0 {
+		// Default score based on validity
+		if v.IsValid {
+			v.Score = 0.5 // Neutral: nothing in the text says how good the claim is
+		} else {
+			v.Score = 0.2 // Default to 0.2 if invalid
+		}
+	}
+
+	// Look for issues in text
+	issuePatterns := []string{
+		"issue",
+		"problem",
+		"weakness",
+		"gap",
+		"flaw",
+		"error",
+		"incorrect",
+		"missing",
+		"concern",
+	}
+	for _, pattern := range issuePatterns {
+		if strings.Contains(textLower, pattern) {
+			// Try to extract specific issues mentioned
+			// For now, just note that issues were found
+			v.Issues = append(v.Issues, "Response mentioned issues: "+pattern)
+			break
+		}
+	}
+
+	// Look for strengths in text
+	if !v.IsValid || len(v.Issues) == 0 {
+		v.Strengths = append(v.Strengths, "Response provided analysis")
+	} else {
+		strengthPatterns := []string{"good", "strong", "valid", "correct", "solid"}
+		for _, pattern := range strengthPatterns {
+			if strings.Contains(textLower, pattern) {
+				v.Strengths = append(v.Strengths, "Response noted positive aspects")
+				break
+			}
+		}
+	}
+	return v
+
+}
+
+// FormatDialecticResult formats the result for display
+func FormatDialecticResult(result *DialecticResult) string {
+	var sb strings.Builder
+	l := func(english string) string { return label(result.Language, english) }
+
+	sb.WriteString(fmt.Sprintf("## %s\n\n", l("Dialectical Reasoning Result")))
+	sb.WriteString(fmt.Sprintf("**%s:** %s\n\n", l("Problem"), result.Problem))
+	sb.WriteString(fmt.Sprintf("**%s:** %s\n", l("Provider"), result.Provider))
+	sb.WriteString(fmt.Sprintf("**%s:** %d\n", l("Rounds"), result.TotalRounds))
+	if result.TotalToolCalls > 0 {
+		sb.WriteString(fmt.Sprintf("**%s:** %d\n", l("Tool calls"), result.TotalToolCalls))
+	}
+	sb.WriteString(fmt.Sprintf("**%s:** %.1f%%\n\n", l("Confidence"), result.Confidence*100))
+
+	if len(result.ToolsUsed) > 0 {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", l("Tools Used")))
+		for tool, count := range result.ToolsUsed {
+			sb.WriteString(fmt.Sprintf("- %s: %d %s\n", tool, count, l("calls")))
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, step := range result.Steps {
+		sb.WriteString(fmt.Sprintf("### %s %d\n\n", l("Round"), step.Round))
+
+		sb.WriteString(fmt.Sprintf("**%s** (%.0f%% %s):\n%s\n\n",
+			l("Thesis"), step.Thesis.Verification.Score*100, l("confidence"), step.Thesis.Content))
+
+		if len(step.Thesis.Verification.ToolResults) > 0 {
+			sb.WriteString(fmt.Sprintf("*%s:*\n", l("Tool evidence")))
+			for _, tr := range step.Thesis.Verification.ToolResults {
+				sb.WriteString(fmt.Sprintf("  - 🔧 [%s] %s\n", tr.Tool, utils.TruncateStr(tr.Output, 80)))
+			}
+			sb.WriteString("\n")
+		}
+
+		if len(step.Thesis.Verification.Issues) > 0 {
+			sb.WriteString(fmt.Sprintf("*%s:* %s\n\n", l("Issues"), strings.Join(step.Thesis.Verification.Issues, "; ")))
+		}
+
+		challenges := step.Challenges
+		if len(challenges) == 0 {
+			challenges = []Challenge{{Antithesis: step.Antithesis}}
+		}
+		for i, c := range challenges {
+			title := l("Antithesis")
+			if len(challenges) > 1 {
+				title = fmt.Sprintf("%s %d — %s", title, i+1, c.Perspective)
+			}
+			sb.WriteString(fmt.Sprintf("**%s** (%.0f%% %s):\n%s\n\n",
+				title, c.Antithesis.Verification.Score*100, l("confidence"), c.Antithesis.Content))
+
+			if len(c.Antithesis.Verification.ToolResults) > 0 {
+				sb.WriteString(fmt.Sprintf("*%s:*\n", l("Tool evidence")))
+				for _, tr := range c.Antithesis.Verification.ToolResults {
+					sb.WriteString(fmt.Sprintf("  - 🔧 [%s] %s\n", tr.Tool, utils.TruncateStr(tr.Output, 80)))
+				}
+				sb.WriteString("\n")
+			}
+
+			if c.Rebuttal != nil {
+				sb.WriteString(fmt.Sprintf("**%s** (%.0f%% %s):\n%s\n\n",
+					l("Rebuttal"), c.Rebuttal.Verification.Score*100, l("confidence"), c.Rebuttal.Content))
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("**%s** (%.0f%% %s):\n%s\n\n",
+			l("Synthesis"), step.Synthesis.Verification.Score*100, l("confidence"), step.Synthesis.Content))
+
+		if len(step.Synthesis.Verification.ToolResults) > 0 {
+			sb.WriteString(fmt.Sprintf("*%s:*\n", l("Tool evidence")))
+			for _, tr := range step.Synthesis.Verification.ToolResults {
+				sb.WriteString(fmt.Sprintf("  - 🔧 [%s] %s\n", tr.Tool, utils.TruncateStr(tr.Output, 80)))
+			}
+			sb.WriteString("\n")
+		}
+
+		if step.Resolved {
+			sb.WriteString(fmt.Sprintf("✓ *%s*\n\n", l("Resolved")))
+		}
+		sb.WriteString("---\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("### %s\n\n%s\n", l("Final Answer"), result.FinalAnswer))
+
+	// JSON summary
+	summary := map[string]interface{}{
+		"success":      result.Success,
+		"confidence":   result.Confidence,
+		"total_rounds": result.TotalRounds,
+		"provider":     result.Provider,
+	}
+	if result.TotalToolCalls > 0 {
+		summary["total_tool_calls"] = result.TotalToolCalls
+		summary["tools_used"] = result.ToolsUsed
+	}
+	jsonResult, _ := json.MarshalIndent(summary, "", "  ")
+
+	sb.WriteString(fmt.Sprintf("\n### %s\n```json\n", l("JSON Summary")))
+	sb.WriteString(string(jsonResult))
+	sb.WriteString("\n```\n")
+
+	return sb.String()
+}
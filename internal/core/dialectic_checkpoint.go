@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Dialectic Checkpoints ============
+//
+// With checkpoints on, dialectic_reason stops after each synthesis that does
+// not end the debate and asks the person overseeing it whether to continue,
+// steer the next round with guidance, or stop. Clients that support MCP
+// elicitation are asked in place. Otherwise the run returns a paused result
+// with a resume token; calling dialectic_reason again with resume_token (and
+// optionally guidance or stop) continues from the next round.
+
+const defaultCheckpointTTL = 24 * time.Hour
+
+// Checkpoint actions
+const (
+	CheckpointContinue = "continue"
+	CheckpointStop     = "stop"
+	CheckpointPause    = "pause" // No answer now; return a resumable result
+)
+
+// DialecticCheckpoint is what the overseer sees at a checkpoint
+type DialecticCheckpoint struct {
+	Round      int      `json:"round"`
+	MaxRounds  int      `json:"max_rounds"`
+	Thesis     string   `json:"thesis"`
+	Synthesis  string   `json:"synthesis"`
+	Confidence float64  `json:"confidence"` // The synthesis verification score
+	Issues     []string `json:"issues,omitempty"`
+}
+
+func newDialecticCheckpoint(step DialecticStep, maxRounds int) DialecticCheckpoint {
+	return DialecticCheckpoint{
+		Round:      step.Round,
+		MaxRounds:  maxRounds,
+		Thesis:     step.Thesis.Content,
+		Synthesis:  step.Synthesis.Content,
+		Confidence: roundTo(step.Synthesis.Verification.Score, 3),
+		Issues:     step.Synthesis.Verification.Issues,
+	}
+}
+
+// CheckpointDecision is the overseer's answer at a checkpoint
+type CheckpointDecision struct {
+	Action   string // continue, stop or pause
+	Guidance string // Steer for the next round's thesis and synthesis
+}
+
+// CheckpointHandler asks for a decision at a checkpoint
+type CheckpointHandler func(ctx context.Context, checkpoint DialecticCheckpoint) CheckpointDecision
+
+// SetCheckpointHandler sets how checkpoints are answered
+func (d *DialecticalReasoner) SetCheckpointHandler(handler CheckpointHandler) {
+	d.checkpoint = handler
+}
+
+// dialecticResume continues a paused debate after its recorded rounds
+type dialecticResume struct {
+	Steps    []DialecticStep
+	Guidance string
+	Stop     bool
+}
+
+// Resume makes the next Reason call continue after steps, applying guidance
+// to the next round, or end the debate there when stop is set
+func (d *DialecticalReasoner) Resume(steps []DialecticStep, guidance string, stop bool) {
+	if len(steps) == 0 {
+		d.resume = nil
+		return
+	}
+	d.resume = &dialecticResume{Steps: steps, Guidance: strings.TrimSpace(guidance), Stop: stop}
+}
+
+// pausedDebate is a debate waiting at a checkpoint
+type pausedDebate struct {
+	args    map[string]interface{} // Arguments of the original call
+	steps   []DialecticStep
+	created time.Time
+}
+
+// pausedDebateStore keeps paused debates in process for CheckpointTTL
+type pausedDebateStore struct {
+	mu      sync.Mutex
+	debates map[string]*pausedDebate
+}
+
+var pausedDebates = &pausedDebateStore{debates: make(map[string]*pausedDebate)}
+
+func checkpointTTL() time.Duration {
+	return time.Duration(parseEnvInt("DIALECTIC_CHECKPOINT_TTL", int(defaultCheckpointTTL/time.Second))) * time.Second
+}
+
+// save stores a paused debate and returns its resume token
+func (s *pausedDebateStore) save(args map[string]interface{}, steps []DialecticStep) string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to generate resume token: %v\n", err)
+	}
+	token := "dlc_" + hex.EncodeToString(buf)
+
+	saved := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		switch k {
+		case "resume_token", "guidance", "stop", "idempotency_key":
+			continue
+		}
+		saved[k] = v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for t, p := range s.debates {
+		if now.Sub(p.created) > checkpointTTL() {
+			delete(s.debates, t)
+		}
+	}
+	s.debates[token] = &pausedDebate{args: saved, steps: steps, created: now}
+	return token
+}
+
+// take removes and returns the paused debate for token, or nil if it is
+// unknown or expired
+func (s *pausedDebateStore) take(token string) *pausedDebate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.debates[token]
+	delete(s.debates, token)
+	if p == nil || time.Since(p.created) > checkpointTTL() {
+		return nil
+	}
+	return p
+}
+
+// checkpointSchema is the form shown to the overseer through elicitation
+var checkpointSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"action": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{CheckpointContinue, CheckpointStop},
+			"description": "continue with the next round, or stop and answer with the current synthesis",
+		},
+		"guidance": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional steer for the next round, e.g. a consideration the debate is missing",
+		},
+	},
+	"required": []string{"action"},
+}
+
+// elicitCheckpoint asks the client's user through MCP elicitation, pausing the
+// run when the client cannot be asked
+func elicitCheckpoint(ctx context.Context, checkpoint DialecticCheckpoint) CheckpointDecision {
+	srv := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if srv == nil || session == nil {
+		return CheckpointDecision{Action: CheckpointPause}
+	}
+	if withInfo, ok := session.(server.SessionWithClientInfo); ok && withInfo.GetClientCapabilities().Elicitation == nil {
+		return CheckpointDecision{Action: CheckpointPause}
+	}
+
+	message := fmt.Sprintf("Round %d of %d synthesis (confidence %.0f%%):\n%s",
+		checkpoint.Round, checkpoint.MaxRounds, checkpoint.Confidence*100, utils.TruncateStr(checkpoint.Synthesis, 1500))
+	if len(checkpoint.Issues) > 0 {
+		message += "\n\nOpen issues: " + strings.Join(checkpoint.Issues, "; ")
+	}
+	message += "\n\nContinue the debate, optionally with guidance for the next round, or stop here?"
+
+	result, err := srv.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{Message: message, RequestedSchema: checkpointSchema},
+	})
+	if err != nil {
+		if !errors.Is(err, server.ErrElicitationNotSupported) {
+			fmt.Fprintf(os.Stderr, "Warning: dialectic checkpoint elicitation failed: %v (pausing instead)\n", err)
+		}
+		return CheckpointDecision{Action: CheckpointPause}
+	}
+
+	switch result.Action {
+	case mcp.ElicitationResponseActionAccept:
+		content, _ := result.Content.(map[string]interface{})
+		action, _ := content["action"].(string)
+		guidance, _ := content["guidance"].(string)
+		if action == CheckpointStop {
+			return CheckpointDecision{Action: CheckpointStop}
+		}
+		return CheckpointDecision{Action: CheckpointContinue, Guidance: guidance}
+	case mcp.ElicitationResponseActionCancel:
+		return CheckpointDecision{Action: CheckpointStop}
+	default: // Declined: carry on without guidance
+		return CheckpointDecision{Action: CheckpointContinue}
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDialecticCheckpoints_Guidance(t *testing.T) {
+	provider := &debateProvider{}
+	config := DefaultDialecticConfig()
+	config.MaxRounds = 3
+	config.EarlyStop = false
+	config.Checkpoints = true
+
+	var seen []DialecticCheckpoint
+	reasoner := NewDialecticalReasoner(provider, config)
+	reasoner.SetCheckpointHandler(func(_ context.Context, cp DialecticCheckpoint) CheckpointDecision {
+		seen = append(seen, cp)
+		if cp.Round == 1 {
+			return CheckpointDecision{Action: CheckpointContinue, Guidance: "Consider delivery vans"}
+		}
+		return CheckpointDecision{Action: CheckpointStop}
+	})
+
+	result, err := reasoner.Reason(context.Background(), "Should the city ban cars downtown?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if len(seen) != 2 || seen[0].MaxRounds != 3 || seen[0].Synthesis == "" {
+		t.Fatalf("Expected checkpoints after rounds 1 and 2, got %+v", seen)
+	}
+	if result.StoppedReason != StopByUser || len(result.Steps) != 2 || result.Paused {
+		t.Errorf("Expected the overseer to stop after round 2, got %s with %d steps", result.StoppedReason, len(result.Steps))
+	}
+	if result.Steps[1].Guidance != "Consider delivery vans" || result.Steps[0].Guidance != "" {
+		t.Errorf("Expected guidance on round 2 only, got %q, %q", result.Steps[0].Guidance, result.Steps[1].Guidance)
+	}
+	steered := 0
+	for _, prompt := range provider.prompts {
+		if strings.Contains(prompt, "(follow it): Consider delivery vans") {
+			steered++
+		}
+	}
+	if steered != 2 {
+		t.Errorf("Expected the guidance in round 2's thesis and synthesis prompts, got %d prompts", steered)
+	}
+}
+
+func TestDialecticCheckpoints_PauseAndResume(t *testing.T) {
+	fake := newFakeOpenAI(t, func(req fakeChatRequest) string {
+		if strings.Contains(req.system(), "careful verifier") {
+			return `{"is_valid": true, "score": 0.6, "issues": ["thin"], "strengths": [], "suggestion": ""}`
+		}
+		return solveResponder(req)
+	})
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t)
+
+	result := callTool(t, s, "dialectic_reason", map[string]interface{}{
+		"problem": "What is 17 * 23?", "max_rounds": 3, "early_stop": false, "checkpoints": true, "open_questions": false,
+	})
+	token, _ := result["resume_token"].(string)
+	if result["paused"] != true || result["stopped_reason"] != StopPaused || token == "" {
+		t.Fatalf("Expected a paused result with a resume token, got %v", result)
+	}
+	if cp, _ := result["checkpoint"].(map[string]interface{}); cp["round"] != float64(1) {
+		t.Errorf("Expected a checkpoint after round 1, got %v", result["checkpoint"])
+	}
+
+	result = callTool(t, s, "dialectic_reason", map[string]interface{}{"resume_token": token, "guidance": "Check with 17 * 20 + 17 * 3"})
+	steps, _ := result["steps"].([]interface{})
+	if len(steps) != 2 || steps[1].(map[string]interface{})["guidance"] != "Check with 17 * 20 + 17 * 3" {
+		t.Fatalf("Expected round 2 to carry the guidance, got %v", result["steps"])
+	}
+	next, _ := result["resume_token"].(string)
+	if next == "" || next == token {
+		t.Fatalf("Expected a new resume token, got %q", next)
+	}
+
+	result = callTool(t, s, "dialectic_reason", map[string]interface{}{"resume_token": next, "stop": true})
+	if result["stopped_reason"] != StopByUser || len(result["steps"].([]interface{})) != 2 || result["resume_token"] != nil {
+		t.Errorf("Expected the debate to end at round 2, got %v", result)
+	}
+	if answer, _ := result["final_answer"].(string); answer == "" {
+		t.Error("Expected the last synthesis as the final answer")
+	}
+	if pausedDebates.take(token) != nil {
+		t.Error("Expected resume tokens to be single-use")
+	}
+}
@@ -0,0 +1,428 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptedProvider answers verifier prompts with a fixed verification and
+// every other prompt with the same claim, counting verifier calls
+type scriptedProvider struct {
+	mu          sync.Mutex
+	verifyCalls int
+}
+
+func (p *scriptedProvider) Name() string { return "scripted" }
+
+func (p *scriptedProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	if strings.Contains(messages[0].Content, "careful verifier") {
+		p.mu.Lock()
+		p.verifyCalls++
+		p.mu.Unlock()
+		return `{"is_valid": true, "score": 0.5, "issues": ["thin"], "strengths": [], "suggestion": ""}`, nil
+	}
+	return "The answer is 42.", nil
+}
+
+func TestDialecticVerificationCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		cache     bool
+		wantCalls int
+		wantHits  int
+	}{
+		{"enabled", true, 3, 3},
+		{"disabled", false, 6, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &scriptedProvider{}
+			config := DefaultDialecticConfig()
+			config.MaxRounds = 2
+			config.CacheVerifications = tc.cache
+
+			result, err := NewDialecticalReasoner(provider, config).Reason(context.Background(), "What is 6*7?")
+			if err != nil {
+				t.Fatalf("Reason failed: %v", err)
+			}
+			if provider.verifyCalls != tc.wantCalls {
+				t.Errorf("Expected %d verifier calls, got %d", tc.wantCalls, provider.verifyCalls)
+			}
+			if result.VerifyCacheHits != tc.wantHits {
+				t.Errorf("Expected %d cache hits, got %d", tc.wantHits, result.VerifyCacheHits)
+			}
+			if first := result.Steps[0].Thesis.Verification; first.Cached {
+				t.Error("Expected the first verification to be fresh")
+			}
+			if last := result.Steps[1].Synthesis.Verification; last.Cached != tc.cache {
+				t.Errorf("Expected final verification cached=%v, got %v", tc.cache, last.Cached)
+			}
+		})
+	}
+}
+
+func TestClaimCacheKey_Normalizes(t *testing.T) {
+	const problem = "What is 6*7?"
+	if claimCacheKey(problem, "thesis", "The  answer\nis 42") != claimCacheKey(problem, "thesis", "the answer is 42") {
+		t.Error("Expected case and whitespace differences to share a cache key")
+	}
+	if claimCacheKey(problem, "thesis", "the answer is 42") == claimCacheKey(problem, "thesis", "the answer is 43") {
+		t.Error("Expected different claims to have different keys")
+	}
+	if claimCacheKey(problem, "thesis", "the answer is 42") == claimCacheKey(problem, "synthesis", "the answer is 42") {
+		t.Error("Expected a synthesis repeating the thesis to have its own key")
+	}
+	if claimCacheKey(problem, "thesis", "the answer is 42") == claimCacheKey("What is 7*6?", "thesis", "the answer is 42") {
+		t.Error("Expected the same claim for another problem to have its own key")
+	}
+}
+
+func synthesisStep(round int, score float64, status VerificationStatus) DialecticStep {
+	return DialecticStep{
+		Round:     round,
+		Synthesis: Claim{Verification: Verification{IsValid: true, Score: score, Status: status}},
+	}
+}
+
+func TestAggregateConfidence(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     []DialecticStep
+		wantConf  float64
+		wantTrend string
+	}{
+		{
+			name:      "single round",
+			steps:     []DialecticStep{synthesisStep(1, 0.8, StatusVerified)},
+			wantConf:  0.8,
+			wantTrend: "",
+		},
+		{
+			name: "rising scores weighted toward recent",
+			steps: []DialecticStep{
+				synthesisStep(1, 0.4, StatusVerified),
+				synthesisStep(2, 0.9, StatusVerified),
+			},
+			wantConf:  (0.4*0.6 + 0.9) / 1.6,
+			wantTrend: "improving",
+		},
+		{
+			name: "failed verification barely moves the estimate",
+			steps: []DialecticStep{
+				synthesisStep(1, 0.8, StatusVerified),
+				synthesisStep(2, 0.0, StatusUnverified),
+			},
+			wantConf:  (0.8 * 0.6) / (0.6 + 0.2),
+			wantTrend: "declining",
+		},
+		{
+			name: "steady scores",
+			steps: []DialecticStep{
+				synthesisStep(1, 0.7, StatusVerified),
+				synthesisStep(2, 0.71, StatusVerified),
+			},
+			wantConf:  (0.7*0.6 + 0.71) / 1.6,
+			wantTrend: "stable",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := &DialecticResult{Steps: tc.steps}
+			aggregateConfidence(result)
+			if diff := result.Confidence - tc.wantConf; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Confidence = %v, want %v", result.Confidence, tc.wantConf)
+			}
+			if result.ConfidenceTrend != tc.wantTrend {
+				t.Errorf("Trend = %q, want %q", result.ConfidenceTrend, tc.wantTrend)
+			}
+			if len(result.ConfidenceTrajectory) != len(tc.steps) {
+				t.Fatalf("Expected %d trajectory points, got %d", len(tc.steps), len(result.ConfidenceTrajectory))
+			}
+			if first := result.ConfidenceTrajectory[0]; first.Aggregated != first.Score {
+				t.Errorf("Expected first aggregate to equal its score, got %+v", first)
+			}
+		})
+	}
+}
+
+func TestDialecticEarlyStop(t *testing.T) {
+	tests := []struct {
+		name       string
+		earlyStop  bool
+		wantRounds int
+		wantReason string
+	}{
+		{"identical syntheses converge", true, 2, StopConverged},
+		{"disabled runs all rounds", false, 4, StopMaxRounds},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultDialecticConfig()
+			config.MaxRounds = 4
+			config.EarlyStop = tc.earlyStop
+
+			result, err := NewDialecticalReasoner(&scriptedProvider{}, config).Reason(context.Background(), "What is 6*7?")
+			if err != nil {
+				t.Fatalf("Reason failed: %v", err)
+			}
+			if result.TotalRounds != tc.wantRounds || len(result.Steps) != tc.wantRounds {
+				t.Errorf("Expected %d rounds, got %d (%d steps)", tc.wantRounds, result.TotalRounds, len(result.Steps))
+			}
+			if result.StoppedReason != tc.wantReason {
+				t.Errorf("Expected stopped_reason %q, got %q", tc.wantReason, result.StoppedReason)
+			}
+		})
+	}
+}
+
+func TestStallReason_ConfidencePlateau(t *testing.T) {
+	config := DefaultDialecticConfig()
+	config.StallSimilarity = 0 // Only check the plateau
+	d := &DialecticalReasoner{config: config}
+
+	result := &DialecticResult{}
+	for i, text := range []string{"first idea", "second idea", "third idea"} {
+		step := synthesisStep(i+1, 0.6, StatusVerified)
+		step.Synthesis.Content = text
+		result.Steps = append(result.Steps, step)
+		if why := d.stallReason(result); (why != "") != (i == 2) {
+			t.Errorf("Round %d: unexpected stall result %q", i+1, why)
+		}
+	}
+}
+
+func TestRemainingIssues(t *testing.T) {
+	withIssues := func(round int, thesis, synthesis []string) DialecticStep {
+		return DialecticStep{
+			Round:     round,
+			Thesis:    Claim{Verification: Verification{Issues: thesis}},
+			Synthesis: Claim{Verification: Verification{Issues: synthesis}},
+		}
+	}
+	steps := []DialecticStep{
+		withIssues(1, []string{"No source for the 2019 figure", "Ignores inflation"}, nil),
+		withIssues(2, []string{"no source for the 2019 figure."}, []string{"Assumes a single region"}),
+	}
+
+	got := remainingIssues(steps)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 open issues (final synthesis + recurring), got %+v", got)
+	}
+	if got[0].Issue != "Assumes a single region" || !got[0].RaisedInSynthesis {
+		t.Errorf("Expected final synthesis issue first, got %+v", got[0])
+	}
+	if got[1].Issue != "No source for the 2019 figure" || len(got[1].Rounds) != 2 {
+		t.Errorf("Expected recurring issue deduplicated across rounds, got %+v", got[1])
+	}
+}
+
+// openQuestionProvider answers the open-questions prompt with JSON
+type openQuestionProvider struct{ scriptedProvider }
+
+func (p *openQuestionProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	if strings.Contains(messages[len(messages)-1].Content, "Unresolved issues") {
+		return `[{"question": "Is the answer well supported?", "needed_information": "A worked derivation"}]`, nil
+	}
+	return p.scriptedProvider.Chat(ctx, messages, opts)
+}
+
+func TestDialecticOpenQuestions(t *testing.T) {
+	config := DefaultDialecticConfig()
+	config.MaxRounds = 2
+
+	result, err := NewDialecticalReasoner(&openQuestionProvider{}, config).Reason(context.Background(), "What is 6*7?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if len(result.OpenQuestions) != 1 {
+		t.Fatalf("Expected one open question, got %+v", result.OpenQuestions)
+	}
+	q := result.OpenQuestions[0]
+	if q.Question != "Is the answer well supported?" || q.NeededInfo != "A worked derivation" || q.Issue != "thin" {
+		t.Errorf("Unexpected open question: %+v", q)
+	}
+
+	// Without a JSON answer the raw issues are still reported
+	result, err = NewDialecticalReasoner(&scriptedProvider{}, config).Reason(context.Background(), "What is 6*7?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if len(result.OpenQuestions) != 1 || result.OpenQuestions[0].Question != "thin" {
+		t.Errorf("Expected fallback to raw issues, got %+v", result.OpenQuestions)
+	}
+}
+
+// confidentProvider verifies every claim with a high score and a minor
+// issue, and counts open-questions prompts
+type confidentProvider struct {
+	mu            sync.Mutex
+	questionCalls int
+}
+
+func (p *confidentProvider) Name() string { return "confident" }
+
+func (p *confidentProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	switch {
+	case strings.Contains(messages[len(messages)-1].Content, "Unresolved issues"):
+		p.mu.Lock()
+		p.questionCalls++
+		p.mu.Unlock()
+		return `[{"question": "?", "needed_information": "?"}]`, nil
+	case strings.Contains(messages[0].Content, "careful verifier"):
+		return `{"is_valid": true, "score": 0.95, "issues": ["minor wording"], "strengths": ["correct"], "suggestion": ""}`, nil
+	}
+	return "The answer is 42.", nil
+}
+
+func TestDialecticOpenQuestions_SkippedAtConfidenceTarget(t *testing.T) {
+	provider := &confidentProvider{}
+	result, err := NewDialecticalReasoner(provider, DefaultDialecticConfig()).Reason(context.Background(), "What is 6*7?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if !result.Success || result.Confidence < DefaultDialecticConfig().ConfidenceTarget {
+		t.Fatalf("Expected a confident run, got success=%v confidence=%v", result.Success, result.Confidence)
+	}
+	if result.OpenQuestions != nil || provider.questionCalls != 0 {
+		t.Errorf("Expected no open questions and no call for them, got %+v after %d calls", result.OpenQuestions, provider.questionCalls)
+	}
+}
+
+// fadingProvider numbers its syntheses and verifies only the second one
+// above the verify threshold, short of the confidence target
+type fadingProvider struct {
+	mu        sync.Mutex
+	syntheses int
+}
+
+func (p *fadingProvider) Name() string { return "fading" }
+
+func (p *fadingProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	switch {
+	case strings.Contains(messages[0].Content, "careful verifier"):
+		if strings.Contains(messages[len(messages)-1].Content, "Synthesis 2.") {
+			return `{"is_valid": true, "score": 0.8, "issues": ["could be tighter"], "strengths": ["sound"], "suggestion": ""}`, nil
+		}
+		return `{"is_valid": false, "score": 0.2, "issues": ["wrong"], "strengths": [], "suggestion": ""}`, nil
+	case strings.Contains(messages[0].Content, "balanced synthesizer"):
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.syntheses++
+		return fmt.Sprintf("Synthesis %d.", p.syntheses), nil
+	}
+	return "The answer is 42.", nil
+}
+
+func TestDialecticSuccess_FollowsReturnedRound(t *testing.T) {
+	config := DefaultDialecticConfig()
+	config.EarlyStop = false
+	config.OpenQuestions = false
+
+	result, err := NewDialecticalReasoner(&fadingProvider{}, config).Reason(context.Background(), "What is 6*7?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if len(result.Steps) != config.MaxRounds || result.Confidence >= config.VerifyThreshold {
+		t.Fatalf("Expected %d rounds ending below the verify threshold, got %d at %.2f", config.MaxRounds, len(result.Steps), result.Confidence)
+	}
+	if result.FinalAnswer != "Synthesis 2." || !result.Success {
+		t.Errorf("Expected the passing round-2 synthesis to succeed, got %q success=%v", result.FinalAnswer, result.Success)
+	}
+}
+
+func TestDialecticEvaluatorProvider(t *testing.T) {
+	generator, critic := &scriptedProvider{}, &scriptedProvider{}
+	config := DefaultDialecticConfig()
+	config.MaxRounds = 1
+	config.Evaluator = critic
+
+	result, err := NewDialecticalReasoner(generator, config).Reason(context.Background(), "What is 6*7?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if generator.verifyCalls != 0 || critic.verifyCalls == 0 {
+		t.Errorf("Expected verification on the critic only, got generator=%d critic=%d", generator.verifyCalls, critic.verifyCalls)
+	}
+	if result.Evaluator != "scripted" {
+		t.Errorf("Expected the critic to be reported, got %q", result.Evaluator)
+	}
+}
+
+// debateProvider records every prompt and answers each role distinctly
+type debateProvider struct {
+	scriptedProvider
+	prompts []string
+}
+
+func (p *debateProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	prompt := messages[len(messages)-1].Content
+	p.mu.Lock()
+	p.prompts = append(p.prompts, prompt)
+	n := len(p.prompts)
+	p.mu.Unlock()
+	switch system := messages[0].Content; {
+	case strings.Contains(system, "devil's advocate"):
+		return fmt.Sprintf("Objection %d.", n), nil
+	case strings.Contains(system, "thesis's advocate"):
+		return fmt.Sprintf("Defense %d.", n), nil
+	}
+	return p.scriptedProvider.Chat(ctx, messages, opts)
+}
+
+func TestDialecticChallengersAndRebuttals(t *testing.T) {
+	provider := &debateProvider{}
+	config := DefaultDialecticConfig()
+	config.MaxRounds = 1
+	config.NumChallengers = 3
+	config.Rebuttals = true
+
+	result, err := NewDialecticalReasoner(provider, config).Reason(context.Background(), "Should the city ban cars downtown?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	step := result.Steps[0]
+	if len(step.Challenges) != 3 {
+		t.Fatalf("Expected 3 challenges, got %+v", step.Challenges)
+	}
+	for i, want := range []string{"devil's advocate", "feasibility", "stakeholders"} {
+		c := step.Challenges[i]
+		if c.Perspective != want || !strings.HasPrefix(c.Antithesis.Content, "Objection") || c.Rebuttal == nil || !strings.HasPrefix(c.Rebuttal.Content, "Defense") {
+			t.Errorf("Unexpected challenge %d: %+v", i, c)
+		}
+	}
+	if step.Antithesis.Content != step.Challenges[0].Antithesis.Content {
+		t.Error("Expected antithesis to mirror the first challenge")
+	}
+
+	var synthesisPrompt, laterChallenger string
+	for _, prompt := range provider.prompts {
+		if strings.Contains(prompt, "Synthesize these positions") {
+			synthesisPrompt = prompt
+		}
+		if strings.Contains(prompt, "Argue from the people affected") {
+			laterChallenger = prompt
+		}
+	}
+	if !strings.Contains(synthesisPrompt, "ANTITHESIS 3 (stakeholders)") || !strings.Contains(synthesisPrompt, "REBUTTAL 2:") {
+		t.Errorf("Expected the synthesis to weigh every challenge and rebuttal, got %q", synthesisPrompt)
+	}
+	if !strings.Contains(laterChallenger, "Other challengers already argued") || !strings.Contains(laterChallenger, step.Challenges[1].Antithesis.Content) {
+		t.Errorf("Expected later challengers to see earlier objections, got %q", laterChallenger)
+	}
+	if out := FormatDialecticResult(result); !strings.Contains(out, "Antithesis 2 — feasibility") || !strings.Contains(out, "**Rebuttal**") {
+		t.Errorf("Expected challenges and rebuttals in the formatted result, got %s", out)
+	}
+
+	// One challenger without rebuttals keeps the classic step shape
+	config.NumChallengers, config.Rebuttals = 1, false
+	result, err = NewDialecticalReasoner(&debateProvider{}, config).Reason(context.Background(), "Should the city ban cars downtown?")
+	if err != nil || len(result.Steps[0].Challenges) != 0 || result.Steps[0].Antithesis.Content == "" {
+		t.Errorf("Expected a single antithesis without challenges, got %+v, %v", result.Steps, err)
+	}
+}
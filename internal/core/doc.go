@@ -0,0 +1,5 @@
+// Package core implements the reasoning strategies, LLM providers, tools and
+// MCP server behind reasoning-tools. It is internal: other Go programs embed
+// the strategies through the stable APIs in pkg/reasoning, pkg/providers and
+// pkg/tools.
+package core
@@ -0,0 +1,613 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Evaluation Harness ============
+//
+// evaluate runs a labeled dataset through one or more reasoning tools,
+// grades every answer against the expected one and reports accuracy, LLM
+// calls and latency per tool, with the problems each got wrong. It is both
+// the `evaluate` subcommand and the evaluate MCP tool.
+
+const (
+	defaultEvalConcurrency = 4
+	maxEvalCases           = 500 // Per evaluate tool call
+)
+
+// EvalCase is a labeled problem
+type EvalCase struct {
+	ID        string
+	Problem   string
+	Expected  string
+	Arguments map[string]interface{} // Tool arguments, the problem included
+}
+
+// EvalCaseResult is how a tool did on one case
+type EvalCaseResult struct {
+	ID         string         `json:"id"`
+	Problem    string         `json:"problem"`
+	Expected   string         `json:"expected"`
+	Answer     string         `json:"answer"`
+	Correct    bool           `json:"correct"`
+	Score      float64        `json:"score"`                 // 0-1; partial credit from the llm grader
+	GraderNote string         `json:"grader_note,omitempty"` // The llm grader's reason
+	Diff       string         `json:"diff,omitempty"`        // Expected and actual answers, for wrong answers
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	LLMCalls   int            `json:"llm_calls"`
+	ModelUsage map[string]int `json:"model_usage,omitempty"`
+}
+
+// EvalLatency summarizes case durations
+type EvalLatency struct {
+	MeanMS int64 `json:"mean_ms"`
+	P50MS  int64 `json:"p50_ms"`
+	P95MS  int64 `json:"p95_ms"`
+	MaxMS  int64 `json:"max_ms"`
+}
+
+// EvalReport is one tool's results over the dataset
+type EvalReport struct {
+	Tool         string           `json:"tool"`
+	Total        int              `json:"total"`
+	Correct      int              `json:"correct"`
+	Incorrect    int              `json:"incorrect"`
+	Errors       int              `json:"errors"`
+	Accuracy     float64          `json:"accuracy"`   // Correct over total; errors count as wrong
+	MeanScore    float64          `json:"mean_score"` // Mean grader score
+	LLMCalls     int              `json:"llm_calls"`  // Reasoning calls, the grader's excluded
+	MeanLLMCalls float64          `json:"mean_llm_calls"`
+	ModelUsage   map[string]int   `json:"model_usage,omitempty"`
+	Latency      EvalLatency      `json:"latency"`
+	Cases        []EvalCaseResult `json:"cases"`
+}
+
+// EvalSummary is the result of an evaluation
+type EvalSummary struct {
+	Grader  string       `json:"grader"`
+	Cases   int          `json:"cases"`
+	Reports []EvalReport `json:"reports"` // One per tool, in the order given
+}
+
+// EvalOptions configures an evaluation
+type EvalOptions struct {
+	Tools       []string
+	Grader      EvalGrader
+	Concurrency int
+	Args        map[string]interface{} // For every case; a case's own arguments win
+}
+
+// evalToolCaller calls a tool as an MCP client would
+type evalToolCaller func(ctx context.Context, tool string, args map[string]interface{}) WorkResult
+
+// runEvaluation runs every case through every tool and grades the answers
+func runEvaluation(ctx context.Context, call evalToolCaller, cases []EvalCase, opts EvalOptions) (*EvalSummary, error) {
+	summary := &EvalSummary{Grader: opts.Grader.Name(), Cases: len(cases)}
+	for _, tool := range opts.Tools {
+		report := EvalReport{Tool: tool, Total: len(cases), Cases: make([]EvalCaseResult, len(cases))}
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, max(opts.Concurrency, 1))
+		for i, c := range cases {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				report.Cases[i] = evaluateCase(ctx, call, tool, c, opts)
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		summarizeEvalReport(&report)
+		summary.Reports = append(summary.Reports, report)
+	}
+	return summary, nil
+}
+
+// evaluateCase runs and grades one case
+func evaluateCase(ctx context.Context, call evalToolCaller, tool string, c EvalCase, opts EvalOptions) EvalCaseResult {
+	args := make(map[string]interface{}, len(opts.Args)+len(c.Arguments))
+	for k, v := range opts.Args {
+		args[k] = v
+	}
+	for k, v := range c.Arguments {
+		args[k] = v
+	}
+	result := EvalCaseResult{ID: c.ID, Problem: c.Problem, Expected: c.Expected}
+
+	start := time.Now()
+	out := call(ctx, tool, args)
+	result.DurationMS = time.Since(start).Milliseconds()
+	if out.IsError {
+		result.Error = utils.TruncateStr(out.Text, 500)
+		return result
+	}
+	result.Answer, result.LLMCalls, result.ModelUsage = evalAnswer(out.Text)
+
+	grade, err := opts.Grader.Grade(ctx, c, result.Answer)
+	if err != nil {
+		result.Error = fmt.Sprintf("grader: %v", err)
+		return result
+	}
+	result.Correct, result.Score, result.GraderNote = grade.Correct, roundTo(grade.Score, 3), grade.Note
+	if !result.Correct {
+		result.Diff = fmt.Sprintf("- %s\n+ %s", c.Expected, utils.TruncateStr(result.Answer, 300))
+	}
+	return result
+}
+
+// evalAnswer reads the answer and LLM calls of a tool result. auto_reason
+// nests the chosen strategy's result under result.
+func evalAnswer(text string) (answer string, llmCalls int, usage map[string]int) {
+	var result struct {
+		FinalAnswer string          `json:"final_answer"`
+		ModelUsage  map[string]int  `json:"model_usage"`
+		LLMCalls    int             `json:"llm_calls"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if json.Unmarshal([]byte(text), &result) != nil {
+		return strings.TrimSpace(text), 0, nil
+	}
+	if result.FinalAnswer == "" && len(result.Result) > 0 {
+		return evalAnswer(string(result.Result))
+	}
+	llmCalls = result.LLMCalls
+	if llmCalls == 0 {
+		for _, n := range result.ModelUsage {
+			llmCalls += n
+		}
+	}
+	return result.FinalAnswer, llmCalls, result.ModelUsage
+}
+
+// summarizeEvalReport fills in a report's totals from its cases
+func summarizeEvalReport(report *EvalReport) {
+	var durations []int64
+	var scores float64
+	for _, c := range report.Cases {
+		switch {
+		case c.Error != "":
+			report.Errors++
+		case c.Correct:
+			report.Correct++
+		default:
+			report.Incorrect++
+		}
+		scores += c.Score
+		report.LLMCalls += c.LLMCalls
+		for model, n := range c.ModelUsage {
+			if report.ModelUsage == nil {
+				report.ModelUsage = make(map[string]int)
+			}
+			report.ModelUsage[model] += n
+		}
+		durations = append(durations, c.DurationMS)
+	}
+	if report.Total == 0 {
+		return
+	}
+	report.Accuracy = roundTo(float64(report.Correct)/float64(report.Total), 4)
+	report.MeanScore = roundTo(scores/float64(report.Total), 4)
+	report.MeanLLMCalls = roundTo(float64(report.LLMCalls)/float64(report.Total), 2)
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total int64
+	for _, d := range durations {
+		total += d
+	}
+	percentile := func(p float64) int64 {
+		return durations[int(math.Ceil(p*float64(len(durations))))-1]
+	}
+	report.Latency = EvalLatency{
+		MeanMS: total / int64(len(durations)),
+		P50MS:  percentile(0.5),
+		P95MS:  percentile(0.95),
+		MaxMS:  durations[len(durations)-1],
+	}
+}
+
+// ============ Graders ============
+
+// EvalGrade is a grader's verdict on an answer
+type EvalGrade struct {
+	Correct bool
+	Score   float64
+	Note    string
+}
+
+// EvalGrader decides whether an answer matches the expected one
+type EvalGrader interface {
+	Name() string
+	Grade(ctx context.Context, c EvalCase, answer string) (EvalGrade, error)
+}
+
+// newEvalGrader returns the named grader: exact, contains, numeric, llm or
+// auto (numeric for numeric expected answers, else contains). The llm
+// grader runs on grader_provider/grader_model, or on EVALUATE_PROVIDER/
+// EVALUATE_MODEL and the usual provider defaults.
+func newEvalGrader(name, provider, model string) (EvalGrader, error) {
+	switch strings.ToLower(withDefault(name, "auto")) {
+	case "auto":
+		return autoGrader{}, nil
+	case "exact":
+		return exactGrader{}, nil
+	case "contains":
+		return containsGrader{}, nil
+	case "numeric":
+		return numericGrader{}, nil
+	case "llm":
+		args := map[string]interface{}{"provider": provider, "model": model}
+		p, err := getProviderFromArgsForTool(args, "evaluate")
+		if err != nil {
+			return nil, fmt.Errorf("grader provider: %w", err)
+		}
+		return &llmGrader{provider: p}, nil
+	}
+	return nil, fmt.Errorf("unknown grader %q: use auto, exact, contains, numeric or llm", name)
+}
+
+// normalizeAnswer lowercases an answer and drops surrounding punctuation
+// and extra whitespace
+func normalizeAnswer(s string) string {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	return strings.Trim(s, " .,;:!?\"'`*")
+}
+
+func boolGrade(ok bool) EvalGrade {
+	if ok {
+		return EvalGrade{Correct: true, Score: 1}
+	}
+	return EvalGrade{}
+}
+
+// exactGrader wants the normalized answer and expected answer to be equal
+type exactGrader struct{}
+
+func (exactGrader) Name() string { return "exact" }
+
+func (exactGrader) Grade(ctx context.Context, c EvalCase, answer string) (EvalGrade, error) {
+	return boolGrade(normalizeAnswer(answer) == normalizeAnswer(c.Expected)), nil
+}
+
+// containsGrader wants the normalized answer to contain the expected one
+type containsGrader struct{}
+
+func (containsGrader) Name() string { return "contains" }
+
+func (containsGrader) Grade(ctx context.Context, c EvalCase, answer string) (EvalGrade, error) {
+	expected := normalizeAnswer(c.Expected)
+	return boolGrade(expected != "" && strings.Contains(normalizeAnswer(answer), expected)), nil
+}
+
+var evalNumberPattern = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?`)
+
+// parseEvalNumber parses a number, allowing thousands separators
+func parseEvalNumber(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	return n, err == nil
+}
+
+// numericGrader wants the answer's last number to equal the expected number,
+// to a relative tolerance of 1e-6
+type numericGrader struct{}
+
+func (numericGrader) Name() string { return "numeric" }
+
+func (numericGrader) Grade(ctx context.Context, c EvalCase, answer string) (EvalGrade, error) {
+	expected, ok := parseEvalNumber(c.Expected)
+	if !ok {
+		return EvalGrade{}, fmt.Errorf("expected answer %q is not a number", c.Expected)
+	}
+	numbers := evalNumberPattern.FindAllString(answer, -1)
+	if len(numbers) == 0 {
+		return EvalGrade{}, nil
+	}
+	got, ok := parseEvalNumber(numbers[len(numbers)-1])
+	return boolGrade(ok && math.Abs(got-expected) <= 1e-6*math.Max(1, math.Abs(expected))), nil
+}
+
+// autoGrader grades numeric expected answers numerically and others by
+// containment
+type autoGrader struct{}
+
+func (autoGrader) Name() string { return "auto" }
+
+func (autoGrader) Grade(ctx context.Context, c EvalCase, answer string) (EvalGrade, error) {
+	if _, ok := parseEvalNumber(c.Expected); ok {
+		return numericGrader{}.Grade(ctx, c, answer)
+	}
+	return containsGrader{}.Grade(ctx, c, answer)
+}
+
+// llmGrader asks a model whether the answer agrees with the expected one
+type llmGrader struct {
+	provider Provider
+}
+
+func (g *llmGrader) Name() string { return "llm" }
+
+const evalGraderPrompt = `You grade answers to problems against a reference answer. An answer is correct when it reaches the same conclusion as the reference, whatever its wording or extra explanation. Give partial credit in score for answers that are partly right.
+
+Respond with ONLY a JSON object:
+{"correct": true or false, "score": 0.0 to 1.0, "reason": "one sentence"}`
+
+func (g *llmGrader) Grade(ctx context.Context, c EvalCase, answer string) (EvalGrade, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: evalGraderPrompt},
+		{Role: "user", Content: fmt.Sprintf("Problem:\n%s\n\nReference answer:\n%s\n\nAnswer to grade:\n%s", c.Problem, c.Expected, answer)},
+	}
+	response, err := g.provider.Chat(ctx, messages, ChatOptions{Temperature: 0, MaxTokens: 300})
+	if err != nil {
+		return EvalGrade{}, err
+	}
+	var verdict struct {
+		Correct bool     `json:"correct"`
+		Score   *float64 `json:"score"`
+		Reason  string   `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(utils.ExtractJSON(response)), &verdict); err != nil {
+		return EvalGrade{}, fmt.Errorf("unreadable verdict %q", utils.TruncateStr(response, 100))
+	}
+	grade := EvalGrade{Correct: verdict.Correct, Note: verdict.Reason}
+	if verdict.Score != nil {
+		grade.Score = math.Max(0, math.Min(1, *verdict.Score))
+	} else if verdict.Correct {
+		grade.Score = 1
+	}
+	return grade, nil
+}
+
+// ============ Datasets ============
+
+// parseEvalCases reads a dataset: JSONL, or a JSON array, of objects with
+// problem, expected (or answer), an optional id and any other tool arguments
+func parseEvalCases(r io.Reader, name string) ([]EvalCase, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		var items []json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &items); err != nil {
+			return nil, fmt.Errorf("%s: invalid JSON array: %w", name, err)
+		}
+		var lines strings.Builder
+		for _, item := range items {
+			line, _ := json.Marshal(item) // Compacts the item onto one line
+			lines.Write(append(line, '\n'))
+		}
+		data = []byte(lines.String())
+	}
+	problems, err := parseBatchProblems(strings.NewReader(string(data)), name)
+	if err != nil {
+		return nil, err
+	}
+
+	cases := make([]EvalCase, 0, len(problems))
+	for _, p := range problems {
+		c := EvalCase{ID: p.ID, Arguments: p.Arguments}
+		c.Problem, _ = p.Arguments["problem"].(string)
+		for _, key := range []string{"expected", "answer"} {
+			if v, ok := p.Arguments[key]; ok {
+				c.Expected = fmt.Sprint(v)
+				delete(p.Arguments, key)
+				break
+			}
+		}
+		if c.Problem == "" || c.Expected == "" {
+			return nil, fmt.Errorf("%s:%d: a case needs problem and expected", name, p.Line)
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, errors.New("the dataset has no cases")
+	}
+	return cases, nil
+}
+
+// parseEvalTools splits a comma-separated list of tools, checking that each
+// is a reasoning tool
+func parseEvalTools(list string) ([]string, error) {
+	var tools []string
+	for _, tool := range strings.Split(list, ",") {
+		if tool = strings.TrimSpace(tool); tool == "" {
+			continue
+		}
+		if !runHistoryTools[tool] {
+			return nil, fmt.Errorf("%q is not a reasoning tool", tool)
+		}
+		tools = append(tools, tool)
+	}
+	if len(tools) == 0 {
+		return nil, errors.New("tool is required")
+	}
+	return tools, nil
+}
+
+// ============ MCP Tool and Subcommand ============
+
+// newEvaluateHandler returns the evaluate tool's handler, which runs the
+// reasoning tools through s
+func newEvaluateHandler(s *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("invalid arguments format"), nil
+		}
+		dataset, _ := args["dataset"].(string)
+		if strings.TrimSpace(dataset) == "" {
+			return mcp.NewToolResultError("dataset parameter is required"), nil
+		}
+		cases, err := parseEvalCases(strings.NewReader(dataset), "dataset")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(cases) > maxEvalCases {
+			return mcp.NewToolResultError(fmt.Sprintf("the dataset has %d cases; the evaluate tool takes at most %d (use the evaluate subcommand for more)", len(cases), maxEvalCases)), nil
+		}
+		toolList, _ := args["tool"].(string)
+		tools, err := parseEvalTools(toolList)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		graderName, _ := args["grader"].(string)
+		graderProvider, _ := args["grader_provider"].(string)
+		graderModel, _ := args["grader_model"].(string)
+		grader, err := newEvalGrader(graderName, graderProvider, graderModel)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		opts := EvalOptions{Tools: tools, Grader: grader, Concurrency: defaultEvalConcurrency}
+		if c, ok := args["concurrency"].(float64); ok && c >= 1 {
+			opts.Concurrency = int(c)
+		}
+		if raw, _ := args["args"].(string); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &opts.Args); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("args must be a JSON object: %v", err)), nil
+			}
+		}
+
+		summary, err := runEvaluation(ctx, func(ctx context.Context, tool string, args map[string]interface{}) WorkResult {
+			return callToolOn(ctx, s, tool, args)
+		}, cases, opts)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("evaluation stopped: %v", err)), nil
+		}
+		output, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to format result: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(output)), nil
+	}
+}
+
+// runEvaluate runs the evaluate subcommand on s and returns the exit code:
+// 0 when the evaluation ran and 2 on a usage error
+func runEvaluate(ctx context.Context, s *server.MCPServer, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("evaluate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dataset := fs.String("dataset", "", "JSONL or JSON array of {\"id\", \"problem\", \"expected\", other tool arguments}")
+	toolList := fs.String("tool", "", "Reasoning tool to evaluate; several, comma-separated, are compared")
+	graderName := fs.String("grader", "auto", "auto, exact, contains, numeric or llm")
+	graderProvider := fs.String("grader-provider", "", "Provider of the llm grader")
+	graderModel := fs.String("grader-model", "", "Model of the llm grader")
+	concurrency := fs.Int("concurrency", defaultEvalConcurrency, "Cases run at once")
+	common := fs.String("args", "", "JSON object of tool arguments for every case")
+	output := fs.String("output", "", "Also write the full JSON report to this file")
+	asJSON := fs.Bool("json", false, "Print the JSON report instead of markdown")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *dataset == "" || *toolList == "" {
+		fmt.Fprintln(stderr, "Usage: reasoning-tools [flags] evaluate --dataset cases.jsonl --tool <tool>[,<tool>...] [--grader auto] [--concurrency 4] [--json]")
+		return 2
+	}
+	f, err := os.Open(*dataset)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to open dataset: %v\n", err)
+		return 2
+	}
+	cases, err := parseEvalCases(f, *dataset)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	tools, err := parseEvalTools(*toolList)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	grader, err := newEvalGrader(*graderName, *graderProvider, *graderModel)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	opts := EvalOptions{Tools: tools, Grader: grader, Concurrency: *concurrency}
+	if *common != "" {
+		if err := json.Unmarshal([]byte(*common), &opts.Args); err != nil {
+			fmt.Fprintf(stderr, "--args must be a JSON object: %v\n", err)
+			return 2
+		}
+	}
+
+	var mu sync.Mutex
+	done := 0
+	summary, err := runEvaluation(ctx, func(ctx context.Context, tool string, args map[string]interface{}) WorkResult {
+		result := callToolOn(ctx, s, tool, args)
+		mu.Lock()
+		done++
+		fmt.Fprintf(stderr, "[EVAL] %d/%d\n", done, len(cases)*len(tools))
+		mu.Unlock()
+		return result
+	}, cases, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "Evaluation stopped: %v\n", err)
+		return 1
+	}
+	report, _ := json.MarshalIndent(summary, "", "  ")
+	if *output != "" {
+		if err := os.WriteFile(*output, append(report, '\n'), 0644); err != nil {
+			fmt.Fprintf(stderr, "failed to write report: %v\n", err)
+		}
+	}
+	if *asJSON {
+		fmt.Fprintln(stdout, string(report))
+	} else {
+		fmt.Fprint(stdout, formatEvalMarkdown(summary))
+	}
+	return 0
+}
+
+// formatEvalMarkdown renders a summary as a comparison table followed by
+// each tool's wrong answers
+func formatEvalMarkdown(summary *EvalSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Evaluation: %s, %s grader\n\n", plural(summary.Cases, "case"), summary.Grader)
+	b.WriteString("| Tool | Accuracy | Correct | Wrong | Errors | Mean LLM calls | p50 latency | p95 latency |\n")
+	b.WriteString("|------|----------|---------|-------|--------|----------------|-------------|-------------|\n")
+	for _, r := range summary.Reports {
+		fmt.Fprintf(&b, "| %s | %.1f%% | %d | %d | %d | %.1f | %s | %s |\n", r.Tool, r.Accuracy*100, r.Correct, r.Incorrect, r.Errors, r.MeanLLMCalls,
+			time.Duration(r.Latency.P50MS)*time.Millisecond, time.Duration(r.Latency.P95MS)*time.Millisecond)
+	}
+	for _, r := range summary.Reports {
+		if r.Correct == r.Total {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s: misses\n", r.Tool)
+		for _, c := range r.Cases {
+			switch {
+			case c.Error != "":
+				fmt.Fprintf(&b, "\n### %s (error)\n\n%s\n\n%s\n", c.ID, utils.TruncateStr(c.Problem, 200), c.Error)
+			case !c.Correct:
+				fmt.Fprintf(&b, "\n### %s\n\n%s\n\n```diff\n%s\n```\n", c.ID, utils.TruncateStr(c.Problem, 200), c.Diff)
+				if c.GraderNote != "" {
+					fmt.Fprintf(&b, "\nGrader: %s\n", c.GraderNote)
+				}
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestEvalGraders(t *testing.T) {
+	for _, tc := range []struct {
+		grader, expected, answer string
+		want                     bool
+	}{
+		{"exact", "Paris", "paris.", true},
+		{"exact", "Paris", "It is Paris", false},
+		{"contains", "Paris", "The capital is **Paris**.", true},
+		{"contains", "Lyon", "Paris", false},
+		{"numeric", "1,024", "2^10 = 1024", true},
+		{"numeric", "391", "17 * 23 = 391.0", true},
+		{"numeric", "391", "391 is 17 * 23", false}, // The last number counts
+		{"auto", "4", "so 2 + 2 = 4", true},
+		{"auto", "Paris", "paris", true},
+	} {
+		grader, _ := newEvalGrader(tc.grader, "", "")
+		grade, err := grader.Grade(context.Background(), EvalCase{Expected: tc.expected}, tc.answer)
+		if err != nil || grade.Correct != tc.want {
+			t.Errorf("%s %q vs %q: expected %v, got %+v, %v", tc.grader, tc.expected, tc.answer, tc.want, grade, err)
+		}
+	}
+}
+
+func TestEvalGrader_LLM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	os.WriteFile(path, []byte(`{"rules": [
+		{"in": "user", "pattern": "Reference answer:\\s+Paris\\s+Answer to grade:\\s+The capital is Paris", "response": "{\"correct\": true, \"score\": 0.9, \"reason\": \"same city\"}"}
+	], "default": "{\"correct\": false, \"score\": 0, \"reason\": \"different\"}"}`), 0600)
+	t.Setenv("MOCK_FIXTURES", path)
+
+	grader, err := newEvalGrader("llm", "mock", "")
+	if err != nil {
+		t.Fatalf("newEvalGrader failed: %v", err)
+	}
+	c := EvalCase{Problem: "Capital of France?", Expected: "Paris"}
+	if grade, err := grader.Grade(context.Background(), c, "The capital is Paris"); err != nil || !grade.Correct || grade.Score != 0.9 || grade.Note != "same city" {
+		t.Errorf("Unexpected grade: %+v, %v", grade, err)
+	}
+	if grade, _ := grader.Grade(context.Background(), c, "Lyon"); grade.Correct {
+		t.Errorf("Expected a wrong answer, got %+v", grade)
+	}
+}
+
+func TestRunEvaluation(t *testing.T) {
+	cases, err := parseEvalCases(strings.NewReader(`[
+		{"id": "a", "problem": "What is 2 + 2?", "expected": 4},
+		{"id": "b", "problem": "What is 3 + 3?", "answer": "6"},
+		{"id": "c", "problem": "fail", "expected": "x"}
+	]`), "dataset")
+	if err != nil {
+		t.Fatalf("parseEvalCases failed: %v", err)
+	}
+	call := func(ctx context.Context, tool string, args map[string]interface{}) WorkResult {
+		switch args["problem"] {
+		case "fail":
+			return WorkResult{Text: "provider exploded", IsError: true}
+		case "What is 2 + 2?":
+			return WorkResult{Text: `{"final_answer": "4", "model_usage": {"mock/mock": 3}}`}
+		}
+		return WorkResult{Text: `{"routing": {"strategy": "reflexion"}, "result": {"final_answer": "5", "model_usage": {"mock/mock": 1}}}`}
+	}
+	summary, err := runEvaluation(context.Background(), call, cases, EvalOptions{Tools: []string{"reflexion", "dialectic_reason"}, Grader: autoGrader{}, Concurrency: 2})
+	if err != nil || len(summary.Reports) != 2 {
+		t.Fatalf("Unexpected summary: %+v, %v", summary, err)
+	}
+	r := summary.Reports[0]
+	if r.Correct != 1 || r.Incorrect != 1 || r.Errors != 1 || r.Accuracy != 0.3333 || r.LLMCalls != 4 || r.ModelUsage["mock/mock"] != 4 {
+		t.Errorf("Unexpected report: %+v", r)
+	}
+	if r.Cases[1].Answer != "5" || r.Cases[1].Diff != "- 6\n+ 5" {
+		t.Errorf("Expected the nested answer and a diff, got %+v", r.Cases[1])
+	}
+
+	if _, err := parseEvalCases(strings.NewReader(`{"problem": "no label"}`), "dataset"); err == nil {
+		t.Error("Expected a case without expected to be rejected")
+	}
+}
+
+func TestIntegration_EvaluateTool(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	s := integrationServer(t)
+	s.AddTool(mcp.NewTool("evaluate"), newEvaluateHandler(s))
+
+	result := callTool(t, s, "evaluate", map[string]interface{}{
+		"dataset": `{"problem": "What is the capital of France?", "expected": "mock answer"}`,
+		"tool":    "sequential_thinking,decompose_solve",
+		"args":    `{"provider": "mock"}`,
+	})
+	reports, _ := result["reports"].([]interface{})
+	if len(reports) != 2 {
+		t.Fatalf("Expected a report per tool, got %v", result)
+	}
+	for _, r := range reports {
+		if report := r.(map[string]interface{}); report["accuracy"] != 1.0 {
+			t.Errorf("Expected %v to get the mock answer right, got %v", report["tool"], report["accuracy"])
+		}
+	}
+}
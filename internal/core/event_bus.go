@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Event Bus ============
+//
+// Engines publish what happens during a run as typed events to the run's
+// EventBus: ThoughtGenerated, NodeScored, ToolExecuted, RoundCompleted and
+// RunFinished, plus TokenStreamed and ProgressReported for the rest. The
+// output side subscribes instead of being called by the engine: the stream
+// buffer and MCP notifier (SetupStreaming), the handler's MCP progress
+// steps, the run trace and the per-tool event counts of metrics://events.
+// Subscribers are called synchronously, in the order they subscribed.
+
+// Event is one thing that happened during a run
+type Event interface {
+	Kind() string
+}
+
+// Kinds of events
+const (
+	EventKindThoughtGenerated = "thought_generated"
+	EventKindNodeScored       = "node_scored"
+	EventKindToolExecuted     = "tool_executed"
+	EventKindRoundCompleted   = "round_completed"
+	EventKindRunFinished      = "run_finished"
+	EventKindTokenStreamed    = "token_streamed"
+	EventKindProgress         = "progress_reported"
+)
+
+// ThoughtGenerated is a new thought: a GoT node, a sequential step, a thesis
+type ThoughtGenerated struct{ ProgressUpdate }
+
+// NodeScored is the evaluation of a thought, claim or answer
+type NodeScored struct{ ProgressUpdate }
+
+// ToolExecuted is a tool call the run made
+type ToolExecuted struct{ ProgressUpdate }
+
+// ProgressReported is any other progress update: merges, solutions, phases
+type ProgressReported struct{ ProgressUpdate }
+
+// TokenStreamed is one streamed token of an LLM reply
+type TokenStreamed struct{ Token string }
+
+// RoundCompleted ends a dialectic round
+type RoundCompleted struct {
+	Round    int
+	Score    float64 // The synthesis's verification score
+	Resolved bool
+}
+
+// RunFinished ends a strategy run
+type RunFinished struct {
+	Tool    string
+	Result  string
+	IsError bool
+	Elapsed time.Duration
+}
+
+func (ThoughtGenerated) Kind() string { return EventKindThoughtGenerated }
+func (NodeScored) Kind() string       { return EventKindNodeScored }
+func (ToolExecuted) Kind() string     { return EventKindToolExecuted }
+func (ProgressReported) Kind() string { return EventKindProgress }
+func (TokenStreamed) Kind() string    { return EventKindTokenStreamed }
+func (RoundCompleted) Kind() string   { return EventKindRoundCompleted }
+func (RunFinished) Kind() string      { return EventKindRunFinished }
+
+// progressEvent is an event with a place in the run's stream
+type progressEvent interface {
+	Event
+	progress() ProgressUpdate
+}
+
+func (u ProgressUpdate) progress() ProgressUpdate { return u }
+
+// eventFromProgress types a progress update by its Type
+func eventFromProgress(update ProgressUpdate) Event {
+	switch update.Type {
+	case EventTypeThought:
+		return ThoughtGenerated{update}
+	case EventTypeEvaluation:
+		return NodeScored{update}
+	case EventTypeTool:
+		return ToolExecuted{update}
+	}
+	return ProgressReported{update}
+}
+
+// EventBus delivers a run's events to its subscribers
+type EventBus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   []eventSubscription
+}
+
+type eventSubscription struct {
+	id int
+	fn func(Event)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe calls fn with every event published from now on, until the
+// returned function is called
+func (b *EventBus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, eventSubscription{id: id, fn: fn})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers e to the subscribers; a nil bus drops it
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s.fn(e)
+	}
+}
+
+type eventBusKey struct{}
+
+func eventBusFromContext(ctx context.Context) *EventBus {
+	bus, _ := ctx.Value(eventBusKey{}).(*EventBus)
+	return bus
+}
+
+// newRunEventBus creates a run's bus with the trace and metrics subscribed
+func newRunEventBus(ctx context.Context, tool string) *EventBus {
+	bus := NewEventBus()
+	if trace := traceFromContext(ctx); trace != nil {
+		trace.subscribe(bus)
+	}
+	bus.Subscribe(runEventCounts.subscriber(tool))
+	return bus
+}
+
+// runEventsMiddleware gives each strategy run an event bus and publishes
+// RunFinished with the result
+func runEventsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !runHistoryTools[request.Params.Name] || eventBusFromContext(ctx) != nil {
+			return next(ctx, request)
+		}
+		start := time.Now()
+		bus := newRunEventBus(ctx, request.Params.Name)
+		result, err := next(context.WithValue(ctx, eventBusKey{}, bus), request)
+		text, isError := toolResultText(result, err)
+		bus.Publish(RunFinished{Tool: request.Params.Name, Result: text, IsError: isError, Elapsed: time.Since(start)})
+		return result, err
+	}
+}
+
+// ============ Event Metrics ============
+
+// eventCounts counts the events of every run by tool and kind since start
+type eventCounts struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int64
+}
+
+var runEventCounts = &eventCounts{counts: make(map[string]map[string]int64)}
+
+// subscriber counts the events of one run of tool, and failed_runs
+func (c *eventCounts) subscriber(tool string) func(Event) {
+	return func(e Event) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		counts := c.counts[tool]
+		if counts == nil {
+			counts = make(map[string]int64)
+			c.counts[tool] = counts
+		}
+		counts[e.Kind()]++
+		if finished, ok := e.(RunFinished); ok && finished.IsError {
+			counts["failed_runs"]++
+		}
+	}
+}
+
+// snapshot returns a copy of the counts, by tool then kind
+func (c *eventCounts) snapshot() map[string]map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]map[string]int64, len(c.counts))
+	for tool, counts := range c.counts {
+		copied := make(map[string]int64, len(counts))
+		for kind, n := range counts {
+			copied[kind] = n
+		}
+		out[tool] = copied
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestEventBus_SubscribeAndPublish(t *testing.T) {
+	bus := NewEventBus()
+	var kinds []string
+	unsubscribe := bus.Subscribe(func(e Event) { kinds = append(kinds, e.Kind()) })
+
+	bus.Publish(eventFromProgress(ProgressUpdate{Type: EventTypeThought, Thought: "a"}))
+	bus.Publish(eventFromProgress(ProgressUpdate{Type: EventTypeEvaluation, Score: 0.7}))
+	bus.Publish(eventFromProgress(ProgressUpdate{Type: EventTypeTool, ToolName: "calculator"}))
+	bus.Publish(eventFromProgress(ProgressUpdate{Type: EventTypeMerge}))
+	bus.Publish(TokenStreamed{Token: "x"})
+	unsubscribe()
+	bus.Publish(RunFinished{Tool: "graph_of_thoughts"})
+
+	want := []string{EventKindThoughtGenerated, EventKindNodeScored, EventKindToolExecuted, EventKindProgress, EventKindTokenStreamed}
+	if len(kinds) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, want[i], kinds[i])
+		}
+	}
+
+	var nilBus *EventBus
+	nilBus.Publish(TokenStreamed{}) // Engines without a bus drop their events
+}
+
+func TestEventBus_FeedsStreamAndMetrics(t *testing.T) {
+	runEventCounts.mu.Lock()
+	saved := runEventCounts.counts
+	runEventCounts.counts = make(map[string]map[string]int64)
+	runEventCounts.mu.Unlock()
+	t.Cleanup(func() {
+		runEventCounts.mu.Lock()
+		runEventCounts.counts = saved
+		runEventCounts.mu.Unlock()
+	})
+
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t, traceMiddleware, runEventsMiddleware)
+	result := callTool(t, s, "sequential_thinking", map[string]interface{}{"problem": "What is 17 * 23?", "stream_mode": "events"})
+	if events, _ := result["stream"].([]interface{}); len(events) == 0 {
+		t.Errorf("Expected the stream to get the run's events, got %v", result)
+	}
+
+	counts := runEventCounts.snapshot()["sequential_thinking"]
+	if counts[EventKindThoughtGenerated] == 0 || counts[EventKindRunFinished] != 1 || counts["failed_runs"] != 0 {
+		t.Errorf("Unexpected event counts %v", counts)
+	}
+}
+
+func TestDialectic_PublishesRoundCompleted(t *testing.T) {
+	bus := NewEventBus()
+	var mu sync.Mutex
+	var rounds []RoundCompleted
+	bus.Subscribe(func(e Event) {
+		if round, ok := e.(RoundCompleted); ok {
+			mu.Lock()
+			rounds = append(rounds, round)
+			mu.Unlock()
+		}
+	})
+
+	config := DefaultDialecticConfig()
+	config.MaxRounds = 2
+	config.EarlyStop = false
+	d := NewDialecticalReasoner(&countProvider{response: `{"content": "391", "score": 0.5}`}, config)
+	d.SetEventBus(bus)
+	if _, err := d.Reason(context.Background(), "What is 17 * 23?"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rounds) != 2 || rounds[0].Round != 1 || rounds[1].Round != 2 {
+		t.Errorf("Expected a RoundCompleted per round, got %+v", rounds)
+	}
+}
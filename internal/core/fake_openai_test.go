@@ -0,0 +1,151 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeChatRequest is a chat completion request received by fakeOpenAI
+type fakeChatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Stream      bool          `json:"stream"`
+	Temperature *float64      `json:"temperature"`
+	Seed        *int          `json:"seed"`
+}
+
+// system returns the request's system prompt
+func (r fakeChatRequest) system() string {
+	if len(r.Messages) > 0 && r.Messages[0].Role == "system" {
+		return r.Messages[0].Content
+	}
+	return ""
+}
+
+// user returns the request's last message
+func (r fakeChatRequest) user() string {
+	if len(r.Messages) == 0 {
+		return ""
+	}
+	return r.Messages[len(r.Messages)-1].Content
+}
+
+// fakeOpenAI is an OpenAI-compatible chat completions server for
+// integration tests. respond picks the reply to each request; the reply is
+// sent whole or, for stream requests, as SSE chunks. failNext injects HTTP
+// errors ahead of the replies, and header is sent with every response.
+type fakeOpenAI struct {
+	*httptest.Server
+	respond func(req fakeChatRequest) string
+	header  http.Header
+
+	mu       sync.Mutex
+	failures []fakeFailure
+	requests []fakeChatRequest
+}
+
+// fakeFailure is an injected error response
+type fakeFailure struct {
+	status int
+	header http.Header
+}
+
+// newFakeOpenAI starts a fake server that is closed when the test ends
+func newFakeOpenAI(t *testing.T, respond func(req fakeChatRequest) string) *fakeOpenAI {
+	t.Helper()
+	f := &fakeOpenAI{respond: respond, header: make(http.Header)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveChat))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// failNext makes the next requests fail with the given HTTP statuses, in order
+func (f *fakeOpenAI) failNext(statuses ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, status := range statuses {
+		f.failures = append(f.failures, fakeFailure{status: status})
+	}
+}
+
+// failNextWith makes the next request fail with status and extra headers
+func (f *fakeOpenAI) failNextWith(status int, header http.Header) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, fakeFailure{status: status, header: header})
+}
+
+// received returns the requests that got an answer
+func (f *fakeOpenAI) received() []fakeChatRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeChatRequest(nil), f.requests...)
+}
+
+func (f *fakeOpenAI) serveChat(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/chat/completions" {
+		http.NotFound(w, r)
+		return
+	}
+	var req fakeChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for key, values := range f.header {
+		w.Header()[key] = values
+	}
+	f.mu.Lock()
+	if len(f.failures) > 0 {
+		failure := f.failures[0]
+		f.failures = f.failures[1:]
+		f.mu.Unlock()
+		for key, values := range failure.header {
+			w.Header()[key] = values
+		}
+		http.Error(w, fmt.Sprintf(`{"error": {"message": "injected %d"}}`, failure.status), failure.status)
+		return
+	}
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	reply := f.respond(req)
+	if !req.Stream {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+	for _, token := range strings.SplitAfter(reply, " ") {
+		chunk, _ := json.Marshal(map[string]interface{}{
+			"choices": []map[string]interface{}{{"delta": map[string]string{"content": token}}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", chunk)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// useFakeProvider points a provider type at f for the rest of the test,
+// with near-instant retries
+func useFakeProvider(t *testing.T, providerType string, f *fakeOpenAI) {
+	t.Helper()
+	prefix := providerEnvPrefix(providerType)
+	t.Setenv(prefix+"_API_KEY", "test-key")
+	t.Setenv(prefix+"_BASE_URL", f.URL)
+	t.Setenv("LLM_RETRY_BASE_DELAY_MS", "1")
+	t.Setenv("LLM_RETRY_JITTER", "0")
+	ResetConfig()
+	t.Cleanup(ResetConfig)
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============ File Tools ============
+//
+// file_read and file_list let reasoning inspect local repositories and data
+// files. They are registered only when FILE_TOOL_ROOTS names one or more
+// directories (comma-separated), and never touch anything outside those
+// roots: paths are resolved with symlinks followed before the check, so a
+// link pointing out of a root is refused too. Reads stop at
+// FILE_TOOL_MAX_BYTES (default 64 KiB) and binary files are described
+// rather than dumped.
+
+const (
+	defaultFileToolMaxBytes = 64 * 1024
+	maxFileListEntries      = 200
+	binarySniffBytes        = 8000
+)
+
+// file_read accepts GitHub-style line ranges: path#L10-L40 or path#L10
+var lineRangePattern = regexp.MustCompile(`#L(\d+)(?:-L?(\d+))?$`)
+
+// fileRoots is the set of directories the file tools may read
+type fileRoots []string
+
+// fileRootsFromEnv resolves FILE_TOOL_ROOTS, skipping roots that do not exist
+func fileRootsFromEnv() fileRoots {
+	var roots fileRoots
+	for _, root := range strings.Split(os.Getenv("FILE_TOOL_ROOTS"), ",") {
+		if root = strings.TrimSpace(root); root == "" {
+			continue
+		}
+		abs, err := filepath.Abs(root)
+		if err == nil {
+			abs, err = filepath.EvalSymlinks(abs)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARNING] file tools: skipping root %s: %v\n", root, err)
+			continue
+		}
+		roots = append(roots, abs)
+	}
+	return roots
+}
+
+// contains reports whether the resolved path is inside one of the roots
+func (roots fileRoots) contains(path string) bool {
+	for _, root := range roots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve maps a tool input path to an absolute path inside the roots.
+// Relative paths are tried against each root in order.
+func (roots fileRoots) resolve(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	candidates := []string{filepath.Clean(path)}
+	if !filepath.IsAbs(path) {
+		candidates = candidates[:0]
+		for _, root := range roots {
+			candidates = append(candidates, filepath.Join(root, path))
+		}
+	}
+
+	var firstErr error
+	for _, candidate := range candidates {
+		resolved, err := filepath.EvalSymlinks(candidate)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !roots.contains(resolved) {
+			return "", fmt.Errorf("%s is outside the allowed roots (%s)", path, strings.Join(roots, ", "))
+		}
+		return resolved, nil
+	}
+	if errors.Is(firstErr, fs.ErrNotExist) {
+		return "", fmt.Errorf("%s not found under %s", path, strings.Join(roots, ", "))
+	}
+	return "", firstErr
+}
+
+// display shows a path relative to its root, prefixed with the root's name
+// when there are several roots
+func (roots fileRoots) display(path string) string {
+	for _, root := range roots {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			if len(roots) > 1 {
+				return filepath.Join(filepath.Base(root), rel)
+			}
+			return rel
+		}
+	}
+	return path
+}
+
+// isBinary reports whether a file's leading bytes look like binary data
+func isBinary(sample []byte) bool {
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	// A multi-byte character may be cut at the end of the sample
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	return !utf8.Valid(sample)
+}
+
+// FileReadTool reads text files under FILE_TOOL_ROOTS
+type FileReadTool struct {
+	roots    fileRoots
+	maxBytes int
+}
+
+func (t *FileReadTool) Name() string {
+	return "file_read"
+}
+
+func (t *FileReadTool) Description() string {
+	return fmt.Sprintf("Read a local text file. Input: a path relative to %s, optionally with a line range like path#L10-L40. Output is capped at %d bytes.", strings.Join(t.roots, " or "), t.maxBytes)
+}
+
+func (t *FileReadTool) Execute(ctx context.Context, input string) (string, error) {
+	input = strings.TrimSpace(input)
+	start, end := 0, 0
+	if m := lineRangePattern.FindStringSubmatch(input); m != nil {
+		start, _ = strconv.Atoi(m[1])
+		end = start
+		if m[2] != "" {
+			end, _ = strconv.Atoi(m[2])
+		}
+		if start < 1 || end < start {
+			return "", fmt.Errorf("invalid line range %s", m[0])
+		}
+		input = strings.TrimSuffix(input, m[0])
+	}
+
+	path, err := t.roots.resolve(input)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory; use file_list", t.roots.display(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	sample, _ := reader.Peek(binarySniffBytes)
+	if isBinary(sample) {
+		return fmt.Sprintf("%s is a binary file (%d bytes); not shown.", t.roots.display(path), info.Size()), nil
+	}
+
+	if start == 0 {
+		data, err := io.ReadAll(io.LimitReader(reader, int64(t.maxBytes)))
+		if err != nil {
+			return "", err
+		}
+		text := string(data)
+		if info.Size() > int64(t.maxBytes) {
+			text += fmt.Sprintf("\n...(truncated: showing the first %d of %d bytes; read a range with #L<start>-L<end>)", t.maxBytes, info.Size())
+		}
+		return text, nil
+	}
+
+	var sb strings.Builder
+	line := 0
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		line++
+		if line < start {
+			continue
+		}
+		if line > end {
+			break
+		}
+		if sb.Len()+len(scanner.Text()) > t.maxBytes {
+			fmt.Fprintf(&sb, "...(truncated at line %d: output is capped at %d bytes)\n", line, t.maxBytes)
+			break
+		}
+		fmt.Fprintf(&sb, "%d: %s\n", line, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if line < start {
+		return "", fmt.Errorf("%s has only %d lines", t.roots.display(path), line)
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// FileListTool lists directories under FILE_TOOL_ROOTS
+type FileListTool struct {
+	roots fileRoots
+}
+
+func (t *FileListTool) Name() string {
+	return "file_list"
+}
+
+func (t *FileListTool) Description() string {
+	return fmt.Sprintf("List a local directory. Input: a path relative to %s, or empty for the top level. Directories end with '/'.", strings.Join(t.roots, " or "))
+}
+
+func (t *FileListTool) Execute(ctx context.Context, input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" || input == "." || input == "/" {
+		if len(t.roots) > 1 {
+			return "Roots:\n" + strings.Join(t.roots, "\n"), nil
+		}
+		input = t.roots[0]
+	}
+	dir, err := t.roots.resolve(input)
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s)\n", withDefault(t.roots.display(dir), "."), plural(len(entries), "entry", "entries"))
+	for i, entry := range entries {
+		if i == maxFileListEntries {
+			fmt.Fprintf(&sb, "...(%d more)\n", len(entries)-i)
+			break
+		}
+		if entry.IsDir() {
+			fmt.Fprintf(&sb, "%s/\n", entry.Name())
+			continue
+		}
+		size := "?"
+		if info, err := entry.Info(); err == nil {
+			size = strconv.FormatInt(info.Size(), 10)
+		}
+		fmt.Fprintf(&sb, "%s  %s bytes\n", entry.Name(), size)
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// newFileTools returns the file tools for FILE_TOOL_ROOTS, or nil when
+// no root is configured
+func newFileTools() []ToolExecutor {
+	roots := fileRootsFromEnv()
+	if len(roots) == 0 {
+		return nil
+	}
+	maxBytes := parseEnvInt("FILE_TOOL_MAX_BYTES", defaultFileToolMaxBytes)
+	if maxBytes < 1 {
+		maxBytes = defaultFileToolMaxBytes
+	}
+	return []ToolExecutor{&FileReadTool{roots: roots, maxBytes: maxBytes}, &FileListTool{roots: roots}}
+}
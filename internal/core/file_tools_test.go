@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fileToolsForTest(t *testing.T, maxBytes string) (*ToolRegistry, string) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "src"), 0o755)
+	os.WriteFile(filepath.Join(root, "README.md"), []byte("line one\nline two\nline three\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "logo.png"), []byte("\x89PNG\r\n\x1a\n\x00\x00\x00"), 0o644)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("token"), 0o644)
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "escape.txt"))
+
+	t.Setenv("FILE_TOOL_ROOTS", root)
+	t.Setenv("FILE_TOOL_MAX_BYTES", maxBytes)
+	return NewToolRegistry(), outside
+}
+
+func TestFileRead(t *testing.T) {
+	registry, outside := fileToolsForTest(t, "")
+	ctx := context.Background()
+
+	for _, tc := range []struct{ input, want string }{
+		{"README.md", "line one\nline two\nline three\n"},
+		{"README.md#L2-L3", "2: line two\n3: line three"},
+		{"README.md#L2", "2: line two"},
+		{"src/../src/main.go", "package main\n"},
+		{"logo.png", "logo.png is a binary file (11 bytes); not shown."},
+	} {
+		if res := registry.Execute(ctx, "file_read", tc.input); !res.Success || res.Output != tc.want {
+			t.Errorf("file_read %s = %+v, want %q", tc.input, res, tc.want)
+		}
+	}
+
+	for _, input := range []string{"../" + filepath.Base(outside) + "/secret.txt", filepath.Join(outside, "secret.txt"), "escape.txt"} {
+		if res := registry.Execute(ctx, "file_read", input); res.Success || !strings.Contains(res.Error, "outside the allowed roots") {
+			t.Errorf("Expected %s refused, got %+v", input, res)
+		}
+	}
+	if res := registry.Execute(ctx, "file_read", "missing.txt"); res.Success || !strings.Contains(res.Error, "not found") {
+		t.Errorf("Expected a missing file reported, got %+v", res)
+	}
+}
+
+func TestFileRead_SizeCap(t *testing.T) {
+	registry, _ := fileToolsForTest(t, "10")
+	res := registry.Execute(context.Background(), "file_read", "README.md")
+	if !strings.HasPrefix(res.Output, "line one\nl\n...(truncated: showing the first 10 of 29 bytes") {
+		t.Errorf("Expected the read capped at 10 bytes, got %q", res.Output)
+	}
+}
+
+func TestFileList(t *testing.T) {
+	registry, _ := fileToolsForTest(t, "")
+	res := registry.Execute(context.Background(), "file_list", "")
+	if !res.Success || !strings.HasPrefix(res.Output, ". (4 entries)\nsrc/\nREADME.md  29 bytes\n") {
+		t.Errorf("Unexpected listing: %+v", res)
+	}
+	if res := registry.Execute(context.Background(), "file_list", "src"); res.Output != "src (1 entry)\nmain.go  13 bytes" {
+		t.Errorf("Unexpected listing: %q", res.Output)
+	}
+
+	t.Setenv("FILE_TOOL_ROOTS", "")
+	if _, ok := NewToolRegistry().tools["file_read"]; ok {
+		t.Error("Expected no file tools without FILE_TOOL_ROOTS")
+	}
+}
@@ -0,0 +1,1451 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"reasoning-tools/utils"
+)
+
+// GraphOfThoughts implements reasoning as a graph where thoughts can merge
+type GraphOfThoughts struct {
+	ReasonerBase
+	gotState
+
+	provider    Provider
+	config      GoTConfig
+	tools       *ToolRegistry
+	similarity  SimilarityBackend
+	policy      ExpansionPolicy
+	toolBudget  *ToolBudget
+	calibration *calibrationRecorder
+	jsonRepair  *jsonRepairer
+	history     *historySummarizer
+	memory      *sharedMemory
+	lessons     []string // Past lessons for the first expansion
+
+	// Run state that survives across Solve/Continue calls, with the search
+	// state in gotState
+	runID     string
+	problem   string
+	createdAt time.Time
+	store     *GoTRunStore
+}
+
+// GoTConfig configures the Graph of Thoughts algorithm
+type GoTConfig struct {
+	BranchingFactor int      `json:"branching_factor"` // Number of candidate thoughts per expansion (default: 3)
+	MaxNodes        int      `json:"max_nodes"`        // Maximum nodes to explore (default: 30)
+	MaxDepth        int      `json:"max_depth"`        // Maximum reasoning depth (default: 8)
+	MergeThreshold  float64  `json:"merge_threshold"`  // Similarity threshold for merging (default: 0.7)
+	MinScore        float64  `json:"min_score"`        // Minimum score to continue a path (default: 0.3)
+	Temperature     float64  `json:"temperature"`      // LLM temperature for diversity (default: 0.8)
+	EnableMerging   bool     `json:"enable_merging"`   // Whether to allow merging paths (default: true)
+	EnableTools     bool     `json:"enable_tools"`     // Whether to allow tool usage during reasoning (default: false)
+	MaxToolCalls    int      `json:"max_tool_calls"`   // Maximum tool calls total (default: 10)
+	EnabledTools    []string `json:"enabled_tools"`    // Which tools to enable (empty = all)
+
+	SimilarityBackend   string  `json:"similarity_backend,omitempty"` // "llm", "embedding" or "minhash" (default: llm)
+	SimilarityPrefilter float64 `json:"similarity_prefilter"`         // Minimum word overlap before the backend is asked (default: 0.1)
+	SimilarityBand      float64 `json:"similarity_band,omitempty"`    // Hybrid backend: embedding scores this close to MergeThreshold go to the LLM (default: 0.1)
+
+	ExpansionPolicy string `json:"expansion_policy,omitempty"` // "ucb1", "beam" or "best_first" (default: ucb1)
+
+	EnableAggregation   bool `json:"enable_aggregation,omitempty"`   // Periodically combine the best thoughts of different branches (default: false)
+	AggregationInterval int  `json:"aggregation_interval,omitempty"` // Expansions between aggregation steps (default: 3)
+	AggregationSize     int  `json:"aggregation_size,omitempty"`     // Thoughts combined per aggregate node, 2-3 (default: 3)
+	BeamWidth           int  `json:"beam_width,omitempty"`           // Nodes kept per depth by the beam policy (default: branching factor)
+	MaxRefinements      int  `json:"max_refinements,omitempty"`      // Revisions of weak-but-salvageable thoughts per run (default: 0, off)
+	MaxHistoryTokens    int  `json:"max_history_tokens,omitempty"`   // Summarize the older steps of a path past this many estimated tokens (default: 6000, 0 = never)
+
+	// Critic for thought evaluation and similarity checks (default: nil, the generator judges itself)
+	Evaluator Provider `json:"-"`
+
+	// Sample each thought score several times and use the mean (default: off)
+	Calibration CalibrationConfig `json:"calibration,omitempty"`
+
+	// Record the run in episodic memory and learn from past ones (default: off)
+	Memory SharedMemoryConfig `json:"-"`
+}
+
+// DefaultGoTConfig returns sensible defaults
+func DefaultGoTConfig() GoTConfig {
+	return GoTConfig{
+		BranchingFactor: 3,
+		MaxNodes:        30,
+		MaxDepth:        8,
+		MergeThreshold:  0.7,
+		MinScore:        0.3,
+		Temperature:     0.8,
+		EnableMerging:   true,
+		EnableTools:     false,
+		MaxToolCalls:    10,
+		EnabledTools:    []string{},
+
+		SimilarityBackend:   SimilarityBackendLLM,
+		SimilarityPrefilter: defaultSimilarityPrefilter,
+		SimilarityBand:      defaultSimilarityBand,
+
+		ExpansionPolicy: ExpansionPolicyUCB1,
+
+		MaxHistoryTokens: defaultMaxHistoryTokens,
+	}
+}
+
+// GoTNode represents a node in the thought graph (can have multiple parents)
+type GoTNode struct {
+	ID          string      `json:"id"`
+	NodeType    string      `json:"node_type"` // "thought", "tool" or "aggregate"
+	Thought     string      `json:"thought"`
+	Depth       int         `json:"depth"`
+	Score       float64     `json:"score"`
+	Visits      int         `json:"visits"`
+	TotalReward float64     `json:"total_reward"`
+	Parents     []string    `json:"parents,omitempty"`  // Multiple parents allowed
+	Children    []string    `json:"children,omitempty"` // IDs of children
+	IsTerminal  bool        `json:"is_terminal"`
+	IsSolution  bool        `json:"is_solution"`
+	Answer      string      `json:"answer,omitempty"`
+	MergedFrom  []string    `json:"merged_from,omitempty"`  // IDs of nodes merged into this
+	ToolCall    *ToolCall   `json:"tool_call,omitempty"`    // If this is a tool node
+	ToolResult  *ToolResult `json:"tool_result,omitempty"`  // Result of tool execution
+	Source      string      `json:"source,omitempty"`       // "human" for user-injected nodes
+	PruneReason string      `json:"prune_reason,omitempty"` // Why a non-solution node stopped being expanded
+	Model       string      `json:"model,omitempty"`        // Provider/model that proposed the node
+	Provenance  *Provenance `json:"provenance,omitempty"`   // Provider/models asked and answering
+	RefinedFrom string      `json:"refined_from,omitempty"` // ID of the weak thought this node revises
+}
+
+// gotConfidentScore is the best-solution score above which exploration stops
+// early with GoTReasonConfident
+const gotConfidentScore = 0.85
+
+// Machine-readable reasons recorded in GoTDecision.Reason and GoTNode.PruneReason
+const (
+	GoTReasonSimilarity    = "similarity_above_threshold"
+	GoTReasonLowScore      = "score_below_min"
+	GoTReasonMaxDepth      = "max_depth_reached"
+	GoTReasonNodeBudget    = "node_budget_exhausted"
+	GoTReasonToolBudget    = "tool_budget_exhausted"
+	GoTReasonConfident     = "confident_solution_found"
+	GoTReasonNoCandidates  = "no_expandable_nodes"
+	GoTReasonExpansionFail = "expansion_failed"
+	GoTReasonProviderError = "provider_failed" // Expansions kept failing; the graph so far is returned
+	GoTReasonLLMCallBudget = "llm_call_budget_exhausted"
+	GoTReasonAggregated    = "combined_top_branches"
+	GoTReasonRefined       = "refined_low_score"
+)
+
+// GoTDecision records why the search merged, pruned or stopped exploring a path
+type GoTDecision struct {
+	Type       string  `json:"type"` // "merge", "aggregate", "refine", "prune", "skip", "stop"
+	Reason     string  `json:"reason"`
+	NodeID     string  `json:"node_id,omitempty"`
+	TargetID   string  `json:"target_id,omitempty"` // Merge target
+	Thought    string  `json:"thought,omitempty"`
+	Score      float64 `json:"score,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
+	Backend    string  `json:"backend,omitempty"` // Similarity backend that made a merge decision
+	Threshold  float64 `json:"threshold,omitempty"`
+	Error      string  `json:"error,omitempty"` // Why an expansion failed
+}
+
+// maxGoTExpansionFailures is how many expansions in a row may fail before
+// the run stops with the graph it has
+const maxGoTExpansionFailures = 3
+
+// GoTResult represents the complete result
+type GoTResult struct {
+	RunID          string              `json:"run_id,omitempty"`
+	Problem        string              `json:"problem"`
+	Category       string              `json:"category"`
+	BestPath       []*GoTNode          `json:"best_path"`
+	Graph          map[string]*GoTNode `json:"graph,omitempty"`
+	FinalAnswer    string              `json:"final_answer"`
+	TotalNodes     int                 `json:"total_nodes"`
+	MergeCount     int                 `json:"merge_count"`
+	AggregateCount int                 `json:"aggregate_count,omitempty"`
+	RefineCount    int                 `json:"refine_count,omitempty"`
+	TotalToolCalls int                 `json:"total_tool_calls"`
+	ToolsUsed      map[string]int      `json:"tools_used,omitempty"`
+	Decisions      []GoTDecision       `json:"decisions,omitempty"`
+	MaxDepth       int                 `json:"max_depth_reached"`
+	Success        bool                `json:"success"`
+	Error          string              `json:"error,omitempty"` // Provider failure that ended the run early
+	Provider       string              `json:"provider"`
+	Evaluator      string              `json:"evaluator,omitempty"` // Set when a separate critic judged the thoughts
+	Policy         string              `json:"expansion_policy"`
+	Language       string              `json:"language,omitempty"` // Requested language of the reasoning and answer
+	LessonsLearned []string            `json:"lessons_learned,omitempty"`
+	Calibration    *CalibrationReport  `json:"calibration,omitempty"`
+	MergeChecks    *SimilarityStats    `json:"merge_checks,omitempty"` // How the hybrid similarity backend settled merge checks
+	Export         *GraphRendering     `json:"export,omitempty"`       // Graph rendering when output_format is not json
+	JSONRepairUsage
+	HistorySummaryUsage
+	LLMCallUsage
+}
+
+// ProgressUpdate for streaming progress
+type ProgressUpdate struct {
+	Type        string  `json:"type"` // "thought", "tool", "evaluation", "merge", "aggregate", "refine", "solution"
+	NodeID      string  `json:"node_id,omitempty"`
+	Thought     string  `json:"thought,omitempty"`
+	Score       float64 `json:"score,omitempty"`
+	Depth       int     `json:"depth,omitempty"`
+	TotalNodes  int     `json:"total_nodes,omitempty"`
+	Message     string  `json:"message,omitempty"`
+	IsSolution  bool    `json:"is_solution,omitempty"`
+	FinalAnswer string  `json:"final_answer,omitempty"`
+	ToolName    string  `json:"tool_name,omitempty"`
+	ToolInput   string  `json:"tool_input,omitempty"`
+	ToolOutput  string  `json:"tool_output,omitempty"`
+	Step        int     `json:"step,omitempty"` // Step number of a step_start/step_end boundary
+	// Tool calls left in the current budget (nil when unlimited or not applicable)
+	ToolBudgetRemaining *int `json:"tool_budget_remaining,omitempty"`
+}
+
+// gotToolPhase is the tool budget phase for tool actions during expansion
+const gotToolPhase = "expansion"
+
+// NewGraphOfThoughts creates a new GoT instance
+func NewGraphOfThoughts(provider Provider, config GoTConfig) *GraphOfThoughts {
+	g := &GraphOfThoughts{
+		gotState: gotState{nodes: make(map[string]*GoTNode)},
+		provider: provider,
+		config:   config,
+
+		toolBudget: NewToolBudget(config.MaxToolCalls),
+	}
+	g.similarity = newSimilarityBackend(config.SimilarityBackend, provider, g.evaluator(), config.MergeThreshold, config.SimilarityBand)
+	g.policy = newExpansionPolicy(config.ExpansionPolicy, config.BeamWidth, config.BranchingFactor)
+
+	// Initialize tools if enabled
+	if config.EnableTools {
+		g.tools = NewToolRegistry()
+		if len(config.EnabledTools) > 0 {
+			g.tools.SetEnabled(config.EnabledTools)
+		}
+	}
+
+	return g
+}
+
+// Solve runs the Graph of Thoughts algorithm on a problem
+func (g *GraphOfThoughts) Solve(ctx context.Context, problem string) (*GoTResult, error) {
+	// A compressed problem brings problem_lookup for reading the original
+	if src := problemSourceFromContext(ctx); src != nil {
+		g.tools = src.attach(g.tools)
+		g.config.EnableTools = true
+	}
+	if pad := scratchpadFromContext(ctx); pad != nil {
+		g.tools = pad.attach(g.tools)
+	}
+	// Initialize root
+	root := &GoTNode{
+		ID:       "root",
+		NodeType: "thought",
+		Thought:  problem,
+		Depth:    0,
+		Score:    1.0,
+		Visits:   1,
+	}
+	g.nodes["root"] = root
+	g.totalVisits = 1
+	g.toolBudget = NewToolBudget(g.config.MaxToolCalls)
+	g.runID = newGoTRunID()
+	g.problem = problem
+	g.mergeCount = 0
+	g.bestScore = -1
+	g.bestNodeID = ""
+	g.finalAnswer = ""
+	g.toolsUsed = make(map[string]int)
+	g.decisions = nil
+	g.createdAt = time.Now()
+	g.memory = openSharedMemory(ctx, g.config.Memory)
+	g.lessons = g.memory.lessons(problem)
+
+	g.emitProgress(ProgressUpdate{
+		Type:       "thought",
+		NodeID:     "root",
+		Thought:    "Starting reasoning...",
+		TotalNodes: 1,
+	})
+
+	return g.explore(ctx)
+}
+
+// Continue resumes exploration of a previously saved run. The node budget in
+// the current config is applied on top of the nodes already in the graph.
+func (g *GraphOfThoughts) Continue(ctx context.Context, state *GoTRunState) (*GoTResult, error) {
+	if state == nil || len(state.Nodes) == 0 {
+		return nil, fmt.Errorf("run state is empty")
+	}
+	if _, ok := state.Nodes["root"]; !ok {
+		return nil, fmt.Errorf("run %s has no root node", state.RunID)
+	}
+	if pad := scratchpadFromContext(ctx); pad != nil {
+		g.tools = pad.attach(g.tools)
+	}
+
+	g.nodes = state.Nodes
+	g.totalVisits = state.TotalVisits
+	g.toolBudget = NewToolBudget(g.config.MaxToolCalls)
+	g.toolBudget.SetUsed(state.ToolCalls)
+	g.runID = state.RunID
+	g.problem = state.Problem
+	g.mergeCount = state.MergeCount
+	g.bestScore = state.BestScore
+	g.bestNodeID = state.BestNodeID
+	g.finalAnswer = state.FinalAnswer
+	g.toolsUsed = state.ToolsUsed
+	if g.toolsUsed == nil {
+		g.toolsUsed = make(map[string]int)
+	}
+	g.decisions = state.Decisions
+	g.createdAt = state.CreatedAt
+	g.memory = openSharedMemory(ctx, g.config.Memory)
+	g.lessons = nil
+
+	// Previously exhausted leaves may be expandable again under a deeper budget
+	for _, node := range g.nodes {
+		if node.IsTerminal && !node.IsSolution && node.Score >= g.config.MinScore && node.Depth < g.config.MaxDepth {
+			node.IsTerminal = false
+			node.PruneReason = ""
+		}
+	}
+
+	g.emitProgress(ProgressUpdate{
+		Type:       "thought",
+		NodeID:     "root",
+		Message:    fmt.Sprintf("Continuing run %s from %d nodes", g.runID, len(g.nodes)),
+		TotalNodes: len(g.nodes),
+	})
+
+	return g.explore(ctx)
+}
+
+// explore runs the main expansion loop until the node budget is spent
+func (g *GraphOfThoughts) explore(ctx context.Context) (*GoTResult, error) {
+	problem := g.problem
+	g.calibration = newCalibrationRecorder(g.config.Calibration)
+	g.jsonRepair = newJSONRepairer()
+	g.history = newHistorySummarizer(g.provider, g.config.MaxHistoryTokens)
+	result := &GoTResult{
+		RunID:     g.runID,
+		Problem:   problem,
+		Category:  ClassifyProblem(problem),
+		Provider:  g.provider.Name(),
+		Evaluator: providerName(g.config.Evaluator),
+		Policy:    g.policy.Name(),
+		Language:  languageFromContext(ctx),
+
+		LessonsLearned: g.lessons,
+	}
+
+	var bestPath []*GoTNode
+	// A continued run was asked to keep exploring, so a solution it was
+	// already confident in does not stop it: only a better one does
+	confidentAbove := gotConfidentScore
+	if node, ok := g.nodes[g.bestNodeID]; ok && g.bestNodeID != "" {
+		bestPath = g.getPathToNode(node)
+		result.FinalAnswer = g.finalAnswer
+		confidentAbove = max(confidentAbove, g.bestScore)
+	}
+
+	// Main exploration loop
+	stopReason := GoTReasonNodeBudget
+	expansions := 0
+	var failures int
+	var lastErr error
+	for g.visits() < g.config.MaxNodes {
+		// Get expandable nodes (non-terminal leaves or high-scoring nodes)
+		candidates := g.getExpansionCandidates()
+		if len(candidates) == 0 {
+			stopReason = GoTReasonNoCandidates
+			break
+		}
+
+		// Select the candidate to expand with the expansion policy
+		selected := g.selectBestCandidate(candidates)
+		if selected == nil {
+			stopReason = GoTReasonNoCandidates
+			break
+		}
+
+		// Generate actions (thoughts and/or tool calls) from selected node,
+		// noting who proposed them
+		genCtx, proposedBy := withModelCalls(ctx)
+		actions, err := g.generateActions(genCtx, selected, problem)
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			stopReason = GoTReasonLLMCallBudget
+			break
+		}
+		if err != nil {
+			g.recordDecision(GoTDecision{
+				Type:   "skip",
+				Reason: GoTReasonExpansionFail,
+				NodeID: selected.ID,
+				Error:  err.Error(),
+			})
+			failures++
+			if failures >= maxGoTExpansionFailures || ctx.Err() != nil {
+				stopReason, lastErr = GoTReasonProviderError, err
+				break
+			}
+			continue
+		}
+		failures = 0
+
+		visits := g.visits()
+		for i, action := range actions {
+			nodeID := fmt.Sprintf("n%d_%d", visits, i)
+			var newNode *GoTNode
+
+			if action.Type == "tool" && g.config.EnableTools && g.tools != nil {
+				if !g.toolBudget.TryConsume(gotToolPhase) {
+					// Skip tool execution, create regular thought instead
+					g.recordDecision(GoTDecision{
+						Type:    "skip",
+						Reason:  GoTReasonToolBudget,
+						NodeID:  selected.ID,
+						Thought: fmt.Sprintf("%s(%s)", action.Tool, action.Input),
+					})
+					continue
+				}
+
+				// Execute tool and create tool node
+				toolResult := g.tools.Execute(ctx, action.Tool, action.Input)
+				g.recordToolUse(action.Tool)
+				nodeID = g.reserveNodeID(nodeID)
+
+				// Determine score based on tool success
+				score := 0.7
+				if !toolResult.Success {
+					score = 0.3
+				}
+
+				newNode = &GoTNode{
+					ID:          nodeID,
+					NodeType:    "tool",
+					Thought:     fmt.Sprintf("Tool %s: %s", action.Tool, action.Input),
+					Depth:       selected.Depth + 1,
+					Score:       score,
+					Visits:      1,
+					TotalReward: score,
+					Parents:     []string{selected.ID},
+					ToolCall: &ToolCall{
+						Tool:   action.Tool,
+						Input:  action.Input,
+						Reason: action.Content,
+					},
+					ToolResult: &toolResult,
+					Model:      proposedBy.label(),
+					Provenance: proposedBy.provenance(requestedModel(g.provider, "")),
+				}
+
+				g.emitProgress(ProgressUpdate{
+					Type:       "tool",
+					NodeID:     nodeID,
+					ToolName:   action.Tool,
+					ToolInput:  action.Input,
+					ToolOutput: utils.TruncateStr(toolResult.Output, 100),
+					Score:      score,
+					Depth:      newNode.Depth,
+					TotalNodes: g.nodeCount() + 1,
+
+					ToolBudgetRemaining: g.toolBudget.remainingPtr(gotToolPhase),
+				})
+			} else {
+				// Regular thought node
+				thought := action.Content
+
+				// Check if this thought can be merged with existing nodes
+				if g.config.EnableMerging {
+					if mergeTarget, similarity := g.findMergeCandidate(ctx, thought, selected.Depth+1); mergeTarget != nil {
+						// Merge instead of creating new node
+						g.mergeIntoNode(mergeTarget, thought, selected.ID)
+						g.recordDecision(GoTDecision{
+							Type:       "merge",
+							Reason:     GoTReasonSimilarity,
+							NodeID:     selected.ID,
+							TargetID:   mergeTarget.ID,
+							Thought:    thought,
+							Similarity: similarity,
+							Threshold:  g.config.MergeThreshold,
+							Backend:    g.similarity.Name(),
+						})
+
+						g.emitProgress(ProgressUpdate{
+							Type:       "merge",
+							NodeID:     mergeTarget.ID,
+							Message:    fmt.Sprintf("Merged thought into existing node %s (similarity %.2f)", mergeTarget.ID, similarity),
+							TotalNodes: g.nodeCount(),
+						})
+						continue
+					}
+				}
+
+				// Evaluate the new thought
+				score, isSolution, answer, err := g.evaluateThought(ctx, thought, problem, selected)
+				if err != nil {
+					score = 0.5
+				}
+
+				nodeID = g.reserveNodeID(nodeID)
+				newNode = &GoTNode{
+					ID:          nodeID,
+					NodeType:    "thought",
+					Thought:     thought,
+					Depth:       selected.Depth + 1,
+					Score:       score,
+					Visits:      1,
+					TotalReward: score,
+					Parents:     []string{selected.ID},
+					IsTerminal:  isSolution || score < g.config.MinScore || selected.Depth+1 >= g.config.MaxDepth,
+					IsSolution:  isSolution,
+					Answer:      answer,
+					Model:       proposedBy.label(),
+					Provenance:  proposedBy.provenance(requestedModel(g.provider, "")),
+				}
+				if !isSolution {
+					switch {
+					case score < g.config.MinScore:
+						newNode.PruneReason = GoTReasonLowScore
+						g.recordDecision(GoTDecision{
+							Type:      "prune",
+							Reason:    GoTReasonLowScore,
+							NodeID:    nodeID,
+							Thought:   thought,
+							Score:     score,
+							Threshold: g.config.MinScore,
+						})
+					case newNode.Depth >= g.config.MaxDepth:
+						newNode.PruneReason = GoTReasonMaxDepth
+						g.recordDecision(GoTDecision{
+							Type:    "prune",
+							Reason:  GoTReasonMaxDepth,
+							NodeID:  nodeID,
+							Thought: thought,
+							Score:   score,
+						})
+					}
+				}
+
+				g.emitProgress(ProgressUpdate{
+					Type:       "thought",
+					NodeID:     nodeID,
+					Thought:    utils.TruncateStr(thought, 100),
+					Score:      score,
+					Depth:      newNode.Depth,
+					TotalNodes: g.nodeCount() + 1,
+					IsSolution: isSolution,
+				})
+
+				if isSolution {
+					if path := g.considerSolution(newNode); path != nil {
+						bestPath = path
+						result.FinalAnswer = answer
+					}
+				}
+			}
+
+			// Add node to graph
+			g.addNode(newNode, selected)
+
+			// Backpropagate
+			g.backpropagate(newNode, newNode.Score)
+
+			// Give weak but salvageable thoughts another chance
+			if g.shouldRefine(newNode) {
+				// A failed revision leaves the thought pruned; an exhausted
+				// call budget stops the search at the next expansion
+				revised, _ := g.refine(ctx, problem, newNode, selected)
+				if revised != nil && revised.IsSolution {
+					if path := g.considerSolution(revised); path != nil {
+						bestPath = path
+						result.FinalAnswer = revised.Answer
+					}
+				}
+			}
+		}
+
+		// Periodically combine the best thoughts of different branches
+		expansions++
+		if g.config.EnableAggregation && expansions%g.config.aggregationInterval() == 0 && g.visits() < g.config.MaxNodes {
+			node, err := g.aggregate(ctx, problem)
+			if errors.Is(err, ErrLLMCallBudgetExhausted) {
+				stopReason = GoTReasonLLMCallBudget
+				break
+			}
+			if node != nil && node.IsSolution {
+				if path := g.considerSolution(node); path != nil {
+					bestPath = path
+					result.FinalAnswer = node.Answer
+				}
+			}
+		}
+
+		// Early termination if we have a high-confidence solution
+		if bestScore, _ := g.best(); bestScore > confidentAbove {
+			stopReason = GoTReasonConfident
+			break
+		}
+	}
+
+	stop := GoTDecision{Type: "stop", Reason: stopReason}
+	if stopReason == GoTReasonConfident {
+		stop.Score, stop.NodeID = g.best()
+	}
+	g.recordDecision(stop)
+	if lastErr != nil {
+		if g.nodeCount() <= 1 {
+			return nil, fmt.Errorf("expansion failed %d times: %w", failures, lastErr)
+		}
+		result.Error = fmt.Sprintf("stopped after %d failed expansions: %v", failures, lastErr)
+	}
+
+	// If no solution found, extract best path
+	if bestPath == nil {
+		bestPath = g.getBestPath()
+		if bestPath == nil && (stopReason == GoTReasonLLMCallBudget || stopReason == GoTReasonProviderError) {
+			// Stopped before any branch ended: take the best thought so far
+			bestPath = g.getBestPartialPath()
+		}
+		switch {
+		case len(bestPath) > 1 && result.FinalAnswer == "" && lastErr != nil:
+			// The provider is failing: the deepest thought on the best path is the best we have
+			result.FinalAnswer = bestPath[len(bestPath)-1].Thought
+		case len(bestPath) > 0 && result.FinalAnswer == "":
+			result.FinalAnswer = g.extractFinalAnswer(ctx, bestPath, problem)
+		}
+	}
+
+	result.BestPath = bestPath
+	g.mu.RLock()
+	result.Graph = g.nodes
+	result.TotalNodes = len(g.nodes)
+	for _, node := range g.nodes {
+		if node.NodeType == "aggregate" {
+			result.AggregateCount++
+		}
+		if node.RefinedFrom != "" {
+			result.RefineCount++
+		}
+	}
+	g.mu.RUnlock()
+	result.MergeCount = g.merges()
+	result.ToolsUsed = g.toolUseCounts()
+	result.Decisions = g.decisionLog()
+	result.TotalToolCalls = g.toolBudget.Used()
+	result.MaxDepth = g.getMaxDepth()
+	result.Success = result.FinalAnswer != "" && result.Error == ""
+	result.Calibration = g.calibration.report()
+	result.JSONRepairUsage = g.jsonRepair.report()
+	result.HistorySummaryUsage = g.history.report()
+	if hybrid, ok := g.similarity.(*hybridSimilarity); ok {
+		stats := hybrid.Stats()
+		result.MergeChecks = &stats
+	}
+	_, bestNodeID := g.best()
+	g.memory.record(gotEpisode(result, bestNodeID != "", g.calculatePathScore(bestPath)))
+
+	if g.store != nil {
+		if err := g.store.Save(g.Snapshot()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to persist GoT run %s: %v\n", g.runID, err)
+			result.RunID = ""
+		}
+	}
+
+	return result, nil
+}
+
+// considerSolution makes a solution node the best one when its path scores
+// higher, returning that path, or nil when it does not
+func (g *GraphOfThoughts) considerSolution(node *GoTNode) []*GoTNode {
+	path := g.getPathToNode(node)
+	pathScore := g.calculatePathScore(path)
+	if !g.setBest(pathScore, node.ID, node.Answer) {
+		return nil
+	}
+
+	g.emitProgress(ProgressUpdate{
+		Type:        "solution",
+		NodeID:      node.ID,
+		Score:       pathScore,
+		FinalAnswer: node.Answer,
+		Message:     "Found solution!",
+	})
+	return path
+}
+
+// getExpansionCandidates returns nodes that can be expanded
+func (g *GraphOfThoughts) getExpansionCandidates() []*GoTNode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var candidates []*GoTNode
+	for _, node := range g.nodes {
+		if !node.IsTerminal && node.Depth < g.config.MaxDepth {
+			candidates = append(candidates, node)
+		}
+	}
+	// In ID order, so policies that keep the first of equal nodes do not
+	// depend on map order
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	return candidates
+}
+
+// selectBestCandidate selects the node to expand with the expansion policy
+func (g *GraphOfThoughts) selectBestCandidate(candidates []*GoTNode) *GoTNode {
+	if len(candidates) == 0 {
+		return nil
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.policy.Select(g, candidates)
+}
+
+// ucb1 scores node for the UCB1 policy; the caller holds mu
+func (g *GraphOfThoughts) ucb1(node *GoTNode) float64 {
+	if node.Visits == 0 {
+		return math.Inf(1)
+	}
+	exploitation := node.TotalReward / float64(node.Visits)
+	exploration := 1.414 * math.Sqrt(math.Log(float64(g.totalVisits))/float64(node.Visits))
+	// Bonus for nodes with multiple parents (merged nodes are often valuable)
+	mergeBonus := float64(len(node.MergedFrom)) * 0.1
+	return exploitation + exploration + mergeBonus
+}
+
+// GoTAction represents a candidate action (thought or tool call)
+type GoTAction struct {
+	Type    string `json:"type"`    // "thought" or "tool"
+	Content string `json:"content"` // The thought content or tool reason
+	Tool    string `json:"tool"`    // Tool name (if type == "tool")
+	Input   string `json:"input"`   // Tool input (if type == "tool")
+}
+
+// generateActions generates candidate actions (thoughts and optionally tool calls)
+func (g *GraphOfThoughts) generateActions(ctx context.Context, node *GoTNode, problem string) ([]GoTAction, error) {
+	path := g.getPathToNode(node)
+	pathStr := g.formatPathWithTools(ctx, path)
+	if lessons := lessonsSection(g.lessons); lessons != "" && node.ID == "root" {
+		// Past lessons steer the first expansion only
+		problem = problem + "\n\n" + strings.TrimSpace(lessons)
+	}
+
+	var prompt string
+	useTools := g.config.EnableTools && g.tools != nil && g.toolBudget.Available(gotToolPhase)
+	toolCaller, native := nativeToolCaller(g.provider)
+	native = native && useTools
+
+	if native {
+		// Tool schemas travel in the request; thoughts come back as content
+		prompt = fmt.Sprintf(`Problem: %s
+
+Previous reasoning path:
+%s
+
+Generate %d different next actions. Each can be either:
+1. A reasoning thought that advances toward the solution
+2. A call to one of the provided tools to compute, verify, or fetch information
+
+Call tools directly for tool actions. Put the reasoning thoughts in your reply as ONLY a JSON array of strings:
+["thought 1", "thought 2"]
+
+Be strategic - use tools when computation or verification would help.`, problem, pathStr, g.config.BranchingFactor)
+	} else if useTools {
+		toolsPrompt := g.tools.GetToolsPrompt()
+		prompt = fmt.Sprintf(`Problem: %s
+
+Previous reasoning path:
+%s
+
+%s
+
+Generate %d different next actions. Each can be either:
+1. A reasoning thought that advances toward the solution
+2. A tool call to compute, verify, or fetch information
+
+Respond with ONLY a JSON array:
+[
+  {"type": "thought", "content": "reasoning step..."},
+  {"type": "tool", "tool": "calculator", "input": "17 * 23", "content": "verify multiplication"},
+  {"type": "thought", "content": "another approach..."}
+]
+
+Be strategic - use tools when computation or verification would help.`, problem, pathStr, toolsPrompt, g.config.BranchingFactor)
+	} else {
+		prompt = fmt.Sprintf(`Problem: %s
+
+Previous reasoning path:
+%s
+
+Generate %d different next reasoning steps. Each should:
+1. Explore a different angle or approach
+2. Build meaningfully on the previous reasoning
+3. Be specific and actionable
+
+Respond with ONLY a JSON array of strings:
+["thought 1", "thought 2", "thought 3"]`, problem, pathStr, g.config.BranchingFactor)
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a thoughtful reasoning assistant. Generate diverse, creative reasoning steps."},
+		{Role: "user", Content: prompt},
+	}
+
+	var response string
+	var err error
+
+	if native {
+		resp, err := toolCaller.ChatWithTools(ctx, messages, ChatOptions{
+			Temperature: g.config.Temperature,
+			MaxTokens:   2048,
+			Tools:       g.tools.GetToolSchemas(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var actions []GoTAction
+		if strings.TrimSpace(resp.Content) != "" {
+			actions = g.parseActions(resp.Content)
+		}
+		for _, nc := range resp.ToolCalls {
+			call := nc.ToToolCall()
+			actions = append(actions, GoTAction{Type: "tool", Tool: call.Tool, Input: call.Input, Content: call.Reason})
+		}
+		return actions, nil
+	}
+
+	response, err = g.callProvider(ctx, g.provider, messages, ChatOptions{
+		Temperature: g.config.Temperature,
+		MaxTokens:   2048,
+	}, g.enableStreams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return g.parseActions(response), nil
+}
+
+// parseActions parses the LLM response into actions
+func (g *GraphOfThoughts) parseActions(response string) []GoTAction {
+	var actions []GoTAction
+
+	// Try to parse as JSON array of actions
+	jsonStr := utils.ExtractJSONArray(response)
+	if jsonStr != "" {
+		// Try parsing as array of GoTAction objects
+		if err := json.Unmarshal([]byte(jsonStr), &actions); err == nil && len(actions) > 0 {
+			return actions
+		}
+
+		// Try parsing as array of strings (old format)
+		var strings []string
+		if err := json.Unmarshal([]byte(jsonStr), &strings); err == nil {
+			for _, s := range strings {
+				actions = append(actions, GoTAction{Type: "thought", Content: s})
+			}
+			return actions
+		}
+	}
+
+	// Fallback: parse as candidates (old behavior) with logging for observability
+	fmt.Fprintf(os.Stderr, "[WARNING] graph_of_thoughts: failed to extract JSON array in parseActions, falling back to parsing candidates. Response preview: %s\n",
+		utils.TruncateStr(response, 100))
+	candidates := g.parseCandidates(response)
+	for _, c := range candidates {
+		actions = append(actions, GoTAction{Type: "thought", Content: c})
+	}
+
+	return actions
+}
+
+// formatPathWithTools formats a path including tool results, summarizing
+// its older steps when it is long
+func (g *GraphOfThoughts) formatPathWithTools(ctx context.Context, path []*GoTNode) string {
+	if len(path) <= 1 {
+		return "(starting point)"
+	}
+
+	var parts []string
+	for i, node := range path[1:] {
+		mergeInfo := ""
+		if len(node.MergedFrom) > 0 {
+			mergeInfo = fmt.Sprintf(" [merged %d paths]", len(node.MergedFrom)+1)
+		}
+
+		if node.NodeType == "tool" && node.ToolResult != nil {
+			parts = append(parts, fmt.Sprintf("%d. (%.2f)%s [TOOL: %s] %s\n   → Result: %s",
+				i+1, node.Score, mergeInfo, node.ToolCall.Tool, node.ToolCall.Input,
+				utils.TruncateStr(node.ToolResult.Output, 1000)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d. (%.2f)%s %s", i+1, node.Score, mergeInfo, node.Thought))
+		}
+	}
+	return strings.Join(g.history.compactSteps(ctx, parts, "Steps"), "\n")
+}
+
+// findMergeCandidate finds a node to merge with based on semantic similarity.
+// Returns the target and its similarity score, or nil if nothing reaches MergeThreshold.
+func (g *GraphOfThoughts) findMergeCandidate(ctx context.Context, thought string, depth int) (*GoTNode, float64) {
+	// Thoughts never change once added, so only collecting the candidates
+	// needs the lock, not the similarity calls
+	g.mu.RLock()
+	var candidates []*GoTNode
+	for _, node := range g.nodes {
+		if node.Depth == depth && !node.IsTerminal && !node.IsSolution {
+			candidates = append(candidates, node)
+		}
+	}
+	g.mu.RUnlock()
+
+	if len(candidates) == 0 {
+		return nil, 0
+	}
+
+	// Cheap lexical pre-filter: only pairs with some word overlap reach the
+	// backend, most lexically similar first
+	type scored struct {
+		node    *GoTNode
+		overlap float64
+	}
+	var filtered []scored
+	for _, candidate := range candidates {
+		overlap := lexicalOverlap(thought, candidate.Thought)
+		if overlap >= g.config.SimilarityPrefilter {
+			filtered = append(filtered, scored{node: candidate, overlap: overlap})
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].overlap != filtered[j].overlap {
+			return filtered[i].overlap > filtered[j].overlap
+		}
+		return filtered[i].node.ID < filtered[j].node.ID
+	})
+
+	for _, c := range filtered {
+		similarity, err := g.similarity.Similarity(ctx, thought, c.node.Thought)
+		if err == nil && similarity >= g.config.MergeThreshold {
+			return c.node, similarity
+		}
+	}
+
+	return nil, 0
+}
+
+// mergeIntoNode merges a new thought into an existing node
+func (g *GraphOfThoughts) mergeIntoNode(target *GoTNode, newThought, parentID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Add new parent
+	hasParent := false
+	for _, p := range target.Parents {
+		if p == parentID {
+			hasParent = true
+			break
+		}
+	}
+	if !hasParent {
+		target.Parents = append(target.Parents, parentID)
+	}
+
+	// Track merged thoughts
+	target.MergedFrom = append(target.MergedFrom, newThought)
+
+	// Boost score slightly for merged nodes
+	target.Score = math.Min(1.0, target.Score+0.05)
+	target.Visits++
+	target.TotalReward += target.Score
+	g.mergeCount++
+
+	// Update parent's children
+	if parent, ok := g.nodes[parentID]; ok {
+		hasChild := false
+		for _, c := range parent.Children {
+			if c == target.ID {
+				hasChild = true
+				break
+			}
+		}
+		if !hasChild {
+			parent.Children = append(parent.Children, target.ID)
+		}
+	}
+}
+
+// evaluator returns the provider that scores thoughts and judges similarity
+func (g *GraphOfThoughts) evaluator() Provider {
+	if g.config.Evaluator != nil {
+		return g.config.Evaluator
+	}
+	return g.provider
+}
+
+// evaluateThought scores a thought and checks if it's a solution
+func (g *GraphOfThoughts) evaluateThought(ctx context.Context, thought, problem string, parent *GoTNode) (float64, bool, string, error) {
+	path := g.getPathToNode(parent)
+	pathStr := g.formatPathWithTools(ctx, path)
+
+	prompt := fmt.Sprintf(`Evaluate this reasoning step for the problem.
+
+Problem: %s
+
+Previous reasoning:
+%s
+
+New thought to evaluate:
+%s
+
+Respond with ONLY a JSON object:
+{
+  "score": <0.0 to 1.0, how promising is this thought>,
+  "is_solution": <true if this completes the reasoning with a final answer>,
+  "answer": "<final answer if is_solution is true, otherwise empty>",
+  "reasoning": "<brief explanation>"
+}`, problem, pathStr, thought)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a critical evaluator of reasoning steps. Be strict but fair."},
+		{Role: "user", Content: prompt},
+	}
+
+	if !g.config.Calibration.enabled() {
+		return g.evaluateOnce(ctx, messages, 0.3)
+	}
+
+	type evaluation struct {
+		score      float64
+		isSolution bool
+		answer     string
+	}
+	samples, err := sampleEvaluations(ctx, g.config.Calibration.Samples, func(ctx context.Context) (evaluation, error) {
+		score, isSolution, answer, err := g.evaluateOnce(ctx, messages, g.config.Calibration.temperature(0.3))
+		return evaluation{score, isSolution, answer}, err
+	})
+	if err != nil {
+		return 0.5, false, "", err
+	}
+
+	// The mean score decides; a thought is a solution when most samples say so
+	var scores []float64
+	var votes int
+	var answer string
+	for _, s := range samples {
+		scores = append(scores, s.score)
+		if s.isSolution {
+			votes++
+			if answer == "" {
+				answer = s.answer
+			}
+		}
+	}
+	calibrated := summarizeScores(scores)
+	g.calibration.record(calibrated)
+	isSolution := votes*2 > len(samples)
+	if !isSolution {
+		answer = ""
+	}
+	return calibrated.Mean, isSolution, answer, nil
+}
+
+// evaluateOnce asks the evaluator for one score
+func (g *GraphOfThoughts) evaluateOnce(ctx context.Context, messages []ChatMessage, temperature float64) (float64, bool, string, error) {
+	response, err := g.evaluator().Chat(ctx, messages, ChatOptions{
+		Temperature: temperature,
+		MaxTokens:   512,
+	})
+	if err != nil {
+		return 0.5, false, "", err
+	}
+
+	response, err = g.jsonRepair.repair(ctx, g.evaluator(), messages, response, gotEvaluationSchema, ChatOptions{
+		Temperature: 0.1,
+		MaxTokens:   512,
+	})
+	if err != nil {
+		return 0.5, false, "", err
+	}
+	return parseGoTEvaluation(response)
+}
+
+// gotEvaluationSchema is the reply evaluateOnce asks for again when it gets no JSON
+const gotEvaluationSchema = `{"score": 0.0, "is_solution": false, "answer": "", "reasoning": "..."}`
+
+// backpropagate updates scores up the graph (handles multiple parents)
+func (g *GraphOfThoughts) backpropagate(node *GoTNode, reward float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	visited := make(map[string]bool)
+	g.backpropagateRecursive(node, reward, visited)
+}
+
+func (g *GraphOfThoughts) backpropagateRecursive(node *GoTNode, reward float64, visited map[string]bool) {
+	for _, parentID := range node.Parents {
+		if visited[parentID] {
+			continue
+		}
+		visited[parentID] = true
+
+		if parent, ok := g.nodes[parentID]; ok {
+			parent.Visits++
+			parent.TotalReward += reward
+			g.backpropagateRecursive(parent, reward, visited)
+		}
+	}
+}
+
+// getPathToNode returns one path from root to node (picks first parent if multiple)
+func (g *GraphOfThoughts) getPathToNode(node *GoTNode) []*GoTNode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.pathToNodeLocked(node)
+}
+
+func (g *GraphOfThoughts) pathToNodeLocked(node *GoTNode) []*GoTNode {
+	var path []*GoTNode
+	current := node
+	visited := make(map[string]bool)
+
+	for current != nil {
+		if visited[current.ID] {
+			break // Avoid cycles
+		}
+		visited[current.ID] = true
+		path = append([]*GoTNode{current}, path...)
+
+		if len(current.Parents) > 0 {
+			current = g.nodes[current.Parents[0]]
+		} else {
+			break
+		}
+	}
+	return path
+}
+
+// getBestPath returns the highest-scoring path to a terminal/solution node
+func (g *GraphOfThoughts) getBestPath() []*GoTNode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var bestPath []*GoTNode
+	var bestScore float64 = -1
+
+	for _, node := range g.nodes {
+		if node.IsSolution || (node.IsTerminal && len(node.Children) == 0) {
+			path := g.pathToNodeLocked(node)
+			score := pathScoreLocked(path)
+			// Equal paths go to the lower leaf ID, not to map order
+			if score > bestScore || score == bestScore && len(bestPath) > 0 && node.ID < bestPath[len(bestPath)-1].ID {
+				bestScore = score
+				bestPath = path
+			}
+		}
+	}
+
+	return bestPath
+}
+
+// getBestPartialPath returns the path to the highest-scoring thought, for runs
+// cut short before any branch ended
+func (g *GraphOfThoughts) getBestPartialPath() []*GoTNode {
+	g.mu.RLock()
+	var best *GoTNode
+	for _, node := range g.nodes {
+		if node.ID != "root" && strings.TrimSpace(node.Thought) != "" && (best == nil || node.Score > best.Score || node.Score == best.Score && node.ID < best.ID) {
+			best = node
+		}
+	}
+	g.mu.RUnlock()
+	if best == nil {
+		return nil
+	}
+	return g.getPathToNode(best)
+}
+
+// calculatePathScore calculates the average score of a path
+func (g *GraphOfThoughts) calculatePathScore(path []*GoTNode) float64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return pathScoreLocked(path)
+}
+
+func pathScoreLocked(path []*GoTNode) float64 {
+	if len(path) == 0 {
+		return 0
+	}
+	var total float64
+	for _, node := range path {
+		total += node.Score
+	}
+	return total / float64(len(path))
+}
+
+// getMaxDepth returns the maximum depth reached in the graph
+func (g *GraphOfThoughts) getMaxDepth() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	maxDepth := 0
+	for _, node := range g.nodes {
+		if node.Depth > maxDepth {
+			maxDepth = node.Depth
+		}
+	}
+	return maxDepth
+}
+
+// extractFinalAnswer generates a final answer from the best path
+func (g *GraphOfThoughts) extractFinalAnswer(ctx context.Context, path []*GoTNode, problem string) string {
+	pathStr := g.formatPath(ctx, path)
+
+	prompt := fmt.Sprintf(`Based on this reasoning chain, provide the final answer.
+
+Problem: %s
+
+Reasoning:
+%s
+
+Provide ONLY the final answer, nothing else.`, problem, pathStr)
+
+	messages := []ChatMessage{
+		{Role: "user", Content: prompt},
+	}
+
+	response, err := g.provider.Chat(ctx, messages, ChatOptions{
+		Temperature: 0.3,
+		MaxTokens:   512,
+	})
+	if errors.Is(err, ErrLLMCallBudgetExhausted) && len(path) > 1 {
+		// Out of calls: the deepest thought on the best path is the best we have
+		return path[len(path)-1].Thought
+	}
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(response)
+}
+
+// formatPath formats a path for display, summarizing its older steps when
+// it is long
+func (g *GraphOfThoughts) formatPath(ctx context.Context, path []*GoTNode) string {
+	if len(path) <= 1 {
+		return "(starting point)"
+	}
+
+	var parts []string
+	for i, node := range path[1:] { // Skip root
+		mergeInfo := ""
+		if len(node.MergedFrom) > 0 {
+			mergeInfo = fmt.Sprintf(" [merged %d paths]", len(node.MergedFrom)+1)
+		}
+		parts = append(parts, fmt.Sprintf("%d. (%.2f)%s %s", i+1, node.Score, mergeInfo, node.Thought))
+	}
+	return strings.Join(g.history.compactSteps(ctx, parts, "Steps"), "\n")
+}
+
+// parseCandidates parses LLM response into thought candidates
+func (g *GraphOfThoughts) parseCandidates(response string) []string {
+	var candidates []string
+
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr != "" {
+		if err := json.Unmarshal([]byte(jsonStr), &candidates); err == nil {
+			return candidates
+		}
+	}
+
+	// Fallback: parse numbered list with logging for observability
+	fmt.Fprintf(os.Stderr, "[WARNING] graph_of_thoughts: failed to extract JSON in parseCandidates, falling back to parsing numbered list. Response preview: %s\n",
+		utils.TruncateStr(response, 100))
+	lines := strings.Split(response, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "[" || line == "]" {
+			continue
+		}
+		line = strings.TrimLeft(line, "0123456789.-) \"")
+		line = strings.TrimRight(line, "\",")
+		if len(line) > 10 {
+			candidates = append(candidates, line)
+		}
+	}
+
+	if len(candidates) > g.config.BranchingFactor {
+		candidates = candidates[:g.config.BranchingFactor]
+	}
+
+	return candidates
+}
+
+func parseGoTEvaluation(response string) (float64, bool, string, error) {
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr == "" {
+		// Fallback: parse from text
+		fmt.Fprintf(os.Stderr, "[WARNING] graph_of_thoughts: failed to extract JSON in parseGoTEvaluation, using text fallback. Response preview: %s\n",
+			utils.TruncateStr(response, 120))
+		score := 0.5
+		isSolution := false
+		answer := ""
+
+		scoreRe := regexp.MustCompile(`(?is)score\s*[:\-]\s*([0-9]*\.?[0-9]+)`)
+		if match := scoreRe.FindStringSubmatch(response); len(match) > 1 {
+			if val, err := strconv.ParseFloat(match[1], 64); err == nil {
+				if val > 1 && val <= 100 {
+					val = val / 100
+				}
+				if val < 0 {
+					val = 0
+				}
+				if val > 1 {
+					val = 1
+				}
+				score = val
+			}
+		}
+
+		solutionRe := regexp.MustCompile(`(?is)is_solution\s*[:\-]\s*(true|false)`)
+		if match := solutionRe.FindStringSubmatch(response); len(match) > 1 {
+			isSolution = strings.EqualFold(match[1], "true")
+		} else if strings.Contains(strings.ToLower(response), "final answer") {
+			isSolution = true
+		}
+
+		answerRe := regexp.MustCompile(`(?is)(?:final answer|answer)\s*[:\-]\s*(.+)$`)
+		if match := answerRe.FindStringSubmatch(response); len(match) > 1 {
+			answer = strings.TrimSpace(match[1])
+		}
+
+		return score, isSolution, answer, nil
+	}
+
+	var eval struct {
+		Score      float64 `json:"score"`
+		IsSolution bool    `json:"is_solution"`
+		Answer     string  `json:"answer"`
+	}
+
+	if err := json.Unmarshal([]byte(jsonStr), &eval); err != nil {
+		return 0.5, false, "", err
+	}
+
+	// Clamp score
+	if eval.Score < 0 {
+		eval.Score = 0
+	}
+	if eval.Score > 1 {
+		eval.Score = 1
+	}
+
+	return eval.Score, eval.IsSolution, eval.Answer, nil
+}
+
+// FormatGoTResult formats the result for display
+func FormatGoTResult(result *GoTResult) string {
+	var sb strings.Builder
+	l := func(english string) string { return label(result.Language, english) }
+
+	sb.WriteString(fmt.Sprintf("## %s\n\n", l("Graph of Thoughts Result")))
+	sb.WriteString(fmt.Sprintf("**%s:** %s\n\n", l("Problem"), result.Problem))
+	sb.WriteString(fmt.Sprintf("**%s:** %s\n", l("Provider"), result.Provider))
+	sb.WriteString(fmt.Sprintf("**%s:** %d\n", l("Nodes explored"), result.TotalNodes))
+	sb.WriteString(fmt.Sprintf("**%s:** %d\n", l("Path merges"), result.MergeCount))
+	if result.TotalToolCalls > 0 {
+		sb.WriteString(fmt.Sprintf("**%s:** %d\n", l("Tool calls"), result.TotalToolCalls))
+	}
+	sb.WriteString(fmt.Sprintf("**%s:** %d\n\n", l("Max depth"), result.MaxDepth))
+
+	if len(result.ToolsUsed) > 0 {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", l("Tools Used")))
+		for tool, count := range result.ToolsUsed {
+			sb.WriteString(fmt.Sprintf("- %s: %d %s\n", tool, count, l("calls")))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("### %s\n\n", l("Best Reasoning Path")))
+	for i, node := range result.BestPath {
+		if i == 0 {
+			continue // Skip root
+		}
+		mergeInfo := ""
+		if len(node.MergedFrom) > 0 {
+			mergeInfo = fmt.Sprintf(" [%d %s]", len(node.MergedFrom)+1, l("merged paths"))
+		}
+
+		icon := "💭"
+		switch node.NodeType {
+		case "tool":
+			icon = "🔧"
+		case "aggregate":
+			icon = "🔗"
+			mergeInfo += fmt.Sprintf(" [%d %s]", len(node.Parents), l("combined thoughts"))
+		}
+		if node.RefinedFrom != "" {
+			mergeInfo += fmt.Sprintf(" [%s]", l("refined"))
+		}
+
+		if node.NodeType == "tool" && node.ToolResult != nil {
+			sb.WriteString(fmt.Sprintf("%d. %s (%s: %.2f)%s [%s] %s\n", i, icon, l("score"), node.Score, mergeInfo, node.ToolCall.Tool, node.ToolCall.Input))
+			sb.WriteString(fmt.Sprintf("   → %s\n\n", utils.TruncateStr(node.ToolResult.Output, 100)))
+		} else {
+			sb.WriteString(fmt.Sprintf("%d. %s (%s: %.2f)%s %s\n\n", i, icon, l("score"), node.Score, mergeInfo, node.Thought))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("### %s\n\n%s\n", l("Final Answer"), result.FinalAnswer))
+
+	// JSON summary
+	summary := map[string]interface{}{
+		"success":      result.Success,
+		"final_answer": result.FinalAnswer,
+		"total_nodes":  result.TotalNodes,
+		"merge_count":  result.MergeCount,
+		"max_depth":    result.MaxDepth,
+		"provider":     result.Provider,
+	}
+	if result.AggregateCount > 0 {
+		summary["aggregate_count"] = result.AggregateCount
+	}
+	if result.RefineCount > 0 {
+		summary["refine_count"] = result.RefineCount
+	}
+	if result.TotalToolCalls > 0 {
+		summary["total_tool_calls"] = result.TotalToolCalls
+		summary["tools_used"] = result.ToolsUsed
+	}
+	jsonResult, _ := json.MarshalIndent(summary, "", "  ")
+
+	sb.WriteString(fmt.Sprintf("\n### %s\n```json\n", l("JSON Summary")))
+	sb.WriteString(string(jsonResult))
+	sb.WriteString("\n```\n")
+
+	return sb.String()
+}
+
+// SortNodesByScore returns nodes sorted by score
+func SortNodesByScore(nodes map[string]*GoTNode) []*GoTNode {
+	var sorted []*GoTNode
+	for _, n := range nodes {
+		sorted = append(sorted, n)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+	return sorted
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// ============ GoT Aggregation ============
+//
+// Expansion and merging only ever extend one line of reasoning at a time.
+// With aggregation on, every AggregationInterval expansions the engine picks
+// the AggregationSize best thoughts from different branches and asks the
+// LLM to synthesize them into one combined thought, added as an "aggregate"
+// node whose parents are all of them.
+
+const (
+	defaultAggregationInterval = 3
+	defaultAggregationSize     = 3
+)
+
+// aggregationSize clamps AggregationSize to 2-3 parents
+func (c GoTConfig) aggregationSize() int {
+	if c.AggregationSize <= 0 {
+		return defaultAggregationSize
+	}
+	return min(max(c.AggregationSize, 2), 3)
+}
+
+func (c GoTConfig) aggregationInterval() int {
+	if c.AggregationInterval <= 0 {
+		return defaultAggregationInterval
+	}
+	return c.AggregationInterval
+}
+
+// ancestors returns the IDs of every node reachable through node's parents
+func (g *GraphOfThoughts) ancestors(node *GoTNode) map[string]bool {
+	seen := make(map[string]bool)
+	stack := append([]string(nil), node.Parents...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if parent, ok := g.nodes[id]; ok {
+			stack = append(stack, parent.Parents...)
+		}
+	}
+	return seen
+}
+
+// aggregationKey identifies a set of parents regardless of order
+func aggregationKey(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "+")
+}
+
+// selectAggregationSet picks the best-scoring thoughts, none an ancestor of
+// another, that have not been aggregated together before. It returns nil
+// when fewer than two qualify.
+func (g *GraphOfThoughts) selectAggregationSet() []*GoTNode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	done := make(map[string]bool)
+	var pool []*GoTNode
+	for _, node := range g.nodes {
+		switch {
+		case node.NodeType == "aggregate":
+			done[aggregationKey(node.Parents)] = true
+			pool = append(pool, node)
+		case node.NodeType == "tool", node.Depth == 0, node.PruneReason != "":
+		default:
+			pool = append(pool, node)
+		}
+	}
+	sort.Slice(pool, func(i, j int) bool { return betterNode(pool[i], pool[j]) })
+
+	var set []*GoTNode
+	lineage := make(map[string]bool) // Picked nodes and their ancestors
+	for _, node := range pool {
+		if len(set) == g.config.aggregationSize() {
+			break
+		}
+		if lineage[node.ID] {
+			continue
+		}
+		up := g.ancestors(node)
+		related := false
+		for _, picked := range set {
+			if up[picked.ID] {
+				related = true
+				break
+			}
+		}
+		if related {
+			continue
+		}
+		set = append(set, node)
+		lineage[node.ID] = true
+		for id := range up {
+			lineage[id] = true
+		}
+	}
+	if len(set) < 2 {
+		return nil
+	}
+	ids := make([]string, len(set))
+	for i, node := range set {
+		ids[i] = node.ID
+	}
+	if done[aggregationKey(ids)] {
+		return nil
+	}
+	return set
+}
+
+// synthesizeThoughts asks the LLM to combine the set into one thought
+func (g *GraphOfThoughts) synthesizeThoughts(ctx context.Context, problem string, set []*GoTNode) (string, error) {
+	var branches strings.Builder
+	for i, node := range set {
+		fmt.Fprintf(&branches, "Line of reasoning %d (score %.2f):\n%s\n\n", i+1, node.Score, g.formatPathWithTools(ctx, g.getPathToNode(node)))
+	}
+
+	prompt := fmt.Sprintf(`Problem: %s
+
+Several promising lines of reasoning have been explored separately:
+
+%sWrite ONE new reasoning step that combines the strongest insights of all of them into a single, coherent step toward the solution. Resolve any conflicts between them rather than listing them side by side.
+
+Respond with ONLY the combined reasoning step.`, problem, branches.String())
+
+	response, err := g.provider.Chat(ctx, []ChatMessage{
+		{Role: "system", Content: "You are a thoughtful reasoning assistant. Synthesize separate lines of reasoning into one combined step."},
+		{Role: "user", Content: prompt},
+	}, ChatOptions{Temperature: g.config.Temperature, MaxTokens: 1024})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// aggregate combines the best thoughts of different branches into a new
+// aggregate node and adds it to the graph. It returns nil when nothing
+// qualifies for aggregation.
+func (g *GraphOfThoughts) aggregate(ctx context.Context, problem string) (*GoTNode, error) {
+	set := g.selectAggregationSet()
+	if set == nil {
+		return nil, nil
+	}
+
+	genCtx, proposedBy := withModelCalls(ctx)
+	thought, err := g.synthesizeThoughts(genCtx, problem, set)
+	if err != nil || thought == "" {
+		return nil, err
+	}
+
+	// Score the combined thought in the context of the best branch
+	score, isSolution, answer, err := g.evaluateThought(ctx, thought, problem, set[0])
+	if err != nil {
+		score = 0.5
+	}
+
+	depth := 0
+	parents := make([]string, len(set))
+	for i, node := range set {
+		parents[i] = node.ID
+		depth = max(depth, node.Depth+1)
+	}
+	node := &GoTNode{
+		ID:          g.reserveNodeID(fmt.Sprintf("a%d", g.visits())),
+		NodeType:    "aggregate",
+		Thought:     thought,
+		Depth:       depth,
+		Score:       score,
+		Visits:      1,
+		TotalReward: score,
+		Parents:     parents,
+		IsTerminal:  isSolution || depth >= g.config.MaxDepth,
+		IsSolution:  isSolution,
+		Answer:      answer,
+		Model:       proposedBy.label(),
+		Provenance:  proposedBy.provenance(requestedModel(g.provider, "")),
+	}
+	if !isSolution && depth >= g.config.MaxDepth {
+		node.PruneReason = GoTReasonMaxDepth
+	}
+
+	g.addNode(node, set...)
+	g.backpropagate(node, score)
+
+	g.recordDecision(GoTDecision{
+		Type:    "aggregate",
+		Reason:  GoTReasonAggregated,
+		NodeID:  node.ID,
+		Thought: fmt.Sprintf("combined %s", strings.Join(parents, ", ")),
+		Score:   score,
+	})
+	g.emitProgress(ProgressUpdate{
+		Type:       "aggregate",
+		NodeID:     node.ID,
+		Thought:    utils.TruncateStr(thought, 100),
+		Score:      score,
+		Depth:      depth,
+		TotalNodes: g.nodeCount(),
+		IsSolution: isSolution,
+		Message:    fmt.Sprintf("Aggregated %d thoughts into %s", len(set), node.ID),
+	})
+	return node, nil
+}
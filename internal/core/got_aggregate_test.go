@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// aggregateProvider proposes two thoughts per expansion, scores every thought
+// 0.6 and answers synthesis prompts with a combined thought
+type aggregateProvider struct {
+	mu         sync.Mutex
+	syntheses  []string
+	expansions int
+}
+
+func (p *aggregateProvider) Name() string { return "aggregate" }
+
+func (p *aggregateProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch system := messages[0].Content; {
+	case strings.Contains(system, "Synthesize separate lines"):
+		p.syntheses = append(p.syntheses, messages[1].Content)
+		return "Combine the invariant with the bound", nil
+	case strings.Contains(system, "critical evaluator"):
+		return `{"score": 0.6, "is_solution": false, "answer": "", "reasoning": "partial"}`, nil
+	}
+	p.expansions++
+	return `[{"type": "thought", "content": "Look for an invariant"}, {"type": "thought", "content": "Bound the search space"}]`, nil
+}
+
+func TestGoT_AggregationNodes(t *testing.T) {
+	provider := &aggregateProvider{}
+	config := DefaultGoTConfig()
+	config.MaxNodes = 8
+	config.EnableMerging = false
+	config.EnableAggregation = true
+	config.AggregationInterval = 1
+	config.AggregationSize = 2
+
+	result, err := NewGraphOfThoughts(provider, config).Solve(context.Background(), "Prove the puzzle has no solution")
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if result.AggregateCount == 0 || len(provider.syntheses) != result.AggregateCount {
+		t.Fatalf("Expected aggregate nodes, got %d after %d synthesis prompts", result.AggregateCount, len(provider.syntheses))
+	}
+	if !strings.Contains(provider.syntheses[0], "Look for an invariant") || !strings.Contains(provider.syntheses[0], "Bound the search space") {
+		t.Errorf("Expected both branches in the synthesis prompt:\n%s", provider.syntheses[0])
+	}
+
+	seen := map[string]bool{}
+	for _, node := range result.Graph {
+		if node.NodeType != "aggregate" {
+			continue
+		}
+		if len(node.Parents) != 2 {
+			t.Errorf("Expected %s to combine 2 parents, got %v", node.ID, node.Parents)
+		}
+		key := aggregationKey(node.Parents)
+		if seen[key] {
+			t.Errorf("Expected each set of parents to be aggregated once, %s repeats %v", node.ID, node.Parents)
+		}
+		seen[key] = true
+		for _, parentID := range node.Parents {
+			parent := result.Graph[parentID]
+			if !strings.Contains(strings.Join(parent.Children, ","), node.ID) {
+				t.Errorf("Expected %s to list %s as a child", parentID, node.ID)
+			}
+		}
+	}
+
+	export := buildGraphExport(result.Graph, nil)
+	for _, e := range export.Edges {
+		if result.Graph[e.To].NodeType == "aggregate" && e.Kind != GraphEdgeAggregate {
+			t.Errorf("Expected aggregate edges into %s, got %s", e.To, e.Kind)
+		}
+	}
+	if !strings.Contains(FormatGoTResult(result), "aggregate_count") {
+		t.Error("Expected the summary to report aggregate_count")
+	}
+}
+
+func TestSelectAggregationSet_SkipsRelatedNodes(t *testing.T) {
+	g := policyGraph(DefaultGoTConfig(),
+		&GoTNode{ID: "root", Score: 0.5},
+		&GoTNode{ID: "a", Depth: 1, Score: 0.7, Parents: []string{"root"}},
+		&GoTNode{ID: "a1", Depth: 2, Score: 0.9, Parents: []string{"a"}},
+		&GoTNode{ID: "b", Depth: 1, Score: 0.6, Parents: []string{"root"}},
+		&GoTNode{ID: "c", Depth: 1, Score: 0.1, Parents: []string{"root"}, PruneReason: GoTReasonLowScore},
+	)
+	g.config.AggregationSize = 3
+
+	set := g.selectAggregationSet()
+	var ids []string
+	for _, node := range set {
+		ids = append(ids, node.ID)
+	}
+	if strings.Join(ids, ",") != "a1,b" {
+		t.Fatalf("Expected a1 and b (a is a1's ancestor, c was pruned), got %v", ids)
+	}
+
+	g.nodes["x"] = &GoTNode{ID: "x", NodeType: "aggregate", Depth: 3, Score: 0.2, Parents: []string{"b", "a1"}}
+	if set := g.selectAggregationSet(); set != nil {
+		t.Errorf("Expected an already aggregated set to be skipped, got %v", set)
+	}
+}
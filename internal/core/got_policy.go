@@ -0,0 +1,156 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Expansion policy names accepted in GoTConfig.ExpansionPolicy
+const (
+	ExpansionPolicyUCB1      = "ucb1"
+	ExpansionPolicyBeam      = "beam"
+	ExpansionPolicyBestFirst = "best_first"
+)
+
+// ExpansionPolicy picks the next node to expand from the expandable nodes.
+// Select is called with the graph read-locked.
+type ExpansionPolicy interface {
+	Name() string
+	Select(g *GraphOfThoughts, candidates []*GoTNode) *GoTNode
+}
+
+// newExpansionPolicy builds the policy named by kind, falling back to UCB1
+// when the name is unknown. beamWidth <= 0 uses the branching factor.
+func newExpansionPolicy(kind string, beamWidth, branchingFactor int) ExpansionPolicy {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", ExpansionPolicyUCB1:
+		return ucb1Policy{}
+	case ExpansionPolicyBestFirst, "best-first", "greedy":
+		return bestFirstPolicy{}
+	case ExpansionPolicyBeam:
+		if beamWidth <= 0 {
+			beamWidth = max(branchingFactor, 1)
+		}
+		return beamPolicy{width: beamWidth}
+	default:
+		fmt.Fprintf(os.Stderr, "[WARNING] graph_of_thoughts: unknown expansion policy %q, using ucb1\n", kind)
+		return ucb1Policy{}
+	}
+}
+
+// ucb1Policy balances a node's mean reward against how rarely it was visited
+type ucb1Policy struct{}
+
+func (ucb1Policy) Name() string { return ExpansionPolicyUCB1 }
+
+func (ucb1Policy) Select(g *GraphOfThoughts, candidates []*GoTNode) *GoTNode {
+	var best *GoTNode
+	bestUCB := math.Inf(-1)
+	for _, node := range candidates {
+		if ucb := g.ucb1(node); ucb > bestUCB || ucb == bestUCB && best != nil && betterNode(node, best) {
+			bestUCB = ucb
+			best = node
+		}
+	}
+	return best
+}
+
+// betterNode orders nodes by score, then shallower, then ID so selection
+// does not depend on map order
+func betterNode(a, b *GoTNode) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	return a.ID < b.ID
+}
+
+// bestOf returns the best node by betterNode
+func bestOf(nodes []*GoTNode) *GoTNode {
+	var best *GoTNode
+	for _, node := range nodes {
+		if best == nil || betterNode(node, best) {
+			best = node
+		}
+	}
+	return best
+}
+
+// unexpanded returns the nodes that have no children yet
+func unexpanded(nodes []*GoTNode) []*GoTNode {
+	var fresh []*GoTNode
+	for _, node := range nodes {
+		if len(node.Children) == 0 {
+			fresh = append(fresh, node)
+		}
+	}
+	return fresh
+}
+
+// bestFirstPolicy greedily expands the highest-scoring node not yet
+// expanded, re-expanding the best node once every candidate has children
+type bestFirstPolicy struct{}
+
+func (bestFirstPolicy) Name() string { return ExpansionPolicyBestFirst }
+
+func (bestFirstPolicy) Select(g *GraphOfThoughts, candidates []*GoTNode) *GoTNode {
+	if fresh := unexpanded(candidates); len(fresh) > 0 {
+		return bestOf(fresh)
+	}
+	return bestOf(candidates)
+}
+
+// beamPolicy searches level by level, keeping only the top-width nodes of
+// each depth by score. Nodes outside a depth's beam are never expanded.
+type beamPolicy struct {
+	width int
+}
+
+func (beamPolicy) Name() string { return ExpansionPolicyBeam }
+
+func (p beamPolicy) Select(g *GraphOfThoughts, candidates []*GoTNode) *GoTNode {
+	byDepth := make(map[int][]*GoTNode)
+	for _, node := range g.nodes {
+		byDepth[node.Depth] = append(byDepth[node.Depth], node)
+	}
+
+	inBeam := make(map[string]bool)
+	for _, level := range byDepth {
+		sort.Slice(level, func(i, j int) bool { return betterNode(level[i], level[j]) })
+		for _, node := range level[:min(p.width, len(level))] {
+			inBeam[node.ID] = true
+		}
+	}
+
+	var beam []*GoTNode
+	for _, node := range candidates {
+		if inBeam[node.ID] {
+			beam = append(beam, node)
+		}
+	}
+	if len(beam) == 0 {
+		return nil
+	}
+
+	// Finish the shallowest level of the beam before going deeper
+	fresh := unexpanded(beam)
+	if len(fresh) == 0 {
+		return bestOf(beam)
+	}
+	shallowest := fresh[0].Depth
+	for _, node := range fresh {
+		shallowest = min(shallowest, node.Depth)
+	}
+	var level []*GoTNode
+	for _, node := range fresh {
+		if node.Depth == shallowest {
+			level = append(level, node)
+		}
+	}
+	return bestOf(level)
+}
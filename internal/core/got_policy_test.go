@@ -0,0 +1,71 @@
+package core
+
+import "testing"
+
+// policyGraph builds a graph from nodes and links each to its parent
+func policyGraph(config GoTConfig, nodes ...*GoTNode) *GraphOfThoughts {
+	g := NewGraphOfThoughts(nil, config)
+	for _, node := range nodes {
+		node.Visits = max(node.Visits, 1)
+		node.TotalReward = node.Score * float64(node.Visits)
+		g.nodes[node.ID] = node
+		g.totalVisits += node.Visits
+		for _, parent := range node.Parents {
+			g.nodes[parent].Children = append(g.nodes[parent].Children, node.ID)
+		}
+	}
+	return g
+}
+
+func TestNewExpansionPolicy(t *testing.T) {
+	for kind, want := range map[string]string{"": ExpansionPolicyUCB1, "BEAM": ExpansionPolicyBeam, "best-first": ExpansionPolicyBestFirst, "random": ExpansionPolicyUCB1} {
+		if got := newExpansionPolicy(kind, 0, 3).Name(); got != want {
+			t.Errorf("newExpansionPolicy(%q) = %s, want %s", kind, got, want)
+		}
+	}
+	if beam := newExpansionPolicy(ExpansionPolicyBeam, 0, 4).(beamPolicy); beam.width != 4 {
+		t.Errorf("Expected the beam width to default to the branching factor, got %d", beam.width)
+	}
+}
+
+func TestExpansionPolicies_Select(t *testing.T) {
+	// root has been expanded into a, b and c; a was expanded into a1
+	nodes := func() []*GoTNode {
+		return []*GoTNode{
+			{ID: "root", Score: 0.5, Visits: 5},
+			{ID: "a", Depth: 1, Score: 0.6, Visits: 3, Parents: []string{"root"}},
+			{ID: "b", Depth: 1, Score: 0.8, Parents: []string{"root"}},
+			{ID: "c", Depth: 1, Score: 0.4, Parents: []string{"root"}},
+			{ID: "a1", Depth: 2, Score: 0.9, Parents: []string{"a"}},
+		}
+	}
+	candidates := func(g *GraphOfThoughts) []*GoTNode {
+		return g.getExpansionCandidates()
+	}
+
+	config := DefaultGoTConfig()
+	config.ExpansionPolicy = ExpansionPolicyBestFirst
+	g := policyGraph(config, nodes()...)
+	if got := g.selectBestCandidate(candidates(g)); got.ID != "a1" {
+		t.Errorf("Expected best_first to pick the highest-scoring unexpanded node, got %s", got.ID)
+	}
+
+	// The beam finishes depth 1 before going deeper, and c falls outside a width of 2
+	config.ExpansionPolicy = ExpansionPolicyBeam
+	config.BeamWidth = 2
+	g = policyGraph(config, nodes()...)
+	if got := g.selectBestCandidate(candidates(g)); got.ID != "b" {
+		t.Errorf("Expected the beam to expand b first, got %s", got.ID)
+	}
+	g.nodes["b1"] = &GoTNode{ID: "b1", Depth: 2, Score: 0.7, Visits: 1, Parents: []string{"b"}}
+	g.nodes["b"].Children = []string{"b1"}
+	if got := g.selectBestCandidate(candidates(g)); got.ID != "a1" {
+		t.Errorf("Expected the beam to skip c and move to depth 2, got %s", got.ID)
+	}
+
+	config.ExpansionPolicy = ExpansionPolicyUCB1
+	g = policyGraph(config, nodes()...)
+	if got := g.selectBestCandidate(candidates(g)); got.ID == "a" || got.ID == "root" {
+		t.Errorf("Expected ucb1 to favor rarely visited nodes, got %s", got.ID)
+	}
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// ============ GoT Refinement ============
+//
+// A thought scoring under MinScore is pruned, even when it is close and only
+// needs fixing. With MaxRefinements > 0, a pruned thought scoring at least
+// half of MinScore gets an improvement prompt instead, and the revision is
+// added as a sibling linked back to it by RefinedFrom. A revision still
+// below MinScore is refined again while it keeps improving, until the run's
+// MaxRefinements are used up.
+
+// refinementFloor is the lowest score a pruned thought can have and still
+// be worth refining
+func (c GoTConfig) refinementFloor() float64 {
+	return c.MinScore / 2
+}
+
+// refinementCountLocked is the number of refined nodes in the graph
+func (g *GraphOfThoughts) refinementCountLocked() int {
+	count := 0
+	for _, node := range g.nodes {
+		if node.RefinedFrom != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// shouldRefine reports whether a node is a weak but salvageable thought and
+// the refinement budget allows another revision
+func (g *GraphOfThoughts) shouldRefine(node *GoTNode) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.config.MaxRefinements > 0 &&
+		node.NodeType == "thought" &&
+		node.PruneReason == GoTReasonLowScore &&
+		node.Score >= g.config.refinementFloor() &&
+		g.totalVisits < g.config.MaxNodes &&
+		g.refinementCountLocked() < g.config.MaxRefinements
+}
+
+// reviseThought asks the LLM to fix a weak thought
+func (g *GraphOfThoughts) reviseThought(ctx context.Context, problem string, node, parent *GoTNode) (string, error) {
+	prompt := fmt.Sprintf(`Problem: %s
+
+Previous reasoning path:
+%s
+
+Proposed next step (scored %.2f out of 1, below the %.2f needed to continue):
+%s
+
+The step is weak but may be salvageable. Identify what makes it weak (an error, a gap, vagueness or a poor fit with the path so far) and write an improved version of the same step that fixes it.
+
+Respond with ONLY the improved reasoning step.`, problem, g.formatPathWithTools(ctx, g.getPathToNode(parent)), node.Score, g.config.MinScore, node.Thought)
+
+	response, err := g.provider.Chat(ctx, []ChatMessage{
+		{Role: "system", Content: "You are a thoughtful reasoning assistant. Improve weak reasoning steps."},
+		{Role: "user", Content: prompt},
+	}, ChatOptions{Temperature: g.config.Temperature, MaxTokens: 1024})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// refine revises a pruned thought, and its revisions while they stay below
+// MinScore and keep improving, adding each revision to the graph. It returns
+// the last revision, or nil when none was made.
+func (g *GraphOfThoughts) refine(ctx context.Context, problem string, node, parent *GoTNode) (*GoTNode, error) {
+	var last *GoTNode
+	for current := node; g.shouldRefine(current); current = last {
+		genCtx, proposedBy := withModelCalls(ctx)
+		thought, err := g.reviseThought(genCtx, problem, current, parent)
+		if err != nil || thought == "" {
+			return last, err
+		}
+
+		score, isSolution, answer, err := g.evaluateThought(ctx, thought, problem, parent)
+		if err != nil {
+			score = 0.5
+		}
+
+		revised := &GoTNode{
+			ID:          g.reserveNodeID(fmt.Sprintf("r%d", g.visits())),
+			NodeType:    "thought",
+			Thought:     thought,
+			Depth:       current.Depth,
+			Score:       score,
+			Visits:      1,
+			TotalReward: score,
+			Parents:     []string{parent.ID},
+			IsTerminal:  isSolution || score < g.config.MinScore || current.Depth >= g.config.MaxDepth,
+			IsSolution:  isSolution,
+			Answer:      answer,
+			RefinedFrom: current.ID,
+			Model:       proposedBy.label(),
+			Provenance:  proposedBy.provenance(requestedModel(g.provider, "")),
+		}
+		if !isSolution {
+			switch {
+			case score < g.config.MinScore:
+				revised.PruneReason = GoTReasonLowScore
+			case revised.Depth >= g.config.MaxDepth:
+				revised.PruneReason = GoTReasonMaxDepth
+			}
+		}
+
+		g.addNode(revised, parent)
+		g.backpropagate(revised, score)
+
+		g.recordDecision(GoTDecision{
+			Type:      "refine",
+			Reason:    GoTReasonRefined,
+			NodeID:    revised.ID,
+			TargetID:  current.ID,
+			Thought:   thought,
+			Score:     score,
+			Threshold: g.config.MinScore,
+		})
+		g.emitProgress(ProgressUpdate{
+			Type:       "refine",
+			NodeID:     revised.ID,
+			Thought:    utils.TruncateStr(thought, 100),
+			Score:      score,
+			Depth:      revised.Depth,
+			TotalNodes: g.nodeCount(),
+			IsSolution: isSolution,
+			Message:    fmt.Sprintf("Refined %s (%.2f -> %.2f)", current.ID, current.Score, score),
+		})
+
+		last = revised
+		if score <= current.Score {
+			break // Not improving; stop spending refinements on this thought
+		}
+	}
+	return last, nil
+}
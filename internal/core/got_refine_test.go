@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// refineProvider proposes weak thoughts and scores each revision higher
+type refineProvider struct {
+	mu        sync.Mutex
+	revisions int
+	weak      string
+}
+
+func (p *refineProvider) Name() string { return "refine" }
+
+func (p *refineProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	system, prompt := messages[0].Content, messages[len(messages)-1].Content
+	switch {
+	case strings.Contains(system, "Improve weak reasoning"):
+		p.revisions++
+		return fmt.Sprintf("Revision %d", p.revisions), nil
+	case strings.Contains(system, "critical evaluator"):
+		score := 0.2
+		switch {
+		case strings.Contains(prompt, "evaluate:\nRevision 1"):
+			score = 0.25
+		case strings.Contains(prompt, "evaluate:\nRevision 2"):
+			score = 0.8
+		case strings.Contains(prompt, "evaluate:\nHopeless"):
+			score = 0.05
+		}
+		return fmt.Sprintf(`{"score": %g, "is_solution": false, "answer": "", "reasoning": "ok"}`, score), nil
+	}
+	return fmt.Sprintf(`[{"type": "thought", "content": %q}]`, p.weak), nil
+}
+
+func TestGoT_RefinesWeakThoughts(t *testing.T) {
+	provider := &refineProvider{weak: "Weak idea"}
+	config := DefaultGoTConfig()
+	config.MaxNodes = 5
+	config.EnableMerging = false
+	config.MaxRefinements = 2
+
+	result, err := NewGraphOfThoughts(provider, config).Solve(context.Background(), "What is 17 * 23?")
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if provider.revisions != 2 || result.RefineCount != 2 {
+		t.Fatalf("Expected the run's 2 refinements to be used, got %d revisions and refine_count %d", provider.revisions, result.RefineCount)
+	}
+
+	revisionOf := map[string]*GoTNode{}
+	for _, node := range result.Graph {
+		if node.RefinedFrom != "" {
+			revisionOf[node.RefinedFrom] = node
+		}
+	}
+	original := result.Graph["n1_0"]
+	first := revisionOf["n1_0"]
+	if original.PruneReason != GoTReasonLowScore || first == nil || first.Thought != "Revision 1" {
+		t.Fatalf("Expected the pruned first thought to be revised, got %+v", revisionOf)
+	}
+	second := revisionOf[first.ID]
+	if second == nil || second.PruneReason != "" || second.Parents[0] != "root" || second.Depth != 1 {
+		t.Fatalf("Expected the still-weak revision to be refined into a live sibling, got %+v", second)
+	}
+	if len(second.Children) == 0 {
+		t.Error("Expected the successful revision to be expanded")
+	}
+
+	refines := 0
+	for _, d := range result.Decisions {
+		if d.Type == "refine" && d.Reason == GoTReasonRefined {
+			refines++
+		}
+	}
+	if refines != 2 {
+		t.Errorf("Expected 2 refine decisions, got %d", refines)
+	}
+
+	kinds := map[string]string{}
+	for _, e := range buildGraphExport(result.Graph, nil).Edges {
+		if e.Kind == GraphEdgeRefined {
+			kinds[e.To] = e.From
+		}
+	}
+	if kinds[second.ID] != first.ID || kinds[first.ID] != "n1_0" {
+		t.Errorf("Expected refined_from edges for both revisions, got %v", kinds)
+	}
+}
+
+func TestGoT_RefinementSkipsHopelessThoughts(t *testing.T) {
+	provider := &refineProvider{weak: "Hopeless"}
+	config := DefaultGoTConfig()
+	config.MaxNodes = 5
+	config.EnableMerging = false
+	config.MaxRefinements = 3
+
+	result, err := NewGraphOfThoughts(provider, config).Solve(context.Background(), "What is 17 * 23?")
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if provider.revisions != 0 || result.RefineCount != 0 {
+		t.Errorf("Expected thoughts under half of min_score to be abandoned, got %d revisions", provider.revisions)
+	}
+}
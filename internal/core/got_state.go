@@ -0,0 +1,133 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"reasoning-tools/utils"
+)
+
+// gotState is the graph and search state of a GoT run. mu guards all of it,
+// the fields of the nodes in the graph included, so expansions can run in
+// parallel on one GraphOfThoughts. Methods named ...Locked expect the caller
+// to hold mu.
+type gotState struct {
+	mu          sync.RWMutex
+	nodes       map[string]*GoTNode
+	reserved    map[string]bool // Node IDs handed out but not added yet
+	totalVisits int
+	mergeCount  int
+	bestScore   float64
+	bestNodeID  string
+	finalAnswer string
+	toolsUsed   map[string]int
+	decisions   []GoTDecision
+}
+
+// visits returns the number of nodes added so far, the node budget's measure
+func (s *gotState) visits() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.totalVisits
+}
+
+// nodeCount returns the number of nodes in the graph
+func (s *gotState) nodeCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.nodes)
+}
+
+// reserveNodeID returns id, or id with a suffix when another expansion
+// already holds it, and keeps it from being handed out again
+func (s *gotState) reserveNodeID(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reserved == nil {
+		s.reserved = make(map[string]bool)
+	}
+	unique := id
+	for n := 2; s.nodes[unique] != nil || s.reserved[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", id, n)
+	}
+	s.reserved[unique] = true
+	return unique
+}
+
+// addNode adds node to the graph as a child of parents
+func (s *gotState) addNode(node *GoTNode, parents ...*GoTNode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[node.ID] = node
+	delete(s.reserved, node.ID)
+	for _, parent := range parents {
+		parent.Children = append(parent.Children, node.ID)
+	}
+	s.totalVisits++
+}
+
+// recordDecision appends an entry to the audit log of search decisions
+func (s *gotState) recordDecision(d GoTDecision) {
+	if d.Thought != "" {
+		d.Thought = utils.TruncateStr(d.Thought, 200)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions = append(s.decisions, d)
+}
+
+// decisionLog returns a copy of the audit log
+func (s *gotState) decisionLog() []GoTDecision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]GoTDecision(nil), s.decisions...)
+}
+
+// recordToolUse counts a tool call the search made
+func (s *gotState) recordToolUse(tool string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.toolsUsed == nil {
+		s.toolsUsed = make(map[string]int)
+	}
+	s.toolsUsed[tool]++
+}
+
+// toolUseCounts returns a copy of the tool call counts
+func (s *gotState) toolUseCounts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[string]int, len(s.toolsUsed))
+	for tool, n := range s.toolsUsed {
+		counts[tool] = n
+	}
+	return counts
+}
+
+// merges returns the number of thoughts merged into existing nodes
+func (s *gotState) merges() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mergeCount
+}
+
+// best returns the path score and node of the best solution so far
+func (s *gotState) best() (float64, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bestScore, s.bestNodeID
+}
+
+// setBest makes nodeID the best solution when score beats the current one,
+// reporting whether it did
+func (s *gotState) setBest(score float64, nodeID, answer string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if score <= s.bestScore {
+		return false
+	}
+	s.bestScore = score
+	s.bestNodeID = nodeID
+	s.finalAnswer = answer
+	return true
+}
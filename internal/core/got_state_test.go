@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// These tests share one GraphOfThoughts between goroutines the way parallel
+// expansions will; run them with -race to check the locking.
+
+// stateGraph builds a graph like policyGraph, with a provider that answers
+// every call
+func stateGraph(nodes ...*GoTNode) *GraphOfThoughts {
+	g := NewGraphOfThoughts(&countProvider{response: "0.5"}, DefaultGoTConfig())
+	for _, node := range nodes {
+		node.Visits = max(node.Visits, 1)
+		g.nodes[node.ID] = node
+		g.totalVisits += node.Visits
+		for _, parent := range node.Parents {
+			g.nodes[parent].Children = append(g.nodes[parent].Children, node.ID)
+		}
+	}
+	g.bestScore = -1
+	return g
+}
+
+func TestGoTState_ConcurrentExpansions(t *testing.T) {
+	g := stateGraph(&GoTNode{ID: "root", Score: 1})
+	root := g.nodes["root"]
+
+	const workers, perWorker = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				node := &GoTNode{
+					ID:          g.reserveNodeID(fmt.Sprintf("n%d_0", g.visits())),
+					NodeType:    "thought",
+					Thought:     fmt.Sprintf("thought %d.%d", w, i),
+					Depth:       1,
+					Score:       float64(i) / perWorker,
+					Visits:      1,
+					TotalReward: 0.5,
+					Parents:     []string{"root"},
+					IsSolution:  i%5 == 0,
+				}
+				g.addNode(node, root)
+				g.backpropagate(node, node.Score)
+				g.recordToolUse("calculator")
+				g.recordDecision(GoTDecision{Type: "prune", NodeID: node.ID, Thought: node.Thought})
+				if node.IsSolution {
+					g.considerSolution(node)
+				}
+				g.selectBestCandidate(g.getExpansionCandidates())
+				g.Snapshot()
+				g.getBestPath()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	const added = workers * perWorker
+	if g.visits() != added+1 || g.nodeCount() != added+1 || len(root.Children) != added {
+		t.Fatalf("Expected %d nodes under root, got %d visits, %d nodes, %d children", added, g.visits(), g.nodeCount(), len(root.Children))
+	}
+	if root.Visits != added+1 {
+		t.Errorf("Expected every backpropagation to reach root, got %d visits", root.Visits)
+	}
+	if counts := g.toolUseCounts(); counts["calculator"] != added {
+		t.Errorf("Expected %d tool calls, got %v", added, counts)
+	}
+	if n := len(g.decisionLog()); n != added {
+		t.Errorf("Expected %d decisions, got %d", added, n)
+	}
+	if score, id := g.best(); id == "" || score <= 0 {
+		t.Errorf("Expected a best solution, got %q at %.2f", id, score)
+	}
+	if len(g.reserved) != 0 {
+		t.Errorf("Expected every reserved ID to be added, got %v", g.reserved)
+	}
+}
+
+func TestGoTState_ReserveNodeID(t *testing.T) {
+	g := stateGraph(&GoTNode{ID: "root", Score: 1})
+
+	// Two expansions that saw the same visit count get different IDs
+	first, second := g.reserveNodeID("n1_0"), g.reserveNodeID("n1_0")
+	if first != "n1_0" || second != "n1_0_2" {
+		t.Errorf("Expected n1_0 and n1_0_2, got %s and %s", first, second)
+	}
+	g.addNode(&GoTNode{ID: first}, g.nodes["root"])
+	if third := g.reserveNodeID("n1_0"); third != "n1_0_3" {
+		t.Errorf("Expected an added node's ID to stay taken, got %s", third)
+	}
+}
+
+func TestGoTState_ConcurrentMerges(t *testing.T) {
+	g := stateGraph(
+		&GoTNode{ID: "root", Score: 1},
+		&GoTNode{ID: "a", Depth: 1, Score: 0.5, Thought: "add the numbers", Parents: []string{"root"}},
+	)
+	target := g.nodes["a"]
+
+	const merges = 50
+	var wg sync.WaitGroup
+	for i := 0; i < merges; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g.mergeIntoNode(target, fmt.Sprintf("add the numbers %d", i), "root")
+			g.findMergeCandidate(context.Background(), "add the numbers", 1)
+		}(i)
+	}
+	wg.Wait()
+
+	if g.merges() != merges || len(target.MergedFrom) != merges {
+		t.Errorf("Expected %d merges, got %d (%d merged thoughts)", merges, g.merges(), len(target.MergedFrom))
+	}
+}
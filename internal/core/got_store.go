@@ -0,0 +1,335 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GoTRunState is the persisted snapshot of a Graph of Thoughts run
+type GoTRunState struct {
+	RunID       string              `json:"run_id"`
+	Problem     string              `json:"problem"`
+	Category    string              `json:"category,omitempty"`
+	Provider    string              `json:"provider"`
+	Config      GoTConfig           `json:"config"`
+	Nodes       map[string]*GoTNode `json:"nodes"`
+	TotalVisits int                 `json:"total_visits"`
+	ToolCalls   int                 `json:"tool_calls"`
+	MergeCount  int                 `json:"merge_count"`
+	BestScore   float64             `json:"best_score"`
+	BestNodeID  string              `json:"best_node_id,omitempty"`
+	FinalAnswer string              `json:"final_answer,omitempty"`
+	ToolsUsed   map[string]int      `json:"tools_used,omitempty"`
+	Decisions   []GoTDecision       `json:"decisions,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	Signature   string              `json:"signature,omitempty"` // HMAC of the rest, with RESULT_SIGNING_KEY set
+}
+
+// defaultInjectedNodeScore is the score given to human-injected thoughts when none is provided
+const defaultInjectedNodeScore = 0.8
+
+// InjectNode adds a human-authored thought under parentID. The node starts
+// unvisited, so ucb1 tries it before any explored node on the next
+// continuation; best_first and beam rank it by score. A node that would
+// reach max_depth is refused, since it could never be expanded.
+func (s *GoTRunState) InjectNode(parentID, thought string, score float64) (*GoTNode, error) {
+	thought = strings.TrimSpace(thought)
+	if thought == "" {
+		return nil, fmt.Errorf("thought is empty")
+	}
+	if score < 0 || score > 1 {
+		return nil, fmt.Errorf("score must be between 0 and 1, got %.2f", score)
+	}
+
+	parent, ok := s.Nodes[parentID]
+	if !ok {
+		return nil, fmt.Errorf("parent node not found: %s", parentID)
+	}
+	if parent.IsSolution {
+		return nil, fmt.Errorf("parent node %s is a final answer and cannot be extended", parentID)
+	}
+	if s.Config.MaxDepth > 0 && parent.Depth+1 >= s.Config.MaxDepth {
+		return nil, fmt.Errorf("a thought under %s would reach max_depth %d and never be expanded; continue the run with a higher max_depth first", parentID, s.Config.MaxDepth)
+	}
+
+	nodeID := ""
+	for i := 1; ; i++ {
+		nodeID = fmt.Sprintf("h%d", i)
+		if _, exists := s.Nodes[nodeID]; !exists {
+			break
+		}
+	}
+
+	node := &GoTNode{
+		ID:       nodeID,
+		NodeType: "thought",
+		Thought:  thought,
+		Depth:    parent.Depth + 1,
+		Score:    score,
+		Parents:  []string{parentID},
+		Source:   "human",
+	}
+	s.Nodes[nodeID] = node
+	parent.Children = append(parent.Children, nodeID)
+	// The parent may have been exhausted; a human branch reopens it
+	parent.IsTerminal = false
+	parent.PruneReason = ""
+	s.UpdatedAt = time.Now()
+
+	return node, nil
+}
+
+// SetRunStore sets the store used to persist the graph after each run
+func (g *GraphOfThoughts) SetRunStore(store *GoTRunStore) {
+	g.store = store
+}
+
+// RunID returns the identifier of the current run
+func (g *GraphOfThoughts) RunID() string {
+	return g.runID
+}
+
+// Snapshot captures the current graph and search state for persistence
+func (g *GraphOfThoughts) Snapshot() *GoTRunState {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return &GoTRunState{
+		RunID:       g.runID,
+		Problem:     g.problem,
+		Category:    ClassifyProblem(g.problem),
+		Provider:    g.provider.Name(),
+		Config:      g.config,
+		Nodes:       g.nodes,
+		TotalVisits: g.totalVisits,
+		ToolCalls:   g.toolBudget.Used(),
+		MergeCount:  g.mergeCount,
+		BestScore:   g.bestScore,
+		BestNodeID:  g.bestNodeID,
+		FinalAnswer: g.finalAnswer,
+		ToolsUsed:   g.toolsUsed,
+		Decisions:   g.decisions,
+		CreatedAt:   g.createdAt,
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// GoTRunStore persists GoT runs as one JSON file per run
+type GoTRunStore struct {
+	dir     string
+	maxRuns int
+	shared  *redis.Client // Copies runs to Redis for the other replicas, when set
+	mu      sync.Mutex
+
+	locksMu sync.Mutex
+	locks   map[string]*gotRunLock // Held by callers that load, change and save a run
+}
+
+type gotRunLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+const defaultGoTMaxSavedRuns = 50
+
+var gotRunIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// NewGoTRunStore creates a store rooted at dir, keeping at most maxRuns files (0 = unlimited)
+func NewGoTRunStore(dir string, maxRuns int) (*GoTRunStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create run directory %s: %w", dir, err)
+	}
+	return &GoTRunStore{dir: dir, maxRuns: maxRuns}, nil
+}
+
+// LockRun serializes load-modify-save sequences on one run in this process,
+// so concurrent got_inject and got_continue calls do not lose each other's
+// changes. The returned function releases the lock.
+func (s *GoTRunStore) LockRun(runID string) (unlock func()) {
+	s.locksMu.Lock()
+	if s.locks == nil {
+		s.locks = make(map[string]*gotRunLock)
+	}
+	l, ok := s.locks[runID]
+	if !ok {
+		l = &gotRunLock{}
+		s.locks[runID] = l
+	}
+	l.refs++
+	s.locksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		s.locksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, runID)
+		}
+		s.locksMu.Unlock()
+	}
+}
+
+func (s *GoTRunStore) pathFor(runID string) (string, error) {
+	if !gotRunIDPattern.MatchString(runID) {
+		return "", fmt.Errorf("invalid run ID: %q", runID)
+	}
+	return filepath.Join(s.dir, runID+".json"), nil
+}
+
+// Save writes the run state to disk and prunes old runs beyond the limit
+func (s *GoTRunStore) Save(state *GoTRunState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.pathFor(state.RunID)
+	if err != nil {
+		return err
+	}
+
+	state.Signature = ""
+	state.Signature = signRecord(state)
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run: %w", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written run
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write run: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to finalize run: %w", err)
+	}
+	if s.shared != nil {
+		saveSharedGoTRun(s.shared, state.RunID, data)
+	}
+
+	s.prune()
+	return nil
+}
+
+// Load reads a saved run by ID. A shared copy wins over the local file,
+// which is stale when another replica continued the run.
+func (s *GoTRunStore) Load(runID string) (*GoTRunState, error) {
+	path, err := s.pathFor(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, shared := []byte(nil), false
+	if s.shared != nil {
+		data, shared = loadSharedGoTRun(s.shared, runID)
+	}
+	if !shared {
+		data, err = os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("run not found: %s", runID)
+			}
+			return nil, fmt.Errorf("failed to read run %s: %w", runID, err)
+		}
+	}
+
+	var state GoTRunState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse run %s: %w", runID, err)
+	}
+	return &state, nil
+}
+
+// prune removes the oldest run files beyond maxRuns. Caller must hold s.mu.
+func (s *GoTRunStore) prune() {
+	if s.maxRuns <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+
+	type runFile struct {
+		path    string
+		modTime time.Time
+	}
+	var runs []runFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		runs = append(runs, runFile{path: filepath.Join(s.dir, e.Name()), modTime: info.ModTime()})
+	}
+
+	if len(runs) <= s.maxRuns {
+		return
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].modTime.Before(runs[j].modTime)
+	})
+	for _, r := range runs[:len(runs)-s.maxRuns] {
+		if err := os.Remove(r.path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to prune GoT run %s: %v\n", r.path, err)
+		}
+	}
+}
+
+func newGoTRunID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("got_%d", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("got_%d_%s", time.Now().Unix(), hex.EncodeToString(b))
+}
+
+var (
+	gotRunStore     *GoTRunStore
+	gotRunStoreOnce sync.Once
+)
+
+// getGoTRunStore returns the shared run store, or nil when persistence is disabled.
+// GOT_PERSIST_RUNS=false disables persistence, GOT_RUNS_DIR overrides the location
+// and GOT_MAX_SAVED_RUNS caps how many runs are kept. With Redis configured, runs
+// are also shared with the other replicas (GOT_RUNS_REDIS=false keeps them local).
+func getGoTRunStore() *GoTRunStore {
+	gotRunStoreOnce.Do(func() {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("GOT_PERSIST_RUNS"))) {
+		case "false", "0", "off":
+			return
+		}
+
+		dir := os.Getenv("GOT_RUNS_DIR")
+		if dir == "" {
+			homeDir, _ := os.UserHomeDir()
+			dir = filepath.Join(homeDir, ".local", "share", "reasoning-tools", "got_runs")
+		}
+
+		store, err := NewGoTRunStore(dir, parseEnvInt("GOT_MAX_SAVED_RUNS", defaultGoTMaxSavedRuns))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (GoT runs will not persist)\n", err)
+			return
+		}
+		if redisEnabled("GOT_RUNS_REDIS") {
+			store.shared = getRedis()
+		}
+		gotRunStore = store
+	})
+	return gotRunStore
+}
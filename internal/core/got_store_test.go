@@ -0,0 +1,237 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGoTRunStore_SaveLoadRoundTrip(t *testing.T) {
+	store, err := NewGoTRunStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewGoTRunStore failed: %v", err)
+	}
+
+	state := &GoTRunState{
+		RunID:   "got_1_abcd",
+		Problem: "What is 2+2?",
+		Config:  DefaultGoTConfig(),
+		Nodes: map[string]*GoTNode{
+			"root": {ID: "root", NodeType: "thought", Thought: "What is 2+2?", Children: []string{"n1_0"}},
+			"n1_0": {ID: "n1_0", NodeType: "thought", Thought: "4", Depth: 1, Score: 0.9, Parents: []string{"root"}},
+		},
+		TotalVisits: 1,
+		BestScore:   0.9,
+		BestNodeID:  "n1_0",
+		CreatedAt:   time.Now(),
+	}
+
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load(state.RunID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Problem != state.Problem || loaded.BestNodeID != "n1_0" || loaded.TotalVisits != 1 {
+		t.Errorf("Loaded state mismatch: %+v", loaded)
+	}
+	if len(loaded.Nodes) != 2 || loaded.Nodes["n1_0"].Parents[0] != "root" {
+		t.Errorf("Expected graph structure to survive round trip, got %+v", loaded.Nodes)
+	}
+	if loaded.Config.MaxNodes != state.Config.MaxNodes {
+		t.Errorf("Expected config MaxNodes %d, got %d", state.Config.MaxNodes, loaded.Config.MaxNodes)
+	}
+}
+
+func TestGoTRunStore_RejectsInvalidRunID(t *testing.T) {
+	store, err := NewGoTRunStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewGoTRunStore failed: %v", err)
+	}
+
+	for _, id := range []string{"", "../escape", "a/b", "run.json"} {
+		if _, err := store.Load(id); err == nil {
+			t.Errorf("Expected error for run ID %q", id)
+		}
+	}
+
+	if _, err := store.Load("got_missing"); err == nil {
+		t.Error("Expected error for missing run")
+	}
+}
+
+func TestGoTRunStore_PrunesOldestRuns(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewGoTRunStore(dir, 2)
+	if err != nil {
+		t.Fatalf("NewGoTRunStore failed: %v", err)
+	}
+
+	for i, id := range []string{"run_a", "run_b", "run_c"} {
+		if err := store.Save(&GoTRunState{RunID: id}); err != nil {
+			t.Fatalf("Save %s failed: %v", id, err)
+		}
+		// Spread modification times so pruning order is deterministic
+		mtime := time.Now().Add(time.Duration(i-3) * time.Minute)
+		if err := os.Chtimes(filepath.Join(dir, id+".json"), mtime, mtime); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+
+	if _, err := store.Load("run_a"); err == nil {
+		t.Error("Expected oldest run to be pruned")
+	}
+	for _, id := range []string{"run_b", "run_c"} {
+		if _, err := store.Load(id); err != nil {
+			t.Errorf("Expected %s to be kept: %v", id, err)
+		}
+	}
+}
+
+func TestGoTRunStore_LockRunSerializesInjections(t *testing.T) {
+	store, err := NewGoTRunStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewGoTRunStore failed: %v", err)
+	}
+	state := &GoTRunState{
+		RunID:  "got_1_lock",
+		Config: DefaultGoTConfig(),
+		Nodes:  map[string]*GoTNode{"root": {ID: "root", NodeType: "thought"}},
+	}
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	const injections = 10
+	var wg sync.WaitGroup
+	for i := 0; i < injections; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			unlock := store.LockRun(state.RunID)
+			defer unlock()
+			loaded, err := store.Load(state.RunID)
+			if err != nil {
+				t.Errorf("Load failed: %v", err)
+				return
+			}
+			if _, err := loaded.InjectNode("root", fmt.Sprintf("idea %d", i), 0.5); err != nil {
+				t.Errorf("InjectNode failed: %v", err)
+				return
+			}
+			if err := store.Save(loaded); err != nil {
+				t.Errorf("Save failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := store.Load(state.RunID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.Nodes) != injections+1 || len(loaded.Nodes["root"].Children) != injections {
+		t.Errorf("Expected every injection to survive, got %d nodes", len(loaded.Nodes))
+	}
+	if len(store.locks) != 0 {
+		t.Errorf("Expected released run locks to be dropped, got %d", len(store.locks))
+	}
+}
+
+func TestGoT_ContinueConfidentRunKeepsExploring(t *testing.T) {
+	generator := &countProvider{response: `["Consider the first case", "Consider the second case"]`}
+	config := DefaultGoTConfig()
+	config.MaxNodes = 20
+	config.EnableMerging = false
+	config.EnableAggregation = false
+	config.Evaluator = &criticProvider{} // Every thought is a 0.9 solution
+
+	stopReason := func(result *GoTResult) string {
+		for i := len(result.Decisions) - 1; i >= 0; i-- {
+			if result.Decisions[i].Type == "stop" {
+				return result.Decisions[i].Reason
+			}
+		}
+		return ""
+	}
+	g := NewGraphOfThoughts(generator, config)
+	result, err := g.Solve(context.Background(), "Explore the cases")
+	if err != nil || stopReason(result) != GoTReasonConfident {
+		t.Fatalf("Expected the first run to stop confident, got %v (%v)", result, err)
+	}
+	state := g.Snapshot()
+	before := len(state.Nodes)
+
+	// Already confident at 0.9, the continued run only stops early for a better solution
+	state.Config.MaxNodes = before + 6
+	result, err = NewGraphOfThoughts(generator, state.Config).Continue(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Continue failed: %v", err)
+	}
+	if reason := stopReason(result); reason == GoTReasonConfident || len(state.Nodes) <= before+2 {
+		t.Errorf("Expected the continued run to spend its budget, stopped %s after %d new nodes", reason, len(state.Nodes)-before)
+	}
+}
+
+func TestGoTRunState_InjectNode(t *testing.T) {
+	state := &GoTRunState{
+		Nodes: map[string]*GoTNode{
+			"root": {ID: "root", NodeType: "thought", Children: []string{"n1_0"}},
+			"n1_0": {ID: "n1_0", NodeType: "thought", Depth: 1, IsTerminal: true, Parents: []string{"root"}},
+			"n2_0": {ID: "n2_0", NodeType: "thought", Depth: 2, IsSolution: true, IsTerminal: true},
+		},
+	}
+
+	node, err := state.InjectNode("n1_0", "  Try working backwards  ", defaultInjectedNodeScore)
+	if err != nil {
+		t.Fatalf("InjectNode failed: %v", err)
+	}
+	if node.ID != "h1" || node.Depth != 2 || node.Source != "human" || node.Thought != "Try working backwards" {
+		t.Errorf("Unexpected injected node: %+v", node)
+	}
+	if node.Visits != 0 {
+		t.Errorf("Expected injected node to be unvisited, got %d visits", node.Visits)
+	}
+	parent := state.Nodes["n1_0"]
+	if parent.IsTerminal {
+		t.Error("Expected parent to be reopened for expansion")
+	}
+	if len(parent.Children) != 1 || parent.Children[0] != "h1" {
+		t.Errorf("Expected parent children [h1], got %v", parent.Children)
+	}
+
+	second, err := state.InjectNode("root", "Another idea", 0.5)
+	if err != nil {
+		t.Fatalf("Second InjectNode failed: %v", err)
+	}
+	if second.ID != "h2" {
+		t.Errorf("Expected unique ID h2, got %s", second.ID)
+	}
+
+	tests := []struct {
+		name     string
+		parentID string
+		thought  string
+		score    float64
+	}{
+		{"missing parent", "nope", "idea", 0.5},
+		{"solution parent", "n2_0", "idea", 0.5},
+		{"empty thought", "root", "   ", 0.5},
+		{"score out of range", "root", "idea", 1.5},
+		{"would reach max_depth", "h1", "idea", 0.5},
+	}
+	state.Config.MaxDepth = 3
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := state.InjectNode(tt.parentID, tt.thought, tt.score); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Graph Export ============
+
+// Graph export formats for output_format and export_graph
+const (
+	GraphFormatJSON      = "json"       // Plain GoT result, no rendering
+	GraphFormatDOT       = "dot"        // Graphviz digraph
+	GraphFormatMermaid   = "mermaid"    // Mermaid flowchart
+	GraphFormatJSONGraph = "json_graph" // Node and edge lists
+)
+
+// Edge kinds in an exported graph
+const (
+	GraphEdgeChild     = "child"        // Node expanded from its first parent
+	GraphEdgeMerge     = "merge"        // Later parent whose thought was merged into the node
+	GraphEdgeAggregate = "aggregate"    // Parent combined into an aggregate node
+	GraphEdgeRefined   = "refined_from" // Weak thought to its revision
+)
+
+// graphLabelLen caps thought text in rendered node labels
+const graphLabelLen = 60
+
+// GraphExport is a GoT graph as node and edge lists, ordered by depth
+type GraphExport struct {
+	Nodes []GraphExportNode `json:"nodes"`
+	Edges []GraphExportEdge `json:"edges"`
+}
+
+type GraphExportNode struct {
+	ID          string  `json:"id"`
+	Type        string  `json:"type"` // "thought", "tool" or "aggregate"
+	Label       string  `json:"label"`
+	Score       float64 `json:"score"`
+	Depth       int     `json:"depth"`
+	IsSolution  bool    `json:"is_solution,omitempty"`
+	Merged      int     `json:"merged,omitempty"` // Thoughts merged into this node
+	Tool        string  `json:"tool,omitempty"`
+	Source      string  `json:"source,omitempty"`
+	PruneReason string  `json:"prune_reason,omitempty"`
+	RefinedFrom string  `json:"refined_from,omitempty"`
+	OnBestPath  bool    `json:"on_best_path,omitempty"`
+}
+
+type GraphExportEdge struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Kind       string `json:"kind"`
+	OnBestPath bool   `json:"on_best_path,omitempty"`
+}
+
+// GraphRendering is a graph in the requested output_format
+type GraphRendering struct {
+	Format  string       `json:"format"`
+	Content string       `json:"content,omitempty"` // DOT or Mermaid source
+	Graph   *GraphExport `json:"graph,omitempty"`   // json_graph
+}
+
+// normalizeGraphFormat maps output_format values to a graph format
+func normalizeGraphFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", GraphFormatJSON:
+		return GraphFormatJSON, nil
+	case GraphFormatDOT, "graphviz":
+		return GraphFormatDOT, nil
+	case GraphFormatMermaid:
+		return GraphFormatMermaid, nil
+	case GraphFormatJSONGraph, "json-graph", "jsongraph":
+		return GraphFormatJSONGraph, nil
+	}
+	return "", fmt.Errorf("unknown output_format %q (use json, dot, mermaid or json_graph)", format)
+}
+
+// buildGraphExport flattens a node map; bestPath marks the highlighted path
+func buildGraphExport(nodes map[string]*GoTNode, bestPath []*GoTNode) *GraphExport {
+	onPath := make(map[string]bool, len(bestPath))
+	for _, n := range bestPath {
+		onPath[n.ID] = true
+	}
+
+	ordered := make([]*GoTNode, 0, len(nodes))
+	for _, n := range nodes {
+		ordered = append(ordered, n)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Depth != ordered[j].Depth {
+			return ordered[i].Depth < ordered[j].Depth
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	export := &GraphExport{Nodes: []GraphExportNode{}, Edges: []GraphExportEdge{}}
+	for _, n := range ordered {
+		node := GraphExportNode{
+			ID:          n.ID,
+			Type:        n.NodeType,
+			Label:       strings.Join(strings.Fields(n.Thought), " "),
+			Score:       n.Score,
+			Depth:       n.Depth,
+			IsSolution:  n.IsSolution,
+			Merged:      len(n.MergedFrom),
+			Source:      n.Source,
+			PruneReason: n.PruneReason,
+			RefinedFrom: n.RefinedFrom,
+			OnBestPath:  onPath[n.ID],
+		}
+		if node.Type == "" {
+			node.Type = "thought"
+		}
+		if n.ToolCall != nil {
+			node.Tool = n.ToolCall.Tool
+		}
+		export.Nodes = append(export.Nodes, node)
+
+		for i, parent := range n.Parents {
+			if _, ok := nodes[parent]; !ok {
+				continue
+			}
+			kind := GraphEdgeChild
+			switch {
+			case n.NodeType == "aggregate":
+				kind = GraphEdgeAggregate
+			case i > 0:
+				kind = GraphEdgeMerge
+			}
+			export.Edges = append(export.Edges, GraphExportEdge{
+				From:       parent,
+				To:         n.ID,
+				Kind:       kind,
+				OnBestPath: onPath[parent] && onPath[n.ID] && i == 0,
+			})
+		}
+		if _, ok := nodes[n.RefinedFrom]; ok {
+			export.Edges = append(export.Edges, GraphExportEdge{From: n.RefinedFrom, To: n.ID, Kind: GraphEdgeRefined})
+		}
+	}
+	return export
+}
+
+// renderGraph builds the rendering for a non-json format
+func renderGraph(format string, nodes map[string]*GoTNode, bestPath []*GoTNode) *GraphRendering {
+	export := buildGraphExport(nodes, bestPath)
+	switch format {
+	case GraphFormatDOT:
+		return &GraphRendering{Format: format, Content: renderDOT(export)}
+	case GraphFormatMermaid:
+		return &GraphRendering{Format: format, Content: renderMermaid(export)}
+	case GraphFormatJSONGraph:
+		return &GraphRendering{Format: format, Graph: export}
+	}
+	return nil
+}
+
+// primaryPath follows first parents from nodeID back to the root
+func primaryPath(nodes map[string]*GoTNode, nodeID string) []*GoTNode {
+	var path []*GoTNode
+	seen := make(map[string]bool)
+	for n, ok := nodes[nodeID]; ok && !seen[n.ID]; {
+		seen[n.ID] = true
+		path = append([]*GoTNode{n}, path...)
+		if len(n.Parents) == 0 {
+			break
+		}
+		n, ok = nodes[n.Parents[0]]
+	}
+	return path
+}
+
+// graphNodeLabel is the display text of a node: tool call or thought, score
+// and merge count
+func graphNodeLabel(n GraphExportNode) string {
+	text := n.Label
+	if n.Type == "tool" && n.Tool != "" {
+		text = n.Tool + ": " + text
+	}
+	text = utils.TruncateStr(text, graphLabelLen)
+	meta := fmt.Sprintf("%s (%.2f)", n.ID, n.Score)
+	if n.Merged > 0 {
+		meta += fmt.Sprintf(" +%d merged", n.Merged)
+	}
+	return meta + "\n" + text
+}
+
+func renderDOT(g *GraphExport) string {
+	var sb strings.Builder
+	sb.WriteString("digraph GoT {\n")
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=white, fontname=\"Helvetica\"];\n")
+
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+	}
+
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + quote(graphNodeLabel(n))}
+		switch {
+		case n.Type == "tool":
+			attrs = append(attrs, "shape=parallelogram", "fillcolor=lightyellow")
+		case n.Type == "aggregate" && !n.IsSolution:
+			attrs = append(attrs, "shape=hexagon", "fillcolor=lavender")
+		case n.IsSolution:
+			attrs = append(attrs, "fillcolor=palegreen", "peripheries=2")
+		case n.PruneReason != "":
+			attrs = append(attrs, "fillcolor=gray90", "fontcolor=gray40")
+		case n.Source == "human":
+			attrs = append(attrs, "fillcolor=lightblue")
+		}
+		if n.OnBestPath {
+			attrs = append(attrs, "penwidth=2")
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", quote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		switch e.Kind {
+		case GraphEdgeMerge:
+			attrs = append(attrs, "style=dashed", `label="merge"`)
+		case GraphEdgeAggregate:
+			attrs = append(attrs, "style=bold", "color=purple")
+		case GraphEdgeRefined:
+			attrs = append(attrs, "style=dotted", `label="refine"`)
+		}
+		if e.OnBestPath {
+			attrs = append(attrs, "penwidth=2", "color=darkgreen")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&sb, "  %s -> %s [%s];\n", quote(e.From), quote(e.To), strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", quote(e.From), quote(e.To))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+var mermaidIDRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func renderMermaid(g *GraphExport) string {
+	id := func(s string) string { return "n_" + mermaidIDRe.ReplaceAllString(s, "_") }
+	label := func(s string) string {
+		s = strings.ReplaceAll(s, `"`, "#quot;")
+		return strings.ReplaceAll(s, "\n", "<br/>")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	classes := map[string][]string{}
+	for _, n := range g.Nodes {
+		text := label(graphNodeLabel(n))
+		switch n.Type {
+		case "tool":
+			fmt.Fprintf(&sb, "  %s[/\"%s\"/]\n", id(n.ID), text)
+		case "aggregate":
+			fmt.Fprintf(&sb, "  %s{{\"%s\"}}\n", id(n.ID), text)
+		default:
+			fmt.Fprintf(&sb, "  %s[\"%s\"]\n", id(n.ID), text)
+		}
+		switch {
+		case n.Type == "tool":
+			classes["tool"] = append(classes["tool"], id(n.ID))
+		case n.Type == "aggregate" && !n.IsSolution:
+			classes["aggregate"] = append(classes["aggregate"], id(n.ID))
+		case n.IsSolution:
+			classes["solution"] = append(classes["solution"], id(n.ID))
+		case n.PruneReason != "":
+			classes["pruned"] = append(classes["pruned"], id(n.ID))
+		case n.Source == "human":
+			classes["human"] = append(classes["human"], id(n.ID))
+		}
+		if n.OnBestPath {
+			classes["best"] = append(classes["best"], id(n.ID))
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Kind == GraphEdgeMerge {
+			fmt.Fprintf(&sb, "  %s -.->|merge| %s\n", id(e.From), id(e.To))
+		} else if e.Kind == GraphEdgeRefined {
+			fmt.Fprintf(&sb, "  %s -.->|refine| %s\n", id(e.From), id(e.To))
+		} else if e.Kind == GraphEdgeAggregate && !e.OnBestPath {
+			fmt.Fprintf(&sb, "  %s -->|combine| %s\n", id(e.From), id(e.To))
+		} else if e.OnBestPath {
+			fmt.Fprintf(&sb, "  %s ==> %s\n", id(e.From), id(e.To))
+		} else {
+			fmt.Fprintf(&sb, "  %s --> %s\n", id(e.From), id(e.To))
+		}
+	}
+
+	styles := []struct{ name, style string }{
+		{"tool", "fill:#fffbe6,stroke:#b59f3b"},
+		{"aggregate", "fill:#ede7f6,stroke:#7e57c2"},
+		{"solution", "fill:#d4f7d4,stroke:#2e8b57,stroke-width:2px"},
+		{"pruned", "fill:#eeeeee,color:#777777"},
+		{"human", "fill:#dbeafe,stroke:#3b82f6"},
+		{"best", "stroke:#006400,stroke-width:3px"},
+	}
+	for _, s := range styles {
+		if members := classes[s.name]; len(members) > 0 {
+			fmt.Fprintf(&sb, "  classDef %s %s\n", s.name, s.style)
+			fmt.Fprintf(&sb, "  class %s %s\n", strings.Join(members, ","), s.name)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// exportTestGraph has a tool node and a thought merged from a second parent
+func exportTestGraph() map[string]*GoTNode {
+	return map[string]*GoTNode{
+		"root": {ID: "root", NodeType: "thought", Thought: "Problem: what is 17 * 23?", Score: 1, Children: []string{"n1_0", "n1_1"}},
+		"n1_0": {ID: "n1_0", NodeType: "tool", Thought: "17*23", Depth: 1, Score: 0.9, Parents: []string{"root"},
+			ToolCall: &ToolCall{Tool: "calculator", Input: "17*23"}},
+		"n1_1": {ID: "n1_1", NodeType: "thought", Thought: `Split as "17 * 20 + 17 * 3"`, Depth: 1, Score: 0.2, Parents: []string{"root"},
+			PruneReason: GoTReasonLowScore},
+		"n2_0": {ID: "n2_0", NodeType: "thought", Thought: "The product is 391", Depth: 2, Score: 0.95, Parents: []string{"n1_0", "n1_1"},
+			IsSolution: true, MergedFrom: []string{"391"}},
+	}
+}
+
+func TestBuildGraphExport(t *testing.T) {
+	nodes := exportTestGraph()
+	export := buildGraphExport(nodes, primaryPath(nodes, "n2_0"))
+
+	if len(export.Nodes) != 4 || export.Nodes[0].ID != "root" || export.Nodes[3].ID != "n2_0" {
+		t.Fatalf("Expected nodes ordered by depth, got %+v", export.Nodes)
+	}
+	if export.Nodes[1].Tool != "calculator" || export.Nodes[3].Merged != 1 || !export.Nodes[3].OnBestPath {
+		t.Errorf("Unexpected node details: %+v", export.Nodes)
+	}
+
+	kinds := map[string]string{}
+	for _, e := range export.Edges {
+		kinds[e.From+">"+e.To] = e.Kind
+	}
+	if kinds["n1_0>n2_0"] != GraphEdgeChild || kinds["n1_1>n2_0"] != GraphEdgeMerge || len(export.Edges) != 4 {
+		t.Errorf("Expected a child edge from the first parent and a merge edge from the second, got %v", kinds)
+	}
+}
+
+func TestRenderGraph_DOTAndMermaid(t *testing.T) {
+	nodes := exportTestGraph()
+	path := primaryPath(nodes, "n2_0")
+
+	dot := renderGraph(GraphFormatDOT, nodes, path).Content
+	for _, want := range []string{
+		"digraph GoT {",
+		`"n1_1" -> "n2_0" [style=dashed, label="merge"];`,
+		`"n1_0" -> "n2_0" [penwidth=2, color=darkgreen];`,
+		"shape=parallelogram",
+		"peripheries=2",
+		`Split as \"17 * 20 + 17 * 3\"`,
+		`n2_0 (0.95) +1 merged\nThe product is 391`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected %q in DOT output:\n%s", want, dot)
+		}
+	}
+
+	mermaid := renderGraph(GraphFormatMermaid, nodes, path).Content
+	for _, want := range []string{
+		"flowchart TD",
+		"n_n1_1 -.->|merge| n_n2_0",
+		"n_root ==> n_n1_0",
+		`n_n1_0[/"n1_0 (0.90)<br/>calculator: 17*23"/]`,
+		"#quot;17 * 20 + 17 * 3#quot;",
+		"class n_n2_0 solution",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Expected %q in Mermaid output:\n%s", want, mermaid)
+		}
+	}
+
+	if r := renderGraph(GraphFormatJSONGraph, nodes, path); r.Graph == nil || r.Content != "" {
+		t.Errorf("Expected json_graph to return node and edge lists, got %+v", r)
+	}
+	if renderGraph(GraphFormatJSON, nodes, path) != nil {
+		t.Error("Expected no rendering for plain json")
+	}
+}
+
+func TestRenderGraph_AggregateNodes(t *testing.T) {
+	nodes := exportTestGraph()
+	nodes["a5"] = &GoTNode{ID: "a5", NodeType: "aggregate", Thought: "Both routes give 391", Depth: 3, Score: 0.9, Parents: []string{"n2_0", "n1_1"}}
+	nodes["n2_0"].Children = append(nodes["n2_0"].Children, "a5")
+	path := primaryPath(nodes, "a5")
+
+	export := buildGraphExport(nodes, path)
+	for _, e := range export.Edges {
+		if e.To == "a5" && (e.Kind != GraphEdgeAggregate || e.OnBestPath != (e.From == "n2_0")) {
+			t.Errorf("Expected aggregate edges into a5 with only the first parent on the best path, got %+v", e)
+		}
+	}
+
+	dot := renderGraph(GraphFormatDOT, nodes, path).Content
+	if !strings.Contains(dot, "shape=hexagon") || !strings.Contains(dot, `"n1_1" -> "a5" [style=bold, color=purple];`) {
+		t.Errorf("Expected a hexagon aggregate with bold edges in DOT output:\n%s", dot)
+	}
+	mermaid := renderGraph(GraphFormatMermaid, nodes, path).Content
+	if !strings.Contains(mermaid, `n_a5{{"`) || !strings.Contains(mermaid, "n_n1_1 -->|combine| n_a5") || !strings.Contains(mermaid, "class n_a5 aggregate") {
+		t.Errorf("Expected a hexagon aggregate with combine edges in Mermaid output:\n%s", mermaid)
+	}
+}
+
+func TestNormalizeGraphFormat(t *testing.T) {
+	for in, want := range map[string]string{"": GraphFormatJSON, "DOT": GraphFormatDOT, "graphviz": GraphFormatDOT, "json-graph": GraphFormatJSONGraph, " mermaid ": GraphFormatMermaid} {
+		if got, err := normalizeGraphFormat(in); err != nil || got != want {
+			t.Errorf("normalizeGraphFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := normalizeGraphFormat("svg"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============ Idempotency Keys ============
+//
+// A strategy call carrying idempotency_key runs at most once per key: a
+// retry of the call (a client that timed out, a load balancer replaying the
+// request on another replica) gets the first run's result instead of paying
+// for a second run, and a retry that arrives while the run is still going
+// waits for it. Keys are shared through Redis when it is configured and
+// kept in process otherwise. Failed runs release their key so they can be
+// retried.
+
+const (
+	defaultIdempotencyTTL = 24 * time.Hour
+
+	// idempotencyPendingTTL frees the key of a run whose replica died before
+	// it finished
+	idempotencyPendingTTL = 15 * time.Minute
+
+	idempotencyPollInterval = 250 * time.Millisecond
+	maxIdempotencyKeyLength = 256
+)
+
+// idempotencyRecord is the state of a key: claimed by a running call, or
+// holding the finished call's result
+type idempotencyRecord struct {
+	ArgsHash string `json:"args_hash"`
+	Done     bool   `json:"done"`
+	Result   string `json:"result,omitempty"`
+}
+
+// idempotencyStore holds idempotency records
+type idempotencyStore interface {
+	// claim reserves key for a new run, or returns the record already there
+	claim(ctx context.Context, key string, rec idempotencyRecord) (existing *idempotencyRecord, claimed bool, err error)
+	// complete stores the finished run's record
+	complete(ctx context.Context, key string, rec idempotencyRecord, ttl time.Duration) error
+	// release drops a claim so the call can run again
+	release(ctx context.Context, key string) error
+}
+
+func idempotencyKeyOption() mcp.ToolOption {
+	return mcp.WithString("idempotency_key",
+		mcp.Description("Client-chosen key making the call safe to retry: a repeat with the same key and arguments returns the first run's result instead of running again (kept IDEMPOTENCY_TTL seconds, default 86400)"),
+	)
+}
+
+// idempotencyMiddleware replays the results of strategy calls whose
+// idempotency_key has already been used
+func idempotencyMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return newIdempotencyMiddleware(getIdempotencyStore)(next)
+}
+
+func newIdempotencyMiddleware(store func() idempotencyStore) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args, _ := request.Params.Arguments.(map[string]interface{})
+			key, _ := args["idempotency_key"].(string)
+			key = strings.TrimSpace(key)
+			if key == "" || !runHistoryTools[request.Params.Name] {
+				return next(ctx, request)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return mcp.NewToolResultError(fmt.Sprintf("idempotency_key is longer than %d characters", maxIdempotencyKeyLength)), nil
+			}
+
+			s := store()
+			scoped := request.Params.Name + ":" + key
+			hash := idempotencyArgsHash(args)
+			for {
+				existing, claimed, err := s.claim(ctx, scoped, idempotencyRecord{ArgsHash: hash})
+				if err != nil {
+					if ctx.Err() != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("cancelled waiting for the run with idempotency_key %q", key)), nil
+					}
+					// Run anyway: a lost key costs a duplicate run, not the call
+					fmt.Fprintf(os.Stderr, "Warning: idempotency store unavailable: %v\n", err)
+					return next(ctx, request)
+				}
+				if claimed {
+					break
+				}
+				if existing.ArgsHash != hash {
+					return mcp.NewToolResultError(fmt.Sprintf("idempotency_key %q was already used with different arguments", key)), nil
+				}
+				if existing.Done {
+					fmt.Fprintf(os.Stderr, "[IDEMPOTENCY] %s: replaying the result for key %q\n", request.Params.Name, key)
+					return mcp.NewToolResultText(existing.Result), nil
+				}
+				select {
+				case <-ctx.Done():
+					return mcp.NewToolResultError(fmt.Sprintf("cancelled waiting for the run with idempotency_key %q", key)), nil
+				case <-time.After(idempotencyPollInterval):
+				}
+			}
+
+			result, err := next(ctx, request)
+			// Use a fresh context: the run's may be cancelled by now
+			storeCtx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+			defer cancel()
+			if err != nil || result == nil || result.IsError {
+				if releaseErr := s.release(storeCtx, scoped); releaseErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to release idempotency key %q: %v\n", key, releaseErr)
+				}
+				return result, err
+			}
+			rec := idempotencyRecord{ArgsHash: hash, Done: true, Result: resultText(result)}
+			ttl := time.Duration(parseEnvInt("IDEMPOTENCY_TTL", int(defaultIdempotencyTTL/time.Second))) * time.Second
+			if completeErr := s.complete(storeCtx, scoped, rec, ttl); completeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to store result for idempotency key %q: %v\n", key, completeErr)
+			}
+			return result, err
+		}
+	}
+}
+
+// idempotencyArgsHash identifies a call's arguments, ignoring the key itself
+func idempotencyArgsHash(args map[string]interface{}) string {
+	rest := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if k != "idempotency_key" {
+			rest[k] = v
+		}
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(canonicalJSON(rest))))
+}
+
+var (
+	idempotency     idempotencyStore
+	idempotencyOnce sync.Once
+)
+
+// getIdempotencyStore returns the Redis store when Redis is configured
+// (unless IDEMPOTENCY_REDIS=false), and the in-process store otherwise
+func getIdempotencyStore() idempotencyStore {
+	idempotencyOnce.Do(func() {
+		if redisEnabled("IDEMPOTENCY_REDIS") {
+			idempotency = &redisIdempotencyStore{client: getRedis()}
+			return
+		}
+		idempotency = newMemoryIdempotencyStore()
+	})
+	return idempotency
+}
+
+// memoryIdempotencyStore keeps records in process
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyEntry
+}
+
+type memoryIdempotencyEntry struct {
+	rec     idempotencyRecord
+	expires time.Time
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]memoryIdempotencyEntry)}
+}
+
+func (s *memoryIdempotencyStore) claim(_ context.Context, key string, rec idempotencyRecord) (*idempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if entry, ok := s.records[key]; ok && now.Before(entry.expires) {
+		existing := entry.rec
+		return &existing, false, nil
+	}
+	// Drop expired records while holding the lock anyway
+	for k, entry := range s.records {
+		if !now.Before(entry.expires) {
+			delete(s.records, k)
+		}
+	}
+	s.records[key] = memoryIdempotencyEntry{rec: rec, expires: now.Add(idempotencyPendingTTL)}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) complete(_ context.Context, key string, rec idempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = memoryIdempotencyEntry{rec: rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// redisIdempotencyStore shares records between replicas
+type redisIdempotencyStore struct {
+	client *redis.Client
+}
+
+func (s *redisIdempotencyStore) claim(ctx context.Context, key string, rec idempotencyRecord) (*idempotencyRecord, bool, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, false, err
+	}
+	opCtx, cancel := context.WithTimeout(ctx, redisOpTimeout)
+	defer cancel()
+	claimed, err := s.client.SetNX(opCtx, redisKey("idempotency", key), data, idempotencyPendingTTL).Result()
+	if err != nil || claimed {
+		return nil, claimed, err
+	}
+
+	raw, err := s.client.Get(opCtx, redisKey("idempotency", key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Released or expired since SETNX; the caller's next claim can take it
+		return &idempotencyRecord{ArgsHash: rec.ArgsHash}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var existing idempotencyRecord
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, false, fmt.Errorf("corrupt idempotency record %s: %w", key, err)
+	}
+	return &existing, false, nil
+}
+
+func (s *redisIdempotencyStore) complete(ctx context.Context, key string, rec idempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKey("idempotency", key), data, ttl).Err()
+}
+
+func (s *redisIdempotencyStore) release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, redisKey("idempotency", key)).Err()
+}
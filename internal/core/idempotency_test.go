@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func idempotentRequest(key, problem string) mcp.CallToolRequest {
+	var req mcp.CallToolRequest
+	req.Params.Name = "sequential_thinking"
+	req.Params.Arguments = map[string]interface{}{"problem": problem, "idempotency_key": key}
+	return req
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	stores := map[string]func(t *testing.T) idempotencyStore{
+		"memory": func(*testing.T) idempotencyStore { return newMemoryIdempotencyStore() },
+		"redis": func(t *testing.T) idempotencyStore {
+			_, client := useMiniredis(t)
+			return &redisIdempotencyStore{client: client}
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			var runs atomic.Int32
+			fail := false
+			handler := newIdempotencyMiddleware(func() idempotencyStore { return store })(
+				func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					n := runs.Add(1)
+					if fail {
+						return mcp.NewToolResultError("provider down"), nil
+					}
+					time.Sleep(50 * time.Millisecond)
+					return mcp.NewToolResultText(`{"run": ` + string(rune('0'+n)) + `}`), nil
+				})
+
+			// Concurrent duplicates wait for the first run and share its result
+			var wg sync.WaitGroup
+			results := make([]string, 3)
+			for i := range results {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					result, _ := handler(context.Background(), idempotentRequest("k1", "p"))
+					results[i] = resultText(result)
+				}(i)
+			}
+			wg.Wait()
+			if runs.Load() != 1 || results[0] != `{"run": 1}` || results[1] != results[0] || results[2] != results[0] {
+				t.Errorf("Expected one run shared by every duplicate, got %d runs and %q", runs.Load(), results)
+			}
+
+			result, _ := handler(context.Background(), idempotentRequest("k1", "other problem"))
+			if !result.IsError || !strings.Contains(resultText(result), "different arguments") {
+				t.Errorf("Expected reuse with other arguments to fail, got %q", resultText(result))
+			}
+
+			// Failed runs release the key so a retry runs again
+			fail = true
+			handler(context.Background(), idempotentRequest("k2", "p"))
+			fail = false
+			handler(context.Background(), idempotentRequest("k2", "p"))
+			if runs.Load() != 3 {
+				t.Errorf("Expected the failed run to be retried, got %d runs", runs.Load())
+			}
+
+			var plain mcp.CallToolRequest
+			plain.Params.Name = "sequential_thinking"
+			plain.Params.Arguments = map[string]interface{}{"problem": "p"}
+			handler(context.Background(), plain)
+			handler(context.Background(), plain)
+			if runs.Load() != 5 {
+				t.Errorf("Expected calls without a key to always run, got %d runs", runs.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// solveResponder answers every strategy's prompts for "What is 17 * 23?"
+func solveResponder(req fakeChatRequest) string {
+	system, user := req.system(), req.user()
+	switch {
+	case strings.Contains(system, "sequential thinking assistant"):
+		return `{"thought_number": 1, "total_thoughts": 1, "thought": "17 * 23 = 391", "next_thought_needed": false, "final_answer": "391"}`
+	case strings.Contains(system, "Generate diverse, creative reasoning steps"):
+		return `["Multiply: 17 * 23 = 391"]`
+	case strings.Contains(system, "critical evaluator of reasoning steps"):
+		return `{"score": 0.95, "is_solution": true, "answer": "391", "reasoning": "correct product"}`
+	case strings.Contains(system, "strict evaluator"):
+		return `{"evaluation": "correct", "is_correct": true, "issues": []}`
+	case strings.Contains(user, `"is_final"`):
+		return `{"thought_number": 1, "thought": "17 * 23 = 391", "is_final": true, "answer": "391"}`
+	case strings.Contains(system, "careful verifier"):
+		return `{"is_valid": true, "score": 0.95, "issues": [], "strengths": ["exact arithmetic"], "suggestion": ""}`
+	case strings.Contains(system, "devil's advocate"):
+		return "The product could be 381 if the tens were miscounted."
+	case strings.Contains(system, "Least-to-Most"):
+		return `{"subproblems": [{"question": "What is 17 * 20?", "depends_on": []}, {"question": "What is 17 * 23?", "depends_on": [1]}]}`
+	case strings.Contains(user, "Now solve sub-problem"):
+		return `{"reasoning": "multiply", "answer": "391"}`
+	case strings.Contains(system, "planning agent"):
+		return `{"steps": [{"description": "Multiply with the calculator", "type": "tool", "tool": "calculator", "input": "17 * 23"}, {"description": "State the product", "type": "reason"}]}`
+	case strings.Contains(user, "Current step"):
+		return `{"result": "The product is 391", "failed": false}`
+	}
+	return "The answer is 391."
+}
+
+// integrationServer serves the strategy tools behind the run middleware and
+// any extra middleware, innermost last
+func integrationServer(t *testing.T, extra ...server.ToolHandlerMiddleware) *server.MCPServer {
+	t.Helper()
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("REFLEXION_MEMORY_PATH", filepath.Join(t.TempDir(), "memory.json"))
+	t.Setenv("PROBLEM_COMPRESSION", "false")
+	t.Setenv("LLM_FALLBACKS", "")
+
+	getToolCache()
+	savedCache := toolCache
+	toolCache = nil
+	getGoTRunStore()
+	savedStore := gotRunStore
+	gotRunStore = nil
+	t.Cleanup(func() { toolCache, gotRunStore = savedCache, savedStore })
+
+	opts := []server.ServerOption{server.WithToolHandlerMiddleware(queueRunMiddleware)}
+	for _, mw := range extra {
+		opts = append(opts, server.WithToolHandlerMiddleware(mw))
+	}
+	s := server.NewMCPServer(serverName, serverVersion, opts...)
+	for name, handler := range map[string]server.ToolHandlerFunc{
+		"sequential_thinking": handleSequentialThink,
+		"graph_of_thoughts":   handleGraphOfThoughts,
+		"reflexion":           handleReflexion,
+		"dialectic_reason":    handleDialecticReason,
+		"decompose_solve":     handleDecomposeSolve,
+		"plan_execute":        handlePlanExecute,
+		"verify_claim":        handleVerifyClaim,
+	} {
+		s.AddTool(mcp.NewTool(name), handler)
+	}
+	return s
+}
+
+// callTool calls a tool and decodes its JSON result
+func callTool(t *testing.T, s *server.MCPServer, name string, args map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+	msg := `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": ` + string(params) + `}`
+	raw, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(msg)))
+
+	var resp struct {
+		Result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Content) == 0 {
+		t.Fatalf("%s: unexpected response %s", name, raw)
+	}
+	text := resp.Result.Content[0].Text
+	if resp.Result.IsError {
+		t.Fatalf("%s failed: %s", name, text)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("%s: result is not JSON: %s", name, text)
+	}
+	return result
+}
+
+func TestIntegration_Strategies(t *testing.T) {
+	fake := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t)
+
+	for _, tool := range []string{"sequential_thinking", "graph_of_thoughts", "reflexion", "dialectic_reason", "decompose_solve", "plan_execute"} {
+		t.Run(tool, func(t *testing.T) {
+			before := len(fake.received())
+			result := callTool(t, s, tool, map[string]interface{}{"problem": "What is 17 * 23?"})
+			if answer, _ := result["final_answer"].(string); !strings.Contains(answer, "391") {
+				t.Errorf("Expected an answer containing 391, got %v", result["final_answer"])
+			}
+			if result["provider"] != "openai" {
+				t.Errorf("Expected provider openai, got %v", result["provider"])
+			}
+			if len(fake.received()) == before {
+				t.Error("Expected requests to reach the fake server")
+			}
+		})
+	}
+}
+
+func TestIntegration_StreamingTokens(t *testing.T) {
+	fake := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t)
+
+	result := callTool(t, s, "sequential_thinking", map[string]interface{}{"problem": "What is 17 * 23?", "stream_mode": "tokens"})
+	var tokens []string
+	events, _ := result["stream"].([]interface{})
+	for _, e := range events {
+		if event, _ := e.(map[string]interface{}); event["type"] == EventTypeToken {
+			tokens = append(tokens, event["content"].(string))
+		}
+	}
+	if len(tokens) < 2 || !strings.Contains(strings.Join(tokens, ""), `"final_answer": "391"`) {
+		t.Errorf("Expected the streamed reply as tokens, got %q", tokens)
+	}
+	if inner, _ := result["result"].(map[string]interface{}); inner["final_answer"] != "391" {
+		t.Errorf("Expected the wrapped result, got %v", result["result"])
+	}
+	requests := fake.received()
+	if len(requests) == 0 || !requests[len(requests)-1].Stream {
+		t.Error("Expected a streaming request")
+	}
+}
+
+func TestIntegration_Caching(t *testing.T) {
+	fake := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t)
+	toolCache = NewToolCache(time.Minute, 10)
+
+	args := map[string]interface{}{"problem": "What is 17 * 23?"}
+	first := callTool(t, s, "graph_of_thoughts", args)
+	calls := len(fake.received())
+	second := callTool(t, s, "graph_of_thoughts", args)
+	if len(fake.received()) != calls {
+		t.Errorf("Expected the second call served from the cache, got %d more requests", len(fake.received())-calls)
+	}
+	if first["final_answer"] != second["final_answer"] {
+		t.Errorf("Cached result differs: %v vs %v", first["final_answer"], second["final_answer"])
+	}
+
+	callTool(t, s, "graph_of_thoughts", map[string]interface{}{"problem": "What is 17 * 24?"})
+	if len(fake.received()) == calls {
+		t.Error("Expected a different problem to miss the cache")
+	}
+}
+
+func TestIntegration_RetriesAndFallback(t *testing.T) {
+	primary := newFakeOpenAI(t, solveResponder)
+	backup := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", primary)
+	useFakeProvider(t, "groq", backup)
+	s := integrationServer(t)
+
+	// Transient errors are retried on the same provider
+	primary.failNext(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	result := callTool(t, s, "sequential_thinking", map[string]interface{}{"problem": "What is 17 * 23?"})
+	if result["final_answer"] != "391" || result["answered_by"] != nil {
+		t.Errorf("Expected the retried primary to answer, got %v (answered_by %v)", result["final_answer"], result["answered_by"])
+	}
+
+	// A dead primary falls back, and the result says who answered
+	primary.failNext(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	result = callTool(t, s, "sequential_thinking", map[string]interface{}{"problem": "What is 17 * 23?", "fallback_providers": "groq"})
+	if result["final_answer"] != "391" {
+		t.Fatalf("Expected the fallback to answer, got %v", result)
+	}
+	answered, _ := result["answered_by"].(map[string]interface{})
+	if answered["groq"] != float64(1) || len(answered) != 1 {
+		t.Errorf("Expected answered_by {groq: 1}, got %v", result["answered_by"])
+	}
+
+	// Client errors are not retried
+	primary.failNext(http.StatusBadRequest)
+	before := len(primary.received())
+	params, _ := json.Marshal(map[string]interface{}{"name": "sequential_thinking", "arguments": map[string]interface{}{"problem": "What is 17 * 23?"}})
+	raw, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": `+string(params)+`}`)))
+	if !strings.Contains(string(raw), "status 400") || len(primary.received()) != before {
+		t.Errorf("Expected the 400 to fail the run without retrying, got %s", raw)
+	}
+}
+
+func TestIntegration_ModelAttribution(t *testing.T) {
+	fake := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", fake)
+	t.Setenv("OPENAI_MODEL", "")
+	s := integrationServer(t)
+
+	result := callTool(t, s, "dialectic_reason", map[string]interface{}{
+		"problem": "What is 17 * 23?", "max_rounds": 1, "thesis_model": "proposer-model",
+	})
+	step := result["steps"].([]interface{})[0].(map[string]interface{})
+	thesis := step["thesis"].(map[string]interface{})
+	if thesis["model"] != "openai/proposer-model" {
+		t.Errorf("Expected the thesis attributed to the per-phase model, got %v", thesis["model"])
+	}
+	if provenance, _ := thesis["provenance"].(map[string]interface{}); provenance["requested"] != "openai/proposer-model" || provenance["fallbacks"] != nil {
+		t.Errorf("Expected the thesis provenance to name the per-phase model, got %v", thesis["provenance"])
+	}
+	if synthesis := step["synthesis"].(map[string]interface{}); synthesis["model"] != "openai/gpt-4o-mini" {
+		t.Errorf("Expected the synthesis attributed to the default model, got %v", synthesis["model"])
+	}
+	usage, _ := result["model_usage"].(map[string]interface{})
+	if usage["openai/proposer-model"] != float64(1) || usage["openai/gpt-4o-mini"] == nil {
+		t.Errorf("Expected model usage for both models, got %v", result["model_usage"])
+	}
+
+	result = callTool(t, s, "graph_of_thoughts", map[string]interface{}{"problem": "What is 17 * 23?"})
+	for _, n := range result["best_path"].([]interface{}) {
+		if node := n.(map[string]interface{}); node["depth"] != float64(0) && node["model"] != "openai/gpt-4o-mini" {
+			t.Errorf("Expected node %v attributed to openai/gpt-4o-mini, got %v", node["id"], node["model"])
+		}
+	}
+
+	result = callTool(t, s, "reflexion", map[string]interface{}{"problem": "What is 17 * 23?"})
+	if attempt := result["attempts"].([]interface{})[0].(map[string]interface{}); attempt["model"] != "openai/gpt-4o-mini" {
+		t.Errorf("Expected the attempt attributed to openai/gpt-4o-mini, got %v", attempt["model"])
+	}
+}
+
+func TestIntegration_ProvenanceFallback(t *testing.T) {
+	primary := newFakeOpenAI(t, solveResponder)
+	backup := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", primary)
+	useFakeProvider(t, "groq", backup)
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("GROQ_MODEL", "backup-model")
+	s := integrationServer(t)
+
+	// The primary is down for the first thesis, so the fallback writes it
+	primary.failNext(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	result := callTool(t, s, "dialectic_reason", map[string]interface{}{"problem": "What is 17 * 23?", "max_rounds": 1, "fallback_providers": "groq"})
+	step := result["steps"].([]interface{})[0].(map[string]interface{})
+	thesis := step["thesis"].(map[string]interface{})
+	provenance, _ := thesis["provenance"].(map[string]interface{})
+	answered, _ := provenance["answered_by"].(map[string]interface{})
+	if provenance["requested"] != "openai/gpt-4o-mini" || provenance["fallbacks"] != float64(1) || answered["groq/backup-model"] != float64(1) {
+		t.Errorf("Expected the thesis attributed to the fallback, got %v", thesis["provenance"])
+	}
+	synthesis := step["synthesis"].(map[string]interface{})
+	if provenance, _ := synthesis["provenance"].(map[string]interface{}); provenance["fallbacks"] != nil {
+		t.Errorf("Expected the synthesis answered without a fallback, got %v", synthesis["provenance"])
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"reasoning-tools/utils"
+)
+
+// ============ JSON Repair ============
+//
+// Verifications and evaluations are asked for as JSON. When a reply has no
+// valid JSON object, the evaluator is shown its reply and asked again for
+// only JSON matching the schema, up to JSON_REPAIR_ATTEMPTS times (default
+// 2, 0 turns repair off). Only then do the parsers fall back on text
+// heuristics; a dialectic verification guessed from text counts as
+// unverified, so it carries little weight in the confidence.
+
+const defaultJSONRepairAttempts = 2
+
+// JSONRepairUsage reports the JSON repairs of a run
+type JSONRepairUsage struct {
+	JSONRepairs        int `json:"json_repairs,omitempty"`         // Follow-up requests for valid JSON
+	JSONRepairFailures int `json:"json_repair_failures,omitempty"` // Replies left to the text fallback
+}
+
+// jsonRepairer asks for valid JSON again and counts the repairs of a run
+type jsonRepairer struct {
+	mu       sync.Mutex
+	attempts int
+	usage    JSONRepairUsage
+}
+
+func newJSONRepairer() *jsonRepairer {
+	return &jsonRepairer{attempts: parseEnvInt("JSON_REPAIR_ATTEMPTS", defaultJSONRepairAttempts)}
+}
+
+// hasJSONObject reports whether response holds a valid JSON object
+func hasJSONObject(response string) bool {
+	jsonStr := utils.ExtractJSON(response)
+	return jsonStr != "" && json.Valid([]byte(jsonStr))
+}
+
+// repair returns response when it holds a JSON object, else the first
+// follow-up reply that does. When every attempt fails, response is returned
+// for the text fallback. A follow-up call that fails returns its error, as a
+// failed first call would, so callers stop on ErrLLMCallBudgetExhausted
+// instead of going on with a verdict guessed from text.
+func (r *jsonRepairer) repair(ctx context.Context, provider Provider, messages []ChatMessage, response, schema string, opts ChatOptions) (string, error) {
+	if r == nil || r.attempts <= 0 || hasJSONObject(response) {
+		return response, nil
+	}
+
+	conversation := append([]ChatMessage{}, messages...)
+	reply := response
+	tried := 0
+	for ; tried < r.attempts; tried++ {
+		conversation = append(conversation,
+			ChatMessage{Role: "assistant", Content: reply},
+			ChatMessage{Role: "user", Content: fmt.Sprintf("Your reply was not valid JSON. Respond with ONLY a valid JSON object matching this schema, with no other text:\n%s", schema)},
+		)
+		r.record(func(u *JSONRepairUsage) { u.JSONRepairs++ })
+
+		var err error
+		reply, err = provider.Chat(ctx, conversation, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARNING] JSON repair attempt %d failed: %v\n", tried+1, err)
+			return "", err
+		}
+		if hasJSONObject(reply) {
+			return reply, nil
+		}
+	}
+
+	r.record(func(u *JSONRepairUsage) { u.JSONRepairFailures++ })
+	fmt.Fprintf(os.Stderr, "[WARNING] JSON repair failed after %d attempts, falling back to text parsing. Response preview: %s\n",
+		tried, utils.TruncateStr(response, 100))
+	return response, nil
+}
+
+func (r *jsonRepairer) record(update func(*JSONRepairUsage)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	update(&r.usage)
+}
+
+// report returns the repairs made so far
+func (r *jsonRepairer) report() JSONRepairUsage {
+	if r == nil {
+		return JSONRepairUsage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// repairProvider answers in prose for its first prose replies, then in JSON
+type repairProvider struct {
+	mu    sync.Mutex
+	prose int
+	calls int
+	last  []ChatMessage
+}
+
+func (p *repairProvider) Name() string { return "repair" }
+
+func (p *repairProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	p.last = messages
+	if p.calls <= p.prose {
+		return "The claim looks sound to me.", nil
+	}
+	return `{"is_valid": true, "score": 0.9, "issues": [], "strengths": ["clear"], "suggestion": ""}`, nil
+}
+
+func TestJSONRepairer_RepairsProse(t *testing.T) {
+	messages := []ChatMessage{{Role: "user", Content: "Verify the claim"}}
+	provider := &repairProvider{prose: 1}
+	r := newJSONRepairer()
+
+	reply, err := r.repair(context.Background(), provider, messages, "The claim looks sound.", verificationSchema, ChatOptions{})
+	if err != nil || !hasJSONObject(reply) || provider.calls != 2 {
+		t.Fatalf("Expected the second follow-up to return JSON, got %q after %d calls", reply, provider.calls)
+	}
+	if n := len(provider.last); n != 5 || provider.last[n-2].Role != "assistant" || !strings.Contains(provider.last[n-1].Content, verificationSchema) {
+		t.Errorf("Expected the follow-up to show the earlier replies and the schema, got %+v", provider.last)
+	}
+	if usage := r.report(); usage.JSONRepairs != 2 || usage.JSONRepairFailures != 0 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	// JSON replies are left alone
+	if reply, _ := r.repair(context.Background(), provider, messages, reply, verificationSchema, ChatOptions{}); provider.calls != 2 || !hasJSONObject(reply) {
+		t.Errorf("Expected no follow-up for a JSON reply, got %d calls", provider.calls)
+	}
+}
+
+func TestJSONRepairer_FallsBackToText(t *testing.T) {
+	provider := &repairProvider{prose: 100}
+	r := newJSONRepairer()
+	if reply, err := r.repair(context.Background(), provider, nil, "Looks valid.", verificationSchema, ChatOptions{}); err != nil || reply != "Looks valid." {
+		t.Errorf("Expected the original reply for the text fallback, got %q", reply)
+	}
+	if usage := r.report(); usage.JSONRepairs != defaultJSONRepairAttempts || usage.JSONRepairFailures != 1 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	// A repair call over the LLM call budget stops the caller instead of
+	// leaving the reply to the text fallback
+	provider = &repairProvider{prose: 100}
+	limited := NewLLMCallCounter(1).Wrap(provider)
+	r = newJSONRepairer()
+	if _, err := r.repair(context.Background(), limited, nil, "Looks valid.", verificationSchema, ChatOptions{}); !errors.Is(err, ErrLLMCallBudgetExhausted) {
+		t.Errorf("Expected the budget error from the repair, got %v", err)
+	}
+	if usage := r.report(); provider.calls != 1 || usage.JSONRepairFailures != 0 {
+		t.Errorf("Expected one follow-up and no text fallback, got %d calls and %+v", provider.calls, usage)
+	}
+
+	t.Setenv("JSON_REPAIR_ATTEMPTS", "0")
+	provider = &repairProvider{}
+	newJSONRepairer().repair(context.Background(), provider, nil, "Looks valid.", verificationSchema, ChatOptions{})
+	if provider.calls != 0 {
+		t.Errorf("Expected JSON_REPAIR_ATTEMPTS=0 to turn repair off, got %d calls", provider.calls)
+	}
+
+	// A verification guessed from text must not pass for a confident one
+	v, err := parseVerification("Looks valid.")
+	if err != nil || v.Status != StatusUnverified || v.Score != 0.5 {
+		t.Errorf("Expected an unverified neutral verification, got %+v, %v", v, err)
+	}
+}
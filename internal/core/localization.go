@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// ============ Localization ============
+//
+// language: "Spanish" (or "es", "pt-BR") makes a strategy reason and answer
+// in that language. Every LLM call of the run gets a "respond in <language>"
+// instruction that keeps JSON keys and fixed keywords in English, so the
+// strategies still parse the replies. FormatGoTResult, FormatDialecticResult
+// and FormatReflexionResult translate their section headers for the
+// languages in sectionLabels; other languages keep English headers.
+
+type languageKey struct{}
+
+// withLanguage carries the run's language, as an English language name
+func withLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+func languageFromContext(ctx context.Context) string {
+	lang, _ := ctx.Value(languageKey{}).(string)
+	return lang
+}
+
+func languageOption() mcp.ToolOption {
+	return mcp.WithString("language",
+		mcp.Description("Language to reason and answer in, as a name or code, e.g. 'Spanish', 'de', 'pt-BR' (default: unset, the model's choice)"),
+	)
+}
+
+// resolveLanguage returns the English name of a language given as a BCP 47
+// code or a name, and its base code when it is known ("" otherwise).
+// English, and anything unset, resolves to "".
+func resolveLanguage(lang string) (name, code string) {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return "", ""
+	}
+	if tag, err := language.Parse(lang); err == nil {
+		base, _ := tag.Base()
+		code = base.String()
+		name = display.English.Tags().Name(tag)
+	}
+	if name == "" {
+		// A name rather than a code: match it against the known languages
+		name = lang
+		for c := range sectionLabels {
+			if strings.EqualFold(display.English.Tags().Name(language.Make(c)), lang) {
+				code = c
+			}
+		}
+	}
+	if code == "en" || strings.EqualFold(name, "English") {
+		return "", ""
+	}
+	return name, code
+}
+
+// languageMiddleware puts the language argument of strategy calls in the
+// context for WithLanguage and the results
+func languageMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !runHistoryTools[request.Params.Name] {
+			return next(ctx, request)
+		}
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		lang, _ := args["language"].(string)
+		if name, _ := resolveLanguage(lang); name != "" {
+			ctx = withLanguage(ctx, name)
+		}
+		return next(ctx, request)
+	}
+}
+
+// languageInstruction is appended to the system prompt of a localized run
+func languageInstruction(lang string) string {
+	return "Respond in " + lang + ". Write all reasoning, explanations and answers in " + lang +
+		", but keep JSON keys, tool names, code and any fixed keywords the requested format asks for exactly as given in English."
+}
+
+// WithLanguage adds the context's language instruction to each chat, stream
+// and tool call, appending it to the system message or adding one
+func WithLanguage() ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		lang := languageFromContext(ctx)
+		if lang == "" || call.Kind == CallEmbed {
+			return next(ctx)
+		}
+		instruction := languageInstruction(lang)
+		messages := make([]ChatMessage, 0, len(call.Messages)+1)
+		if len(call.Messages) > 0 && call.Messages[0].Role == "system" {
+			system := call.Messages[0]
+			system.Content += "\n\n" + instruction
+			messages = append(append(messages, system), call.Messages[1:]...)
+		} else {
+			messages = append(append(messages, ChatMessage{Role: "system", Content: instruction}), call.Messages...)
+		}
+		call.Messages = messages
+		return next(ctx)
+	})
+}
+
+// sectionLabels translate the headers and labels of the Format functions,
+// by base language code
+var sectionLabels = map[string]map[string]string{
+	"es": {
+		"Graph of Thoughts Result": "Resultado de Graph of Thoughts", "Dialectical Reasoning Result": "Resultado del razonamiento dialéctico", "Reflexion Reasoning Result": "Resultado del razonamiento Reflexion",
+		"Problem": "Problema", "Provider": "Proveedor", "Nodes explored": "Nodos explorados", "Path merges": "Fusiones de caminos", "Tool calls": "Llamadas a herramientas", "Max depth": "Profundidad máxima",
+		"Tools Used": "Herramientas usadas", "Best Reasoning Path": "Mejor camino de razonamiento", "Final Answer": "Respuesta final", "JSON Summary": "Resumen JSON", "calls": "llamadas", "score": "puntuación", "merged paths": "caminos fusionados", "combined thoughts": "pensamientos combinados", "refined": "refinado",
+		"Rounds": "Rondas", "Confidence": "Confianza", "Round": "Ronda", "Thesis": "Tesis", "Antithesis": "Antítesis", "Rebuttal": "Réplica", "Synthesis": "Síntesis", "confidence": "de confianza", "Tool evidence": "Evidencia de herramientas", "Issues": "Problemas", "Resolved": "Resuelto",
+		"Total Attempts": "Intentos totales", "Success": "Éxito", "Total Tool Calls": "Llamadas totales a herramientas", "Lessons from Past (Applied)": "Lecciones del pasado (aplicadas)", "Attempt": "Intento", "Reasoning": "Razonamiento", "Answer": "Respuesta", "Evaluation": "Evaluación", "Result": "Resultado", "Reflection": "Reflexión",
+	},
+	"fr": {
+		"Graph of Thoughts Result": "Résultat de Graph of Thoughts", "Dialectical Reasoning Result": "Résultat du raisonnement dialectique", "Reflexion Reasoning Result": "Résultat du raisonnement Reflexion",
+		"Problem": "Problème", "Provider": "Fournisseur", "Nodes explored": "Nœuds explorés", "Path merges": "Fusions de chemins", "Tool calls": "Appels d'outils", "Max depth": "Profondeur maximale",
+		"Tools Used": "Outils utilisés", "Best Reasoning Path": "Meilleur chemin de raisonnement", "Final Answer": "Réponse finale", "JSON Summary": "Résumé JSON", "calls": "appels", "score": "score", "merged paths": "chemins fusionnés", "combined thoughts": "pensées combinées", "refined": "affiné",
+		"Rounds": "Tours", "Confidence": "Confiance", "Round": "Tour", "Thesis": "Thèse", "Antithesis": "Antithèse", "Rebuttal": "Réfutation", "Synthesis": "Synthèse", "confidence": "de confiance", "Tool evidence": "Preuves des outils", "Issues": "Problèmes", "Resolved": "Résolu",
+		"Total Attempts": "Tentatives", "Success": "Succès", "Total Tool Calls": "Appels d'outils au total", "Lessons from Past (Applied)": "Leçons du passé (appliquées)", "Attempt": "Tentative", "Reasoning": "Raisonnement", "Answer": "Réponse", "Evaluation": "Évaluation", "Result": "Résultat", "Reflection": "Réflexion",
+	},
+	"de": {
+		"Graph of Thoughts Result": "Ergebnis von Graph of Thoughts", "Dialectical Reasoning Result": "Ergebnis des dialektischen Denkens", "Reflexion Reasoning Result": "Ergebnis des Reflexion-Denkens",
+		"Problem": "Problem", "Provider": "Anbieter", "Nodes explored": "Untersuchte Knoten", "Path merges": "Zusammengeführte Pfade", "Tool calls": "Werkzeugaufrufe", "Max depth": "Maximale Tiefe",
+		"Tools Used": "Verwendete Werkzeuge", "Best Reasoning Path": "Bester Denkpfad", "Final Answer": "Endgültige Antwort", "JSON Summary": "JSON-Zusammenfassung", "calls": "Aufrufe", "score": "Bewertung", "merged paths": "Pfade zusammengeführt", "combined thoughts": "Gedanken kombiniert", "refined": "verfeinert",
+		"Rounds": "Runden", "Confidence": "Konfidenz", "Round": "Runde", "Thesis": "These", "Antithesis": "Antithese", "Rebuttal": "Erwiderung", "Synthesis": "Synthese", "confidence": "Konfidenz", "Tool evidence": "Belege der Werkzeuge", "Issues": "Mängel", "Resolved": "Gelöst",
+		"Total Attempts": "Versuche insgesamt", "Success": "Erfolg", "Total Tool Calls": "Werkzeugaufrufe insgesamt", "Lessons from Past (Applied)": "Lehren aus der Vergangenheit (angewandt)", "Attempt": "Versuch", "Reasoning": "Überlegungen", "Answer": "Antwort", "Evaluation": "Bewertung", "Result": "Ergebnis", "Reflection": "Reflexion",
+	},
+	"pt": {
+		"Graph of Thoughts Result": "Resultado do Graph of Thoughts", "Dialectical Reasoning Result": "Resultado do raciocínio dialético", "Reflexion Reasoning Result": "Resultado do raciocínio Reflexion",
+		"Problem": "Problema", "Provider": "Provedor", "Nodes explored": "Nós explorados", "Path merges": "Fusões de caminhos", "Tool calls": "Chamadas de ferramentas", "Max depth": "Profundidade máxima",
+		"Tools Used": "Ferramentas usadas", "Best Reasoning Path": "Melhor caminho de raciocínio", "Final Answer": "Resposta final", "JSON Summary": "Resumo JSON", "calls": "chamadas", "score": "pontuação", "merged paths": "caminhos fundidos", "combined thoughts": "pensamentos combinados", "refined": "refinado",
+		"Rounds": "Rodadas", "Confidence": "Confiança", "Round": "Rodada", "Thesis": "Tese", "Antithesis": "Antítese", "Rebuttal": "Réplica", "Synthesis": "Síntese", "confidence": "de confiança", "Tool evidence": "Evidências das ferramentas", "Issues": "Problemas", "Resolved": "Resolvido",
+		"Total Attempts": "Tentativas", "Success": "Sucesso", "Total Tool Calls": "Total de chamadas de ferramentas", "Lessons from Past (Applied)": "Lições do passado (aplicadas)", "Attempt": "Tentativa", "Reasoning": "Raciocínio", "Answer": "Resposta", "Evaluation": "Avaliação", "Result": "Resultado", "Reflection": "Reflexão",
+	},
+	"zh": {
+		"Graph of Thoughts Result": "Graph of Thoughts 结果", "Dialectical Reasoning Result": "辩证推理结果", "Reflexion Reasoning Result": "Reflexion 推理结果",
+		"Problem": "问题", "Provider": "提供方", "Nodes explored": "探索的节点", "Path merges": "路径合并", "Tool calls": "工具调用", "Max depth": "最大深度",
+		"Tools Used": "使用的工具", "Best Reasoning Path": "最佳推理路径", "Final Answer": "最终答案", "JSON Summary": "JSON 摘要", "calls": "次调用", "score": "得分", "merged paths": "条路径已合并", "combined thoughts": "个思路已综合", "refined": "已改进",
+		"Rounds": "轮数", "Confidence": "置信度", "Round": "轮次", "Thesis": "正题", "Antithesis": "反题", "Rebuttal": "反驳", "Synthesis": "合题", "confidence": "置信度", "Tool evidence": "工具证据", "Issues": "问题点", "Resolved": "已解决",
+		"Total Attempts": "尝试次数", "Success": "成功", "Total Tool Calls": "工具调用总数", "Lessons from Past (Applied)": "过往经验（已应用）", "Attempt": "尝试", "Reasoning": "推理", "Answer": "答案", "Evaluation": "评估", "Result": "结果", "Reflection": "反思",
+	},
+	"ja": {
+		"Graph of Thoughts Result": "Graph of Thoughts の結果", "Dialectical Reasoning Result": "弁証法的推論の結果", "Reflexion Reasoning Result": "Reflexion 推論の結果",
+		"Problem": "問題", "Provider": "プロバイダー", "Nodes explored": "探索したノード", "Path merges": "パスの統合", "Tool calls": "ツール呼び出し", "Max depth": "最大深さ",
+		"Tools Used": "使用したツール", "Best Reasoning Path": "最良の推論パス", "Final Answer": "最終回答", "JSON Summary": "JSON 概要", "calls": "回", "score": "スコア", "merged paths": "パスを統合", "combined thoughts": "件の思考を統合", "refined": "改善済み",
+		"Rounds": "ラウンド数", "Confidence": "確信度", "Round": "ラウンド", "Thesis": "テーゼ", "Antithesis": "アンチテーゼ", "Rebuttal": "反論", "Synthesis": "ジンテーゼ", "confidence": "確信度", "Tool evidence": "ツールによる根拠", "Issues": "問題点", "Resolved": "解決済み",
+		"Total Attempts": "試行回数", "Success": "成功", "Total Tool Calls": "ツール呼び出し総数", "Lessons from Past (Applied)": "過去の教訓（適用済み）", "Attempt": "試行", "Reasoning": "推論", "Answer": "回答", "Evaluation": "評価", "Result": "結果", "Reflection": "振り返り",
+	},
+}
+
+// label translates an English header or label into lang, a language name
+// or code; unknown languages and labels stay English
+func label(lang, english string) string {
+	if lang == "" {
+		return english
+	}
+	_, code := resolveLanguage(lang)
+	if translated, ok := sectionLabels[code][english]; ok {
+		return translated
+	}
+	return english
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResolveLanguage(t *testing.T) {
+	for _, tc := range []struct{ in, name, code string }{
+		{"es", "Spanish", "es"},
+		{"Spanish", "Spanish", "es"},
+		{"pt-BR", "Brazilian Portuguese", "pt"},
+		{"ja", "Japanese", "ja"},
+		{"en", "", ""},
+		{"English", "", ""},
+		{"", "", ""},
+	} {
+		if name, code := resolveLanguage(tc.in); name != tc.name || code != tc.code {
+			t.Errorf("resolveLanguage(%q) = %q, %q, expected %q, %q", tc.in, name, code, tc.name, tc.code)
+		}
+	}
+}
+
+func TestFormatDialecticResult_Localized(t *testing.T) {
+	result := &DialecticResult{
+		Problem:     "¿Cuánto es 2 + 2?",
+		FinalAnswer: "4",
+		TotalRounds: 1,
+		Language:    "Spanish",
+		Steps:       []DialecticStep{{Round: 1, Resolved: true}},
+	}
+	text := FormatDialecticResult(result)
+	for _, want := range []string{"## Resultado del razonamiento dialéctico", "**Problema:**", "### Ronda 1", "**Tesis**", "### Respuesta final"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+	result.Language = ""
+	if text := FormatDialecticResult(result); !strings.Contains(text, "### Final Answer") {
+		t.Errorf("Expected English headers without a language:\n%s", text)
+	}
+}
+
+func TestLanguageMiddleware_InstructsEveryCall(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		systems []string
+	)
+	fake := newFakeOpenAI(t, func(req fakeChatRequest) string {
+		mu.Lock()
+		systems = append(systems, req.system())
+		mu.Unlock()
+		return solveResponder(req)
+	})
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t, languageMiddleware)
+
+	result := callTool(t, s, "reflexion", map[string]interface{}{"problem": "What is 17 * 23?", "language": "de", "max_attempts": 1})
+	if result["language"] != "German" {
+		t.Errorf("Expected the result to name the language, got %v", result["language"])
+	}
+	if len(systems) == 0 {
+		t.Fatal("Expected LLM calls")
+	}
+	for _, system := range systems {
+		if !strings.HasSuffix(system, languageInstruction("German")) {
+			t.Errorf("Expected the German instruction at the end of the system prompt, got %q", system)
+		}
+	}
+}
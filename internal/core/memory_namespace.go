@@ -0,0 +1,237 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Memory Namespaces ============
+//
+// Reflexion episodes belong to a namespace, so lessons learned on one
+// project or tenant are not recalled for another. A call picks its
+// namespace with memory_namespace; REFLEXION_MEMORY_NAMESPACE sets the
+// default, and "client" names the namespace after the MCP client (its
+// clientInfo name). The default namespace, "", holds the episodes written
+// before namespaces existed. Seed lessons apply in every namespace, and
+// MaxEpisodes caps each namespace separately. memory_list and memory_delete
+// inspect and clean up one namespace.
+
+// memoryNamespaceClient resolves to the namespace of the calling MCP client
+const memoryNamespaceClient = "client"
+
+const defaultMemoryListLimit = 20
+
+var (
+	memoryNamespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+	namespaceInvalidChars  = regexp.MustCompile(`[^a-z0-9_.-]+`)
+)
+
+func memoryNamespaceOption() mcp.ToolOption {
+	return mcp.WithString("memory_namespace",
+		mcp.Description("Memory namespace to learn from and record lessons in, e.g. a project name; 'client' = the MCP client's name (default: REFLEXION_MEMORY_NAMESPACE, else the shared default namespace)"),
+	)
+}
+
+// parseMemoryNamespace validates a namespace name: lowercase letters,
+// digits, '.', '_' and '-'. "" and "default" are the default namespace.
+func parseMemoryNamespace(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "default" {
+		return "", nil
+	}
+	if !memoryNamespacePattern.MatchString(value) {
+		return "", fmt.Errorf("invalid memory_namespace %q: use up to 64 letters, digits, '.', '_' or '-'", value)
+	}
+	return value, nil
+}
+
+// defaultMemoryNamespace is REFLEXION_MEMORY_NAMESPACE, or the default
+// namespace when it is unset or invalid
+func defaultMemoryNamespace() string {
+	namespace, err := parseMemoryNamespace(os.Getenv("REFLEXION_MEMORY_NAMESPACE"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: REFLEXION_MEMORY_NAMESPACE: %v (using the default namespace)\n", err)
+		return ""
+	}
+	return namespace
+}
+
+// resolveMemoryNamespace turns "client" into the calling client's namespace,
+// or the default namespace when the client did not name itself
+func resolveMemoryNamespace(ctx context.Context, namespace string) string {
+	if namespace != memoryNamespaceClient {
+		return namespace
+	}
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return ""
+	}
+	name := strings.Trim(namespaceInvalidChars.ReplaceAllString(strings.ToLower(session.GetClientInfo().Name), "-"), "-._")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// namespaceLabel names a namespace in output
+func namespaceLabel(namespace string) string {
+	if namespace == "" {
+		return "default"
+	}
+	return namespace
+}
+
+// memoryNamespaceFromArgs is the namespace a memory tool call names, with
+// the same defaults as reflexion
+func memoryNamespaceFromArgs(ctx context.Context, args map[string]interface{}) (string, error) {
+	namespace := defaultMemoryNamespace()
+	if ns, ok := args["memory_namespace"].(string); ok {
+		var err error
+		if namespace, err = parseMemoryNamespace(ns); err != nil {
+			return "", err
+		}
+	}
+	return resolveMemoryNamespace(ctx, namespace), nil
+}
+
+// MemoryListEntry is an episode as memory_list shows it
+type MemoryListEntry struct {
+	ID            string    `json:"id"`
+	Problem       string    `json:"problem"`
+	Category      string    `json:"category"`
+	Attempt       int       `json:"attempt"`
+	WasSuccessful bool      `json:"was_successful"`
+	FinalAnswer   string    `json:"final_answer,omitempty"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	Reflection    string    `json:"reflection,omitempty"`
+	Provider      string    `json:"provider"`
+	Tool          string    `json:"tool"`
+	Confidence    float64   `json:"confidence,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// memoryListEntry is the listed form of an episode
+func memoryListEntry(ep Episode) MemoryListEntry {
+	return MemoryListEntry{
+		ID:            ep.ID,
+		Problem:       ep.Problem,
+		Category:      episodeCategory(ep),
+		Attempt:       ep.Attempt,
+		WasSuccessful: ep.WasSuccessful,
+		FinalAnswer:   utils.TruncateStr(ep.FinalAnswer, 200),
+		FailureReason: ep.FailureReason,
+		Reflection:    ep.Reflection,
+		Provider:      ep.Provider,
+		Tool:          withDefault(ep.Tool, "reflexion"),
+		Confidence:    ep.Confidence,
+		Timestamp:     ep.Timestamp,
+	}
+}
+
+func handleMemoryList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		args = map[string]interface{}{}
+	}
+	namespace, err := memoryNamespaceFromArgs(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	category, _ := args["category"].(string)
+	if category, err = parseCategoryFilter(category); err != nil || category == "auto" {
+		return mcp.NewToolResultError(fmt.Sprintf("unknown category %q", args["category"])), nil
+	}
+	limit := defaultMemoryListLimit
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	memory := loadOrCreateMemory(DefaultReflexionConfig().MemoryPath)
+	memory.mu.RLock()
+	namespaces := make(map[string]int)
+	var episodes []Episode
+	for _, ep := range memory.Episodes {
+		if ep.Seed {
+			continue
+		}
+		namespaces[namespaceLabel(ep.Namespace)]++
+		if ep.Namespace == namespace && (category == "" || episodeCategory(ep) == category) {
+			episodes = append(episodes, ep)
+		}
+	}
+	memory.mu.RUnlock()
+
+	sort.SliceStable(episodes, func(i, j int) bool { return episodes[i].Timestamp.After(episodes[j].Timestamp) })
+	entries := make([]MemoryListEntry, 0, min(limit, len(episodes)))
+	for _, ep := range episodes[:min(limit, len(episodes))] {
+		entries = append(entries, memoryListEntry(ep))
+	}
+
+	output, err := json.MarshalIndent(map[string]interface{}{
+		"namespace":  namespaceLabel(namespace),
+		"total":      len(episodes),
+		"episodes":   entries,
+		"namespaces": namespaces,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize episodes: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+func handleMemoryDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	namespace, err := memoryNamespaceFromArgs(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ids := make(map[string]bool)
+	if raw, _ := args["ids"].(string); raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids[id] = true
+			}
+		}
+	}
+	all, _ := args["all"].(bool)
+	if len(ids) == 0 && !all {
+		return mcp.NewToolResultError("pass ids to delete, or all: true to empty the namespace"), nil
+	}
+
+	memory := loadOrCreateMemory(DefaultReflexionConfig().MemoryPath)
+	memory.mu.Lock()
+	kept := make([]Episode, 0, len(memory.Episodes))
+	deleted := 0
+	for _, ep := range memory.Episodes {
+		if !ep.Seed && ep.Namespace == namespace && (all || ids[ep.ID]) {
+			deleted++
+			continue
+		}
+		kept = append(kept, ep)
+	}
+	memory.Episodes = kept
+	if deleted > 0 {
+		memory.save()
+	}
+	memory.mu.Unlock()
+
+	output, _ := json.MarshalIndent(map[string]interface{}{
+		"namespace": namespaceLabel(namespace),
+		"deleted":   deleted,
+	}, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestReflexion_NamespacedLessons(t *testing.T) {
+	config := DefaultReflexionConfig()
+	config.MemoryPath = filepath.Join(t.TempDir(), "memory.json")
+	config.MaxEpisodes = 1
+	r := NewReflexion(&countProvider{}, config)
+
+	problem := "Compute the sum of the first 100 prime numbers"
+	r.namespace = "project-a"
+	r.storeEpisode(problem, 1, nil, "a", false, "wrong", "Check the prime sieve bounds")
+	r.namespace = "project-b"
+	r.storeEpisode(problem, 1, nil, "a", false, "wrong", "Use a segmented sieve")
+
+	if lessons := r.memory.lessonsFor(problem, "", "project-a"); len(lessons) != 1 || lessons[0] != "Check the prime sieve bounds" {
+		t.Errorf("Expected only project-a's lesson, got %v", lessons)
+	}
+	if lessons := r.memory.lessonsFor(problem, "", ""); len(lessons) != 0 {
+		t.Errorf("Expected no lessons in the default namespace, got %v", lessons)
+	}
+	// MaxEpisodes applies per namespace
+	if stats := r.GetMemoryStats(); stats["namespaces"].(map[string]int)["project-a"] != 1 || stats["total_episodes"] != 2 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
+
+func TestParseMemoryNamespace(t *testing.T) {
+	for in, want := range map[string]string{"": "", "default": "", " Project-A ": "project-a", "client": memoryNamespaceClient} {
+		if got, err := parseMemoryNamespace(in); err != nil || got != want {
+			t.Errorf("parseMemoryNamespace(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"../etc", "a b", "-lead"} {
+		if _, err := parseMemoryNamespace(in); err == nil {
+			t.Errorf("Expected %q to be rejected", in)
+		}
+	}
+	if got := resolveMemoryNamespace(context.Background(), memoryNamespaceClient); got != "" {
+		t.Errorf("Expected the default namespace without a client, got %q", got)
+	}
+}
+
+func TestMemoryListAndDelete(t *testing.T) {
+	s := integrationServer(t)
+	s.AddTool(mcp.NewTool("memory_list"), handleMemoryList)
+	s.AddTool(mcp.NewTool("memory_delete"), handleMemoryDelete)
+
+	r := NewReflexion(&countProvider{}, DefaultReflexionConfig())
+	r.namespace = "team"
+	r.storeEpisode("p1", 1, nil, "a", false, "wrong", "try again")
+	r.storeEpisode("p2", 1, nil, "b", true, "", "")
+	r.namespace = ""
+	r.storeEpisode("p3", 1, nil, "c", false, "wrong", "other")
+
+	list := callTool(t, s, "memory_list", map[string]interface{}{"memory_namespace": "team"})
+	episodes := list["episodes"].([]interface{})
+	if list["total"] != 2.0 || len(episodes) != 2 || list["namespaces"].(map[string]interface{})["default"] != 1.0 {
+		t.Fatalf("Unexpected list: %v", list)
+	}
+
+	id := episodes[0].(map[string]interface{})["id"].(string)
+	if deleted := callTool(t, s, "memory_delete", map[string]interface{}{"memory_namespace": "team", "ids": id}); deleted["deleted"] != 1.0 {
+		t.Errorf("Expected one deletion, got %v", deleted)
+	}
+	if deleted := callTool(t, s, "memory_delete", map[string]interface{}{"memory_namespace": "team", "all": true}); deleted["deleted"] != 1.0 {
+		t.Errorf("Expected the rest of the namespace deleted, got %v", deleted)
+	}
+	if list := callTool(t, s, "memory_list", map[string]interface{}{}); list["total"] != 1.0 {
+		t.Errorf("Expected the default namespace untouched, got %v", list)
+	}
+}
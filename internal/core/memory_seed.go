@@ -0,0 +1,167 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ============ Seed Lessons ============
+//
+// Curated lessons (problem pattern → advice) are loaded into episodic memory
+// at startup as synthetic failed episodes, so reflexion applies them to
+// matching problems before it has failed at anything itself. Seeds are kept
+// apart from MaxEpisodes and EpisodeTTL, and each load replaces the previous
+// seeds so the files stay the source of truth.
+
+// SeedLesson is one curated lesson
+type SeedLesson struct {
+	Pattern string `json:"pattern" yaml:"pattern"` // Case-insensitive regular expression matched against problems
+	Advice  string `json:"advice" yaml:"advice"`
+}
+
+// loadSeedLessons reads lessons from a YAML file (a list, or a map with a
+// "lessons" list), a JSON array or JSONL, chosen by extension
+func loadSeedLessons(path string) ([]SeedLesson, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lessons []SeedLesson
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &lessons); err != nil {
+			var wrapped struct {
+				Lessons []SeedLesson `yaml:"lessons"`
+			}
+			if wrapErr := yaml.Unmarshal(data, &wrapped); wrapErr != nil {
+				return nil, fmt.Errorf("invalid YAML: %w", err)
+			}
+			lessons = wrapped.Lessons
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &lessons); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	default: // JSONL
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			var lesson SeedLesson
+			if err := json.Unmarshal([]byte(text), &lesson); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			lessons = append(lessons, lesson)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, lesson := range lessons {
+		lessons[i].Pattern = strings.TrimSpace(lesson.Pattern)
+		lessons[i].Advice = strings.TrimSpace(lesson.Advice)
+		if lessons[i].Pattern == "" || lessons[i].Advice == "" {
+			return nil, fmt.Errorf("lesson %d needs both pattern and advice", i+1)
+		}
+		if _, err := seedPattern(lessons[i].Pattern); err != nil {
+			return nil, fmt.Errorf("lesson %d: invalid pattern %q: %w", i+1, lessons[i].Pattern, err)
+		}
+	}
+	return lessons, nil
+}
+
+// seedMemory replaces the seed episodes in the memory file at memoryPath
+// with the lessons from paths and returns how many were stored
+func seedMemory(memoryPath string, paths []string) (int, error) {
+	var lessons []SeedLesson
+	for _, path := range paths {
+		loaded, err := loadSeedLessons(path)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		lessons = append(lessons, loaded...)
+	}
+
+	memory := loadOrCreateMemory(memoryPath)
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+	n := memory.replaceSeeds(lessons, time.Now())
+	memory.save()
+	return n, nil
+}
+
+// replaceSeeds drops existing seed episodes and adds one per distinct lesson
+func (m *EpisodicMemory) replaceSeeds(lessons []SeedLesson, now time.Time) int {
+	kept := make([]Episode, 0, len(m.Episodes)+len(lessons))
+	for _, ep := range m.Episodes {
+		if !ep.Seed {
+			kept = append(kept, ep)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, lesson := range lessons {
+		id := "seed_" + hashProblem(lesson.Pattern + "\n" + lesson.Advice)[:12]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		kept = append(kept, Episode{
+			ID:            id,
+			Problem:       lesson.Pattern,
+			ProblemHash:   hashProblem(lesson.Pattern),
+			FailureReason: "seed lesson",
+			Reflection:    lesson.Advice,
+			Timestamp:     now,
+			Provider:      "seed",
+			Seed:          true,
+		})
+	}
+	m.Episodes = kept
+	return len(seen)
+}
+
+var seedPatterns sync.Map // pattern -> *regexp.Regexp
+
+func seedPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := seedPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	seedPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// seedMatches reports whether a seed episode's pattern matches the problem
+func seedMatches(pattern, problem string) bool {
+	re, err := seedPattern(pattern)
+	return err == nil && re.MatchString(problem)
+}
+
+// seedLessonPaths splits REFLEXION_SEED_LESSONS-style comma-separated paths
+func seedLessonPaths(value string) []string {
+	var paths []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadSeedLessons_Formats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"list.yaml":     "- pattern: 'off[- ]by[- ]one'\n  advice: Check loop bounds with the smallest inputs.\n",
+		"wrapped.yml":   "lessons:\n  - pattern: probability\n    advice: Enumerate the sample space before computing.\n",
+		"lessons.json":  `[{"pattern": "prime", "advice": "Remember 1 is not prime."}]`,
+		"lessons.jsonl": "# curated\n{\"pattern\": \"regex\", \"advice\": \"Anchor the pattern.\"}\n\n{\"pattern\": \"date\", \"advice\": \"Mind leap years.\"}\n",
+	}
+	want := map[string]int{"list.yaml": 1, "wrapped.yml": 1, "lessons.json": 1, "lessons.jsonl": 2}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0600)
+		lessons, err := loadSeedLessons(path)
+		if err != nil || len(lessons) != want[name] {
+			t.Errorf("%s: expected %d lessons, got %v (%v)", name, want[name], lessons, err)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"missing.jsonl": `{"pattern": "x"}`,
+		"badre.jsonl":   `{"pattern": "(", "advice": "x"}`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0600)
+		if _, err := loadSeedLessons(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSeedMemory_LessonsAppliedAndKept(t *testing.T) {
+	dir := t.TempDir()
+	memoryPath := filepath.Join(dir, "memory.json")
+	seedPath := filepath.Join(dir, "seeds.jsonl")
+	os.WriteFile(seedPath, []byte(`{"pattern": "\\bprimes?\\b", "advice": "Remember 1 is not prime."}
+{"pattern": "\\bprimes?\\b", "advice": "Remember 1 is not prime."}
+`), 0600)
+
+	for i := 0; i < 2; i++ { // Reseeding replaces rather than duplicates
+		n, err := seedMemory(memoryPath, []string{seedPath})
+		if err != nil || n != 1 {
+			t.Fatalf("Expected one distinct seed, got %d (%v)", n, err)
+		}
+	}
+
+	config := DefaultReflexionConfig()
+	config.MemoryPath = memoryPath
+	config.MaxEpisodes = 1
+	config.EpisodeTTL = time.Hour
+	r := NewReflexion(&countProvider{}, config)
+
+	if lessons := r.getPastLessons("How many PRIMES are there below 50?"); len(lessons) != 1 || lessons[0] != "Remember 1 is not prime." {
+		t.Errorf("Expected the seed lesson for a matching problem, got %v", lessons)
+	}
+	if lessons := r.getPastLessons("Sort this list of numbers"); len(lessons) != 0 {
+		t.Errorf("Expected no lessons for an unrelated problem, got %v", lessons)
+	}
+
+	r.storeEpisode("p1", 1, nil, "a", false, "wrong", "try again")
+	r.storeEpisode("p2", 1, nil, "a", false, "wrong", "try harder")
+	stats := r.GetMemoryStats()
+	if stats["seed_lessons"] != 1 || stats["failed_episodes"] != 1 {
+		t.Errorf("Expected the seed kept outside MaxEpisodes, got %v", stats)
+	}
+}
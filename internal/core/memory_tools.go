@@ -0,0 +1,317 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ============ Memory Management Tools ============
+//
+// memory_search finds episodes by keyword and similarity, memory_export and
+// memory_import move a namespace's episodes as JSON (for backups, or to
+// seed a new project from an old one), and memory_prune deletes episodes
+// by age, outcome or count. Each works on one namespace (see
+// memory_namespace.go); seed lessons are left alone.
+
+const defaultMemorySearchLimit = 10
+
+// MemoryExport is the document memory_export writes and memory_import reads
+type MemoryExport struct {
+	Version    int       `json:"version"`
+	Namespace  string    `json:"namespace"`
+	ExportedAt time.Time `json:"exported_at"`
+	Episodes   []Episode `json:"episodes"`
+}
+
+// MemorySearchHit is an episode found by memory_search
+type MemorySearchHit struct {
+	Score float64 `json:"score"`
+	MemoryListEntry
+}
+
+// episodeSearchScore rates an episode against a query: the larger of the
+// share of query words found anywhere in the episode and the similarity of
+// the query to its problem
+func episodeSearchScore(ep Episode, query string) float64 {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return 0
+	}
+	text := strings.ToLower(strings.Join([]string{ep.Problem, ep.FinalAnswer, ep.FailureReason, ep.Reflection}, "\n"))
+	found := 0
+	for _, w := range words {
+		if strings.Contains(text, w) {
+			found++
+		}
+	}
+	return max(float64(found)/float64(len(words)), stringSimilarity(query, ep.Problem))
+}
+
+func handleMemorySearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	query, _ := args["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("query parameter is required"), nil
+	}
+	namespace, err := memoryNamespaceFromArgs(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	limit := defaultMemorySearchLimit
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+	minScore := 0.2
+	if ms, ok := args["min_score"].(float64); ok {
+		minScore = ms
+	}
+
+	memory := loadOrCreateMemory(DefaultReflexionConfig().MemoryPath)
+	memory.mu.RLock()
+	var hits []MemorySearchHit
+	for _, ep := range memory.Episodes {
+		if ep.Seed || ep.Namespace != namespace {
+			continue
+		}
+		if score := episodeSearchScore(ep, query); score >= minScore && score > 0 {
+			hits = append(hits, MemorySearchHit{Score: roundTo(score, 3), MemoryListEntry: memoryListEntry(ep)})
+		}
+	}
+	memory.mu.RUnlock()
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Timestamp.After(hits[j].Timestamp)
+	})
+	total := len(hits)
+	hits = hits[:min(limit, len(hits))]
+
+	output, err := json.MarshalIndent(map[string]interface{}{
+		"namespace": namespaceLabel(namespace),
+		"query":     query,
+		"total":     total,
+		"episodes":  hits,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize episodes: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+func handleMemoryExport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		args = map[string]interface{}{}
+	}
+	namespace, err := memoryNamespaceFromArgs(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	memory := loadOrCreateMemory(DefaultReflexionConfig().MemoryPath)
+	memory.mu.RLock()
+	export := MemoryExport{Version: 1, Namespace: namespaceLabel(namespace), ExportedAt: time.Now().UTC(), Episodes: []Episode{}}
+	for _, ep := range memory.Episodes {
+		if !ep.Seed && ep.Namespace == namespace {
+			export.Episodes = append(export.Episodes, ep)
+		}
+	}
+	memory.mu.RUnlock()
+
+	output, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize episodes: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+// parseMemoryImport reads a memory_export document or a bare array of
+// episodes
+func parseMemoryImport(data string) ([]Episode, error) {
+	data = strings.TrimSpace(data)
+	var episodes []Episode
+	if strings.HasPrefix(data, "[") {
+		if err := json.Unmarshal([]byte(data), &episodes); err != nil {
+			return nil, fmt.Errorf("invalid episodes array: %w", err)
+		}
+		return episodes, nil
+	}
+	var export MemoryExport
+	if err := json.Unmarshal([]byte(data), &export); err != nil {
+		return nil, fmt.Errorf("invalid memory export: %w", err)
+	}
+	if export.Version > 1 {
+		return nil, fmt.Errorf("memory export version %d is newer than this server supports", export.Version)
+	}
+	return export.Episodes, nil
+}
+
+func handleMemoryImport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	data, _ := args["episodes"].(string)
+	if strings.TrimSpace(data) == "" {
+		return mcp.NewToolResultError("episodes parameter is required"), nil
+	}
+	episodes, err := parseMemoryImport(data)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	namespace, err := memoryNamespaceFromArgs(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	config := DefaultReflexionConfig()
+	memory := loadOrCreateMemory(config.MemoryPath)
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+	existing := make(map[string]bool, len(memory.Episodes))
+	for _, ep := range memory.Episodes {
+		if ep.Namespace == namespace {
+			existing[ep.ID] = true
+		}
+	}
+	imported, skipped := 0, 0
+	for _, ep := range episodes {
+		if ep.Seed || strings.TrimSpace(ep.Problem) == "" || existing[ep.ID] {
+			skipped++
+			continue
+		}
+		// Moving an episode into the namespace changes it, so re-sign the
+		// ones that arrive with a valid signature and leave the rest as is
+		valid := checkSignature(ep, ep.Signature) == signatureVerified
+		ep.Namespace = namespace
+		if ep.ID == "" {
+			ep.ID = fmt.Sprintf("%s_%d_%d", hashProblem(ep.Problem)[:8], ep.Attempt, ep.Timestamp.Unix())
+		}
+		if ep.ProblemHash == "" {
+			ep.ProblemHash = hashProblem(ep.Problem)
+		}
+		if ep.Timestamp.IsZero() {
+			ep.Timestamp = time.Now()
+		}
+		if valid {
+			ep.Signature = signRecord(ep)
+		}
+		existing[ep.ID] = true
+		memory.Episodes = append(memory.Episodes, ep)
+		imported++
+	}
+	// Keep the timeline in order so the episode limit drops the oldest
+	sort.SliceStable(memory.Episodes, func(i, j int) bool { return memory.Episodes[i].Timestamp.Before(memory.Episodes[j].Timestamp) })
+	dropped := memory.cleanup(config.MaxEpisodes, config.EpisodeTTL)
+	if imported > 0 {
+		memory.save()
+	}
+
+	output, _ := json.MarshalIndent(map[string]interface{}{
+		"namespace": namespaceLabel(namespace),
+		"imported":  imported,
+		"skipped":   skipped, // Seeds, episodes without a problem and IDs already present
+		"dropped":   dropped, // Over the episode limit or past the TTL
+	}, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+// MemoryPruneOptions select the episodes memory_prune deletes. OlderThan and
+// Status narrow the candidates (both must match when both are set);
+// KeepLatest then deletes all but the newest remaining episodes.
+type MemoryPruneOptions struct {
+	OlderThan  time.Duration // 0 = any age
+	Status     string        // "successful", "failed" or "" = either
+	KeepLatest int           // -1 = no count limit
+}
+
+// prune deletes a namespace's episodes per opts and returns the deleted ones
+func (m *EpisodicMemory) prune(namespace string, opts MemoryPruneOptions, now time.Time) []Episode {
+	filtered := opts.OlderThan > 0 || opts.Status != ""
+	var kept, deleted, namespaceKept []Episode
+	for _, ep := range m.Episodes {
+		if ep.Seed || ep.Namespace != namespace {
+			kept = append(kept, ep)
+			continue
+		}
+		match := filtered &&
+			(opts.OlderThan == 0 || ep.Timestamp.Before(now.Add(-opts.OlderThan))) &&
+			(opts.Status == "" || (opts.Status == "successful") == ep.WasSuccessful)
+		if match {
+			deleted = append(deleted, ep)
+		} else {
+			namespaceKept = append(namespaceKept, ep)
+		}
+	}
+	if opts.KeepLatest >= 0 && len(namespaceKept) > opts.KeepLatest {
+		sort.SliceStable(namespaceKept, func(i, j int) bool { return namespaceKept[i].Timestamp.Before(namespaceKept[j].Timestamp) })
+		cut := len(namespaceKept) - opts.KeepLatest
+		deleted = append(deleted, namespaceKept[:cut]...)
+		namespaceKept = namespaceKept[cut:]
+	}
+	m.Episodes = append(kept, namespaceKept...)
+	sort.SliceStable(m.Episodes, func(i, j int) bool { return m.Episodes[i].Timestamp.Before(m.Episodes[j].Timestamp) })
+	return deleted
+}
+
+func handleMemoryPrune(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	namespace, err := memoryNamespaceFromArgs(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts := MemoryPruneOptions{KeepLatest: -1}
+	if days, ok := args["older_than_days"].(float64); ok && days > 0 {
+		opts.OlderThan = time.Duration(days * float64(24*time.Hour))
+	}
+	if status, _ := args["status"].(string); status != "" {
+		opts.Status = strings.ToLower(strings.TrimSpace(status))
+		if opts.Status != "successful" && opts.Status != "failed" {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown status %q (use successful or failed)", status)), nil
+		}
+	}
+	if keep, ok := args["keep_latest"].(float64); ok && keep >= 0 {
+		opts.KeepLatest = int(keep)
+	}
+	if opts.OlderThan == 0 && opts.Status == "" && opts.KeepLatest < 0 {
+		return mcp.NewToolResultError("pass older_than_days, status or keep_latest"), nil
+	}
+	dryRun, _ := args["dry_run"].(bool)
+
+	memory := loadOrCreateMemory(DefaultReflexionConfig().MemoryPath)
+	memory.mu.Lock()
+	before := memory.Episodes
+	deleted := memory.prune(namespace, opts, time.Now())
+	if dryRun {
+		memory.Episodes = before
+	} else if len(deleted) > 0 {
+		memory.save()
+	}
+	memory.mu.Unlock()
+
+	ids := make([]string, len(deleted))
+	for i, ep := range deleted {
+		ids[i] = ep.ID
+	}
+	output, _ := json.MarshalIndent(map[string]interface{}{
+		"namespace": namespaceLabel(namespace),
+		"deleted":   len(deleted),
+		"ids":       ids,
+		"dry_run":   dryRun,
+	}, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
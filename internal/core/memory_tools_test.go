@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestEpisodicMemory_Prune(t *testing.T) {
+	now := time.Now()
+	m := &EpisodicMemory{Episodes: []Episode{
+		{ID: "old-ok", Problem: "p", WasSuccessful: true, Timestamp: now.Add(-40 * 24 * time.Hour)},
+		{ID: "old-failed", Problem: "p", Timestamp: now.Add(-30 * 24 * time.Hour)},
+		{ID: "new-failed", Problem: "p", Timestamp: now.Add(-time.Hour)},
+		{ID: "new-ok", Problem: "p", WasSuccessful: true, Timestamp: now},
+		{ID: "other", Problem: "p", Namespace: "other", Timestamp: now.Add(-50 * 24 * time.Hour)},
+		{ID: "seed", Problem: "p", Seed: true, Timestamp: now.Add(-50 * 24 * time.Hour)},
+	}}
+
+	ids := func(episodes []Episode) []string {
+		var out []string
+		for _, ep := range episodes {
+			out = append(out, ep.ID)
+		}
+		return out
+	}
+	if deleted := m.prune("", MemoryPruneOptions{OlderThan: 7 * 24 * time.Hour, Status: "successful", KeepLatest: -1}, now); len(deleted) != 1 || deleted[0].ID != "old-ok" {
+		t.Errorf("Expected only the old successful episode deleted, got %v", ids(deleted))
+	}
+	if deleted := m.prune("", MemoryPruneOptions{KeepLatest: 1}, now); len(deleted) != 2 {
+		t.Errorf("Expected all but the newest deleted, got %v", ids(deleted))
+	}
+	if got := ids(m.Episodes); len(got) != 3 || got[2] != "new-ok" {
+		t.Errorf("Expected the other namespace, the seed and the newest episode kept, got %v", got)
+	}
+}
+
+func TestMemoryTools_SearchExportImport(t *testing.T) {
+	s := integrationServer(t)
+	s.AddTool(mcp.NewTool("memory_search"), handleMemorySearch)
+	s.AddTool(mcp.NewTool("memory_export"), handleMemoryExport)
+	s.AddTool(mcp.NewTool("memory_import"), handleMemoryImport)
+	s.AddTool(mcp.NewTool("memory_list"), handleMemoryList)
+
+	r := NewReflexion(&countProvider{}, DefaultReflexionConfig())
+	r.namespace = "alpha"
+	r.storeEpisode("Compute the sum of the first 100 prime numbers", 1, nil, "24133", false, "off by one", "Check the prime sieve bounds")
+	r.storeEpisode("Plan a three day trip to Rome", 1, nil, "Day 1: ...", true, "", "")
+
+	found := callTool(t, s, "memory_search", map[string]interface{}{"query": "prime sieve", "memory_namespace": "alpha"})
+	hits := found["episodes"].([]interface{})
+	if len(hits) != 1 || hits[0].(map[string]interface{})["score"] != 1.0 {
+		t.Fatalf("Expected the prime episode as the only full match, got %v", found)
+	}
+
+	exported := callToolOn(context.Background(), s, "memory_export", map[string]interface{}{"memory_namespace": "alpha"})
+	if exported.IsError {
+		t.Fatalf("memory_export failed: %s", exported.Text)
+	}
+	imported := callTool(t, s, "memory_import", map[string]interface{}{"episodes": exported.Text, "memory_namespace": "beta"})
+	if imported["imported"] != 2.0 || imported["skipped"] != 0.0 {
+		t.Errorf("Unexpected import: %v", imported)
+	}
+	again := callTool(t, s, "memory_import", map[string]interface{}{"episodes": exported.Text, "memory_namespace": "beta"})
+	if again["imported"] != 0.0 || again["skipped"] != 2.0 {
+		t.Errorf("Expected a second import to skip every episode, got %v", again)
+	}
+	if list := callTool(t, s, "memory_list", map[string]interface{}{"memory_namespace": "beta"}); list["total"] != 2.0 {
+		t.Errorf("Expected both episodes in beta, got %v", list)
+	}
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ============ Mock Provider ============
+//
+// provider=mock answers from scripted rules instead of an API, so every
+// reasoning engine can run end to end in CI or locally without keys. Rules
+// come from the JSON fixture named by MOCK_FIXTURES; without one, built-in
+// rules give each strategy a well-formed reply to its prompts. Replies are
+// deterministic: a provider is built per tool call, so every run starts its
+// reply sequences over.
+
+// MockRule scripts the reply to prompts matching it
+type MockRule struct {
+	Match     string   `json:"match,omitempty"`     // Substring the prompt must contain
+	Pattern   string   `json:"pattern,omitempty"`   // Regular expression the prompt must match
+	In        string   `json:"in,omitempty"`        // Where to look: system, user (the last message) or any (default)
+	Model     string   `json:"model,omitempty"`     // Only for calls using this model
+	Response  string   `json:"response,omitempty"`  // Reply; {{...}} is expanded as a Go template
+	Responses []string `json:"responses,omitempty"` // Replies for successive matches; the last one repeats
+	Error     string   `json:"error,omitempty"`     // Fail the call with this error instead
+
+	pattern *regexp.Regexp
+}
+
+// MockFixture is the MOCK_FIXTURES file: rules are tried in order and the
+// first match answers; unmatched prompts get the default reply
+type MockFixture struct {
+	Rules   []MockRule `json:"rules"`
+	Default string     `json:"default,omitempty"`
+}
+
+// mockTemplateData is what a reply template can use
+type mockTemplateData struct {
+	System string   // System prompt
+	User   string   // Last message
+	Model  string   // Model of the call
+	Call   int      // 1-based call number of the provider
+	Groups []string // Pattern submatches, the whole match first
+}
+
+const defaultMockReply = "This is a mock reply."
+
+// defaultMockFixture answers the prompts of every strategy with replies in
+// the format it parses
+var defaultMockFixture = MockFixture{
+	Rules: []MockRule{
+		{In: "system", Match: "sequential thinking assistant", Response: `{"thought_number": 1, "total_thoughts": 1, "thought": "Mock reasoning about the problem.", "next_thought_needed": false, "final_answer": "mock answer"}`},
+		{In: "system", Match: "Generate diverse, creative reasoning steps", Response: `["Mock reasoning step"]`},
+		{In: "system", Match: "critical evaluator of reasoning steps", Response: `{"score": 0.9, "is_solution": true, "answer": "mock answer", "reasoning": "mock evaluation"}`},
+		{In: "system", Match: "strict evaluator", Response: `{"evaluation": "correct", "is_correct": true, "issues": []}`},
+		{In: "user", Match: `"is_final"`, Response: `{"thought_number": 1, "thought": "Mock reasoning about the problem.", "is_final": true, "answer": "mock answer"}`},
+		{In: "system", Match: "careful verifier", Response: `{"is_valid": true, "score": 0.9, "issues": [], "strengths": ["mock strength"], "suggestion": ""}`},
+		{In: "system", Match: "devil's advocate", Response: "A mock objection to the thesis."},
+		{In: "system", Match: "Least-to-Most", Response: `{"subproblems": [{"question": "What does the problem ask?", "depends_on": []}, {"question": "What is the answer?", "depends_on": [1]}]}`},
+		{In: "user", Match: "Now solve sub-problem", Response: `{"reasoning": "mock reasoning", "answer": "mock answer"}`},
+		{In: "system", Match: "planning agent", Response: `{"steps": [{"description": "Work through the problem", "type": "reason"}]}`},
+		{In: "user", Match: "Current step", Response: `{"result": "mock answer", "failed": false}`},
+	},
+	Default: "The answer is mock answer.",
+}
+
+// MockProvider answers from a MockFixture
+type MockProvider struct {
+	model   string
+	fixture MockFixture
+	latency time.Duration
+
+	mu    sync.Mutex
+	calls int
+	hits  []int // Matches of each rule so far
+}
+
+// newMockProvider loads the MOCK_FIXTURES file, or uses the built-in rules.
+// MOCK_LATENCY_MS delays every reply, to exercise timeouts and streaming.
+func newMockProvider(cfg ProviderConfig) (*MockProvider, error) {
+	fixture := defaultMockFixture
+	if path := os.Getenv("MOCK_FIXTURES"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MOCK_FIXTURES: %w", err)
+		}
+		fixture = MockFixture{}
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse MOCK_FIXTURES %s: %w", path, err)
+		}
+	}
+	rules := make([]MockRule, len(fixture.Rules))
+	for i, rule := range fixture.Rules {
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("mock rule %d: invalid pattern: %w", i+1, err)
+			}
+			rule.pattern = re
+		}
+		switch rule.In {
+		case "", "any", "system", "user":
+		default:
+			return nil, fmt.Errorf("mock rule %d: in must be system, user or any, got %q", i+1, rule.In)
+		}
+		rules[i] = rule
+	}
+	fixture.Rules = rules
+	return &MockProvider{
+		model:   withDefault(cfg.Model, "mock"),
+		fixture: fixture,
+		latency: time.Duration(parseEnvInt("MOCK_LATENCY_MS", 0)) * time.Millisecond,
+		hits:    make([]int, len(rules)),
+	}, nil
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// Model returns the default model
+func (p *MockProvider) Model() string {
+	return p.model
+}
+
+func (p *MockProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	if p.latency > 0 {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(p.latency):
+		}
+	}
+	return p.reply(messages, withDefault(opts.Model, p.model))
+}
+
+// reply picks and renders the reply to messages
+func (p *MockProvider) reply(messages []ChatMessage, model string) (string, error) {
+	data := mockTemplateData{Model: model}
+	if len(messages) > 0 {
+		if messages[0].Role == "system" {
+			data.System = messages[0].Content
+		}
+		data.User = messages[len(messages)-1].Content
+	}
+	var all strings.Builder
+	for _, m := range messages {
+		all.WriteString(m.Content)
+		all.WriteString("\n")
+	}
+
+	p.mu.Lock()
+	p.calls++
+	data.Call = p.calls
+	text := p.fixture.Default
+	if text == "" {
+		text = defaultMockReply
+	}
+	for i, rule := range p.fixture.Rules {
+		subject := all.String()
+		switch rule.In {
+		case "system":
+			subject = data.System
+		case "user":
+			subject = data.User
+		}
+		if rule.Model != "" && rule.Model != model {
+			continue
+		}
+		if rule.Match != "" && !strings.Contains(subject, rule.Match) {
+			continue
+		}
+		if rule.pattern != nil {
+			data.Groups = rule.pattern.FindStringSubmatch(subject)
+			if data.Groups == nil {
+				continue
+			}
+		}
+		if rule.Error != "" {
+			p.mu.Unlock()
+			return "", errors.New(rule.Error)
+		}
+		text = rule.Response
+		if len(rule.Responses) > 0 {
+			text = rule.Responses[min(p.hits[i], len(rule.Responses)-1)]
+		}
+		p.hits[i]++
+		break
+	}
+	p.mu.Unlock()
+
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("mock").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("mock reply template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("mock reply template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func (p *MockProvider) SupportsStreaming() bool {
+	return true
+}
+
+// ChatStream sends the reply word by word
+func (p *MockProvider) ChatStream(ctx context.Context, messages []ChatMessage, opts ChatOptions, onToken TokenCallback) (string, error) {
+	reply, err := p.Chat(ctx, messages, opts)
+	if err != nil {
+		return "", err
+	}
+	if onToken != nil {
+		for _, token := range strings.SplitAfter(reply, " ") {
+			onToken(token)
+		}
+	}
+	return reply, nil
+}
+
+// mockEmbeddingDims is the size of mock embeddings
+const mockEmbeddingDims = 64
+
+// Embed returns bag-of-words vectors, so texts sharing words are similar
+func (p *MockProvider) Embed(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		v := make([]float64, mockEmbeddingDims)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			h.Write([]byte(strings.Trim(word, ".,;:!?\"'()")))
+			v[h.Sum32()%mockEmbeddingDims]++
+		}
+		var norm float64
+		for _, x := range v {
+			norm += x * x
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for j := range v {
+				v[j] /= norm
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
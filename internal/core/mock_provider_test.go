@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMockProvider_Fixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	os.WriteFile(path, []byte(`{
+		"rules": [
+			{"match": "flaky", "error": "injected failure"},
+			{"in": "user", "pattern": "What is (\\d+) \\+ (\\d+)\\?", "response": "{{index .Groups 1}} plus {{index .Groups 2}}, call {{.Call}}"},
+			{"in": "system", "match": "critic", "model": "judge", "response": "strict"},
+			{"in": "system", "match": "critic", "responses": ["first", "second"]}
+		],
+		"default": "fallback for {{.Model}}"
+	}`), 0600)
+	t.Setenv("MOCK_FIXTURES", path)
+
+	provider, err := NewProvider(ProviderConfig{Type: "mock"})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	ask := func(system, user, model string) (string, error) {
+		return provider.Chat(context.Background(), []ChatMessage{{Role: "system", Content: system}, {Role: "user", Content: user}}, ChatOptions{Model: model})
+	}
+
+	for _, tc := range []struct{ system, user, model, want string }{
+		{"", "What is 2 + 3?", "", "2 plus 3, call 1"},
+		{"a critic", "review", "judge", "strict"},
+		{"a critic", "review", "", "first"},
+		{"a critic", "review", "", "second"},
+		{"a critic", "review", "", "second"},
+		{"", "anything else", "", "fallback for mock"},
+	} {
+		if got, err := ask(tc.system, tc.user, tc.model); err != nil || got != tc.want {
+			t.Errorf("%q/%q: expected %q, got %q, %v", tc.system, tc.user, tc.want, got, err)
+		}
+	}
+	if _, err := ask("", "a flaky prompt", ""); err == nil || !strings.Contains(err.Error(), "injected failure") {
+		t.Errorf("Expected the scripted error, got %v", err)
+	}
+
+	var tokens []string
+	reply, err := provider.(StreamingProvider).ChatStream(context.Background(), []ChatMessage{{Role: "user", Content: "other"}}, ChatOptions{}, func(token string) { tokens = append(tokens, token) })
+	if err != nil || strings.Join(tokens, "") != reply || len(tokens) != 3 {
+		t.Errorf("Expected the reply streamed word by word, got %q from %q", tokens, reply)
+	}
+}
+
+func TestMockProvider_InvalidFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	os.WriteFile(path, []byte(`{"rules": [{"pattern": "("}]}`), 0600)
+	t.Setenv("MOCK_FIXTURES", path)
+	if _, err := NewProvider(ProviderConfig{Type: "mock"}); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+}
+
+func TestIntegration_MockProvider(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	s := integrationServer(t)
+
+	for _, tool := range []string{"sequential_thinking", "graph_of_thoughts", "reflexion", "dialectic_reason", "decompose_solve", "plan_execute"} {
+		t.Run(tool, func(t *testing.T) {
+			result := callTool(t, s, tool, map[string]interface{}{"problem": "What is the capital of France?", "provider": "mock"})
+			if answer, _ := result["final_answer"].(string); !strings.Contains(answer, "mock answer") {
+				t.Errorf("Expected the built-in mock answer, got %v", result["final_answer"])
+			}
+			if result["provider"] != "mock" {
+				t.Errorf("Expected provider mock, got %v", result["provider"])
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ============ Model Attribution ============
+//
+// Per-phase models, attempt rotations and fallback chains mean one run can
+// use several provider/models. Every answered LLM call is recorded against
+// the run (LLMCallUsage.ModelUsage) and against any scope opened with
+// withModelCalls, so strategies can label the nodes, claims and attempts a
+// call produced, and give them a provenance: the provider/model asked for,
+// the ones that answered and how many answers came from a fallback.
+
+// Provenance records which provider/models produced a node, claim or attempt
+type Provenance struct {
+	Requested  string         `json:"requested,omitempty"` // "provider/model" the strategy asked, e.g. a per-phase model
+	AnsweredBy map[string]int `json:"answered_by"`         // Calls answered by each "provider/model"
+	Fallbacks  int            `json:"fallbacks,omitempty"` // Calls answered by a fallback provider after the first in the chain failed
+}
+
+// modelCalls counts the calls answered by each "provider/model"
+type modelCalls struct {
+	mu        sync.Mutex
+	counts    map[string]int
+	fallbacks int
+	parent    *modelCalls // Enclosing scope, which also sees the calls
+}
+
+type modelCallsKey struct{}
+
+// withModelCalls opens a scope that records the calls made with the returned
+// context, including those inside nested scopes
+func withModelCalls(ctx context.Context) (context.Context, *modelCalls) {
+	parent, _ := ctx.Value(modelCallsKey{}).(*modelCalls)
+	calls := &modelCalls{parent: parent}
+	return context.WithValue(ctx, modelCallsKey{}, calls), calls
+}
+
+// noteModelCall records that provider/model answered a call made with ctx
+func noteModelCall(ctx context.Context, provider, model string) {
+	key := provider
+	if model != "" {
+		key += "/" + model
+	}
+	if run := queueRunFromContext(ctx); run != nil {
+		run.models.add(key)
+	}
+	for calls, _ := ctx.Value(modelCallsKey{}).(*modelCalls); calls != nil; calls = calls.parent {
+		calls.add(key)
+	}
+}
+
+// noteFallbackAnswer records that a fallback provider answered a call made
+// with ctx in place of the first provider of its chain
+func noteFallbackAnswer(ctx context.Context) {
+	for calls, _ := ctx.Value(modelCallsKey{}).(*modelCalls); calls != nil; calls = calls.parent {
+		calls.mu.Lock()
+		calls.fallbacks++
+		calls.mu.Unlock()
+	}
+}
+
+func (m *modelCalls) add(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counts == nil {
+		m.counts = make(map[string]int)
+	}
+	m.counts[key]++
+}
+
+// usage returns a copy of the counts, or nil when no call was recorded
+func (m *modelCalls) usage() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.counts) == 0 {
+		return nil
+	}
+	usage := make(map[string]int, len(m.counts))
+	for key, n := range m.counts {
+		usage[key] = n
+	}
+	return usage
+}
+
+// label names the provider/models that answered, sorted and comma-separated
+func (m *modelCalls) label() string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.counts))
+	for key := range m.counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// provenance returns the scope's calls as the provenance of what they
+// produced, asked of requested, or nil when no call was answered
+func (m *modelCalls) provenance(requested string) *Provenance {
+	if m == nil {
+		return nil
+	}
+	answeredBy := m.usage()
+	if answeredBy == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &Provenance{Requested: requested, AnsweredBy: answeredBy, Fallbacks: m.fallbacks}
+}
+
+// requestedModel names the "provider/model" a call to p with model asks
+// for, p's default model when model is empty. A fallback chain is asked
+// for its first provider.
+func requestedModel(p Provider, model string) string {
+	if p == nil {
+		return ""
+	}
+	if chain := fallbackChain(p); chain != nil && len(chain.providers) > 0 {
+		p = chain.providers[0]
+	}
+	if model == "" {
+		model = defaultModel(p)
+	}
+	if model == "" {
+		return p.Name()
+	}
+	return p.Name() + "/" + model
+}
+
+// modelUsage returns the calls each provider/model answered in the current run
+func modelUsage(ctx context.Context) map[string]int {
+	run := queueRunFromContext(ctx)
+	if run == nil {
+		return nil
+	}
+	return run.models.usage()
+}
+
+// defaultModel returns the model p uses when a call does not name one
+func defaultModel(p Provider) string {
+	if m, ok := unwrapProvider(p).(interface{ Model() string }); ok {
+		return m.Model()
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// failAfterProvider answers like inner for its first after calls, then fails
+type failAfterProvider struct {
+	mu    sync.Mutex
+	inner Provider
+	after int
+	calls int
+}
+
+func (p *failAfterProvider) Name() string { return "fail-after" }
+
+func (p *failAfterProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	p.mu.Lock()
+	p.calls++
+	failed := p.calls > p.after
+	p.mu.Unlock()
+	if failed {
+		return "", errors.New("API error (status 503): unavailable")
+	}
+	return p.inner.Chat(ctx, messages, opts)
+}
+
+func TestDialectic_KeepsRoundsWhenProviderFails(t *testing.T) {
+	config := DefaultDialecticConfig()
+	config.MaxRounds = 3
+	config.EarlyStop = false
+	inner := &countProvider{response: `{"content": "The answer is 391", "score": 0.5}`}
+
+	// The first round takes six calls and the second round's verifications
+	// are cached, so its antithesis is the eighth call and fails
+	provider := &failAfterProvider{inner: inner, after: 7}
+	result, err := NewDialecticalReasoner(provider, config).Reason(context.Background(), "What is 17 * 23?")
+	if err != nil {
+		t.Fatalf("Expected the first round to be kept, got %v", err)
+	}
+	if result.Success || result.StoppedReason != StopProvider || result.Error == "" || len(result.Steps) != 2 {
+		t.Fatalf("Expected a failed result with two rounds, got %+v", result)
+	}
+	if result.Steps[0].Error != "" || result.Steps[1].Error == "" || result.Steps[1].Thesis.Content == "" {
+		t.Errorf("Expected only the second round to carry the error, got %+v", result.Steps)
+	}
+	if result.FinalAnswer != result.Steps[0].Synthesis.Content || len(result.ConfidenceTrajectory) != 1 {
+		t.Errorf("Expected the answer and confidence of the completed round, got %q and %+v", result.FinalAnswer, result.ConfidenceTrajectory)
+	}
+
+	// Nothing to keep when the first thesis fails
+	provider = &failAfterProvider{inner: inner}
+	if _, err := NewDialecticalReasoner(provider, config).Reason(context.Background(), "What is 17 * 23?"); err == nil {
+		t.Error("Expected an error when no round started")
+	}
+}
+
+func TestGoT_KeepsGraphWhenProviderFails(t *testing.T) {
+	inner := &countProvider{response: `["Consider the first case", "Consider the second case"]`}
+	provider := &failAfterProvider{inner: inner, after: 6}
+	result, err := NewGraphOfThoughts(provider, DefaultGoTConfig()).Solve(context.Background(), "Explore the cases")
+	if err != nil {
+		t.Fatalf("Expected a partial result, got %v", err)
+	}
+	if last := result.Decisions[len(result.Decisions)-1]; last.Type != "stop" || last.Reason != GoTReasonProviderError {
+		t.Errorf("Expected the run to stop on the failing provider, got %+v", last)
+	}
+	if result.Success || result.Error == "" || result.TotalNodes < 2 || len(result.BestPath) == 0 || result.FinalAnswer == "" {
+		t.Errorf("Expected a failed result with the graph so far, got %+v", result)
+	}
+	for _, d := range result.Decisions {
+		if d.Reason == GoTReasonExpansionFail && d.Error == "" {
+			t.Errorf("Expected failed expansions to record their error, got %+v", d)
+		}
+	}
+
+	if _, err := NewGraphOfThoughts(&failAfterProvider{inner: inner}, DefaultGoTConfig()).Solve(context.Background(), "Explore the cases"); err == nil {
+		t.Error("Expected an error when the root could not be expanded")
+	}
+}
@@ -0,0 +1,435 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// maxPipelineStages bounds a pipeline spec so one call cannot chain an
+// unbounded number of full reasoning runs
+const maxPipelineStages = 8
+
+// PipelineForEachSubproblems runs a stage once per sub-problem of the
+// preceding decompose_solve stage
+const PipelineForEachSubproblems = "subproblems"
+
+// pipelineStrategies lists the tools a pipeline stage can run
+var pipelineStrategies = []string{
+	"sequential_thinking",
+	"graph_of_thoughts",
+	"reflexion",
+	"dialectic_reason",
+	"decompose_solve",
+	"plan_execute",
+}
+
+// PipelineSpec declares a chain of reasoning strategies, e.g.
+//
+//	{"stages": [
+//	  {"strategy": "decompose_solve"},
+//	  {"strategy": "graph_of_thoughts", "for_each": "subproblems", "params": {"max_nodes": 15}},
+//	  {"strategy": "dialectic_reason", "provider": "anthropic", "instruction": "Verify the synthesis"}
+//	]}
+type PipelineSpec struct {
+	Stages []PipelineStage `json:"stages"`
+}
+
+// PipelineStage is one step of a pipeline. Params take the same names as the
+// strategy's own tool parameters.
+type PipelineStage struct {
+	Name        string                 `json:"name,omitempty"`
+	Strategy    string                 `json:"strategy"`
+	Provider    string                 `json:"provider,omitempty"` // Overrides the pipeline's provider for this stage
+	Model       string                 `json:"model,omitempty"`    // Overrides the pipeline's model for this stage
+	ForEach     string                 `json:"for_each,omitempty"` // "subproblems": run once per sub-problem of the previous stage
+	Instruction string                 `json:"instruction,omitempty"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+}
+
+// PipelineStageResult records one stage, or one sub-problem of a for_each stage
+type PipelineStageResult struct {
+	Name     string                `json:"name"`
+	Strategy string                `json:"strategy"`
+	Provider string                `json:"provider,omitempty"`
+	Input    string                `json:"input"`
+	Answer   string                `json:"answer"`
+	Success  bool                  `json:"success"`
+	Error    string                `json:"error,omitempty"`
+	Result   interface{}           `json:"result,omitempty"` // The strategy's full result
+	Items    []PipelineStageResult `json:"items,omitempty"`  // Per sub-problem runs of a for_each stage
+}
+
+// PipelineResult represents the complete result of a reasoning pipeline
+type PipelineResult struct {
+	Problem     string                `json:"problem"`
+	Stages      []PipelineStageResult `json:"stages"`
+	FinalAnswer string                `json:"final_answer"`
+	Success     bool                  `json:"success"`
+	LLMCallUsage
+}
+
+// parsePipelineSpec reads and validates a pipeline spec. A bare array of
+// stages is accepted as shorthand for {"stages": [...]}.
+func parsePipelineSpec(raw string) (PipelineSpec, error) {
+	var spec PipelineSpec
+	raw = strings.TrimSpace(raw)
+	var err error
+	if strings.HasPrefix(raw, "[") {
+		err = json.Unmarshal([]byte(raw), &spec.Stages)
+	} else {
+		err = json.Unmarshal([]byte(raw), &spec)
+	}
+	if err != nil {
+		return spec, fmt.Errorf("invalid pipeline spec: %v", err)
+	}
+	if len(spec.Stages) == 0 {
+		return spec, fmt.Errorf("pipeline spec has no stages")
+	}
+	if len(spec.Stages) > maxPipelineStages {
+		return spec, fmt.Errorf("pipeline spec has %d stages, maximum is %d", len(spec.Stages), maxPipelineStages)
+	}
+
+	for i := range spec.Stages {
+		stage := &spec.Stages[i]
+		stage.Strategy = strings.ToLower(strings.TrimSpace(stage.Strategy))
+		if !isPipelineStrategy(stage.Strategy) {
+			return spec, fmt.Errorf("stage %d: unknown strategy %q (valid: %s)", i+1, stage.Strategy, strings.Join(pipelineStrategies, ", "))
+		}
+		if _, err := parseArgs(stage.Params, strategyArgSpecs[stage.Strategy]); err != nil {
+			return spec, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		if err := checkArgLimits(stage.Params); err != nil {
+			return spec, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		if stage.Name == "" {
+			stage.Name = fmt.Sprintf("%d_%s", i+1, stage.Strategy)
+		}
+		switch stage.ForEach {
+		case "":
+		case PipelineForEachSubproblems:
+			if i == 0 || spec.Stages[i-1].Strategy != "decompose_solve" || spec.Stages[i-1].ForEach != "" {
+				return spec, fmt.Errorf("stage %d: for_each %q must follow a decompose_solve stage", i+1, stage.ForEach)
+			}
+		default:
+			return spec, fmt.Errorf("stage %d: unknown for_each %q (valid: %s)", i+1, stage.ForEach, PipelineForEachSubproblems)
+		}
+	}
+	return spec, nil
+}
+
+func isPipelineStrategy(name string) bool {
+	for _, s := range pipelineStrategies {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReasoningPipeline runs the stages of a spec in order, feeding each stage's
+// answer into the next
+type ReasoningPipeline struct {
+	ReasonerBase
+
+	spec       PipelineSpec
+	providers  []Provider // One per stage, already resolved with any overrides
+	evaluators []Provider // Optional critic per stage; nil entries let the generator judge
+	llmCalls   *LLMCallCounter
+}
+
+// pipelineReasoner is the callback surface shared by every strategy
+type pipelineReasoner interface {
+	SetProgressCallback(cb func(ProgressUpdate))
+	SetTokenCallback(cb func(token string))
+	SetEnableStreaming(enable bool)
+}
+
+// NewReasoningPipeline creates a pipeline. providers[i] runs spec.Stages[i];
+// llmCalls is the shared cap the providers were wrapped with (nil for none).
+func NewReasoningPipeline(spec PipelineSpec, providers []Provider, llmCalls *LLMCallCounter) *ReasoningPipeline {
+	return &ReasoningPipeline{spec: spec, providers: providers, llmCalls: llmCalls}
+}
+
+// SetEvaluators sets a critic provider per stage for evaluation calls
+func (p *ReasoningPipeline) SetEvaluators(evaluators []Provider) {
+	p.evaluators = evaluators
+}
+
+// evaluator returns stage i's critic, or nil
+func (p *ReasoningPipeline) evaluator(i int) Provider {
+	if i < len(p.evaluators) {
+		return p.evaluators[i]
+	}
+	return nil
+}
+
+// Run executes the pipeline. A failing stage, or the LLM call budget running
+// out, stops the pipeline and returns the stages completed so far.
+func (p *ReasoningPipeline) Run(ctx context.Context, problem string) (*PipelineResult, error) {
+	if len(p.providers) != len(p.spec.Stages) {
+		return nil, fmt.Errorf("pipeline has %d stages but %d providers", len(p.spec.Stages), len(p.providers))
+	}
+	result := &PipelineResult{Problem: problem, Stages: []PipelineStageResult{}}
+
+	var prev *PipelineStageResult
+	var prevDecomposition *DecomposeResult
+	for i, stage := range p.spec.Stages {
+		provider := p.providers[i]
+		p.emitProgress(ProgressUpdate{
+			Type:    EventTypeProgress,
+			NodeID:  stage.Name,
+			Message: fmt.Sprintf("Stage %d/%d: %s", i+1, len(p.spec.Stages), stage.Strategy),
+			Depth:   i + 1,
+		})
+
+		var sr PipelineStageResult
+		var err error
+		if stage.ForEach == PipelineForEachSubproblems {
+			sr, err = p.runForEach(ctx, stage, provider, p.evaluator(i), problem, prevDecomposition)
+		} else {
+			sr = PipelineStageResult{
+				Name:     stage.Name,
+				Strategy: stage.Strategy,
+				Provider: provider.Name(),
+				Input:    pipelineStageInput(problem, prev, stage.Instruction),
+			}
+			sr.Answer, sr.Result, err = p.runStrategy(ctx, stage, provider, p.evaluator(i), sr.Input)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			sr.Error = err.Error()
+		}
+		sr.Success = sr.Error == "" && sr.Answer != "" && allItemsSucceeded(sr.Items)
+		result.Stages = append(result.Stages, sr)
+
+		if err != nil || sr.Answer == "" || p.llmCalls.Exhausted() {
+			// Later stages build on this one, so there is nothing useful to run
+			return result, nil
+		}
+
+		p.emitProgress(ProgressUpdate{
+			Type:    EventTypeThought,
+			NodeID:  stage.Name,
+			Thought: utils.TruncateStr(sr.Answer, 100),
+			Depth:   i + 1,
+		})
+		prev = &result.Stages[len(result.Stages)-1]
+		prevDecomposition, _ = sr.Result.(*DecomposeResult)
+	}
+
+	result.FinalAnswer = prev.Answer
+	result.Success = true
+	for _, sr := range result.Stages {
+		result.Success = result.Success && sr.Success
+	}
+	p.emitProgress(ProgressUpdate{
+		Type:        EventTypeSolution,
+		FinalAnswer: result.FinalAnswer,
+		IsSolution:  result.Success,
+	})
+	return result, nil
+}
+
+// runForEach solves every sub-problem of the previous decomposition with the
+// stage's strategy, feeding earlier answers forward, then composes the answers
+func (p *ReasoningPipeline) runForEach(ctx context.Context, stage PipelineStage, provider, evaluator Provider, problem string, decomposition *DecomposeResult) (PipelineStageResult, error) {
+	sr := PipelineStageResult{
+		Name:     stage.Name,
+		Strategy: stage.Strategy,
+		Provider: provider.Name(),
+		Items:    []PipelineStageResult{},
+	}
+	if decomposition == nil || len(decomposition.Subproblems) == 0 {
+		return sr, fmt.Errorf("no sub-problems from the previous stage")
+	}
+
+	var solved []Subproblem
+	for _, sub := range decomposition.Subproblems {
+		item := PipelineStageResult{
+			Name:     fmt.Sprintf("%s/s%d", stage.Name, sub.Number),
+			Strategy: stage.Strategy,
+			Provider: provider.Name(),
+			Input:    pipelineSubproblemInput(problem, solved, sub, stage.Instruction),
+		}
+		answer, res, err := p.runStrategy(ctx, stage, provider, evaluator, item.Input)
+		item.Answer, item.Result = answer, res
+		if err != nil {
+			item.Error = err.Error()
+		}
+		item.Success = item.Error == "" && item.Answer != ""
+		sr.Items = append(sr.Items, item)
+
+		sub.Answer, sub.Reasoning, sub.Error = answer, "", item.Error
+		solved = append(solved, sub)
+		if err != nil && (ctx.Err() != nil || errors.Is(err, ErrLLMCallBudgetExhausted)) {
+			return sr, err
+		}
+	}
+
+	composer := NewDecomposeSolver(provider, DefaultDecomposeConfig())
+	p.attachCallbacks(composer)
+	answer, err := composer.compose(ctx, problem, solved)
+	sr.Answer = answer
+	sr.Result = &DecomposeResult{
+		Problem:          problem,
+		Subproblems:      solved,
+		FinalAnswer:      answer,
+		TotalSubproblems: len(solved),
+		Decomposed:       len(solved) > 1,
+		Success:          answer != "",
+		Provider:         provider.Name(),
+	}
+	return sr, err
+}
+
+// runStrategy runs one strategy on input and returns its final answer and
+// full result. Params use the strategy's tool parameter names; evaluator, when
+// set, judges for the strategies that evaluate their own output.
+func (p *ReasoningPipeline) runStrategy(ctx context.Context, stage PipelineStage, provider, evaluator Provider, input string) (string, interface{}, error) {
+	params := stage.Params
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	switch stage.Strategy {
+	case "sequential_thinking":
+		maxThoughts, err := maxThoughtsFromArgs(params)
+		if err != nil {
+			return "", nil, err
+		}
+		client := NewSequentialClient(provider)
+		p.attachCallbacks(client)
+		res, err := client.Think(ctx, input, maxThoughts)
+		if err != nil {
+			return "", nil, err
+		}
+		return res.FinalAnswer, res, nil
+	case "graph_of_thoughts":
+		config, err := gotConfigFromArgs(params)
+		if err != nil {
+			return "", nil, err
+		}
+		config.Evaluator = evaluator
+		memory, err := sharedMemoryConfigFromArgs(params)
+		if err != nil {
+			return "", nil, err
+		}
+		config.Memory = memory
+		got := NewGraphOfThoughts(provider, config)
+		p.attachCallbacks(got)
+		res, err := got.Solve(ctx, input)
+		if err != nil {
+			return "", nil, err
+		}
+		return res.FinalAnswer, res, nil
+	case "reflexion":
+		config, err := reflexionConfigFromArgs(params, p.llmCalls)
+		if err != nil {
+			return "", nil, err
+		}
+		config.Evaluator = evaluator
+		reflexion := NewReflexion(provider, config)
+		p.attachCallbacks(reflexion)
+		res, err := reflexion.Reason(ctx, input)
+		if err != nil {
+			return "", nil, err
+		}
+		return res.FinalAnswer, res, nil
+	case "dialectic_reason":
+		config, err := dialecticConfigFromArgs(params)
+		if err != nil {
+			return "", nil, err
+		}
+		config.Evaluator = evaluator
+		memory, err := sharedMemoryConfigFromArgs(params)
+		if err != nil {
+			return "", nil, err
+		}
+		config.Memory = memory
+		dialectic := NewDialecticalReasoner(provider, config)
+		p.attachCallbacks(dialectic)
+		res, err := dialectic.Reason(ctx, input)
+		if err != nil {
+			return "", nil, err
+		}
+		return res.FinalAnswer, res, nil
+	case "decompose_solve":
+		config, err := decomposeConfigFromArgs(params)
+		if err != nil {
+			return "", nil, err
+		}
+		solver := NewDecomposeSolver(provider, config)
+		p.attachCallbacks(solver)
+		res, err := solver.Solve(ctx, input)
+		if err != nil {
+			return "", nil, err
+		}
+		return res.FinalAnswer, res, nil
+	case "plan_execute":
+		config, err := planExecuteConfigFromArgs(params)
+		if err != nil {
+			return "", nil, err
+		}
+		agent := NewPlanExecutor(provider, config)
+		p.attachCallbacks(agent)
+		res, err := agent.Run(ctx, input)
+		if err != nil {
+			return "", nil, err
+		}
+		return res.FinalAnswer, res, nil
+	}
+	return "", nil, fmt.Errorf("unknown strategy %q", stage.Strategy)
+}
+
+// attachCallbacks forwards a stage's progress and tokens to the pipeline's
+func (p *ReasoningPipeline) attachCallbacks(r pipelineReasoner) {
+	r.SetProgressCallback(p.emitProgress)
+	r.SetTokenCallback(p.emitToken)
+	r.SetEnableStreaming(p.enableStreams)
+}
+
+// pipelineStageInput builds a stage's problem from the original problem, the
+// previous stage's answer and the stage instruction
+func pipelineStageInput(problem string, prev *PipelineStageResult, instruction string) string {
+	if prev == nil && instruction == "" {
+		return problem
+	}
+	input := fmt.Sprintf("Problem:\n%s\n", problem)
+	if prev != nil {
+		input += fmt.Sprintf("\nResult of the previous stage (%s):\n%s\n", prev.Strategy, prev.Answer)
+	}
+	if instruction != "" {
+		input += fmt.Sprintf("\nTask for this stage: %s\n", instruction)
+	}
+	return input
+}
+
+// pipelineSubproblemInput builds the problem for one sub-problem of a
+// for_each stage
+func pipelineSubproblemInput(problem string, solved []Subproblem, sub Subproblem, instruction string) string {
+	input := fmt.Sprintf("Original problem:\n%s\n\n", problem)
+	if len(solved) > 0 {
+		input += "Sub-problems solved so far:\n" + formatSolvedSubproblems(solved) + "\n"
+	}
+	input += fmt.Sprintf("Solve this sub-problem: %s\n", sub.Question)
+	if instruction != "" {
+		input += fmt.Sprintf("\nTask for this stage: %s\n", instruction)
+	}
+	return input
+}
+
+func allItemsSucceeded(items []PipelineStageResult) bool {
+	for _, item := range items {
+		if !item.Success {
+			return false
+		}
+	}
+	return true
+}
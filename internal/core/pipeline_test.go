@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// pipelineProvider decomposes into two sub-problems, answers sub-problems and
+// sequential thinking in one step, and records the sequential problems it was given
+type pipelineProvider struct {
+	name     string
+	mu       sync.Mutex
+	problems []string
+}
+
+func (p *pipelineProvider) Name() string { return p.name }
+
+func (p *pipelineProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	system, user := messages[0].Content, messages[len(messages)-1].Content
+	switch {
+	case strings.Contains(system, "Least-to-Most"):
+		return `{"subproblems": [{"question": "How many apples are in a crate?"}, {"question": "How many apples are in 3 crates?", "depends_on": [1]}]}`, nil
+	case strings.Contains(system, "one sub-problem at a time"):
+		return `{"reasoning": "a crate holds 12", "answer": "12"}`, nil
+	case strings.Contains(system, "compose a final answer"):
+		return "There are 36 apples.", nil
+	case strings.Contains(system, "sequential thinking"):
+		p.mu.Lock()
+		p.problems = append(p.problems, user)
+		n := len(p.problems)
+		p.mu.Unlock()
+		return fmt.Sprintf(`{"thought_number": 1, "total_thoughts": 1, "thought": "done", "next_thought_needed": false, "final_answer": "answer %d"}`, n), nil
+	}
+	return "unexpected prompt", nil
+}
+
+func TestParsePipelineSpec(t *testing.T) {
+	spec, err := parsePipelineSpec(`{"stages": [
+		{"strategy": "decompose_solve"},
+		{"strategy": "Graph_Of_Thoughts", "for_each": "subproblems", "params": {"max_nodes": 15}},
+		{"name": "verify", "strategy": "dialectic_reason", "provider": "anthropic"}
+	]}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(spec.Stages) != 3 || spec.Stages[1].Strategy != "graph_of_thoughts" || spec.Stages[1].Name != "2_graph_of_thoughts" || spec.Stages[2].Name != "verify" {
+		t.Errorf("Unexpected normalization: %+v", spec.Stages)
+	}
+	if cfg, _ := gotConfigFromArgs(spec.Stages[1].Params); cfg.MaxNodes != 15 {
+		t.Errorf("Expected stage params to configure the strategy, got max_nodes %d", cfg.MaxNodes)
+	}
+	if spec, err := parsePipelineSpec(`[{"strategy": "reflexion"}]`); err != nil || len(spec.Stages) != 1 {
+		t.Errorf("Expected a bare stage array to parse, got %+v, %v", spec, err)
+	}
+
+	invalid := map[string]string{
+		"empty":             `{"stages": []}`,
+		"unknown strategy":  `[{"strategy": "tree_of_thoughts"}]`,
+		"for_each first":    `[{"strategy": "reflexion", "for_each": "subproblems"}]`,
+		"for_each misplace": `[{"strategy": "reflexion"}, {"strategy": "reflexion", "for_each": "subproblems"}]`,
+		"unknown for_each":  `[{"strategy": "decompose_solve"}, {"strategy": "reflexion", "for_each": "steps"}]`,
+		"too many stages":   "[" + strings.Repeat(`{"strategy": "reflexion"},`, maxPipelineStages) + `{"strategy": "reflexion"}]`,
+		"not json":          "decompose then verify",
+	}
+	for name, raw := range invalid {
+		if _, err := parsePipelineSpec(raw); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestReasoningPipeline_ForEachSubproblems(t *testing.T) {
+	spec, err := parsePipelineSpec(`[
+		{"strategy": "decompose_solve"},
+		{"strategy": "sequential_thinking", "for_each": "subproblems"},
+		{"strategy": "sequential_thinking", "instruction": "Check the arithmetic"}
+	]`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	base, verifier := &pipelineProvider{name: "base"}, &pipelineProvider{name: "verifier"}
+
+	result, err := NewReasoningPipeline(spec, []Provider{base, base, verifier}, nil).Run(context.Background(), "Apples are packed 12 to a crate. How many are in 3 crates?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Stages) != 3 || !result.Success {
+		t.Fatalf("Expected 3 successful stages, got %+v", result.Stages)
+	}
+
+	forEach := result.Stages[1]
+	if len(forEach.Items) != 2 || forEach.Answer != "There are 36 apples." {
+		t.Fatalf("Expected 2 sub-problem runs composed into one answer, got %+v", forEach)
+	}
+	if !strings.Contains(forEach.Items[1].Input, "How many apples are in 3 crates?") || !strings.Contains(forEach.Items[1].Input, "Answer: answer 1") {
+		t.Errorf("Expected earlier sub-problem answers fed forward, got %q", forEach.Items[1].Input)
+	}
+
+	verify := result.Stages[2]
+	if verify.Provider != "verifier" || len(verifier.problems) != 1 {
+		t.Errorf("Expected the stage provider override to run the last stage, got %q", verify.Provider)
+	}
+	if !strings.Contains(verifier.problems[0], "There are 36 apples.") || !strings.Contains(verifier.problems[0], "Check the arithmetic") {
+		t.Errorf("Expected the previous answer and instruction in the stage input, got %q", verifier.problems[0])
+	}
+	if result.FinalAnswer != verify.Answer {
+		t.Errorf("Expected the last stage's answer as final answer, got %q", result.FinalAnswer)
+	}
+}
+
+func TestReasoningPipeline_StopsAtLLMCallBudget(t *testing.T) {
+	spec, err := parsePipelineSpec(`[{"strategy": "decompose_solve"}, {"strategy": "sequential_thinking"}]`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Decomposing, two sub-problems and composing need four calls
+	counter := NewLLMCallCounter(3)
+	provider := counter.Wrap(&pipelineProvider{name: "base"})
+
+	result, err := NewReasoningPipeline(spec, []Provider{provider, provider}, counter).Run(context.Background(), "How many apples?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Stages) != 1 || result.Success || result.FinalAnswer != "" {
+		t.Errorf("Expected the pipeline to stop after the first stage, got %+v", result)
+	}
+	if !counter.Exhausted() {
+		t.Error("Expected the budget to be exhausted")
+	}
+}
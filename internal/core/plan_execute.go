@@ -0,0 +1,469 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// Plan step types
+const (
+	PlanStepReason = "reason"
+	PlanStepTool   = "tool"
+)
+
+// planToolPhase is the tool budget phase for plan step execution
+const planToolPhase = "execution"
+
+// PlanExecutor implements a plan-and-execute agent loop: the LLM writes an
+// explicit plan, each step runs as a reasoning step or a tool call, and the
+// rest of the plan is rewritten when a step fails
+type PlanExecutor struct {
+	ReasonerBase
+
+	provider   Provider
+	config     PlanExecuteConfig
+	tools      *ToolRegistry
+	toolBudget *ToolBudget
+	toolsUsed  map[string]int
+}
+
+// PlanExecuteConfig configures the agent loop
+type PlanExecuteConfig struct {
+	MaxSteps     int      // Maximum steps executed across all plans (default: 12)
+	MaxReplans   int      // Re-plans allowed after failed steps (default: 2)
+	EnableTools  bool     // Allow tool steps (default: true)
+	MaxToolCalls int      // Maximum tool calls (default: 10)
+	EnabledTools []string // Which tools to enable (empty = all)
+	Temperature  float64  // LLM temperature (default: 0.3)
+}
+
+// DefaultPlanExecuteConfig returns sensible defaults
+func DefaultPlanExecuteConfig() PlanExecuteConfig {
+	return PlanExecuteConfig{
+		MaxSteps:     12,
+		MaxReplans:   2,
+		EnableTools:  true,
+		MaxToolCalls: 10,
+		Temperature:  0.3,
+	}
+}
+
+// PlanStep is one step of a plan
+type PlanStep struct {
+	Number      int    `json:"number"`
+	Description string `json:"description"`
+	Type        string `json:"type"` // "reason" or "tool"
+	Tool        string `json:"tool,omitempty"`
+	Input       string `json:"input,omitempty"`
+}
+
+// StepLog records the execution of a plan step
+type StepLog struct {
+	Step        int    `json:"step"`         // Execution order across all plans
+	PlanVersion int    `json:"plan_version"` // 1 for the initial plan, +1 per re-plan
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Tool        string `json:"tool,omitempty"`
+	Input       string `json:"input,omitempty"`
+	Output      string `json:"output,omitempty"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Replan records a rewrite of the remaining plan after a failed step
+type Replan struct {
+	AfterStep int        `json:"after_step"`
+	Reason    string     `json:"reason"`
+	Plan      []PlanStep `json:"plan"`
+}
+
+// PlanExecuteResult represents the complete result of plan-and-execute
+type PlanExecuteResult struct {
+	Problem        string         `json:"problem"`
+	Plan           []PlanStep     `json:"plan"`
+	Replans        []Replan       `json:"replans,omitempty"`
+	Steps          []StepLog      `json:"steps"`
+	FinalAnswer    string         `json:"final_answer"`
+	TotalSteps     int            `json:"total_steps"`
+	TotalToolCalls int            `json:"total_tool_calls,omitempty"`
+	ToolsUsed      map[string]int `json:"tools_used,omitempty"`
+	Success        bool           `json:"success"`
+	Provider       string         `json:"provider"`
+	LLMCallUsage
+}
+
+// NewPlanExecutor creates a new plan-and-execute agent
+func NewPlanExecutor(provider Provider, config PlanExecuteConfig) *PlanExecutor {
+	p := &PlanExecutor{
+		provider:   provider,
+		config:     config,
+		toolBudget: NewToolBudget(config.MaxToolCalls),
+	}
+	if config.EnableTools {
+		p.tools = NewToolRegistry()
+		if len(config.EnabledTools) > 0 {
+			p.tools.SetEnabled(config.EnabledTools)
+		}
+	}
+	return p
+}
+
+func (p *PlanExecutor) chat(ctx context.Context, messages []ChatMessage, maxTokens int) (string, error) {
+	opts := ChatOptions{Temperature: p.config.Temperature, MaxTokens: maxTokens}
+	return p.callProvider(ctx, p.provider, messages, opts, p.enableStreams)
+}
+
+// Run plans, executes and composes the final answer
+func (p *PlanExecutor) Run(ctx context.Context, problem string) (*PlanExecuteResult, error) {
+	// A compressed problem brings problem_lookup for reading the original
+	if src := problemSourceFromContext(ctx); src != nil {
+		p.tools = src.attach(p.tools)
+		p.config.EnableTools = true
+	}
+	if pad := scratchpadFromContext(ctx); pad != nil {
+		p.tools = pad.attach(p.tools)
+	}
+	result := &PlanExecuteResult{
+		Problem:   problem,
+		Steps:     []StepLog{},
+		Provider:  p.provider.Name(),
+		ToolsUsed: make(map[string]int),
+	}
+	p.toolBudget = NewToolBudget(p.config.MaxToolCalls)
+	p.toolsUsed = result.ToolsUsed
+
+	p.emitProgress(ProgressUpdate{Type: EventTypeProgress, Message: "Planning..."})
+	plan, err := p.makePlan(ctx, problem)
+	if err != nil {
+		return nil, fmt.Errorf("planning failed: %w", err)
+	}
+	result.Plan = plan
+	p.emitProgress(ProgressUpdate{
+		Type:       EventTypeProgress,
+		Message:    fmt.Sprintf("Plan has %d steps", len(plan)),
+		TotalNodes: len(plan),
+	})
+
+	queue := plan
+	planVersion := 1
+	for len(queue) > 0 && len(result.Steps) < p.config.MaxSteps {
+		step := queue[0]
+		queue = queue[1:]
+
+		log, err := p.executeStep(ctx, problem, step, result.Steps)
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Step = len(result.Steps) + 1
+		log.PlanVersion = planVersion
+		result.Steps = append(result.Steps, log)
+
+		if log.Success || len(result.Replans) >= p.config.MaxReplans {
+			continue
+		}
+
+		// Rewrite the rest of the plan around the failure
+		p.emitProgress(ProgressUpdate{
+			Type:    EventTypeEvaluation,
+			Message: fmt.Sprintf("Step %d failed (%s), re-planning", log.Step, utils.TruncateStr(log.Error, 80)),
+		})
+		newPlan, err := p.replan(ctx, problem, result.Steps, queue)
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARNING] plan_execute: re-planning failed, continuing with the current plan: %v\n", err)
+			continue
+		}
+		planVersion++
+		result.Replans = append(result.Replans, Replan{AfterStep: log.Step, Reason: log.Error, Plan: newPlan})
+		queue = newPlan
+	}
+	result.TotalSteps = len(result.Steps)
+	result.TotalToolCalls = p.toolBudget.Used()
+
+	finalAnswer, err := p.compose(ctx, problem, result.Steps)
+	if err != nil && !errors.Is(err, ErrLLMCallBudgetExhausted) {
+		return nil, fmt.Errorf("composing final answer failed: %w", err)
+	}
+	result.FinalAnswer = finalAnswer
+	result.Success = finalAnswer != ""
+
+	p.emitProgress(ProgressUpdate{
+		Type:        EventTypeSolution,
+		FinalAnswer: finalAnswer,
+		IsSolution:  result.Success,
+	})
+	return result, nil
+}
+
+// toolList describes the enabled tools for the planner, sorted by name
+func (p *PlanExecutor) toolList() string {
+	if p.tools == nil {
+		return ""
+	}
+	tools := p.tools.GetAvailableTools()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	var b strings.Builder
+	for _, t := range tools {
+		b.WriteString(fmt.Sprintf("- %s: %s\n", t.Name, t.Description))
+	}
+	return b.String()
+}
+
+func (p *PlanExecutor) planSystemPrompt() string {
+	prompt := `You are a planning agent. Write an explicit, ordered plan of concrete steps that solves the problem.
+Each step is either a "reason" step (think, derive or decide something) or a "tool" step (call one tool with an exact input).`
+	if tools := p.toolList(); tools != "" {
+		prompt += "\n\nAvailable tools:\n" + tools +
+			"Only use a tool step when the tool gives a more reliable answer than reasoning. Leave input empty if it depends on earlier results; it will be filled in when the step runs."
+	} else {
+		prompt += "\n\nNo tools are available, so every step must be a reason step."
+	}
+	prompt += `
+
+Respond with ONLY a JSON object:
+{
+  "steps": [
+    {"description": "<what this step does>", "type": "reason" or "tool", "tool": "<tool name, tool steps only>", "input": "<tool input, tool steps only>"}
+  ]
+}`
+	return prompt
+}
+
+// makePlan asks for the initial plan. A response that cannot be parsed falls
+// back to a single reasoning step.
+func (p *PlanExecutor) makePlan(ctx context.Context, problem string) ([]PlanStep, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: p.planSystemPrompt()},
+		{Role: "user", Content: fmt.Sprintf("Problem:\n\n%s\n\nWrite a plan of at most %d steps.", problem, p.config.MaxSteps)},
+	}
+	response, err := p.chat(ctx, messages, 1024)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := parsePlanSteps(response, p.config.MaxSteps)
+	if len(plan) == 0 {
+		fmt.Fprintf(os.Stderr, "[WARNING] plan_execute: failed to parse plan, using a single reasoning step. Response preview: %s\n",
+			utils.TruncateStr(response, 100))
+		plan = []PlanStep{{Number: 1, Description: "Solve the problem directly", Type: PlanStepReason}}
+	}
+	return plan, nil
+}
+
+// replan rewrites the remaining steps given what has run so far
+func (p *PlanExecutor) replan(ctx context.Context, problem string, logs []StepLog, remaining []PlanStep) ([]PlanStep, error) {
+	var rest strings.Builder
+	for _, s := range remaining {
+		rest.WriteString(fmt.Sprintf("- [%s] %s\n", s.Type, s.Description))
+	}
+	if rest.Len() == 0 {
+		rest.WriteString("(none)\n")
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: p.planSystemPrompt()},
+		{Role: "user", Content: fmt.Sprintf(`Problem:
+
+%s
+
+Steps executed so far:
+%s
+The last step failed. Steps that were still planned:
+%s
+Write a new plan for the REMAINING work only. Work around the failure instead of repeating it, and use at most %d steps.`,
+			problem, formatStepLogs(logs), rest.String(), max(p.config.MaxSteps-len(logs), 1))},
+	}
+	response, err := p.chat(ctx, messages, 1024)
+	if err != nil {
+		return nil, err
+	}
+	plan := parsePlanSteps(response, max(p.config.MaxSteps-len(logs), 1))
+	if len(plan) == 0 {
+		return nil, fmt.Errorf("could not parse new plan: %s", utils.TruncateStr(response, 100))
+	}
+	return plan, nil
+}
+
+// parsePlanSteps reads a plan, numbering steps from 1. Steps without a known
+// type become reasoning steps.
+func parsePlanSteps(response string, limit int) []PlanStep {
+	var parsed struct {
+		Steps []PlanStep `json:"steps"`
+	}
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr == "" || json.Unmarshal([]byte(jsonStr), &parsed) != nil {
+		return nil
+	}
+
+	var plan []PlanStep
+	for _, s := range parsed.Steps {
+		s.Description = strings.TrimSpace(s.Description)
+		if s.Description == "" {
+			continue
+		}
+		if len(plan) >= limit {
+			break
+		}
+		s.Number = len(plan) + 1
+		s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+		s.Tool = strings.TrimSpace(s.Tool)
+		if s.Type != PlanStepTool || s.Tool == "" {
+			s.Type, s.Tool, s.Input = PlanStepReason, "", ""
+		}
+		plan = append(plan, s)
+	}
+	return plan
+}
+
+// executeStep runs one step. Step failures are reported in the log; only LLM
+// errors are returned.
+func (p *PlanExecutor) executeStep(ctx context.Context, problem string, step PlanStep, logs []StepLog) (StepLog, error) {
+	log := StepLog{Description: step.Description, Type: step.Type, Tool: step.Tool, Input: step.Input}
+	nodeID := fmt.Sprintf("s%d", len(logs)+1)
+
+	p.emitProgress(ProgressUpdate{
+		Type:    EventTypeProgress,
+		NodeID:  nodeID,
+		Message: fmt.Sprintf("Step %d: %s", len(logs)+1, utils.TruncateStr(step.Description, 80)),
+		Depth:   len(logs) + 1,
+	})
+
+	if step.Type == PlanStepTool {
+		if p.tools == nil {
+			log.Error = "tools are disabled"
+			return log, nil
+		}
+		if strings.TrimSpace(log.Input) == "" {
+			input, err := p.toolInput(ctx, problem, step, logs)
+			if err != nil {
+				return log, err
+			}
+			log.Input = input
+		}
+		if !p.toolBudget.TryConsume(planToolPhase) {
+			log.Error = "tool limit reached"
+			return log, nil
+		}
+
+		toolResult := p.tools.Execute(ctx, step.Tool, log.Input)
+		p.toolsUsed[step.Tool]++
+		log.Output = toolResult.Output
+		log.Success = toolResult.Success
+		log.Error = toolResult.Error
+
+		p.emitProgress(ProgressUpdate{
+			Type:       EventTypeTool,
+			NodeID:     nodeID,
+			ToolName:   step.Tool,
+			ToolInput:  log.Input,
+			ToolOutput: utils.TruncateStr(toolResult.Output, 100),
+
+			ToolBudgetRemaining: p.toolBudget.remainingPtr(planToolPhase),
+		})
+		return log, nil
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You carry out one step of a plan, using the results of earlier steps."},
+		{Role: "user", Content: fmt.Sprintf(`Problem:
+%s
+
+Completed steps:
+%s
+Current step: %s
+
+Respond with ONLY a JSON object:
+{"result": "<the outcome of this step>", "failed": <true if the step cannot be completed>, "reason": "<why it failed, if it did>"}`,
+			problem, formatStepLogs(logs), step.Description)},
+	}
+	response, err := p.chat(ctx, messages, 1024)
+	if err != nil {
+		return log, err
+	}
+
+	var parsed struct {
+		Result string `json:"result"`
+		Failed bool   `json:"failed"`
+		Reason string `json:"reason"`
+	}
+	if jsonStr := utils.ExtractJSON(response); jsonStr != "" && json.Unmarshal([]byte(jsonStr), &parsed) == nil {
+		log.Output = strings.TrimSpace(parsed.Result)
+		log.Success = !parsed.Failed
+		if parsed.Failed {
+			log.Error = withDefault(strings.TrimSpace(parsed.Reason), "step could not be completed")
+		}
+	} else {
+		log.Output = strings.TrimSpace(response)
+		log.Success = log.Output != ""
+	}
+
+	p.emitProgress(ProgressUpdate{
+		Type:    EventTypeThought,
+		NodeID:  nodeID,
+		Thought: utils.TruncateStr(log.Output, 100),
+		Depth:   len(logs) + 1,
+	})
+	return log, nil
+}
+
+// toolInput asks for the exact input of a tool step that depends on earlier results
+func (p *PlanExecutor) toolInput(ctx context.Context, problem string, step PlanStep, logs []StepLog) (string, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: "You write the exact input for a tool call. Respond with the input only, no explanation."},
+		{Role: "user", Content: fmt.Sprintf("Problem:\n%s\n\nCompleted steps:\n%s\nStep: %s\nTool: %s\n\nTool input:",
+			problem, formatStepLogs(logs), step.Description, step.Tool)},
+	}
+	response, err := p.chat(ctx, messages, 256)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(strings.TrimSpace(response), "`"), nil
+}
+
+// compose writes the final answer from the step logs
+func (p *PlanExecutor) compose(ctx context.Context, problem string, logs []StepLog) (string, error) {
+	if len(logs) == 0 {
+		return "", nil
+	}
+	messages := []ChatMessage{
+		{Role: "system", Content: "You write the final answer to a problem from the results of an executed plan. Be direct and complete."},
+		{Role: "user", Content: fmt.Sprintf("Problem:\n%s\n\nExecuted steps:\n%s\nGive the final answer to the problem.", problem, formatStepLogs(logs))},
+	}
+	response, err := p.chat(ctx, messages, 1024)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
+}
+
+func formatStepLogs(logs []StepLog) string {
+	if len(logs) == 0 {
+		return "(none)\n"
+	}
+	var b strings.Builder
+	for _, l := range logs {
+		label := l.Type
+		if l.Type == PlanStepTool {
+			label = fmt.Sprintf("tool %s(%s)", l.Tool, utils.TruncateStr(l.Input, 80))
+		}
+		b.WriteString(fmt.Sprintf("%d. [%s] %s\n", l.Step, label, l.Description))
+		if l.Success {
+			b.WriteString(fmt.Sprintf("   Result: %s\n", utils.TruncateStr(l.Output, 500)))
+		} else {
+			b.WriteString(fmt.Sprintf("   FAILED: %s\n", l.Error))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// planProvider plans a failing tool step first, re-plans around it with a
+// calculator step, and reports every reasoning step as done
+type planProvider struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (p *planProvider) Name() string { return "plan" }
+
+func (p *planProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	user := messages[len(messages)-1].Content
+	p.mu.Lock()
+	p.prompts = append(p.prompts, user)
+	p.mu.Unlock()
+
+	switch {
+	case strings.Contains(user, "REMAINING work"):
+		return `{"steps": [
+			{"description": "Multiply with the calculator", "type": "tool", "tool": "calculator", "input": "17 * 23"},
+			{"description": "State the product", "type": "reason"}
+		]}`, nil
+	case strings.Contains(messages[0].Content, "planning agent"):
+		return `{"steps": [
+			{"description": "Look the product up in a table", "type": "tool", "tool": "lookup_table", "input": "17x23"},
+			{"description": "State the product", "type": "reason"}
+		]}`, nil
+	case strings.Contains(user, "Current step"):
+		return `{"result": "The product is 391", "failed": false}`, nil
+	default:
+		return "391", nil
+	}
+}
+
+func TestPlanExecutor_ReplansAfterFailedStep(t *testing.T) {
+	provider := &planProvider{}
+	result, err := NewPlanExecutor(provider, DefaultPlanExecuteConfig()).Run(context.Background(), "What is 17 * 23?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(result.Plan) != 2 || result.Plan[0].Tool != "lookup_table" {
+		t.Fatalf("Expected the initial plan to be reported, got %+v", result.Plan)
+	}
+	if len(result.Replans) != 1 || result.Replans[0].AfterStep != 1 {
+		t.Fatalf("Expected one re-plan after step 1, got %+v", result.Replans)
+	}
+	if len(result.Steps) != 3 {
+		t.Fatalf("Expected 3 executed steps, got %+v", result.Steps)
+	}
+
+	failed, calc, final := result.Steps[0], result.Steps[1], result.Steps[2]
+	if failed.Success || !strings.Contains(failed.Error, "unknown tool") {
+		t.Errorf("Expected the unknown tool step to fail, got %+v", failed)
+	}
+	if !calc.Success || calc.Output != "391" || calc.PlanVersion != 2 {
+		t.Errorf("Expected calculator step from plan 2, got %+v", calc)
+	}
+	if final.Type != PlanStepReason || !final.Success {
+		t.Errorf("Expected a successful reasoning step, got %+v", final)
+	}
+	if result.TotalToolCalls != 2 || result.ToolsUsed["calculator"] != 1 {
+		t.Errorf("Unexpected tool accounting: %d calls, %v", result.TotalToolCalls, result.ToolsUsed)
+	}
+	if result.FinalAnswer != "391" || !result.Success {
+		t.Errorf("Expected final answer 391, got %q", result.FinalAnswer)
+	}
+
+	// The reasoning step must see the tool output
+	var sawResult bool
+	for _, p := range provider.prompts {
+		if strings.Contains(p, "Current step") && strings.Contains(p, "Result: 391") {
+			sawResult = true
+		}
+	}
+	if !sawResult {
+		t.Error("Expected earlier step results fed into later steps")
+	}
+}
+
+func TestPlanExecutor_ToolsDisabled(t *testing.T) {
+	config := DefaultPlanExecuteConfig()
+	config.EnableTools = false
+	config.MaxReplans = 0
+
+	result, err := NewPlanExecutor(&planProvider{}, config).Run(context.Background(), "What is 17 * 23?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Steps[0].Success || result.Steps[0].Error != "tools are disabled" {
+		t.Errorf("Expected tool step to fail with tools disabled, got %+v", result.Steps[0])
+	}
+	if len(result.Replans) != 0 || len(result.Steps) != 2 {
+		t.Errorf("Expected the original plan to continue without re-planning, got %d steps, %d replans", len(result.Steps), len(result.Replans))
+	}
+}
+
+func TestParsePlanSteps(t *testing.T) {
+	plan := parsePlanSteps(`Here is the plan: {"steps": [
+		{"description": "Think", "type": "reason", "tool": "calculator"},
+		{"description": "", "type": "reason"},
+		{"description": "Compute", "type": "TOOL", "tool": "calculator", "input": "1+1"},
+		{"description": "Missing tool", "type": "tool"}
+	]}`, 10)
+	if len(plan) != 3 {
+		t.Fatalf("Expected 3 steps, got %+v", plan)
+	}
+	if plan[0].Tool != "" || plan[1].Type != PlanStepTool || plan[1].Number != 2 || plan[2].Type != PlanStepReason {
+		t.Errorf("Unexpected normalization: %+v", plan)
+	}
+	if parsePlanSteps("no plan", 10) != nil {
+		t.Error("Expected nil for unparseable plan")
+	}
+}
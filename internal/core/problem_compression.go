@@ -0,0 +1,300 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Long Problem Compression ============
+//
+// A problem too long for the model's context is split into excerpts, each
+// excerpt is summarized (map) and the summaries are merged into a working
+// brief (reduce). The strategy reasons over the brief, and the problem_lookup
+// tool reads the original excerpts on demand.
+
+const (
+	defaultCompressThreshold = 24000 // Characters, roughly 6k tokens
+	defaultCompressChunk     = 8000
+	maxCompressedBriefs      = 16
+)
+
+// ProblemSource holds the original text of a compressed problem
+type ProblemSource struct {
+	Excerpts      []string
+	OriginalChars int
+}
+
+type problemSourceKey struct{}
+
+func problemSourceFromContext(ctx context.Context) *ProblemSource {
+	src, _ := ctx.Value(problemSourceKey{}).(*ProblemSource)
+	return src
+}
+
+// attach returns registry with problem_lookup registered and enabled,
+// creating a registry holding only problem_lookup when registry is nil
+func (s *ProblemSource) attach(registry *ToolRegistry) *ToolRegistry {
+	if registry == nil {
+		registry = &ToolRegistry{tools: make(map[string]ToolExecutor), enabled: make(map[string]bool)}
+	}
+	registry.Register(&ProblemLookupTool{source: s})
+	registry.Enable("problem_lookup")
+	return registry
+}
+
+// ProblemLookupTool reads excerpts of the original problem
+type ProblemLookupTool struct {
+	source *ProblemSource
+}
+
+func (t *ProblemLookupTool) Name() string { return "problem_lookup" }
+
+func (t *ProblemLookupTool) Description() string {
+	return fmt.Sprintf("Read the original problem, which was condensed into a brief. Input an excerpt number (1-%d) for its full text, or a word or phrase to find the passages that mention it", len(t.source.Excerpts))
+}
+
+func (t *ProblemLookupTool) Execute(ctx context.Context, input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("input an excerpt number or a phrase")
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(input, "#")); err == nil {
+		if n < 1 || n > len(t.source.Excerpts) {
+			return "", fmt.Errorf("excerpt %d does not exist (1-%d)", n, len(t.source.Excerpts))
+		}
+		return fmt.Sprintf("[Excerpt %d of %d]\n%s", n, len(t.source.Excerpts), t.source.Excerpts[n-1]), nil
+	}
+
+	const window = 300
+	needle := strings.ToLower(input)
+	var sb strings.Builder
+	matches := 0
+	for i, excerpt := range t.source.Excerpts {
+		lower := strings.ToLower(excerpt)
+		for offset := 0; matches < 5; {
+			idx := strings.Index(lower[offset:], needle)
+			if idx < 0 {
+				break
+			}
+			idx += offset
+			start, end := max(0, idx-window), min(len(excerpt), idx+len(needle)+window)
+			for start > 0 && !utf8.RuneStart(excerpt[start]) {
+				start--
+			}
+			for end < len(excerpt) && !utf8.RuneStart(excerpt[end]) {
+				end++
+			}
+			fmt.Fprintf(&sb, "[Excerpt %d] ...%s...\n\n", i+1, excerpt[start:end])
+			matches++
+			offset = end
+		}
+	}
+	if matches == 0 {
+		return fmt.Sprintf("No passage mentions %q. Read an excerpt by number (1-%d) instead.", input, len(t.source.Excerpts)), nil
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// chunkProblem splits text into pieces of at most size bytes, preferring
+// paragraph, then line, then sentence boundaries
+func chunkProblem(text string, size int) []string {
+	var chunks []string
+	for len(text) > size {
+		cut := -1
+		for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+			if i := strings.LastIndex(text[:size], sep); i > size/2 {
+				cut = i + len(sep)
+				break
+			}
+		}
+		if cut < 0 {
+			cut = size
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		if chunk := strings.TrimSpace(text[:cut]); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		text = text[cut:]
+	}
+	if chunk := strings.TrimSpace(text); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+const compressMapPrompt = `You condense one excerpt of a long problem statement so the problem can be solved from notes.
+Keep every number, name, definition, constraint, requirement and question. Drop repetition and filler. Respond with dense bullet points only.`
+
+const compressReducePrompt = `You merge notes on the excerpts of a long problem statement into one working brief for solving it.
+Start with the question or task being asked. Then list every fact, number, constraint and requirement needed to solve it, grouped by topic, citing excerpt numbers like [3]. Do not solve the problem.`
+
+// compressProblem condenses a long problem into a brief and its source
+func compressProblem(ctx context.Context, provider Provider, problem string, chunkChars int) (string, *ProblemSource, error) {
+	excerpts := chunkProblem(problem, chunkChars)
+	source := &ProblemSource{Excerpts: excerpts, OriginalChars: len(problem)}
+
+	notes := make([]string, len(excerpts))
+	var wg sync.WaitGroup
+	for i, excerpt := range excerpts {
+		wg.Add(1)
+		go func(i int, excerpt string) {
+			defer wg.Done()
+			response, err := provider.Chat(ctx, []ChatMessage{
+				{Role: "system", Content: compressMapPrompt},
+				{Role: "user", Content: fmt.Sprintf("Excerpt %d of %d:\n\n%s", i+1, len(excerpts), excerpt)},
+			}, ChatOptions{Temperature: 0.2, MaxTokens: 800})
+			if err != nil || strings.TrimSpace(response) == "" {
+				fmt.Fprintf(os.Stderr, "[WARNING] problem compression: excerpt %d could not be summarized: %v\n", i+1, err)
+				response = fmt.Sprintf("(Not summarized; read it with problem_lookup.) Starts: %s", utils.TruncateStr(excerpt, 400))
+			}
+			notes[i] = fmt.Sprintf("[%d] %s", i+1, strings.TrimSpace(response))
+		}(i, excerpt)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	// Merge the notes in groups that fit one request until one brief is left
+	for len(notes) > 1 || len(notes[0]) > chunkChars {
+		var groups [][]string
+		size := 0
+		for _, note := range notes {
+			if len(groups) == 0 || (size+len(note) > chunkChars && len(groups[len(groups)-1]) > 1) {
+				groups = append(groups, nil)
+				size = 0
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], note)
+			size += len(note)
+		}
+		if len(groups) == len(notes) && len(notes) > 1 {
+			groups = [][]string{notes} // Every note is large; merge them all at once
+		}
+
+		merged := make([]string, 0, len(groups))
+		for _, group := range groups {
+			response, err := provider.Chat(ctx, []ChatMessage{
+				{Role: "system", Content: compressReducePrompt},
+				{Role: "user", Content: strings.Join(group, "\n\n")},
+			}, ChatOptions{Temperature: 0.2, MaxTokens: 2000})
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to merge excerpt notes: %w", err)
+			}
+			merged = append(merged, strings.TrimSpace(response))
+		}
+		if len(merged) == 1 && len(notes) == 1 {
+			notes = merged
+			break // A single oversized note was condensed once; keep the result
+		}
+		notes = merged
+	}
+
+	brief := fmt.Sprintf("%s\n\n[This is a working brief of a %d-character problem. The original is split into %d excerpts; use the problem_lookup tool with an excerpt number or a phrase to read the exact text.]",
+		notes[0], len(problem), len(excerpts))
+	return brief, source, nil
+}
+
+// compressedProblem is a cached brief
+type compressedProblem struct {
+	brief  string
+	source *ProblemSource
+}
+
+var (
+	compressedBriefs   = make(map[string]*compressedProblem)
+	compressedOrder    []string
+	compressedBriefsMu sync.Mutex
+)
+
+// compressProblemCached reuses the brief of a problem compressed recently,
+// so repeated calls cost nothing and produce the same cache keys
+func compressProblemCached(ctx context.Context, provider Provider, problem string, chunkChars int) (string, *ProblemSource, error) {
+	key := hashProblem(problem) + "|" + strconv.Itoa(chunkChars)
+	compressedBriefsMu.Lock()
+	cached, ok := compressedBriefs[key]
+	compressedBriefsMu.Unlock()
+	if ok {
+		return cached.brief, cached.source, nil
+	}
+
+	brief, source, err := compressProblem(ctx, provider, problem, chunkChars)
+	if err != nil {
+		return "", nil, err
+	}
+	compressedBriefsMu.Lock()
+	defer compressedBriefsMu.Unlock()
+	if _, ok := compressedBriefs[key]; !ok {
+		if len(compressedOrder) >= maxCompressedBriefs {
+			delete(compressedBriefs, compressedOrder[0])
+			compressedOrder = compressedOrder[1:]
+		}
+		compressedOrder = append(compressedOrder, key)
+	}
+	compressedBriefs[key] = &compressedProblem{brief: brief, source: source}
+	return brief, source, nil
+}
+
+// problemCompressionMiddleware replaces problems longer than
+// PROBLEM_COMPRESS_THRESHOLD characters with a brief before a strategy
+// runs. PROBLEM_COMPRESSION=false disables it and PROBLEM_COMPRESS_CHUNK
+// sets the excerpt size.
+func problemCompressionMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return newProblemCompressionMiddleware(getProviderFromArgsForTool)(next)
+}
+
+// newProblemCompressionMiddleware compresses long problems with the provider
+// returned by providerFor. It sits inside runHistoryMiddleware so history
+// records the original problem.
+func newProblemCompressionMiddleware(providerFor func(args map[string]interface{}, toolName string) (Provider, error)) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args, ok := request.Params.Arguments.(map[string]interface{})
+			problem, _ := args["problem"].(string)
+			if !ok || !runHistoryTools[request.Params.Name] || problemSourceFromContext(ctx) != nil {
+				return next(ctx, request)
+			}
+			switch strings.ToLower(strings.TrimSpace(os.Getenv("PROBLEM_COMPRESSION"))) {
+			case "false", "0", "off":
+				return next(ctx, request)
+			}
+			if len(problem) <= parseEnvInt("PROBLEM_COMPRESS_THRESHOLD", defaultCompressThreshold) {
+				return next(ctx, request)
+			}
+
+			provider, err := providerFor(args, request.Params.Name)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Provider error: %v", err)), nil
+			}
+			chunkChars := parseEnvInt("PROBLEM_COMPRESS_CHUNK", defaultCompressChunk)
+			if chunkChars < 1000 {
+				chunkChars = 1000
+			}
+			brief, source, err := compressProblemCached(ctx, provider, problem, chunkChars)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Problem is too long (%d characters) and compression failed: %v", len(problem), err)), nil
+			}
+			fmt.Fprintf(os.Stderr, "[COMPRESS] %s: condensed a %d-character problem into a %d-character brief (%d excerpts)\n",
+				request.Params.Name, len(problem), len(brief), len(source.Excerpts))
+
+			compressed := make(map[string]interface{}, len(args))
+			for k, v := range args {
+				compressed[k] = v
+			}
+			compressed["problem"] = brief
+			request.Params.Arguments = compressed
+			return next(context.WithValue(ctx, problemSourceKey{}, source), request)
+		}
+	}
+}
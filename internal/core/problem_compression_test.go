@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"reasoning-tools/utils"
+)
+
+func TestChunkProblem(t *testing.T) {
+	paragraph := strings.Repeat("word ", 199) + "end."
+	text := strings.Join([]string{paragraph, paragraph, paragraph}, "\n\n")
+	chunks := chunkProblem(text, 1500)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected one chunk per paragraph, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if chunk != paragraph {
+			t.Errorf("Chunk %d was not split at the paragraph boundary: %q", i, utils.TruncateStr(chunk, 40))
+		}
+	}
+
+	// Text without separators is split at the hard limit
+	if chunks := chunkProblem(strings.Repeat("x", 2500), 1000); len(chunks) != 3 || len(chunks[0]) != 1000 {
+		t.Errorf("Expected hard splits, got %d chunks", len(chunks))
+	}
+}
+
+func TestProblemLookupTool(t *testing.T) {
+	tool := &ProblemLookupTool{source: &ProblemSource{Excerpts: []string{
+		"The warehouse holds 120 crates.",
+		"Each truck carries 8 crates. The deadline is Friday.",
+	}}}
+	ctx := context.Background()
+
+	out, err := tool.Execute(ctx, "2")
+	if err != nil || !strings.Contains(out, "Each truck carries 8 crates") {
+		t.Errorf("Expected excerpt 2, got %q (%v)", out, err)
+	}
+	if _, err := tool.Execute(ctx, "3"); err == nil {
+		t.Error("Expected an error for a missing excerpt")
+	}
+	if out, _ := tool.Execute(ctx, "deadline"); !strings.Contains(out, "[Excerpt 2]") || !strings.Contains(out, "Friday") {
+		t.Errorf("Expected the matching passage, got %q", out)
+	}
+	if out, _ := tool.Execute(ctx, "forklift"); !strings.Contains(out, "No passage") {
+		t.Errorf("Expected no matches, got %q", out)
+	}
+}
+
+func TestCompressProblem(t *testing.T) {
+	provider := &countProvider{response: "- key facts"}
+	problem := strings.Repeat(strings.Repeat("fact ", 199)+"end.\n\n", 6)
+	brief, source, err := compressProblem(context.Background(), provider, problem, 1500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(source.Excerpts) != 6 || source.OriginalChars != len(problem) {
+		t.Errorf("Expected 6 excerpts of the original, got %d", len(source.Excerpts))
+	}
+	// Six map calls, then the notes fit in one reduce call
+	if provider.calls != 7 {
+		t.Errorf("Expected 7 LLM calls, got %d", provider.calls)
+	}
+	if !strings.HasPrefix(brief, "- key facts") || !strings.Contains(brief, "problem_lookup") {
+		t.Errorf("Unexpected brief: %q", brief)
+	}
+}
+
+func TestProblemCompressionMiddleware(t *testing.T) {
+	t.Setenv("PROBLEM_COMPRESS_THRESHOLD", "1000")
+	t.Setenv("PROBLEM_COMPRESS_CHUNK", "1000")
+	var seen string
+	var source *ProblemSource
+	provider := &countProvider{response: "- fox facts"}
+	providerFor := func(map[string]interface{}, string) (Provider, error) { return provider, nil }
+	handler := newProblemCompressionMiddleware(providerFor)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seen = request.GetArguments()["problem"].(string)
+		source = problemSourceFromContext(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call := func(tool, problem string) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tool
+		request.Params.Arguments = map[string]interface{}{"problem": problem}
+		handler(context.Background(), request)
+	}
+
+	call("graph_of_thoughts", "short problem")
+	if seen != "short problem" || source != nil {
+		t.Errorf("Expected short problems untouched, got %q", seen)
+	}
+
+	long := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 60)
+	call("graph_of_thoughts", long)
+	if seen == long || source == nil || len(source.Excerpts) < 2 {
+		t.Fatalf("Expected a compressed problem with excerpts, got %d chars", len(seen))
+	}
+	registry := source.attach(nil)
+	if result := registry.Execute(context.Background(), "problem_lookup", "1"); !result.Success || !strings.Contains(result.Output, "quick brown fox") {
+		t.Errorf("Expected problem_lookup to read the original, got %+v", result)
+	}
+
+	// The brief is reused for the same problem
+	calls := provider.calls
+	call("graph_of_thoughts", long)
+	if provider.calls != calls {
+		t.Errorf("Expected the cached brief, got %d more calls", provider.calls-calls)
+	}
+
+	call("queue_status", long)
+	if seen != long {
+		t.Error("Expected non-strategy tools untouched")
+	}
+
+	t.Setenv("PROBLEM_COMPRESSION", "off")
+	call("graph_of_thoughts", long)
+	if seen != long {
+		t.Error("Expected PROBLEM_COMPRESSION=off to disable compression")
+	}
+}
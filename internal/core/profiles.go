@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Profiles ============
+//
+// A profile is a named bundle of provider, model and parameters, so a client
+// can ask for "fast" or "thorough" without knowing model names. The profile
+// argument fills every parameter the call leaves unset; explicit arguments
+// win over the profile, and the profile wins over session defaults.
+
+// Profile is a named provider, model and parameter bundle
+type Profile struct {
+	Description string                 `json:"description,omitempty" yaml:"description"`
+	Provider    string                 `json:"provider,omitempty" yaml:"provider"`
+	Model       string                 `json:"model,omitempty" yaml:"model"`
+	Params      map[string]interface{} `json:"params,omitempty" yaml:"params"` // Applied only to tools that declare them
+}
+
+// builtinProfiles returns the profiles available without configuration.
+// fast moves to a small Groq model when a Groq key is set.
+func builtinProfiles() map[string]Profile {
+	fast := Profile{
+		Description: "Small budgets for quick answers",
+		Params: map[string]interface{}{
+			"max_thoughts": 5, "max_nodes": 10, "max_depth": 4, "branching_factor": 2,
+			"max_attempts": 2, "max_rounds": 1, "max_subproblems": 3, "additional_nodes": 5,
+		},
+	}
+	if isProviderConfigured("groq") {
+		fast.Provider, fast.Model = "groq", "llama-3.1-8b-instant"
+	}
+	return map[string]Profile{
+		"fast": fast,
+		"balanced": {
+			Description: "The tools' default budgets on the default provider",
+			Params:      map[string]interface{}{},
+		},
+		"thorough": {
+			Description: "Large budgets for hard problems",
+			Params: map[string]interface{}{
+				"max_thoughts": 15, "max_nodes": 50, "max_depth": 10, "branching_factor": 4,
+				"max_attempts": 5, "max_rounds": 4, "max_subproblems": 8, "additional_nodes": 30,
+			},
+		},
+	}
+}
+
+// loadProfiles returns the built-in profiles overlaid with the ones in
+// REASONING_PROFILES, a JSON object of name to profile (set from the
+// config file's profiles section)
+func loadProfiles() (map[string]Profile, error) {
+	profiles := builtinProfiles()
+	raw := strings.TrimSpace(os.Getenv("REASONING_PROFILES"))
+	if raw == "" {
+		return profiles, nil
+	}
+	var configured map[string]Profile
+	if err := json.Unmarshal([]byte(raw), &configured); err != nil {
+		return nil, fmt.Errorf("invalid REASONING_PROFILES: %w", err)
+	}
+	for name, p := range configured {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("invalid REASONING_PROFILES: empty profile name")
+		}
+		if p.Provider != "" && providerEnvPrefix(p.Provider) == "" {
+			return nil, fmt.Errorf("profile %s: unknown provider %q", name, p.Provider)
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// profileNames lists profile names in order
+func profileNames(profiles map[string]Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileOption declares the profile argument on a reasoning tool
+func profileOption() mcp.ToolOption {
+	return mcp.WithString("profile",
+		mcp.Description("Named bundle of provider, model and parameters: fast, balanced, thorough or one from the config file. Explicit arguments override it"),
+	)
+}
+
+// values returns the arguments a profile supplies, typed as they would be
+// when decoded from a JSON tool call
+func (p Profile) values() map[string]interface{} {
+	values := make(map[string]interface{}, len(p.Params)+2)
+	if data, err := json.Marshal(p.Params); err == nil && len(p.Params) > 0 {
+		json.Unmarshal(data, &values)
+	}
+	if p.Provider != "" {
+		values["provider"] = p.Provider
+	}
+	if p.Model != "" {
+		values["model"] = p.Model
+	}
+	return values
+}
+
+type sessionDefaultedKey struct{}
+
+// sessionDefaultedFromContext returns the arguments session defaults filled in
+func sessionDefaultedFromContext(ctx context.Context) map[string]bool {
+	filled, _ := ctx.Value(sessionDefaultedKey{}).(map[string]bool)
+	return filled
+}
+
+// profileMiddleware expands the profile argument. It sits inside
+// sessionDefaultsMiddleware so a session can default the profile, and
+// overrides values that came from session defaults.
+func profileMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		name, _ := args["profile"].(string)
+		if !ok || strings.TrimSpace(name) == "" {
+			return next(ctx, request)
+		}
+
+		profiles, err := loadProfiles()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		profile, ok := profiles[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown profile %q (available: %s)", name, strings.Join(profileNames(profiles), ", "))), nil
+		}
+
+		var declared map[string]interface{}
+		if srv := server.ServerFromContext(ctx); srv != nil {
+			if tool := srv.GetTool(request.Params.Name); tool != nil {
+				declared = tool.Tool.InputSchema.Properties
+			}
+		}
+		request.Params.Arguments = applyProfile(args, profile, declared, sessionDefaultedFromContext(ctx))
+		return next(ctx, request)
+	}
+}
+
+// applyProfile fills a profile's values into args for parameters the tool
+// declares and the caller left unset (or that only session defaults set)
+func applyProfile(args map[string]interface{}, profile Profile, declared map[string]interface{}, sessionDefaulted map[string]bool) map[string]interface{} {
+	merged := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		merged[k] = v
+	}
+	for k, v := range profile.values() {
+		if _, ok := declared[k]; declared != nil && !ok {
+			continue
+		}
+		if _, set := merged[k]; !set || sessionDefaulted[k] {
+			merged[k] = v
+		}
+	}
+	// A session's default model belongs to the session's provider
+	if profile.Provider != "" && profile.Model == "" && sessionDefaulted["model"] && sessionDefaulted["provider"] {
+		delete(merged, "model")
+	}
+	return merged
+}
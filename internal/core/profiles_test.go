@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestProfileMiddleware_Precedence(t *testing.T) {
+	sessionDefaults = newSessionDefaultsStore(maxDefaultSessions)
+	t.Cleanup(func() { sessionDefaults = newSessionDefaultsStore(maxDefaultSessions) })
+	t.Setenv("GROQ_API_KEY", "")
+	t.Setenv("REASONING_PROFILES", `{"cheap": {"provider": "groq", "params": {"max_depth": 2, "temperature": 0.1}}}`)
+
+	s := server.NewMCPServer(serverName, serverVersion,
+		server.WithToolHandlerMiddleware(sessionDefaultsMiddleware),
+		server.WithToolHandlerMiddleware(profileMiddleware))
+	s.AddTool(mcp.NewTool("set_session_defaults"), handleSetSessionDefaults)
+
+	var seen map[string]interface{}
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("provider"), mcp.WithString("model"), mcp.WithNumber("max_depth"),
+		mcp.WithNumber("max_nodes"), profileOption()),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			seen, _ = request.Params.Arguments.(map[string]interface{})
+			return mcp.NewToolResultText("ok"), nil
+		})
+
+	call := func(ctx context.Context, id int, tool, args string) string {
+		msg := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %d, "method": "tools/call", "params": {"name": %q, "arguments": %s}}`, id, tool, args)
+		raw, _ := json.Marshal(s.HandleMessage(ctx, json.RawMessage(msg)))
+		var resp struct {
+			Result struct {
+				Content []mcp.TextContent `json:"content"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Content) == 0 {
+			t.Fatalf("Unexpected response: %s", raw)
+		}
+		return resp.Result.Content[0].Text
+	}
+	ctx := s.WithContext(context.Background(), server.NewInProcessSession("profile-session", nil))
+
+	call(ctx, 1, "echo", `{"profile": "FAST", "max_nodes": 25}`)
+	if seen["max_nodes"] != float64(25) || seen["max_depth"] != float64(4) || seen["provider"] != nil {
+		t.Errorf("Expected the fast budgets under explicit arguments, got %v", seen)
+	}
+
+	call(ctx, 2, "set_session_defaults", `{"defaults": "{\"provider\": \"deepseek\", \"model\": \"deepseek-chat\", \"max_nodes\": 40, \"profile\": \"cheap\"}"}`)
+	call(ctx, 3, "echo", `{}`)
+	if seen["provider"] != "groq" || seen["model"] != nil || seen["max_depth"] != float64(2) || seen["max_nodes"] != float64(40) || seen["temperature"] != nil {
+		t.Errorf("Expected the session's profile to override its other defaults, got %v", seen)
+	}
+
+	if out := call(ctx, 4, "echo", `{"profile": "nope"}`); out == "ok" {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestLoadProfiles_Validation(t *testing.T) {
+	t.Setenv("REASONING_PROFILES", `{"mine": {"provider": "acme"}}`)
+	if _, err := loadProfiles(); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+	t.Setenv("REASONING_PROFILES", `{"Thorough": {"model": "gpt-4o"}}`)
+	profiles, err := loadProfiles()
+	if err != nil || profiles["thorough"].Model != "gpt-4o" || len(profiles["thorough"].Params) != 0 || len(profiles) != 3 {
+		t.Errorf("Expected a configured profile to replace the built-in one, got %v (%v)", profiles, err)
+	}
+}
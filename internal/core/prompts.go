@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ MCP Prompts ============
+//
+// Reasoning recipes are canned workflows exposed as MCP prompts, so clients
+// with a prompt picker can discover them. Getting a prompt returns a user
+// message that asks the model to call the recipe's tool with its arguments
+// pre-filled from the prompt arguments.
+
+type recipeArgument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+type reasoningRecipe struct {
+	Name        string
+	Description string
+	Arguments   []recipeArgument
+	Tool        string
+	Params      map[string]interface{} // Tool arguments the recipe presets
+	// problem builds the tool's problem from the prompt arguments
+	problem func(args map[string]string) string
+}
+
+var reasoningRecipes = []reasoningRecipe{
+	{
+		Name:        "analyze-tradeoff",
+		Description: "Weigh two or more options against each other with a multi-perspective debate (dialectic_reason)",
+		Arguments: []recipeArgument{
+			{Name: "options", Description: "The options to compare, e.g. \"Postgres vs DynamoDB\"", Required: true},
+			{Name: "context", Description: "The situation the choice is made in"},
+			{Name: "criteria", Description: "What matters most, e.g. cost, latency, team skills"},
+		},
+		Tool:   "dialectic_reason",
+		Params: map[string]interface{}{"max_rounds": 3, "num_challengers": 3, "rebuttals": true},
+		problem: func(args map[string]string) string {
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "Analyze the trade-off between %s.", args["options"])
+			if args["context"] != "" {
+				fmt.Fprintf(&sb, "\n\nContext: %s", args["context"])
+			}
+			if args["criteria"] != "" {
+				fmt.Fprintf(&sb, "\n\nCriteria: %s", args["criteria"])
+			}
+			sb.WriteString("\n\nRecommend one option, and say when another would be the better choice.")
+			return sb.String()
+		},
+	},
+	{
+		Name:        "debug-root-cause",
+		Description: "Explore competing hypotheses for a bug or incident and converge on the most likely root cause (graph_of_thoughts)",
+		Arguments: []recipeArgument{
+			{Name: "symptom", Description: "What goes wrong, e.g. \"requests time out after deploys\"", Required: true},
+			{Name: "evidence", Description: "Logs, metrics, recent changes or anything already ruled out"},
+			{Name: "system", Description: "The system or component involved"},
+		},
+		Tool:   "graph_of_thoughts",
+		Params: map[string]interface{}{"branching_factor": 3, "max_nodes": 24, "expansion_policy": ExpansionPolicyBestFirst, "enable_aggregation": true},
+		problem: func(args map[string]string) string {
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "Find the root cause of this problem: %s", args["symptom"])
+			if args["system"] != "" {
+				fmt.Fprintf(&sb, "\n\nSystem: %s", args["system"])
+			}
+			if args["evidence"] != "" {
+				fmt.Fprintf(&sb, "\n\nEvidence so far: %s", args["evidence"])
+			}
+			sb.WriteString("\n\nConsider several hypotheses, rule them in or out against the evidence, and give the most likely root cause, how to confirm it, and the fix.")
+			return sb.String()
+		},
+	},
+	{
+		Name:        "estimate-fermi",
+		Description: "Estimate a quantity by breaking it into factors that can be guessed and multiplying them out (decompose_solve)",
+		Arguments: []recipeArgument{
+			{Name: "quantity", Description: "What to estimate, e.g. \"piano tuners in Chicago\"", Required: true},
+			{Name: "assumptions", Description: "Known figures or assumptions to use"},
+		},
+		Tool:   "decompose_solve",
+		Params: map[string]interface{}{"max_subproblems": 6},
+		problem: func(args map[string]string) string {
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "Make a Fermi estimate of %s.", args["quantity"])
+			if args["assumptions"] != "" {
+				fmt.Fprintf(&sb, "\n\nUse these assumptions: %s", args["assumptions"])
+			}
+			sb.WriteString("\n\nBreak it into factors, estimate each with a stated assumption, combine them, and give the result as an order of magnitude with a plausible range.")
+			return sb.String()
+		},
+	},
+}
+
+// newRecipePrompt builds the MCP prompt definition of a recipe
+func newRecipePrompt(recipe reasoningRecipe) mcp.Prompt {
+	opts := []mcp.PromptOption{mcp.WithPromptDescription(recipe.Description)}
+	for _, arg := range recipe.Arguments {
+		argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(arg.Description)}
+		if arg.Required {
+			argOpts = append(argOpts, mcp.RequiredArgument())
+		}
+		opts = append(opts, mcp.WithArgument(arg.Name, argOpts...))
+	}
+	return mcp.NewPrompt(recipe.Name, opts...)
+}
+
+// recipeToolArguments fills in a recipe's tool arguments
+func recipeToolArguments(recipe reasoningRecipe, args map[string]string) (map[string]interface{}, error) {
+	for _, arg := range recipe.Arguments {
+		if arg.Required && strings.TrimSpace(args[arg.Name]) == "" {
+			return nil, fmt.Errorf("prompt %s needs the %s argument", recipe.Name, arg.Name)
+		}
+	}
+	trimmed := make(map[string]string, len(args))
+	for k, v := range args {
+		trimmed[k] = strings.TrimSpace(v)
+	}
+
+	toolArgs := map[string]interface{}{"problem": recipe.problem(trimmed)}
+	for k, v := range recipe.Params {
+		toolArgs[k] = v
+	}
+	return toolArgs, nil
+}
+
+// recipePromptHandler renders a recipe as a request to call its tool
+func recipePromptHandler(recipe reasoningRecipe) server.PromptHandlerFunc {
+	return func(_ context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		toolArgs, err := recipeToolArguments(recipe, request.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(toolArgs, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		text := fmt.Sprintf("Call the `%s` tool with these arguments:\n\n```json\n%s\n```\n\nThen summarize its final answer and the reasoning that supports it.", recipe.Tool, data)
+		return mcp.NewGetPromptResult(recipe.Description, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
+
+// registerPrompts adds the reasoning recipes as MCP prompts
+func registerPrompts(s *server.MCPServer) {
+	for _, recipe := range reasoningRecipes {
+		s.AddPrompt(newRecipePrompt(recipe), recipePromptHandler(recipe))
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func promptRequest(t *testing.T, s *server.MCPServer, method string, params interface{}) []byte {
+	t.Helper()
+	data, _ := json.Marshal(params)
+	raw, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "`+method+`", "params": `+string(data)+`}`)))
+	return raw
+}
+
+func TestPrompts_ListAndGet(t *testing.T) {
+	s := server.NewMCPServer(serverName, serverVersion)
+	registerPrompts(s)
+
+	var list struct {
+		Result struct {
+			Prompts []struct {
+				Name      string `json:"name"`
+				Arguments []struct {
+					Name     string `json:"name"`
+					Required bool   `json:"required"`
+				} `json:"arguments"`
+			} `json:"prompts"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(promptRequest(t, s, "prompts/list", map[string]interface{}{}), &list); err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, p := range list.Result.Prompts {
+		names[p.Name] = true
+	}
+	for _, want := range []string{"analyze-tradeoff", "debug-root-cause", "estimate-fermi"} {
+		if !names[want] {
+			t.Errorf("Expected prompt %s, got %v", want, names)
+		}
+	}
+
+	var get struct {
+		Result struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		} `json:"result"`
+	}
+	raw := promptRequest(t, s, "prompts/get", map[string]interface{}{
+		"name": "analyze-tradeoff", "arguments": map[string]string{"options": "Postgres vs DynamoDB", "criteria": " cost "},
+	})
+	if err := json.Unmarshal(raw, &get); err != nil || len(get.Result.Messages) != 1 {
+		t.Fatalf("Expected one prompt message, got %s", raw)
+	}
+	text := get.Result.Messages[0].Content.Text
+	for _, want := range []string{"`dialectic_reason`", `"num_challengers": 3`, "Analyze the trade-off between Postgres vs DynamoDB.", "Criteria: cost\\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in the prompt:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Context:") {
+		t.Errorf("Expected missing optional arguments to be left out:\n%s", text)
+	}
+
+	raw = promptRequest(t, s, "prompts/get", map[string]interface{}{"name": "estimate-fermi", "arguments": map[string]string{}})
+	if !strings.Contains(string(raw), "needs the quantity argument") {
+		t.Errorf("Expected a missing required argument to fail, got %s", raw)
+	}
+}
+
+func TestRecipes_ProblemAndTool(t *testing.T) {
+	for _, recipe := range reasoningRecipes {
+		if !runHistoryTools[recipe.Tool] {
+			t.Errorf("%s: expected a strategy tool, got %s", recipe.Name, recipe.Tool)
+		}
+		args := map[string]string{}
+		for _, arg := range recipe.Arguments {
+			args[arg.Name] = "value of " + arg.Name
+		}
+		toolArgs, err := recipeToolArguments(recipe, args)
+		if err != nil {
+			t.Fatalf("%s: %v", recipe.Name, err)
+		}
+		for _, arg := range recipe.Arguments {
+			if !strings.Contains(toolArgs["problem"].(string), "value of "+arg.Name) {
+				t.Errorf("%s: expected the %s argument in the problem, got %q", recipe.Name, arg.Name, toolArgs["problem"])
+			}
+		}
+	}
+}
@@ -1,14 +1,12 @@
-package main
+package core
 
 import (
 	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -17,8 +15,10 @@ import (
 
 // ChatMessage represents a message in the chat
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []NativeToolCall `json:"tool_calls,omitempty"`   // Assistant tool invocations (native function calling)
+	ToolCallID string           `json:"tool_call_id,omitempty"` // For role "tool": the call this message answers
 }
 
 // Provider interface for LLM backends
@@ -42,6 +42,12 @@ type ChatOptions struct {
 	Temperature float64
 	MaxTokens   int
 	Model       string
+	Tools       []ToolSchema // Tool definitions for native function calling
+
+	// Deterministic sends Temperature even when it is 0, and Seed to the
+	// providers that accept a sampling seed
+	Deterministic bool
+	Seed          int
 }
 
 func normalizeChatOptions(opts ChatOptions) ChatOptions {
@@ -62,8 +68,19 @@ type ProviderConfig struct {
 	Model   string
 }
 
-// NewProvider creates a provider from config
+// NewProvider creates a provider from config, wrapped in the default
+// middleware stack (see defaultProviderMiddleware)
 func NewProvider(cfg ProviderConfig) (Provider, error) {
+	p, err := newHTTPProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return WrapProvider(p, defaultProviderMiddleware()...), nil
+}
+
+// newHTTPProvider creates the provider's API client, which makes one attempt
+// per call and leaves retries and concurrency limits to middleware
+func newHTTPProvider(cfg ProviderConfig) (Provider, error) {
 	// Get global configuration for timeouts
 	config := GetConfig()
 
@@ -138,6 +155,8 @@ func NewProvider(cfg ProviderConfig) (Provider, error) {
 			client:  &http.Client{Timeout: config.TogetherTimeout},
 			name:    "together",
 		}, nil
+	case "mock":
+		return newMockProvider(cfg)
 	default:
 		return nil, fmt.Errorf("unknown provider type: %s", cfg.Type)
 	}
@@ -157,10 +176,42 @@ func NewProviderFromEnv() (Provider, error) {
 		BaseURL: os.Getenv("LLM_BASE_URL"),
 		Model:   os.Getenv("LLM_MODEL"),
 	}
+	applyProviderEnv(&cfg)
 
 	return NewProvider(cfg)
 }
 
+// providerEnvPrefix returns the environment variable prefix of a provider
+// type, or "" for an unknown type
+func providerEnvPrefix(providerType string) string {
+	switch strings.ToLower(providerType) {
+	case "zai", "glm", "zhipu":
+		return "ZAI"
+	case "openai", "anthropic", "groq", "ollama", "deepseek", "openrouter", "together", "mock":
+		return strings.ToUpper(providerType)
+	default:
+		return ""
+	}
+}
+
+// applyProviderEnv lets <PROVIDER>_BASE_URL override LLM_BASE_URL and
+// <PROVIDER>_MODEL supply the model when none was chosen
+func applyProviderEnv(cfg *ProviderConfig) {
+	prefix := providerEnvPrefix(cfg.Type)
+	if prefix == "" {
+		return
+	}
+	if url := os.Getenv(prefix + "_BASE_URL"); url != "" {
+		cfg.BaseURL = url
+	}
+	if cfg.Model == "" {
+		cfg.Model = os.Getenv(prefix + "_MODEL")
+	}
+	if cfg.Model == "" && prefix == "ZAI" {
+		cfg.Model = os.Getenv("GLM_MODEL")
+	}
+}
+
 func detectProviderFromEnv() string {
 	checks := []struct {
 		envKey   string
@@ -210,6 +261,14 @@ func getAPIKeyForProvider(provider string) string {
 	}
 }
 
+// providerName returns p's name, or "" for a nil provider
+func providerName(p Provider) string {
+	if p == nil {
+		return ""
+	}
+	return p.Name()
+}
+
 func withDefault(val, def string) string {
 	if val == "" {
 		return def
@@ -235,15 +294,22 @@ func (p *OpenAIProvider) Name() string {
 	return "openai"
 }
 
+// Model returns the model used when a call does not name one
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
 func (p *OpenAIProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
-	release, err := AcquireLLMSlot(ctx)
+	resp, err := p.chatCompletion(ctx, messages, opts)
 	if err != nil {
 		return "", err
 	}
-	if release != nil {
-		defer release()
-	}
+	return resp.Content, nil
+}
 
+// chatCompletion performs a non-streaming chat completion, returning both the
+// text content and any native tool calls
+func (p *OpenAIProvider) chatCompletion(ctx context.Context, messages []ChatMessage, opts ChatOptions) (*ChatResponse, error) {
 	opts = normalizeChatOptions(opts)
 	model := opts.Model
 	if model == "" {
@@ -254,189 +320,104 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []ChatMessage, opts
 		"model":    model,
 		"messages": messages,
 	}
-	if opts.Temperature > 0 {
+	if opts.Temperature > 0 || opts.Deterministic {
 		reqBody["temperature"] = opts.Temperature
 	}
+	if opts.Deterministic {
+		reqBody["seed"] = opts.Seed
+	}
 	if opts.MaxTokens > 0 {
 		reqBody["max_tokens"] = opts.MaxTokens
 	}
+	if len(opts.Tools) > 0 {
+		reqBody["tools"] = openAIToolDefinitions(opts.Tools)
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	var lastErr error
-	retryCfg := defaultRetryConfig()
-	rateLimitHits := 0
-
-	for attempt := 0; attempt < retryCfg.maxAttempts+retryCfg.rateLimitAttempts; attempt++ {
-		if attempt > 0 {
-			// Use exponential backoff
-			delay := calculateBackoff(attempt-1, retryCfg)
-			select {
-			case <-ctx.Done():
-				return "", ctx.Err()
-			case <-time.After(delay):
-			}
-		}
-
-		// Check if we've exceeded normal retries and only rate limit retries remain
-		if attempt >= retryCfg.maxAttempts && rateLimitHits == 0 {
-			break // No more retries unless we hit rate limits
-		}
-
-		req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
-		if err != nil {
-			return "", fmt.Errorf("failed to create request: %w", err)
-		}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
-		req.Header.Set("Content-Type", "application/json")
-		if p.apiKey != "" {
-			req.Header.Set("Authorization", "Bearer "+p.apiKey)
-		}
-		for k, v := range p.headers {
-			req.Header.Set(k, v)
-		}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
 
-		resp, err := p.client.Do(req)
-		if err != nil {
-			lastErr = err
-			// Retry on connection reset or timeout
-			if isTransientError(err) {
-				continue
-			}
-			return "", fmt.Errorf("request failed: %w", err)
-		}
-		// Ensure response body is closed on all code paths (defer handles return statements,
-		// but continue statements need explicit close to release resources before next iteration)
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			lastErr = err
-			// Close response body before retrying
-			resp.Body.Close()
-			continue
-		}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	observeRateLimits(p.Name(), resp.Header, time.Now())
 
-		if resp.StatusCode != http.StatusOK {
-			if resp.StatusCode == http.StatusTooManyRequests {
-				rateLimitHits++
-				lastErr = fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-				// Close response body before retrying
-				resp.Body.Close()
-				continue
-			}
-			if resp.StatusCode >= 500 {
-				lastErr = fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-				// Close response body before retrying
-				resp.Body.Close()
-				continue
-			}
-			return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-		}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 
-		var chatResp struct {
-			Choices []struct {
-				Message struct {
-					Content          string `json:"content"`
-					ReasoningContent string `json:"reasoning_content,omitempty"` // Some models (e.g., z.ai) use this field
-				} `json:"message"`
-			} `json:"choices"`
-			Error *struct {
-				Message string `json:"message"`
-			} `json:"error,omitempty"`
-		}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, body)
+	}
 
-		if err := json.Unmarshal(body, &chatResp); err != nil {
-			return "", fmt.Errorf("failed to parse response: %w", err)
-		}
+	var chatResp struct {
+		Choices []struct {
+			Message struct {
+				Content          string           `json:"content"`
+				ReasoningContent string           `json:"reasoning_content,omitempty"` // Some models (e.g., z.ai) use this field
+				ToolCalls        []NativeToolCall `json:"tool_calls,omitempty"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
 
-		if chatResp.Error != nil {
-			return "", fmt.Errorf("API error: %s", chatResp.Error.Message)
-		}
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
 
-		if len(chatResp.Choices) == 0 {
-			// Include full response details in error for better debugging
-			var responseSnippet string
-			if len(body) > 500 {
-				responseSnippet = string(body)[:500] + "..."
-			} else {
-				responseSnippet = string(body)
-			}
-			return "", fmt.Errorf("no choices in API response (provider: %s, model: %s, status: %d, body: %s)",
-				p.Name(), model, resp.StatusCode, responseSnippet)
-		}
+	if chatResp.Error != nil {
+		return nil, fmt.Errorf("API error: %s", chatResp.Error.Message)
+	}
 
-		content := chatResp.Choices[0].Message.Content
-		if content == "" {
-			content = chatResp.Choices[0].Message.ReasoningContent
-		}
-		if content == "" {
-			// Empty response - log full body for debugging
-			var responseSnippet string
-			if len(body) > 500 {
-				responseSnippet = string(body)[:500] + "..."
-			} else {
-				responseSnippet = string(body)
-			}
-			return "", fmt.Errorf("empty content in API response (provider: %s, model: %s, status: %d, body: %s)",
-				p.Name(), model, resp.StatusCode, responseSnippet)
+	if len(chatResp.Choices) == 0 {
+		// Include full response details in error for better debugging
+		var responseSnippet string
+		if len(body) > 500 {
+			responseSnippet = string(body)[:500] + "..."
+		} else {
+			responseSnippet = string(body)
 		}
-
-		return content, nil
+		return nil, fmt.Errorf("no choices in API response (provider: %s, model: %s, status: %d, body: %s)",
+			p.Name(), model, resp.StatusCode, responseSnippet)
 	}
 
-	// Note: response body is already closed above on all paths
-	return "", fmt.Errorf("request failed after retries: %w", lastErr)
-}
-
-func isTransientError(err error) bool {
-	if err == nil {
-		return false
-	}
-	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
-		return true
-	}
-	var netErr net.Error
-	if errors.As(err, &netErr) {
-		return netErr.Timeout() || netErr.Temporary()
+	content := chatResp.Choices[0].Message.Content
+	if content == "" {
+		content = chatResp.Choices[0].Message.ReasoningContent
 	}
-	if strings.Contains(err.Error(), "connection reset by peer") ||
-		strings.Contains(err.Error(), "broken pipe") ||
-		strings.Contains(err.Error(), "connection refused") {
-		return true
-	}
-	return false
-}
-
-// retryConfig holds retry settings for API calls
-type retryConfig struct {
-	maxAttempts       int           // Maximum number of attempts
-	baseDelay         time.Duration // Base delay for exponential backoff
-	maxDelay          time.Duration // Maximum delay cap
-	rateLimitAttempts int           // Extra attempts for rate limit errors
-}
-
-// defaultRetryConfig returns default retry settings
-func defaultRetryConfig() retryConfig {
-	return retryConfig{
-		maxAttempts:       3,
-		baseDelay:         2 * time.Second,
-		maxDelay:          30 * time.Second,
-		rateLimitAttempts: 5, // More attempts for rate limits
+	toolCalls := chatResp.Choices[0].Message.ToolCalls
+	if content == "" && len(toolCalls) == 0 {
+		// Empty response - log full body for debugging
+		var responseSnippet string
+		if len(body) > 500 {
+			responseSnippet = string(body)[:500] + "..."
+		} else {
+			responseSnippet = string(body)
+		}
+		return nil, fmt.Errorf("empty content in API response (provider: %s, model: %s, status: %d, body: %s)",
+			p.Name(), model, resp.StatusCode, responseSnippet)
 	}
-}
 
-// calculateBackoff returns the backoff duration for the given attempt.
-// Uses exponential backoff: baseDelay * 2^attempt, capped at maxDelay.
-func calculateBackoff(attempt int, cfg retryConfig) time.Duration {
-	delay := cfg.baseDelay * time.Duration(1<<uint(attempt)) // 2^attempt
-	if delay > cfg.maxDelay {
-		delay = cfg.maxDelay
-	}
-	return delay
+	return &ChatResponse{Content: content, ToolCalls: toolCalls}, nil
 }
 
 // ============ Anthropic Provider ============
@@ -452,15 +433,21 @@ func (p *AnthropicProvider) Name() string {
 	return "anthropic"
 }
 
+// Model returns the model used when a call does not name one
+func (p *AnthropicProvider) Model() string {
+	return p.model
+}
+
 func (p *AnthropicProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
-	release, err := AcquireLLMSlot(ctx)
+	resp, err := p.messagesCompletion(ctx, messages, opts)
 	if err != nil {
 		return "", err
 	}
-	if release != nil {
-		defer release()
-	}
+	return resp.Content, nil
+}
 
+// messagesCompletion calls the Messages API, returning text content and any tool_use blocks
+func (p *AnthropicProvider) messagesCompletion(ctx context.Context, messages []ChatMessage, opts ChatOptions) (*ChatResponse, error) {
 	opts = normalizeChatOptions(opts)
 	model := opts.Model
 	if model == "" {
@@ -468,19 +455,7 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []ChatMessage, op
 	}
 
 	// Convert messages to Anthropic format
-	var systemPrompt string
-	var anthropicMessages []map[string]string
-
-	for _, msg := range messages {
-		if msg.Role == "system" {
-			systemPrompt = msg.Content
-		} else {
-			anthropicMessages = append(anthropicMessages, map[string]string{
-				"role":    msg.Role,
-				"content": msg.Content,
-			})
-		}
-	}
+	systemPrompt, anthropicMessages := toAnthropicMessages(messages)
 
 	reqBody := map[string]interface{}{
 		"model":      model,
@@ -490,18 +465,21 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []ChatMessage, op
 	if systemPrompt != "" {
 		reqBody["system"] = systemPrompt
 	}
-	if opts.Temperature > 0 {
+	if opts.Temperature > 0 || opts.Deterministic {
 		reqBody["temperature"] = opts.Temperature
 	}
+	if len(opts.Tools) > 0 {
+		reqBody["tools"] = anthropicToolDefinitions(opts.Tools)
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/messages", bytes.NewReader(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -510,22 +488,27 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []ChatMessage, op
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	observeRateLimits(p.Name(), resp.Header, time.Now())
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body)
 	}
 
 	var chatResp struct {
 		Content []struct {
-			Text string `json:"text"`
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
 		} `json:"content"`
 		Error *struct {
 			Message string `json:"message"`
@@ -533,18 +516,34 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []ChatMessage, op
 	}
 
 	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if chatResp.Error != nil {
-		return "", fmt.Errorf("API error: %s", chatResp.Error.Message)
+		return nil, fmt.Errorf("API error: %s", chatResp.Error.Message)
 	}
 
 	if len(chatResp.Content) == 0 {
-		return "", fmt.Errorf("no content in response")
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	result := &ChatResponse{}
+	var texts []string
+	for _, block := range chatResp.Content {
+		switch block.Type {
+		case "tool_use":
+			call := NativeToolCall{ID: block.ID, Type: "function"}
+			call.Function.Name = block.Name
+			call.Function.Arguments = string(block.Input)
+			result.ToolCalls = append(result.ToolCalls, call)
+		default:
+			if block.Text != "" {
+				texts = append(texts, block.Text)
+			}
+		}
 	}
-
-	return chatResp.Content[0].Text, nil
+	result.Content = strings.Join(texts, "\n")
+	return result, nil
 }
 
 // ============ Ollama Provider ============
@@ -559,15 +558,12 @@ func (p *OllamaProvider) Name() string {
 	return "ollama"
 }
 
-func (p *OllamaProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
-	release, err := AcquireLLMSlot(ctx)
-	if err != nil {
-		return "", err
-	}
-	if release != nil {
-		defer release()
-	}
+// Model returns the model used when a call does not name one
+func (p *OllamaProvider) Model() string {
+	return p.model
+}
 
+func (p *OllamaProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
 	opts = normalizeChatOptions(opts)
 	model := opts.Model
 	if model == "" {
@@ -579,7 +575,12 @@ func (p *OllamaProvider) Chat(ctx context.Context, messages []ChatMessage, opts
 		"messages": messages,
 		"stream":   false,
 	}
-	if opts.Temperature > 0 {
+	if opts.Deterministic {
+		reqBody["options"] = map[string]interface{}{
+			"temperature": opts.Temperature,
+			"seed":        opts.Seed,
+		}
+	} else if opts.Temperature > 0 {
 		reqBody["options"] = map[string]interface{}{
 			"temperature": opts.Temperature,
 		}
@@ -609,7 +610,7 @@ func (p *OllamaProvider) Chat(ctx context.Context, messages []ChatMessage, opts
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return "", newAPIError(resp, body)
 	}
 
 	var chatResp struct {
@@ -633,6 +634,67 @@ func withDefaultInt(val, def int) int {
 	return val
 }
 
+// ============ Embeddings Support ============
+
+// Embed returns embeddings for texts from an OpenAI-compatible /embeddings endpoint
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"model": model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/embeddings", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, body)
+	}
+
+	var embResp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(embResp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embResp.Data))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
 // ============ Streaming Support ============
 
 // SupportsStreaming returns true for OpenAI-compatible providers
@@ -642,14 +704,6 @@ func (p *OpenAIProvider) SupportsStreaming() bool {
 
 // ChatStream streams tokens from OpenAI-compatible APIs
 func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []ChatMessage, opts ChatOptions, onToken TokenCallback) (string, error) {
-	release, err := AcquireLLMSlot(ctx)
-	if err != nil {
-		return "", err
-	}
-	if release != nil {
-		defer release()
-	}
-
 	opts = normalizeChatOptions(opts)
 	model := opts.Model
 	if model == "" {
@@ -661,9 +715,12 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []ChatMessage,
 		"messages": messages,
 		"stream":   true,
 	}
-	if opts.Temperature > 0 {
+	if opts.Temperature > 0 || opts.Deterministic {
 		reqBody["temperature"] = opts.Temperature
 	}
+	if opts.Deterministic {
+		reqBody["seed"] = opts.Seed
+	}
 	if opts.MaxTokens > 0 {
 		reqBody["max_tokens"] = opts.MaxTokens
 	}
@@ -673,71 +730,31 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []ChatMessage,
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	var lastErr error
-	retryCfg := defaultRetryConfig()
-	rateLimitHits := 0
-
-	for attempt := 0; attempt < retryCfg.maxAttempts+retryCfg.rateLimitAttempts; attempt++ {
-		if attempt > 0 {
-			delay := calculateBackoff(attempt-1, retryCfg)
-			select {
-			case <-ctx.Done():
-				return "", ctx.Err()
-			case <-time.After(delay):
-			}
-		}
-
-		if attempt >= retryCfg.maxAttempts && rateLimitHits == 0 {
-			break
-		}
-
-		req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
-		if err != nil {
-			return "", fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		if p.apiKey != "" {
-			req.Header.Set("Authorization", "Bearer "+p.apiKey)
-		}
-		for k, v := range p.headers {
-			req.Header.Set(k, v)
-		}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
 
-		resp, err := p.client.Do(req)
-		if err != nil {
-			lastErr = err
-			if isTransientError(err) {
-				continue
-			}
-			return "", fmt.Errorf("request failed: %w", err)
-		}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			body, readErr := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			if readErr != nil {
-				lastErr = fmt.Errorf("API error (status %d): failed to read response body: %w", resp.StatusCode, readErr)
-				continue
-			}
-			if resp.StatusCode == http.StatusTooManyRequests {
-				rateLimitHits++
-				lastErr = fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-				continue
-			}
-			if resp.StatusCode >= 500 {
-				lastErr = fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-				continue
-			}
-			return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-		}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	observeRateLimits(p.Name(), resp.Header, time.Now())
 
-		result, parseErr := parseOpenAISSE(resp.Body, onToken)
-		resp.Body.Close()
-		return result, parseErr
+	if resp.StatusCode != http.StatusOK {
+		return "", readAPIError(resp)
 	}
 
-	return "", fmt.Errorf("request failed after retries: %w", lastErr)
+	return parseOpenAISSE(resp.Body, onToken)
 }
 
 // parseOpenAISSE parses OpenAI-style SSE stream
@@ -803,14 +820,6 @@ func (p *AnthropicProvider) SupportsStreaming() bool {
 
 // ChatStream streams tokens from Anthropic API
 func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []ChatMessage, opts ChatOptions, onToken TokenCallback) (string, error) {
-	release, err := AcquireLLMSlot(ctx)
-	if err != nil {
-		return "", err
-	}
-	if release != nil {
-		defer release()
-	}
-
 	opts = normalizeChatOptions(opts)
 	model := opts.Model
 	if model == "" {
@@ -841,7 +850,7 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []ChatMessa
 	if systemPrompt != "" {
 		reqBody["system"] = systemPrompt
 	}
-	if opts.Temperature > 0 {
+	if opts.Temperature > 0 || opts.Deterministic {
 		reqBody["temperature"] = opts.Temperature
 	}
 
@@ -864,13 +873,10 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []ChatMessa
 		return "", fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	observeRateLimits(p.Name(), resp.Header, time.Now())
 
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", fmt.Errorf("API error (status %d): failed to read response body: %w", resp.StatusCode, err)
-		}
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return "", readAPIError(resp)
 	}
 
 	return parseAnthropicSSE(resp.Body, onToken)
@@ -944,14 +950,6 @@ func (p *OllamaProvider) SupportsStreaming() bool {
 
 // ChatStream streams tokens from Ollama API (NDJSON format)
 func (p *OllamaProvider) ChatStream(ctx context.Context, messages []ChatMessage, opts ChatOptions, onToken TokenCallback) (string, error) {
-	release, err := AcquireLLMSlot(ctx)
-	if err != nil {
-		return "", err
-	}
-	if release != nil {
-		defer release()
-	}
-
 	opts = normalizeChatOptions(opts)
 	model := opts.Model
 	if model == "" {
@@ -963,7 +961,12 @@ func (p *OllamaProvider) ChatStream(ctx context.Context, messages []ChatMessage,
 		"messages": messages,
 		"stream":   true,
 	}
-	if opts.Temperature > 0 {
+	if opts.Deterministic {
+		reqBody["options"] = map[string]interface{}{
+			"temperature": opts.Temperature,
+			"seed":        opts.Seed,
+		}
+	} else if opts.Temperature > 0 {
 		reqBody["options"] = map[string]interface{}{
 			"temperature": opts.Temperature,
 		}
@@ -988,11 +991,7 @@ func (p *OllamaProvider) ChatStream(ctx context.Context, messages []ChatMessage,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", fmt.Errorf("API error (status %d): failed to read response body: %w", resp.StatusCode, err)
-		}
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return "", readAPIError(resp)
 	}
 
 	return parseOllamaNDJSON(resp.Body, onToken)
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrLLMCallBudgetExhausted is returned by a call-limited provider once the
+// run has used all of its LLM calls
+var ErrLLMCallBudgetExhausted = errors.New("LLM call budget exhausted")
+
+// ErrRunTimedOut is returned by a call-limited provider once the run is past
+// its timeout_seconds. It wraps ErrLLMCallBudgetExhausted, so strategies stop
+// with a partial result the same way.
+var ErrRunTimedOut = fmt.Errorf("run timed out: %w", ErrLLMCallBudgetExhausted)
+
+// LLMCallUsage reports a run's LLM calls when max_llm_calls is set, whether
+// the run reached timeout_seconds, which
+// providers answered when a fallback chain is configured, which
+// provider/models answered, how many requests were shortened to fit the
+// context window and the seed of a deterministic run. It is embedded in every reasoning result.
+type LLMCallUsage struct {
+	LLMCalls        int            `json:"llm_calls,omitempty"`
+	MaxLLMCalls     int            `json:"max_llm_calls,omitempty"`
+	BudgetExhausted bool           `json:"budget_exhausted,omitempty"` // The result is partial: the run hit max_llm_calls or timeout_seconds
+	TimeoutSeconds  float64        `json:"timeout_seconds,omitempty"`
+	TimedOut        bool           `json:"timed_out,omitempty"`     // The result is partial: the run hit timeout_seconds
+	AnsweredBy      map[string]int `json:"answered_by,omitempty"`   // Calls answered by each provider of a fallback chain
+	ModelUsage      map[string]int `json:"model_usage,omitempty"`   // Calls answered by each "provider/model"
+	ContextTrims    int            `json:"context_trims,omitempty"` // Requests shortened to fit the model's context window
+	Deterministic   bool           `json:"deterministic,omitempty"` // Calls were sent with temperature 0 and Seed
+	Seed            *int           `json:"seed,omitempty"`          // Sampling seed of a deterministic run
+}
+
+// LLMCallCounter enforces a hard cap on LLM calls shared by every provider
+// wrapped with it, so one run cannot exceed the cap however many providers
+// (fallbacks, attempt rotations) it uses. It also ends the run's LLM calls
+// at its timeout deadline.
+type LLMCallCounter struct {
+	mu        sync.Mutex
+	limit     int // 0 = unlimited
+	calls     int
+	exhausted bool
+	timeout   time.Duration
+	deadline  time.Time
+	timedOut  bool
+}
+
+// NewLLMCallCounter creates a counter allowing limit calls (0 = unlimited)
+func NewLLMCallCounter(limit int) *LLMCallCounter {
+	return &LLMCallCounter{limit: limit}
+}
+
+// SetTimeout refuses calls from timeout from now, and cuts off the calls
+// still running then
+func (c *LLMCallCounter) SetTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = timeout
+	c.deadline = time.Now().Add(timeout)
+}
+
+// take reserves one call, failing once the limit or the deadline is reached
+func (c *LLMCallCounter) take() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.deadline.IsZero() && !time.Now().Before(c.deadline) {
+		return c.timeoutErrorLocked()
+	}
+	if c.limit > 0 && c.calls >= c.limit {
+		c.exhausted = true
+		return fmt.Errorf("%w (max_llm_calls=%d)", ErrLLMCallBudgetExhausted, c.limit)
+	}
+	c.calls++
+	return nil
+}
+
+func (c *LLMCallCounter) timeoutErrorLocked() error {
+	c.exhausted, c.timedOut = true, true
+	return fmt.Errorf("%w (timeout_seconds=%v)", ErrRunTimedOut, c.timeout.Seconds())
+}
+
+// timeoutError records that a call was cut off at the deadline
+func (c *LLMCallCounter) timeoutError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.timeoutErrorLocked()
+}
+
+func (c *LLMCallCounter) getDeadline() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadline
+}
+
+// Exhausted reports whether a call was refused. Nil counters are unlimited.
+func (c *LLMCallCounter) Exhausted() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.exhausted
+}
+
+// Usage summarizes the counter for a result. Nil counters report nothing.
+func (c *LLMCallCounter) Usage() LLMCallUsage {
+	if c == nil {
+		return LLMCallUsage{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return LLMCallUsage{
+		LLMCalls:        c.calls,
+		MaxLLMCalls:     c.limit,
+		BudgetExhausted: c.exhausted,
+		TimeoutSeconds:  c.timeout.Seconds(),
+		TimedOut:        c.timedOut,
+	}
+}
+
+// runLLMUsage summarizes a run's LLM calls for its result
+func runLLMUsage(ctx context.Context, counter *LLMCallCounter) LLMCallUsage {
+	usage := counter.Usage()
+	usage.AnsweredBy = providerAnswers(ctx)
+	usage.ModelUsage = modelUsage(ctx)
+	if run := queueRunFromContext(ctx); run != nil {
+		usage.ContextTrims = int(run.contextTrims.Load())
+	}
+	if seed, ok := deterministicSeed(ctx); ok {
+		usage.Deterministic = true
+		usage.Seed = &seed
+	}
+	return usage
+}
+
+// Wrap returns p with every chat call counted against the limit. Embedding
+// requests are not counted as LLM calls.
+func (c *LLMCallCounter) Wrap(p Provider) Provider {
+	if c == nil || p == nil {
+		return p
+	}
+	return WrapProvider(p, WithCallLimit(c))
+}
+
+type llmCallCounterKey struct{}
+
+// llmCallCounterFromContext returns the counter a call was charged to, or nil
+func llmCallCounterFromContext(ctx context.Context) *LLMCallCounter {
+	c, _ := ctx.Value(llmCallCounterKey{}).(*LLMCallCounter)
+	return c
+}
+
+// WithCallLimit counts chat calls against c, refusing them once it runs out
+// or its deadline passes. The rest of the stack finds c in the context, so
+// calls middleware makes on its own (context summaries) are charged too.
+func WithCallLimit(c *LLMCallCounter) ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		if call.Kind == CallEmbed {
+			return next(ctx)
+		}
+		if err := c.take(); err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, llmCallCounterKey{}, c)
+		deadline := c.getDeadline()
+		if deadline.IsZero() {
+			return next(ctx)
+		}
+		callCtx, cancel := context.WithDeadline(ctx, deadline)
+		defer cancel()
+		err := next(callCtx)
+		if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			return c.timeoutError()
+		}
+		return err
+	})
+}
+
+// llmCallCounterFromArgs returns a counter for the run's max_llm_calls,
+// falling back to <TOOL>_MAX_LLM_CALLS and MAX_LLM_CALLS, and its
+// timeout_seconds. It returns nil (unlimited) when neither is configured.
+func llmCallCounterFromArgs(args map[string]interface{}, toolName string) *LLMCallCounter {
+	limit := parseEnvInt(toolEnvKey(toolName, "MAX_LLM_CALLS"), parseEnvInt("MAX_LLM_CALLS", 0))
+	if v, ok := args["max_llm_calls"].(float64); ok {
+		limit = int(v)
+	}
+	if limit < 0 {
+		fmt.Fprintf(os.Stderr, "[WARNING] %s: ignoring negative max_llm_calls %d\n", toolName, limit)
+		limit = 0
+	}
+	timeout := runTimeoutFromArgs(args)
+	if limit == 0 && timeout <= 0 {
+		return nil
+	}
+	counter := NewLLMCallCounter(limit)
+	if timeout > 0 {
+		counter.SetTimeout(timeout)
+	}
+	return counter
+}
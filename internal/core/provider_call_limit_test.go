@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// countProvider answers every call with the same text and counts calls
+type countProvider struct {
+	mu       sync.Mutex
+	calls    int
+	response string
+}
+
+func (p *countProvider) Name() string { return "count" }
+
+func (p *countProvider) Chat(context.Context, []ChatMessage, ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return p.response, nil
+}
+
+func TestLLMCallCounter_EnforcesLimit(t *testing.T) {
+	inner := &countProvider{response: "ok"}
+	counter := NewLLMCallCounter(2)
+	provider := counter.Wrap(inner)
+
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Chat(context.Background(), nil, ChatOptions{}); err != nil {
+			t.Fatalf("call %d: unexpected error %v", i+1, err)
+		}
+	}
+	if counter.Exhausted() {
+		t.Error("Expected counter not to be exhausted before a call is refused")
+	}
+
+	_, err := provider.Chat(context.Background(), nil, ChatOptions{})
+	if !errors.Is(err, ErrLLMCallBudgetExhausted) {
+		t.Fatalf("Expected budget error, got %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected refused call not to reach the provider, got %d calls", inner.calls)
+	}
+	usage := counter.Usage()
+	if usage.LLMCalls != 2 || usage.MaxLLMCalls != 2 || !usage.BudgetExhausted {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+}
+
+func TestLLMCallCounter_SharedAcrossProviders(t *testing.T) {
+	counter := NewLLMCallCounter(1)
+	a, b := counter.Wrap(&countProvider{}), counter.Wrap(&countProvider{})
+
+	if _, err := a.Chat(context.Background(), nil, ChatOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := b.Chat(context.Background(), nil, ChatOptions{}); !errors.Is(err, ErrLLMCallBudgetExhausted) {
+		t.Errorf("Expected the second provider to share the budget, got %v", err)
+	}
+}
+
+func TestLLMCallCounter_PreservesEmbeddings(t *testing.T) {
+	counter := NewLLMCallCounter(1)
+	if _, ok := counter.Wrap(&vectorProvider{}).(EmbeddingProvider); !ok {
+		t.Error("Expected wrapped embedding provider to keep Embed")
+	}
+	if _, ok := counter.Wrap(&countProvider{}).(EmbeddingProvider); ok {
+		t.Error("Expected wrapper not to claim embeddings the provider lacks")
+	}
+
+	var nilCounter *LLMCallCounter
+	inner := &countProvider{}
+	if nilCounter.Wrap(inner) != Provider(inner) || nilCounter.Exhausted() {
+		t.Error("Expected a nil counter to be unlimited")
+	}
+}
+
+func TestLLMCallCounterFromArgs(t *testing.T) {
+	t.Setenv("MAX_LLM_CALLS", "40")
+	t.Setenv("GRAPH_OF_THOUGHTS_MAX_LLM_CALLS", "")
+
+	if c := llmCallCounterFromArgs(map[string]interface{}{}, "graph_of_thoughts"); c == nil || c.limit != 40 {
+		t.Errorf("Expected global env default of 40, got %+v", c)
+	}
+	t.Setenv("GRAPH_OF_THOUGHTS_MAX_LLM_CALLS", "25")
+	if c := llmCallCounterFromArgs(map[string]interface{}{}, "graph_of_thoughts"); c == nil || c.limit != 25 {
+		t.Errorf("Expected per-tool env of 25, got %+v", c)
+	}
+	if c := llmCallCounterFromArgs(map[string]interface{}{"max_llm_calls": float64(5)}, "graph_of_thoughts"); c == nil || c.limit != 5 {
+		t.Errorf("Expected parameter to win, got %+v", c)
+	}
+	if c := llmCallCounterFromArgs(map[string]interface{}{"max_llm_calls": float64(0)}, "graph_of_thoughts"); c != nil {
+		t.Errorf("Expected 0 to disable the cap, got %+v", c)
+	}
+}
+
+func TestGoT_StopsAtLLMCallBudget(t *testing.T) {
+	inner := &countProvider{response: `["Consider the first case", "Consider the second case"]`}
+	counter := NewLLMCallCounter(7)
+	config := DefaultGoTConfig()
+	config.MaxNodes = 500
+
+	result, err := NewGraphOfThoughts(counter.Wrap(inner), config).Solve(context.Background(), "Explore the cases")
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if inner.calls > 7 {
+		t.Errorf("Expected at most 7 LLM calls, got %d", inner.calls)
+	}
+	last := result.Decisions[len(result.Decisions)-1]
+	if last.Type != "stop" || last.Reason != GoTReasonLLMCallBudget {
+		t.Errorf("Expected stop decision %q, got %+v", GoTReasonLLMCallBudget, last)
+	}
+	if !counter.Exhausted() || result.TotalNodes < 2 {
+		t.Errorf("Expected a partial graph and an exhausted budget, got %d nodes", result.TotalNodes)
+	}
+}
+
+func TestSequential_ReturnsPartialResultAtLLMCallBudget(t *testing.T) {
+	inner := &countProvider{response: `{"thought_number": 1, "total_thoughts": 5, "thought": "keep going", "next_thought_needed": true}`}
+	counter := NewLLMCallCounter(3)
+	client := &SequentialClient{provider: counter.Wrap(inner)}
+
+	result, err := client.Think(context.Background(), "Think about it", 10)
+	if err != nil {
+		t.Fatalf("Think failed: %v", err)
+	}
+	if len(result.Steps) != 3 || result.Success {
+		t.Errorf("Expected 3 steps and no success, got %d steps (success=%v)", len(result.Steps), result.Success)
+	}
+}
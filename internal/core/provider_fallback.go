@@ -0,0 +1,297 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FallbackProvider tries multiple providers in order until one succeeds.
+// Providers whose circuit breaker is open are skipped while another
+// provider is available, and the run records which provider answered.
+type FallbackProvider struct {
+	providers []Provider
+}
+
+// NewFallbackProvider chains providers. The chain has an embeddings endpoint
+// when any of them does.
+func NewFallbackProvider(providers []Provider) Provider {
+	if len(providers) == 0 {
+		return nil
+	}
+	if len(providers) == 1 {
+		return providers[0]
+	}
+	chain := &FallbackProvider{providers: providers}
+	for _, p := range providers {
+		if _, ok := p.(EmbeddingProvider); ok {
+			return &fallbackEmbeddingProvider{FallbackProvider: chain}
+		}
+	}
+	return chain
+}
+
+// fallbackChain returns the fallback chain under p's middleware, or nil
+func fallbackChain(p Provider) *FallbackProvider {
+	switch chain := unwrapProvider(p).(type) {
+	case *FallbackProvider:
+		return chain
+	case *fallbackEmbeddingProvider:
+		return chain.FallbackProvider
+	}
+	return nil
+}
+
+func (f *FallbackProvider) Name() string {
+	if len(f.providers) == 0 {
+		return "fallback"
+	}
+	return f.providers[0].Name()
+}
+
+func (f *FallbackProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	var resp string
+	err := f.each(ctx, nil, func(p Provider) error {
+		var err error
+		resp, err = p.Chat(ctx, messages, opts)
+		return err
+	})
+	return resp, err
+}
+
+func (f *FallbackProvider) SupportsStreaming() bool {
+	return true
+}
+
+func (f *FallbackProvider) ChatStream(ctx context.Context, messages []ChatMessage, opts ChatOptions, onToken TokenCallback) (string, error) {
+	var resp string
+	err := f.each(ctx, nil, func(p Provider) error {
+		var err error
+		if sp, ok := p.(StreamingProvider); ok && sp.SupportsStreaming() {
+			resp, err = sp.ChatStream(ctx, messages, opts, onToken)
+		} else {
+			resp, err = p.Chat(ctx, messages, opts)
+		}
+		return err
+	})
+	return resp, err
+}
+
+// fallbackEmbeddingProvider is a fallback chain with embeddings
+type fallbackEmbeddingProvider struct {
+	*FallbackProvider
+}
+
+// Embed tries the providers with an embeddings endpoint, in order
+func (f *fallbackEmbeddingProvider) Embed(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	var vectors [][]float64
+	embeds := func(p Provider) bool {
+		_, ok := p.(EmbeddingProvider)
+		return ok
+	}
+	err := f.each(ctx, embeds, func(p Provider) error {
+		var err error
+		vectors, err = p.(EmbeddingProvider).Embed(ctx, texts, model)
+		return err
+	})
+	return vectors, err
+}
+
+// each calls call with the eligible providers in order until one succeeds.
+// Providers with an open circuit are put off until the end, so they are
+// only tried when every other provider has failed.
+func (f *FallbackProvider) each(ctx context.Context, eligible func(Provider) bool, call func(Provider) error) error {
+	var errs []string
+	try := func(p Provider) (bool, error) {
+		breaker := providerBreaker(p.Name())
+		err := call(p)
+		if err == nil {
+			breaker.success()
+			noteProviderAnswer(ctx, p.Name())
+			if p != f.providers[0] {
+				noteFallbackAnswer(ctx)
+			}
+			return true, nil
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrLLMCallBudgetExhausted) {
+			breaker.abandon()
+			return true, err // Not the provider's fault, and no other provider would do better
+		}
+		breaker.failure(time.Now())
+		errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+		return false, nil
+	}
+
+	var open []Provider
+	for _, p := range f.providers {
+		if eligible != nil && !eligible(p) {
+			continue
+		}
+		if !providerBreaker(p.Name()).allow(time.Now()) {
+			open = append(open, p)
+			continue
+		}
+		if done, err := try(p); done {
+			return err
+		}
+	}
+	for _, p := range open {
+		if done, err := try(p); done {
+			return err
+		}
+	}
+	return fmt.Errorf("all providers failed: %s", strings.Join(errs, "; "))
+}
+
+// ============ Circuit Breaker ============
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Requests flow normally
+	CircuitOpen     = "open"      // Failing; skipped until the cooldown passes
+	CircuitHalfOpen = "half_open" // Cooldown passed; one probe request decides
+)
+
+// circuitBreaker tracks consecutive failures of one provider. After
+// threshold failures in a row it opens for cooldown, then lets one probe
+// through: success closes it, failure opens it again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int // 0 disables the breaker
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// allow reports whether a request may go to the provider now
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false // One probe at a time
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// success closes the breaker
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != CircuitClosed {
+		fmt.Fprintf(os.Stderr, "[CIRCUIT] %s: closed after a successful probe\n", b.name)
+	}
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// abandon ends a probe that neither succeeded nor failed, e.g. on cancellation
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// failure counts a failed request, opening the breaker at the threshold or
+// when a probe fails
+func (b *circuitBreaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.threshold <= 0 {
+		return
+	}
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		if b.state != CircuitOpen {
+			fmt.Fprintf(os.Stderr, "[CIRCUIT] %s: open after %d consecutive failures; skipping it for %v\n", b.name, b.failures, b.cooldown)
+		}
+		b.state = CircuitOpen
+		b.openedAt = now
+	}
+}
+
+// State returns the breaker's current state
+func (b *circuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+var (
+	providerBreakers   = make(map[string]*circuitBreaker)
+	providerBreakersMu sync.Mutex
+)
+
+// providerBreaker returns the shared breaker of a provider, configured by
+// PROVIDER_BREAKER_THRESHOLD and PROVIDER_BREAKER_COOLDOWN
+func providerBreaker(name string) *circuitBreaker {
+	providerBreakersMu.Lock()
+	defer providerBreakersMu.Unlock()
+	b, ok := providerBreakers[name]
+	if !ok {
+		cfg := GetConfig()
+		b = &circuitBreaker{name: name, threshold: cfg.BreakerThreshold, cooldown: cfg.BreakerCooldown, state: CircuitClosed}
+		providerBreakers[name] = b
+	}
+	return b
+}
+
+// providerCircuitState returns a provider's breaker state, or "" when no
+// fallback chain has used the provider yet
+func providerCircuitState(name string) string {
+	providerBreakersMu.Lock()
+	b, ok := providerBreakers[name]
+	providerBreakersMu.Unlock()
+	if !ok {
+		return ""
+	}
+	return b.State()
+}
+
+// openProviderCircuits returns each provider whose breaker is not closed,
+// with the time left until it lets a probe through
+func openProviderCircuits(now time.Time) map[string]time.Duration {
+	providerBreakersMu.Lock()
+	breakers := make([]*circuitBreaker, 0, len(providerBreakers))
+	for _, b := range providerBreakers {
+		breakers = append(breakers, b)
+	}
+	providerBreakersMu.Unlock()
+
+	open := make(map[string]time.Duration)
+	for _, b := range breakers {
+		b.mu.Lock()
+		if b.state != CircuitClosed {
+			open[b.name] = max(b.cooldown-now.Sub(b.openedAt), 0)
+		}
+		b.mu.Unlock()
+	}
+	return open
+}
+
+// resetProviderBreakers forgets every breaker, e.g. after a config reload
+func resetProviderBreakers() {
+	providerBreakersMu.Lock()
+	defer providerBreakersMu.Unlock()
+	providerBreakers = make(map[string]*circuitBreaker)
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails while down is set
+type flakyProvider struct {
+	name  string
+	down  bool
+	calls int
+}
+
+func (p *flakyProvider) Name() string { return p.name }
+
+func (p *flakyProvider) Chat(context.Context, []ChatMessage, ChatOptions) (string, error) {
+	p.calls++
+	if p.down {
+		return "", errors.New("connection refused")
+	}
+	return p.name + " answer", nil
+}
+
+func TestFallbackProvider_CircuitBreaker(t *testing.T) {
+	t.Setenv("PROVIDER_BREAKER_THRESHOLD", "2")
+	ResetConfig()
+	t.Cleanup(ResetConfig)
+
+	primary := &flakyProvider{name: "test-primary", down: true}
+	backup := &flakyProvider{name: "test-backup"}
+	fallback := NewFallbackProvider([]Provider{primary, backup})
+	run := &queueRun{}
+	ctx := context.WithValue(context.Background(), queueRunKey{}, run)
+
+	for i := 0; i < 4; i++ {
+		if resp, err := fallback.Chat(ctx, nil, ChatOptions{}); err != nil || resp != "test-backup answer" {
+			t.Fatalf("call %d: got %q, %v", i, resp, err)
+		}
+	}
+	// The primary is skipped once its breaker opens after two failures
+	if primary.calls != 2 {
+		t.Errorf("Expected the open breaker to skip the primary, got %d calls", primary.calls)
+	}
+	if state := providerCircuitState("test-primary"); state != CircuitOpen {
+		t.Errorf("Expected an open breaker, got %q", state)
+	}
+	if answers := providerAnswers(ctx); answers["test-backup"] != 4 || len(answers) != 1 {
+		t.Errorf("Expected 4 answers from the backup, got %v", answers)
+	}
+
+	// An open provider is still the last resort when the others fail
+	backup.down = true
+	if _, err := fallback.Chat(ctx, nil, ChatOptions{}); err == nil || primary.calls != 3 {
+		t.Errorf("Expected the open primary tried last, got %d calls (%v)", primary.calls, err)
+	}
+	backup.down = false
+
+	// After the cooldown one probe goes through and closes the breaker
+	breaker := providerBreaker("test-primary")
+	breaker.mu.Lock()
+	breaker.openedAt = time.Now().Add(-time.Hour)
+	breaker.mu.Unlock()
+	primary.down = false
+	if resp, _ := fallback.Chat(ctx, nil, ChatOptions{}); resp != "test-primary answer" {
+		t.Errorf("Expected the probe to reach the primary, got %q", resp)
+	}
+	if state := breaker.State(); state != CircuitClosed {
+		t.Errorf("Expected a closed breaker after the probe, got %q", state)
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	now := time.Now()
+	b := &circuitBreaker{name: "test", threshold: 1, cooldown: time.Minute, state: CircuitClosed}
+	b.failure(now)
+	if b.allow(now) {
+		t.Error("Expected an open breaker to refuse requests")
+	}
+	later := now.Add(2 * time.Minute)
+	if !b.allow(later) || b.allow(later) {
+		t.Error("Expected exactly one probe once the cooldown passes")
+	}
+	b.failure(later)
+	if b.State() != CircuitOpen || b.allow(later.Add(time.Second)) {
+		t.Error("Expected a failed probe to reopen the breaker")
+	}
+
+	disabled := &circuitBreaker{name: "test", threshold: 0, state: CircuitClosed}
+	for i := 0; i < 10; i++ {
+		disabled.failure(now)
+	}
+	if !disabled.allow(now) {
+		t.Error("Expected threshold 0 to disable the breaker")
+	}
+}
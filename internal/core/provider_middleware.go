@@ -0,0 +1,347 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Provider Middleware ============
+//
+// Cross-cutting provider behavior (retries, concurrency slots, call limits,
+// model attribution, logging, response caching) is written once as an
+// Interceptor and stacked around any Provider with WrapProvider:
+//
+//	p = WrapProvider(client, WithRetry(), WithProviderSlots(), WithLogging(os.Stderr))
+//
+// The wrapped provider keeps the streaming, tool-calling and embedding
+// capabilities of the one inside, so middleware never has to re-implement
+// them. NewProvider applies defaultProviderMiddleware to every client.
+
+// Kinds of ProviderCall
+const (
+	CallChat   = "chat"
+	CallStream = "stream"
+	CallTools  = "tools"
+	CallEmbed  = "embed"
+)
+
+// ProviderCall is one call passing through provider middleware. Middleware
+// may change the request fields before calling next, and reads or sets the
+// result fields; setting a result without calling next answers the call.
+type ProviderCall struct {
+	Kind     string // CallChat, CallStream, CallTools or CallEmbed
+	Provider string // Name of the wrapped provider
+	Model    string // Opts.Model, or the provider's default model
+
+	Messages []ChatMessage
+	Opts     ChatOptions
+	OnToken  TokenCallback // Stream calls
+	Texts    []string      // Embed calls
+	EmbedFor string        // Embedding model of embed calls
+
+	Content  string        // Chat and stream result
+	Response *ChatResponse // Tools result
+	Vectors  [][]float64   // Embed result
+	Streamed bool          // A stream has already passed tokens to OnToken
+}
+
+// ProviderNext runs the rest of the stack for a call
+type ProviderNext func(ctx context.Context) error
+
+// Interceptor handles a call, usually by calling next
+type Interceptor func(ctx context.Context, call *ProviderCall, next ProviderNext) error
+
+// ProviderMiddleware wraps a provider with one behavior
+type ProviderMiddleware func(Provider) Provider
+
+// WrapProvider applies middleware to p; the first middleware is the outermost
+func WrapProvider(p Provider, middleware ...ProviderMiddleware) Provider {
+	if p == nil {
+		return nil
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		p = middleware[i](p)
+	}
+	return p
+}
+
+// Intercept turns an Interceptor into middleware
+func Intercept(intercept Interceptor) ProviderMiddleware {
+	return func(p Provider) Provider {
+		decorated := &DecoratedProvider{inner: p, intercept: intercept}
+		if embedder, ok := p.(EmbeddingProvider); ok {
+			return &decoratedEmbeddingProvider{DecoratedProvider: decorated, embedder: embedder}
+		}
+		return decorated
+	}
+}
+
+// DecoratedProvider runs every call of the wrapped provider through an Interceptor
+type DecoratedProvider struct {
+	inner     Provider
+	intercept Interceptor
+}
+
+// Unwrap returns the wrapped provider
+func (p *DecoratedProvider) Unwrap() Provider {
+	return p.inner
+}
+
+// unwrapProvider returns the provider client under every middleware layer
+func unwrapProvider(p Provider) Provider {
+	for {
+		u, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			return p
+		}
+		p = u.Unwrap()
+	}
+}
+
+func (p *DecoratedProvider) Name() string {
+	return p.inner.Name()
+}
+
+func (p *DecoratedProvider) newCall(kind string, messages []ChatMessage, opts ChatOptions) *ProviderCall {
+	return &ProviderCall{
+		Kind:     kind,
+		Provider: p.inner.Name(),
+		Model:    withDefault(opts.Model, defaultModel(p.inner)),
+		Messages: messages,
+		Opts:     opts,
+	}
+}
+
+func (p *DecoratedProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	call := p.newCall(CallChat, messages, opts)
+	err := p.intercept(ctx, call, func(ctx context.Context) error {
+		var err error
+		call.Content, err = p.inner.Chat(ctx, call.Messages, call.Opts)
+		return err
+	})
+	return call.Content, err
+}
+
+func (p *DecoratedProvider) SupportsStreaming() bool {
+	sp, ok := p.inner.(StreamingProvider)
+	return ok && sp.SupportsStreaming()
+}
+
+func (p *DecoratedProvider) ChatStream(ctx context.Context, messages []ChatMessage, opts ChatOptions, onToken TokenCallback) (string, error) {
+	sp, ok := p.inner.(StreamingProvider)
+	if !ok {
+		return p.Chat(ctx, messages, opts)
+	}
+	call := p.newCall(CallStream, messages, opts)
+	call.OnToken = onToken
+	err := p.intercept(ctx, call, func(ctx context.Context) error {
+		var err error
+		call.Content, err = sp.ChatStream(ctx, call.Messages, call.Opts, func(token string) {
+			call.Streamed = true
+			if call.OnToken != nil {
+				call.OnToken(token)
+			}
+		})
+		return err
+	})
+	return call.Content, err
+}
+
+func (p *DecoratedProvider) SupportsToolCalling() bool {
+	tp, ok := p.inner.(ToolCallingProvider)
+	return ok && tp.SupportsToolCalling()
+}
+
+func (p *DecoratedProvider) ChatWithTools(ctx context.Context, messages []ChatMessage, opts ChatOptions) (*ChatResponse, error) {
+	tp, ok := p.inner.(ToolCallingProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support tool calling", p.inner.Name())
+	}
+	call := p.newCall(CallTools, messages, opts)
+	err := p.intercept(ctx, call, func(ctx context.Context) error {
+		var err error
+		call.Response, err = tp.ChatWithTools(ctx, call.Messages, call.Opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Response, nil
+}
+
+type decoratedEmbeddingProvider struct {
+	*DecoratedProvider
+	embedder EmbeddingProvider
+}
+
+func (p *decoratedEmbeddingProvider) Embed(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	call := &ProviderCall{Kind: CallEmbed, Provider: p.inner.Name(), Model: model, Texts: texts, EmbedFor: model}
+	err := p.intercept(ctx, call, func(ctx context.Context) error {
+		var err error
+		call.Vectors, err = p.embedder.Embed(ctx, call.Texts, call.EmbedFor)
+		return err
+	})
+	return call.Vectors, err
+}
+
+// ============ Middleware ============
+
+// defaultProviderMiddleware is the stack NewProvider puts around every
+// provider client, outermost first. Each retry attempt takes its own
+// concurrency slot, so backoff waits do not hold one.
+func defaultProviderMiddleware() []ProviderMiddleware {
+	// The language instruction, document chunks and deterministic sampling
+	// options are part of the request, so they go outside tracing and the
+	// cache, and the context window check sees them. Tracing records cached
+	// answers too.
+	middleware := []ProviderMiddleware{WithLanguage(), WithDocuments(), WithDeterminism(), WithContextWindow(), WithTracing()}
+	if cache := getResponseCache(); cache != nil {
+		middleware = append(middleware, WithResponseCache(cache))
+	}
+	middleware = append(middleware, WithModelAttribution())
+	// Replay stands in for the provider; recording sees each call's final
+	// outcome after retries
+	if dir := os.Getenv("LLM_REPLAY_DIR"); dir != "" {
+		return append(middleware, WithReplay(dir))
+	}
+	if dir := os.Getenv("LLM_RECORD_DIR"); dir != "" {
+		middleware = append(middleware, WithRecording(dir))
+	}
+	middleware = append(middleware, WithRetry(), WithProviderSlots())
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("LLM_LOG"))) {
+	case "true", "1", "on":
+		middleware = append(middleware, WithLogging(os.Stderr))
+	}
+	return middleware
+}
+
+// WithProviderSlots holds a concurrency slot of the provider (see
+// AcquireProviderSlot) for the duration of each call
+func WithProviderSlots() ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		release, err := AcquireProviderSlot(ctx, call.Provider)
+		if err != nil {
+			return err
+		}
+		if release != nil {
+			defer release()
+		}
+		return next(ctx)
+	})
+}
+
+// WithModelAttribution records the provider/model that answered each chat
+// call (see noteModelCall)
+func WithModelAttribution() ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		err := next(ctx)
+		if err == nil && call.Kind != CallEmbed {
+			noteModelCall(ctx, call.Provider, call.Model)
+		}
+		return err
+	})
+}
+
+// WithLogging writes one line per call to w with its provider/model, kind,
+// latency and outcome
+func WithLogging(w io.Writer) ProviderMiddleware {
+	var mu sync.Mutex
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		start := time.Now()
+		err := next(ctx)
+		outcome := "ok"
+		if err != nil {
+			outcome = "error: " + err.Error()
+		}
+		mu.Lock()
+		fmt.Fprintf(w, "[LLM] %s/%s %s %dms %s\n", call.Provider, call.Model, call.Kind, time.Since(start).Milliseconds(), utils.TruncateStr(outcome, 200))
+		mu.Unlock()
+		return err
+	})
+}
+
+// WithResponseCache answers repeated chat, stream and tool calls with
+// identical messages and options from cache instead of the provider. A
+// cached stream is replayed as a single token.
+func WithResponseCache(cache *ToolCache) ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		if call.Kind == CallEmbed {
+			return next(ctx)
+		}
+		key := responseCacheKey(call)
+		if cached, ok := cache.Get(key); ok {
+			if call.Kind == CallTools {
+				var resp ChatResponse
+				if json.Unmarshal([]byte(cached), &resp) == nil {
+					call.Response = &resp
+					return nil
+				}
+			} else {
+				call.Content = cached
+				if call.Kind == CallStream && call.OnToken != nil {
+					call.OnToken(cached)
+				}
+				return nil
+			}
+		}
+
+		if err := next(ctx); err != nil {
+			return err
+		}
+		if call.Kind == CallTools {
+			if data, err := json.Marshal(call.Response); err == nil {
+				cache.Set(key, string(data))
+			}
+		} else {
+			cache.Set(key, call.Content)
+		}
+		return nil
+	})
+}
+
+// responseCacheKey identifies a call's request; streamed and plain chat
+// calls share entries
+func responseCacheKey(call *ProviderCall) string {
+	kind := call.Kind
+	if kind == CallStream {
+		kind = CallChat
+	}
+	opts := call.Opts
+	opts.Model = call.Model
+	payload, _ := json.Marshal(struct {
+		Kind     string
+		Provider string
+		Messages []ChatMessage
+		Opts     ChatOptions
+	}{kind, call.Provider, call.Messages, opts})
+	return fmt.Sprintf("llm:%x", sha256.Sum256(payload))
+}
+
+var (
+	responseCache     *ToolCache
+	responseCacheOnce sync.Once
+)
+
+// getResponseCache returns the provider response cache when
+// LLM_RESPONSE_CACHE_TTL (seconds) is positive
+func getResponseCache() *ToolCache {
+	responseCacheOnce.Do(func() {
+		ttlSeconds := parseEnvInt("LLM_RESPONSE_CACHE_TTL", 0)
+		if ttlSeconds <= 0 {
+			return
+		}
+		ttl := time.Duration(ttlSeconds) * time.Second
+		responseCache = NewToolCache(ttl, parseEnvInt("LLM_RESPONSE_CACHE_MAX", defaultToolCacheMaxEntries))
+		responseCache.shared = redisCacheFromEnv(ttl)
+	})
+	return responseCache
+}
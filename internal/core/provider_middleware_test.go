@@ -0,0 +1,111 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingMiddleware appends its name to order before and after each call
+func recordingMiddleware(name string, order *[]string) ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		*order = append(*order, name+">")
+		err := next(ctx)
+		*order = append(*order, "<"+name)
+		return err
+	})
+}
+
+func TestWrapProvider_Order(t *testing.T) {
+	var order []string
+	p := WrapProvider(&countProvider{response: "ok"}, recordingMiddleware("a", &order), recordingMiddleware("b", &order))
+
+	if resp, err := p.Chat(context.Background(), nil, ChatOptions{}); err != nil || resp != "ok" {
+		t.Fatalf("got %q, %v", resp, err)
+	}
+	if got := strings.Join(order, " "); got != "a> b> <b <a" {
+		t.Errorf("Expected the first middleware outermost, got %s", got)
+	}
+	if unwrapProvider(p).Name() != "count" {
+		t.Errorf("Expected unwrapProvider to reach the client, got %s", unwrapProvider(p).Name())
+	}
+}
+
+func TestWrapProvider_KeepsCapabilities(t *testing.T) {
+	var order []string
+	streaming := WrapProvider(&brokenStream{}, recordingMiddleware("a", &order))
+	if sp, ok := streaming.(StreamingProvider); !ok || !sp.SupportsStreaming() {
+		t.Error("Expected a wrapped streaming provider to stream")
+	}
+	if _, ok := streaming.(EmbeddingProvider); ok {
+		t.Error("Expected a provider without embeddings to stay without them")
+	}
+
+	plain := WrapProvider(&countProvider{response: "ok"}, recordingMiddleware("a", &order))
+	if sp := plain.(StreamingProvider); sp.SupportsStreaming() {
+		t.Error("Expected a provider without streaming to report no streaming")
+	}
+	if tp := plain.(ToolCallingProvider); tp.SupportsToolCalling() {
+		t.Error("Expected a provider without tools to report no tool calling")
+	}
+
+	embedding := WrapProvider(&OpenAIProvider{}, recordingMiddleware("a", &order))
+	if _, ok := embedding.(EmbeddingProvider); !ok {
+		t.Error("Expected a wrapped embedding provider to embed")
+	}
+}
+
+// streamingCountProvider streams countProvider's response as one token
+type streamingCountProvider struct {
+	countProvider
+}
+
+func (p *streamingCountProvider) SupportsStreaming() bool { return true }
+
+func (p *streamingCountProvider) ChatStream(ctx context.Context, messages []ChatMessage, opts ChatOptions, onToken TokenCallback) (string, error) {
+	resp, err := p.Chat(ctx, messages, opts)
+	onToken(resp)
+	return resp, err
+}
+
+func TestWithResponseCache(t *testing.T) {
+	inner := &streamingCountProvider{countProvider{response: "cached answer"}}
+	p := WrapProvider(inner, WithResponseCache(NewToolCache(time.Minute, 10)))
+	messages := []ChatMessage{{Role: "user", Content: "What is 17 * 23?"}}
+
+	for i := 0; i < 2; i++ {
+		if resp, err := p.Chat(context.Background(), messages, ChatOptions{}); err != nil || resp != "cached answer" {
+			t.Fatalf("call %d: got %q, %v", i+1, resp, err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected the repeat served from cache, got %d calls", inner.calls)
+	}
+
+	var tokens []string
+	resp, err := p.(StreamingProvider).ChatStream(context.Background(), messages, ChatOptions{}, func(token string) { tokens = append(tokens, token) })
+	if err != nil || resp != "cached answer" || len(tokens) != 1 || inner.calls != 1 {
+		t.Errorf("Expected the stream replayed from cache, got %q, %v, tokens %q, %d calls", resp, err, tokens, inner.calls)
+	}
+
+	if _, err := p.Chat(context.Background(), messages, ChatOptions{Temperature: 0.9}); err != nil {
+		t.Fatal(err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected different options to miss the cache, got %d calls", inner.calls)
+	}
+}
+
+func TestWithLogging(t *testing.T) {
+	var buf bytes.Buffer
+	p := WrapProvider(&countProvider{response: "ok"}, WithLogging(&buf))
+
+	if _, err := p.Chat(context.Background(), nil, ChatOptions{Model: "m1"}); err != nil {
+		t.Fatal(err)
+	}
+	if line := buf.String(); !strings.HasPrefix(line, "[LLM] count/m1 chat ") || !strings.HasSuffix(line, " ok\n") {
+		t.Errorf("Unexpected log line %q", line)
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ============ Retries ============
+//
+// Provider clients make one attempt per call and report HTTP failures as
+// *APIError. WithRetry, part of every provider built by NewProvider,
+// retries 429s, 5xx responses and transient network errors with jittered
+// backoff, waiting for the reset a 429 asks for when it names one.
+
+// APIError is a non-200 response from a provider API
+type APIError struct {
+	StatusCode int
+	Body       string
+	Header     http.Header
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// newAPIError builds the error for a non-200 response whose body was read
+func newAPIError(resp *http.Response, body []byte) error {
+	return &APIError{StatusCode: resp.StatusCode, Body: string(body), Header: resp.Header}
+}
+
+// readAPIError reads a non-200 response's body into an APIError
+func readAPIError(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &APIError{StatusCode: resp.StatusCode, Body: "failed to read response body: " + err.Error(), Header: resp.Header}
+	}
+	return newAPIError(resp, body)
+}
+
+// WithRetry retries failed calls per the provider's retryConfig. A stream
+// is only retried while no token has been emitted, so callers never see a
+// reply twice.
+func WithRetry() ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		return retryCall(ctx, call.Provider, func() error {
+			err := next(ctx)
+			if err != nil && call.Streamed {
+				return finalError{err} // A retry would repeat the tokens already sent
+			}
+			return err
+		})
+	})
+}
+
+// finalError marks an error that must not be retried
+type finalError struct {
+	err error
+}
+
+func (e finalError) Error() string { return e.err.Error() }
+func (e finalError) Unwrap() error { return e.err }
+
+// retryCall runs call until it succeeds or fails for good. 5xx responses and
+// transient network errors get maxAttempts tries; a 429 unlocks
+// rateLimitAttempts more and waits for the reset it names, failing at once
+// when that is past ctx's deadline.
+func retryCall(ctx context.Context, name string, call func() error) error {
+	cfg := retryConfigFor(name)
+	var lastErr error
+	rateLimitHits := 0
+	var retryAfter time.Duration // Wait a 429 asked for, replacing the backoff once
+
+	for attempt := 0; attempt < cfg.maxAttempts+cfg.rateLimitAttempts; attempt++ {
+		if attempt > 0 {
+			if attempt >= cfg.maxAttempts && rateLimitHits == 0 {
+				break // Only rate-limited requests get the extra attempts
+			}
+			delay := calculateBackoff(attempt-1, cfg)
+			if retryAfter > 0 {
+				delay, retryAfter = retryAfter, 0
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		err := call()
+		if err == nil {
+			return nil
+		}
+		var final finalError
+		if errors.As(err, &final) {
+			return final.err
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+			rateLimitHits++
+			NoteRateLimited(name)
+			wait, hinted, waitErr := rateLimitRetryDelay(ctx, name, apiErr.Header, cfg.jitter)
+			if waitErr != nil {
+				return fmt.Errorf("%w: %v", waitErr, err)
+			}
+			if hinted {
+				retryAfter = wait
+			}
+		case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		case ctx.Err() == nil && isTransientError(err):
+		default:
+			return err
+		}
+	}
+	return fmt.Errorf("request failed after retries: %w", lastErr)
+}
+
+// isTransientError reports network failures worth retrying
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+	if strings.Contains(err.Error(), "connection reset by peer") ||
+		strings.Contains(err.Error(), "broken pipe") ||
+		strings.Contains(err.Error(), "connection refused") {
+		return true
+	}
+	return false
+}
+
+// retryConfig holds retry settings for API calls
+type retryConfig struct {
+	maxAttempts       int           // Maximum number of attempts
+	baseDelay         time.Duration // Base delay for exponential backoff
+	maxDelay          time.Duration // Maximum delay cap
+	rateLimitAttempts int           // Extra attempts for rate limit errors
+	jitter            float64       // Fraction of each delay randomized away, so parallel branches spread out
+}
+
+// defaultRetryConfig returns default retry settings
+func defaultRetryConfig() retryConfig {
+	return retryConfig{
+		maxAttempts:       3,
+		baseDelay:         2 * time.Second,
+		maxDelay:          30 * time.Second,
+		rateLimitAttempts: 5, // More attempts for rate limits
+		jitter:            0.5,
+	}
+}
+
+// calculateBackoff returns the backoff duration for the given attempt.
+// Uses exponential backoff: baseDelay * 2^attempt, capped at maxDelay, then
+// shortened by a random share of up to jitter.
+func calculateBackoff(attempt int, cfg retryConfig) time.Duration {
+	delay := cfg.maxDelay
+	if attempt < 32 {
+		delay = min(cfg.baseDelay*time.Duration(1<<uint(attempt)), cfg.maxDelay) // 2^attempt
+	}
+	if cfg.jitter > 0 && delay > 0 {
+		delay -= time.Duration(rand.Float64() * cfg.jitter * float64(delay))
+	}
+	return delay
+}
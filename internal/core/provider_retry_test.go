@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// anthropicReply is a Messages API response, whole or as SSE
+func anthropicReply(w http.ResponseWriter, stream bool, text string) {
+	if !stream {
+		w.Write([]byte(`{"content": [{"type": "text", "text": "` + text + `"}]}`))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Write([]byte("event: content_block_delta\ndata: {\"delta\": {\"type\": \"text_delta\", \"text\": \"" + text + "\"}}\n\nevent: message_stop\ndata: {}\n\n"))
+}
+
+func TestWithRetry_Anthropic(t *testing.T) {
+	t.Setenv("LLM_RETRY_BASE_DELAY_MS", "1")
+	ResetConfig()
+	t.Cleanup(ResetConfig)
+
+	var mu sync.Mutex
+	var statuses []int // Errors to send before answering
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		calls++
+		var status int
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		mu.Unlock()
+		if status != 0 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"type": "error"}`, status)
+			return
+		}
+		anthropicReply(w, strings.Contains(string(body), `"stream":true`), "pong")
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(ProviderConfig{Type: "anthropic", APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	messages := []ChatMessage{{Role: "user", Content: "ping"}}
+
+	statuses = []int{http.StatusTooManyRequests, 529}
+	if resp, err := p.Chat(ctx, messages, ChatOptions{}); err != nil || resp != "pong" || calls != 3 {
+		t.Errorf("Chat: got %q, %v after %d calls; want pong after 3", resp, err, calls)
+	}
+
+	calls, statuses = 0, []int{http.StatusServiceUnavailable}
+	var tokens []string
+	resp, err := p.(StreamingProvider).ChatStream(ctx, messages, ChatOptions{}, func(token string) { tokens = append(tokens, token) })
+	if err != nil || resp != "pong" || calls != 2 || len(tokens) != 1 {
+		t.Errorf("ChatStream: got %q, %v, tokens %q after %d calls", resp, err, tokens, calls)
+	}
+
+	calls, statuses = 0, []int{http.StatusBadRequest}
+	if _, err := p.Chat(ctx, messages, ChatOptions{}); err == nil || !strings.Contains(err.Error(), "status 400") || calls != 1 {
+		t.Errorf("Expected a 400 to fail without retries, got %v after %d calls", err, calls)
+	}
+}
+
+// brokenStream emits a token, then loses the connection
+type brokenStream struct {
+	countProvider
+	streams int
+}
+
+func (p *brokenStream) SupportsStreaming() bool { return true }
+
+func (p *brokenStream) ChatStream(_ context.Context, _ []ChatMessage, _ ChatOptions, onToken TokenCallback) (string, error) {
+	p.streams++
+	onToken("partial")
+	return "partial", io.ErrUnexpectedEOF
+}
+
+func TestWithRetry_StreamNotRepeated(t *testing.T) {
+	t.Setenv("LLM_RETRY_BASE_DELAY_MS", "1")
+	ResetConfig()
+	t.Cleanup(ResetConfig)
+
+	inner := &brokenStream{}
+	p := WrapProvider(inner, WithRetry()).(StreamingProvider)
+	var tokens []string
+	if _, err := p.ChatStream(context.Background(), nil, ChatOptions{}, func(token string) { tokens = append(tokens, token) }); err == nil {
+		t.Error("Expected the stream error")
+	}
+	if inner.streams != 1 || len(tokens) != 1 {
+		t.Errorf("Expected no retry once tokens were sent, got %d streams and tokens %q", inner.streams, tokens)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package core
 
 import (
 	"os"
@@ -392,3 +392,28 @@ func TestProviderName(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateBackoff(t *testing.T) {
+	cfg := retryConfig{baseDelay: time.Second, maxDelay: 5 * time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if got := calculateBackoff(attempt, cfg); got != want {
+			t.Errorf("attempt %d: got %v, want %v", attempt, got, want)
+		}
+	}
+	if got := calculateBackoff(100, cfg); got != 5*time.Second {
+		t.Errorf("Expected large attempts capped, got %v", got)
+	}
+
+	cfg.jitter = 0.5
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		got := calculateBackoff(2, cfg)
+		if got < 2*time.Second || got > 4*time.Second {
+			t.Fatalf("Jittered delay %v outside [2s, 4s]", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("Expected jitter to vary the delay")
+	}
+}
@@ -0,0 +1,269 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ToolSchema describes a tool offered to the model for native function calling
+type ToolSchema struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"` // JSON schema of the arguments object
+}
+
+// NativeToolCall is a tool invocation returned by the provider (OpenAI wire format)
+type NativeToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"` // always "function"
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded arguments object
+	} `json:"function"`
+}
+
+// ChatResponse is a chat completion that may contain native tool calls
+type ChatResponse struct {
+	Content   string
+	ToolCalls []NativeToolCall
+}
+
+// ToolCallingProvider is implemented by providers that support native function calling
+type ToolCallingProvider interface {
+	Provider
+	ChatWithTools(ctx context.Context, messages []ChatMessage, opts ChatOptions) (*ChatResponse, error)
+	SupportsToolCalling() bool
+}
+
+// nativeToolCaller returns the provider as a ToolCallingProvider when native
+// function calling is available and not disabled via NATIVE_TOOL_CALLING=false
+func nativeToolCaller(p Provider) (ToolCallingProvider, bool) {
+	if nativeToolCallingOff("NATIVE_TOOL_CALLING") {
+		return nil, false
+	}
+	tp, ok := p.(ToolCallingProvider)
+	if !ok || !tp.SupportsToolCalling() {
+		return nil, false
+	}
+	return tp, true
+}
+
+// ToToolCall converts a native tool call into the registry's ToolCall form
+func (c NativeToolCall) ToToolCall() ToolCall {
+	call := ToolCall{Tool: c.Function.Name}
+	var args struct {
+		Input  string `json:"input"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(c.Function.Arguments), &args); err == nil {
+		call.Input = args.Input
+		call.Reason = args.Reason
+	} else {
+		// Some models send the bare input instead of an arguments object
+		call.Input = c.Function.Arguments
+	}
+	return call
+}
+
+// toolResultMessage builds the tool-role message that answers a native tool call
+func toolResultMessage(call NativeToolCall, result ToolResult) ChatMessage {
+	content := result.Output
+	if !result.Success {
+		content = fmt.Sprintf("Error: %s", result.Error)
+	}
+	return ChatMessage{Role: "tool", ToolCallID: call.ID, Content: content}
+}
+
+// nativeToolCallingOff reports whether the environment variable key turns
+// native tool calling off
+func nativeToolCallingOff(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "false", "0", "off":
+		return true
+	}
+	return false
+}
+
+// providerToolCallingOff reports whether <PROVIDER>_NATIVE_TOOL_CALLING turns
+// native tool calling off for the named provider, e.g. GROQ_NATIVE_TOOL_CALLING=false
+func providerToolCallingOff(name string) bool {
+	prefix := providerEnvPrefix(name)
+	return prefix != "" && nativeToolCallingOff(prefix+"_NATIVE_TOOL_CALLING")
+}
+
+// ============ OpenAI ============
+
+// toolsRejected holds the "baseURL|model" of OpenAI-compatible models whose
+// API refused a request's tool definitions
+var toolsRejected sync.Map
+
+// SupportsToolCalling returns true for OpenAI-compatible providers, unless
+// <PROVIDER>_NATIVE_TOOL_CALLING is false or the API has rejected the tools
+// of the provider's model
+func (p *OpenAIProvider) SupportsToolCalling() bool {
+	if providerToolCallingOff(p.Name()) {
+		return false
+	}
+	_, rejected := toolsRejected.Load(p.baseURL + "|" + p.model)
+	return !rejected
+}
+
+// ChatWithTools performs a chat completion with tool definitions attached.
+// Not every model behind an OpenAI-compatible API takes tools: when the API
+// rejects them, the request is resent without tools and the model marked, so
+// later runs use prompt-based tools instead.
+func (p *OpenAIProvider) ChatWithTools(ctx context.Context, messages []ChatMessage, opts ChatOptions) (*ChatResponse, error) {
+	resp, err := p.chatCompletion(ctx, messages, opts)
+	if err == nil || len(opts.Tools) == 0 || !isToolsRejection(err) {
+		return resp, err
+	}
+	model := withDefault(opts.Model, p.model)
+	toolsRejected.Store(p.baseURL+"|"+model, true)
+	fmt.Fprintf(os.Stderr, "[WARNING] %s/%s rejected native tool calls, using prompt-based tools from now on: %v\n", p.Name(), model, err)
+	opts.Tools = nil
+	return p.chatCompletion(ctx, messages, opts)
+}
+
+// isToolsRejection reports whether an API refused a request for its tool
+// definitions, as opposed to failing it for another reason
+func isToolsRejection(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity:
+		body := strings.ToLower(apiErr.Body)
+		return strings.Contains(body, "tool") || strings.Contains(body, "function call")
+	}
+	return false
+}
+
+func openAIToolDefinitions(tools []ToolSchema) []map[string]interface{} {
+	defs := make([]map[string]interface{}, 0, len(tools))
+	for _, t := range tools {
+		defs = append(defs, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        t.Name,
+				"description": t.Description,
+				"parameters":  t.Parameters,
+			},
+		})
+	}
+	return defs
+}
+
+// ============ Anthropic ============
+
+// SupportsToolCalling returns true for Anthropic, unless
+// ANTHROPIC_NATIVE_TOOL_CALLING is false
+func (p *AnthropicProvider) SupportsToolCalling() bool {
+	return !providerToolCallingOff(p.Name())
+}
+
+// ChatWithTools performs a Messages API call with tool definitions attached
+func (p *AnthropicProvider) ChatWithTools(ctx context.Context, messages []ChatMessage, opts ChatOptions) (*ChatResponse, error) {
+	return p.messagesCompletion(ctx, messages, opts)
+}
+
+func anthropicToolDefinitions(tools []ToolSchema) []map[string]interface{} {
+	defs := make([]map[string]interface{}, 0, len(tools))
+	for _, t := range tools {
+		defs = append(defs, map[string]interface{}{
+			"name":         t.Name,
+			"description":  t.Description,
+			"input_schema": t.Parameters,
+		})
+	}
+	return defs
+}
+
+// toAnthropicMessages converts chat messages to the Messages API format. Assistant
+// tool calls become tool_use blocks and consecutive tool results are grouped into
+// a single user message of tool_result blocks, as the API requires.
+func toAnthropicMessages(messages []ChatMessage) (string, []map[string]interface{}) {
+	var systemPrompt string
+	var out []map[string]interface{}
+
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "system":
+			systemPrompt = msg.Content
+		case msg.Role == "tool":
+			block := map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": msg.ToolCallID,
+				"content":     msg.Content,
+			}
+			if n := len(out); n > 0 && out[n-1]["role"] == "user" {
+				if blocks, ok := out[n-1]["content"].([]map[string]interface{}); ok {
+					out[n-1]["content"] = append(blocks, block)
+					continue
+				}
+			}
+			out = append(out, map[string]interface{}{
+				"role":    "user",
+				"content": []map[string]interface{}{block},
+			})
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			var blocks []map[string]interface{}
+			if msg.Content != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, map[string]interface{}{
+					"type":  "tool_use",
+					"id":    call.ID,
+					"name":  call.Function.Name,
+					"input": input,
+				})
+			}
+			out = append(out, map[string]interface{}{"role": "assistant", "content": blocks})
+		default:
+			out = append(out, map[string]interface{}{"role": msg.Role, "content": msg.Content})
+		}
+	}
+
+	return systemPrompt, out
+}
+
+// ============ Fallback ============
+
+// SupportsToolCalling returns true if any wrapped provider supports native tool calls
+func (f *FallbackProvider) SupportsToolCalling() bool {
+	for _, p := range f.providers {
+		if tp, ok := p.(ToolCallingProvider); ok && tp.SupportsToolCalling() {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatWithTools tries the providers that support native tool calls, in order
+func (f *FallbackProvider) ChatWithTools(ctx context.Context, messages []ChatMessage, opts ChatOptions) (*ChatResponse, error) {
+	var resp *ChatResponse
+	supportsTools := func(p Provider) bool {
+		tp, ok := p.(ToolCallingProvider)
+		return ok && tp.SupportsToolCalling()
+	}
+	err := f.each(ctx, supportsTools, func(p Provider) error {
+		var err error
+		resp, err = p.(ToolCallingProvider).ChatWithTools(ctx, messages, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
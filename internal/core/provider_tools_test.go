@@ -0,0 +1,164 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIChatWithTools_ParsesToolCalls(t *testing.T) {
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"","tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"calculator","arguments":"{\"input\":\"17*23\",\"reason\":\"check\"}"}}
+		]}}]}`))
+	}))
+	defer server.Close()
+
+	p := &OpenAIProvider{baseURL: server.URL, model: "test-model", client: server.Client()}
+	registry := NewToolRegistry()
+	registry.SetEnabled([]string{"calculator"})
+
+	resp, err := p.ChatWithTools(context.Background(), []ChatMessage{{Role: "user", Content: "What is 17*23?"}}, ChatOptions{
+		Tools: registry.GetToolSchemas(),
+	})
+	if err != nil {
+		t.Fatalf("ChatWithTools failed: %v", err)
+	}
+
+	tools, ok := gotBody["tools"].([]interface{})
+	if !ok || len(tools) != 1 {
+		t.Fatalf("Expected one tool definition in request, got %v", gotBody["tools"])
+	}
+	fn := tools[0].(map[string]interface{})["function"].(map[string]interface{})
+	if fn["name"] != "calculator" {
+		t.Errorf("Expected calculator tool definition, got %v", fn["name"])
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+	call := resp.ToolCalls[0].ToToolCall()
+	if call.Tool != "calculator" || call.Input != "17*23" || call.Reason != "check" {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+func TestNativeToolCall_ToToolCallBareInput(t *testing.T) {
+	var nc NativeToolCall
+	nc.Function.Name = "string_ops"
+	nc.Function.Arguments = "len:hello"
+
+	call := nc.ToToolCall()
+	if call.Tool != "string_ops" || call.Input != "len:hello" {
+		t.Errorf("Expected bare arguments to be used as input, got %+v", call)
+	}
+}
+
+func TestToAnthropicMessages_ToolBlocks(t *testing.T) {
+	var nc1, nc2 NativeToolCall
+	nc1.ID, nc1.Function.Name, nc1.Function.Arguments = "tu_1", "calculator", `{"input":"2+2"}`
+	nc2.ID, nc2.Function.Name, nc2.Function.Arguments = "tu_2", "calculator", `not json`
+
+	system, msgs := toAnthropicMessages([]ChatMessage{
+		{Role: "system", Content: "be careful"},
+		{Role: "user", Content: "add things"},
+		{Role: "assistant", Content: "let me compute", ToolCalls: []NativeToolCall{nc1, nc2}},
+		{Role: "tool", ToolCallID: "tu_1", Content: "4"},
+		{Role: "tool", ToolCallID: "tu_2", Content: "Error: bad input"},
+	})
+
+	if system != "be careful" {
+		t.Errorf("Expected system prompt to be extracted, got %q", system)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("Expected 3 messages (user, assistant, grouped tool results), got %d", len(msgs))
+	}
+
+	assistant := msgs[1]["content"].([]map[string]interface{})
+	if len(assistant) != 3 || assistant[1]["type"] != "tool_use" || assistant[1]["id"] != "tu_1" {
+		t.Errorf("Unexpected assistant blocks: %v", assistant)
+	}
+	if string(assistant[2]["input"].(json.RawMessage)) != "{}" {
+		t.Errorf("Expected invalid arguments to be replaced with {}, got %s", assistant[2]["input"])
+	}
+
+	results := msgs[2]["content"].([]map[string]interface{})
+	if msgs[2]["role"] != "user" || len(results) != 2 || results[1]["tool_use_id"] != "tu_2" {
+		t.Errorf("Expected tool results grouped into one user message, got %v", msgs[2])
+	}
+}
+
+func TestNativeToolCaller_EnvToggle(t *testing.T) {
+	p := &OpenAIProvider{}
+
+	t.Setenv("NATIVE_TOOL_CALLING", "")
+	if _, ok := nativeToolCaller(p); !ok {
+		t.Error("Expected native tool calling to be enabled by default for OpenAI")
+	}
+
+	t.Setenv("NATIVE_TOOL_CALLING", "false")
+	if _, ok := nativeToolCaller(p); ok {
+		t.Error("Expected NATIVE_TOOL_CALLING=false to disable native tool calling")
+	}
+
+	t.Setenv("NATIVE_TOOL_CALLING", "")
+	if _, ok := nativeToolCaller(&OllamaProvider{}); ok {
+		t.Error("Expected Ollama to fall back to prompt-based tool calls")
+	}
+
+	// Each provider can be opted out on its own
+	t.Setenv("GROQ_NATIVE_TOOL_CALLING", "false")
+	if _, ok := nativeToolCaller(&OpenAIProvider{name: "groq"}); ok {
+		t.Error("Expected GROQ_NATIVE_TOOL_CALLING=false to disable native tool calling for groq")
+	}
+	if _, ok := nativeToolCaller(&OpenAIProvider{name: "deepseek"}); !ok {
+		t.Error("Expected other providers to keep native tool calling")
+	}
+}
+
+func TestOpenAIChatWithTools_FallsBackWhenToolsRejected(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		if body["tools"] != nil {
+			http.Error(w, `{"error":{"message":"tools is not supported for this model"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"391"}}]}`))
+	}))
+	defer server.Close()
+
+	p := &OpenAIProvider{baseURL: server.URL, model: "no-tools-model", client: server.Client(), name: "together"}
+	if !p.SupportsToolCalling() {
+		t.Fatal("Expected tool calling before any rejection")
+	}
+	registry := NewToolRegistry()
+	registry.SetEnabled([]string{"calculator"})
+	resp, err := p.ChatWithTools(context.Background(), []ChatMessage{{Role: "user", Content: "What is 17*23?"}}, ChatOptions{Tools: registry.GetToolSchemas()})
+	if err != nil || resp.Content != "391" {
+		t.Fatalf("Expected the request resent without tools, got %v (%v)", resp, err)
+	}
+	if len(bodies) != 2 || bodies[1]["tools"] != nil {
+		t.Errorf("Expected a second request without tools, got %d requests", len(bodies))
+	}
+	if p.SupportsToolCalling() {
+		t.Error("Expected the model to use prompt-based tools after the rejection")
+	}
+
+	// Other failures are not mistaken for a rejection of tools
+	if isToolsRejection(&APIError{StatusCode: http.StatusBadRequest, Body: "maximum context length is 8192 tokens"}) || isToolsRejection(&APIError{StatusCode: http.StatusInternalServerError, Body: "tool server down"}) {
+		t.Error("Expected only 4xx errors about tools to count as rejections")
+	}
+}
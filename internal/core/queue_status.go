@@ -0,0 +1,346 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Run Queue Visibility ============
+//
+// Every LLM request is tracked from the moment it asks the FIFO limiter for
+// a slot until it releases it, tagged with its provider and the tool call
+// (run) that issued it. queue_status reports the snapshot.
+
+// queueRun identifies one tool call
+type queueRun struct {
+	ID        string
+	Tool      string
+	SessionID string
+	Started   time.Time
+	llmCalls  atomic.Int64 // LLM requests that got a slot
+
+	answersMu sync.Mutex
+	answers   map[string]int // Calls answered by each provider of a fallback chain
+
+	models modelCalls // Calls answered by each provider/model
+
+	contextTrims atomic.Int64 // Requests shortened to fit the context window
+}
+
+type queueRunKey struct{}
+
+var queueRunSeq atomic.Uint64
+
+// queueRunFromContext returns the run of the current tool call, or nil
+func queueRunFromContext(ctx context.Context) *queueRun {
+	run, _ := ctx.Value(queueRunKey{}).(*queueRun)
+	return run
+}
+
+// noteProviderAnswer records that a fallback chain's provider answered a
+// call of the current run
+func noteProviderAnswer(ctx context.Context, provider string) {
+	run := queueRunFromContext(ctx)
+	if run == nil {
+		return
+	}
+	run.answersMu.Lock()
+	defer run.answersMu.Unlock()
+	if run.answers == nil {
+		run.answers = make(map[string]int)
+	}
+	run.answers[provider]++
+}
+
+// providerAnswers returns the calls each fallback-chain provider answered
+// in the current run, or nil when the run used no fallback chain
+func providerAnswers(ctx context.Context) map[string]int {
+	run := queueRunFromContext(ctx)
+	if run == nil {
+		return nil
+	}
+	run.answersMu.Lock()
+	defer run.answersMu.Unlock()
+	if len(run.answers) == 0 {
+		return nil
+	}
+	answers := make(map[string]int, len(run.answers))
+	for provider, n := range run.answers {
+		answers[provider] = n
+	}
+	return answers
+}
+
+// queueRunMiddleware tags each tool call with a run for queue_status
+func queueRunMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		run := &queueRun{
+			ID:      fmt.Sprintf("run-%d", queueRunSeq.Add(1)),
+			Tool:    request.Params.Name,
+			Started: time.Now(),
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			run.SessionID = session.SessionID()
+		}
+		llmQueue.startRun(run)
+		defer llmQueue.endRun(run)
+		return next(context.WithValue(ctx, queueRunKey{}, run), request)
+	}
+}
+
+// llmRequest is one LLM request waiting for or holding a limiter slot
+type llmRequest struct {
+	seq      uint64
+	provider string
+	run      *queueRun
+	enqueued time.Time
+	started  time.Time // Zero while queued
+}
+
+type llmQueueTracker struct {
+	mu       sync.Mutex
+	seq      uint64
+	requests map[uint64]*llmRequest
+	runs     map[*queueRun]bool
+}
+
+var llmQueue = &llmQueueTracker{
+	requests: make(map[uint64]*llmRequest),
+	runs:     make(map[*queueRun]bool),
+}
+
+func (t *llmQueueTracker) startRun(run *queueRun) {
+	t.mu.Lock()
+	t.runs[run] = true
+	t.mu.Unlock()
+}
+
+func (t *llmQueueTracker) endRun(run *queueRun) {
+	t.mu.Lock()
+	delete(t.runs, run)
+	t.mu.Unlock()
+}
+
+func (t *llmQueueTracker) enqueue(provider string, run *queueRun) *llmRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq++
+	req := &llmRequest{seq: t.seq, provider: provider, run: run, enqueued: time.Now()}
+	t.requests[req.seq] = req
+	return req
+}
+
+func (t *llmQueueTracker) start(req *llmRequest) {
+	t.mu.Lock()
+	req.started = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *llmQueueTracker) remove(req *llmRequest) {
+	t.mu.Lock()
+	delete(t.requests, req.seq)
+	t.mu.Unlock()
+}
+
+// AcquireProviderSlot is AcquireLLMSlot for a named provider, using its
+// adaptive limiter when enabled, then a cluster-wide slot when
+// REDIS_LLM_MAX_CONCURRENT is set; the request is visible to queue_status
+// while it waits and runs
+func AcquireProviderSlot(ctx context.Context, provider string) (func(), error) {
+	req := llmQueue.enqueue(provider, queueRunFromContext(ctx))
+	var release func()
+	var err error
+	if limiter := getAdaptiveLimiter(provider); limiter != nil {
+		release, err = limiter.acquire(ctx)
+	} else {
+		release, err = AcquireLLMSlot(ctx)
+	}
+	if err != nil {
+		llmQueue.remove(req)
+		return nil, err
+	}
+	if cluster := clusterLLMSlots(); cluster != nil {
+		local := release
+		clusterRelease, err := cluster.acquire(ctx)
+		if err != nil {
+			local()
+			llmQueue.remove(req)
+			return nil, err
+		}
+		release = func() {
+			clusterRelease()
+			local()
+		}
+	}
+	llmQueue.start(req)
+	if req.run != nil {
+		req.run.llmCalls.Add(1)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			release()
+			llmQueue.remove(req)
+		})
+	}, nil
+}
+
+// QueueStatus is the output of queue_status
+type QueueStatus struct {
+	MaxConcurrent int                   `json:"max_concurrent"` // 0 = unlimited or adaptive
+	InFlight      int                   `json:"in_flight"`
+	Queued        int                   `json:"queued"`
+	OldestWaitMs  int64                 `json:"oldest_wait_ms"`
+	Providers     []ProviderQueueStatus `json:"providers"`
+	Runs          []RunQueueStatus      `json:"runs"`
+	Adaptive      []AdaptiveLimitStatus `json:"adaptive,omitempty"`    // Per-provider limits under adaptive concurrency
+	RateLimits    []ProviderRateLimit   `json:"rate_limits,omitempty"` // Last rate-limit headers each provider sent
+	WorkQueue     *WorkQueueStatus      `json:"work_queue,omitempty"`  // Runs queued for workers, on api and worker processes
+}
+
+type ProviderQueueStatus struct {
+	Provider     string `json:"provider"`
+	InFlight     int    `json:"in_flight"`
+	Queued       int    `json:"queued"`
+	OldestWaitMs int64  `json:"oldest_wait_ms,omitempty"`
+	Limit        int    `json:"limit,omitempty"` // Current adaptive limit
+}
+
+type RunQueueStatus struct {
+	RunID          string `json:"run_id"`
+	Tool           string `json:"tool"`
+	SessionID      string `json:"session_id,omitempty"`
+	CurrentSession bool   `json:"current_session,omitempty"`
+	ElapsedMs      int64  `json:"elapsed_ms"`
+	InFlight       int    `json:"in_flight"`
+	Queued         int    `json:"queued"`
+	Position       int    `json:"position,omitempty"` // 1-based queue position of the run's oldest waiting request
+	WaitMs         int64  `json:"wait_ms,omitempty"`  // Wait of that request so far
+}
+
+// snapshot reports the tracker state; exclude is left out of the runs
+func (t *llmQueueTracker) snapshot(now time.Time, exclude *queueRun) QueueStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var queued []*llmRequest
+	providers := make(map[string]*ProviderQueueStatus)
+	runs := make(map[*queueRun]*RunQueueStatus)
+	for run := range t.runs {
+		if run != exclude {
+			runs[run] = &RunQueueStatus{
+				RunID:     run.ID,
+				Tool:      run.Tool,
+				SessionID: run.SessionID,
+				ElapsedMs: now.Sub(run.Started).Milliseconds(),
+			}
+		}
+	}
+
+	status := QueueStatus{Providers: []ProviderQueueStatus{}, Runs: []RunQueueStatus{}}
+	for _, req := range t.requests {
+		name := req.provider
+		if name == "" {
+			name = "unknown"
+		}
+		p := providers[name]
+		if p == nil {
+			p = &ProviderQueueStatus{Provider: name}
+			providers[name] = p
+		}
+		r := runs[req.run]
+		if req.started.IsZero() {
+			queued = append(queued, req)
+			status.Queued++
+			p.Queued++
+			if wait := now.Sub(req.enqueued).Milliseconds(); wait > p.OldestWaitMs {
+				p.OldestWaitMs = wait
+			}
+			if r != nil {
+				r.Queued++
+			}
+		} else {
+			status.InFlight++
+			p.InFlight++
+			if r != nil {
+				r.InFlight++
+			}
+		}
+	}
+
+	sort.Slice(queued, func(i, j int) bool { return queued[i].seq < queued[j].seq })
+	for i, req := range queued {
+		if i == 0 {
+			status.OldestWaitMs = now.Sub(req.enqueued).Milliseconds()
+		}
+		if r := runs[req.run]; r != nil && r.Position == 0 {
+			r.Position = i + 1
+			r.WaitMs = now.Sub(req.enqueued).Milliseconds()
+		}
+	}
+
+	for _, p := range providers {
+		status.Providers = append(status.Providers, *p)
+	}
+	sort.Slice(status.Providers, func(i, j int) bool { return status.Providers[i].Provider < status.Providers[j].Provider })
+	for _, r := range runs {
+		status.Runs = append(status.Runs, *r)
+	}
+	sort.Slice(status.Runs, func(i, j int) bool {
+		a, b := status.Runs[i], status.Runs[j]
+		if (a.Position == 0) != (b.Position == 0) {
+			return a.Position != 0
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ElapsedMs > b.ElapsedMs
+	})
+	return status
+}
+
+func handleQueueStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	status := llmQueue.snapshot(time.Now(), queueRunFromContext(ctx))
+	cfg := GetConfig()
+	status.MaxConcurrent = cfg.MaxConcurrentLLMRequests
+	if cfg.AdaptiveConcurrency {
+		status.MaxConcurrent = 0
+		status.Adaptive = adaptiveLimitStatuses()
+		limits := make(map[string]int, len(status.Adaptive))
+		for _, a := range status.Adaptive {
+			limits[a.Provider] = a.Limit
+		}
+		for i := range status.Providers {
+			status.Providers[i].Limit = limits[status.Providers[i].Provider]
+		}
+	}
+
+	status.RateLimits = rateLimitStatuses()
+	if serverRole != roleAll {
+		if q := getWorkQueue(); q != nil {
+			work := q.Status(ctx)
+			status.WorkQueue = &work
+		}
+	}
+
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		for i := range status.Runs {
+			status.Runs[i].CurrentSession = status.Runs[i].SessionID == session.SessionID()
+		}
+	}
+
+	output, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize queue status: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestQueueStatus_ReportsProvidersAndPositions(t *testing.T) {
+	ResetConfig()
+	os.Setenv("LLM_MAX_CONCURRENT", "1")
+	defer func() {
+		os.Unsetenv("LLM_MAX_CONCURRENT")
+		ResetConfig()
+	}()
+	LoadConfig()
+
+	holder := &queueRun{ID: "run-a", Tool: "graph_of_thoughts", Started: time.Now()}
+	waiter := &queueRun{ID: "run-b", Tool: "reflexion", Started: time.Now()}
+	llmQueue.startRun(holder)
+	llmQueue.startRun(waiter)
+	defer llmQueue.endRun(holder)
+	defer llmQueue.endRun(waiter)
+
+	release, err := AcquireProviderSlot(context.WithValue(context.Background(), queueRunKey{}, holder), "openai")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		r, _ := AcquireProviderSlot(context.WithValue(context.Background(), queueRunKey{}, waiter), "anthropic")
+		acquired <- r
+	}()
+
+	var status QueueStatus
+	deadline := time.Now().Add(time.Second)
+	for status.Queued == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		res, _ := handleQueueStatus(context.Background(), mcp.CallToolRequest{})
+		status = QueueStatus{}
+		if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &status); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+	}
+
+	if status.MaxConcurrent != 1 || status.InFlight != 1 || status.Queued != 1 {
+		t.Fatalf("Expected one in-flight and one queued request, got %+v", status)
+	}
+	if len(status.Providers) != 2 || status.Providers[0].Provider != "anthropic" || status.Providers[0].Queued != 1 || status.Providers[1].InFlight != 1 {
+		t.Errorf("Unexpected provider breakdown: %+v", status.Providers)
+	}
+	if len(status.Runs) != 2 || status.Runs[0].RunID != "run-b" || status.Runs[0].Position != 1 || status.Runs[1].InFlight != 1 {
+		t.Errorf("Expected the waiting run first at position 1, got %+v", status.Runs)
+	}
+
+	release()
+	release() // Releasing twice must not free a second slot
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("Queued request should run after release")
+	}
+
+	if s := llmQueue.snapshot(time.Now(), nil); s.InFlight != 0 || s.Queued != 0 {
+		t.Errorf("Expected the queue to drain, got %+v", s)
+	}
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ Provider Rate-Limit Headers ============
+//
+// OpenAI-compatible APIs report their rate limits in response headers
+// (x-ratelimit-*) and say when to come back after a 429 (Retry-After).
+// Retries wait for the indicated reset instead of backing off blindly, and
+// queue_status reports the last state each provider sent.
+
+// ProviderRateLimit is the last rate-limit state a provider reported
+type ProviderRateLimit struct {
+	Provider          string     `json:"provider"`
+	LimitRequests     int        `json:"limit_requests,omitempty"`
+	RemainingRequests *int       `json:"remaining_requests,omitempty"`
+	ResetRequests     *time.Time `json:"reset_requests,omitempty"`
+	LimitTokens       int        `json:"limit_tokens,omitempty"`
+	RemainingTokens   *int       `json:"remaining_tokens,omitempty"`
+	ResetTokens       *time.Time `json:"reset_tokens,omitempty"`
+	RateLimitedUntil  *time.Time `json:"rate_limited_until,omitempty"` // From the last 429's Retry-After or reset headers
+	RateLimitHits     int        `json:"rate_limit_hits"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+var (
+	providerRateLimits   = make(map[string]*ProviderRateLimit)
+	providerRateLimitsMu sync.Mutex
+)
+
+// observeRateLimits records the rate-limit headers of a provider response
+func observeRateLimits(provider string, h http.Header, now time.Time) {
+	limitReq, hasLimitReq := headerInt(h, "X-Ratelimit-Limit-Requests")
+	remainingReq, hasRemainingReq := headerInt(h, "X-Ratelimit-Remaining-Requests")
+	limitTok, hasLimitTok := headerInt(h, "X-Ratelimit-Limit-Tokens")
+	remainingTok, hasRemainingTok := headerInt(h, "X-Ratelimit-Remaining-Tokens")
+	resetReq, hasResetReq := parseRateLimitReset(h.Get("X-Ratelimit-Reset-Requests"), now)
+	resetTok, hasResetTok := parseRateLimitReset(h.Get("X-Ratelimit-Reset-Tokens"), now)
+	if !hasLimitReq && !hasRemainingReq && !hasLimitTok && !hasRemainingTok && !hasResetReq && !hasResetTok {
+		return
+	}
+
+	providerRateLimitsMu.Lock()
+	defer providerRateLimitsMu.Unlock()
+	state := rateLimitStateLocked(provider)
+	state.UpdatedAt = now
+	if hasLimitReq {
+		state.LimitRequests = limitReq
+	}
+	if hasRemainingReq {
+		state.RemainingRequests = &remainingReq
+	}
+	if hasResetReq {
+		at := now.Add(resetReq)
+		state.ResetRequests = &at
+	}
+	if hasLimitTok {
+		state.LimitTokens = limitTok
+	}
+	if hasRemainingTok {
+		state.RemainingTokens = &remainingTok
+	}
+	if hasResetTok {
+		at := now.Add(resetTok)
+		state.ResetTokens = &at
+	}
+}
+
+// rateLimitStateLocked returns the state of a provider, creating it. The
+// caller holds providerRateLimitsMu.
+func rateLimitStateLocked(provider string) *ProviderRateLimit {
+	state, ok := providerRateLimits[provider]
+	if !ok {
+		state = &ProviderRateLimit{Provider: provider}
+		providerRateLimits[provider] = state
+	}
+	return state
+}
+
+// rateLimitRetryDelay returns how long to wait before retrying a 429, from
+// headers that observeRateLimits has already recorded. ok is false when the
+// headers say nothing, leaving the exponential backoff in charge. It fails
+// when the reset comes after ctx's deadline, since waiting would only burn
+// the remaining time.
+func rateLimitRetryDelay(ctx context.Context, provider string, h http.Header, jitter float64) (time.Duration, bool, error) {
+	now := time.Now()
+	wait, ok := retryAfterDelay(h, now)
+
+	providerRateLimitsMu.Lock()
+	state := rateLimitStateLocked(provider)
+	state.RateLimitHits++
+	state.UpdatedAt = now
+	if ok {
+		until := now.Add(wait)
+		state.RateLimitedUntil = &until
+	}
+	providerRateLimitsMu.Unlock()
+
+	if !ok {
+		return 0, false, nil
+	}
+	if wait > maxRetryDelay {
+		wait = maxRetryDelay
+	}
+	if deadline, has := ctx.Deadline(); has && now.Add(wait).After(deadline) {
+		return 0, false, fmt.Errorf("rate limited by %s until %s, after the request deadline", provider, now.Add(wait).Format(time.RFC3339))
+	}
+	// Spread retries a little past the reset so parallel branches do not
+	// arrive at the same instant
+	if jitter > 0 {
+		wait += time.Duration(rand.Float64() * jitter * 0.1 * float64(wait))
+	}
+	return wait, true, nil
+}
+
+// retryAfterDelay reads the wait a 429 asks for: retry-after-ms, then
+// Retry-After (seconds or an HTTP date), then the x-ratelimit reset headers
+// of the exhausted limit
+func retryAfterDelay(h http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(strings.TrimSpace(h.Get("Retry-After-Ms")), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	if v := strings.TrimSpace(h.Get("Retry-After")); v != "" {
+		if s, err := strconv.ParseFloat(v, 64); err == nil && s >= 0 {
+			return time.Duration(s * float64(time.Second)), true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return max(at.Sub(now), 0), true
+		}
+	}
+
+	var wait time.Duration
+	found := false
+	for _, limit := range []string{"Requests", "Tokens"} {
+		reset, ok := parseRateLimitReset(h.Get("X-Ratelimit-Reset-"+limit), now)
+		if !ok {
+			continue
+		}
+		// Prefer the limit that ran out; without remaining counts take the later reset
+		if remaining, known := headerInt(h, "X-Ratelimit-Remaining-"+limit); known && remaining > 0 {
+			continue
+		}
+		if !found || reset > wait {
+			wait, found = reset, true
+		}
+	}
+	if !found {
+		if reset, ok := parseRateLimitReset(h.Get("X-Ratelimit-Reset"), now); ok {
+			return reset, true
+		}
+	}
+	return wait, found
+}
+
+// parseRateLimitReset parses a reset header: a Go-style duration ("1s",
+// "6m0s", "20ms"), seconds, or a Unix timestamp
+func parseRateLimitReset(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return max(d, 0), true
+	}
+	s, err := strconv.ParseFloat(v, 64)
+	if err != nil || s < 0 {
+		return 0, false
+	}
+	if s > 1e9 { // A Unix timestamp, not a delta
+		return max(time.Unix(0, int64(s*float64(time.Second))).Sub(now), 0), true
+	}
+	return time.Duration(s * float64(time.Second)), true
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(h.Get(key)))
+	return n, err == nil
+}
+
+// rateLimitStatuses reports every provider's rate-limit state, sorted by
+// provider
+func rateLimitStatuses() []ProviderRateLimit {
+	providerRateLimitsMu.Lock()
+	defer providerRateLimitsMu.Unlock()
+	statuses := make([]ProviderRateLimit, 0, len(providerRateLimits))
+	for _, state := range providerRateLimits {
+		statuses = append(statuses, *state)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Provider < statuses[j].Provider })
+	return statuses
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfterDelay(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", map[string]string{"Retry-After": "7"}, 7 * time.Second, true},
+		{"http date", map[string]string{"Retry-After": now.Add(90 * time.Second).Format(http.TimeFormat)}, 90 * time.Second, true},
+		{"milliseconds win", map[string]string{"Retry-After-Ms": "250", "Retry-After": "7"}, 250 * time.Millisecond, true},
+		{"exhausted limit", map[string]string{
+			"X-Ratelimit-Remaining-Requests": "12", "X-Ratelimit-Reset-Requests": "2s",
+			"X-Ratelimit-Remaining-Tokens": "0", "X-Ratelimit-Reset-Tokens": "1m30s",
+		}, 90 * time.Second, true},
+		{"later reset without counts", map[string]string{"X-Ratelimit-Reset-Requests": "2s", "X-Ratelimit-Reset-Tokens": "500ms"}, 2 * time.Second, true},
+		{"unix reset", map[string]string{"X-Ratelimit-Reset": "1767323105"}, 60 * time.Second, true},
+		{"none", map[string]string{}, 0, false},
+	} {
+		h := make(http.Header)
+		for k, v := range tc.header {
+			h.Set(k, v)
+		}
+		got, ok := retryAfterDelay(h, now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s: got %v, %v; want %v, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestOpenAIProvider_WaitsForRateLimitReset(t *testing.T) {
+	fake := newFakeOpenAI(t, func(fakeChatRequest) string { return "ok" })
+	fake.header.Set("X-Ratelimit-Limit-Requests", "100")
+	fake.header.Set("X-Ratelimit-Remaining-Requests", "99")
+	useFakeProvider(t, "groq", fake)
+	t.Setenv("LLM_RETRY_BASE_DELAY_MS", "60000") // A blind backoff would outlast the test
+	ResetConfig()
+
+	provider, err := buildProvider("groq", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.failNextWith(http.StatusTooManyRequests, http.Header{"Retry-After-Ms": {"20"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if resp, err := provider.Chat(ctx, []ChatMessage{{Role: "user", Content: "hi"}}, ChatOptions{}); err != nil || resp != "ok" {
+		t.Fatalf("Chat: %q, %v", resp, err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("Expected a wait of about 20ms, took %v", elapsed)
+	}
+
+	var state *ProviderRateLimit
+	for _, s := range rateLimitStatuses() {
+		if s.Provider == "groq" {
+			state = &s
+		}
+	}
+	if state == nil || state.RateLimitHits < 1 || state.RemainingRequests == nil || *state.RemainingRequests != 99 || state.LimitRequests != 100 {
+		t.Errorf("Expected the recorded rate-limit state, got %+v", state)
+	}
+
+	// A reset past the deadline fails fast instead of burning the retries
+	fake.failNextWith(http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+	short, cancelShort := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelShort()
+	start = time.Now()
+	if _, err := provider.Chat(short, []ChatMessage{{Role: "user", Content: "hi"}}, ChatOptions{}); err == nil {
+		t.Error("Expected an error for a reset after the deadline")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected to fail fast, took %v", time.Since(start))
+	}
+}
@@ -0,0 +1,67 @@
+package core
+
+import "context"
+
+// ReasonerBase is the progress and token plumbing shared by the reasoning
+// engines. Embedding it gives an engine SetProgressCallback,
+// SetTokenCallback and SetEnableStreaming (the pipelineReasoner surface) and
+// SetEventBus; emitProgress and emitToken publish to the event bus and call
+// the callbacks, and callProvider streams a call's tokens when streaming is
+// on.
+type ReasonerBase struct {
+	onProgress    func(ProgressUpdate)
+	onToken       func(token string)
+	enableStreams bool
+	events        *EventBus
+}
+
+// SetEventBus sets the bus the run's events are published to
+func (b *ReasonerBase) SetEventBus(bus *EventBus) {
+	b.events = bus
+}
+
+// SetProgressCallback sets a callback for progress updates
+func (b *ReasonerBase) SetProgressCallback(cb func(ProgressUpdate)) {
+	b.onProgress = cb
+}
+
+// SetTokenCallback sets a callback for token streaming
+func (b *ReasonerBase) SetTokenCallback(cb func(token string)) {
+	b.onToken = cb
+}
+
+// SetEnableStreaming enables or disables LLM streaming
+func (b *ReasonerBase) SetEnableStreaming(enable bool) {
+	b.enableStreams = enable
+}
+
+func (b *ReasonerBase) emitProgress(update ProgressUpdate) {
+	b.events.Publish(eventFromProgress(update))
+	if b.onProgress != nil {
+		b.onProgress(update)
+	}
+}
+
+func (b *ReasonerBase) emitToken(token string) {
+	b.events.Publish(TokenStreamed{Token: token})
+	if b.onToken != nil {
+		b.onToken(token)
+	}
+}
+
+// publish sends an event with no progress update, such as RoundCompleted
+func (b *ReasonerBase) publish(e Event) {
+	b.events.Publish(e)
+}
+
+// callProvider sends messages to provider, streaming the reply's tokens when
+// stream is set and the provider can. A cancelled ctx fails before the call.
+func (b *ReasonerBase) callProvider(ctx context.Context, provider Provider, messages []ChatMessage, opts ChatOptions, stream bool) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if sp, ok := provider.(StreamingProvider); ok && stream && sp.SupportsStreaming() {
+		return sp.ChatStream(ctx, messages, opts, b.emitToken)
+	}
+	return provider.Chat(ctx, messages, opts)
+}
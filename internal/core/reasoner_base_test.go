@@ -0,0 +1,43 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Every engine gets the callback surface from ReasonerBase
+var (
+	_ pipelineReasoner = (*SequentialClient)(nil)
+	_ pipelineReasoner = (*GraphOfThoughts)(nil)
+	_ pipelineReasoner = (*Reflexion)(nil)
+	_ pipelineReasoner = (*DialecticalReasoner)(nil)
+	_ pipelineReasoner = (*DecomposeSolver)(nil)
+	_ pipelineReasoner = (*PlanExecutor)(nil)
+	_ pipelineReasoner = (*ReasoningPipeline)(nil)
+)
+
+func TestReasonerBase_CallProvider(t *testing.T) {
+	var b ReasonerBase
+	var tokens []string
+	b.SetTokenCallback(func(token string) { tokens = append(tokens, token) })
+
+	provider := &tokenStreamProvider{}
+	response, err := b.callProvider(context.Background(), provider, nil, ChatOptions{}, true)
+	if err != nil || strings.Join(tokens, "") != response || len(tokens) < 2 {
+		t.Fatalf("Expected the reply to be streamed token by token, got %q from %v, %v", response, tokens, err)
+	}
+
+	tokens = nil
+	if _, err := b.callProvider(context.Background(), provider, nil, ChatOptions{}, false); err != nil || len(tokens) != 0 {
+		t.Errorf("Expected no tokens without streaming, got %v, %v", tokens, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := provider.calls
+	if _, err := b.callProvider(ctx, provider, nil, ChatOptions{}, true); !errors.Is(err, context.Canceled) || provider.calls != calls {
+		t.Errorf("Expected a cancelled run not to call the provider, got %v", err)
+	}
+}
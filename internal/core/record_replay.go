@@ -0,0 +1,216 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ============ Record / Replay ============
+//
+// With LLM_RECORD_DIR set, every provider call is written to that directory
+// as one JSON file holding the request and the response (or error). With
+// LLM_REPLAY_DIR set, calls are answered from such recordings and never
+// reach an API, so a recorded GoT, Reflexion or Dialectic run can be
+// replayed deterministically in tests or debugged offline.
+//
+// Recordings are matched on the request (kind, messages, temperature, max
+// tokens, tools, embedding texts), not on the provider or model, so a run
+// recorded against one provider replays under any. Identical requests are
+// numbered in the order they are made, and the n-th repeat replays the n-th
+// recording; once those run out, the last one is repeated.
+
+// ErrNoRecording is returned in replay mode for a request never recorded
+var ErrNoRecording = errors.New("no recorded response for request")
+
+// LLMRecording is one recorded provider call
+type LLMRecording struct {
+	Key         string        `json:"key"`
+	Seq         int           `json:"seq"` // Earlier identical requests in the recording process
+	Kind        string        `json:"kind"`
+	Provider    string        `json:"provider"`
+	Model       string        `json:"model,omitempty"`
+	Messages    []ChatMessage `json:"messages,omitempty"`
+	Temperature float64       `json:"temperature,omitempty"`
+	Seed        *int          `json:"seed,omitempty"` // Seed of a deterministic run
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Tools       []ToolSchema  `json:"tools,omitempty"`
+	Texts       []string      `json:"texts,omitempty"`
+	Content     string        `json:"content,omitempty"`
+	Response    *ChatResponse `json:"response,omitempty"`
+	Vectors     [][]float64   `json:"vectors,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	DurationMs  int64         `json:"duration_ms"`
+	RecordedAt  time.Time     `json:"recorded_at"`
+}
+
+// recordingDir numbers the identical requests recorded into, or replayed
+// from, one directory. It is shared by every provider recording (or
+// replaying) with the directory, since providers are rebuilt for each tool
+// call.
+type recordingDir struct {
+	dir string
+
+	mu   sync.Mutex
+	seqs map[string]int
+}
+
+var (
+	recordingDirs   = make(map[string]*recordingDir)
+	recordingDirsMu sync.Mutex
+)
+
+// getRecordingDir returns the state of dir for mode ("record" or "replay")
+func getRecordingDir(mode, dir string) *recordingDir {
+	recordingDirsMu.Lock()
+	defer recordingDirsMu.Unlock()
+	d, ok := recordingDirs[mode+":"+dir]
+	if !ok {
+		d = &recordingDir{dir: dir, seqs: make(map[string]int)}
+		recordingDirs[mode+":"+dir] = d
+	}
+	return d
+}
+
+// next returns the sequence number of the next request with key
+func (d *recordingDir) next(key string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seq := d.seqs[key]
+	d.seqs[key] = seq + 1
+	return seq
+}
+
+func (d *recordingDir) path(key string, seq int) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%s_%d.json", key[:16], seq))
+}
+
+// recordingKey identifies a call's request for record and replay; streamed
+// and plain chat calls share recordings
+func recordingKey(call *ProviderCall) string {
+	kind := call.Kind
+	if kind == CallStream {
+		kind = CallChat
+	}
+	payload, _ := json.Marshal(struct {
+		Kind        string
+		Messages    []ChatMessage
+		Temperature float64
+		MaxTokens   int
+		Tools       []ToolSchema
+		Texts       []string
+		EmbedFor    string
+	}{kind, call.Messages, call.Opts.Temperature, call.Opts.MaxTokens, call.Opts.Tools, call.Texts, call.EmbedFor})
+	return fmt.Sprintf("%x", sha256.Sum256(payload))
+}
+
+// WithRecording writes every call and its outcome to dir
+func WithRecording(dir string) ProviderMiddleware {
+	d := getRecordingDir("record", dir)
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		start := time.Now()
+		err := next(ctx)
+		key := recordingKey(call)
+		rec := LLMRecording{
+			Key:         key,
+			Kind:        call.Kind,
+			Provider:    call.Provider,
+			Model:       call.Model,
+			Messages:    call.Messages,
+			Temperature: call.Opts.Temperature,
+			MaxTokens:   call.Opts.MaxTokens,
+			Tools:       call.Opts.Tools,
+			Texts:       call.Texts,
+			Content:     call.Content,
+			Response:    call.Response,
+			Vectors:     call.Vectors,
+			DurationMs:  time.Since(start).Milliseconds(),
+			RecordedAt:  start,
+		}
+		if call.Opts.Deterministic {
+			seed := call.Opts.Seed
+			rec.Seed = &seed
+		}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		if writeErr := d.write(rec); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record LLM call: %v\n", writeErr)
+		}
+		return err
+	})
+}
+
+// write stores rec under the next free sequence number of its key; another
+// process recording into the same directory may have taken some
+func (d *recordingDir) write(rec LLMRecording) error {
+	if err := os.MkdirAll(d.dir, 0700); err != nil {
+		return err
+	}
+	for {
+		rec.Seq = d.next(rec.Key)
+		data, err := json.MarshalIndent(rec, "", "  ")
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(d.path(rec.Key, rec.Seq), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+}
+
+// WithReplay answers every call from the recordings in dir, failing calls
+// that were never recorded
+func WithReplay(dir string) ProviderMiddleware {
+	d := getRecordingDir("replay", dir)
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		key := recordingKey(call)
+		rec, err := d.read(key, d.next(key))
+		if err != nil {
+			return err
+		}
+		if rec.Error != "" {
+			return errors.New(rec.Error)
+		}
+		call.Content, call.Response, call.Vectors = rec.Content, rec.Response, rec.Vectors
+		if call.Kind == CallStream && call.OnToken != nil {
+			call.OnToken(rec.Content)
+		}
+		return nil
+	})
+}
+
+// read loads recording seq of key, or the last one recorded when there are
+// fewer
+func (d *recordingDir) read(key string, seq int) (*LLMRecording, error) {
+	for ; seq >= 0; seq-- {
+		data, err := os.ReadFile(d.path(key, seq))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+		var rec LLMRecording
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("failed to parse recording %s: %w", d.path(key, seq), err)
+		}
+		return &rec, nil
+	}
+	return nil, fmt.Errorf("%w (LLM_REPLAY_DIR=%s, key %s)", ErrNoRecording, d.dir, key[:16])
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// numberedProvider answers "<prompt> #<n>" for the n-th call
+type numberedProvider struct {
+	countProvider
+}
+
+func (p *numberedProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	p.countProvider.Chat(ctx, messages, opts)
+	if messages[0].Content == "fail" {
+		return "", errors.New("provider exploded")
+	}
+	return fmt.Sprintf("%s #%d", messages[0].Content, p.calls), nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	ask := func(p Provider, prompt string) (string, error) {
+		return p.Chat(context.Background(), []ChatMessage{{Role: "user", Content: prompt}}, ChatOptions{Temperature: 0.7})
+	}
+
+	recorder := WrapProvider(&numberedProvider{}, WithRecording(dir))
+	for _, prompt := range []string{"a", "b", "a", "fail"} {
+		ask(recorder, prompt)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 4 {
+		t.Fatalf("Expected 4 recordings, got %d", len(files))
+	}
+
+	// The provider under replay must never be called
+	inner := &numberedProvider{}
+	replayer := WrapProvider(inner, WithReplay(dir))
+	for _, want := range []struct{ prompt, answer string }{
+		{"a", "a #1"}, {"b", "b #2"}, {"a", "a #3"},
+		{"a", "a #3"}, // Out of recordings: the last one repeats
+	} {
+		if got, err := ask(replayer, want.prompt); err != nil || got != want.answer {
+			t.Errorf("%s: expected %q, got %q, %v", want.prompt, want.answer, got, err)
+		}
+	}
+	if _, err := ask(replayer, "fail"); err == nil || err.Error() != "provider exploded" {
+		t.Errorf("Expected the recorded error, got %v", err)
+	}
+	if _, err := ask(replayer, "never asked"); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("Expected ErrNoRecording, got %v", err)
+	}
+	if inner.calls != 0 {
+		t.Errorf("Expected replay not to reach the provider, got %d calls", inner.calls)
+	}
+}
+
+func TestIntegration_RecordReplay(t *testing.T) {
+	fake := newFakeOpenAI(t, solveResponder)
+	useFakeProvider(t, "openai", fake)
+	dir := t.TempDir()
+	t.Setenv("LLM_RECORD_DIR", dir)
+	s := integrationServer(t)
+
+	args := map[string]interface{}{"problem": "What is 17 * 23?", "max_rounds": 2}
+	recorded := callTool(t, s, "dialectic_reason", args)
+	requests := len(fake.received())
+
+	t.Setenv("LLM_RECORD_DIR", "")
+	t.Setenv("LLM_REPLAY_DIR", dir)
+	replayed := callTool(t, s, "dialectic_reason", args)
+	if len(fake.received()) != requests {
+		t.Errorf("Expected the replay to make no requests, got %d", len(fake.received())-requests)
+	}
+	if replayed["final_answer"] != recorded["final_answer"] || len(replayed["steps"].([]interface{})) != len(recorded["steps"].([]interface{})) {
+		t.Errorf("Expected the replay to reproduce the run, got %v vs %v", replayed["final_answer"], recorded["final_answer"])
+	}
+}
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============ Redis Backend ============
+//
+// Replicas behind a load balancer each keep their own cache, limiter and
+// saved runs, so they repeat each other's work and together exceed the
+// concurrency a provider allows. With REDIS_URL set they share that state
+// through Redis instead: the result cache, idempotency keys, a cluster-wide
+// cap on concurrent LLM requests, and saved GoT runs. Redis errors never
+// fail a run; the affected feature falls back to the replica's own state.
+
+const (
+	defaultRedisPrefix = "reasoning-tools:"
+	redisOpTimeout     = 2 * time.Second
+
+	// defaultRedisSlotLease is how long a cluster LLM slot outlives a
+	// replica that stops renewing it (e.g. one that crashed mid-request)
+	defaultRedisSlotLease = 30 * time.Second
+
+	// defaultGoTRunRedisTTL is how long shared GoT runs are kept
+	defaultGoTRunRedisTTL = 7 * 24 * time.Hour
+)
+
+var (
+	redisClient     *redis.Client
+	redisPrefix     = defaultRedisPrefix
+	redisClientOnce sync.Once
+)
+
+// getRedis returns the shared Redis client, or nil when REDIS_URL is unset
+// or the server cannot be reached at startup. REDIS_PREFIX namespaces the
+// keys so several deployments can share one server.
+func getRedis() *redis.Client {
+	redisClientOnce.Do(func() {
+		url := strings.TrimSpace(os.Getenv("REDIS_URL"))
+		if url == "" {
+			return
+		}
+		opts, err := redis.ParseURL(url)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: invalid REDIS_URL: %v (state stays per-process)\n", err)
+			return
+		}
+		client := redis.NewClient(opts)
+		ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Redis at %s is unreachable: %v (state stays per-process)\n", opts.Addr, err)
+			client.Close()
+			return
+		}
+		redisClient = client
+		if prefix := os.Getenv("REDIS_PREFIX"); prefix != "" {
+			redisPrefix = prefix
+		}
+		log.Printf("[REDIS] Sharing state through %s (prefix %q)", opts.Addr, redisPrefix)
+	})
+	return redisClient
+}
+
+// redisKey namespaces a key under REDIS_PREFIX
+func redisKey(parts ...string) string {
+	return redisPrefix + strings.Join(parts, ":")
+}
+
+// redisEnabled reports whether an optional Redis backend should be used:
+// Redis is configured and the backend's switch is not turned off
+func redisEnabled(switchEnv string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(switchEnv))) {
+	case "false", "0", "off":
+		return false
+	}
+	return getRedis() != nil
+}
+
+// ============ Shared Result Cache ============
+
+// RedisCache is the shared layer of ToolCache, checked after memory and
+// before disk, so a result computed by one replica serves all of them
+type RedisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// redisCacheFromEnv returns the shared cache layer when Redis is configured,
+// unless TOOL_CACHE_REDIS=false
+func redisCacheFromEnv(ttl time.Duration) *RedisCache {
+	if !redisEnabled("TOOL_CACHE_REDIS") {
+		return nil
+	}
+	return &RedisCache{client: getRedis(), ttl: ttl}
+}
+
+func (c *RedisCache) Get(key string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	value, err := c.client.Get(ctx, redisKey("cache", key)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			fmt.Fprintf(os.Stderr, "Warning: shared cache read failed: %v\n", err)
+		}
+		return "", false
+	}
+	return value, true
+}
+
+func (c *RedisCache) Set(key, value string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	return c.client.Set(ctx, redisKey("cache", key), value, c.ttl).Err()
+}
+
+// Clear deletes every shared cache entry, returning how many were removed
+func (c *RedisCache) Clear() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*redisOpTimeout)
+	defer cancel()
+	removed := 0
+	iter := c.client.Scan(ctx, 0, redisKey("cache", "*"), 500).Iterator()
+	for iter.Next(ctx) {
+		if n, err := c.client.Del(ctx, iter.Val()).Result(); err == nil {
+			removed += int(n)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to clear shared cache: %v\n", err)
+	}
+	return removed
+}
+
+// ============ Cluster LLM Slots ============
+
+// redisSemaphore caps concurrent holders across replicas. Holders are
+// members of a sorted set scored by lease expiry and renew their lease
+// while they run, so slots held by a crashed replica free themselves.
+type redisSemaphore struct {
+	client *redis.Client
+	key    string
+	limit  int
+	lease  time.Duration
+}
+
+// redisAcquireScript drops expired holders and adds ARGV[4] when fewer than
+// ARGV[2] remain. ARGV[1] is now and ARGV[3] the lease, in milliseconds.
+var redisAcquireScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('ZADD', KEYS[1], now + tonumber(ARGV[3]), ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// clusterLLMSlots returns the cluster-wide LLM request limit when Redis is
+// configured and REDIS_LLM_MAX_CONCURRENT is positive
+func clusterLLMSlots() *redisSemaphore {
+	limit := parseEnvInt("REDIS_LLM_MAX_CONCURRENT", 0)
+	if limit <= 0 {
+		return nil
+	}
+	client := getRedis()
+	if client == nil {
+		return nil
+	}
+	return &redisSemaphore{client: client, key: redisKey("llm_slots"), limit: limit, lease: defaultRedisSlotLease}
+}
+
+// acquire waits for a slot, polling with backoff. If Redis fails, the call
+// proceeds without a cluster slot rather than failing the run.
+func (s *redisSemaphore) acquire(ctx context.Context) (func(), error) {
+	id := newRedisHolderID()
+	poll := 20 * time.Millisecond
+	for {
+		opCtx, cancel := context.WithTimeout(ctx, redisOpTimeout)
+		acquired, err := redisAcquireScript.Run(opCtx, s.client, []string{s.key},
+			time.Now().UnixMilli(), s.limit, s.lease.Milliseconds(), id).Int()
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "Warning: cluster LLM slot unavailable: %v (continuing without it)\n", err)
+			return func() {}, nil
+		}
+		if acquired == 1 {
+			return s.hold(id), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(poll):
+		}
+		poll = min(poll*2, 500*time.Millisecond)
+	}
+}
+
+// hold renews the lease of holder id until the returned release is called
+func (s *redisSemaphore) hold(id string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+				s.client.ZAddXX(ctx, s.key, redis.Z{Score: float64(time.Now().Add(s.lease).UnixMilli()), Member: id})
+				cancel()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+			defer cancel()
+			s.client.ZRem(ctx, s.key, id)
+		})
+	}
+}
+
+func newRedisHolderID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// ============ Shared GoT Runs ============
+
+// saveSharedGoTRun copies a saved GoT run to Redis for got_continue and
+// export_graph on other replicas
+func saveSharedGoTRun(client *redis.Client, runID string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	ttl := defaultGoTRunRedisTTL
+	if hours := parseEnvInt("GOT_RUN_REDIS_TTL_HOURS", 0); hours > 0 {
+		ttl = time.Duration(hours) * time.Hour
+	}
+	if err := client.Set(ctx, redisKey("got_run", runID), data, ttl).Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to share GoT run %s: %v\n", runID, err)
+	}
+}
+
+// loadSharedGoTRun reads a GoT run from Redis; ok is false when it is not there
+func loadSharedGoTRun(client *redis.Client, runID string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	data, err := client.Get(ctx, redisKey("got_run", runID)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read shared GoT run %s: %v\n", runID, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// useMiniredis points the Redis backends at an in-memory server for the
+// rest of the test
+func useMiniredis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	getRedis()
+	savedClient, savedPrefix := redisClient, redisPrefix
+	redisClient, redisPrefix = client, "test:"
+	t.Cleanup(func() { redisClient, redisPrefix = savedClient, savedPrefix })
+	return mr, client
+}
+
+func TestToolCache_SharedBetweenReplicas(t *testing.T) {
+	mr, _ := useMiniredis(t)
+	key := buildToolCacheKey("dialectic_reason", "openai", map[string]interface{}{"problem": "p"})
+
+	first := NewToolCache(time.Hour, 10)
+	first.shared = redisCacheFromEnv(time.Hour)
+	second := NewToolCache(time.Hour, 10)
+	second.shared = redisCacheFromEnv(time.Hour)
+	first.Set(key, "cached answer")
+
+	if value, ok := second.Get(key); !ok || value != "cached answer" {
+		t.Fatalf("Expected the other replica's entry, got %q, %v", value, ok)
+	}
+	if stats := second.Stats(); !stats.Shared || stats.SharedHits != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if ttl := mr.TTL("test:cache:" + key); ttl != time.Hour {
+		t.Errorf("Expected the cache TTL on the shared entry, got %v", ttl)
+	}
+
+	if removed := first.ClearShared(); removed != 1 {
+		t.Errorf("Expected 1 shared entry removed, got %d", removed)
+	}
+	third := NewToolCache(time.Hour, 10)
+	third.shared = redisCacheFromEnv(time.Hour)
+	if _, ok := third.Get(key); ok {
+		t.Error("Expected a miss after clearing the shared layer")
+	}
+}
+
+func TestRedisSemaphore_CapsAcrossReplicas(t *testing.T) {
+	_, client := useMiniredis(t)
+	a := &redisSemaphore{client: client, key: "test:llm_slots", limit: 1, lease: time.Minute}
+	b := &redisSemaphore{client: client, key: "test:llm_slots", limit: 1, lease: time.Minute}
+
+	release, err := a.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := b.acquire(ctx); err == nil {
+		t.Fatal("Expected the second replica to wait while the slot is held")
+	}
+
+	release()
+	releaseB, err := b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the released slot to be taken, got %v", err)
+	}
+	releaseB()
+
+	// A holder whose lease ran out (its replica died) no longer counts
+	client.ZAdd(context.Background(), "test:llm_slots", redis.Z{Score: float64(time.Now().Add(-time.Second).UnixMilli()), Member: "dead"})
+	releaseB, err = b.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the expired slot to be reclaimed, got %v", err)
+	}
+	releaseB()
+}
+
+func TestGoTRunStore_SharedBetweenReplicas(t *testing.T) {
+	_, client := useMiniredis(t)
+	a, _ := NewGoTRunStore(t.TempDir(), 0)
+	b, _ := NewGoTRunStore(t.TempDir(), 0)
+	a.shared, b.shared = client, client
+
+	state := &GoTRunState{RunID: "got_1_shared", Problem: "p", Nodes: map[string]*GoTNode{"root": {ID: "root"}}, TotalVisits: 1}
+	if err := a.Save(state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := b.Load(state.RunID)
+	if err != nil || loaded.Problem != "p" {
+		t.Fatalf("Expected the run saved by the other replica, got %+v, %v", loaded, err)
+	}
+
+	// b continues the run; a must see b's version, not its stale file
+	loaded.TotalVisits = 5
+	if err := b.Save(loaded); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if again, _ := a.Load(state.RunID); again == nil || again.TotalVisits != 5 {
+		t.Errorf("Expected the shared copy to win over the local file, got %+v", again)
+	}
+}
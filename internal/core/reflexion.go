@@ -0,0 +1,1347 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"reasoning-tools/utils"
+)
+
+// Reflexion implements episodic memory and learning from failures
+type Reflexion struct {
+	ReasonerBase
+
+	provider    Provider
+	config      ReflexionConfig
+	memory      *EpisodicMemory
+	namespace   string // Memory namespace of the current run
+	tools       *ToolRegistry
+	toolBudget  *ToolBudget
+	calibration *calibrationRecorder
+	jsonRepair  *jsonRepairer
+	history     *historySummarizer
+}
+
+// ReflexionConfig configures the reflexion process
+type ReflexionConfig struct {
+	MaxAttempts           int              // Maximum reasoning attempts before giving up (default: 3)
+	MaxThoughtsPerAttempt int              // Max thoughts per attempt (default: 10)
+	MemoryPath            string           // Path to store episodic memory (default: REFLEXION_MEMORY_PATH or ~/.local/share/reasoning-tools/memory.json)
+	LearnFromPast         bool             // Whether to query past failures (default: true)
+	MemoryCategory        string           // Only recall episodes of this category; "auto" = the problem's (default: "" = any)
+	MemoryNamespace       string           // Store and recall episodes in this namespace; "client" = the MCP client's name (default: REFLEXION_MEMORY_NAMESPACE, "" = the default namespace)
+	Temperature           float64          // LLM temperature (default: 0.7)
+	EnableTools           bool             // Enable tool usage during reasoning
+	MaxToolCalls          int              // Maximum tool calls per attempt (default: 5)
+	EnabledTools          []string         // Which tools to enable (empty = all)
+	MaxEpisodes           int              // Maximum episodes to keep per namespace (default: REFLEXION_MAX_EPISODES or 100, 0 = unlimited)
+	EpisodeTTL            time.Duration    // Time-to-live for episodes (default: 0 = no expiration)
+	HiddenTests           *HiddenTests     // Tests that decide success for coding problems (default: none, LLM judges)
+	SuccessCriteria       *SuccessCriteria // Rubric for the evaluator and/or validators that decide success (default: none)
+	ForceDiversity        bool             // Require each retry to use a different high-level strategy (default: true)
+	DiversityThreshold    float64          // Redo an attempt once if it is at least this similar to a prior one (default: 0.6)
+	AttemptMaxTokens      int              // Estimated completion tokens per attempt, unused tokens carry over (default: 0 = unlimited)
+	AttemptTimeout        time.Duration    // Reasoning time per attempt, unused time carries over (default: 0 = unlimited)
+	MaxHistoryTokens      int              // Summarize an attempt's older turns past this many estimated tokens (default: 6000, 0 = never)
+	// Providers for successive attempts, e.g. cheap, then a different family, then strongest.
+	// Attempts beyond the list reuse the last entry; reflection, and evaluation unless Evaluator is set, stay on the main provider.
+	AttemptProviders []AttemptProvider
+	// Critic for answer evaluation (default: nil, the main provider judges)
+	Evaluator Provider
+	// How high the evaluator's bar for "is_correct" is: lenient, normal or strict (default: normal)
+	EvaluatorStrictness string
+	// Panel size for majority voting on "is_correct" (default: 1 = single verdict)
+	EvaluatorVotes int
+	// Providers on the voting panel, cycled to EvaluatorVotes entries (default: none, the evaluator votes K times)
+	Evaluators []AttemptProvider
+	// Judge each answer several times; the share of "correct" verdicts is its score (default: off)
+	Calibration CalibrationConfig
+}
+
+// AttemptProvider is a provider/model used for one reasoning attempt
+type AttemptProvider struct {
+	Label    string // As configured, e.g. "groq:llama-3.1-8b-instant"
+	Provider Provider
+}
+
+// DefaultReflexionConfig returns sensible defaults
+func DefaultReflexionConfig() ReflexionConfig {
+	memoryPath := os.Getenv("REFLEXION_MEMORY_PATH")
+	if memoryPath == "" {
+		homeDir, _ := os.UserHomeDir()
+		memoryPath = filepath.Join(homeDir, ".local", "share", "reasoning-tools", "memory.json")
+	}
+	return ReflexionConfig{
+		MaxAttempts:           3,
+		MaxThoughtsPerAttempt: 10,
+		MemoryPath:            memoryPath,
+		MemoryNamespace:       defaultMemoryNamespace(),
+		LearnFromPast:         true,
+		Temperature:           0.7,
+		MaxEpisodes:           parseEnvInt("REFLEXION_MAX_EPISODES", 100),
+		EpisodeTTL:            0, // No TTL by default (episodes kept indefinitely until max limit)
+		ForceDiversity:        true,
+		DiversityThreshold:    0.6,
+		EvaluatorStrictness:   StrictnessNormal,
+		EvaluatorVotes:        1,
+		MaxHistoryTokens:      defaultMaxHistoryTokens,
+	}
+}
+
+// EpisodicMemory stores past reasoning attempts and their outcomes
+type EpisodicMemory struct {
+	Episodes []Episode `json:"episodes"`
+	mu       sync.RWMutex
+	path     string
+}
+
+// Episode represents a single reasoning attempt
+type Episode struct {
+	ID            string    `json:"id"`
+	Problem       string    `json:"problem"`
+	ProblemHash   string    `json:"problem_hash"` // For similarity matching
+	Attempt       int       `json:"attempt"`
+	Thoughts      []string  `json:"thoughts"`
+	FinalAnswer   string    `json:"final_answer"`
+	WasSuccessful bool      `json:"was_successful"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	Reflection    string    `json:"reflection,omitempty"` // What went wrong / what to try differently
+	Timestamp     time.Time `json:"timestamp"`
+	Provider      string    `json:"provider"`
+	Seed          bool      `json:"seed,omitempty"` // Curated lesson; Problem is a pattern
+	Category      string    `json:"category,omitempty"`
+	Namespace     string    `json:"namespace,omitempty"` // "" is the default namespace
+	Tool          string    `json:"tool,omitempty"`      // Strategy that recorded it; "" is reflexion
+	Confidence    float64   `json:"confidence,omitempty"`
+	Signature     string    `json:"signature,omitempty"` // HMAC of the rest, with RESULT_SIGNING_KEY set
+}
+
+// ReflexionResult represents the complete result of reflexion reasoning
+type ReflexionResult struct {
+	Problem        string             `json:"problem"`
+	Category       string             `json:"category"`
+	Attempts       []Attempt          `json:"attempts"`
+	FinalAnswer    string             `json:"final_answer"`
+	TotalAttempts  int                `json:"total_attempts"`
+	Success        bool               `json:"success"`
+	Provider       string             `json:"provider"`
+	LessonsLearned []string           `json:"lessons_learned,omitempty"`
+	TotalToolCalls int                `json:"total_tool_calls,omitempty"`
+	ToolsUsed      map[string]int     `json:"tools_used,omitempty"`
+	FinalAttempt   int                `json:"final_attempt,omitempty"`  // Attempt that produced the final answer
+	FinalProvider  string             `json:"final_provider,omitempty"` // Provider/model of that attempt
+	Evaluator      string             `json:"evaluator,omitempty"`      // Set when a separate critic judged the answers
+	Language       string             `json:"language,omitempty"`       // Requested language of the reasoning and answer
+	Calibration    *CalibrationReport `json:"calibration,omitempty"`
+	JSONRepairUsage
+	HistorySummaryUsage
+	LLMCallUsage
+}
+
+// Attempt represents one reasoning attempt
+type Attempt struct {
+	Number          int              `json:"number"`
+	Thoughts        []string         `json:"thoughts"`
+	Answer          string           `json:"answer"`
+	Evaluation      string           `json:"evaluation"`
+	WasSuccessful   bool             `json:"was_successful"`
+	Reflection      string           `json:"reflection,omitempty"`
+	ToolResults     []ToolResult     `json:"tool_results,omitempty"`
+	TestResults     []TestCaseResult `json:"test_results,omitempty"`
+	CriteriaResults []TestCaseResult `json:"criteria_results,omitempty"`    // Outcomes of the success_criteria validators
+	Approach        string           `json:"approach,omitempty"`            // One-line summary of the strategy used
+	Similarity      float64          `json:"similarity_to_prior,omitempty"` // Highest similarity to an earlier attempt
+	Redone          bool             `json:"redone_for_diversity,omitempty"`
+	Provider        string           `json:"provider,omitempty"` // Provider/model that generated this attempt
+	Model           string           `json:"model,omitempty"`    // Provider/models that answered, which a fallback chain can change
+	Provenance      *Provenance      `json:"provenance,omitempty"`
+	Budget          *AttemptBudget   `json:"budget,omitempty"`
+	Calibration     *ScoreSamples    `json:"calibration,omitempty"` // Sampled verdicts, 1 = correct
+	Votes           *EvaluationVotes `json:"votes,omitempty"`       // Panel verdicts with evaluator voting
+}
+
+// NewReflexion creates a new Reflexion instance
+func NewReflexion(provider Provider, config ReflexionConfig) *Reflexion {
+	memory := loadOrCreateMemory(config.MemoryPath)
+	r := &Reflexion{
+		provider: provider,
+		config:   config,
+		memory:   memory,
+	}
+
+	// Initialize tools if enabled
+	if config.EnableTools {
+		r.tools = NewToolRegistry()
+		// Filter enabled tools if specified
+		if len(config.EnabledTools) > 0 {
+			r.tools.SetEnabled(config.EnabledTools)
+		}
+	}
+
+	return r
+}
+
+// Reason performs reflexion-style reasoning with learning from failures
+func (r *Reflexion) Reason(ctx context.Context, problem string) (*ReflexionResult, error) {
+	// A compressed problem brings problem_lookup for reading the original
+	if src := problemSourceFromContext(ctx); src != nil {
+		r.tools = src.attach(r.tools)
+		r.config.EnableTools = true
+	}
+	if pad := scratchpadFromContext(ctx); pad != nil {
+		r.tools = pad.attach(r.tools)
+	}
+	r.namespace = resolveMemoryNamespace(ctx, r.config.MemoryNamespace)
+	// Reset tool budget for this reasoning session. There is no total cap;
+	// each attempt gets its own MaxToolCalls sub-budget.
+	r.toolBudget = NewToolBudget(-1)
+	r.calibration = newCalibrationRecorder(r.config.Calibration)
+	r.jsonRepair = newJSONRepairer()
+	r.history = newHistorySummarizer(r.provider, r.config.MaxHistoryTokens)
+
+	result := &ReflexionResult{
+		Problem:   problem,
+		Category:  ClassifyProblem(problem),
+		Attempts:  []Attempt{},
+		Provider:  r.provider.Name(),
+		Evaluator: providerName(r.config.Evaluator),
+		Language:  languageFromContext(ctx),
+		ToolsUsed: make(map[string]int),
+	}
+
+	// Get lessons from similar past problems
+	var pastLessons []string
+	if r.config.LearnFromPast {
+		pastLessons = r.getPastLessons(problem)
+		if len(pastLessons) > 0 {
+			result.LessonsLearned = pastLessons
+			r.emitProgress(ProgressUpdate{
+				Type:    "thought",
+				Message: fmt.Sprintf("Found %d relevant lessons from past attempts", len(pastLessons)),
+			})
+		}
+	}
+
+	var lastReflection string
+	var carryTokens int
+	var carryTime time.Duration
+
+	for attemptNum := 1; attemptNum <= r.config.MaxAttempts; attemptNum++ {
+		r.emitProgress(ProgressUpdate{
+			Type:    "thought",
+			Message: fmt.Sprintf("Starting attempt %d/%d", attemptNum, r.config.MaxAttempts),
+			Depth:   attemptNum,
+		})
+
+		attempt := Attempt{
+			Number:   attemptNum,
+			Thoughts: []string{},
+			Provider: r.attemptProvider(attemptNum).Label,
+		}
+
+		// Generate reasoning with awareness of past failures
+		priorApproaches := r.priorApproaches(result.Attempts)
+		budget := newAttemptBudget(r.config.AttemptMaxTokens, r.config.AttemptTimeout, carryTokens, carryTime)
+		genCtx, generatedBy := withModelCalls(ctx)
+		thoughts, answer, attemptToolResults, err := r.generateReasoning(genCtx, problem, pastLessons, lastReflection, priorApproaches, attemptNum, budget)
+		attempt.Model = generatedBy.label()
+		attempt.Provenance = generatedBy.provenance(requestedModel(r.attemptProvider(attemptNum).Provider, ""))
+		if err != nil {
+			attempt.Budget = budget.report()
+			carryTokens, carryTime = budget.leftover()
+			attempt.Evaluation = fmt.Sprintf("Error: %v", err)
+			result.Attempts = append(result.Attempts, attempt)
+			if errors.Is(err, ErrLLMCallBudgetExhausted) {
+				break
+			}
+			continue
+		}
+
+		// Redo the attempt once if it repeats an approach that already failed
+		if r.config.ForceDiversity && len(result.Attempts) > 0 && budget.cutOff == "" {
+			attempt.Similarity = maxAttemptSimilarity(ctx, thoughts, answer, result.Attempts)
+			if attempt.Similarity >= r.config.DiversityThreshold {
+				r.emitProgress(ProgressUpdate{
+					Type:    "thought",
+					Message: fmt.Sprintf("Attempt %d repeats a previous approach (similarity %.2f), retrying with a different strategy", attemptNum, attempt.Similarity),
+				})
+				nudge := fmt.Sprintf("%s\n\nYour first draft of this attempt repeated an approach that already failed (similarity %.2f). Discard it and solve the problem with a fundamentally different high-level strategy.", lastReflection, attempt.Similarity)
+				redoCtx, redoneBy := withModelCalls(ctx)
+				t2, a2, tr2, err := r.generateReasoning(redoCtx, problem, pastLessons, strings.TrimSpace(nudge), priorApproaches, attemptNum, budget)
+				if err == nil {
+					if sim := maxAttemptSimilarity(ctx, t2, a2, result.Attempts); sim < attempt.Similarity {
+						thoughts, answer, attempt.Similarity = t2, a2, sim
+						attemptToolResults = append(attemptToolResults, tr2...)
+						attempt.Redone = true
+						attempt.Model = redoneBy.label()
+						attempt.Provenance = redoneBy.provenance(requestedModel(r.attemptProvider(attemptNum).Provider, ""))
+					}
+				}
+			}
+		}
+
+		attempt.Thoughts = thoughts
+		attempt.Answer = answer
+		attempt.ToolResults = attemptToolResults
+		attempt.Budget = budget.report()
+		carryTokens, carryTime = budget.leftover()
+
+		// Track tool usage
+		for _, tr := range attemptToolResults {
+			result.ToolsUsed[tr.Tool]++
+		}
+
+		// Evaluate the answer: hidden tests and validators decide when supplied, otherwise the LLM judges
+		var evaluation string
+		var isCorrect bool
+		if !r.config.HiddenTests.Empty() || r.config.SuccessCriteria.Programmatic() {
+			evaluation, isCorrect, err = r.checkAnswer(ctx, &attempt, thoughts, answer)
+		} else {
+			evaluation, isCorrect, attempt.Calibration, attempt.Votes, err = r.evaluateAnswer(ctx, problem, thoughts, answer)
+		}
+		if err != nil {
+			attempt.Evaluation = fmt.Sprintf("Evaluation error: %v", err)
+			result.Attempts = append(result.Attempts, attempt)
+			if errors.Is(err, ErrLLMCallBudgetExhausted) {
+				break
+			}
+			continue
+		}
+
+		attempt.Evaluation = evaluation
+		attempt.WasSuccessful = isCorrect
+
+		score := boolToFloat(isCorrect)
+		if attempt.Calibration != nil {
+			score = attempt.Calibration.Mean
+		}
+		r.emitProgress(ProgressUpdate{
+			Type:       "evaluation",
+			Message:    fmt.Sprintf("Attempt %d evaluation: %s", attemptNum, evaluation),
+			IsSolution: isCorrect,
+			Score:      score,
+		})
+
+		if isCorrect {
+			attempt.WasSuccessful = true
+			result.Attempts = append(result.Attempts, attempt)
+			result.FinalAnswer = answer
+			result.FinalAttempt = attemptNum
+			result.FinalProvider = attempt.Provider
+			result.Success = true
+			result.TotalAttempts = attemptNum
+			result.TotalToolCalls = r.toolBudget.Used()
+			result.Calibration = r.calibration.report()
+			result.JSONRepairUsage = r.jsonRepair.report()
+			result.HistorySummaryUsage = r.history.report()
+
+			// Store successful episode
+			r.storeEpisode(problem, attemptNum, thoughts, answer, true, "", "")
+
+			r.emitProgress(ProgressUpdate{
+				Type:        "solution",
+				FinalAnswer: answer,
+				Message:     fmt.Sprintf("Success on attempt %d!", attemptNum),
+			})
+
+			return result, nil
+		}
+
+		// Generate reflection on what went wrong
+		reflection, err := r.generateReflection(ctx, problem, thoughts, answer, evaluation)
+		if err != nil {
+			reflection = "Unable to generate reflection"
+		}
+
+		attempt.Reflection = reflection
+		lastReflection = reflection
+		if r.config.ForceDiversity && attemptNum < r.config.MaxAttempts {
+			attempt.Approach = r.summarizeApproach(ctx, problem, thoughts, answer)
+		}
+		result.Attempts = append(result.Attempts, attempt)
+
+		r.emitProgress(ProgressUpdate{
+			Type:    "thought",
+			Message: fmt.Sprintf("Reflection: %s", utils.TruncateStr(reflection, 100)),
+		})
+
+		// Store failed episode
+		r.storeEpisode(problem, attemptNum, thoughts, answer, false, evaluation, reflection)
+	}
+
+	// All attempts failed (or the LLM call budget ran out)
+	result.TotalAttempts = len(result.Attempts)
+	result.TotalToolCalls = r.toolBudget.Used()
+	result.Calibration = r.calibration.report()
+	result.JSONRepairUsage = r.jsonRepair.report()
+	result.HistorySummaryUsage = r.history.report()
+	if len(result.Attempts) > 0 {
+		// Use the last answered attempt
+		last := result.Attempts[len(result.Attempts)-1]
+		for i := len(result.Attempts) - 1; i >= 0; i-- {
+			if result.Attempts[i].Answer != "" {
+				last = result.Attempts[i]
+				break
+			}
+		}
+		result.FinalAnswer = last.Answer
+		result.FinalAttempt = last.Number
+		result.FinalProvider = last.Provider
+	}
+
+	return result, nil
+}
+
+// generateReasoning generates a chain of thoughts for the problem. When the
+// attempt's budget runs out it stops thinking and asks for a final answer
+// instead of failing.
+func (r *Reflexion) generateReasoning(ctx context.Context, problem string, pastLessons []string, lastReflection string, priorApproaches []string, attemptNum int, budget *attemptBudget) ([]string, string, []ToolResult, error) {
+	var thoughts []string
+	var toolResults []ToolResult
+	provider := r.attemptProvider(attemptNum).Provider
+
+	// Build context from past lessons and reflections
+	var contextParts []string
+	if len(pastLessons) > 0 {
+		contextParts = append(contextParts, "Lessons from similar past problems:")
+		for _, lesson := range pastLessons {
+			contextParts = append(contextParts, fmt.Sprintf("- %s", lesson))
+		}
+	}
+	if lastReflection != "" {
+		contextParts = append(contextParts, fmt.Sprintf("\nReflection from previous attempt:\n%s", lastReflection))
+	}
+	if len(priorApproaches) > 0 {
+		contextParts = append(contextParts, "\nApproaches already tried in this session (all failed):")
+		for _, approach := range priorApproaches {
+			contextParts = append(contextParts, fmt.Sprintf("- %s", approach))
+		}
+		contextParts = append(contextParts, "You MUST use a different high-level strategy from every approach above, not a variation of one.")
+	}
+
+	systemPrompt := `You are a thoughtful problem solver. You learn from past mistakes and adapt your approach.
+Think step by step and show your reasoning clearly. Each thought should build toward a solution.`
+
+	if criteria := r.config.SuccessCriteria; criteria != nil {
+		if criteria.Rubric != "" {
+			systemPrompt += "\n\nYour answer will be judged against these criteria:\n" + criteria.Rubric
+		}
+		if criteria.Programmatic() {
+			systemPrompt += "\n\nYour final answer will be checked automatically, so state it plainly and exactly."
+		}
+	}
+
+	if !r.config.HiddenTests.Empty() {
+		systemPrompt += "\n\nThis is a coding problem that will be checked by hidden tests. Your final answer must contain the complete, self-contained Python solution in a ```python code block."
+	}
+
+	// Add tool instructions if enabled. With native function calling the tool
+	// schemas travel in the request instead of the prompt.
+	toolPrompt := ""
+	var toolCaller ToolCallingProvider
+	var toolSchemas []ToolSchema
+	if r.tools != nil {
+		systemPrompt += "\n\nYou have access to tools that can help you compute, verify, or look up information."
+		if tp, ok := nativeToolCaller(provider); ok {
+			toolCaller = tp
+			toolSchemas = r.tools.GetToolSchemas()
+			systemPrompt += " Call them directly when they would help."
+		} else {
+			toolPrompt = r.tools.GetToolsPrompt()
+		}
+	}
+
+	var userPrompt string
+	if r.tools != nil && toolCaller == nil {
+		userPrompt = fmt.Sprintf(`Problem: %s
+
+%s
+
+This is attempt %d. Think through the problem step by step, learning from any past mistakes.
+
+%s
+
+For each step, output a JSON object with ONE of these formats:
+
+For a reasoning step:
+{
+  "type": "thought",
+  "thought_number": <step number>,
+  "thought": "<your reasoning for this step>",
+  "is_final": <true if this is your final answer, false otherwise>,
+  "answer": "<your answer, only if is_final is true>"
+}
+
+To use a tool:
+{
+  "type": "tool",
+  "tool": "<tool name>",
+  "input": "<tool input>"
+}`, problem, strings.Join(contextParts, "\n"), attemptNum, toolPrompt)
+	} else {
+		userPrompt = fmt.Sprintf(`Problem: %s
+
+%s
+
+This is attempt %d. Think through the problem step by step, learning from any past mistakes.
+
+For each step, output a JSON object:
+{
+  "thought_number": <step number>,
+  "thought": "<your reasoning for this step>",
+  "is_final": <true if this is your final answer, false otherwise>,
+  "answer": "<your answer, only if is_final is true>"
+}`, problem, strings.Join(contextParts, "\n"), attemptNum)
+	}
+
+	messages := []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	}
+
+	maxToolCallsPerAttempt := r.config.MaxToolCalls
+	if maxToolCallsPerAttempt == 0 {
+		maxToolCallsPerAttempt = 5
+	}
+	phase := fmt.Sprintf("attempt_%d", attemptNum)
+	if r.toolBudget == nil {
+		r.toolBudget = NewToolBudget(-1)
+	}
+	r.toolBudget.SetPhaseLimit(phase, maxToolCallsPerAttempt)
+
+	// Check if provider supports streaming
+	streamingProvider, canStream := provider.(StreamingProvider)
+	useStreaming := canStream && r.enableStreams && streamingProvider.SupportsStreaming()
+
+	genCtx, cancel := budget.context(ctx)
+	defer cancel()
+
+	// callLLM sends the conversation, with its older turns summarized, using
+	// native tool calling when available
+	callLLM := func(ctx context.Context, maxTokens int) (string, []NativeToolCall, error) {
+		messages := r.history.compactMessages(ctx, messages)
+		opts := ChatOptions{
+			Temperature: r.config.Temperature,
+			MaxTokens:   maxTokens,
+		}
+		if toolCaller != nil {
+			opts.Tools = toolSchemas
+			resp, err := toolCaller.ChatWithTools(ctx, messages, opts)
+			if err != nil {
+				return "", nil, err
+			}
+			return resp.Content, resp.ToolCalls, nil
+		}
+		if useStreaming {
+			response, err := streamingProvider.ChatStream(ctx, messages, opts, r.emitToken)
+			return response, nil, err
+		}
+		response, err := provider.Chat(ctx, messages, opts)
+		return response, nil, err
+	}
+
+	for i := 0; i < r.config.MaxThoughtsPerAttempt; i++ {
+		maxTokens := budget.stepTokens(1024)
+		if maxTokens == 0 {
+			break
+		}
+		response, nativeCalls, err := callLLM(genCtx, maxTokens)
+		if err != nil {
+			if budget.timedOut(ctx, err) {
+				break
+			}
+			return thoughts, "", toolResults, fmt.Errorf("reasoning failed at step %d: %w", i+1, err)
+		}
+		budget.charge(response)
+
+		// Native tool calls: execute each and answer with tool-role messages
+		if len(nativeCalls) > 0 {
+			messages = append(messages, ChatMessage{Role: "assistant", Content: response, ToolCalls: nativeCalls})
+			for _, nc := range nativeCalls {
+				call := nc.ToToolCall()
+				var result ToolResult
+				if r.toolBudget.TryConsume(phase) {
+					result = r.tools.Execute(genCtx, call.Tool, call.Input)
+					toolResults = append(toolResults, result)
+
+					r.emitProgress(ProgressUpdate{
+						Type:       "tool",
+						ToolName:   call.Tool,
+						ToolInput:  call.Input,
+						ToolOutput: result.Output,
+
+						ToolBudgetRemaining: r.toolBudget.remainingPtr(phase),
+					})
+				} else {
+					result = ToolResult{Tool: call.Tool, Input: call.Input, Error: "tool limit reached, continue reasoning without tools"}
+				}
+				messages = append(messages, toolResultMessage(nc, result))
+			}
+			continue
+		}
+
+		// Parse the response
+		jsonStr := utils.ExtractJSON(response)
+		if jsonStr == "" {
+			// Log JSON parsing failure for observability
+			fmt.Fprintf(os.Stderr, "[WARNING] reflexion: failed to extract JSON at step %d (attempt %d), falling back to plain text. Response preview: %s\n",
+				i+1, attemptNum, utils.TruncateStr(response, 100))
+			// Try to extract thought anyway
+			thoughts = append(thoughts, response)
+			messages = append(messages, ChatMessage{Role: "assistant", Content: response})
+			messages = append(messages, ChatMessage{Role: "user", Content: "Continue your reasoning."})
+			continue
+		}
+
+		// First check if it's a tool call
+		var toolStep struct {
+			Type  string `json:"type"`
+			Tool  string `json:"tool"`
+			Input string `json:"input"`
+		}
+		if err := json.Unmarshal([]byte(jsonStr), &toolStep); err == nil && toolStep.Type == "tool" && r.tools != nil {
+			// Execute tool if within limits
+			if r.toolBudget.TryConsume(phase) {
+				result := r.tools.Execute(genCtx, toolStep.Tool, toolStep.Input)
+				toolResults = append(toolResults, result)
+
+				r.emitProgress(ProgressUpdate{
+					Type:       "tool",
+					ToolName:   toolStep.Tool,
+					ToolInput:  toolStep.Input,
+					ToolOutput: result.Output,
+
+					ToolBudgetRemaining: r.toolBudget.remainingPtr(phase),
+				})
+
+				messages = append(messages, ChatMessage{Role: "assistant", Content: response})
+				messages = append(messages, ChatMessage{Role: "user", Content: fmt.Sprintf("Tool result: %s\n\nContinue your reasoning.", result.Output)})
+			} else {
+				messages = append(messages, ChatMessage{Role: "assistant", Content: response})
+				messages = append(messages, ChatMessage{Role: "user", Content: "Tool limit reached. Continue reasoning without tools."})
+			}
+			continue
+		}
+
+		var step struct {
+			ThoughtNumber int    `json:"thought_number"`
+			Thought       string `json:"thought"`
+			IsFinal       bool   `json:"is_final"`
+			Answer        string `json:"answer"`
+		}
+
+		if err := json.Unmarshal([]byte(jsonStr), &step); err != nil {
+			thoughts = append(thoughts, response)
+		} else {
+			thoughts = append(thoughts, step.Thought)
+
+			r.emitProgress(ProgressUpdate{
+				Type:    "thought",
+				NodeID:  fmt.Sprintf("t%d", i+1),
+				Thought: utils.TruncateStr(step.Thought, 100),
+				Depth:   i + 1,
+			})
+
+			if step.IsFinal {
+				return thoughts, step.Answer, toolResults, nil
+			}
+		}
+
+		messages = append(messages, ChatMessage{Role: "assistant", Content: response})
+		messages = append(messages, ChatMessage{Role: "user", Content: "Continue your reasoning. Output another JSON object for your next thought."})
+	}
+
+	// Reached max thoughts or the attempt budget, ask for final answer
+	finalPrompt := "You've reached the maximum number of reasoning steps. Please provide your final answer now as a JSON object with is_final: true."
+	if budget.cutOff != "" {
+		r.emitProgress(ProgressUpdate{
+			Type:    "thought",
+			Message: fmt.Sprintf("Attempt %d ran out of its %s budget, asking for a final answer", attemptNum, budget.cutOff),
+		})
+		finalPrompt = "You've run out of budget for this attempt. Stop reasoning and give your best final answer now as a JSON object with is_final: true."
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: finalPrompt})
+
+	finalCtx, finalCancel := budget.finalContext(ctx)
+	defer finalCancel()
+	response, _, err := callLLM(finalCtx, budget.finalTokens(512))
+	if err != nil {
+		// A cut-off attempt still answers with its last thought
+		if budget.cutOff != "" && len(thoughts) > 0 && ctx.Err() == nil {
+			return thoughts, thoughts[len(thoughts)-1], toolResults, nil
+		}
+		return thoughts, "", toolResults, err
+	}
+	budget.charge(response)
+
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr != "" {
+		var step struct {
+			Answer string `json:"answer"`
+		}
+		if err := json.Unmarshal([]byte(jsonStr), &step); err == nil && step.Answer != "" {
+			return thoughts, step.Answer, toolResults, nil
+		}
+	}
+
+	// Fallback: use the response as the answer (with logging for observability)
+	fmt.Fprintf(os.Stderr, "[WARNING] reflexion: failed to extract final answer JSON at end of reasoning, using plain text fallback. Response preview: %s\n",
+		utils.TruncateStr(response, 100))
+	return thoughts, strings.TrimSpace(response), toolResults, nil
+}
+
+// evaluator returns the provider that judges answers
+func (r *Reflexion) evaluator() Provider {
+	if r.config.Evaluator != nil {
+		return r.config.Evaluator
+	}
+	return r.provider
+}
+
+// checkAnswer runs the hidden tests and success criteria validators on an
+// answer; it is correct only when every check passes
+func (r *Reflexion) checkAnswer(ctx context.Context, attempt *Attempt, thoughts []string, answer string) (string, bool, error) {
+	var summaries []string
+	isCorrect := true
+	if !r.config.HiddenTests.Empty() {
+		results, err := runHiddenTests(ctx, extractSolutionCode(answer, thoughts), r.config.HiddenTests)
+		if err != nil {
+			return "", false, err
+		}
+		attempt.TestResults = results
+		summary, passed := summarizeTestResults(results)
+		summaries = append(summaries, summary)
+		isCorrect = isCorrect && passed
+	}
+	if r.config.SuccessCriteria.Programmatic() {
+		results, err := r.config.SuccessCriteria.Validate(ctx, answer)
+		attempt.CriteriaResults = results
+		if err != nil {
+			return "", false, err
+		}
+		summary, passed := summarizeCriteriaResults(results)
+		summaries = append(summaries, summary)
+		isCorrect = isCorrect && passed
+	}
+	return strings.Join(summaries, " "), isCorrect, nil
+}
+
+// evaluateAnswer evaluates if the answer is correct/satisfactory
+func (r *Reflexion) evaluateAnswer(ctx context.Context, problem string, thoughts []string, answer string) (string, bool, *ScoreSamples, *EvaluationVotes, error) {
+	var thoughtsStr strings.Builder
+	for i, t := range thoughts {
+		thoughtsStr.WriteString(fmt.Sprintf("%d. %s\n", i+1, t))
+	}
+
+	prompt := fmt.Sprintf(`Evaluate this reasoning and answer for the given problem.
+
+Problem: %s
+
+Reasoning:
+%s
+
+Final Answer: %s
+
+Evaluate:
+1. Is the reasoning logical and sound?
+2. Does the answer correctly solve the problem?
+3. Are there any errors or gaps?
+%s
+%s
+
+Respond with ONLY a JSON object:
+{
+  "evaluation": "<brief evaluation of the reasoning and answer>",
+  "is_correct": <true if the answer is correct and well-reasoned, false otherwise>,
+  "issues": ["<list of any issues found>"]
+}`, problem, thoughtsStr.String(), answer, r.rubricSection(), strictnessInstruction(r.config.EvaluatorStrictness))
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a strict evaluator. Check reasoning carefully for errors."},
+		{Role: "user", Content: prompt},
+	}
+
+	if panel := r.voters(); len(panel) > 0 {
+		evaluation, isCorrect, votes, err := r.voteOnAnswer(ctx, panel, messages)
+		return evaluation, isCorrect, nil, votes, err
+	}
+	evaluation, isCorrect, samples, err := r.judge(ctx, r.evaluator(), messages, r.enableStreams)
+	return evaluation, isCorrect, samples, nil, err
+}
+
+// judge asks one evaluator for a verdict, sampling it when calibration is on
+func (r *Reflexion) judge(ctx context.Context, evaluator Provider, messages []ChatMessage, stream bool) (string, bool, *ScoreSamples, error) {
+	if !r.config.Calibration.enabled() {
+		evaluation, isCorrect, err := r.evaluateOnce(ctx, evaluator, messages, 0.3, stream)
+		return evaluation, isCorrect, nil, err
+	}
+
+	type verdict struct {
+		evaluation string
+		isCorrect  bool
+	}
+	// Samples are not streamed, so the tokens of several verdicts don't interleave
+	samples, err := sampleEvaluations(ctx, r.config.Calibration.Samples, func(ctx context.Context) (verdict, error) {
+		evaluation, isCorrect, err := r.evaluateOnce(ctx, evaluator, messages, r.config.Calibration.temperature(0.3), false)
+		return verdict{evaluation, isCorrect}, err
+	})
+	if err != nil {
+		return "", false, nil, err
+	}
+
+	scores := make([]float64, len(samples))
+	for i, s := range samples {
+		scores[i] = boolToFloat(s.isCorrect)
+	}
+	calibrated := summarizeScores(scores)
+	r.calibration.record(calibrated)
+
+	// Report the evaluation of a sample that agrees with the majority
+	isCorrect := calibrated.Mean > 0.5
+	evaluation := samples[0].evaluation
+	for _, s := range samples {
+		if s.isCorrect == isCorrect {
+			evaluation = s.evaluation
+			break
+		}
+	}
+	return evaluation, isCorrect, &calibrated, nil
+}
+
+// rubricSection is the caller's rubric for the evaluation prompt, if any
+func (r *Reflexion) rubricSection() string {
+	if r.config.SuccessCriteria == nil || r.config.SuccessCriteria.Rubric == "" {
+		return ""
+	}
+	return "4. Does the answer meet the caller's success criteria? The answer is correct only if it meets every one of them:\n" +
+		r.config.SuccessCriteria.Rubric + "\n"
+}
+
+// evaluateOnce asks the evaluator for one verdict on an answer
+func (r *Reflexion) evaluateOnce(ctx context.Context, evaluator Provider, messages []ChatMessage, temperature float64, stream bool) (string, bool, error) {
+	var response string
+	var err error
+
+	response, err = r.callProvider(ctx, evaluator, messages, ChatOptions{
+		Temperature: temperature,
+		MaxTokens:   512,
+	}, stream)
+	if err != nil {
+		return "", false, err
+	}
+
+	response, err = r.jsonRepair.repair(ctx, evaluator, messages, response, reflexionEvaluationSchema, ChatOptions{
+		Temperature: 0.1,
+		MaxTokens:   512,
+	})
+	if err != nil {
+		return "", false, err
+	}
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr == "" {
+		// Log JSON parsing failure for observability
+		fmt.Fprintf(os.Stderr, "[WARNING] reflexion: failed to extract JSON in evaluateAnswer, falling back to plain text. Response preview: %s\n",
+			utils.TruncateStr(response, 100))
+		return response, false, nil
+	}
+
+	var eval struct {
+		Evaluation string   `json:"evaluation"`
+		IsCorrect  bool     `json:"is_correct"`
+		Issues     []string `json:"issues"`
+	}
+
+	if err := json.Unmarshal([]byte(jsonStr), &eval); err != nil {
+		return response, false, nil
+	}
+
+	evalStr := eval.Evaluation
+	if len(eval.Issues) > 0 {
+		evalStr += " Issues: " + strings.Join(eval.Issues, "; ")
+	}
+
+	return evalStr, eval.IsCorrect, nil
+}
+
+// reflexionEvaluationSchema is the reply evaluateOnce asks for again when it gets no JSON
+const reflexionEvaluationSchema = `{"evaluation": "...", "is_correct": false, "issues": ["..."]}`
+
+// attemptProvider returns the provider for an attempt: the rotation entry
+// when AttemptProviders is set, otherwise the main provider
+func (r *Reflexion) attemptProvider(attemptNum int) AttemptProvider {
+	if n := len(r.config.AttemptProviders); n > 0 {
+		return r.config.AttemptProviders[min(attemptNum, n)-1]
+	}
+	return AttemptProvider{Label: r.provider.Name(), Provider: r.provider}
+}
+
+// priorApproaches returns the approach summaries of earlier attempts
+func (r *Reflexion) priorApproaches(attempts []Attempt) []string {
+	if !r.config.ForceDiversity {
+		return nil
+	}
+	var approaches []string
+	for _, a := range attempts {
+		if a.Approach != "" {
+			approaches = append(approaches, a.Approach)
+		}
+	}
+	return approaches
+}
+
+// summarizeApproach asks for a one-line description of the strategy an
+// attempt used, so later attempts can be told to avoid it
+func (r *Reflexion) summarizeApproach(ctx context.Context, problem string, thoughts []string, answer string) string {
+	prompt := fmt.Sprintf(`Problem: %s
+
+Reasoning:
+%s
+
+Answer: %s
+
+In one sentence, name the high-level strategy this reasoning used (e.g. "brute-force enumeration", "algebraic substitution", "recursion with memoization"). Respond with just the sentence.`, problem, strings.Join(thoughts, "\n"), answer)
+
+	response, err := r.provider.Chat(ctx, []ChatMessage{
+		{Role: "user", Content: prompt},
+	}, ChatOptions{
+		Temperature: 0.2,
+		MaxTokens:   80,
+	})
+	if err != nil {
+		return ""
+	}
+	return utils.TruncateStr(strings.TrimSpace(utils.StripChainOfThought(response)), 200)
+}
+
+// maxAttemptSimilarity returns the highest MinHash similarity between a new
+// attempt and any earlier one. It is cheap enough to run on every retry.
+func maxAttemptSimilarity(ctx context.Context, thoughts []string, answer string, prior []Attempt) float64 {
+	sim := newMinHashSimilarity(64)
+	text := strings.Join(thoughts, " ") + " " + answer
+	best := 0.0
+	for _, a := range prior {
+		if len(a.Thoughts) == 0 && a.Answer == "" {
+			continue
+		}
+		score, _ := sim.Similarity(ctx, text, strings.Join(a.Thoughts, " ")+" "+a.Answer)
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// generateReflection generates a reflection on what went wrong
+func (r *Reflexion) generateReflection(ctx context.Context, problem string, thoughts []string, answer, evaluation string) (string, error) {
+	var thoughtsStr strings.Builder
+	for i, t := range thoughts {
+		thoughtsStr.WriteString(fmt.Sprintf("%d. %s\n", i+1, t))
+	}
+
+	prompt := fmt.Sprintf(`The following reasoning attempt was not successful. Generate a reflection to improve the next attempt.
+
+Problem: %s
+
+Reasoning:
+%s
+
+Answer Given: %s
+
+Evaluation: %s
+
+Generate a brief, actionable reflection:
+1. What went wrong?
+2. What should be done differently next time?
+3. What key insight was missed?
+
+Respond with just the reflection text, no JSON.`, problem, thoughtsStr.String(), answer, evaluation)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a thoughtful analyst. Generate concise, actionable reflections on reasoning failures."},
+		{Role: "user", Content: prompt},
+	}
+
+	var response string
+	var err error
+
+	response, err = r.callProvider(ctx, r.provider, messages, ChatOptions{
+		Temperature: 0.5,
+		MaxTokens:   512,
+	}, r.enableStreams)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(response), nil
+}
+
+// getPastLessons retrieves relevant lessons from past similar problems
+func (r *Reflexion) getPastLessons(problem string) []string {
+	category := r.config.MemoryCategory
+	if category == "auto" {
+		category = ClassifyProblem(problem)
+	}
+	return r.memory.lessonsFor(problem, category, r.namespace)
+}
+
+// lessonsFor returns up to 3 reflections from failed attempts at problems
+// similar to problem in a namespace, most recent first. A non-empty category
+// restricts the search to that category; seed lessons match by keyword in
+// every namespace.
+func (m *EpisodicMemory) lessonsFor(problem, category, namespace string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	problemHash := hashProblem(problem)
+	var relevantEpisodes []Episode
+
+	// Find episodes with similar problems
+	for _, ep := range m.Episodes {
+		if ep.Seed {
+			if seedMatches(ep.Problem, problem) {
+				relevantEpisodes = append(relevantEpisodes, ep)
+			}
+			continue
+		}
+		if ep.Namespace != namespace {
+			continue
+		}
+		if category != "" && episodeCategory(ep) != category {
+			continue
+		}
+		// Check for exact match or similar hash
+		if ep.ProblemHash == problemHash || stringSimilarity(ep.Problem, problem) > 0.5 {
+			relevantEpisodes = append(relevantEpisodes, ep)
+		}
+	}
+
+	if len(relevantEpisodes) == 0 {
+		return nil
+	}
+
+	// Sort by timestamp (most recent first)
+	sort.Slice(relevantEpisodes, func(i, j int) bool {
+		return relevantEpisodes[i].Timestamp.After(relevantEpisodes[j].Timestamp)
+	})
+
+	// Extract lessons (reflections from failed attempts)
+	var lessons []string
+	seen := make(map[string]bool)
+
+	for _, ep := range relevantEpisodes {
+		if !ep.WasSuccessful && ep.Reflection != "" {
+			if !seen[ep.Reflection] {
+				lessons = append(lessons, ep.Reflection)
+				seen[ep.Reflection] = true
+			}
+		}
+		if len(lessons) >= 3 { // Limit to 3 lessons
+			break
+		}
+	}
+
+	return lessons
+}
+
+// storeEpisode stores a reasoning episode in memory
+func (r *Reflexion) storeEpisode(problem string, attempt int, thoughts []string, answer string, successful bool, failureReason, reflection string) {
+	r.memory.mu.Lock()
+	defer r.memory.mu.Unlock()
+
+	r.memory.add(Episode{
+		Problem:       problem,
+		Attempt:       attempt,
+		Thoughts:      thoughts,
+		FinalAnswer:   answer,
+		WasSuccessful: successful,
+		FailureReason: failureReason,
+		Reflection:    reflection,
+		Provider:      r.provider.Name(),
+		Namespace:     r.namespace,
+	}, r.config.MaxEpisodes, r.config.EpisodeTTL)
+}
+
+// loadOrCreateMemory loads existing memory or creates new
+func loadOrCreateMemory(path string) *EpisodicMemory {
+	memory := &EpisodicMemory{
+		Episodes: []Episode{},
+		path:     path,
+	}
+
+	// Ensure directory exists
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		// Log the error but continue with in-memory storage
+		// The memory will still work, just won't persist to disk
+		fmt.Fprintf(os.Stderr, "Warning: failed to create memory directory %s: %v (memory will not persist)\n", dir, err)
+		// Store a flag to indicate persistence is disabled
+		memory.path = ""
+		return memory
+	}
+
+	// Try to load existing memory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// File doesn't exist yet, that's okay
+		return memory
+	}
+
+	if err := json.Unmarshal(data, memory); err != nil {
+		// Invalid file - back up the corrupted file before starting fresh
+		// This prevents data loss if the file contains valuable information
+		backupPath := path + ".corrupted." + fmt.Sprintf("%d", time.Now().Unix())
+		if backupErr := os.WriteFile(backupPath, data, 0600); backupErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse memory file %s: %v (starting with empty memory)\n", path, err)
+			fmt.Fprintf(os.Stderr, "Warning: failed to create backup at %s: %v (original file may be overwritten on next save)\n", backupPath, backupErr)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse memory file %s: %v (starting with empty memory)\n", path, err)
+			fmt.Fprintf(os.Stderr, "Info: corrupted file backed up to %s for potential recovery\n", backupPath)
+		}
+		// Return empty memory with path still set - the backup is safe
+		return memory
+	}
+
+	memory.path = path
+	return memory
+}
+
+// save persists the memory to disk
+func (m *EpisodicMemory) save() {
+	// If path is empty, persistence is disabled (directory creation failed)
+	if m.path == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to marshal memory data: %v\n", err)
+		return
+	}
+
+	if err := os.WriteFile(m.path, data, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save memory to %s: %v\n", m.path, err)
+	}
+}
+
+// cleanup removes old episodes based on max count and TTL
+// maxEpisodes: maximum number of episodes to keep per namespace (0 = unlimited)
+// ttl: time-to-live for episodes (0 = no expiration)
+// Seed episodes are exempt from both limits. Returns the episodes removed.
+func (m *EpisodicMemory) cleanup(maxEpisodes int, ttl time.Duration) int {
+	now := time.Now()
+	before := len(m.Episodes)
+	var seeds, episodes []Episode
+	for _, ep := range m.Episodes {
+		if ep.Seed {
+			seeds = append(seeds, ep)
+		} else {
+			episodes = append(episodes, ep)
+		}
+	}
+
+	// First, filter by TTL if set
+	if ttl > 0 {
+		var filtered []Episode
+		cutoff := now.Add(-ttl)
+		for _, ep := range episodes {
+			if ep.Timestamp.After(cutoff) {
+				filtered = append(filtered, ep)
+			}
+		}
+		episodes = filtered
+	}
+
+	// Then, enforce max count limit if set, keeping each namespace's most
+	// recent episodes
+	if maxEpisodes > 0 && len(episodes) > maxEpisodes {
+		remaining := make(map[string]int)
+		for _, ep := range episodes {
+			remaining[ep.Namespace]++
+		}
+		var kept []Episode
+		for _, ep := range episodes {
+			if remaining[ep.Namespace] <= maxEpisodes {
+				kept = append(kept, ep)
+			}
+			remaining[ep.Namespace]--
+		}
+		episodes = kept
+	}
+
+	m.Episodes = append(seeds, episodes...)
+	return before - len(m.Episodes)
+}
+
+// hashProblem creates a hash for similarity matching
+func hashProblem(problem string) string {
+	// Normalize: lowercase, remove extra whitespace
+	normalized := strings.ToLower(strings.TrimSpace(problem))
+	normalized = strings.Join(strings.Fields(normalized), " ")
+
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
+
+// stringSimilarity computes a simple similarity score between strings
+func stringSimilarity(a, b string) float64 {
+	a = strings.ToLower(a)
+	b = strings.ToLower(b)
+
+	wordsA := strings.Fields(a)
+	wordsB := strings.Fields(b)
+
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	// Count common words
+	wordSetB := make(map[string]bool)
+	for _, w := range wordsB {
+		wordSetB[w] = true
+	}
+
+	common := 0
+	for _, w := range wordsA {
+		if wordSetB[w] {
+			common++
+		}
+	}
+
+	// Jaccard-like similarity
+	return float64(common) / float64(len(wordsA)+len(wordsB)-common)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1.0
+	}
+	return 0.0
+}
+
+// FormatReflexionResult formats the result for display
+func FormatReflexionResult(result *ReflexionResult) string {
+	var sb strings.Builder
+	l := func(english string) string { return label(result.Language, english) }
+
+	sb.WriteString(fmt.Sprintf("## %s\n\n", l("Reflexion Reasoning Result")))
+	sb.WriteString(fmt.Sprintf("**%s:** %s\n\n", l("Problem"), result.Problem))
+	sb.WriteString(fmt.Sprintf("**%s:** %s\n", l("Provider"), result.Provider))
+	sb.WriteString(fmt.Sprintf("**%s:** %d\n", l("Total Attempts"), result.TotalAttempts))
+	sb.WriteString(fmt.Sprintf("**%s:** %v\n", l("Success"), result.Success))
+	if result.TotalToolCalls > 0 {
+		sb.WriteString(fmt.Sprintf("**%s:** %d\n", l("Total Tool Calls"), result.TotalToolCalls))
+	}
+	sb.WriteString("\n")
+
+	if len(result.LessonsLearned) > 0 {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", l("Lessons from Past (Applied)")))
+		for _, lesson := range result.LessonsLearned {
+			sb.WriteString(fmt.Sprintf("- %s\n", utils.TruncateStr(lesson, 100)))
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, attempt := range result.Attempts {
+		sb.WriteString(fmt.Sprintf("### %s %d\n\n", l("Attempt"), attempt.Number))
+
+		sb.WriteString(fmt.Sprintf("**%s:**\n", l("Reasoning")))
+		for i, thought := range attempt.Thoughts {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, utils.TruncateStr(thought, 150)))
+		}
+		sb.WriteString("\n")
+
+		// Display tool results for this attempt
+		if len(attempt.ToolResults) > 0 {
+			sb.WriteString(fmt.Sprintf("**%s:**\n", l("Tools Used")))
+			for _, tr := range attempt.ToolResults {
+				sb.WriteString(fmt.Sprintf("- `%s(%s)` → %s\n", tr.Tool, utils.TruncateStr(tr.Input, 30), utils.TruncateStr(tr.Output, 50)))
+			}
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString(fmt.Sprintf("**%s:** %s\n\n", l("Answer"), attempt.Answer))
+		sb.WriteString(fmt.Sprintf("**%s:** %s\n\n", l("Evaluation"), attempt.Evaluation))
+
+		if attempt.WasSuccessful {
+			sb.WriteString(fmt.Sprintf("**%s:** %s!\n\n", l("Result"), l("Success")))
+		} else if attempt.Reflection != "" {
+			sb.WriteString(fmt.Sprintf("**%s:** %s\n\n", l("Reflection"), attempt.Reflection))
+		}
+
+		sb.WriteString("---\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("### %s\n\n%s\n", l("Final Answer"), result.FinalAnswer))
+
+	// JSON summary
+	summaryMap := map[string]interface{}{
+		"success":        result.Success,
+		"total_attempts": result.TotalAttempts,
+		"final_answer":   result.FinalAnswer,
+		"provider":       result.Provider,
+	}
+	if result.TotalToolCalls > 0 {
+		summaryMap["total_tool_calls"] = result.TotalToolCalls
+		summaryMap["tools_used"] = result.ToolsUsed
+	}
+	jsonResult, _ := json.MarshalIndent(summaryMap, "", "  ")
+
+	sb.WriteString(fmt.Sprintf("\n### %s\n```json\n", l("JSON Summary")))
+	sb.WriteString(string(jsonResult))
+	sb.WriteString("\n```\n")
+
+	return sb.String()
+}
+
+// ClearMemory clears the episodic memory (for testing)
+func (r *Reflexion) ClearMemory() {
+	r.memory.mu.Lock()
+	defer r.memory.mu.Unlock()
+	r.memory.Episodes = []Episode{}
+	r.memory.save()
+}
+
+// GetMemoryStats returns statistics about the memory
+func (r *Reflexion) GetMemoryStats() map[string]interface{} {
+	r.memory.mu.RLock()
+	defer r.memory.mu.RUnlock()
+
+	successful := 0
+	failed := 0
+	seeds := 0
+	categories := make(map[string]int)
+	namespaces := make(map[string]int)
+	for _, ep := range r.memory.Episodes {
+		switch {
+		case ep.Seed:
+			seeds++
+			continue
+		case ep.WasSuccessful:
+			successful++
+		default:
+			failed++
+		}
+		categories[episodeCategory(ep)]++
+		namespaces[namespaceLabel(ep.Namespace)]++
+	}
+
+	return map[string]interface{}{
+		"total_episodes":      len(r.memory.Episodes),
+		"successful_episodes": successful,
+		"failed_episodes":     failed,
+		"seed_lessons":        seeds,
+		"categories":          categories,
+		"namespaces":          namespaces,
+		"memory_path":         r.memory.path,
+	}
+}
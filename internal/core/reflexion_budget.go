@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Budget cutoff reasons reported in AttemptBudget.CutOff
+const (
+	BudgetCutOffTokens = "tokens"
+	BudgetCutOffTime   = "time"
+)
+
+const (
+	// Tokens kept back from the thought loop so a cut-off attempt can still
+	// produce a final answer
+	budgetFinalAnswerReserve = 256
+	// Extra time allowed for the final answer once the time budget runs out
+	budgetFinalAnswerGrace = 20 * time.Second
+	// A thought step with less room than this is not worth starting
+	budgetMinStepTokens = 64
+)
+
+// AttemptBudget reports what an attempt was allowed and what it consumed.
+// Tokens are estimated from completion length (about 4 characters per token).
+type AttemptBudget struct {
+	TokensAllowed int    `json:"tokens_allowed,omitempty"` // Including carry-over from earlier attempts
+	TokensUsed    int    `json:"tokens_used"`
+	TimeAllowedMs int64  `json:"time_allowed_ms,omitempty"`
+	TimeUsedMs    int64  `json:"time_used_ms"`
+	CutOff        string `json:"cut_off,omitempty"` // "tokens" or "time" when the attempt was cut short
+}
+
+// attemptBudget tracks one attempt's token and time allowance. A zero limit
+// means unlimited.
+type attemptBudget struct {
+	maxTokens int
+	timeout   time.Duration
+	start     time.Time
+	deadline  time.Time
+	used      int
+	cutOff    string
+}
+
+// newAttemptBudget starts an attempt's budget: the configured per-attempt
+// limits plus whatever earlier attempts left unused
+func newAttemptBudget(maxTokens int, timeout time.Duration, carryTokens int, carryTime time.Duration) *attemptBudget {
+	b := &attemptBudget{start: time.Now()}
+	if maxTokens > 0 {
+		b.maxTokens = maxTokens + carryTokens
+	}
+	if timeout > 0 {
+		b.timeout = timeout + carryTime
+		b.deadline = b.start.Add(b.timeout)
+	}
+	return b
+}
+
+// context bounds generation by the attempt's deadline
+func (b *attemptBudget) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, b.deadline)
+}
+
+// finalContext gives the final answer a short grace period past the deadline
+func (b *attemptBudget) finalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.cutOff != BudgetCutOffTime {
+		return b.context(ctx)
+	}
+	return context.WithTimeout(ctx, budgetFinalAnswerGrace)
+}
+
+// stepTokens returns the max tokens for the next thought, or 0 with the
+// cutoff recorded when the budget no longer allows one
+func (b *attemptBudget) stepTokens(want int) int {
+	if !b.deadline.IsZero() && !time.Now().Before(b.deadline) {
+		b.cutOff = BudgetCutOffTime
+		return 0
+	}
+	if b.maxTokens == 0 {
+		return want
+	}
+	room := b.maxTokens - b.used - budgetFinalAnswerReserve
+	if room < budgetMinStepTokens {
+		b.cutOff = BudgetCutOffTokens
+		return 0
+	}
+	return min(want, room)
+}
+
+// finalTokens returns the max tokens for the closing answer. It always allows
+// at least the reserve so a cut-off attempt still answers.
+func (b *attemptBudget) finalTokens(want int) int {
+	if b.maxTokens == 0 {
+		return want
+	}
+	return min(want, max(b.maxTokens-b.used, budgetFinalAnswerReserve))
+}
+
+// charge records the estimated tokens of a completion
+func (b *attemptBudget) charge(completion string) {
+	b.used += estimateTokens(completion)
+}
+
+// timedOut reports whether err was caused by the attempt deadline rather
+// than the caller's context, and records the cutoff
+func (b *attemptBudget) timedOut(parent context.Context, err error) bool {
+	if err == nil || b.deadline.IsZero() || parent.Err() != nil || time.Now().Before(b.deadline) {
+		return false
+	}
+	b.cutOff = BudgetCutOffTime
+	return true
+}
+
+// report summarizes the budget for the attempt result
+func (b *attemptBudget) report() *AttemptBudget {
+	return &AttemptBudget{
+		TokensAllowed: b.maxTokens,
+		TokensUsed:    b.used,
+		TimeAllowedMs: b.timeout.Milliseconds(),
+		TimeUsedMs:    time.Since(b.start).Milliseconds(),
+		CutOff:        b.cutOff,
+	}
+}
+
+// leftover returns the unused tokens and time to carry into the next attempt
+func (b *attemptBudget) leftover() (int, time.Duration) {
+	var tokens int
+	var remaining time.Duration
+	if b.maxTokens > 0 {
+		tokens = max(b.maxTokens-b.used, 0)
+	}
+	if b.timeout > 0 {
+		remaining = max(time.Until(b.deadline), 0)
+	}
+	return tokens, remaining
+}
+
+// estimateTokens approximates a token count at about 4 characters per token
+func estimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
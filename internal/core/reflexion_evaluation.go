@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ============ Reflexion Evaluation Strictness and Voting ============
+//
+// One evaluator verdict decides whether the reflexion loop stops, and a
+// single low-temperature judgment flips between otherwise similar attempts.
+// Strictness sets how high the evaluator's bar is, and voting asks a panel
+// of K evaluators, which may be different providers/models, and lets the
+// majority decide.
+
+// Evaluation strictness levels
+const (
+	StrictnessLenient = "lenient"
+	StrictnessNormal  = "normal"
+	StrictnessStrict  = "strict"
+)
+
+const maxEvaluatorVotes = 9
+
+// parseStrictness normalizes a strictness level ("" is normal)
+func parseStrictness(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", StrictnessNormal:
+		return StrictnessNormal, nil
+	case StrictnessLenient:
+		return StrictnessLenient, nil
+	case StrictnessStrict:
+		return StrictnessStrict, nil
+	}
+	return "", fmt.Errorf("unknown evaluator_strictness %q: use lenient, normal or strict", s)
+}
+
+// strictnessInstruction is the bar for "is_correct" at a strictness level
+func strictnessInstruction(strictness string) string {
+	switch strictness {
+	case StrictnessLenient:
+		return "Be lenient: mark the answer correct if its final result is right, even when the reasoning is brief, informal or has minor slips that do not affect the result."
+	case StrictnessStrict:
+		return "Be strict: mark the answer correct only if the final result is right AND every step is justified and error-free. Any unsupported step, calculation error, ambiguity or unaddressed part of the problem makes it incorrect; when in doubt, mark it incorrect."
+	}
+	return "Mark the answer correct if it correctly solves the problem with sound reasoning."
+}
+
+// EvaluatorVote is one panel member's verdict
+type EvaluatorVote struct {
+	Evaluator  string `json:"evaluator"`
+	IsCorrect  bool   `json:"is_correct"`
+	Evaluation string `json:"evaluation,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// EvaluationVotes is the outcome of a panel evaluation
+type EvaluationVotes struct {
+	Votes   []EvaluatorVote `json:"votes"`
+	Correct int             `json:"correct"`
+	Counted int             `json:"counted"` // Votes that returned a verdict
+}
+
+// voters returns the panel for K-evaluator voting: the evaluators list
+// cycled to K entries, or the evaluator K times
+func (r *Reflexion) voters() []AttemptProvider {
+	k := r.config.EvaluatorVotes
+	if len(r.config.Evaluators) > 0 && k <= 1 {
+		k = len(r.config.Evaluators)
+	}
+	if k <= 1 {
+		return nil
+	}
+	panel := make([]AttemptProvider, k)
+	for i := range panel {
+		if n := len(r.config.Evaluators); n > 0 {
+			panel[i] = r.config.Evaluators[i%n]
+		} else {
+			panel[i] = AttemptProvider{Label: r.evaluator().Name(), Provider: r.evaluator()}
+		}
+	}
+	return panel
+}
+
+// voteOnAnswer asks each panel member for a verdict; the answer is correct
+// when a strict majority of the verdicts returned say so
+func (r *Reflexion) voteOnAnswer(ctx context.Context, panel []AttemptProvider, messages []ChatMessage) (string, bool, *EvaluationVotes, error) {
+	votes := &EvaluationVotes{}
+	var lastErr error
+	for _, voter := range panel {
+		if ctx.Err() != nil {
+			lastErr = ctx.Err()
+			break
+		}
+		evaluation, isCorrect, _, err := r.judge(ctx, voter.Provider, messages, false)
+		vote := EvaluatorVote{Evaluator: voter.Label, IsCorrect: isCorrect, Evaluation: evaluation}
+		if err != nil {
+			lastErr = err
+			vote.Error = err.Error()
+			votes.Votes = append(votes.Votes, vote)
+			if errors.Is(err, ErrLLMCallBudgetExhausted) {
+				break
+			}
+			continue
+		}
+		votes.Votes = append(votes.Votes, vote)
+		votes.Counted++
+		if isCorrect {
+			votes.Correct++
+		}
+	}
+	if votes.Counted == 0 {
+		return "", false, votes, lastErr
+	}
+
+	// Report the evaluation of a voter that agrees with the majority
+	isCorrect := votes.Correct*2 > votes.Counted
+	evaluation := ""
+	for _, v := range votes.Votes {
+		if v.Error == "" && v.IsCorrect == isCorrect {
+			evaluation = v.Evaluation
+			break
+		}
+	}
+	return fmt.Sprintf("%d/%d evaluators judged it correct. %s", votes.Correct, votes.Counted, evaluation), isCorrect, votes, nil
+}
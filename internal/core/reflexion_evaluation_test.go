@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// promptRecorder answers every call the same way and keeps the user prompts
+type promptRecorder struct {
+	response string
+	prompts  []string
+}
+
+func (p *promptRecorder) Name() string { return "recorder" }
+
+func (p *promptRecorder) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	p.prompts = append(p.prompts, messages[len(messages)-1].Content)
+	return p.response, nil
+}
+
+func TestReflexionEvaluate_Strictness(t *testing.T) {
+	for strictness, want := range map[string]string{
+		StrictnessLenient: "Be lenient",
+		StrictnessNormal:  "correctly solves the problem with sound reasoning",
+		StrictnessStrict:  "when in doubt, mark it incorrect",
+	} {
+		provider := &promptRecorder{response: `{"evaluation": "ok", "is_correct": true, "issues": []}`}
+		config := DefaultReflexionConfig()
+		config.EvaluatorStrictness = strictness
+		if _, _, _, _, err := NewReflexion(provider, config).evaluateAnswer(context.Background(), "What is 6*7?", []string{"6*7=42"}, "42"); err != nil {
+			t.Fatal(err)
+		}
+		if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], want) {
+			t.Errorf("%s: expected the prompt to contain %q", strictness, want)
+		}
+	}
+
+	if _, err := parseStrictness("harsh"); err == nil {
+		t.Error("Expected an unknown strictness to be rejected")
+	}
+	if s, _ := parseStrictness(" Strict "); s != StrictnessStrict {
+		t.Errorf("Expected strict, got %q", s)
+	}
+}
+
+func TestReflexionEvaluate_MajorityVote(t *testing.T) {
+	approve := `{"evaluation": "correct", "is_correct": true, "issues": []}`
+	reject := `{"evaluation": "wrong", "is_correct": false, "issues": ["off by one"]}`
+
+	// The evaluator alone flips between verdicts; three votes give the majority
+	provider := &sampleProvider{responses: []string{approve, reject, approve}}
+	config := DefaultReflexionConfig()
+	config.EvaluatorVotes = 3
+	evaluation, isCorrect, _, votes, err := NewReflexion(provider, config).evaluateAnswer(context.Background(), "What is 6*7?", []string{"6*7=42"}, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCorrect || votes == nil || votes.Correct != 2 || votes.Counted != 3 || evaluation != "2/3 evaluators judged it correct. correct" {
+		t.Errorf("Expected a 2/3 majority, got %v %q %+v", isCorrect, evaluation, votes)
+	}
+
+	// A panel of providers, cycled to four votes; a tie is not a majority
+	config.EvaluatorVotes = 4
+	config.Evaluators = []AttemptProvider{
+		{Label: "critic-a", Provider: &sampleProvider{responses: []string{approve}}},
+		{Label: "critic-b", Provider: &sampleProvider{responses: []string{reject}}},
+	}
+	_, isCorrect, _, votes, err = NewReflexion(provider, config).evaluateAnswer(context.Background(), "What is 6*7?", []string{"6*7=42"}, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isCorrect || len(votes.Votes) != 4 || votes.Votes[2].Evaluator != "critic-a" || votes.Correct != 2 {
+		t.Errorf("Expected a 2/4 tie to count as incorrect, got %v %+v", isCorrect, votes)
+	}
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// TestCase is a hidden input/expected pair for a coding problem. Input is a
+// Python expression evaluated against the solution (e.g. "add(2, 3)");
+// Expected is a Python literal or the expected str() of the result.
+type TestCase struct {
+	Input    string `json:"input"`
+	Expected string `json:"expected"`
+}
+
+// HiddenTests are caller-supplied checks that decide success for coding
+// problems instead of LLM judgment
+type HiddenTests struct {
+	Cases  []TestCase `json:"cases,omitempty"`
+	Script string     `json:"script,omitempty"` // Assert statements and/or pytest-style test_* functions
+}
+
+// Empty reports whether no tests were supplied
+func (h *HiddenTests) Empty() bool {
+	return h == nil || (len(h.Cases) == 0 && strings.TrimSpace(h.Script) == "")
+}
+
+// TestCaseResult is the outcome of one hidden test
+type TestCaseResult struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ParseHiddenTests accepts a JSON array of {"input", "expected"} pairs or a
+// Python test snippet
+func ParseHiddenTests(raw string) (*HiddenTests, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(raw, "[") {
+		var cases []map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &cases); err != nil {
+			return nil, fmt.Errorf("invalid test_cases JSON: %w", err)
+		}
+		tests := &HiddenTests{}
+		for i, c := range cases {
+			input, ok := c["input"].(string)
+			if !ok || strings.TrimSpace(input) == "" {
+				return nil, fmt.Errorf("test case %d: input must be a non-empty Python expression", i+1)
+			}
+			expected, ok := c["expected"].(string)
+			if !ok {
+				// Allow JSON numbers, booleans, etc. and compare their Python form
+				data, _ := json.Marshal(c["expected"])
+				expected = pythonLiteral(string(data))
+			}
+			tests.Cases = append(tests.Cases, TestCase{Input: input, Expected: expected})
+		}
+		return tests, nil
+	}
+	return &HiddenTests{Script: raw}, nil
+}
+
+// pythonLiteral converts JSON scalars to their Python spelling
+func pythonLiteral(jsonValue string) string {
+	switch jsonValue {
+	case "true":
+		return "True"
+	case "false":
+		return "False"
+	case "null":
+		return "None"
+	}
+	return jsonValue
+}
+
+var pythonBlockPattern = regexp.MustCompile("(?s)```(?:python|py)?[ \\t]*\\n(.*?)```")
+
+// extractSolutionCode returns the Python code in the answer's fenced code
+// blocks, falling back to the last code block in the reasoning, then to the
+// raw answer
+func extractSolutionCode(answer string, thoughts []string) string {
+	if blocks := pythonBlockPattern.FindAllStringSubmatch(answer, -1); len(blocks) > 0 {
+		var parts []string
+		for _, b := range blocks {
+			parts = append(parts, strings.TrimSpace(b[1]))
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	for i := len(thoughts) - 1; i >= 0; i-- {
+		if blocks := pythonBlockPattern.FindAllStringSubmatch(thoughts[i], -1); len(blocks) > 0 {
+			return strings.TrimSpace(blocks[len(blocks)-1][1])
+		}
+	}
+	return strings.TrimSpace(answer)
+}
+
+const testResultsMarker = "__REFLEXION_TEST_RESULTS__"
+
+// testHarnessTemplate loads the solution into a namespace, evaluates each
+// case, runs the script (module-level asserts plus any test_* functions) and
+// prints the results as JSON after a marker line. The %s placeholders are
+// JSON-encoded, which is also valid Python string/list literal syntax.
+const testHarnessTemplate = `import json, traceback
+
+_solution = %s
+_cases = %s
+_script = %s
+_results = []
+_ns = {"__name__": "__solution__"}
+
+def _report():
+    print("` + testResultsMarker + `" + json.dumps(_results))
+
+try:
+    exec(compile(_solution, "solution.py", "exec"), _ns)
+except BaseException as e:
+    _results.append({"name": "load solution", "passed": False, "error": traceback.format_exception_only(type(e), e)[-1].strip()})
+    _report()
+    raise SystemExit(0)
+
+for _c in _cases:
+    _r = {"name": _c["input"], "expected": _c["expected"], "passed": False}
+    try:
+        _got = eval(_c["input"], _ns)
+        _r["actual"] = repr(_got)
+        try:
+            _want = eval(_c["expected"], _ns)
+        except Exception:
+            _want = _c["expected"]
+        _r["passed"] = _got == _want or str(_got) == _c["expected"]
+    except BaseException as e:
+        _r["error"] = traceback.format_exception_only(type(e), e)[-1].strip()
+    _results.append(_r)
+
+if _script:
+    _before = set(_ns)
+    try:
+        exec(compile(_script, "tests.py", "exec"), _ns)
+        _tests = [n for n in _ns if n.startswith("test_") and n not in _before and callable(_ns[n])]
+        if not _tests:
+            _results.append({"name": "tests.py", "passed": True})
+        for _name in _tests:
+            try:
+                _ns[_name]()
+                _results.append({"name": _name, "passed": True})
+            except BaseException as e:
+                _results.append({"name": _name, "passed": False, "error": traceback.format_exception_only(type(e), e)[-1].strip()})
+    except BaseException as e:
+        _results.append({"name": "tests.py", "passed": False, "error": traceback.format_exception_only(type(e), e)[-1].strip()})
+
+_report()
+`
+
+func buildTestHarness(solution string, tests *HiddenTests) string {
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	cases := tests.Cases
+	if cases == nil {
+		cases = []TestCase{}
+	}
+	return fmt.Sprintf(testHarnessTemplate, encode(solution), encode(cases), encode(tests.Script))
+}
+
+// parseTestHarnessOutput reads the results printed after the marker
+func parseTestHarnessOutput(stdout string) ([]TestCaseResult, error) {
+	idx := strings.LastIndex(stdout, testResultsMarker)
+	if idx < 0 {
+		return nil, fmt.Errorf("test harness produced no results")
+	}
+	line := stdout[idx+len(testResultsMarker):]
+	if nl := strings.IndexByte(line, '\n'); nl >= 0 {
+		line = line[:nl]
+	}
+	var results []TestCaseResult
+	if err := json.Unmarshal([]byte(line), &results); err != nil {
+		return nil, fmt.Errorf("invalid test harness output: %w", err)
+	}
+	return results, nil
+}
+
+// runHiddenTests executes the solution against the hidden tests using the
+// configured code execution backend
+func runHiddenTests(ctx context.Context, solution string, tests *HiddenTests) ([]TestCaseResult, error) {
+	backend, err := codeExecBackendFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	// The harness itself needs exec/eval, so on the unsandboxed backend
+	// everything it runs besides itself goes through the blocklist: the
+	// solution, and the caller's case inputs, expected values and script
+	if !backend.Sandboxed() {
+		if err := validatePythonCode(ctx, solution); err != nil {
+			return []TestCaseResult{{Name: "load solution", Error: fmt.Sprintf("code validation failed: %v", err)}}, nil
+		}
+		if name, err := validateHiddenTests(ctx, tests); err != nil {
+			return []TestCaseResult{{Name: name, Error: fmt.Sprintf("code validation failed: %v", err)}}, nil
+		}
+	}
+
+	timeout := GetConfig().CodeExecTimeout
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, stderr, err := backend.Run(execCtx, buildTestHarness(solution, tests))
+	if execCtx.Err() == context.DeadlineExceeded {
+		return []TestCaseResult{{Name: "run tests", Error: fmt.Sprintf("execution timed out (%v limit)", timeout)}}, nil
+	}
+	results, parseErr := parseTestHarnessOutput(stdout)
+	if parseErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("test execution failed: %w (%s)", err, utils.TruncateStr(strings.TrimSpace(stderr), 200))
+		}
+		return nil, parseErr
+	}
+	return results, nil
+}
+
+// validateHiddenTests checks every case input, expected value and the
+// script against the blocklist, naming the first part that fails
+func validateHiddenTests(ctx context.Context, tests *HiddenTests) (string, error) {
+	for i, c := range tests.Cases {
+		if err := validatePythonCode(ctx, c.Input); err != nil {
+			return fmt.Sprintf("test case %d input", i+1), err
+		}
+		if err := validatePythonCode(ctx, c.Expected); err != nil {
+			return fmt.Sprintf("test case %d expected", i+1), err
+		}
+	}
+	if strings.TrimSpace(tests.Script) != "" {
+		if err := validatePythonCode(ctx, tests.Script); err != nil {
+			return "tests.py", err
+		}
+	}
+	return "", nil
+}
+
+// summarizeTestResults builds the evaluation text fed into reflection
+func summarizeTestResults(results []TestCaseResult) (string, bool) {
+	return summarizeChecks("Hidden tests", results)
+}
+
+// summarizeChecks reports how many checks passed and why the others failed
+func summarizeChecks(label string, results []TestCaseResult) (string, bool) {
+	passed := 0
+	var failures []string
+	for _, r := range results {
+		if r.Passed {
+			passed++
+			continue
+		}
+		detail := r.Name
+		switch {
+		case r.Error != "":
+			detail += ": " + r.Error
+		case r.Expected != "":
+			detail += fmt.Sprintf(": expected %s, got %s", r.Expected, r.Actual)
+		}
+		failures = append(failures, detail)
+	}
+
+	allPassed := len(results) > 0 && passed == len(results)
+	summary := fmt.Sprintf("%s: %d/%d passed.", label, passed, len(results))
+	if len(failures) > 0 {
+		summary += " Failed: " + strings.Join(failures, "; ")
+	}
+	return summary, allPassed
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParseHiddenTests(t *testing.T) {
+	tests, err := ParseHiddenTests(`[{"input": "add(2, 3)", "expected": "5"}, {"input": "is_even(3)", "expected": false}]`)
+	if err != nil {
+		t.Fatalf("ParseHiddenTests failed: %v", err)
+	}
+	if len(tests.Cases) != 2 || tests.Cases[1].Expected != "False" {
+		t.Errorf("Unexpected cases: %+v", tests.Cases)
+	}
+
+	script, err := ParseHiddenTests("assert add(1, 1) == 2")
+	if err != nil || script.Script == "" || len(script.Cases) != 0 {
+		t.Errorf("Expected snippet to be kept as a script, got %+v, %v", script, err)
+	}
+
+	if _, err := ParseHiddenTests(`[{"expected": "5"}]`); err == nil {
+		t.Error("Expected error for case without input")
+	}
+	if empty, _ := ParseHiddenTests("  "); !empty.Empty() {
+		t.Error("Expected blank input to yield no tests")
+	}
+}
+
+func TestExtractSolutionCode(t *testing.T) {
+	answer := "Here is the fix:\n```python\ndef add(a, b):\n    return a + b\n```\nDone."
+	if got := extractSolutionCode(answer, nil); got != "def add(a, b):\n    return a + b" {
+		t.Errorf("Unexpected code from answer: %q", got)
+	}
+
+	thoughts := []string{"```python\ndef add(a, b):\n    return a - b\n```", "```py\ndef add(a, b):\n    return a + b\n```"}
+	if got := extractSolutionCode("See above", thoughts); !strings.Contains(got, "a + b") {
+		t.Errorf("Expected last code block from reasoning, got %q", got)
+	}
+}
+
+func TestRunHiddenTests(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	t.Setenv("CODE_EXEC_BACKEND", "")
+
+	tests := &HiddenTests{
+		Cases: []TestCase{
+			{Input: "add(2, 3)", Expected: "5"},
+			{Input: "add('a', 'b')", Expected: "'ab'"},
+			{Input: "add(1, 1)", Expected: "3"},
+		},
+		Script: "def test_negative():\n    assert add(-1, -1) == -2\n\ndef test_broken():\n    assert add(0, 0) == 1, 'zero'\n",
+	}
+	results, err := runHiddenTests(context.Background(), "def add(a, b):\n    print('noise')\n    return a + b", tests)
+	if err != nil {
+		t.Fatalf("runHiddenTests failed: %v", err)
+	}
+
+	passed := map[string]bool{}
+	for _, r := range results {
+		passed[r.Name] = r.Passed
+	}
+	want := map[string]bool{"add(2, 3)": true, "add('a', 'b')": true, "add(1, 1)": false, "test_negative": true, "test_broken": false}
+	for name, ok := range want {
+		if got, exists := passed[name]; !exists || got != ok {
+			t.Errorf("Test %s: passed=%v (present=%v), want %v", name, got, exists, ok)
+		}
+	}
+
+	summary, allPassed := summarizeTestResults(results)
+	if allPassed || !strings.Contains(summary, "3/5 passed") || !strings.Contains(summary, "expected 3, got 2") {
+		t.Errorf("Unexpected summary %q (all passed: %v)", summary, allPassed)
+	}
+
+	// Blocked code fails the tests instead of running on the host
+	results, err = runHiddenTests(context.Background(), "import os\ndef add(a, b): return a + b", tests)
+	if err != nil {
+		t.Fatalf("runHiddenTests failed: %v", err)
+	}
+	if _, ok := summarizeTestResults(results); ok || len(results) != 1 {
+		t.Errorf("Expected blocked solution to fail, got %+v", results)
+	}
+
+	// So do blocked case inputs, expected values and scripts, which the
+	// harness would otherwise eval or exec
+	solution := "def add(a, b): return a + b"
+	for name, malicious := range map[string]*HiddenTests{
+		"input":    {Cases: []TestCase{{Input: "add(1, 1)", Expected: "2"}, {Input: "__import__('os').system('touch /tmp/pwned')", Expected: "0"}}},
+		"expected": {Cases: []TestCase{{Input: "add(1, 1)", Expected: "open('/etc/passwd').read()"}}},
+		"script":   {Script: "import subprocess\nsubprocess.run(['true'])"},
+	} {
+		results, err := runHiddenTests(context.Background(), solution, malicious)
+		if err != nil {
+			t.Fatalf("%s: runHiddenTests failed: %v", name, err)
+		}
+		if _, ok := summarizeTestResults(results); ok || len(results) != 1 || !strings.Contains(results[0].Error, "code validation failed") {
+			t.Errorf("%s: expected the blocked test code to be rejected, got %+v", name, results)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"reasoning-tools/utils"
+)
+
+// SuccessCriteria are caller-supplied rules for judging reflexion answers.
+// A rubric guides the LLM evaluator; any programmatic validator (regex,
+// numeric or script) decides success instead of the evaluator.
+type SuccessCriteria struct {
+	Rubric    string   `json:"rubric,omitempty"`    // Natural-language criteria for the evaluator
+	Regex     string   `json:"regex,omitempty"`     // The answer must match this (RE2) pattern
+	Numeric   *float64 `json:"numeric,omitempty"`   // The answer's last number must equal this...
+	Tolerance float64  `json:"tolerance,omitempty"` // ...within this absolute tolerance (default: 1e-6 relative)
+	Script    string   `json:"script,omitempty"`    // Python asserts run with answer and numbers defined
+
+	pattern *regexp.Regexp
+}
+
+// Programmatic reports whether a validator decides success
+func (c *SuccessCriteria) Programmatic() bool {
+	return c != nil && (c.pattern != nil || c.Numeric != nil || strings.TrimSpace(c.Script) != "")
+}
+
+// ParseSuccessCriteria accepts a JSON object of criteria, or plain text used
+// as the rubric
+func ParseSuccessCriteria(raw string) (*SuccessCriteria, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(raw, "{") {
+		return &SuccessCriteria{Rubric: raw}, nil
+	}
+
+	var criteria SuccessCriteria
+	if err := json.Unmarshal([]byte(raw), &criteria); err != nil {
+		return nil, fmt.Errorf("invalid success_criteria JSON: %w", err)
+	}
+	return NewSuccessCriteria(criteria)
+}
+
+// NewSuccessCriteria checks criteria and compiles their regex; criteria
+// built any other way have no regex validator
+func NewSuccessCriteria(criteria SuccessCriteria) (*SuccessCriteria, error) {
+	criteria.Rubric = strings.TrimSpace(criteria.Rubric)
+	if criteria.Regex != "" {
+		pattern, err := regexp.Compile(criteria.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success_criteria regex: %w", err)
+		}
+		criteria.pattern = pattern
+	}
+	if criteria.Tolerance < 0 {
+		return nil, fmt.Errorf("success_criteria tolerance must not be negative")
+	}
+	if criteria.Rubric == "" && !criteria.Programmatic() {
+		return nil, fmt.Errorf("success_criteria needs a rubric, regex, numeric or script")
+	}
+	return &criteria, nil
+}
+
+// answerNumbers returns the numbers in an answer, in order
+func answerNumbers(answer string) []float64 {
+	numbers := []float64{}
+	for _, s := range evalNumberPattern.FindAllString(answer, -1) {
+		if n, ok := parseEvalNumber(s); ok {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// checkNumeric compares the answer's last number with the expected value
+func (c *SuccessCriteria) checkNumeric(answer string) TestCaseResult {
+	expected := *c.Numeric
+	result := TestCaseResult{Name: "numeric", Expected: fmt.Sprintf("%g", expected)}
+	numbers := answerNumbers(answer)
+	if len(numbers) == 0 {
+		result.Error = "the answer contains no number"
+		return result
+	}
+	got := numbers[len(numbers)-1]
+	tolerance := c.Tolerance
+	if tolerance == 0 {
+		tolerance = 1e-6 * math.Max(1, math.Abs(expected))
+	}
+	result.Actual = fmt.Sprintf("%g", got)
+	result.Passed = math.Abs(got-expected) <= tolerance
+	return result
+}
+
+// criteriaHarnessTemplate runs the caller's assertions with the answer text
+// and its numbers in scope and prints the outcome after the results marker
+const criteriaHarnessTemplate = `import json, traceback
+
+_ns = {"__name__": "__criteria__", "answer": %s, "numbers": %s}
+_result = {"name": "script", "passed": False}
+try:
+    exec(compile(%s, "criteria.py", "exec"), _ns)
+    _result["passed"] = True
+except BaseException as e:
+    _result["error"] = traceback.format_exception_only(type(e), e)[-1].strip()
+print("` + testResultsMarker + `" + json.dumps([_result]))
+`
+
+// runCriteriaScript executes the assertion script through the configured
+// code execution backend. The answer is passed as data, never executed.
+func runCriteriaScript(ctx context.Context, script, answer string) (TestCaseResult, error) {
+	backend, err := codeExecBackendFromEnv()
+	if err != nil {
+		return TestCaseResult{}, err
+	}
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+
+	timeout := GetConfig().CodeExecTimeout
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, stderr, err := backend.Run(execCtx, fmt.Sprintf(criteriaHarnessTemplate, encode(answer), encode(answerNumbers(answer)), encode(script)))
+	if execCtx.Err() == context.DeadlineExceeded {
+		return TestCaseResult{Name: "script", Error: fmt.Sprintf("execution timed out (%v limit)", timeout)}, nil
+	}
+	results, parseErr := parseTestHarnessOutput(stdout)
+	if parseErr != nil || len(results) != 1 {
+		if err != nil {
+			return TestCaseResult{}, fmt.Errorf("criteria script failed: %w (%s)", err, utils.TruncateStr(strings.TrimSpace(stderr), 200))
+		}
+		return TestCaseResult{}, fmt.Errorf("criteria script produced no result")
+	}
+	return results[0], nil
+}
+
+// Validate runs the programmatic validators on an answer
+func (c *SuccessCriteria) Validate(ctx context.Context, answer string) ([]TestCaseResult, error) {
+	var results []TestCaseResult
+	if c.pattern != nil {
+		result := TestCaseResult{Name: "regex", Expected: c.Regex, Passed: c.pattern.MatchString(answer)}
+		if !result.Passed {
+			result.Actual = utils.TruncateStr(strings.TrimSpace(answer), 80)
+		}
+		results = append(results, result)
+	}
+	if c.Numeric != nil {
+		results = append(results, c.checkNumeric(answer))
+	}
+	if strings.TrimSpace(c.Script) != "" {
+		result, err := runCriteriaScript(ctx, c.Script, answer)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// summarizeCriteriaResults builds the evaluation text fed into reflection
+func summarizeCriteriaResults(results []TestCaseResult) (string, bool) {
+	return summarizeChecks("Success criteria", results)
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParseSuccessCriteria(t *testing.T) {
+	rubric, err := ParseSuccessCriteria("Must cite the theorem used")
+	if err != nil || rubric.Rubric != "Must cite the theorem used" || rubric.Programmatic() {
+		t.Errorf("Expected plain text to be a rubric, got %+v, %v", rubric, err)
+	}
+
+	criteria, err := ParseSuccessCriteria(`{"regex": "(?i)^x = -?\\d+$", "numeric": 3.14, "tolerance": 0.01}`)
+	if err != nil || !criteria.Programmatic() || *criteria.Numeric != 3.14 {
+		t.Fatalf("Unexpected criteria %+v, %v", criteria, err)
+	}
+
+	for _, raw := range []string{`{"regex": "("}`, `{"numeric": 1, "tolerance": -1}`, `{}`, `{"numeric": "one"}`} {
+		if _, err := ParseSuccessCriteria(raw); err == nil {
+			t.Errorf("Expected error for %s", raw)
+		}
+	}
+	if empty, _ := ParseSuccessCriteria(" "); empty.Programmatic() {
+		t.Error("Expected blank input to yield no criteria")
+	}
+}
+
+func TestSuccessCriteria_Validate(t *testing.T) {
+	criteria, _ := ParseSuccessCriteria(`{"regex": "^x = ", "numeric": 3.14, "tolerance": 0.01}`)
+
+	results, err := criteria.Validate(context.Background(), "x = 3.141")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary, ok := summarizeCriteriaResults(results); !ok || summary != "Success criteria: 2/2 passed." {
+		t.Errorf("Expected both checks to pass, got %q", summary)
+	}
+
+	results, _ = criteria.Validate(context.Background(), "The root is 1,003.2")
+	summary, ok := summarizeCriteriaResults(results)
+	if ok || !strings.Contains(summary, "0/2 passed") || !strings.Contains(summary, "expected 3.14, got 1003.2") {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+
+	exact, _ := ParseSuccessCriteria(`{"numeric": 391}`)
+	if results, _ := exact.Validate(context.Background(), "No idea"); results[0].Passed || results[0].Error == "" {
+		t.Errorf("Expected an answer without a number to fail, got %+v", results)
+	}
+}
+
+func TestSuccessCriteria_Script(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	t.Setenv("CODE_EXEC_BACKEND", "")
+
+	criteria, _ := ParseSuccessCriteria(`{"script": "assert numbers[-1] ** 2 == 2025, 'wrong root'\nassert 'import' not in answer"}`)
+	results, err := criteria.Validate(context.Background(), "The root is 45")
+	if err != nil || len(results) != 1 || !results[0].Passed {
+		t.Fatalf("Expected the script to pass, got %+v, %v", results, err)
+	}
+
+	// The answer is data to the script, not code
+	results, err = criteria.Validate(context.Background(), "\"); import os; print(\"44")
+	if err != nil || results[0].Passed || !strings.Contains(results[0].Error, "wrong root") {
+		t.Errorf("Expected the script to fail with its message, got %+v, %v", results, err)
+	}
+}
+
+func TestReflexion_SuccessCriteriaOverrideEvaluator(t *testing.T) {
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t)
+
+	// The evaluator always approves, so only the validator can reject
+	result := callTool(t, s, "reflexion", map[string]interface{}{
+		"problem": "What is 17 * 23?", "max_attempts": 2, "success_criteria": `{"numeric": 392}`,
+	})
+	attempts, _ := result["attempts"].([]interface{})
+	if result["success"] != false || len(attempts) != 2 {
+		t.Fatalf("Expected the validator to fail both attempts, got %v", result)
+	}
+	first := attempts[0].(map[string]interface{})
+	if evaluation, _ := first["evaluation"].(string); !strings.Contains(evaluation, "expected 392, got 391") || first["criteria_results"] == nil {
+		t.Errorf("Expected the validator outcome as the evaluation, got %v", first)
+	}
+
+	result = callTool(t, s, "reflexion", map[string]interface{}{
+		"problem": "What is 17 * 23?", "success_criteria": `{"numeric": 391, "regex": "391"}`,
+	})
+	if result["success"] != true || result["total_attempts"] != float64(1) {
+		t.Errorf("Expected the first attempt to pass the validators, got %v", result)
+	}
+}
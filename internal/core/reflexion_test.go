@@ -0,0 +1,536 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLoadOrCreateMemory_InvalidJSON tests that invalid memory files
+// are handled gracefully without data loss
+func TestLoadOrCreateMemory_InvalidJSON(t *testing.T) {
+	// Create a temporary directory
+	tmpDir := t.TempDir()
+	memoryPath := filepath.Join(tmpDir, "memory.json")
+
+	// Write invalid JSON to simulate a corrupted file
+	invalidData := []byte(`{"episodes": [{"id": "test", "problem": "test", "was_successful": true, INVALID_JSON_HERE}]`)
+	if err := os.WriteFile(memoryPath, invalidData, 0644); err != nil {
+		t.Fatalf("Failed to write invalid memory file: %v", err)
+	}
+
+	// Load the memory - should handle invalid JSON gracefully
+	memory := loadOrCreateMemory(memoryPath)
+
+	// Verify memory is initialized with empty episodes
+	if len(memory.Episodes) != 0 {
+		t.Errorf("Expected empty episodes for invalid JSON file, got %d episodes", len(memory.Episodes))
+	}
+
+	// Verify path is still set so we can save new data
+	if memory.path != memoryPath {
+		t.Errorf("Expected path to be %q, got %q", memoryPath, memory.path)
+	}
+
+	// Verify we can add episodes and save
+	memory.Episodes = append(memory.Episodes, Episode{
+		ID:            "test1",
+		Problem:       "Test problem",
+		ProblemHash:   "abc123",
+		Attempt:       1,
+		Thoughts:      []string{"thought1"},
+		FinalAnswer:   "answer",
+		WasSuccessful: true,
+		Timestamp:     time.Now(),
+		Provider:      "test",
+	})
+
+	memory.save()
+
+	// Verify the file now contains valid JSON
+	data, err := os.ReadFile(memoryPath)
+	if err != nil {
+		t.Fatalf("Failed to read memory file: %v", err)
+	}
+
+	// Should be parseable now
+	var loaded EpisodicMemory
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Errorf("After saving, memory file should contain valid JSON, got error: %v", err)
+	}
+
+	// The original corrupted content should be replaced with valid data
+	if len(loaded.Episodes) != 1 {
+		t.Errorf("Expected 1 episode after save, got %d", len(loaded.Episodes))
+	}
+}
+
+// TestLoadOrCreateMemory_DirectoryExists tests loading when directory already exists
+func TestLoadOrCreateMemory_DirectoryExists(t *testing.T) {
+	tmpDir := t.TempDir()
+	memoryPath := filepath.Join(tmpDir, "subdir", "memory.json")
+
+	// Create the directory first
+	if err := os.MkdirAll(filepath.Dir(memoryPath), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	// Create valid memory file
+	episode := Episode{
+		ID:            "existing",
+		Problem:       "Existing problem",
+		ProblemHash:   "hash123",
+		Attempt:       1,
+		Thoughts:      []string{"existing thought"},
+		FinalAnswer:   "existing answer",
+		WasSuccessful: true,
+		Timestamp:     time.Now(),
+		Provider:      "test",
+	}
+	existingMemory := &EpisodicMemory{
+		Episodes: []Episode{episode},
+		path:     memoryPath,
+	}
+
+	// Save the existing memory
+	existingMemory.save()
+
+	// Now load it
+	loaded := loadOrCreateMemory(memoryPath)
+
+	// Verify the existing episode was loaded
+	if len(loaded.Episodes) != 1 {
+		t.Errorf("Expected 1 existing episode, got %d", len(loaded.Episodes))
+	}
+
+	if len(loaded.Episodes) > 0 && loaded.Episodes[0].ID != "existing" {
+		t.Errorf("Expected episode ID 'existing', got %q", loaded.Episodes[0].ID)
+	}
+}
+
+// TestLoadOrCreateMemory_NewFile tests creating new memory when file doesn't exist
+func TestLoadOrCreateMemory_NewFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	memoryPath := filepath.Join(tmpDir, "memory.json")
+
+	// Load memory that doesn't exist yet
+	memory := loadOrCreateMemory(memoryPath)
+
+	// Should have empty episodes
+	if len(memory.Episodes) != 0 {
+		t.Errorf("Expected empty episodes for new file, got %d", len(memory.Episodes))
+	}
+
+	// Path should be set correctly
+	if memory.path != memoryPath {
+		t.Errorf("Expected path %q, got %q", memoryPath, memory.path)
+	}
+}
+
+// TestLoadOrCreateMemory_InvalidJSON_CorruptedFile tests data loss prevention
+// when an existing valid memory file gets corrupted
+func TestLoadOrCreateMemory_InvalidJSON_CorruptedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	memoryPath := filepath.Join(tmpDir, "memory.json")
+
+	// First, create a valid memory file with important data
+	validMemory := &EpisodicMemory{
+		Episodes: []Episode{
+			{
+				ID:            "important1",
+				Problem:       "Important problem 1",
+				ProblemHash:   "hash1",
+				Attempt:       1,
+				Thoughts:      []string{"thought1"},
+				FinalAnswer:   "answer1",
+				WasSuccessful: true,
+				Reflection:    "Important reflection 1",
+				Timestamp:     time.Now(),
+				Provider:      "test",
+			},
+			{
+				ID:            "important2",
+				Problem:       "Important problem 2",
+				ProblemHash:   "hash2",
+				Attempt:       2,
+				Thoughts:      []string{"thought2"},
+				FinalAnswer:   "answer2",
+				WasSuccessful: false,
+				Reflection:    "Important reflection 2 - what went wrong",
+				Timestamp:     time.Now(),
+				Provider:      "test",
+			},
+		},
+		path: memoryPath,
+	}
+
+	// Save the valid memory
+	validMemory.save()
+
+	// Verify file exists and has content
+	data, err := os.ReadFile(memoryPath)
+	if err != nil {
+		t.Fatalf("Failed to read memory file: %v", err)
+	}
+	_ = len(data) // Original file size for reference
+
+	// Now corrupt the file by writing invalid JSON over it
+	corruptedData := []byte(`{"episodes": [{"id": "test", invalid json here}]`)
+	if err := os.WriteFile(memoryPath, corruptedData, 0644); err != nil {
+		t.Fatalf("Failed to corrupt memory file: %v", err)
+	}
+
+	// Load the corrupted memory
+	loaded := loadOrCreateMemory(memoryPath)
+
+	// FIX: The new implementation should create a backup of the corrupted file
+	// Find the backup file
+	backupFiles, err := filepath.Glob(memoryPath + ".corrupted.*")
+	if err != nil {
+		t.Fatalf("Failed to search for backup files: %v", err)
+	}
+
+	if len(backupFiles) == 0 {
+		t.Error("FIX NOT WORKING: No backup file was created for the corrupted memory!")
+	} else {
+		t.Logf("FIX VERIFIED: Backup file created: %s", backupFiles[0])
+
+		// Verify the backup contains the original corrupted data
+		backupData, err := os.ReadFile(backupFiles[0])
+		if err != nil {
+			t.Fatalf("Failed to read backup file: %v", err)
+		}
+
+		// The backup should contain the corrupted data we wrote
+		if string(backupData) != string(corruptedData) {
+			t.Errorf("Backup file doesn't match the corrupted data")
+		}
+	}
+
+	// The loaded memory should have empty episodes
+	if len(loaded.Episodes) != 0 {
+		t.Errorf("Expected empty episodes after loading corrupted file, got %d", len(loaded.Episodes))
+	}
+
+	// Path should still be set so we can save new data
+	if loaded.path != memoryPath {
+		t.Errorf("Expected path %q, got %q", memoryPath, loaded.path)
+	}
+
+	// Add a new episode and save
+	loaded.Episodes = append(loaded.Episodes, Episode{
+		ID:            "new1",
+		Problem:       "New problem",
+		ProblemHash:   "newhash",
+		Attempt:       1,
+		Thoughts:      []string{"new thought"},
+		FinalAnswer:   "new answer",
+		WasSuccessful: true,
+		Timestamp:     time.Now(),
+		Provider:      "test",
+	})
+	loaded.save()
+
+	// Verify the main file was updated with new data
+	newData, err := os.ReadFile(memoryPath)
+	if err != nil {
+		t.Fatalf("Failed to read memory file after save: %v", err)
+	}
+
+	// Should contain the new episode
+	var loadedMemory EpisodicMemory
+	if err := json.Unmarshal(newData, &loadedMemory); err != nil {
+		t.Fatalf("Failed to parse new memory file: %v", err)
+	}
+
+	if len(loadedMemory.Episodes) != 1 {
+		t.Errorf("Expected 1 episode in new file, got %d", len(loadedMemory.Episodes))
+	}
+
+	if loadedMemory.Episodes[0].ID != "new1" {
+		t.Errorf("Expected episode ID 'new1', got %q", loadedMemory.Episodes[0].ID)
+	}
+
+	// Most importantly: verify the backup file still exists and wasn't deleted
+	backupFiles, err = filepath.Glob(memoryPath + ".corrupted.*")
+	if err != nil {
+		t.Fatalf("Failed to search for backup files: %v", err)
+	}
+
+	if len(backupFiles) == 0 {
+		t.Error("DATA LOSS: Backup file was deleted! The original corrupted data is lost forever.")
+	} else {
+		t.Logf("FIX VERIFIED: Backup file preserved after save: %s", backupFiles[0])
+		t.Logf("FIX SUCCESS: Original corrupted data (corrupted file with %d bytes) is recoverable from backup", len(corruptedData))
+	}
+}
+
+// diversityProvider repeats the same approach unless told its draft repeated
+// a failed one, and never passes evaluation
+type diversityProvider struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (p *diversityProvider) Name() string { return "diversity" }
+
+func (p *diversityProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	user := messages[len(messages)-1].Content
+	p.mu.Lock()
+	p.prompts = append(p.prompts, user)
+	p.mu.Unlock()
+
+	switch {
+	case strings.Contains(messages[0].Content, "strict evaluator"):
+		return `{"evaluation": "wrong", "is_correct": false, "issues": ["off by one"]}`, nil
+	case strings.Contains(messages[0].Content, "thoughtful analyst"):
+		return "Count more carefully.", nil
+	case strings.Contains(user, "name the high-level strategy"):
+		return "Brute-force enumeration of all cases.", nil
+	case strings.Contains(user, "repeated an approach that already failed"):
+		return `{"type": "thought", "thought_number": 1, "thought": "Build a recurrence relation and solve it in closed form", "is_final": true, "answer": "8"}`, nil
+	default:
+		return `{"type": "thought", "thought_number": 1, "thought": "Enumerate every case one by one and count them", "is_final": true, "answer": "7"}`, nil
+	}
+}
+
+func TestReflexion_ForcesDiverseRetries(t *testing.T) {
+	provider := &diversityProvider{}
+	config := DefaultReflexionConfig()
+	config.MemoryPath = filepath.Join(t.TempDir(), "memory.json")
+	config.LearnFromPast = false
+	config.MaxAttempts = 2
+
+	result, err := NewReflexion(provider, config).Reason(context.Background(), "How many ways to tile a 2x4 board?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if len(result.Attempts) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(result.Attempts))
+	}
+
+	first, second := result.Attempts[0], result.Attempts[1]
+	if first.Approach != "Brute-force enumeration of all cases." {
+		t.Errorf("Expected approach summary on failed attempt, got %q", first.Approach)
+	}
+	if !second.Redone || second.Answer != "8" {
+		t.Errorf("Expected second attempt to be redone with a new strategy, got %+v", second)
+	}
+
+	sawApproaches := false
+	for _, p := range provider.prompts {
+		if strings.Contains(p, "Approaches already tried") && strings.Contains(p, "Brute-force enumeration") {
+			sawApproaches = true
+		}
+	}
+	if !sawApproaches {
+		t.Error("Expected the retry prompt to list prior approaches")
+	}
+}
+
+func TestMaxAttemptSimilarity(t *testing.T) {
+	prior := []Attempt{{Thoughts: []string{"Enumerate every case one by one and count them"}, Answer: "7"}}
+	same := maxAttemptSimilarity(context.Background(), []string{"Enumerate every case one by one and count them"}, "7", prior)
+	different := maxAttemptSimilarity(context.Background(), []string{"Set up a recurrence relation"}, "8", prior)
+	if same < 0.99 || different > 0.3 {
+		t.Errorf("Unexpected similarities: same=%.2f different=%.2f", same, different)
+	}
+}
+
+func TestReflexion_RotatesAttemptProviders(t *testing.T) {
+	main, cheap, strong := &diversityProvider{}, &diversityProvider{}, &diversityProvider{}
+	config := DefaultReflexionConfig()
+	config.MemoryPath = filepath.Join(t.TempDir(), "memory.json")
+	config.LearnFromPast = false
+	config.ForceDiversity = false
+	config.MaxAttempts = 3
+	config.AttemptProviders = []AttemptProvider{
+		{Label: "cheap", Provider: cheap},
+		{Label: "strong", Provider: strong},
+	}
+
+	result, err := NewReflexion(main, config).Reason(context.Background(), "How many ways to tile a 2x4 board?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+
+	var labels []string
+	for _, a := range result.Attempts {
+		labels = append(labels, a.Provider)
+	}
+	if strings.Join(labels, ",") != "cheap,strong,strong" {
+		t.Errorf("Expected rotation cheap,strong,strong, got %v", labels)
+	}
+	if len(cheap.prompts) == 0 || len(strong.prompts) == 0 {
+		t.Error("Expected each rotation provider to generate reasoning")
+	}
+	for _, p := range strong.prompts {
+		if strings.Contains(p, "Evaluate this reasoning") {
+			t.Error("Expected evaluation to stay on the main provider")
+		}
+	}
+	if result.FinalAttempt != 3 || result.FinalProvider != "strong" {
+		t.Errorf("Expected final answer attributed to attempt 3 (strong), got %d (%s)", result.FinalAttempt, result.FinalProvider)
+	}
+}
+
+func TestParseAttemptProviders(t *testing.T) {
+	rotation, err := ParseAttemptProviders("openai:gpt-4o-mini, ollama:llama3.1:8b,anthropic")
+	if err != nil {
+		t.Fatalf("ParseAttemptProviders failed: %v", err)
+	}
+	if len(rotation) != 3 || rotation[1].Label != "ollama:llama3.1:8b" {
+		t.Fatalf("Unexpected rotation: %+v", rotation)
+	}
+	if op, ok := unwrapProvider(rotation[1].Provider).(*OllamaProvider); !ok || op.model != "llama3.1:8b" {
+		t.Errorf("Expected Ollama model tag to be kept, got %+v", rotation[1].Provider)
+	}
+
+	if _, err := ParseAttemptProviders("openai,not-a-provider"); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+}
+
+// runawayProvider never finishes on its own: thoughts are long and non-final,
+// and it only answers when told the attempt's budget has run out. With block
+// set, thought calls hang until the context is cancelled.
+type runawayProvider struct {
+	block bool
+}
+
+func (p *runawayProvider) Name() string { return "runaway" }
+
+func (p *runawayProvider) Chat(ctx context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	user := messages[len(messages)-1].Content
+	switch {
+	case strings.Contains(messages[0].Content, "strict evaluator"):
+		return `{"evaluation": "wrong", "is_correct": false, "issues": ["incomplete"]}`, nil
+	case strings.Contains(messages[0].Content, "thoughtful analyst"):
+		return "Be more concise.", nil
+	case strings.Contains(user, "run out of budget"):
+		return `{"is_final": true, "answer": "42"}`, nil
+	}
+	if p.block {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	return `{"thought_number": 1, "thought": "` + strings.Repeat("more detail ", 31) + `", "is_final": false}`, nil
+}
+
+func TestReflexion_AttemptTokenBudget(t *testing.T) {
+	config := DefaultReflexionConfig()
+	config.MemoryPath = filepath.Join(t.TempDir(), "memory.json")
+	config.LearnFromPast = false
+	config.ForceDiversity = false
+	config.MaxAttempts = 2
+	config.AttemptMaxTokens = 600
+
+	result, err := NewReflexion(&runawayProvider{}, config).Reason(context.Background(), "What is the answer?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if len(result.Attempts) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(result.Attempts))
+	}
+
+	first, second := result.Attempts[0], result.Attempts[1]
+	if first.Budget == nil || first.Budget.CutOff != BudgetCutOffTokens {
+		t.Fatalf("Expected first attempt to be cut off on tokens, got %+v", first.Budget)
+	}
+	if first.Answer != "42" {
+		t.Errorf("Expected cut-off attempt to still answer, got %q", first.Answer)
+	}
+	if first.Budget.TokensUsed > first.Budget.TokensAllowed {
+		t.Errorf("Expected usage within budget, got %d of %d", first.Budget.TokensUsed, first.Budget.TokensAllowed)
+	}
+	if want := 600 + (600 - first.Budget.TokensUsed); second.Budget.TokensAllowed != want {
+		t.Errorf("Expected unused tokens to carry over (%d allowed), got %d", want, second.Budget.TokensAllowed)
+	}
+	if len(second.Thoughts) <= len(first.Thoughts) {
+		t.Errorf("Expected carried-over budget to allow more thoughts, got %d then %d", len(first.Thoughts), len(second.Thoughts))
+	}
+}
+
+func TestReflexion_AttemptTimeBudget(t *testing.T) {
+	config := DefaultReflexionConfig()
+	config.MemoryPath = filepath.Join(t.TempDir(), "memory.json")
+	config.LearnFromPast = false
+	config.ForceDiversity = false
+	config.MaxAttempts = 1
+	config.AttemptTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	result, err := NewReflexion(&runawayProvider{block: true}, config).Reason(context.Background(), "What is the answer?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Expected the attempt to be cut off quickly, took %v", elapsed)
+	}
+
+	attempt := result.Attempts[0]
+	if attempt.Budget == nil || attempt.Budget.CutOff != BudgetCutOffTime || attempt.Budget.TimeAllowedMs != 50 {
+		t.Fatalf("Expected a time cutoff with 50ms allowed, got %+v", attempt.Budget)
+	}
+	if attempt.Answer != "42" {
+		t.Errorf("Expected cut-off attempt to still answer, got %q (evaluation %q)", attempt.Answer, attempt.Evaluation)
+	}
+}
+
+func TestReflexion_EvaluatorProvider(t *testing.T) {
+	main, critic := &diversityProvider{}, &diversityProvider{}
+	config := DefaultReflexionConfig()
+	config.MemoryPath = filepath.Join(t.TempDir(), "memory.json")
+	config.LearnFromPast = false
+	config.ForceDiversity = false
+	config.MaxAttempts = 2
+	config.Evaluator = critic
+
+	result, err := NewReflexion(main, config).Reason(context.Background(), "How many ways to tile a 2x4 board?")
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	for _, p := range main.prompts {
+		if strings.Contains(p, "Evaluate this reasoning") {
+			t.Error("Expected evaluation to move off the generator")
+		}
+	}
+	if len(critic.prompts) != 2 {
+		t.Errorf("Expected the critic to evaluate both attempts, got %d calls", len(critic.prompts))
+	}
+	if result.Evaluator != "diversity" {
+		t.Errorf("Expected the critic to be reported, got %q", result.Evaluator)
+	}
+}
+
+func TestGetEvaluatorProviderFromArgs(t *testing.T) {
+	for _, key := range []string{"EVALUATOR_PROVIDER", "EVALUATOR_MODEL", "REFLEXION_EVALUATOR_PROVIDER", "REFLEXION_EVALUATOR_MODEL"} {
+		t.Setenv(key, "")
+	}
+
+	if p, err := getEvaluatorProviderFromArgs(map[string]interface{}{}, "reflexion"); err != nil || p != nil {
+		t.Errorf("Expected no evaluator by default, got %v, %v", p, err)
+	}
+
+	// A model alone runs on the generator's provider
+	p, err := getEvaluatorProviderFromArgs(map[string]interface{}{"provider": "ollama", "evaluator_model": "qwen2.5:32b"}, "reflexion")
+	if op, ok := unwrapProvider(p).(*OllamaProvider); err != nil || !ok || op.model != "qwen2.5:32b" {
+		t.Errorf("Expected an Ollama evaluator with the given model, got %+v, %v", p, err)
+	}
+
+	t.Setenv("EVALUATOR_PROVIDER", "ollama")
+	t.Setenv("REFLEXION_EVALUATOR_MODEL", "llama3.1:70b")
+	p, err = getEvaluatorProviderFromArgs(map[string]interface{}{"provider": "openai"}, "reflexion")
+	if op, ok := unwrapProvider(p).(*OllamaProvider); err != nil || !ok || op.model != "llama3.1:70b" {
+		t.Errorf("Expected env evaluator settings, got %+v, %v", p, err)
+	}
+
+	if _, err := getEvaluatorProviderFromArgs(map[string]interface{}{"evaluator_provider": "not-a-provider"}, "reflexion"); err == nil {
+		t.Error("Expected error for unknown evaluator provider")
+	}
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Request Limits ============
+//
+// requestLimitsMiddleware rejects strategy calls whose inputs are too big
+// before any provider call is made, with an E_LIMIT_EXCEEDED error: a
+// problem longer than LIMIT_PROBLEM_CHARS, context_documents longer than
+// LIMIT_CONTEXT_CHARS in total, and graph sizes over LIMIT_MAX_NODES,
+// LIMIT_MAX_DEPTH and LIMIT_BRANCHING_FACTOR (also checked for every
+// reasoning_pipeline stage). A limit of 0 turns it off.
+
+const (
+	defaultLimitProblemChars = 200000 // Problems over PROBLEM_COMPRESS_THRESHOLD are still condensed
+	defaultLimitContextChars = 500000
+)
+
+// argLimits caps the graph size arguments
+var argLimits = []struct {
+	Field   string
+	Env     string
+	Default int
+}{
+	{"max_nodes", "LIMIT_MAX_NODES", 200},
+	{"additional_nodes", "LIMIT_MAX_NODES", 200},
+	{"max_depth", "LIMIT_MAX_DEPTH", 30},
+	{"branching_factor", "LIMIT_BRANCHING_FACTOR", 6},
+}
+
+// limitExceeded builds the E_LIMIT_EXCEEDED error of field
+func limitExceeded(field, what string, actual, limit int, env string) *toolError {
+	return &toolError{
+		Code:    errCodeLimitExceeded,
+		Message: fmt.Sprintf("%s is %d%s, more than the limit of %d (%s)", field, actual, what, limit, env),
+		Fields:  []string{field},
+		Limit:   limit,
+		Actual:  actual,
+	}
+}
+
+// checkArgLimits checks the graph size arguments of args
+func checkArgLimits(args map[string]interface{}) error {
+	for _, l := range argLimits {
+		n, ok := normalizeArgValue(args[l.Field]).(float64)
+		if !ok {
+			continue
+		}
+		if limit := parseEnvInt(l.Env, l.Default); limit > 0 && n > float64(limit) {
+			return limitExceeded(l.Field, "", int(n), limit, l.Env)
+		}
+	}
+	return nil
+}
+
+// checkRequestLimits checks the size of a strategy call's inputs
+func checkRequestLimits(args map[string]interface{}) error {
+	if problem, _ := args["problem"].(string); problem != "" {
+		if limit := parseEnvInt("LIMIT_PROBLEM_CHARS", defaultLimitProblemChars); limit > 0 && len(problem) > limit {
+			return limitExceeded("problem", " characters", len(problem), limit, "LIMIT_PROBLEM_CHARS")
+		}
+	}
+	if args["context_documents"] != nil {
+		// Malformed documents are reported by contextDocumentsMiddleware
+		if docs, err := parseContextDocuments(args["context_documents"]); err == nil {
+			total := 0
+			for _, doc := range docs {
+				total += len(doc)
+			}
+			if limit := parseEnvInt("LIMIT_CONTEXT_CHARS", defaultLimitContextChars); limit > 0 && total > limit {
+				return limitExceeded("context_documents", " characters", total, limit, "LIMIT_CONTEXT_CHARS")
+			}
+		}
+	}
+	return checkArgLimits(args)
+}
+
+// requestLimitsMiddleware rejects strategy calls over the request limits
+func requestLimitsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok || !runHistoryTools[request.Params.Name] {
+			return next(ctx, request)
+		}
+		if err := checkRequestLimits(args); err != nil {
+			return toolErrorResult(err), nil
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCheckRequestLimits(t *testing.T) {
+	t.Setenv("LIMIT_PROBLEM_CHARS", "20")
+	t.Setenv("LIMIT_CONTEXT_CHARS", "10")
+	t.Setenv("LIMIT_MAX_NODES", "")
+
+	if err := checkRequestLimits(map[string]interface{}{"problem": "What is 17 * 23?", "max_nodes": 200.0}); err != nil {
+		t.Errorf("Expected inputs within the limits to pass, got %v", err)
+	}
+	for want, args := range map[string]map[string]interface{}{
+		"problem is 30 characters, more than the limit of 20 (LIMIT_PROBLEM_CHARS)":           {"problem": strings.Repeat("x", 30)},
+		"context_documents is 12 characters, more than the limit of 10 (LIMIT_CONTEXT_CHARS)": {"context_documents": `["abcdef", "ghijkl"]`},
+		"max_nodes is 201, more than the limit of 200 (LIMIT_MAX_NODES)":                      {"max_nodes": 201.0},
+		"branching_factor is 7, more than the limit of 6 (LIMIT_BRANCHING_FACTOR)":            {"branching_factor": 7},
+	} {
+		err := checkRequestLimits(args)
+		if err == nil || err.Error() != "[E_LIMIT_EXCEEDED] "+want {
+			t.Errorf("Expected %q, got %v", want, err)
+		}
+	}
+
+	t.Setenv("LIMIT_MAX_NODES", "0")
+	if err := checkArgLimits(map[string]interface{}{"max_nodes": 900.0}); err != nil {
+		t.Errorf("Expected LIMIT_MAX_NODES=0 to turn the limit off, got %v", err)
+	}
+}
+
+func TestRequestLimitsMiddleware_ErrorCode(t *testing.T) {
+	t.Setenv("LIMIT_MAX_DEPTH", "4")
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t, requestLimitsMiddleware)
+
+	raw := promptRequest(t, s, "tools/call", map[string]interface{}{
+		"name":      "graph_of_thoughts",
+		"arguments": map[string]interface{}{"problem": "What is 17 * 23?", "max_depth": 5},
+	})
+	var resp struct {
+		Result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+			Meta    struct {
+				Error toolError `json:"error"`
+			} `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Result.IsError || !strings.HasPrefix(resp.Result.Content[0].Text, "[E_LIMIT_EXCEEDED] max_depth is 5") {
+		t.Errorf("Expected a limit error, got %s", raw)
+	}
+	if e := resp.Result.Meta.Error; e.Code != errCodeLimitExceeded || e.Limit != 4 || e.Actual != 5 || len(e.Fields) != 1 || e.Fields[0] != "max_depth" {
+		t.Errorf("Unexpected error details %+v", e)
+	}
+
+	if _, err := parsePipelineSpec(`[{"strategy": "graph_of_thoughts", "params": {"max_depth": 9}}]`); err == nil || !strings.Contains(err.Error(), "stage 1: [E_LIMIT_EXCEEDED]") {
+		t.Errorf("Expected pipeline stages to be limited, got %v", err)
+	}
+}
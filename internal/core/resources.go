@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ MCP Resources ============
+//
+// Prior reasoning artifacts are exposed as MCP resources, so clients can
+// browse them with resources/read instead of the bespoke tools:
+//
+//	run://{id}/result     the result of a recent run (export_trace keeps the same runs)
+//	run://{id}/graph.dot  the thought graph of a GoT run, as Graphviz DOT
+//	memory://episodes     the reflexion episodes of the session's memory namespace
+//	config://providers    the list_providers output
+//	metrics://events      event counts of the strategy runs since start, by tool
+
+const (
+	resourceURIRunResult    = "run://{id}/result"
+	resourceURIRunGraph     = "run://{id}/graph.dot"
+	resourceURIEpisodes     = "memory://episodes"
+	resourceURIProviders    = "config://providers"
+	resourceURIEventMetrics = "metrics://events"
+	resourceMIMEJSON        = "application/json"
+	resourceMIMEGraphviz    = "text/vnd.graphviz"
+	resourceMIMEPlainText   = "text/plain"
+	resourceRunURIPrefix    = "run://"
+	resourceRunResultSuffix = "/result"
+)
+
+// runResultURI is the resource URI of a run's result
+func runResultURI(runID string) string {
+	return resourceRunURIPrefix + runID + resourceRunResultSuffix
+}
+
+// resourceRunID returns the {id} of a run:// resource read
+func resourceRunID(request mcp.ReadResourceRequest) (string, error) {
+	var id string
+	switch v := request.Params.Arguments["id"].(type) {
+	case string:
+		id = v
+	case []string:
+		if len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("resource %s has no run ID", request.Params.URI)
+	}
+	return id, nil
+}
+
+// toolResultResource wraps what a tool handler returned as resource contents
+func toolResultResource(uri, mimeType string, result *mcp.CallToolResult, err error) ([]mcp.ResourceContents, error) {
+	text, isError := toolResultText(result, err)
+	if isError {
+		return nil, fmt.Errorf("%s", text)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: text}}, nil
+}
+
+// handleRunResultResource reads run://{id}/result from the trace store
+func handleRunResultResource(_ context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	runID, err := resourceRunID(request)
+	if err != nil {
+		return nil, err
+	}
+	store := getTraceStore()
+	if store == nil {
+		return nil, fmt.Errorf("runs are not kept (TRACE_KEEP=0)")
+	}
+	trace := store.find(runID, "")
+	if trace == nil {
+		return nil, fmt.Errorf("no run %s (only the last %d runs are kept)", runID, store.keep)
+	}
+
+	mimeType := resourceMIMEPlainText
+	if json.Valid([]byte(trace.Result)) {
+		mimeType = resourceMIMEJSON
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, MIMEType: mimeType, Text: trace.Result}}, nil
+}
+
+// gotRunIDFor resolves the {id} of run://{id}/graph.dot: a recent run of a
+// GoT tool names its saved graph in the result's run_id, anything else is
+// taken as a GoT run ID
+func gotRunIDFor(id string) string {
+	store := getTraceStore()
+	if store == nil {
+		return id
+	}
+	trace := store.find(id, "")
+	if trace == nil {
+		return id
+	}
+	var result struct {
+		RunID string `json:"run_id"`
+	}
+	if json.Unmarshal([]byte(trace.Result), &result) == nil && result.RunID != "" {
+		return result.RunID
+	}
+	return id
+}
+
+// handleRunGraphResource renders the saved graph of a GoT run as DOT
+func handleRunGraphResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id, err := resourceRunID(request)
+	if err != nil {
+		return nil, err
+	}
+	call := mcp.CallToolRequest{}
+	call.Params.Name = "export_graph"
+	call.Params.Arguments = map[string]interface{}{"run_id": gotRunIDFor(id), "format": GraphFormatDOT}
+	result, err := handleExportGraph(ctx, call)
+	return toolResultResource(request.Params.URI, resourceMIMEGraphviz, result, err)
+}
+
+// handleEpisodesResource reads memory://episodes, the memory_export of the
+// session's default namespace
+func handleEpisodesResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	result, err := handleMemoryExport(ctx, mcp.CallToolRequest{})
+	return toolResultResource(request.Params.URI, resourceMIMEJSON, result, err)
+}
+
+// handleProvidersResource reads config://providers
+func handleProvidersResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	result, err := handleListProviders(ctx, mcp.CallToolRequest{})
+	return toolResultResource(request.Params.URI, resourceMIMEJSON, result, err)
+}
+
+// handleEventMetricsResource reads metrics://events
+func handleEventMetricsResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(runEventCounts.snapshot(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, MIMEType: resourceMIMEJSON, Text: string(data)}}, nil
+}
+
+// notifyRunResources tells clients that a finished run's resources, and the
+// episodes when the run was a reflexion, have new contents
+func notifyRunResources(ctx context.Context, trace *RunTrace) {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+	uris := []string{runResultURI(trace.ID)}
+	if strings.HasPrefix(trace.Tool, "reflexion") {
+		uris = append(uris, resourceURIEpisodes)
+	}
+	for _, uri := range uris {
+		srv.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+	}
+}
+
+// registerResources adds the run, memory and provider resources
+func registerResources(s *server.MCPServer) {
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(resourceURIRunResult, "Run result",
+			mcp.WithTemplateDescription(fmt.Sprintf("Result of one of the last TRACE_KEEP (default %d) strategy runs, by the run ID of its queue status or export_trace", defaultTraceKeep)),
+			mcp.WithTemplateMIMEType(resourceMIMEJSON),
+		),
+		handleRunResultResource,
+	)
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(resourceURIRunGraph, "Run graph",
+			mcp.WithTemplateDescription("Thought graph of a graph_of_thoughts run as Graphviz DOT, by run ID or GoT run_id (needs GOT_PERSIST_RUNS)"),
+			mcp.WithTemplateMIMEType(resourceMIMEGraphviz),
+		),
+		handleRunGraphResource,
+	)
+	s.AddResource(
+		mcp.NewResource(resourceURIEpisodes, "Reflexion episodes",
+			mcp.WithResourceDescription("Reflexion episodes of the session's memory namespace, in memory_export format"),
+			mcp.WithMIMEType(resourceMIMEJSON),
+		),
+		handleEpisodesResource,
+	)
+	s.AddResource(
+		mcp.NewResource(resourceURIProviders, "LLM providers",
+			mcp.WithResourceDescription("Available LLM providers and whether they are configured, as list_providers shows them"),
+			mcp.WithMIMEType(resourceMIMEJSON),
+		),
+		handleProvidersResource,
+	)
+	s.AddResource(
+		mcp.NewResource(resourceURIEventMetrics, "Event metrics",
+			mcp.WithResourceDescription("Counts of the events published by strategy runs since the server started, by tool and event kind, with failed_runs"),
+			mcp.WithMIMEType(resourceMIMEJSON),
+		),
+		handleEventMetricsResource,
+	)
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type resourceReadResponse struct {
+	Result struct {
+		Contents []struct {
+			MIMEType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"contents"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func readResource(t *testing.T, s *server.MCPServer, uri string) resourceReadResponse {
+	t.Helper()
+	var resp resourceReadResponse
+	if err := json.Unmarshal(promptRequest(t, s, "resources/read", map[string]interface{}{"uri": uri}), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// readResourceText returns the text and MIME type of uri
+func readResourceText(t *testing.T, s *server.MCPServer, uri string) (string, string) {
+	t.Helper()
+	resp := readResource(t, s, uri)
+	if resp.Error != nil || len(resp.Result.Contents) != 1 {
+		t.Fatalf("Failed to read %s: %+v", uri, resp)
+	}
+	return resp.Result.Contents[0].Text, resp.Result.Contents[0].MIMEType
+}
+
+// readResourceError returns the error of reading uri
+func readResourceError(t *testing.T, s *server.MCPServer, uri string) string {
+	t.Helper()
+	resp := readResource(t, s, uri)
+	if resp.Error == nil {
+		t.Fatalf("Expected reading %s to fail, got %+v", uri, resp)
+	}
+	return resp.Error.Message
+}
+
+func TestResources_RunResultAndGraph(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	s := integrationServer(t, traceMiddleware)
+	registerResources(s)
+
+	store, err := NewGoTRunStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewGoTRunStore failed: %v", err)
+	}
+	gotRunStore = store
+	if err := store.Save(&GoTRunState{
+		RunID:   "got_1_abcd",
+		Problem: "What is 2+2?",
+		Config:  DefaultGoTConfig(),
+		Nodes: map[string]*GoTNode{
+			"root": {ID: "root", NodeType: "thought", Thought: "What is 2+2?", Children: []string{"n1_0"}},
+			"n1_0": {ID: "n1_0", NodeType: "thought", Thought: "4", Depth: 1, Score: 0.9, Parents: []string{"root"}},
+		},
+		BestNodeID: "n1_0",
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	callTool(t, s, "sequential_thinking", map[string]interface{}{"problem": "What is 2 + 2?", "provider": "mock"})
+	trace := getTraceStore().find("", "")
+	if trace == nil {
+		t.Fatal("Expected the run to be traced")
+	}
+
+	var result map[string]interface{}
+	text, mimeType := readResourceText(t, s, runResultURI(trace.ID))
+	if err := json.Unmarshal([]byte(text), &result); err != nil || mimeType != resourceMIMEJSON {
+		t.Fatalf("Expected the run's JSON result, got %s %q", mimeType, text)
+	}
+	if result["final_answer"] == nil {
+		t.Errorf("Expected the sequential result, got %v", result)
+	}
+
+	dot, mimeType := readResourceText(t, s, "run://got_1_abcd/graph.dot")
+	if !strings.HasPrefix(dot, "digraph") || mimeType != resourceMIMEGraphviz {
+		t.Errorf("Expected a DOT graph, got %s %q", mimeType, dot)
+	}
+
+	if msg := readResourceError(t, s, "run://run-missing/result"); !strings.Contains(msg, "no run run-missing") {
+		t.Errorf("Expected an unknown run error, got %q", msg)
+	}
+	if msg := readResourceError(t, s, "run://got_9_missing/graph.dot"); !strings.Contains(msg, "run not found") {
+		t.Errorf("Expected an unknown graph error, got %q", msg)
+	}
+}
+
+func TestResources_EpisodesAndProviders(t *testing.T) {
+	s := integrationServer(t)
+	registerResources(s)
+
+	var list struct {
+		Result struct {
+			Resources []struct {
+				URI string `json:"uri"`
+			} `json:"resources"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(promptRequest(t, s, "resources/list", map[string]interface{}{}), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Result.Resources) != 3 {
+		t.Errorf("Expected the episodes, providers and event metrics resources, got %+v", list.Result.Resources)
+	}
+
+	text, _ := readResourceText(t, s, resourceURIEpisodes)
+	var export MemoryExport
+	if err := json.Unmarshal([]byte(text), &export); err != nil || export.Version != 1 {
+		t.Errorf("Expected a memory export, got %q", text)
+	}
+
+	text, _ = readResourceText(t, s, resourceURIProviders)
+	if !strings.Contains(text, `"name": "mock"`) {
+		t.Errorf("Expected the provider list, got %q", text)
+	}
+
+	text, _ = readResourceText(t, s, resourceURIEventMetrics)
+	var counts map[string]map[string]int64
+	if err := json.Unmarshal([]byte(text), &counts); err != nil {
+		t.Errorf("Expected event counts, got %q", text)
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Result Output ============
+//
+// output picks what the text of a strategy result holds: the JSON object
+// (json, the default), a markdown report (markdown) or only the final answer
+// (answer_only), for clients that put the answer straight into a chat reply.
+// The JSON object is still sent as the structured content either way. Only
+// the strategy tools (runHistoryTools) take output; the other tools refuse
+// it, since their results have no final answer or report.
+
+// Result output values
+const (
+	OutputJSON       = "json"
+	OutputMarkdown   = "markdown"
+	OutputAnswerOnly = "answer_only"
+)
+
+func outputOption() mcp.ToolOption {
+	return mcp.WithString("output",
+		mcp.Description("Text of the result: json (the full result object), markdown (a readable report) or answer_only (just the final answer). The JSON object is still sent as structured content (default: json)"),
+	)
+}
+
+// parseOutput validates an output value, "" for the default
+func parseOutput(raw interface{}) (string, error) {
+	s, ok := raw.(string)
+	if raw != nil && !ok {
+		return "", fmt.Errorf("must be a string, got %s", describeArgValue(raw))
+	}
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "", OutputJSON, OutputMarkdown, OutputAnswerOnly:
+		return s, nil
+	case "answer", "answer-only":
+		return OutputAnswerOnly, nil
+	case "md":
+		return OutputMarkdown, nil
+	}
+	return "", fmt.Errorf("must be json, markdown or answer_only, got %q", s)
+}
+
+// resultOutputMiddleware validates the output argument of strategy calls and
+// rewrites the text of successful results to match it
+func resultOutputMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		if args["output"] == nil {
+			return next(ctx, request)
+		}
+		if !runHistoryTools[request.Params.Name] {
+			return toolErrorResult(&toolError{Code: errCodeInvalidArgument, Message: fmt.Sprintf("invalid arguments: output is not supported by %s, only by the reasoning strategies (%s)", request.Params.Name, strings.Join(outputToolNames(), ", ")), Fields: []string{"output"}}), nil
+		}
+		output, err := parseOutput(args["output"])
+		if err != nil {
+			return toolErrorResult(&toolError{Code: errCodeInvalidArgument, Message: "invalid arguments: output " + err.Error(), Fields: []string{"output"}}), nil
+		}
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.IsError || output == "" || output == OutputJSON {
+			return result, err
+		}
+		text := resultText(result)
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(text), &fields) != nil {
+			return result, err
+		}
+		formatted, ok := formatResultOutput(request.Params.Name, text, fields, output)
+		if !ok {
+			return result, err
+		}
+		return &mcp.CallToolResult{
+			Content:           []mcp.Content{mcp.NewTextContent(formatted)},
+			StructuredContent: fields,
+		}, nil
+	}
+}
+
+// outputToolNames lists the tools that take output, sorted
+func outputToolNames() []string {
+	names := make([]string, 0, len(runHistoryTools))
+	for name := range runHistoryTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatResultOutput renders a tool's JSON result text as output, the
+// strategy result inside a streaming wrapper when there is one. ok is false
+// for an answer_only result without an answer, which keeps its JSON.
+func formatResultOutput(tool, text string, fields map[string]interface{}, output string) (string, bool) {
+	if inner, ok := fields["result"].(map[string]interface{}); ok {
+		data, err := json.Marshal(inner)
+		if err != nil {
+			return "", false
+		}
+		text, fields = string(data), inner
+	}
+	if output == OutputAnswerOnly {
+		for _, field := range cliAnswerFields {
+			if answer, ok := fields[field].(string); ok && strings.TrimSpace(answer) != "" {
+				return answer, true
+			}
+		}
+		return "", false
+	}
+	// The strategies with a report of their own use it, the others and
+	// degraded results the generic markdown of the CLI
+	report := tool
+	if degraded, _ := fields["degraded"].(bool); degraded {
+		report = ""
+	}
+	switch report {
+	case "graph_of_thoughts", "got_continue":
+		var result GoTResult
+		if json.Unmarshal([]byte(text), &result) == nil {
+			return FormatGoTResult(&result), true
+		}
+	case "reflexion":
+		var result ReflexionResult
+		if json.Unmarshal([]byte(text), &result) == nil {
+			return FormatReflexionResult(&result), true
+		}
+	case "dialectic_reason":
+		var result DialecticResult
+		if json.Unmarshal([]byte(text), &result) == nil {
+			return FormatDialecticResult(&result), true
+		}
+	}
+	return formatCLIMarkdown(tool, text), true
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callToolText calls a tool and returns the text of its result and its
+// structured content
+func callToolText(t *testing.T, s *server.MCPServer, name string, args map[string]interface{}) (string, map[string]interface{}) {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+	raw, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": `+string(params)+`}`)))
+	var resp struct {
+		Result struct {
+			Content           []mcp.TextContent      `json:"content"`
+			StructuredContent map[string]interface{} `json:"structuredContent"`
+			IsError           bool                   `json:"isError"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Content) == 0 || resp.Result.IsError {
+		t.Fatalf("%s: expected a result, got %s", name, raw)
+	}
+	return resp.Result.Content[0].Text, resp.Result.StructuredContent
+}
+
+func TestResultOutput(t *testing.T) {
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t, resultOutputMiddleware)
+	problem := "What is 17 * 23?"
+
+	text, structured := callToolText(t, s, "reflexion", map[string]interface{}{"problem": problem, "learn_from_past": false, "output": "answer_only"})
+	if strings.TrimSpace(text) != "391" {
+		t.Errorf("Expected only the answer, got %q", text)
+	}
+	if structured["final_answer"] != "391" {
+		t.Errorf("Expected the result object as structured content, got %v", structured)
+	}
+
+	text, _ = callToolText(t, s, "graph_of_thoughts", map[string]interface{}{"problem": problem, "output": "markdown"})
+	if !strings.HasPrefix(text, "## Graph of Thoughts Result") || !strings.Contains(text, "### Final Answer") {
+		t.Errorf("Expected the GoT report, got %q", text)
+	}
+
+	// Strategies without a report of their own get the generic markdown
+	text, _ = callToolText(t, s, "decompose_solve", map[string]interface{}{"problem": problem, "output": "markdown"})
+	if !strings.HasPrefix(text, "# decompose_solve") || !strings.Contains(text, "## Answer\n\nThe answer is 391.") {
+		t.Errorf("Expected the generic markdown, got %q", text)
+	}
+
+	text, _ = callToolText(t, s, "sequential_thinking", map[string]interface{}{"problem": problem, "output": "json"})
+	if !json.Valid([]byte(text)) {
+		t.Errorf("Expected the JSON result, got %q", text)
+	}
+
+	text = callToolError(t, s, "reflexion", map[string]interface{}{"problem": problem, "output": "yaml"})
+	if !strings.Contains(text, errCodeInvalidArgument) || !strings.Contains(text, "output") {
+		t.Errorf("Expected an invalid argument error, got %q", text)
+	}
+
+	// Tools outside the strategies refuse output instead of ignoring it
+	text = callToolError(t, s, "verify_claim", map[string]interface{}{"claim": "17 * 23 = 391", "output": "answer_only"})
+	if !strings.Contains(text, errCodeInvalidArgument) || !strings.Contains(text, "not supported by verify_claim") || !strings.Contains(text, "reflexion") {
+		t.Errorf("Expected output to be refused on verify_claim, got %q", text)
+	}
+}
+
+func TestFormatResultOutput_StreamingAndDegraded(t *testing.T) {
+	wrapped := `{"stream_id": "s1", "result": {"problem": "p", "final_answer": "391", "success": true}}`
+	var fields map[string]interface{}
+	json.Unmarshal([]byte(wrapped), &fields)
+	if text, ok := formatResultOutput("reflexion", wrapped, fields, OutputAnswerOnly); !ok || text != "391" {
+		t.Errorf("Expected the answer inside the streaming wrapper, got %q", text)
+	}
+
+	degraded := `{"degraded": true, "tool": "graph_of_thoughts", "problem": "p", "error": "provider down"}`
+	fields = nil
+	json.Unmarshal([]byte(degraded), &fields)
+	if _, ok := formatResultOutput("graph_of_thoughts", degraded, fields, OutputAnswerOnly); ok {
+		t.Errorf("Expected a result without an answer to keep its JSON")
+	}
+	if text, _ := formatResultOutput("graph_of_thoughts", degraded, fields, OutputMarkdown); !strings.Contains(text, "provider down") {
+		t.Errorf("Expected degraded results in the generic markdown, got %q", text)
+	}
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ============ Result Signing ============
+//
+// With RESULT_SIGNING_KEY set, every stored run record, Graph of Thoughts run
+// and memory episode carries a "signature": an HMAC-SHA256 over the record's
+// canonical JSON. The canonical form is the record without its signature
+// field, object keys sorted, no insignificant whitespace and no HTML
+// escaping, so a consumer holding the key can recompute it. verify_run checks
+// a stored record against its signature.
+
+const signaturePrefix = "hmac-sha256:"
+
+// Signature check outcomes reported by verify_run
+const (
+	signatureVerified = "verified"
+	signatureTampered = "tampered" // Signed, but the record or signature changed since
+	signatureUnsigned = "unsigned" // Stored without a signature
+	signatureNoKey    = "no_key"   // RESULT_SIGNING_KEY is not set, so nothing can be checked
+)
+
+func resultSigningKey() []byte {
+	return []byte(os.Getenv("RESULT_SIGNING_KEY"))
+}
+
+// signingPayload is the canonical JSON of v: its top-level "signature" field
+// removed, object keys sorted and numbers kept as encoded
+func signingPayload(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	if obj, ok := generic.(map[string]interface{}); ok {
+		delete(obj, "signature")
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func computeSignature(key []byte, v interface{}) (string, error) {
+	canonical, err := signingPayload(v)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(canonical)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// signRecord returns the signature of v, or "" when no signing key is set
+func signRecord(v interface{}) string {
+	key := resultSigningKey()
+	if len(key) == 0 {
+		return ""
+	}
+	sig, err := computeSignature(key, v)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to sign record: %v\n", err)
+		return ""
+	}
+	return sig
+}
+
+// checkSignature reports whether signature matches v under the signing key
+func checkSignature(v interface{}, signature string) string {
+	key := resultSigningKey()
+	switch {
+	case len(key) == 0:
+		return signatureNoKey
+	case signature == "":
+		return signatureUnsigned
+	}
+	want, err := computeSignature(key, v)
+	if err != nil || !hmac.Equal([]byte(want), []byte(signature)) {
+		return signatureTampered
+	}
+	return signatureVerified
+}
+
+// requireUntampered refuses to build on a stored GoT run whose signature no
+// longer matches, since saving it again would sign the altered graph
+func requireUntampered(state *GoTRunState) error {
+	if checkSignature(state, state.Signature) == signatureTampered {
+		return fmt.Errorf("run %s failed signature verification; it was modified outside the server", state.RunID)
+	}
+	return nil
+}
+
+// VerifyRunResult is the verify_run result
+type VerifyRunResult struct {
+	Kind      string `json:"kind"` // got_run, episode or record
+	ID        string `json:"id,omitempty"`
+	Status    string `json:"status"`
+	Verified  bool   `json:"verified"`
+	Signature string `json:"signature,omitempty"`
+}
+
+func handleVerifyRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		args = map[string]interface{}{}
+	}
+	runID, _ := args["run_id"].(string)
+	episodeID, _ := args["episode_id"].(string)
+	record, _ := args["record"].(string)
+	runID, episodeID, record = strings.TrimSpace(runID), strings.TrimSpace(episodeID), strings.TrimSpace(record)
+
+	given := 0
+	for _, v := range []string{runID, episodeID, record} {
+		if v != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return mcp.NewToolResultError("pass exactly one of run_id, episode_id or record"), nil
+	}
+
+	var result VerifyRunResult
+	switch {
+	case runID != "":
+		store := getGoTRunStore()
+		if store == nil {
+			return mcp.NewToolResultError("GoT run persistence is disabled (GOT_PERSIST_RUNS=false)"), nil
+		}
+		state, err := store.Load(runID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result = VerifyRunResult{Kind: "got_run", ID: state.RunID, Signature: state.Signature}
+		result.Status = checkSignature(state, state.Signature)
+
+	case episodeID != "":
+		memory := loadOrCreateMemory(DefaultReflexionConfig().MemoryPath)
+		memory.mu.RLock()
+		var found *Episode
+		for i := range memory.Episodes {
+			if memory.Episodes[i].ID == episodeID {
+				ep := memory.Episodes[i]
+				found = &ep
+				break
+			}
+		}
+		memory.mu.RUnlock()
+		if found == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("episode not found: %s", episodeID)), nil
+		}
+		result = VerifyRunResult{Kind: "episode", ID: found.ID, Signature: found.Signature}
+		result.Status = checkSignature(found, found.Signature)
+
+	default:
+		// Any stored record: a runs.jsonl line, a GoT run file or an episode
+		dec := json.NewDecoder(strings.NewReader(record))
+		dec.UseNumber()
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("record is not a JSON object: %v", err)), nil
+		}
+		sig, _ := obj["signature"].(string)
+		result = VerifyRunResult{Kind: "record", Signature: sig}
+		for _, field := range []string{"run_id", "id"} {
+			if id, ok := obj[field].(string); ok {
+				result.ID = id
+				break
+			}
+		}
+		result.Status = checkSignature(obj, sig)
+	}
+	result.Verified = result.Status == signatureVerified
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize verification: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
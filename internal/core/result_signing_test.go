@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestResultSigning_GoTRunTamperDetection(t *testing.T) {
+	t.Setenv("RESULT_SIGNING_KEY", "test-key")
+	dir := t.TempDir()
+	store, _ := NewGoTRunStore(dir, 0)
+	state := &GoTRunState{
+		RunID:   "got_1_sign",
+		Problem: "Is 1 < 2 & 3 > 2?",
+		Config:  DefaultGoTConfig(),
+		Nodes: map[string]*GoTNode{
+			"root": {ID: "root", NodeType: "thought", Thought: "Is 1 < 2?", Children: []string{"n1_0"}},
+			"n1_0": {ID: "n1_0", NodeType: "thought", Thought: "yes", Depth: 1, Score: 0.1 + 0.2, Parents: []string{"root"}},
+		},
+		FinalAnswer: "yes",
+		CreatedAt:   time.Now(),
+	}
+	if err := store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load(state.RunID)
+	if err != nil || !strings.HasPrefix(loaded.Signature, signaturePrefix) {
+		t.Fatalf("Expected a signed run, got %v, %v", loaded, err)
+	}
+	if status := checkSignature(loaded, loaded.Signature); status != signatureVerified {
+		t.Errorf("Expected the saved run to verify, got %s", status)
+	}
+
+	path := filepath.Join(dir, state.RunID+".json")
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), `"final_answer": "yes"`, `"final_answer": "no"`, 1)), 0600)
+	tampered, _ := store.Load(state.RunID)
+	if status := checkSignature(tampered, tampered.Signature); status != signatureTampered {
+		t.Errorf("Expected the edited run to fail verification, got %s", status)
+	}
+	if err := requireUntampered(tampered); err == nil {
+		t.Error("Expected continuing an edited run to be refused")
+	}
+}
+
+func TestVerifyRun_Record(t *testing.T) {
+	t.Setenv("RESULT_SIGNING_KEY", "test-key")
+	history, _ := NewRunHistory(filepath.Join(t.TempDir(), "runs.jsonl"), 0)
+	confidence := 0.85
+	if err := history.Append(RunRecord{Time: time.Now(), Tool: "reflexion", Category: CategoryMath, Confidence: &confidence, LLMCalls: 3}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(history.path)
+	line := strings.TrimSpace(string(data))
+
+	verify := func(args map[string]interface{}) VerifyRunResult {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		result, _ := handleVerifyRun(context.Background(), request)
+		var out VerifyRunResult
+		if result.IsError || json.Unmarshal([]byte(resultText(result)), &out) != nil {
+			t.Fatalf("verify_run failed: %s", resultText(result))
+		}
+		return out
+	}
+
+	if out := verify(map[string]interface{}{"record": line}); !out.Verified || out.Kind != "record" {
+		t.Errorf("Expected the stored record to verify, got %+v", out)
+	}
+	edited := strings.Replace(line, `"llm_calls":3`, `"llm_calls":1`, 1)
+	if out := verify(map[string]interface{}{"record": edited}); out.Verified || out.Status != signatureTampered {
+		t.Errorf("Expected the edited record to fail verification, got %+v", out)
+	}
+	if out := verify(map[string]interface{}{"record": `{"tool": "reflexion"}`}); out.Status != signatureUnsigned {
+		t.Errorf("Expected an unsigned record, got %+v", out)
+	}
+
+	t.Setenv("RESULT_SIGNING_KEY", "")
+	if out := verify(map[string]interface{}{"record": line}); out.Status != signatureNoKey {
+		t.Errorf("Expected no_key without a signing key, got %+v", out)
+	}
+	if sig := signRecord(RunRecord{Tool: "reflexion"}); sig != "" {
+		t.Errorf("Expected no signature without a key, got %q", sig)
+	}
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Strategy Router ============
+//
+// auto_reason picks a strategy and a model tier for the problem and runs it,
+// for callers who just want "think about this". Routing is a keyword and
+// length heuristic by default, or one short LLM call with router: "llm".
+// The tier is a profile (fast, balanced, thorough) applied under the
+// caller's explicit arguments.
+
+// Problem complexity levels and the profile each one runs with
+const (
+	ComplexitySimple   = "simple"
+	ComplexityModerate = "moderate"
+	ComplexityComplex  = "complex"
+)
+
+var complexityProfiles = map[string]string{
+	ComplexitySimple:   "fast",
+	ComplexityModerate: "balanced",
+	ComplexityComplex:  "thorough",
+}
+
+// routedStrategies are the strategies auto_reason chooses between
+var routedStrategies = map[string]server.ToolHandlerFunc{
+	"sequential_thinking": handleSequentialThink,
+	"graph_of_thoughts":   handleGraphOfThoughts,
+	"dialectic_reason":    handleDialecticReason,
+}
+
+// RouteDecision is auto_reason's choice of strategy and tier
+type RouteDecision struct {
+	Strategy   string `json:"strategy"`
+	Complexity string `json:"complexity"`
+	Profile    string `json:"profile"` // Tier applied to the strategy; empty when the caller chose one
+	Category   string `json:"category"`
+	Method     string `json:"method"` // heuristic or llm
+	Reason     string `json:"reason"`
+}
+
+// AutoReasonResult wraps the routed strategy's result
+type AutoReasonResult struct {
+	Routing RouteDecision   `json:"routing"`
+	Result  json.RawMessage `json:"result"`
+}
+
+var (
+	debateSignal  = regexp.MustCompile(`(?i)\b(should (we|i|you|they|companies|the)|pros and cons|trade-?offs?|versus|vs\.?|better to|is it (better|worth|ethical|fair)|argue|debate|controversial|ethic(al|s)|opinion|advantages and disadvantages|compare)\b`)
+	complexSignal = regexp.MustCompile(`(?i)\b(design|architect(ure)?|optimi[sz]e|prove|proof|derive|multi-?step|strategy|plan|algorithm|system|distributed|trade-?offs?|edge cases|constraints?|scal(e|able|ability))\b`)
+	simpleSignal  = regexp.MustCompile(`(?i)^\s*(what is|what's|who is|when (did|was)|where is|define|how many|convert|calculate)\b`)
+)
+
+// routeHeuristic classifies a problem by keywords and length
+func routeHeuristic(problem string) RouteDecision {
+	words := len(strings.Fields(problem))
+	questions := strings.Count(problem, "?")
+	complexHits := len(complexSignal.FindAllStringIndex(problem, -1))
+
+	d := RouteDecision{Category: ClassifyProblem(problem), Method: "heuristic"}
+	switch {
+	case words > 120 || complexHits >= 3 || (complexHits >= 1 && words > 40) || questions > 2:
+		d.Complexity = ComplexityComplex
+	case words <= 25 && complexHits == 0 && (simpleSignal.MatchString(problem) || d.Category == CategoryFactual || words <= 12):
+		d.Complexity = ComplexitySimple
+	default:
+		d.Complexity = ComplexityModerate
+	}
+
+	switch {
+	case debateSignal.MatchString(problem):
+		d.Strategy = "dialectic_reason"
+		d.Reason = "the problem weighs positions or trade-offs"
+	case d.Complexity == ComplexitySimple:
+		d.Strategy = "sequential_thinking"
+		d.Reason = "a short, direct question"
+	case d.Complexity == ComplexityComplex || d.Category == CategoryMath || d.Category == CategoryCoding || d.Category == CategoryPlanning:
+		d.Strategy = "graph_of_thoughts"
+		d.Reason = fmt.Sprintf("a %s %s problem that benefits from exploring alternatives", d.Complexity, d.Category)
+	default:
+		d.Strategy = "sequential_thinking"
+		d.Reason = "a moderate problem without competing positions"
+	}
+	return d
+}
+
+const routerSystemPrompt = `You route problems to a reasoning strategy.
+Strategies:
+- sequential_thinking: a linear chain of thought, for direct questions and routine problems
+- graph_of_thoughts: explores and merges alternative paths, for hard math, coding, design and planning problems
+- dialectic_reason: thesis, antithesis and synthesis, for questions with competing positions or trade-offs
+
+Complexity is simple (a quick answer), moderate or complex (many steps or constraints).
+
+Respond with ONLY a JSON object:
+{"strategy": "<strategy>", "complexity": "<simple|moderate|complex>", "reason": "<one short sentence>"}`
+
+// routeWithLLM asks the provider to route the problem, falling back to the
+// heuristic when the call fails or the answer is unusable
+func routeWithLLM(ctx context.Context, provider Provider, problem string) RouteDecision {
+	fallback := routeHeuristic(problem)
+	response, err := provider.Chat(ctx, []ChatMessage{
+		{Role: "system", Content: routerSystemPrompt},
+		{Role: "user", Content: utils.TruncateStr(problem, 4000)},
+	}, ChatOptions{Temperature: 0, MaxTokens: 200})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARNING] auto_reason: router call failed, using heuristics: %v\n", err)
+		return fallback
+	}
+
+	var parsed struct {
+		Strategy   string `json:"strategy"`
+		Complexity string `json:"complexity"`
+		Reason     string `json:"reason"`
+	}
+	jsonStr := utils.ExtractJSON(response)
+	if jsonStr == "" || json.Unmarshal([]byte(jsonStr), &parsed) != nil {
+		fmt.Fprintf(os.Stderr, "[WARNING] auto_reason: failed to parse router response, using heuristics. Response preview: %s\n",
+			utils.TruncateStr(response, 100))
+		return fallback
+	}
+	parsed.Strategy = strings.ToLower(strings.TrimSpace(parsed.Strategy))
+	parsed.Complexity = strings.ToLower(strings.TrimSpace(parsed.Complexity))
+	if _, ok := routedStrategies[parsed.Strategy]; !ok {
+		return fallback
+	}
+	if _, ok := complexityProfiles[parsed.Complexity]; !ok {
+		parsed.Complexity = fallback.Complexity
+	}
+	return RouteDecision{
+		Strategy:   parsed.Strategy,
+		Complexity: parsed.Complexity,
+		Category:   fallback.Category,
+		Method:     "llm",
+		Reason:     strings.TrimSpace(parsed.Reason),
+	}
+}
+
+func handleAutoReason(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	problem, ok := args["problem"].(string)
+	if !ok || problem == "" {
+		return mcp.NewToolResultError("problem parameter is required"), nil
+	}
+
+	var decision RouteDecision
+	switch router := strings.ToLower(getStringArgOrEnv(args, "router", "AUTO_REASON_ROUTER")); router {
+	case "", "heuristic":
+		decision = routeHeuristic(problem)
+	case "llm":
+		provider, err := getProviderFromArgsForTool(args, "auto_reason")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Provider error: %v", err)), nil
+		}
+		decision = routeWithLLM(ctx, provider, problem)
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unknown router %q (use heuristic or llm)", router)), nil
+	}
+	if strategy, _ := args["strategy"].(string); strategy != "" {
+		if _, ok := routedStrategies[strategy]; !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown strategy %q (use sequential_thinking, graph_of_thoughts or dialectic_reason)", strategy)), nil
+		}
+		decision.Strategy, decision.Reason = strategy, "chosen by the caller"
+	}
+
+	var declared map[string]interface{}
+	if srv := server.ServerFromContext(ctx); srv != nil {
+		if tool := srv.GetTool(decision.Strategy); tool != nil {
+			declared = tool.Tool.InputSchema.Properties
+		}
+	}
+	routedArgs := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if k != "router" && k != "strategy" {
+			routedArgs[k] = v
+		}
+	}
+	if _, chosen := args["profile"]; !chosen {
+		profiles, err := loadProfiles()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		decision.Profile = complexityProfiles[decision.Complexity]
+		routedArgs = applyProfile(routedArgs, profiles[decision.Profile], declared, sessionDefaultedFromContext(ctx))
+	}
+
+	request.Params.Name = decision.Strategy
+	request.Params.Arguments = routedArgs
+	result, err := routedStrategies[decision.Strategy](ctx, request)
+	if err != nil || result == nil || result.IsError || len(result.Content) == 0 {
+		return result, err
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok || !json.Valid([]byte(text.Text)) {
+		return result, nil
+	}
+
+	output, err := json.MarshalIndent(AutoReasonResult{Routing: decision, Result: json.RawMessage(text.Text)}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize response: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
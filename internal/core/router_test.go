@@ -0,0 +1,39 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRouteHeuristic(t *testing.T) {
+	for _, tc := range []struct {
+		problem, strategy, complexity string
+	}{
+		{"What is the capital of Australia?", "sequential_thinking", ComplexitySimple},
+		{"What is 17 * 23?", "sequential_thinking", ComplexitySimple},
+		{"Should companies adopt a 4-day work week?", "dialectic_reason", ComplexitySimple},
+		{"Compare PostgreSQL versus MongoDB for an analytics workload with heavy joins and frequent schema changes", "dialectic_reason", ComplexityModerate},
+		{"Find all integer solutions of x^2 - y^2 = 45 and explain why there are no others", "graph_of_thoughts", ComplexityModerate},
+		{"Design a distributed rate limiter that scales to millions of users per second across regions, handles clock skew and network partitions, and degrades gracefully when the backing store is unavailable. Describe the algorithm and its edge cases.", "graph_of_thoughts", ComplexityComplex},
+		{"Summarize the main causes of the French Revolution in a few sentences for a high school student", "sequential_thinking", ComplexityModerate},
+	} {
+		d := routeHeuristic(tc.problem)
+		if d.Strategy != tc.strategy || d.Complexity != tc.complexity || d.Method != "heuristic" || d.Reason == "" {
+			t.Errorf("routeHeuristic(%q) = %s/%s (%s), want %s/%s", tc.problem, d.Strategy, d.Complexity, d.Reason, tc.strategy, tc.complexity)
+		}
+	}
+}
+
+func TestRouteWithLLM(t *testing.T) {
+	problem := "What is the capital of Australia?"
+	d := routeWithLLM(context.Background(), &countProvider{response: "Sure: {\"strategy\": \"Graph_of_Thoughts\", \"complexity\": \"complex\", \"reason\": \"needs care\"}"}, problem)
+	if d.Strategy != "graph_of_thoughts" || d.Complexity != ComplexityComplex || d.Method != "llm" || d.Category != CategoryFactual {
+		t.Errorf("Expected the LLM's route, got %+v", d)
+	}
+
+	for _, response := range []string{"no idea", `{"strategy": "magic", "complexity": "simple"}`} {
+		if d := routeWithLLM(context.Background(), &countProvider{response: response}, problem); d.Method != "heuristic" || d.Strategy != "sequential_thinking" {
+			t.Errorf("Expected the heuristic fallback for %q, got %+v", response, d)
+		}
+	}
+}
@@ -0,0 +1,547 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Run History & Analytics ============
+//
+// Every reasoning strategy run is appended to a JSONL history file with its
+// tool, problem category, outcome, confidence, LLM calls and latency. The
+// analytics tool aggregates that history, together with reflexion's episodic
+// memory, into per-strategy and per-category success rates.
+
+// defaultRunHistoryMaxBytes is the size at which the history file is rotated
+const defaultRunHistoryMaxBytes = 10 << 20
+
+// runHistoryTools are the strategy tools whose runs are recorded
+var runHistoryTools = map[string]bool{
+	"sequential_thinking": true,
+	"graph_of_thoughts":   true,
+	"got_continue":        true,
+	"reflexion":           true,
+	"dialectic_reason":    true,
+	"decompose_solve":     true,
+	"plan_execute":        true,
+	"reasoning_pipeline":  true,
+	"auto_reason":         true,
+}
+
+// RunRecord is one recorded strategy run
+type RunRecord struct {
+	Time       time.Time `json:"time"`
+	Tool       string    `json:"tool"`
+	Category   string    `json:"category"`
+	Provider   string    `json:"provider,omitempty"`
+	Success    *bool     `json:"success,omitempty"`    // Unset when the result has no success field
+	Confidence *float64  `json:"confidence,omitempty"` // Unset when the strategy reports no confidence
+	LLMCalls   int       `json:"llm_calls"`
+	LatencyMs  int64     `json:"latency_ms"`
+	Error      bool      `json:"error,omitempty"`
+	Cached     bool      `json:"cached,omitempty"`    // Served without LLM calls
+	Signature  string    `json:"signature,omitempty"` // HMAC of the rest, with RESULT_SIGNING_KEY set
+}
+
+// RunHistory is an append-only JSONL store of run records, rotated to
+// <path>.1 once it grows past maxBytes
+type RunHistory struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+}
+
+// NewRunHistory creates a history store at path
+func NewRunHistory(path string, maxBytes int64) (*RunHistory, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create run history directory: %w", err)
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultRunHistoryMaxBytes
+	}
+	return &RunHistory{path: path, maxBytes: maxBytes}, nil
+}
+
+// Append adds a record to the history
+func (h *RunHistory) Append(rec RunRecord) error {
+	rec.Signature = signRecord(rec)
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if info, err := os.Stat(h.path); err == nil && info.Size()+int64(len(line)) > h.maxBytes {
+		if err := os.Rename(h.path, h.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate run history: %w", err)
+		}
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load returns the records in [since, until), oldest first. A zero bound is
+// open. Unparseable lines are skipped.
+func (h *RunHistory) Load(since, until time.Time) ([]RunRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var records []RunRecord
+	for _, path := range []string{h.path + ".1", h.path} {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for scanner.Scan() {
+			var rec RunRecord
+			if json.Unmarshal(scanner.Bytes(), &rec) != nil {
+				continue
+			}
+			if (!since.IsZero() && rec.Time.Before(since)) || (!until.IsZero() && !rec.Time.Before(until)) {
+				continue
+			}
+			records = append(records, rec)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+var (
+	runHistory     *RunHistory
+	runHistoryOnce sync.Once
+)
+
+// getRunHistory returns the shared run history, or nil when it is disabled.
+// RUN_HISTORY=false disables it, RUN_HISTORY_PATH overrides the location and
+// RUN_HISTORY_MAX_MB sets the rotation size.
+func getRunHistory() *RunHistory {
+	runHistoryOnce.Do(func() {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("RUN_HISTORY"))) {
+		case "false", "0", "off":
+			return
+		}
+
+		path := os.Getenv("RUN_HISTORY_PATH")
+		if path == "" {
+			homeDir, _ := os.UserHomeDir()
+			path = filepath.Join(homeDir, ".local", "share", "reasoning-tools", "runs.jsonl")
+		}
+
+		history, err := NewRunHistory(path, int64(parseEnvInt("RUN_HISTORY_MAX_MB", 0))<<20)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (run history will not be recorded)\n", err)
+			return
+		}
+		runHistory = history
+	})
+	return runHistory
+}
+
+// runHistoryMiddleware records strategy runs in the shared run history
+func runHistoryMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return newRunHistoryMiddleware(getRunHistory)(next)
+}
+
+// newRunHistoryMiddleware records strategy runs in the history returned by
+// history. It must sit inside queueRunMiddleware, whose run counts LLM calls.
+func newRunHistoryMiddleware(history func() *RunHistory) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !runHistoryTools[request.Params.Name] {
+				return next(ctx, request)
+			}
+			h := history()
+			if h == nil {
+				return next(ctx, request)
+			}
+
+			start := time.Now()
+			result, err := next(ctx, request)
+			rec := buildRunRecord(request, result, err, start)
+			if run := queueRunFromContext(ctx); run != nil {
+				rec.LLMCalls = int(run.llmCalls.Load())
+				rec.Cached = rec.LLMCalls == 0 && !rec.Error
+			}
+			if appendErr := h.Append(rec); appendErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record run: %v\n", appendErr)
+			}
+			return result, err
+		}
+	}
+}
+
+// buildRunRecord extracts a run record from a tool call and its result
+func buildRunRecord(request mcp.CallToolRequest, result *mcp.CallToolResult, err error, start time.Time) RunRecord {
+	rec := RunRecord{
+		Time:      start.UTC(),
+		Tool:      request.Params.Name,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Error:     err != nil || result == nil || result.IsError,
+	}
+
+	var fields map[string]interface{}
+	if !rec.Error {
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				fields = runResultFields(text.Text)
+				break
+			}
+		}
+	}
+
+	problem, _ := fields["problem"].(string)
+	if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
+		if p, ok := args["problem"].(string); ok && p != "" {
+			problem = p
+		}
+	}
+	rec.Category, _ = fields["category"].(string)
+	if rec.Category == "" {
+		rec.Category = ClassifyProblem(problem)
+	}
+	rec.Provider, _ = fields["provider"].(string)
+	if success, ok := fields["success"].(bool); ok {
+		rec.Success = &success
+	}
+	if confidence, ok := runConfidence(fields); ok {
+		rec.Confidence = &confidence
+	}
+	return rec
+}
+
+// runResultFields parses a tool's JSON output, unwrapping the streaming and
+// semantic cache envelopes
+func runResultFields(text string) map[string]interface{} {
+	var fields map[string]interface{}
+	if json.Unmarshal([]byte(text), &fields) != nil {
+		return nil
+	}
+	if inner, ok := fields["result"].(map[string]interface{}); ok {
+		if _, hasProblem := fields["problem"]; !hasProblem {
+			return inner
+		}
+	}
+	return fields
+}
+
+// runConfidence reads a result's confidence, falling back to the score of
+// the last node on a graph's best path
+func runConfidence(fields map[string]interface{}) (float64, bool) {
+	if c, ok := fields["confidence"].(float64); ok {
+		return c, true
+	}
+	if path, ok := fields["best_path"].([]interface{}); ok && len(path) > 0 {
+		if node, ok := path[len(path)-1].(map[string]interface{}); ok {
+			if score, ok := node["score"].(float64); ok {
+				return score, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// StrategyStats aggregates the runs of one strategy, optionally in one category
+type StrategyStats struct {
+	Tool          string   `json:"tool"`
+	Category      string   `json:"category,omitempty"`
+	Runs          int      `json:"runs"`
+	Errors        int      `json:"errors"`
+	Cached        int      `json:"cached"`
+	SuccessRate   *float64 `json:"success_rate,omitempty"` // Over runs that reported success
+	AvgConfidence *float64 `json:"avg_confidence,omitempty"`
+	TotalLLMCalls int      `json:"total_llm_calls"`
+	AvgLLMCalls   float64  `json:"avg_llm_calls"`
+	AvgLatencyMs  float64  `json:"avg_latency_ms"` // Over uncached runs
+	P95LatencyMs  int64    `json:"p95_latency_ms"`
+
+	successes, judged, confidences int
+	confidenceSum                  float64
+	latencies                      []int64
+}
+
+func (s *StrategyStats) add(rec RunRecord) {
+	s.Runs++
+	s.TotalLLMCalls += rec.LLMCalls
+	if rec.Error {
+		s.Errors++
+	}
+	if rec.Cached {
+		s.Cached++
+	} else {
+		s.latencies = append(s.latencies, rec.LatencyMs)
+	}
+	if rec.Success != nil {
+		s.judged++
+		if *rec.Success {
+			s.successes++
+		}
+	}
+	if rec.Confidence != nil {
+		s.confidences++
+		s.confidenceSum += *rec.Confidence
+	}
+}
+
+func (s *StrategyStats) finish() {
+	if s.judged > 0 {
+		rate := roundTo(float64(s.successes)/float64(s.judged), 3)
+		s.SuccessRate = &rate
+	}
+	if s.confidences > 0 {
+		avg := roundTo(s.confidenceSum/float64(s.confidences), 3)
+		s.AvgConfidence = &avg
+	}
+	if s.Runs > 0 {
+		s.AvgLLMCalls = roundTo(float64(s.TotalLLMCalls)/float64(s.Runs), 2)
+	}
+	if len(s.latencies) > 0 {
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		var sum int64
+		for _, l := range s.latencies {
+			sum += l
+		}
+		s.AvgLatencyMs = roundTo(float64(sum)/float64(len(s.latencies)), 1)
+		s.P95LatencyMs = s.latencies[(len(s.latencies)*95-1)/100]
+	}
+}
+
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+// MemoryCategoryStats summarizes reflexion episodes in one category
+type MemoryCategoryStats struct {
+	Episodes    int     `json:"episodes"`
+	Successful  int     `json:"successful"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// AnalyticsReport is the output of the analytics tool
+type AnalyticsReport struct {
+	Since      string                         `json:"since,omitempty"`
+	Until      string                         `json:"until,omitempty"`
+	TotalRuns  int                            `json:"total_runs"`
+	Strategies []*StrategyStats               `json:"strategies"`  // One row per strategy
+	ByCategory []*StrategyStats               `json:"by_category"` // One row per strategy and category
+	Memory     map[string]MemoryCategoryStats `json:"memory,omitempty"`
+	Guidance   []string                       `json:"guidance,omitempty"`
+}
+
+// AnalyticsFilter narrows the runs an analytics report covers
+type AnalyticsFilter struct {
+	Since, Until time.Time
+	Tool         string
+	Category     string
+}
+
+// minGuidanceRuns is how many judged runs a strategy needs in a category
+// before analytics recommends it
+const minGuidanceRuns = 3
+
+// buildAnalytics aggregates run records and memory episodes into a report
+func buildAnalytics(records []RunRecord, episodes []Episode, filter AnalyticsFilter) *AnalyticsReport {
+	report := &AnalyticsReport{Strategies: []*StrategyStats{}, ByCategory: []*StrategyStats{}}
+	if !filter.Since.IsZero() {
+		report.Since = filter.Since.Format(time.RFC3339)
+	}
+	if !filter.Until.IsZero() {
+		report.Until = filter.Until.Format(time.RFC3339)
+	}
+
+	byTool := make(map[string]*StrategyStats)
+	byPair := make(map[[2]string]*StrategyStats)
+	for _, rec := range records {
+		if (filter.Tool != "" && rec.Tool != filter.Tool) || (filter.Category != "" && rec.Category != filter.Category) {
+			continue
+		}
+		report.TotalRuns++
+		if byTool[rec.Tool] == nil {
+			byTool[rec.Tool] = &StrategyStats{Tool: rec.Tool}
+			report.Strategies = append(report.Strategies, byTool[rec.Tool])
+		}
+		byTool[rec.Tool].add(rec)
+		key := [2]string{rec.Tool, rec.Category}
+		if byPair[key] == nil {
+			byPair[key] = &StrategyStats{Tool: rec.Tool, Category: rec.Category}
+			report.ByCategory = append(report.ByCategory, byPair[key])
+		}
+		byPair[key].add(rec)
+	}
+	for _, s := range report.Strategies {
+		s.finish()
+	}
+	for _, s := range report.ByCategory {
+		s.finish()
+	}
+	sort.Slice(report.Strategies, func(i, j int) bool { return report.Strategies[i].Tool < report.Strategies[j].Tool })
+	sort.Slice(report.ByCategory, func(i, j int) bool {
+		a, b := report.ByCategory[i], report.ByCategory[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.Tool < b.Tool
+	})
+
+	if filter.Tool == "" || filter.Tool == "reflexion" {
+		for _, ep := range episodes {
+			if ep.Seed || (!filter.Since.IsZero() && ep.Timestamp.Before(filter.Since)) || (!filter.Until.IsZero() && !ep.Timestamp.Before(filter.Until)) {
+				continue
+			}
+			category := episodeCategory(ep)
+			if filter.Category != "" && category != filter.Category {
+				continue
+			}
+			if report.Memory == nil {
+				report.Memory = make(map[string]MemoryCategoryStats)
+			}
+			stats := report.Memory[category]
+			stats.Episodes++
+			if ep.WasSuccessful {
+				stats.Successful++
+			}
+			stats.SuccessRate = roundTo(float64(stats.Successful)/float64(stats.Episodes), 3)
+			report.Memory[category] = stats
+		}
+	}
+
+	report.Guidance = analyticsGuidance(report.ByCategory)
+	return report
+}
+
+// analyticsGuidance names the most successful strategy in each category
+// that has enough judged runs to compare
+func analyticsGuidance(rows []*StrategyStats) []string {
+	var guidance []string
+	for _, s := range bestStrategies(rows) {
+		guidance = append(guidance, fmt.Sprintf("%s problems: %s has the best success rate (%.0f%% over %d runs, %.1f LLM calls on average)",
+			s.Category, s.Tool, *s.SuccessRate*100, s.judged, s.AvgLLMCalls))
+	}
+	return guidance
+}
+
+// bestStrategies returns the by-category row of the most successful strategy
+// in each category with at least minGuidanceRuns judged runs, breaking ties
+// on fewer LLM calls
+func bestStrategies(rows []*StrategyStats) []*StrategyStats {
+	best := make(map[string]*StrategyStats)
+	var categories []string
+	for _, s := range rows {
+		if s.SuccessRate == nil || s.judged < minGuidanceRuns {
+			continue
+		}
+		cur, ok := best[s.Category]
+		if !ok {
+			categories = append(categories, s.Category)
+		}
+		if !ok || *s.SuccessRate > *cur.SuccessRate || (*s.SuccessRate == *cur.SuccessRate && s.AvgLLMCalls < cur.AvgLLMCalls) {
+			best[s.Category] = s
+		}
+	}
+
+	result := make([]*StrategyStats, 0, len(categories))
+	for _, category := range categories {
+		result = append(result, best[category])
+	}
+	return result
+}
+
+// parseAnalyticsTime parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseAnalyticsTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use RFC3339 or YYYY-MM-DD)", value)
+}
+
+func handleAnalytics(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		args = map[string]interface{}{}
+	}
+
+	var filter AnalyticsFilter
+	var err error
+	since, _ := args["since"].(string)
+	if filter.Since, err = parseAnalyticsTime(since); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("since: %v", err)), nil
+	}
+	until, _ := args["until"].(string)
+	if filter.Until, err = parseAnalyticsTime(until); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("until: %v", err)), nil
+	}
+	if len(strings.TrimSpace(until)) == len("2006-01-02") {
+		filter.Until = filter.Until.AddDate(0, 0, 1) // A date bound includes the whole day
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return mcp.NewToolResultError("since must be before until"), nil
+	}
+	if tool, _ := args["tool"].(string); tool != "" {
+		if !runHistoryTools[tool] {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %q is not a recorded strategy", tool)), nil
+		}
+		filter.Tool = tool
+	}
+	if category, _ := args["category"].(string); category != "" {
+		if filter.Category, err = parseCategoryFilter(category); err != nil || filter.Category == "auto" {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown category %q", category)), nil
+		}
+	}
+
+	history := getRunHistory()
+	if history == nil {
+		return mcp.NewToolResultError("run history is disabled (RUN_HISTORY=false)"), nil
+	}
+	records, err := history.Load(filter.Since, filter.Until)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read run history: %v", err)), nil
+	}
+
+	memory := loadOrCreateMemory(DefaultReflexionConfig().MemoryPath)
+	memory.mu.RLock()
+	episodes := append([]Episode(nil), memory.Episodes...)
+	memory.mu.RUnlock()
+
+	report := buildAnalytics(records, episodes, filter)
+	refreshToolRecommendations(ctx)
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize analytics: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunHistoryMiddleware_RecordsRuns(t *testing.T) {
+	history, err := NewRunHistory(filepath.Join(t.TempDir(), "runs.jsonl"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := queueRunMiddleware(newRunHistoryMiddleware(func() *RunHistory { return history })(
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := request.GetArguments()
+			if args["calls"] == true {
+				for i := 0; i < 2; i++ {
+					release, err := AcquireProviderSlot(ctx, "openai")
+					if err != nil {
+						return nil, err
+					}
+					release()
+				}
+			}
+			if request.Params.Name == "graph_of_thoughts" {
+				return mcp.NewToolResultText(`{"stream": [], "result": {"problem": "Compute 17 * 23", "success": true, "provider": "openai", "best_path": [{"score": 0.5}, {"score": 0.9}]}}`), nil
+			}
+			return mcp.NewToolResultText(`{"success": false, "confidence": 0.4}`), nil
+		}))
+
+	call := func(tool string, args map[string]interface{}) {
+		var request mcp.CallToolRequest
+		request.Params.Name = tool
+		request.Params.Arguments = args
+		handler(context.Background(), request)
+	}
+	call("graph_of_thoughts", map[string]interface{}{"calls": true})
+	call("dialectic_reason", map[string]interface{}{"problem": "Write a haiku about autumn rain"})
+	call("queue_status", nil) // Not a strategy
+
+	records, err := history.Load(time.Time{}, time.Time{})
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected two records, got %v (%v)", records, err)
+	}
+	got := records[0]
+	if got.Tool != "graph_of_thoughts" || got.Category != CategoryMath || got.Provider != "openai" ||
+		got.Success == nil || !*got.Success || got.Confidence == nil || *got.Confidence != 0.9 || got.LLMCalls != 2 || got.Cached {
+		t.Errorf("Unexpected GoT record: %+v", got)
+	}
+	got = records[1]
+	if got.Category != CategoryCreative || got.Success == nil || *got.Success || *got.Confidence != 0.4 || !got.Cached {
+		t.Errorf("Unexpected dialectic record: %+v", got)
+	}
+}
+
+func TestRunHistory_LoadRangeAndRotation(t *testing.T) {
+	history, _ := NewRunHistory(filepath.Join(t.TempDir(), "runs.jsonl"), 150)
+	base := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if err := history.Append(RunRecord{Time: base.AddDate(0, 0, i), Tool: "reflexion", Category: CategoryMath}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if records, _ := history.Load(time.Time{}, time.Time{}); len(records) < 2 {
+		t.Errorf("Expected records from both the current and rotated file, got %d", len(records))
+	}
+	records, _ := history.Load(base.AddDate(0, 0, 2), base.AddDate(0, 0, 3))
+	if len(records) != 1 || !records[0].Time.Equal(base.AddDate(0, 0, 2)) {
+		t.Errorf("Expected only the third day's run, got %v", records)
+	}
+}
+
+func TestBuildAnalytics(t *testing.T) {
+	yes, no := true, false
+	conf := func(v float64) *float64 { return &v }
+	var records []RunRecord
+	for i := 0; i < 4; i++ {
+		records = append(records, RunRecord{Tool: "graph_of_thoughts", Category: CategoryMath, Success: &yes, Confidence: conf(0.8), LLMCalls: 10, LatencyMs: 1000})
+		records = append(records, RunRecord{Tool: "reflexion", Category: CategoryMath, Success: &no, LLMCalls: 4, LatencyMs: 500})
+	}
+	records[7].Success = &yes
+	records = append(records,
+		RunRecord{Tool: "reflexion", Category: CategoryCoding, Success: &yes, LatencyMs: 5, Cached: true},
+		RunRecord{Tool: "dialectic_reason", Category: CategoryCreative, Error: true, LLMCalls: 2, LatencyMs: 300},
+	)
+	episodes := []Episode{
+		{Problem: "Compute 2 + 2", WasSuccessful: true},
+		{Problem: "Compute 3 + 3", Category: CategoryMath},
+		{Problem: "prime", Seed: true},
+	}
+
+	report := buildAnalytics(records, episodes, AnalyticsFilter{})
+	if report.TotalRuns != 10 || len(report.Strategies) != 3 || len(report.ByCategory) != 4 {
+		t.Fatalf("Unexpected report shape: %+v", report)
+	}
+	var reflexionMath *StrategyStats
+	for _, row := range report.ByCategory {
+		if row.Tool == "reflexion" && row.Category == CategoryMath {
+			reflexionMath = row
+		}
+	}
+	if reflexionMath == nil || *reflexionMath.SuccessRate != 0.25 || reflexionMath.AvgConfidence != nil || reflexionMath.AvgLLMCalls != 4 || reflexionMath.AvgLatencyMs != 500 {
+		t.Errorf("Unexpected reflexion/math row: %+v", reflexionMath)
+	}
+	for _, row := range report.Strategies {
+		if row.Tool == "reflexion" && (row.Cached != 1 || row.AvgLatencyMs != 500) {
+			t.Errorf("Expected the cached run left out of latency: %+v", row)
+		}
+		if row.Tool == "dialectic_reason" && (row.Errors != 1 || row.SuccessRate != nil) {
+			t.Errorf("Unexpected dialectic row: %+v", row)
+		}
+	}
+	if m := report.Memory[CategoryMath]; m.Episodes != 2 || m.Successful != 1 || m.SuccessRate != 0.5 {
+		t.Errorf("Unexpected memory stats: %+v", report.Memory)
+	}
+	if len(report.Guidance) != 1 {
+		t.Errorf("Expected guidance for math only, got %v", report.Guidance)
+	}
+
+	filtered := buildAnalytics(records, episodes, AnalyticsFilter{Tool: "graph_of_thoughts"})
+	if filtered.TotalRuns != 4 || filtered.Memory != nil {
+		t.Errorf("Expected only GoT runs and no memory section, got %+v", filtered)
+	}
+}
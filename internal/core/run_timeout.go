@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Run Timeouts ============
+//
+// timeout_seconds bounds the time of a reasoning run (default:
+// <TOOL>_TIMEOUT_SECONDS, then RUN_TIMEOUT_SECONDS). At the deadline the
+// run's LLMCallCounter refuses further LLM calls and cuts off those in
+// flight with ErrRunTimedOut, which strategies handle like an exhausted
+// max_llm_calls budget: they return what they have so far (GoT's best path,
+// the attempts reflexion completed, ...) marked timed_out: true.
+// runTimeoutMiddleware also wraps the run in context.WithTimeout,
+// runTimeoutGrace past the deadline, to stop work that makes no LLM calls.
+
+var runTimeoutGrace = 10 * time.Second
+
+var runTimeoutArgSpecs = []argSpec{
+	{Name: "timeout_seconds", Kind: argNumber, Min: 0, Max: noArgLimit},
+}
+
+func runTimeoutOption() mcp.ToolOption {
+	return mcp.WithNumber("timeout_seconds",
+		mcp.Description("Time limit for the run; when reached, a partial result with what was found so far is returned with timed_out: true (default: RUN_TIMEOUT_SECONDS or unlimited)"),
+	)
+}
+
+// runTimeoutFromArgs returns the run's timeout_seconds, 0 for none
+func runTimeoutFromArgs(args map[string]interface{}) time.Duration {
+	seconds, ok := normalizeArgValue(args["timeout_seconds"]).(float64)
+	if !ok {
+		seconds, _ = strconv.ParseFloat(strings.TrimSpace(os.Getenv("RUN_TIMEOUT_SECONDS")), 64)
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// runTimeoutMiddleware validates timeout_seconds and bounds the run's
+// context by it
+func runTimeoutMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok || !runHistoryTools[request.Params.Name] {
+			return next(ctx, request)
+		}
+		if _, err := parseArgs(args, runTimeoutArgSpecs); err != nil {
+			return toolErrorResult(err), nil
+		}
+		timeout := runTimeoutFromArgs(args)
+		if timeout <= 0 {
+			return next(ctx, request)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout+runTimeoutGrace)
+		defer cancel()
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowProvider answers like inner after delay, or fails when ctx ends first
+type slowProvider struct {
+	inner Provider
+	delay time.Duration
+}
+
+func (p *slowProvider) Name() string { return "slow" }
+
+func (p *slowProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	select {
+	case <-time.After(p.delay):
+		return p.inner.Chat(ctx, messages, opts)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestLLMCallCounter_Timeout(t *testing.T) {
+	counter := NewLLMCallCounter(0)
+	counter.SetTimeout(50 * time.Millisecond)
+	provider := counter.Wrap(&slowProvider{inner: &countProvider{response: "ok"}, delay: time.Hour})
+
+	start := time.Now()
+	_, err := provider.Chat(context.Background(), nil, ChatOptions{})
+	if !errors.Is(err, ErrRunTimedOut) || !errors.Is(err, ErrLLMCallBudgetExhausted) {
+		t.Fatalf("Expected the call in flight to be cut off with ErrRunTimedOut, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the call to end at the deadline, took %v", elapsed)
+	}
+	if _, err := provider.Chat(context.Background(), nil, ChatOptions{}); !errors.Is(err, ErrRunTimedOut) {
+		t.Errorf("Expected later calls to be refused, got %v", err)
+	}
+	if usage := counter.Usage(); !usage.TimedOut || !usage.BudgetExhausted || usage.TimeoutSeconds != 0.05 || usage.MaxLLMCalls != 0 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	// A caller's own cancellation is not a timeout
+	counter = NewLLMCallCounter(0)
+	counter.SetTimeout(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := counter.Wrap(&slowProvider{inner: &countProvider{}, delay: time.Hour}).Chat(ctx, nil, ChatOptions{}); errors.Is(err, ErrRunTimedOut) || counter.Usage().TimedOut {
+		t.Errorf("Expected a plain cancellation, got %v", err)
+	}
+}
+
+func TestGoT_ReturnsBestPathAtTimeout(t *testing.T) {
+	inner := &countProvider{response: `["Consider the first case", "Consider the second case"]`}
+	counter := llmCallCounterFromArgs(map[string]interface{}{"timeout_seconds": 0.3}, "graph_of_thoughts")
+	config := DefaultGoTConfig()
+	config.MaxNodes = 500
+
+	result, err := NewGraphOfThoughts(counter.Wrap(&slowProvider{inner: inner, delay: 20 * time.Millisecond}), config).Solve(context.Background(), "Explore the cases")
+	if err != nil {
+		t.Fatalf("Expected a partial result, got %v", err)
+	}
+	if last := result.Decisions[len(result.Decisions)-1]; last.Type != "stop" || last.Reason != GoTReasonLLMCallBudget {
+		t.Errorf("Expected the run to stop at the deadline, got %+v", last)
+	}
+	if !counter.Usage().TimedOut || result.TotalNodes < 2 || len(result.BestPath) == 0 {
+		t.Errorf("Expected a partial graph with a best path, got %d nodes and path %v", result.TotalNodes, result.BestPath)
+	}
+}
+
+func TestRunTimeoutFromArgs(t *testing.T) {
+	t.Setenv("RUN_TIMEOUT_SECONDS", "90")
+	if d := runTimeoutFromArgs(map[string]interface{}{}); d != 90*time.Second {
+		t.Errorf("Expected the RUN_TIMEOUT_SECONDS default, got %v", d)
+	}
+	if d := runTimeoutFromArgs(map[string]interface{}{"timeout_seconds": 1.5}); d != 1500*time.Millisecond {
+		t.Errorf("Expected the argument to win, got %v", d)
+	}
+	if c := llmCallCounterFromArgs(map[string]interface{}{"timeout_seconds": 0.0}, "reflexion"); c != nil {
+		t.Errorf("Expected no counter without a limit or timeout, got %+v", c)
+	}
+
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t, runTimeoutMiddleware)
+	if msg := callToolError(t, s, "sequential_thinking", map[string]interface{}{"problem": "p", "timeout_seconds": -1}); !strings.Contains(msg, "timeout_seconds must be at least 0") {
+		t.Errorf("Expected a negative timeout to be rejected, got %q", msg)
+	}
+}
@@ -0,0 +1,281 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Scratchpad ============
+//
+// Each strategy run gets a scratchpad: a key-value store of artifacts such
+// as fetched documents and computed tables. Tool outputs of at least
+// SCRATCHPAD_MIN_CHARS characters (default 400) are saved automatically,
+// and repeating the same tool call later in the run returns the saved
+// artifact instead of running the tool again. Reasoning steps read and
+// write artifacts with the scratchpad tool, and any tool input may name one
+// as artifact:<name>, which is replaced by its content. reasoning_pipeline
+// and auto_reason stages share their run's scratchpad. The artifacts are
+// listed in the result under "artifacts"; SCRATCHPAD=false disables it.
+
+const (
+	defaultScratchpadMinChars = 400
+	maxScratchpadArtifacts    = 50
+	maxArtifactResultChars    = 2000 // Content shown per artifact in the result
+)
+
+var (
+	artifactNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+	// A trailing '.' ends a sentence rather than the name
+	artifactRefPattern = regexp.MustCompile(`artifact:([A-Za-z0-9](?:[A-Za-z0-9_.-]*[A-Za-z0-9_-])?)`)
+)
+
+// Artifact is one scratchpad entry
+type Artifact struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"` // Tool call that produced it, or "scratchpad" when written directly
+	Size      int    `json:"size"`
+	Reads     int    `json:"reads,omitempty"` // Times it was read or reused after being saved
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"` // Content cut to maxArtifactResultChars in the result
+}
+
+// Scratchpad holds one run's artifacts
+type Scratchpad struct {
+	mu        sync.Mutex
+	artifacts map[string]*Artifact
+	order     []string
+	calls     map[string]string // Tool name and input -> artifact name
+	perTool   map[string]int
+	minChars  int
+}
+
+type scratchpadKey struct{}
+
+func newScratchpad(minChars int) *Scratchpad {
+	return &Scratchpad{
+		artifacts: make(map[string]*Artifact),
+		calls:     make(map[string]string),
+		perTool:   make(map[string]int),
+		minChars:  minChars,
+	}
+}
+
+func scratchpadFromContext(ctx context.Context) *Scratchpad {
+	pad, _ := ctx.Value(scratchpadKey{}).(*Scratchpad)
+	return pad
+}
+
+// Put saves or replaces an artifact
+func (p *Scratchpad) Put(name, content, source string) error {
+	if !artifactNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid artifact name %q: use up to 64 letters, digits, '.', '_' or '-'", name)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.put(name, content, source)
+	return nil
+}
+
+func (p *Scratchpad) put(name, content, source string) {
+	if a, ok := p.artifacts[name]; ok {
+		a.Content, a.Source, a.Size = content, source, len(content)
+		return
+	}
+	if len(p.order) >= maxScratchpadArtifacts {
+		// Make room by dropping the oldest artifact
+		oldest := p.order[0]
+		p.order = p.order[1:]
+		delete(p.artifacts, oldest)
+		for call, name := range p.calls {
+			if name == oldest {
+				delete(p.calls, call)
+			}
+		}
+	}
+	p.artifacts[name] = &Artifact{Name: name, Source: source, Size: len(content), Content: content}
+	p.order = append(p.order, name)
+}
+
+// Get returns an artifact's content
+func (p *Scratchpad) Get(name string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	a, ok := p.artifacts[name]
+	if !ok {
+		return "", false
+	}
+	a.Reads++
+	return a.Content, true
+}
+
+// names lists the artifacts in the order they were saved
+func (p *Scratchpad) names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.order...)
+}
+
+// expand replaces artifact:<name> references in a tool input
+func (p *Scratchpad) expand(input string) (string, error) {
+	var missing []string
+	expanded := artifactRefPattern.ReplaceAllStringFunc(input, func(ref string) string {
+		name := strings.TrimPrefix(ref, "artifact:")
+		content, ok := p.Get(name)
+		if !ok {
+			missing = append(missing, name)
+			return ref
+		}
+		return content
+	})
+	if len(missing) > 0 {
+		return input, fmt.Errorf("unknown artifact %q (saved: %s)", missing[0], withDefault(strings.Join(p.names(), ", "), "none"))
+	}
+	return expanded, nil
+}
+
+func scratchpadCallKey(tool, input string) string {
+	return tool + "\x00" + strings.TrimSpace(input)
+}
+
+// reuse returns the artifact saved from an identical earlier tool call
+func (p *Scratchpad) reuse(tool, input string) (output, name string, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name, ok = p.calls[scratchpadCallKey(tool, input)]
+	if !ok {
+		return "", "", false
+	}
+	a := p.artifacts[name]
+	a.Reads++
+	return a.Content, name, true
+}
+
+// saveToolOutput saves a long tool output as <tool>-<n> and returns the
+// artifact name, or "" when the output is too short to keep
+func (p *Scratchpad) saveToolOutput(tool, input, output string) string {
+	if len(output) < p.minChars {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.perTool[tool]++
+	name := fmt.Sprintf("%s-%d", tool, p.perTool[tool])
+	p.put(name, output, fmt.Sprintf("%s(%s)", tool, utils.TruncateStr(strings.TrimSpace(input), 80)))
+	p.calls[scratchpadCallKey(tool, input)] = name
+	return name
+}
+
+// list returns the artifacts for a result, content cut to
+// maxArtifactResultChars
+func (p *Scratchpad) list() []Artifact {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Artifact, 0, len(p.order))
+	for _, name := range p.order {
+		a := *p.artifacts[name]
+		if len(a.Content) > maxArtifactResultChars {
+			a.Content = utils.TruncateStr(a.Content, maxArtifactResultChars)
+			a.Truncated = true
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// attach returns registry with the scratchpad tool registered and enabled.
+// Runs without tools have nothing to store, so a nil registry stays nil.
+func (p *Scratchpad) attach(registry *ToolRegistry) *ToolRegistry {
+	if registry == nil {
+		return nil
+	}
+	registry.Register(&ScratchpadTool{pad: p})
+	registry.Enable("scratchpad")
+	return registry
+}
+
+// ScratchpadTool reads and writes the run's artifacts
+type ScratchpadTool struct {
+	pad *Scratchpad
+}
+
+func (t *ScratchpadTool) Name() string { return "scratchpad" }
+
+func (t *ScratchpadTool) Description() string {
+	desc := "Keep intermediate results for the rest of this run instead of re-deriving them. Input 'write <name>: <content>', 'read <name>' or 'list'. Any tool input can include artifact:<name> to insert an artifact's content."
+	if names := t.pad.names(); len(names) > 0 {
+		desc += " Saved: " + strings.Join(names, ", ")
+	}
+	return desc
+}
+
+func (t *ScratchpadTool) Execute(ctx context.Context, input string) (string, error) {
+	input = strings.TrimSpace(input)
+	op, rest, _ := strings.Cut(input, " ")
+	rest = strings.TrimSpace(rest)
+	switch strings.ToLower(op) {
+	case "write", "save":
+		name, content, ok := strings.Cut(rest, ":")
+		name, content = strings.TrimSpace(name), strings.TrimSpace(content)
+		if !ok || content == "" {
+			return "", fmt.Errorf("use 'write <name>: <content>'")
+		}
+		if err := t.pad.Put(name, content, "scratchpad"); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Saved artifact:%s (%d characters)", name, len(content)), nil
+	case "read", "get":
+		name := strings.TrimPrefix(rest, "artifact:")
+		content, ok := t.pad.Get(name)
+		if !ok {
+			return "", fmt.Errorf("unknown artifact %q (saved: %s)", name, withDefault(strings.Join(t.pad.names(), ", "), "none"))
+		}
+		return content, nil
+	case "list", "":
+		artifacts := t.pad.list()
+		if len(artifacts) == 0 {
+			return "The scratchpad is empty.", nil
+		}
+		var sb strings.Builder
+		for _, a := range artifacts {
+			fmt.Fprintf(&sb, "artifact:%s - %s, %d characters\n", a.Name, a.Source, a.Size)
+		}
+		return strings.TrimSpace(sb.String()), nil
+	}
+	return "", fmt.Errorf("unknown scratchpad operation %q (use write, read or list)", op)
+}
+
+// scratchpadMiddleware gives each strategy run a scratchpad and lists its
+// artifacts in the result. Nested strategy calls share the outer run's.
+func scratchpadMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !runHistoryTools[request.Params.Name] || scratchpadFromContext(ctx) != nil {
+			return next(ctx, request)
+		}
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("SCRATCHPAD"))) {
+		case "false", "0", "off":
+			return next(ctx, request)
+		}
+		pad := newScratchpad(parseEnvInt("SCRATCHPAD_MIN_CHARS", defaultScratchpadMinChars))
+		result, err := next(context.WithValue(ctx, scratchpadKey{}, pad), request)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+		artifacts := pad.list()
+		if len(artifacts) == 0 {
+			return result, err
+		}
+		if text, ok := appendResultField(resultText(result), "artifacts", artifacts); ok {
+			return mcp.NewToolResultText(text), nil
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// echoTool repeats its input at length and counts its calls
+type echoTool struct{ calls int }
+
+func (t *echoTool) Name() string        { return "echo" }
+func (t *echoTool) Description() string { return "Repeat the input" }
+func (t *echoTool) Execute(ctx context.Context, input string) (string, error) {
+	t.calls++
+	return strings.Repeat(input+" ", 10), nil
+}
+
+func TestScratchpad_SavesReusesAndExpands(t *testing.T) {
+	pad := newScratchpad(20)
+	ctx := context.WithValue(context.Background(), scratchpadKey{}, pad)
+	echo := &echoTool{}
+	registry := pad.attach(NewToolRegistry())
+	registry.Register(echo)
+	registry.Enable("echo")
+
+	first := registry.Execute(ctx, "echo", "table")
+	if first.Artifact != "echo-1" || first.Reused {
+		t.Fatalf("Expected the output saved as echo-1, got %+v", first)
+	}
+	if again := registry.Execute(ctx, "echo", "table"); !again.Reused || again.Output != first.Output || echo.calls != 1 {
+		t.Errorf("Expected the repeated call answered from the artifact, got %+v after %d calls", again, echo.calls)
+	}
+	if short := registry.Execute(ctx, "calculator", "2 + 2"); short.Artifact != "" {
+		t.Errorf("Expected short outputs not saved, got %q", short.Artifact)
+	}
+
+	if res := registry.Execute(ctx, "scratchpad", "write totals: 4, 8, 15"); !res.Success {
+		t.Fatalf("write failed: %s", res.Error)
+	}
+	if res := registry.Execute(ctx, "string_ops", "upper:artifact:totals."); !res.Success || res.Output != "4, 8, 15." {
+		t.Errorf("Expected the reference replaced by the artifact, got %+v", res)
+	}
+	if res := registry.Execute(ctx, "string_ops", "upper:artifact:missing"); res.Success || !strings.Contains(res.Error, "echo-1, totals") {
+		t.Errorf("Expected an unknown-artifact error naming the saved ones, got %+v", res)
+	}
+	if res := registry.Execute(ctx, "scratchpad", "read totals"); res.Output != "4, 8, 15" {
+		t.Errorf("Unexpected read: %+v", res)
+	}
+
+	artifacts := pad.list()
+	if len(artifacts) != 2 || artifacts[0].Source != "echo(table)" || artifacts[1].Source != "scratchpad" {
+		t.Errorf("Unexpected artifacts: %+v", artifacts)
+	}
+}
+
+func TestScratchpadMiddleware_ListsArtifacts(t *testing.T) {
+	t.Setenv("SCRATCHPAD_MIN_CHARS", "1")
+	useFakeProvider(t, "openai", newFakeOpenAI(t, solveResponder))
+	s := integrationServer(t, scratchpadMiddleware)
+
+	result := callTool(t, s, "plan_execute", map[string]interface{}{"problem": "What is 17 * 23?", "enable_tools": true})
+	artifacts, _ := result["artifacts"].([]interface{})
+	if len(artifacts) != 1 {
+		t.Fatalf("Expected the calculator output as an artifact, got %v", result["artifacts"])
+	}
+	if a := artifacts[0].(map[string]interface{}); a["name"] != "calculator-1" || a["content"] != "391" {
+		t.Errorf("Unexpected artifact: %v", a)
+	}
+
+	t.Setenv("SCRATCHPAD", "false")
+	if result := callTool(t, s, "plan_execute", map[string]interface{}{"problem": "What is 17 * 23?", "enable_tools": true}); result["artifacts"] != nil {
+		t.Errorf("Expected no artifacts with SCRATCHPAD=false, got %v", result["artifacts"])
+	}
+}
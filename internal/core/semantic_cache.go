@@ -0,0 +1,270 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSemanticCacheThreshold  = 0.92
+	defaultSemanticCacheMaxEntries = 256
+	defaultSemanticCacheTTL        = 3600
+)
+
+type semanticCacheEntry struct {
+	scope     string // Tool, provider and every argument except the problem
+	problem   string
+	embedding []float64
+	value     string
+	createdAt time.Time
+}
+
+// SemanticCacheMatch describes the cached problem a result was served for
+type SemanticCacheMatch struct {
+	Similarity      float64   `json:"similarity"`
+	OriginalProblem string    `json:"original_problem"`
+	CachedAt        time.Time `json:"cached_at"`
+}
+
+// SemanticCache returns results cached for earlier problems whose embedding
+// is close to the new problem's. Unlike ToolCache it matches rephrasings, so
+// hits report the similarity and original problem for the client to judge.
+type SemanticCache struct {
+	mu         sync.Mutex
+	entries    []semanticCacheEntry
+	threshold  float64
+	ttl        time.Duration
+	maxEntries int
+	embedder   EmbeddingProvider // Fixed embedder; nil uses the request's provider
+	model      string
+	pending    map[string][]float64 // Embeddings computed on a miss, reused by Store
+}
+
+// NewSemanticCache creates a semantic cache. When embedder is nil the
+// request's provider is used if it supports embeddings.
+func NewSemanticCache(threshold float64, ttl time.Duration, maxEntries int, embedder EmbeddingProvider, model string) *SemanticCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultSemanticCacheMaxEntries
+	}
+	return &SemanticCache{
+		threshold:  threshold,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		embedder:   embedder,
+		model:      model,
+		pending:    make(map[string][]float64),
+	}
+}
+
+// semanticCacheScope keys everything but the problem text, so a cached result
+// is only reused for requests with the same tool, provider and parameters
+func semanticCacheScope(tool, providerName string, args map[string]interface{}) string {
+	scoped := sanitizeToolCacheArgs(args)
+	delete(scoped, "problem")
+	return fmt.Sprintf("%s|%s|%s", tool, providerName, canonicalJSON(scoped))
+}
+
+func (c *SemanticCache) embedderFor(provider Provider) (EmbeddingProvider, bool) {
+	if c.embedder != nil {
+		return c.embedder, true
+	}
+	embedder, ok := provider.(EmbeddingProvider)
+	return embedder, ok
+}
+
+func (c *SemanticCache) embed(ctx context.Context, provider Provider, scope, problem string) ([]float64, bool) {
+	pendingKey := fmt.Sprintf("%x", sha256.Sum256([]byte(scope+"|"+problem)))
+
+	c.mu.Lock()
+	if v, ok := c.pending[pendingKey]; ok {
+		c.mu.Unlock()
+		return v, true
+	}
+	c.mu.Unlock()
+
+	embedder, ok := c.embedderFor(provider)
+	if !ok {
+		return nil, false
+	}
+	vectors, err := embedder.Embed(ctx, []string{problem}, c.model)
+	if err != nil || len(vectors) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: semantic cache embedding failed: %v\n", err)
+		}
+		return nil, false
+	}
+
+	c.mu.Lock()
+	if len(c.pending) >= c.maxEntries {
+		c.pending = make(map[string][]float64)
+	}
+	c.pending[pendingKey] = vectors[0]
+	c.mu.Unlock()
+	return vectors[0], true
+}
+
+// Lookup returns the cached output for the most similar earlier problem at or
+// above the threshold, annotated with the match
+func (c *SemanticCache) Lookup(ctx context.Context, tool string, provider Provider, args map[string]interface{}) (string, bool) {
+	problem, _ := args["problem"].(string)
+	if problem == "" {
+		return "", false
+	}
+	scope := semanticCacheScope(tool, provider.Name(), args)
+	embedding, ok := c.embed(ctx, provider, scope, problem)
+	if !ok {
+		return "", false
+	}
+
+	c.mu.Lock()
+	var best *semanticCacheEntry
+	bestScore := 0.0
+	now := time.Now()
+	kept := c.entries[:0]
+	for i := range c.entries {
+		e := c.entries[i]
+		if now.Sub(e.createdAt) > c.ttl {
+			continue
+		}
+		kept = append(kept, e)
+		if e.scope != scope {
+			continue
+		}
+		if score := cosineSimilarity(embedding, e.embedding); score >= c.threshold && score > bestScore {
+			bestScore = score
+			entry := e
+			best = &entry
+		}
+	}
+	c.entries = kept
+	c.mu.Unlock()
+
+	if best == nil {
+		return "", false
+	}
+
+	output, err := annotateSemanticHit(best.value, SemanticCacheMatch{
+		Similarity:      bestScore,
+		OriginalProblem: best.problem,
+		CachedAt:        best.createdAt,
+	})
+	if err != nil {
+		return "", false
+	}
+	return output, true
+}
+
+// Similar returns the cached output of tool whose problem shares the most
+// words with problem, at or above minSimilarity. Unlike Lookup it needs no
+// embedding, and it ignores the other arguments.
+func (c *SemanticCache) Similar(tool, problem string, minSimilarity float64) (string, SemanticCacheMatch, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var best *semanticCacheEntry
+	bestScore := 0.0
+	now := time.Now()
+	for i := range c.entries {
+		e := &c.entries[i]
+		if now.Sub(e.createdAt) > c.ttl || !strings.HasPrefix(e.scope, tool+"|") {
+			continue
+		}
+		if score := stringSimilarity(e.problem, problem); score >= minSimilarity && score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	if best == nil {
+		return "", SemanticCacheMatch{}, false
+	}
+	return best.value, SemanticCacheMatch{Similarity: bestScore, OriginalProblem: best.problem, CachedAt: best.createdAt}, true
+}
+
+// Store records the output for the request's problem
+func (c *SemanticCache) Store(ctx context.Context, tool string, provider Provider, args map[string]interface{}, output string) {
+	problem, _ := args["problem"].(string)
+	if problem == "" {
+		return
+	}
+	scope := semanticCacheScope(tool, provider.Name(), args)
+	embedding, ok := c.embed(ctx, provider, scope, problem)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, fmt.Sprintf("%x", sha256.Sum256([]byte(scope+"|"+problem))))
+	c.entries = append(c.entries, semanticCacheEntry{
+		scope:     scope,
+		problem:   problem,
+		embedding: embedding,
+		value:     output,
+		createdAt: time.Now(),
+	})
+	if len(c.entries) > c.maxEntries {
+		c.entries = c.entries[len(c.entries)-c.maxEntries:]
+	}
+}
+
+// annotateSemanticHit wraps a cached JSON result with the match details
+func annotateSemanticHit(value string, match SemanticCacheMatch) (string, error) {
+	wrapped := struct {
+		SemanticCache SemanticCacheMatch `json:"semantic_cache"`
+		Result        json.RawMessage    `json:"result"`
+	}{SemanticCache: match, Result: json.RawMessage(value)}
+
+	data, err := json.MarshalIndent(wrapped, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+var (
+	semanticCache     *SemanticCache
+	semanticCacheOnce sync.Once
+)
+
+// getSemanticCache returns the semantic cache when SEMANTIC_CACHE is enabled
+func getSemanticCache() *SemanticCache {
+	semanticCacheOnce.Do(func() {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("SEMANTIC_CACHE"))) {
+		case "true", "1", "on":
+		default:
+			return
+		}
+
+		threshold := defaultSemanticCacheThreshold
+		if v := os.Getenv("SEMANTIC_CACHE_THRESHOLD"); v != "" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 1 {
+				threshold = f
+			}
+		}
+		ttl := time.Duration(parseEnvInt("SEMANTIC_CACHE_TTL", defaultSemanticCacheTTL)) * time.Second
+		model := withDefault(os.Getenv("SEMANTIC_CACHE_EMBEDDING_MODEL"), "text-embedding-3-small")
+
+		var embedder EmbeddingProvider
+		if providerType := os.Getenv("SEMANTIC_CACHE_EMBEDDING_PROVIDER"); providerType != "" {
+			p, err := NewProvider(ProviderConfig{Type: providerType, APIKey: getAPIKeyForProvider(providerType)})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: semantic cache disabled: %v\n", err)
+				return
+			}
+			var ok bool
+			if embedder, ok = p.(EmbeddingProvider); !ok {
+				fmt.Fprintf(os.Stderr, "Warning: semantic cache disabled: provider %s does not support embeddings\n", providerType)
+				return
+			}
+		}
+
+		semanticCache = NewSemanticCache(threshold, ttl, parseEnvInt("SEMANTIC_CACHE_MAX", defaultSemanticCacheMaxEntries), embedder, model)
+	})
+	return semanticCache
+}
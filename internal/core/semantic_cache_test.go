@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// vectorProvider embeds texts with fixed vectors for deterministic similarity
+type vectorProvider struct {
+	vectors map[string][]float64
+	calls   int
+}
+
+func (p *vectorProvider) Name() string { return "vectors" }
+
+func (p *vectorProvider) Chat(context.Context, []ChatMessage, ChatOptions) (string, error) {
+	return "", nil
+}
+
+func (p *vectorProvider) Embed(_ context.Context, texts []string, _ string) ([][]float64, error) {
+	p.calls++
+	var out [][]float64
+	for _, text := range texts {
+		out = append(out, p.vectors[text])
+	}
+	return out, nil
+}
+
+func TestSemanticCache_MatchesRephrasing(t *testing.T) {
+	provider := &vectorProvider{vectors: map[string][]float64{
+		"How many primes are below 100?":        {1, 0, 0},
+		"How many prime numbers are under 100?": {0.99, 0.1, 0},
+		"What is the capital of France?":        {0, 0, 1},
+	}}
+	cache := NewSemanticCache(0.95, time.Hour, 10, nil, "test")
+	ctx := context.Background()
+
+	original := map[string]interface{}{"problem": "How many primes are below 100?", "max_rounds": float64(3)}
+	if _, ok := cache.Lookup(ctx, "dialectic_reason", provider, original); ok {
+		t.Fatal("Expected miss on empty cache")
+	}
+	cache.Store(ctx, "dialectic_reason", provider, original, `{"final_answer":"25"}`)
+	if provider.calls != 1 {
+		t.Errorf("Expected Store to reuse the lookup embedding, got %d embed calls", provider.calls)
+	}
+
+	rephrased := map[string]interface{}{"problem": "How many prime numbers are under 100?", "max_rounds": float64(3)}
+	out, ok := cache.Lookup(ctx, "dialectic_reason", provider, rephrased)
+	if !ok {
+		t.Fatal("Expected semantic hit for rephrased problem")
+	}
+	var hit struct {
+		SemanticCache SemanticCacheMatch `json:"semantic_cache"`
+		Result        struct {
+			FinalAnswer string `json:"final_answer"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(out), &hit); err != nil {
+		t.Fatalf("Invalid hit output: %v", err)
+	}
+	if hit.Result.FinalAnswer != "25" || hit.SemanticCache.OriginalProblem != "How many primes are below 100?" || hit.SemanticCache.Similarity < 0.95 {
+		t.Errorf("Unexpected hit: %+v", hit)
+	}
+
+	unrelated := map[string]interface{}{"problem": "What is the capital of France?", "max_rounds": float64(3)}
+	if _, ok := cache.Lookup(ctx, "dialectic_reason", provider, unrelated); ok {
+		t.Error("Expected miss for unrelated problem")
+	}
+
+	otherParams := map[string]interface{}{"problem": "How many prime numbers are under 100?", "max_rounds": float64(5)}
+	if _, ok := cache.Lookup(ctx, "dialectic_reason", provider, otherParams); ok {
+		t.Error("Expected miss when other parameters differ")
+	}
+	if _, ok := cache.Lookup(ctx, "reflexion", provider, rephrased); ok {
+		t.Error("Expected miss for a different tool")
+	}
+}
+
+func TestSemanticCache_SkipsProvidersWithoutEmbeddings(t *testing.T) {
+	cache := NewSemanticCache(0.9, time.Hour, 10, nil, "test")
+	args := map[string]interface{}{"problem": "anything"}
+	cache.Store(context.Background(), "reflexion", &OllamaProvider{}, args, `{}`)
+	if _, ok := cache.Lookup(context.Background(), "reflexion", &OllamaProvider{}, args); ok {
+		t.Error("Expected no semantic caching without an embedding provider")
+	}
+}
@@ -1,8 +1,9 @@
-package main
+package core
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -13,31 +14,14 @@ import (
 
 // SequentialClient performs simple linear sequential thinking
 type SequentialClient struct {
-	provider      Provider
-	onProgress    func(ProgressUpdate)
-	onToken       func(token string)
-	enableStreams bool
-}
-
-// SetProgressCallback sets a callback for progress updates
-func (c *SequentialClient) SetProgressCallback(cb func(ProgressUpdate)) {
-	c.onProgress = cb
-}
+	ReasonerBase
 
-// SetTokenCallback sets a callback for token streaming
-func (c *SequentialClient) SetTokenCallback(cb func(token string)) {
-	c.onToken = cb
+	provider Provider
 }
 
-// SetEnableStreaming enables or disables LLM streaming
-func (c *SequentialClient) SetEnableStreaming(enable bool) {
-	c.enableStreams = enable
-}
-
-func (c *SequentialClient) emitProgress(update ProgressUpdate) {
-	if c.onProgress != nil {
-		c.onProgress(update)
-	}
+// NewSequentialClient creates a sequential thinking client
+func NewSequentialClient(provider Provider) *SequentialClient {
+	return &SequentialClient{provider: provider}
 }
 
 // ThinkingStep represents a single step in the thinking process
@@ -60,6 +44,9 @@ type ThinkingResult struct {
 	TotalSteps  int            `json:"total_steps"`
 	Success     bool           `json:"success"`
 	Provider    string         `json:"provider"`
+	SessionID   string         `json:"session_id,omitempty"`
+	NewSteps    int            `json:"new_steps,omitempty"` // Steps added by this call to a session's chain
+	LLMCallUsage
 }
 
 // LLMThinkingResponse is what we expect from the LLM in JSON format
@@ -97,31 +84,79 @@ Guidelines:
 5. Each thought should build meaningfully toward the solution
 6. You can adjust total_thoughts up or down as needed`
 
+// SequentialChain is the conversation and steps of a thinking chain. A
+// session keeps it between sequential_thinking calls so more thoughts can
+// be added later.
+type SequentialChain struct {
+	Problem     string
+	Messages    []ChatMessage
+	Steps       []ThinkingStep
+	FinalAnswer string
+	Done        bool // The model gave a final answer
+}
+
+func NewSequentialChain(problem string) *SequentialChain {
+	return &SequentialChain{
+		Problem: problem,
+		Steps:   []ThinkingStep{},
+		Messages: []ChatMessage{
+			{Role: "system", Content: sequentialSystemPrompt},
+			{Role: "user", Content: fmt.Sprintf("Problem to solve:\n\n%s\n\nBegin your sequential thinking process.", problem)},
+		},
+	}
+}
+
+// nextPrompt asks for the next thought, passing on new information from the
+// caller when there is some
+func nextPrompt(observation string) string {
+	if observation == "" {
+		return "Continue to the next thought."
+	}
+	return fmt.Sprintf("New information since your last thought:\n\n%s\n\nTake it into account and continue to the next thought.", observation)
+}
+
 // Think performs sequential thinking on a problem
 func (c *SequentialClient) Think(ctx context.Context, problem string, maxThoughts int) (*ThinkingResult, error) {
+	return c.Extend(ctx, NewSequentialChain(problem), "", maxThoughts)
+}
+
+// Extend adds up to maxThoughts thoughts to a chain, first passing on
+// observation (e.g. the results of tools the caller ran) when it is set.
+// The chain is updated in place, so it can be extended again later.
+func (c *SequentialClient) Extend(ctx context.Context, chain *SequentialChain, observation string, maxThoughts int) (*ThinkingResult, error) {
 	result := &ThinkingResult{
-		Problem:  problem,
-		Steps:    []ThinkingStep{},
+		Problem:  chain.Problem,
 		Success:  false,
 		Provider: c.provider.Name(),
 	}
-
-	messages := []ChatMessage{
-		{Role: "system", Content: sequentialSystemPrompt},
-		{Role: "user", Content: fmt.Sprintf("Problem to solve:\n\n%s\n\nBegin your sequential thinking process.", problem)},
+	finish := func(finalAnswer string) *ThinkingResult {
+		result.Steps = chain.Steps
+		result.TotalSteps = len(chain.Steps)
+		result.FinalAnswer = finalAnswer
+		return result
 	}
 
 	// Check if provider supports streaming
 	streamingProvider, canStream := c.provider.(StreamingProvider)
 	useStreaming := canStream && c.enableStreams && streamingProvider.SupportsStreaming()
 
+	observation = strings.TrimSpace(observation)
+	limit := len(chain.Steps) + maxThoughts
 	for i := 0; i < maxThoughts; i++ {
+		n := len(chain.Steps) + 1
+
+		// Prompt for the next thought after the last one
+		messages := chain.Messages
+		if last := messages[len(messages)-1]; last.Role == "assistant" {
+			messages = append(messages, ChatMessage{Role: "user", Content: nextPrompt(observation)})
+		}
+
 		// Emit progress: generating thought
 		c.emitProgress(ProgressUpdate{
 			Type:    EventTypeProgress,
-			NodeID:  fmt.Sprintf("t%d", i+1),
-			Message: fmt.Sprintf("Generating thought %d...", i+1),
-			Depth:   i + 1,
+			NodeID:  fmt.Sprintf("t%d", n),
+			Message: fmt.Sprintf("Generating thought %d...", n),
+			Depth:   n,
 		})
 
 		var response string
@@ -129,13 +164,24 @@ func (c *SequentialClient) Think(ctx context.Context, problem string, maxThought
 
 		// Call LLM with or without streaming
 		if useStreaming {
+			// Bracket each thought's tokens so clients can render it as it forms
+			c.emitProgress(ProgressUpdate{
+				Type:    EventTypeStepStart,
+				NodeID:  fmt.Sprintf("t%d", n),
+				Message: fmt.Sprintf("Step %d started", n),
+				Step:    n,
+				Depth:   n,
+			})
 			response, err = streamingProvider.ChatStream(ctx, messages, ChatOptions{
 				Temperature: 0.7,
 				MaxTokens:   2048,
-			}, func(token string) {
-				if c.onToken != nil {
-					c.onToken(token)
-				}
+			}, c.emitToken)
+			c.emitProgress(ProgressUpdate{
+				Type:    EventTypeStepEnd,
+				NodeID:  fmt.Sprintf("t%d", n),
+				Message: fmt.Sprintf("Step %d finished", n),
+				Step:    n,
+				Depth:   n,
 			})
 		} else {
 			response, err = c.provider.Chat(ctx, messages, ChatOptions{
@@ -144,21 +190,24 @@ func (c *SequentialClient) Think(ctx context.Context, problem string, maxThought
 			})
 		}
 
+		if errors.Is(err, ErrLLMCallBudgetExhausted) {
+			return finish("LLM call budget exhausted before a definitive answer. Review the steps above."), nil
+		}
 		if err != nil {
-			return result, fmt.Errorf("LLM call failed at step %d: %w", i+1, err)
+			return finish(""), fmt.Errorf("LLM call failed at step %d: %w", n, err)
 		}
 
 		// Parse the response as JSON (with fallback to structured text)
-		thinkingResp, usedFallback, err := parseThinkingResponse(response, i+1, maxThoughts)
+		thinkingResp, usedFallback, err := parseThinkingResponse(response, n, limit)
 		if err != nil {
-			return result, fmt.Errorf("failed to parse thinking response at step %d: %w", i+1, err)
+			return finish(""), fmt.Errorf("failed to parse thinking response at step %d: %w", n, err)
 		}
 		if usedFallback {
 			fmt.Fprintf(os.Stderr, "[WARNING] sequential_thinking: falling back to text parsing at step %d. Response preview: %s\n",
-				i+1, utils.TruncateStr(response, 120))
+				n, utils.TruncateStr(response, 120))
 		}
 
-		// Record the step
+		// Record the step and the exchange that produced it
 		step := ThinkingStep{
 			ThoughtNumber:     thinkingResp.ThoughtNumber,
 			TotalThoughts:     thinkingResp.TotalThoughts,
@@ -169,24 +218,23 @@ func (c *SequentialClient) Think(ctx context.Context, problem string, maxThought
 			BranchID:          thinkingResp.BranchID,
 			NeedsMoreThoughts: thinkingResp.NeedsMoreThoughts,
 		}
-		result.Steps = append(result.Steps, step)
+		chain.Steps = append(chain.Steps, step)
+		chain.Messages = append(messages, ChatMessage{Role: "assistant", Content: response})
+		observation = ""
 
 		// Emit progress: thought generated
 		c.emitProgress(ProgressUpdate{
 			Type:    EventTypeThought,
-			NodeID:  fmt.Sprintf("t%d", i+1),
+			NodeID:  fmt.Sprintf("t%d", n),
 			Thought: utils.TruncateStr(thinkingResp.Thought, 100),
-			Depth:   i + 1,
+			Depth:   n,
 		})
 
-		// Add assistant response to conversation
-		messages = append(messages, ChatMessage{Role: "assistant", Content: response})
-
 		// Check if thinking is complete
 		if !thinkingResp.NextThoughtNeeded {
-			result.FinalAnswer = thinkingResp.FinalAnswer
+			chain.Done = true
+			chain.FinalAnswer = thinkingResp.FinalAnswer
 			result.Success = true
-			result.TotalSteps = len(result.Steps)
 
 			// Emit solution progress
 			c.emitProgress(ProgressUpdate{
@@ -195,20 +243,13 @@ func (c *SequentialClient) Think(ctx context.Context, problem string, maxThought
 				IsSolution:  true,
 			})
 
-			return result, nil
+			return finish(thinkingResp.FinalAnswer), nil
 		}
-
-		// Prompt for next thought
-		messages = append(messages, ChatMessage{
-			Role:    "user",
-			Content: "Continue to the next thought.",
-		})
+		chain.Done = false
 	}
 
 	// Reached max thoughts without completion
-	result.TotalSteps = len(result.Steps)
-	result.FinalAnswer = "Maximum thinking steps reached without a definitive answer. Review the steps above."
-	return result, nil
+	return finish("Maximum thinking steps reached without a definitive answer. Review the steps above."), nil
 }
 
 func parseThinkingResponse(response string, stepNum, maxThoughts int) (LLMThinkingResponse, bool, error) {
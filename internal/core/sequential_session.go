@@ -0,0 +1,110 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ Sequential Thinking Sessions ============
+//
+// A sequential_thinking call with session_id keeps its chain server-side.
+// Calling again with the same session_id and continue: true appends more
+// thoughts to it, so the agent driving the client can think a few steps,
+// run its own tools, and feed the results back as an observation before
+// thinking on. Sessions expire SEQUENTIAL_SESSION_TTL seconds after their
+// last use.
+
+const (
+	defaultSequentialSessionTTL = time.Hour
+	maxSessionIDLen             = 128
+)
+
+// sequentialSession is a thinking chain kept between calls
+type sequentialSession struct {
+	args     map[string]interface{} // Arguments of the call that started it
+	chain    *SequentialChain
+	lastUsed time.Time
+	busy     bool // A call is extending the chain
+}
+
+// sequentialSessionStore keeps sessions in process
+type sequentialSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*sequentialSession
+}
+
+var sequentialSessions = &sequentialSessionStore{sessions: make(map[string]*sequentialSession)}
+
+func sequentialSessionTTL() time.Duration {
+	return time.Duration(parseEnvInt("SEQUENTIAL_SESSION_TTL", int(defaultSequentialSessionTTL/time.Second))) * time.Second
+}
+
+// validateSessionID checks a caller-chosen session ID
+func validateSessionID(id string) error {
+	if len(id) > maxSessionIDLen {
+		return fmt.Errorf("session_id must be at most %d characters", maxSessionIDLen)
+	}
+	if strings.TrimSpace(id) != id || id == "" {
+		return fmt.Errorf("session_id must be non-empty without leading or trailing spaces")
+	}
+	return nil
+}
+
+// expire drops sessions unused for longer than the TTL. Callers hold s.mu.
+func (s *sequentialSessionStore) expire(now time.Time) {
+	for id, session := range s.sessions {
+		if !session.busy && now.Sub(session.lastUsed) > sequentialSessionTTL() {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// start creates a session, replacing any idle session with the same ID, and
+// checks it out to the caller
+func (s *sequentialSessionStore) start(id string, args map[string]interface{}, chain *SequentialChain) (*sequentialSession, error) {
+	saved := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		switch k {
+		case "session_id", "continue", "observation", "max_thoughts", "idempotency_key":
+			continue
+		}
+		saved[k] = v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.expire(now)
+	if existing := s.sessions[id]; existing != nil && existing.busy {
+		return nil, fmt.Errorf("session %q is busy with another call", id)
+	}
+	session := &sequentialSession{args: saved, chain: chain, lastUsed: now, busy: true}
+	s.sessions[id] = session
+	return session, nil
+}
+
+// checkout returns the session for id for exclusive use until release
+func (s *sequentialSessionStore) checkout(id string) (*sequentialSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(time.Now())
+	session := s.sessions[id]
+	switch {
+	case session == nil:
+		return nil, fmt.Errorf("unknown or expired session_id %q", id)
+	case session.busy:
+		return nil, fmt.Errorf("session %q is busy with another call", id)
+	}
+	session.busy = true
+	return session, nil
+}
+
+// release returns a checked-out session to the store
+func (s *sequentialSessionStore) release(session *sequentialSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session.busy = false
+	session.lastUsed = time.Now()
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSequentialSessions_ContinueWithObservation(t *testing.T) {
+	var lastHistory []ChatMessage
+	fake := newFakeOpenAI(t, func(req fakeChatRequest) string {
+		lastHistory = req.Messages
+		n := 1
+		for _, m := range req.Messages {
+			if m.Role == "assistant" {
+				n++
+			}
+		}
+		if strings.Contains(req.user(), "calculator: 391") {
+			return fmt.Sprintf(`{"thought_number": %d, "total_thoughts": %d, "thought": "The calculator confirms it", "next_thought_needed": false, "final_answer": "391"}`, n, n)
+		}
+		return fmt.Sprintf(`{"thought_number": %d, "total_thoughts": 5, "thought": "Thought %d", "next_thought_needed": true}`, n, n)
+	})
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t)
+
+	result := callTool(t, s, "sequential_thinking", map[string]interface{}{"problem": "What is 17 * 23?", "session_id": "chain-1", "max_thoughts": 2})
+	if result["session_id"] != "chain-1" || result["new_steps"] != float64(2) || result["success"] != false {
+		t.Fatalf("Expected 2 steps kept in session chain-1, got %v", result)
+	}
+
+	result = callTool(t, s, "sequential_thinking", map[string]interface{}{"session_id": "chain-1", "continue": true, "max_thoughts": 1})
+	if steps, _ := result["steps"].([]interface{}); len(steps) != 3 || result["new_steps"] != float64(1) {
+		t.Fatalf("Expected the chain to grow to 3 steps, got %v", result)
+	}
+
+	result = callTool(t, s, "sequential_thinking", map[string]interface{}{
+		"session_id": "chain-1", "continue": true, "observation": "calculator: 391", "max_thoughts": 3,
+	})
+	steps, _ := result["steps"].([]interface{})
+	if len(steps) != 4 || result["final_answer"] != "391" || result["success"] != true || result["new_steps"] != float64(1) {
+		t.Fatalf("Expected the observation to lead to the answer at step 4, got %v", result)
+	}
+	if len(lastHistory) != 8 || lastHistory[1].Content != NewSequentialChain("What is 17 * 23?").Messages[1].Content {
+		t.Errorf("Expected the whole chain to be sent back to the model, got %d messages", len(lastHistory))
+	}
+
+	if text := callToolError(t, s, "sequential_thinking", map[string]interface{}{"session_id": "nope", "continue": true}); !strings.Contains(text, "unknown or expired session_id") {
+		t.Errorf("Expected an unknown session error, got %q", text)
+	}
+	if text := callToolError(t, s, "sequential_thinking", map[string]interface{}{"continue": true}); !strings.Contains(text, "continue requires session_id") {
+		t.Errorf("Expected continue without session_id to fail, got %q", text)
+	}
+	if text := callToolError(t, s, "sequential_thinking", map[string]interface{}{"problem": "x", "observation": "y"}); !strings.Contains(text, "requires continue") {
+		t.Errorf("Expected an observation without continue to fail, got %q", text)
+	}
+}
+
+func TestSequentialSessionStore_BusyAndExpiry(t *testing.T) {
+	store := &sequentialSessionStore{sessions: make(map[string]*sequentialSession)}
+	session, err := store.start("s", map[string]interface{}{"problem": "p", "max_thoughts": 3.0, "provider": "mock"}, NewSequentialChain("p"))
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if _, ok := session.args["max_thoughts"]; ok || session.args["provider"] != "mock" {
+		t.Errorf("Expected per-call arguments to be dropped from the saved settings, got %v", session.args)
+	}
+	if _, err := store.checkout("s"); err == nil || !strings.Contains(err.Error(), "busy") {
+		t.Errorf("Expected a busy session to be refused, got %v", err)
+	}
+	if _, err := store.start("s", nil, NewSequentialChain("p")); err == nil {
+		t.Error("Expected a busy session not to be replaced")
+	}
+	store.release(session)
+
+	if _, err := store.checkout("s"); err != nil {
+		t.Fatalf("checkout failed: %v", err)
+	}
+	store.release(session)
+	session.lastUsed = time.Now().Add(-2 * defaultSequentialSessionTTL)
+	if _, err := store.checkout("s"); err == nil {
+		t.Error("Expected an expired session to be gone")
+	}
+
+	if err := validateSessionID(" padded "); err == nil {
+		t.Error("Expected a padded session_id to be rejected")
+	}
+}
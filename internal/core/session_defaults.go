@@ -0,0 +1,239 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Session Defaults ============
+//
+// set_session_defaults stores parameter values per MCP session. Later tool
+// calls in the session get them for every parameter the tool declares and
+// the call leaves unset, so explicit arguments always win.
+
+// maxDefaultSessions bounds stored sessions; the least recently updated are
+// evicted first, since streamable HTTP unregisters sessions whenever a GET
+// stream closes
+const maxDefaultSessions = 256
+
+var sessionDefaults = newSessionDefaultsStore(maxDefaultSessions)
+
+type sessionDefaultsStore struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionDefaultsEntry
+	max      int
+}
+
+type sessionDefaultsEntry struct {
+	values  map[string]interface{}
+	updated time.Time
+}
+
+func newSessionDefaultsStore(max int) *sessionDefaultsStore {
+	return &sessionDefaultsStore{sessions: make(map[string]*sessionDefaultsEntry), max: max}
+}
+
+// get returns a copy of a session's defaults
+func (s *sessionDefaultsStore) get(sessionID string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	values := make(map[string]interface{}, len(entry.values))
+	for k, v := range entry.values {
+		values[k] = v
+	}
+	return values
+}
+
+// update merges values into a session's defaults after removing the unset
+// keys (all of them when clear is set) and returns the result
+func (s *sessionDefaultsStore) update(sessionID string, values map[string]interface{}, unset []string, clear bool) map[string]interface{} {
+	s.mu.Lock()
+	entry, ok := s.sessions[sessionID]
+	if !ok || clear {
+		if !ok && len(s.sessions) >= s.max {
+			s.evictOldestLocked()
+		}
+		entry = &sessionDefaultsEntry{values: make(map[string]interface{})}
+		s.sessions[sessionID] = entry
+	}
+	for _, key := range unset {
+		delete(entry.values, key)
+	}
+	for k, v := range values {
+		entry.values[k] = v
+	}
+	entry.updated = time.Now()
+	if len(entry.values) == 0 {
+		delete(s.sessions, sessionID)
+	}
+	s.mu.Unlock()
+	return s.get(sessionID)
+}
+
+func (s *sessionDefaultsStore) evictOldestLocked() {
+	var oldestID string
+	var oldest time.Time
+	for id, e := range s.sessions {
+		if oldestID == "" || e.updated.Before(oldest) {
+			oldestID, oldest = id, e.updated
+		}
+	}
+	delete(s.sessions, oldestID)
+}
+
+// sessionDefaultsMiddleware fills unset arguments from the session's defaults
+func sessionDefaultsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		request, filled := applySessionDefaults(ctx, request)
+		if len(filled) > 0 {
+			ctx = context.WithValue(ctx, sessionDefaultedKey{}, filled)
+		}
+		return next(ctx, request)
+	}
+}
+
+// applySessionDefaults returns the request with session defaults filled in
+// and the names of the arguments it filled
+func applySessionDefaults(ctx context.Context, request mcp.CallToolRequest) (mcp.CallToolRequest, map[string]bool) {
+	session := server.ClientSessionFromContext(ctx)
+	srv := server.ServerFromContext(ctx)
+	if session == nil || srv == nil || request.Params.Name == "set_session_defaults" {
+		return request, nil
+	}
+	defaults := sessionDefaults.get(session.SessionID())
+	if len(defaults) == 0 {
+		return request, nil
+	}
+	tool := srv.GetTool(request.Params.Name)
+	if tool == nil {
+		return request, nil
+	}
+
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok && request.Params.Arguments != nil {
+		return request, nil
+	}
+	merged := make(map[string]interface{}, len(args)+len(defaults))
+	filled := make(map[string]bool)
+	for k, v := range args {
+		merged[k] = v
+	}
+	for k, v := range defaults {
+		if _, declared := tool.Tool.InputSchema.Properties[k]; !declared || perCallParams[k] {
+			continue
+		}
+		if _, set := merged[k]; !set {
+			merged[k] = v
+			filled[k] = true
+		}
+	}
+	request.Params.Arguments = merged
+	return request, filled
+}
+
+// declaredToolParams returns every parameter name declared by a registered tool
+func declaredToolParams(srv *server.MCPServer) map[string]bool {
+	params := make(map[string]bool)
+	for name, tool := range srv.ListTools() {
+		if name == "set_session_defaults" {
+			continue
+		}
+		for param := range tool.Tool.InputSchema.Properties {
+			if !perCallParams[param] {
+				params[param] = true
+			}
+		}
+	}
+	return params
+}
+
+// perCallParams identify a single call, so a default would make every call
+// in the session look like the same one
+var perCallParams = map[string]bool{"idempotency_key": true}
+
+// sessionDefaultParams are the set_session_defaults parameters stored as
+// defaults under the same name; any other tool parameter goes in "defaults"
+var sessionDefaultParams = []string{
+	"provider", "model", "fallback_providers", "evaluator_provider", "evaluator_model",
+	"stream", "stream_mode", "stderr_stream", "mcp_logging", "mcp_progress",
+	"max_llm_calls", "confidence_samples", "confidence_temperature",
+}
+
+// SessionDefaultsResult is the output of set_session_defaults
+type SessionDefaultsResult struct {
+	SessionID string                 `json:"session_id"`
+	Defaults  map[string]interface{} `json:"defaults"`
+	Ignored   []string               `json:"ignored,omitempty"` // Keys no tool declares
+}
+
+func handleSetSessionDefaults(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok && request.Params.Arguments != nil {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil || session.SessionID() == "" {
+		return mcp.NewToolResultError("session defaults need an MCP session"), nil
+	}
+
+	values := make(map[string]interface{})
+	if raw, ok := args["defaults"].(string); ok && strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("defaults must be a JSON object: %v", err)), nil
+		}
+	}
+	for _, key := range sessionDefaultParams {
+		if v, ok := args[key]; ok {
+			values[key] = v
+		}
+	}
+
+	var ignored []string
+	if srv := server.ServerFromContext(ctx); srv != nil {
+		declared := declaredToolParams(srv)
+		for key := range values {
+			if !declared[key] {
+				ignored = append(ignored, key)
+				delete(values, key)
+			}
+		}
+		sort.Strings(ignored)
+	}
+
+	var unset []string
+	if raw, ok := args["unset"].(string); ok {
+		for _, key := range strings.Split(raw, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				unset = append(unset, key)
+			}
+		}
+	}
+	clear, _ := args["clear"].(bool)
+
+	result := SessionDefaultsResult{
+		SessionID: session.SessionID(),
+		Defaults:  sessionDefaults.update(session.SessionID(), values, unset, clear),
+		Ignored:   ignored,
+	}
+	if result.Defaults == nil {
+		result.Defaults = map[string]interface{}{}
+	}
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize session defaults: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestSessionDefaults_AppliedToDeclaredParams(t *testing.T) {
+	sessionDefaults = newSessionDefaultsStore(maxDefaultSessions)
+	t.Cleanup(func() { sessionDefaults = newSessionDefaultsStore(maxDefaultSessions) })
+
+	s := server.NewMCPServer(serverName, serverVersion, server.WithToolHandlerMiddleware(sessionDefaultsMiddleware))
+	s.AddTool(mcp.NewTool("set_session_defaults"), handleSetSessionDefaults)
+
+	var seen map[string]interface{}
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("provider"), mcp.WithString("model"), mcp.WithNumber("max_depth")),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			seen, _ = request.Params.Arguments.(map[string]interface{})
+			return mcp.NewToolResultText("ok"), nil
+		})
+
+	call := func(ctx context.Context, id int, tool, args string) string {
+		msg := fmt.Sprintf(`{"jsonrpc": "2.0", "id": %d, "method": "tools/call", "params": {"name": %q, "arguments": %s}}`, id, tool, args)
+		raw, _ := json.Marshal(s.HandleMessage(ctx, json.RawMessage(msg)))
+		var resp struct {
+			Result struct {
+				Content []mcp.TextContent `json:"content"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Content) == 0 {
+			t.Fatalf("Unexpected response: %s", raw)
+		}
+		return resp.Result.Content[0].Text
+	}
+	ctx := s.WithContext(context.Background(), server.NewInProcessSession("defaults-session", nil))
+	other := s.WithContext(context.Background(), server.NewInProcessSession("other-session", nil))
+
+	var result SessionDefaultsResult
+	out := call(ctx, 1, "set_session_defaults", `{"provider": "deepseek", "model": "deepseek-chat", "defaults": "{\"max_depth\": 4, \"bogus_param\": 1}"}`)
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, out)
+	}
+	if result.SessionID != "defaults-session" || len(result.Defaults) != 3 || len(result.Ignored) != 1 || result.Ignored[0] != "bogus_param" {
+		t.Errorf("Unexpected defaults result: %+v", result)
+	}
+
+	call(ctx, 2, "echo", `{"model": "explicit"}`)
+	if seen["provider"] != "deepseek" || seen["model"] != "explicit" || seen["max_depth"] != float64(4) {
+		t.Errorf("Expected defaults to fill only unset params, got %v", seen)
+	}
+
+	call(other, 3, "echo", `{}`)
+	if len(seen) != 0 {
+		t.Errorf("Expected no defaults for another session, got %v", seen)
+	}
+
+	out = call(ctx, 4, "set_session_defaults", `{"unset": "provider, max_depth"}`)
+	result = SessionDefaultsResult{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil || len(result.Defaults) != 1 || result.Defaults["model"] != "deepseek-chat" {
+		t.Errorf("Expected only model left after unset, got %s", out)
+	}
+
+	out = call(ctx, 5, "set_session_defaults", `{"clear": true}`)
+	result = SessionDefaultsResult{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil || len(result.Defaults) != 0 {
+		t.Errorf("Expected defaults cleared, got %s", out)
+	}
+}
+
+func TestSessionDefaultsStore_EvictsOldest(t *testing.T) {
+	store := newSessionDefaultsStore(2)
+	store.update("a", map[string]interface{}{"model": "a"}, nil, false)
+	store.update("b", map[string]interface{}{"model": "b"}, nil, false)
+	store.update("a", map[string]interface{}{"provider": "x"}, nil, false)
+	store.update("c", map[string]interface{}{"model": "c"}, nil, false)
+
+	if store.get("b") != nil || store.get("a")["provider"] != "x" || store.get("c")["model"] != "c" {
+		t.Errorf("Expected the least recently updated session evicted, got %v", store.sessions)
+	}
+}
@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Shared Memory ============
+//
+// graph_of_thoughts and dialectic_reason can use reflexion's episodic memory
+// too. With record_episode a run is stored as an episode tagged with its
+// tool: the problem, final answer and confidence, plus a failure reason and
+// reflection built from the run's own trace (pruned thoughts, open
+// questions) when it fell short, so recording costs no extra LLM call. With
+// learn_from_past the first prompt carries lessons from similar past
+// episodes, whichever tool wrote them. memory_namespace works as for
+// reflexion.
+
+// SharedMemoryConfig controls how a strategy uses episodic memory
+type SharedMemoryConfig struct {
+	Record        bool   // Store the run as an episode (default: false)
+	LearnFromPast bool   // Start from lessons of similar past episodes (default: false)
+	Namespace     string // Memory namespace; "client" is resolved per call (default: REFLEXION_MEMORY_NAMESPACE)
+}
+
+// DefaultSharedMemoryConfig returns a config that neither records nor
+// learns, in the default memory namespace
+func DefaultSharedMemoryConfig() SharedMemoryConfig {
+	return SharedMemoryConfig{Namespace: defaultMemoryNamespace()}
+}
+
+func sharedMemoryOption() mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		mcp.WithBoolean("record_episode",
+			mcp.Description("Store this run's problem, answer, confidence and failure modes in episodic memory, where reflexion and later runs learn from it (default: false)"),
+		)(t)
+		mcp.WithBoolean("learn_from_past",
+			mcp.Description("Start from lessons of similar past problems in episodic memory, including those reflexion recorded (default: false)"),
+		)(t)
+		memoryNamespaceOption()(t)
+	}
+}
+
+// sharedMemoryConfigFromArgs reads record_episode, learn_from_past and
+// memory_namespace
+func sharedMemoryConfigFromArgs(args map[string]interface{}) (SharedMemoryConfig, error) {
+	config := DefaultSharedMemoryConfig()
+	if re, ok := args["record_episode"].(bool); ok {
+		config.Record = re
+	}
+	if lp, ok := args["learn_from_past"].(bool); ok {
+		config.LearnFromPast = lp
+	}
+	if ns, ok := args["memory_namespace"].(string); ok {
+		namespace, err := parseMemoryNamespace(ns)
+		if err != nil {
+			return config, err
+		}
+		config.Namespace = namespace
+	}
+	return config, nil
+}
+
+// sharedMemory is one run's handle on episodic memory
+type sharedMemory struct {
+	config      SharedMemoryConfig
+	namespace   string
+	memory      *EpisodicMemory
+	maxEpisodes int
+	ttl         time.Duration
+}
+
+// openSharedMemory returns nil when the run neither records nor learns
+func openSharedMemory(ctx context.Context, config SharedMemoryConfig) *sharedMemory {
+	if !config.Record && !config.LearnFromPast {
+		return nil
+	}
+	reflexion := DefaultReflexionConfig()
+	return &sharedMemory{
+		config:      config,
+		namespace:   resolveMemoryNamespace(ctx, config.Namespace),
+		memory:      loadOrCreateMemory(reflexion.MemoryPath),
+		maxEpisodes: reflexion.MaxEpisodes,
+		ttl:         reflexion.EpisodeTTL,
+	}
+}
+
+// lessons returns lessons from similar past problems, or nil when the run
+// does not learn from the past
+func (sm *sharedMemory) lessons(problem string) []string {
+	if sm == nil || !sm.config.LearnFromPast {
+		return nil
+	}
+	return sm.memory.lessonsFor(problem, "", sm.namespace)
+}
+
+// record stores an episode when the run records them
+func (sm *sharedMemory) record(ep Episode) {
+	if sm == nil || !sm.config.Record {
+		return
+	}
+	ep.Namespace = sm.namespace
+	sm.memory.mu.Lock()
+	defer sm.memory.mu.Unlock()
+	sm.memory.add(ep, sm.maxEpisodes, sm.ttl)
+}
+
+// add appends an episode, fills in its derived fields, applies the memory
+// limits and saves. The caller holds m.mu.
+func (m *EpisodicMemory) add(ep Episode, maxEpisodes int, ttl time.Duration) {
+	now := time.Now()
+	if ep.ID == "" {
+		prefix := hashProblem(ep.Problem)[:8]
+		if ep.Tool != "" {
+			prefix = ep.Tool + "_" + prefix
+		}
+		ep.ID = fmt.Sprintf("%s_%d_%d", prefix, ep.Attempt, now.Unix())
+	}
+	ep.ProblemHash = hashProblem(ep.Problem)
+	ep.Category = ClassifyProblem(ep.Problem)
+	ep.Timestamp = now
+	ep.Signature = signRecord(ep)
+	m.Episodes = append(m.Episodes, ep)
+
+	if dropped := m.cleanup(maxEpisodes, ttl); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "[MEMORY] Dropped %s from namespace %s (limit %d per namespace, REFLEXION_MAX_EPISODES; see memory_prune and memory_export)\n",
+			plural(dropped, "old episode"), namespaceLabel(ep.Namespace), maxEpisodes)
+	}
+	m.save()
+}
+
+// lessonsSection is the prompt paragraph listing past lessons, ending in a
+// blank line ("" for none)
+func lessonsSection(lessons []string) string {
+	if len(lessons) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Lessons from similar past problems:\n")
+	for _, lesson := range lessons {
+		sb.WriteString("- " + lesson + "\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// gotEpisode summarizes a Graph of Thoughts run. It succeeded when a thought
+// was judged a solution; otherwise the reflection names the stop reason and
+// the thoughts that were pruned, so later runs try something else.
+func gotEpisode(result *GoTResult, solved bool, confidence float64) Episode {
+	ep := Episode{
+		Tool:          "graph_of_thoughts",
+		Problem:       result.Problem,
+		Attempt:       1,
+		FinalAnswer:   result.FinalAnswer,
+		WasSuccessful: solved,
+		Confidence:    roundTo(confidence, 3),
+		Provider:      result.Provider,
+	}
+	for _, node := range result.BestPath {
+		if node.ID != "root" {
+			ep.Thoughts = append(ep.Thoughts, node.Thought)
+		}
+	}
+	if solved {
+		return ep
+	}
+
+	stopReason := ""
+	pruned := make(map[string]int)
+	var abandoned []string
+	for _, d := range result.Decisions {
+		switch d.Type {
+		case "stop":
+			stopReason = d.Reason
+		case "prune":
+			pruned[d.Reason]++
+			if d.Reason == GoTReasonLowScore && len(abandoned) < 2 {
+				abandoned = append(abandoned, fmt.Sprintf("%q (%.2f)", utils.TruncateStr(d.Thought, 120), d.Score))
+			}
+		}
+	}
+	ep.FailureReason = fmt.Sprintf("no thought was judged a solution (stopped: %s)", stopReason)
+
+	parts := []string{fmt.Sprintf("Graph of Thoughts found no solution; best path score %.2f.", confidence)}
+	if len(pruned) > 0 {
+		reasons := make([]string, 0, len(pruned))
+		for reason, n := range pruned {
+			reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+		}
+		sort.Strings(reasons)
+		parts = append(parts, fmt.Sprintf("Pruned thoughts: %s.", strings.Join(reasons, ", ")))
+	}
+	if len(abandoned) > 0 {
+		parts = append(parts, fmt.Sprintf("Low-scoring approaches: %s.", strings.Join(abandoned, "; ")))
+	}
+	ep.Reflection = strings.Join(parts, " ")
+	return ep
+}
+
+// dialecticEpisode summarizes a dialectic run. It succeeded when it reached
+// the verification threshold; otherwise the reflection lists the questions
+// and verifier issues the debate left open.
+func dialecticEpisode(result *DialecticResult) Episode {
+	ep := Episode{
+		Tool:          "dialectic_reason",
+		Problem:       result.Problem,
+		Attempt:       max(result.TotalRounds, 1),
+		FinalAnswer:   result.FinalAnswer,
+		WasSuccessful: result.Success,
+		Confidence:    roundTo(result.Confidence, 3),
+		Provider:      result.Provider,
+	}
+	for _, step := range result.Steps {
+		ep.Thoughts = append(ep.Thoughts, step.Synthesis.Content)
+	}
+	if result.Success {
+		return ep
+	}
+
+	ep.FailureReason = fmt.Sprintf("confidence %.2f after %s (stopped: %s)", result.Confidence, plural(result.TotalRounds, "round"), result.StoppedReason)
+	var open []string
+	for _, q := range result.OpenQuestions {
+		if len(open) == 3 {
+			break
+		}
+		open = append(open, q.Question)
+	}
+	if len(open) == 0 && len(result.Steps) > 0 {
+		// Without open questions, fall back to the final synthesis critique
+		last := result.Steps[len(result.Steps)-1].Synthesis.Verification
+		open = append(open, last.Issues[:min(3, len(last.Issues))]...)
+		if last.Suggestion != "" {
+			open = append(open, last.Suggestion)
+		}
+	}
+	reflection := fmt.Sprintf("Dialectic debate did not reach a confident answer (%.2f).", result.Confidence)
+	if len(open) > 0 {
+		reflection += " Unresolved: " + strings.Join(open, "; ")
+	}
+	ep.Reflection = reflection
+	return ep
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSharedMemory_RecordAndLearn(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		theses   []string
+		firstGoT string
+	)
+	fake := newFakeOpenAI(t, func(req fakeChatRequest) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch system := req.system(); {
+		case strings.Contains(system, "Propose clear, defensible claims"):
+			theses = append(theses, req.user())
+		case strings.Contains(system, "Generate diverse, creative reasoning steps") && firstGoT == "":
+			firstGoT = req.user()
+		}
+		return solveResponder(req)
+	})
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t)
+
+	problem := "What is 17 * 23?"
+	r := NewReflexion(&countProvider{}, DefaultReflexionConfig())
+	r.storeEpisode(problem, 1, nil, "381", false, "miscounted the tens", "Double-check the tens digit")
+
+	result := callTool(t, s, "dialectic_reason", map[string]interface{}{"problem": problem, "max_rounds": 1, "learn_from_past": true, "record_episode": true})
+	if lessons, _ := result["lessons_learned"].([]interface{}); len(lessons) != 1 {
+		t.Errorf("Expected the reflexion lesson applied, got %v", result["lessons_learned"])
+	}
+	if len(theses) == 0 || !strings.Contains(theses[0], "- Double-check the tens digit") {
+		t.Errorf("Expected the lesson in the first thesis prompt, got %q", theses)
+	}
+	callTool(t, s, "graph_of_thoughts", map[string]interface{}{"problem": problem, "max_nodes": 3, "learn_from_past": true, "record_episode": true})
+	if !strings.Contains(firstGoT, "Double-check the tens digit") {
+		t.Errorf("Expected the lesson in the first expansion prompt, got %q", firstGoT)
+	}
+
+	tools := make(map[string]Episode)
+	for _, ep := range loadOrCreateMemory(DefaultReflexionConfig().MemoryPath).Episodes {
+		tools[ep.Tool] = ep
+	}
+	if ep, ok := tools["graph_of_thoughts"]; !ok || !ep.WasSuccessful || ep.FinalAnswer != "391" || ep.Confidence == 0 {
+		t.Errorf("Expected a successful graph_of_thoughts episode, got %+v", ep)
+	}
+	if ep, ok := tools["dialectic_reason"]; !ok || ep.Problem != problem || ep.Confidence == 0 {
+		t.Errorf("Expected a dialectic_reason episode, got %+v", ep)
+	}
+
+	// Without record_episode nothing is stored
+	callTool(t, s, "graph_of_thoughts", map[string]interface{}{"problem": problem, "max_nodes": 3})
+	if n := len(loadOrCreateMemory(DefaultReflexionConfig().MemoryPath).Episodes); n != 3 {
+		t.Errorf("Expected 3 episodes, got %d", n)
+	}
+}
+
+func TestDialecticEpisode_FailureReflection(t *testing.T) {
+	ep := dialecticEpisode(&DialecticResult{
+		Problem:       "Is P = NP?",
+		FinalAnswer:   "Unknown",
+		Confidence:    0.42,
+		TotalRounds:   2,
+		StoppedReason: StopMaxRounds,
+		OpenQuestions: []OpenQuestion{{Question: "Which complexity assumptions hold?"}},
+	})
+	if ep.WasSuccessful || ep.Tool != "dialectic_reason" || ep.Attempt != 2 {
+		t.Errorf("Unexpected episode: %+v", ep)
+	}
+	if !strings.Contains(ep.FailureReason, "max_rounds") || !strings.Contains(ep.Reflection, "Which complexity assumptions hold?") {
+		t.Errorf("Expected the stop reason and open question, got %q / %q", ep.FailureReason, ep.Reflection)
+	}
+}
@@ -0,0 +1,357 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"reasoning-tools/utils"
+)
+
+// Similarity backend names accepted in GoTConfig.SimilarityBackend
+const (
+	SimilarityBackendLLM       = "llm"
+	SimilarityBackendEmbedding = "embedding"
+	SimilarityBackendMinHash   = "minhash"
+	SimilarityBackendHybrid    = "hybrid"
+)
+
+// defaultSimilarityBand is how close to MergeThreshold an embedding score
+// must be for the hybrid backend to ask the LLM
+const defaultSimilarityBand = 0.1
+
+// defaultSimilarityPrefilter is the minimum lexical overlap required before
+// the configured backend is consulted
+const defaultSimilarityPrefilter = 0.1
+
+// SimilarityBackend scores how semantically similar two thoughts are (0.0-1.0)
+type SimilarityBackend interface {
+	Name() string
+	Similarity(ctx context.Context, a, b string) (float64, error)
+}
+
+// EmbeddingProvider is implemented by providers that expose an embeddings endpoint
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, texts []string, model string) ([][]float64, error)
+}
+
+// newSimilarityBackend builds the backend named by kind, falling back to the
+// LLM backend when the choice is unknown or no embeddings are available.
+// Embeddings come from provider or the configured embeddings provider; the
+// LLM backend asks judge. The hybrid backend escalates embedding scores
+// within band of threshold to the LLM.
+func newSimilarityBackend(kind string, provider, judge Provider, threshold, band float64) SimilarityBackend {
+	switch kind = strings.ToLower(strings.TrimSpace(kind)); kind {
+	case "", SimilarityBackendLLM:
+		return &llmSimilarity{provider: judge}
+	case SimilarityBackendMinHash:
+		return newMinHashSimilarity(64)
+	case SimilarityBackendEmbedding, SimilarityBackendHybrid:
+		embedder, err := similarityEmbedder(provider)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARNING] graph_of_thoughts: %v, using llm similarity\n", err)
+			return &llmSimilarity{provider: judge}
+		}
+		embeddings := &embeddingSimilarity{
+			embedder: embedder,
+			model:    withDefault(os.Getenv("GOT_EMBEDDING_MODEL"), withDefault(os.Getenv("SEMANTIC_CACHE_EMBEDDING_MODEL"), "text-embedding-3-small")),
+			cache:    make(map[string][]float64),
+		}
+		if kind == SimilarityBackendEmbedding {
+			return embeddings
+		}
+		return &hybridSimilarity{embeddings: embeddings, llm: &llmSimilarity{provider: judge}, threshold: threshold, band: band}
+	default:
+		fmt.Fprintf(os.Stderr, "[WARNING] graph_of_thoughts: unknown similarity backend %q, using llm\n", kind)
+		return &llmSimilarity{provider: judge}
+	}
+}
+
+// similarityEmbedder returns the provider's embeddings endpoint (that of the
+// first provider with one in a fallback chain, see NewFallbackProvider), or else the
+// embeddings provider the semantic cache is configured with
+// (GOT_EMBEDDING_PROVIDER overrides SEMANTIC_CACHE_EMBEDDING_PROVIDER)
+func similarityEmbedder(provider Provider) (EmbeddingProvider, error) {
+	providerType := withDefault(os.Getenv("GOT_EMBEDDING_PROVIDER"), os.Getenv("SEMANTIC_CACHE_EMBEDDING_PROVIDER"))
+	if providerType == "" {
+		if embedder, ok := provider.(EmbeddingProvider); ok {
+			return embedder, nil
+		}
+		return nil, fmt.Errorf("provider %s does not support embeddings (set GOT_EMBEDDING_PROVIDER)", providerName(provider))
+	}
+	p, err := NewProvider(ProviderConfig{Type: providerType, APIKey: getAPIKeyForProvider(providerType)})
+	if err != nil {
+		return nil, fmt.Errorf("embedding provider: %w", err)
+	}
+	embedder, ok := p.(EmbeddingProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support embeddings", providerType)
+	}
+	return embedder, nil
+}
+
+// llmSimilarity asks the LLM to rate similarity (one call per pair)
+type llmSimilarity struct {
+	provider Provider
+}
+
+func (s *llmSimilarity) Name() string { return SimilarityBackendLLM }
+
+func (s *llmSimilarity) Similarity(ctx context.Context, a, b string) (float64, error) {
+	prompt := fmt.Sprintf(`How similar are these two reasoning steps? Consider whether they express the same idea or reach the same conclusion.
+
+Thought 1: %s
+
+Thought 2: %s
+
+Respond with ONLY a number from 0.0 (completely different) to 1.0 (the same idea).`, a, b)
+
+	messages := []ChatMessage{
+		{Role: "user", Content: prompt},
+	}
+
+	response, err := s.provider.Chat(ctx, messages, ChatOptions{
+		Temperature: 0.1,
+		MaxTokens:   10,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return parseSimilarityScore(response)
+}
+
+// parseSimilarityScore reads a 0-1 similarity score, tolerating percentages
+// and plain yes/no answers
+func parseSimilarityScore(response string) (float64, error) {
+	text := strings.ToLower(strings.TrimSpace(response))
+	switch {
+	case strings.HasPrefix(text, "yes"):
+		return 1, nil
+	case strings.HasPrefix(text, "no"):
+		return 0, nil
+	}
+
+	match := regexp.MustCompile(`[0-9]*\.?[0-9]+`).FindString(text)
+	if match == "" {
+		return 0, fmt.Errorf("no similarity score in response: %q", utils.TruncateStr(response, 50))
+	}
+	val, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, err
+	}
+	if val > 1 && val <= 100 {
+		val = val / 100
+	}
+	return math.Max(0, math.Min(1, val)), nil
+}
+
+// embeddingSimilarity compares thoughts by cosine similarity of their embeddings
+type embeddingSimilarity struct {
+	embedder EmbeddingProvider
+	model    string
+	mu       sync.Mutex
+	cache    map[string][]float64
+}
+
+func (s *embeddingSimilarity) Name() string { return SimilarityBackendEmbedding }
+
+func (s *embeddingSimilarity) Similarity(ctx context.Context, a, b string) (float64, error) {
+	va, err := s.embedding(ctx, a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := s.embedding(ctx, b)
+	if err != nil {
+		return 0, err
+	}
+	// Cosine similarity of text embeddings is effectively non-negative
+	return math.Max(0, cosineSimilarity(va, vb)), nil
+}
+
+func (s *embeddingSimilarity) embedding(ctx context.Context, text string) ([]float64, error) {
+	s.mu.Lock()
+	if v, ok := s.cache[text]; ok {
+		s.mu.Unlock()
+		return v, nil
+	}
+	s.mu.Unlock()
+
+	vectors, err := s.embedder.Embed(ctx, []string{text}, s.model)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+
+	s.mu.Lock()
+	s.cache[text] = vectors[0]
+	s.mu.Unlock()
+	return vectors[0], nil
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// hybridSimilarity scores pairs by embedding cosine similarity and asks the
+// LLM only about borderline pairs, whose cosine is within band of the merge
+// threshold. Pairs whose embeddings fail are asked too.
+type hybridSimilarity struct {
+	embeddings *embeddingSimilarity
+	llm        *llmSimilarity
+	threshold  float64
+	band       float64
+
+	mu    sync.Mutex
+	stats SimilarityStats
+}
+
+// SimilarityStats counts how the hybrid backend decided merge checks
+type SimilarityStats struct {
+	Backend     string `json:"backend"`
+	Checks      int    `json:"checks"`
+	ByEmbedding int    `json:"decided_by_embedding"`
+	Escalated   int    `json:"escalated_to_llm"`
+}
+
+func (s *hybridSimilarity) Name() string { return SimilarityBackendHybrid }
+
+func (s *hybridSimilarity) Similarity(ctx context.Context, a, b string) (float64, error) {
+	cosine, err := s.embeddings.Similarity(ctx, a, b)
+	s.mu.Lock()
+	s.stats.Checks++
+	if err == nil && math.Abs(cosine-s.threshold) > s.band {
+		s.stats.ByEmbedding++
+		s.mu.Unlock()
+		return cosine, nil
+	}
+	s.stats.Escalated++
+	s.mu.Unlock()
+	return s.llm.Similarity(ctx, a, b)
+}
+
+// Stats reports how many checks the embeddings settled
+func (s *hybridSimilarity) Stats() SimilarityStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Backend = SimilarityBackendHybrid
+	return stats
+}
+
+// minHashSimilarity estimates Jaccard similarity of word shingles without any API calls
+type minHashSimilarity struct {
+	numHashes int
+}
+
+func newMinHashSimilarity(numHashes int) *minHashSimilarity {
+	return &minHashSimilarity{numHashes: numHashes}
+}
+
+func (s *minHashSimilarity) Name() string { return SimilarityBackendMinHash }
+
+func (s *minHashSimilarity) Similarity(_ context.Context, a, b string) (float64, error) {
+	sa := s.signature(wordShingles(a))
+	sb := s.signature(wordShingles(b))
+	if sa == nil || sb == nil {
+		return 0, nil
+	}
+	matches := 0
+	for i := range sa {
+		if sa[i] == sb[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(s.numHashes), nil
+}
+
+func (s *minHashSimilarity) signature(shingles map[string]bool) []uint64 {
+	if len(shingles) == 0 {
+		return nil
+	}
+	sig := make([]uint64, s.numHashes)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for sh := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(sh))
+		base := h.Sum64()
+		for i := range sig {
+			// Derive independent hash functions from a single base hash
+			v := base ^ (uint64(i+1) * 0x9e3779b97f4a7c15)
+			v ^= v >> 33
+			v *= 0xff51afd7ed558ccd
+			v ^= v >> 33
+			if v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// wordShingles returns the set of unigrams and bigrams of normalized words
+func wordShingles(text string) map[string]bool {
+	words := normalizedWords(text)
+	shingles := make(map[string]bool, len(words)*2)
+	for i, w := range words {
+		shingles[w] = true
+		if i > 0 {
+			shingles[words[i-1]+" "+w] = true
+		}
+	}
+	return shingles
+}
+
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// lexicalOverlap is the Jaccard similarity of the word sets of a and b.
+// It is used as a cheap pre-filter before any backend call.
+func lexicalOverlap(a, b string) float64 {
+	setA := make(map[string]bool)
+	for _, w := range normalizedWords(a) {
+		setA[w] = true
+	}
+	setB := make(map[string]bool)
+	for _, w := range normalizedWords(b) {
+		setB[w] = true
+	}
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for w := range setA {
+		if setB[w] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	return float64(intersection) / float64(union)
+}
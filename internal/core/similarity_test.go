@@ -0,0 +1,172 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestParseSimilarityScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     float64
+		wantErr  bool
+	}{
+		{"decimal", "0.82", 0.82, false},
+		{"with text", "Similarity: 0.4", 0.4, false},
+		{"percentage", "75", 0.75, false},
+		{"clamped", "250", 1, false},
+		{"yes", "Yes.", 1, false},
+		{"no", "no", 0, false},
+		{"garbage", "unclear", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSimilarityScore(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSimilarityScore(%q) error = %v, wantErr %v", tt.response, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseSimilarityScore(%q) = %v, want %v", tt.response, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexicalOverlap(t *testing.T) {
+	if got := lexicalOverlap("The cache is stale", "the CACHE is stale!"); got != 1 {
+		t.Errorf("Expected identical word sets to overlap fully, got %v", got)
+	}
+	if got := lexicalOverlap("use a hash map", "sort the array"); got != 0 {
+		t.Errorf("Expected disjoint word sets to have no overlap, got %v", got)
+	}
+	if got := lexicalOverlap("", "anything"); got != 0 {
+		t.Errorf("Expected empty text to have no overlap, got %v", got)
+	}
+}
+
+func TestMinHashSimilarity(t *testing.T) {
+	backend := newSimilarityBackend(SimilarityBackendMinHash, nil, nil, 0.7, defaultSimilarityBand)
+	if backend.Name() != SimilarityBackendMinHash {
+		t.Fatalf("Expected minhash backend, got %s", backend.Name())
+	}
+
+	same, err := backend.Similarity(context.Background(), "invalidate the cache on write", "invalidate the cache on write")
+	if err != nil || same != 1 {
+		t.Errorf("Expected identical thoughts to score 1, got %v (err %v)", same, err)
+	}
+
+	near, _ := backend.Similarity(context.Background(),
+		"invalidate the cache entry whenever the record is written",
+		"invalidate the cache entry whenever the row is written")
+	far, _ := backend.Similarity(context.Background(),
+		"invalidate the cache entry whenever the record is written",
+		"use a priority queue to schedule jobs by deadline")
+	if near <= far {
+		t.Errorf("Expected near-duplicate (%v) to score higher than unrelated (%v)", near, far)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := cosineSimilarity([]float64{1, 0}, []float64{1, 0}); got != 1 {
+		t.Errorf("Expected 1 for identical vectors, got %v", got)
+	}
+	if got := cosineSimilarity([]float64{1, 0}, []float64{0, 1}); got != 0 {
+		t.Errorf("Expected 0 for orthogonal vectors, got %v", got)
+	}
+	if got := cosineSimilarity([]float64{1}, []float64{1, 2}); got != 0 {
+		t.Errorf("Expected 0 for mismatched lengths, got %v", got)
+	}
+}
+
+// criticProvider records evaluation prompts and scores every thought as a solution
+type criticProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *criticProvider) Name() string { return "critic" }
+
+func (p *criticProvider) Chat(context.Context, []ChatMessage, ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return `{"score": 0.9, "is_solution": true, "answer": "42", "reasoning": "complete"}`, nil
+}
+
+func TestGoT_EvaluatorProvider(t *testing.T) {
+	generator := &countProvider{response: `["Consider the first case", "Consider the second case"]`}
+	critic := &criticProvider{}
+	config := DefaultGoTConfig()
+	config.MaxNodes = 4
+	config.EnableMerging = false
+	config.Evaluator = critic
+
+	result, err := NewGraphOfThoughts(generator, config).Solve(context.Background(), "Explore the cases")
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if critic.calls == 0 {
+		t.Error("Expected thoughts to be evaluated by the critic")
+	}
+	if result.Evaluator != "critic" || result.Provider != "count" {
+		t.Errorf("Expected generator count and evaluator critic, got %q and %q", result.Provider, result.Evaluator)
+	}
+
+	// LLM similarity checks are judged by the critic too
+	before := critic.calls
+	_, _ = newSimilarityBackend(SimilarityBackendLLM, generator, critic, 0.7, defaultSimilarityBand).Similarity(context.Background(), "a", "b")
+	if critic.calls != before+1 {
+		t.Error("Expected the llm similarity backend to ask the critic")
+	}
+}
+
+func TestHybridSimilarity_EscalatesBorderlinePairs(t *testing.T) {
+	t.Setenv("GOT_EMBEDDING_PROVIDER", "")
+	t.Setenv("SEMANTIC_CACHE_EMBEDDING_PROVIDER", "")
+	embedder := &vectorProvider{vectors: map[string][]float64{
+		"base":       {1, 0},
+		"same":       {1, 0.05},  // cosine ~1.0: merge without asking
+		"unrelated":  {0, 1},     // cosine 0: reject without asking
+		"borderline": {0.7, 0.7}, // cosine ~0.71: ask the LLM
+	}}
+	judge := &countProvider{response: "0.2"}
+	backend := newSimilarityBackend(SimilarityBackendHybrid, embedder, judge, 0.7, defaultSimilarityBand)
+	if backend.Name() != SimilarityBackendHybrid {
+		t.Fatalf("Expected the hybrid backend, got %s", backend.Name())
+	}
+
+	ctx := context.Background()
+	if sim, _ := backend.Similarity(ctx, "base", "same"); sim < 0.99 {
+		t.Errorf("Expected the embedding score for a near duplicate, got %v", sim)
+	}
+	if sim, _ := backend.Similarity(ctx, "base", "unrelated"); sim != 0 {
+		t.Errorf("Expected the embedding score for an unrelated pair, got %v", sim)
+	}
+	if judge.calls != 0 {
+		t.Fatalf("Expected clear pairs to skip the LLM, got %d calls", judge.calls)
+	}
+	if sim, _ := backend.Similarity(ctx, "base", "borderline"); sim != 0.2 || judge.calls != 1 {
+		t.Errorf("Expected the borderline pair to be judged by the LLM, got %v after %d calls", sim, judge.calls)
+	}
+
+	stats := backend.(*hybridSimilarity).Stats()
+	if stats.Checks != 3 || stats.ByEmbedding != 2 || stats.Escalated != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// Embeddings are found under call limits and in fallback chains
+	chained := NewLLMCallCounter(5).Wrap(NewFallbackProvider([]Provider{&countProvider{}, embedder}))
+	if got := newSimilarityBackend(SimilarityBackendEmbedding, chained, judge, 0.7, defaultSimilarityBand); got.Name() != SimilarityBackendEmbedding {
+		t.Errorf("Expected the embedding backend through the wrapped chain, got %s", got.Name())
+	} else if sim, err := got.Similarity(ctx, "base", "same"); err != nil || sim < 0.99 {
+		t.Errorf("Expected the chain's embedder to score the pair, got %v (%v)", sim, err)
+	}
+
+	// Without any embeddings the backend falls back to the LLM
+	if got := newSimilarityBackend(SimilarityBackendHybrid, &countProvider{}, &countProvider{}, 0.7, defaultSimilarityBand).Name(); got != SimilarityBackendLLM {
+		t.Errorf("Expected llm fallback without embeddings, got %s", got)
+	}
+}
@@ -0,0 +1,264 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Strategy Explanations ============
+//
+// explain_strategy: true appends a plain-language account of how the
+// strategy went about the problem (rounds, branches, merges, tools) to a
+// strategy's result, for readers who do not know GoT or Reflexion. It is
+// built from the result itself and costs no LLM calls.
+
+// StrategyExplanation is the strategy_explanation appendix of a result
+type StrategyExplanation struct {
+	Strategy     string   `json:"strategy"`
+	HowItWorks   string   `json:"how_it_works"`
+	WhatHappened []string `json:"what_happened"`
+}
+
+// strategyDescriptions name each strategy and say how it works
+var strategyDescriptions = map[string][2]string{
+	"sequential_thinking": {"Step-by-step thinking", "The model reasons in a single chain of steps, each building on the last, until it reaches an answer."},
+	"graph_of_thoughts":   {"Graph of Thoughts", "The model proposes several alternative next steps at each point, scores them, keeps exploring the most promising ones and combines branches that reach the same idea."},
+	"got_continue":        {"Graph of Thoughts (continued)", "An earlier Graph of Thoughts search was resumed: more alternative steps were proposed and scored, starting from the saved graph."},
+	"reflexion":           {"Reflexion", "The model answers, checks its own answer and, when the check fails, writes down what went wrong and tries again with that lesson in mind."},
+	"dialectic_reason":    {"Dialectic reasoning", "The model proposes an answer (thesis), argues against it (antithesis) and combines the two into a better answer (synthesis), checking each claim along the way."},
+	"decompose_solve":     {"Decompose and solve", "The model splits the problem into simpler sub-problems, solves them in order, passing each answer forward, and composes the final answer from the parts."},
+	"plan_execute":        {"Plan and execute", "The model writes a plan, carries it out one step at a time and revises the plan when a step fails."},
+	"reasoning_pipeline":  {"Reasoning pipeline", "Several strategies ran one after another, each starting from the previous one's answer."},
+	"auto_reason":         {"Automatic strategy choice", "The problem was assessed first and handed to the strategy that suited it best."},
+}
+
+func explainStrategyOption() mcp.ToolOption {
+	return mcp.WithBoolean("explain_strategy",
+		mcp.Description("Append strategy_explanation: a plain-language account of how the strategy explored the problem (rounds, branches, merges, tools used). No extra LLM calls (default: false)"),
+	)
+}
+
+// strategyExplanationMiddleware appends strategy_explanation to successful
+// strategy results when the call asks for it
+func strategyExplanationMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.IsError || !runHistoryTools[request.Params.Name] {
+			return result, err
+		}
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		if explain, _ := args["explain_strategy"].(bool); !explain {
+			return result, err
+		}
+		text := resultText(result)
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(text), &fields) != nil {
+			return result, err
+		}
+		if text, ok := appendResultField(text, "strategy_explanation", explainStrategy(request.Params.Name, fields)); ok {
+			return mcp.NewToolResultText(text), nil
+		}
+		return result, err
+	}
+}
+
+// appendResultField adds a field at the end of a JSON object result as is,
+// keeping the result's field order
+func appendResultField(text, key string, value interface{}) (string, bool) {
+	appendix, err := json.MarshalIndent(value, "  ", "  ")
+	if err != nil || !strings.HasSuffix(strings.TrimRight(text, " \n"), "}") {
+		return text, false
+	}
+	body := strings.TrimSuffix(strings.TrimRight(text, " \n"), "}")
+	body = strings.TrimRight(body, " \n")
+	separator := ","
+	if strings.HasSuffix(body, "{") {
+		separator = ""
+	}
+	return body + separator + "\n  " + fmt.Sprintf("%q", key) + ": " + string(appendix) + "\n}", true
+}
+
+// explainStrategy describes a tool's run from its result
+func explainStrategy(tool string, result map[string]interface{}) StrategyExplanation {
+	description := strategyDescriptions[tool]
+	e := StrategyExplanation{Strategy: description[0], HowItWorks: description[1]}
+	r := explainedResult(result)
+
+	switch tool {
+	case "sequential_thinking":
+		e.add("It took %s to reach the answer.", plural(r.count("steps"), "step"))
+	case "graph_of_thoughts", "got_continue":
+		e.add("It considered %s in total and followed the best path %s deep.", plural(r.num("total_nodes"), "idea"), plural(max(r.count("best_path")-1, 0), "step")) // Past the problem itself
+		if merges := r.num("merge_count"); merges > 0 {
+			e.add("%s combined because different branches arrived at the same idea, which counts as stronger evidence.", plural(merges, "pair of ideas was", "pairs of ideas were"))
+		} else {
+			e.add("No branches had to be combined.")
+		}
+		pruned := 0
+		stop := ""
+		for _, d := range r.list("decisions") {
+			decision, _ := d.(map[string]interface{})
+			switch decision["type"] {
+			case "prune":
+				pruned++
+			case "stop":
+				stop, _ = decision["reason"].(string)
+			}
+		}
+		if pruned > 0 {
+			e.add("%s dropped for scoring too low or going too deep.", plural(pruned, "idea was", "ideas were"))
+		}
+		switch stop {
+		case "confident_solution_found":
+			e.add("It stopped once it found an answer it was confident in.")
+		case "node_budget_exhausted":
+			e.add("It stopped when it reached its limit of ideas to consider.")
+		case "no_expandable_nodes":
+			e.add("It stopped when no promising ideas were left to explore.")
+		}
+	case "reflexion":
+		attempts := r.count("attempts")
+		success, _ := result["success"].(bool)
+		switch {
+		case !success:
+			e.add("It made %s and none of the answers passed its check.", plural(attempts, "attempt"))
+		case attempts <= 1:
+			e.add("The first answer passed its check, so no retry was needed.")
+		default:
+			e.add("It made %s: the answers before the last did not pass its check, and each retry used what was learned.", plural(attempts, "attempt"))
+		}
+		if lessons := r.count("lessons_learned"); lessons > 0 {
+			e.add("It drew on %s learned from earlier attempts at similar problems.", plural(lessons, "lesson"))
+		}
+	case "dialectic_reason":
+		e.add("It went through %s of argument and counter-argument.", plural(r.num("total_rounds"), "round"))
+		switch r.str("stopped_reason") {
+		case "resolved":
+			e.add("It stopped when the counter-arguments were answered.")
+		case "converged":
+			e.add("It stopped when further rounds no longer changed its confidence.")
+		case "max_rounds":
+			e.add("It stopped at its limit of rounds.")
+		}
+		if confidence, ok := result["confidence"].(float64); ok {
+			e.add("Its confidence in the final answer is %.0f%%.", confidence*100)
+		}
+		if open := r.count("open_questions"); open > 0 {
+			e.add("%s left open that would make the answer more certain.", plural(open, "question was", "questions were"))
+		}
+	case "decompose_solve":
+		if decomposed, _ := result["decomposed"].(bool); decomposed {
+			e.add("It split the problem into %s and solved them in order.", plural(r.count("subproblems"), "smaller question"))
+		} else {
+			e.add("The problem could not be split, so it was solved in one step.")
+		}
+	case "plan_execute":
+		e.add("It planned %s and carried out %s.", plural(r.count("plan"), "step"), plural(r.num("total_steps"), "step"))
+		if replans := r.count("replans"); replans > 0 {
+			e.add("The plan was revised %s after steps failed.", plural(replans, "time"))
+		}
+	case "reasoning_pipeline":
+		var strategies []string
+		for _, s := range r.list("stages") {
+			stage, _ := s.(map[string]interface{})
+			if name, ok := stage["strategy"].(string); ok {
+				strategies = append(strategies, strategyName(name))
+			}
+		}
+		if len(strategies) > 0 {
+			e.add("It ran %s: %s.", plural(len(strategies), "stage"), strings.Join(strategies, ", then "))
+		}
+	case "auto_reason":
+		routing := explainedResult(r.obj("routing"))
+		if strategy := routing.str("strategy"); strategy != "" {
+			if reason := strings.TrimSuffix(strings.TrimSpace(routing.str("reason")), "."); reason != "" {
+				e.add("It chose %s for this problem: %s.", strategyName(strategy), reason)
+			} else {
+				e.add("It chose %s for this problem.", strategyName(strategy))
+			}
+			if inner := r.obj("result"); inner != nil {
+				explained := explainStrategy(strategy, inner)
+				e.WhatHappened = append(e.WhatHappened, explained.WhatHappened...)
+			}
+			return e
+		}
+	}
+
+	if tools := r.obj("tools_used"); len(tools) > 0 {
+		names := make([]string, 0, len(tools))
+		for name, n := range tools {
+			count, _ := n.(float64)
+			names = append(names, fmt.Sprintf("%s (%s)", name, plural(int(count), "time")))
+		}
+		sort.Strings(names)
+		e.add("It used tools to check its work: %s.", strings.Join(names, ", "))
+	} else if calls := r.num("total_tool_calls"); calls > 0 {
+		e.add("It made %s to check its work.", plural(calls, "tool call"))
+	}
+	if usage := r.obj("model_usage"); len(usage) > 0 {
+		calls := 0
+		for _, n := range usage {
+			count, _ := n.(float64)
+			calls += int(count)
+		}
+		e.add("In all it asked the language model %s.", plural(calls, "time"))
+	}
+	return e
+}
+
+func (e *StrategyExplanation) add(format string, args ...interface{}) {
+	e.WhatHappened = append(e.WhatHappened, fmt.Sprintf(format, args...))
+}
+
+// explainedResult reads the fields of a decoded result
+type explainedResult map[string]interface{}
+
+func (r explainedResult) num(key string) int {
+	n, _ := r[key].(float64)
+	return int(n)
+}
+
+func (r explainedResult) str(key string) string {
+	s, _ := r[key].(string)
+	return s
+}
+
+func (r explainedResult) list(key string) []interface{} {
+	l, _ := r[key].([]interface{})
+	return l
+}
+
+func (r explainedResult) count(key string) int {
+	return len(r.list(key))
+}
+
+func (r explainedResult) obj(key string) map[string]interface{} {
+	o, _ := r[key].(map[string]interface{})
+	return o
+}
+
+// strategyName is a tool's plain-language strategy name
+func strategyName(tool string) string {
+	if description, ok := strategyDescriptions[tool]; ok {
+		return description[0]
+	}
+	return tool
+}
+
+// plural formats a count with its noun, "1 step" or "3 steps". The plural
+// form defaults to the noun with an s.
+func plural(n int, forms ...string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", forms[0])
+	}
+	if len(forms) > 1 {
+		return fmt.Sprintf("%d %s", n, forms[1])
+	}
+	return fmt.Sprintf("%d %ss", n, forms[0])
+}
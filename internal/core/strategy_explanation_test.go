@@ -0,0 +1,53 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExplainStrategy_GraphOfThoughts(t *testing.T) {
+	var result map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"total_nodes": 12,
+		"best_path": [{"id": "root"}, {"id": "n1_0"}, {"id": "n2_1"}],
+		"merge_count": 2,
+		"decisions": [{"type": "prune"}, {"type": "merge"}, {"type": "stop", "reason": "node_budget_exhausted"}],
+		"tools_used": {"calculator": 2},
+		"model_usage": {"openai/gpt-4o": 9, "openai/gpt-4o-mini": 3}
+	}`), &result)
+
+	e := explainStrategy("graph_of_thoughts", result)
+	want := []string{
+		"It considered 12 ideas in total and followed the best path 2 steps deep.",
+		"2 pairs of ideas were combined because different branches arrived at the same idea, which counts as stronger evidence.",
+		"1 idea was dropped for scoring too low or going too deep.",
+		"It stopped when it reached its limit of ideas to consider.",
+		"It used tools to check its work: calculator (2 times).",
+		"In all it asked the language model 12 times.",
+	}
+	if e.Strategy != "Graph of Thoughts" || !reflect.DeepEqual(e.WhatHappened, want) {
+		t.Errorf("Unexpected explanation: %+v", e)
+	}
+}
+
+func TestStrategyExplanationMiddleware(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	s := integrationServer(t, strategyExplanationMiddleware)
+
+	plain := callTool(t, s, "dialectic_reason", map[string]interface{}{"problem": "What is 2 + 2?", "provider": "mock", "max_rounds": 1})
+	if _, ok := plain["strategy_explanation"]; ok {
+		t.Error("Expected no explanation without explain_strategy")
+	}
+
+	result := callTool(t, s, "dialectic_reason", map[string]interface{}{"problem": "What is 2 + 2?", "provider": "mock", "max_rounds": 1, "explain_strategy": true})
+	explanation, _ := result["strategy_explanation"].(map[string]interface{})
+	happened, _ := explanation["what_happened"].([]interface{})
+	if explanation["strategy"] != "Dialectic reasoning" || len(happened) == 0 || !strings.Contains(happened[0].(string), "1 round") {
+		t.Errorf("Unexpected explanation: %v", result["strategy_explanation"])
+	}
+	if result["final_answer"] != plain["final_answer"] {
+		t.Errorf("Expected the result otherwise unchanged, got %v", result["final_answer"])
+	}
+}
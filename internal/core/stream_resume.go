@@ -0,0 +1,392 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Resumable Streams ============
+//
+// Over the HTTP transports every streaming tool run keeps its event buffer
+// in a registry under a random stream ID. Clients that lose the connection
+// reconnect to GET <events-path>/<stream_id> with a Last-Event-ID header and
+// receive the events they missed, then live events, then the final result.
+//
+// Every tool run, streaming or not, is also tracked under its run ID so
+// dashboards can follow it at GET <stream-path>/<run_id>.
+
+const (
+	defaultStreamResumeTTL = 10 * time.Minute
+	defaultStreamResumeMax = 100
+	streamKeepAlive        = 15 * time.Second
+)
+
+// streamRegistry holds resumable runs; nil (stdio) disables resumption
+var streamRegistry *runStreamRegistry
+
+type runStreamRegistry struct {
+	mu      sync.Mutex
+	streams map[string]*resumableStream // By stream ID, streaming runs only
+	runs    map[string]*resumableStream // By run ID, every tracked run
+	ttl     time.Duration               // How long finished streams stay replayable
+	max     int                         // Streams (and runs) kept before the oldest are evicted
+}
+
+type resumableStream struct {
+	manager  *StreamingManager
+	streamID string
+	runID    string
+	tool     string
+	created  time.Time
+	finished time.Time
+}
+
+func newRunStreamRegistry(ttl time.Duration, max int) *runStreamRegistry {
+	if ttl <= 0 {
+		ttl = defaultStreamResumeTTL
+	}
+	if max <= 0 {
+		max = defaultStreamResumeMax
+	}
+	return &runStreamRegistry{
+		streams: make(map[string]*resumableStream),
+		runs:    make(map[string]*resumableStream),
+		ttl:     ttl,
+		max:     max,
+	}
+}
+
+// enableStreamResume turns on resumable streams for an HTTP transport unless
+// STREAM_RESUME disables them
+func enableStreamResume() {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("STREAM_RESUME"))) {
+	case "false", "0", "off", "no":
+		return
+	}
+	ttl := time.Duration(parseEnvInt("STREAM_RESUME_TTL", int(defaultStreamResumeTTL/time.Second))) * time.Second
+	streamRegistry = newRunStreamRegistry(ttl, parseEnvInt("STREAM_RESUME_MAX", defaultStreamResumeMax))
+}
+
+// register stores a run's event buffer and returns its new stream ID
+func (r *runStreamRegistry) register(sm *StreamingManager) string {
+	return r.track(sm, nil, true).streamID
+}
+
+// track stores a run's event buffer under its run ID (when run is set) and,
+// when resumable, under a new stream ID
+func (r *runStreamRegistry) track(sm *StreamingManager, run *queueRun, resumable bool) *resumableStream {
+	entry := &resumableStream{manager: sm, tool: sm.toolName, created: time.Now()}
+	if resumable {
+		entry.streamID = newStreamID()
+	}
+	if run != nil {
+		entry.runID = run.ID
+		entry.tool = run.Tool
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked(entry.created)
+	if entry.streamID != "" {
+		for len(r.streams) >= r.max {
+			evictOldest(r.streams)
+		}
+		r.streams[entry.streamID] = entry
+	}
+	if entry.runID != "" {
+		for len(r.runs) >= r.max {
+			evictOldest(r.runs)
+		}
+		r.runs[entry.runID] = entry
+	}
+
+	if entry.streamID != "" {
+		sm.mu.Lock()
+		sm.streamID = entry.streamID
+		sm.mu.Unlock()
+	}
+	return entry
+}
+
+// finish starts the replay window of a completed run
+func (r *runStreamRegistry) finish(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.streams[id]; ok {
+		s.finished = time.Now()
+	}
+}
+
+func (r *runStreamRegistry) finishEntry(entry *resumableStream) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.finished = time.Now()
+}
+
+func (r *runStreamRegistry) get(id string) *StreamingManager {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked(time.Now())
+	if s, ok := r.streams[id]; ok {
+		return s.manager
+	}
+	return nil
+}
+
+func (r *runStreamRegistry) getRun(runID string) *StreamingManager {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked(time.Now())
+	if s, ok := r.runs[runID]; ok {
+		return s.manager
+	}
+	return nil
+}
+
+// LiveRun describes a tracked run for the live stream listing
+type LiveRun struct {
+	RunID    string     `json:"run_id"`
+	Tool     string     `json:"tool"`
+	StreamID string     `json:"stream_id,omitempty"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// listRuns returns tracked runs, newest first
+func (r *runStreamRegistry) listRuns() []LiveRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked(time.Now())
+	runs := make([]LiveRun, 0, len(r.runs))
+	for _, s := range r.runs {
+		run := LiveRun{RunID: s.runID, Tool: s.tool, StreamID: s.streamID, Started: s.created}
+		if !s.finished.IsZero() {
+			finished := s.finished
+			run.Finished = &finished
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.After(runs[j].Started) })
+	return runs
+}
+
+func (r *runStreamRegistry) pruneLocked(now time.Time) {
+	for _, streams := range []map[string]*resumableStream{r.streams, r.runs} {
+		for id, s := range streams {
+			if !s.finished.IsZero() && now.Sub(s.finished) > r.ttl {
+				delete(streams, id)
+			}
+		}
+	}
+}
+
+func evictOldest(streams map[string]*resumableStream) {
+	var oldestID string
+	var oldest time.Time
+	for id, s := range streams {
+		if oldestID == "" || s.created.Before(oldest) {
+			oldestID, oldest = id, s.created
+		}
+	}
+	delete(streams, oldestID)
+}
+
+func newStreamID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ============ Tool Handler Integration ============
+
+type streamSlotKey struct{}
+
+// streamSlot carries a tool call's registered stream from SetupStreaming back
+// to the middleware, which records the result once the handler returns
+type streamSlot struct {
+	entry   *resumableStream
+	manager *StreamingManager
+}
+
+// streamResumeMiddleware lets tool runs register their event buffer and
+// completes it with the tool result
+func streamResumeMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		registry := streamRegistry
+		if registry == nil {
+			return next(ctx, request)
+		}
+
+		slot := &streamSlot{}
+		result, err := next(context.WithValue(ctx, streamSlotKey{}, slot), request)
+		if slot.manager != nil {
+			text, isError := toolResultText(result, err)
+			slot.manager.Finish(text, isError)
+			registry.finishEntry(slot.entry)
+		}
+		return result, err
+	}
+}
+
+// registerRunStream registers a run's event buffer when the call came
+// through streamResumeMiddleware: under its run ID for live streaming and,
+// when resumable, under a stream ID, which it returns (or "")
+func registerRunStream(ctx context.Context, sm *StreamingManager, resumable bool) string {
+	registry := streamRegistry
+	slot, ok := ctx.Value(streamSlotKey{}).(*streamSlot)
+	if registry == nil || !ok || slot.manager != nil {
+		return ""
+	}
+	run := queueRunFromContext(ctx)
+	if run == nil && !resumable {
+		return ""
+	}
+	slot.entry = registry.track(sm, run, resumable)
+	slot.manager = sm
+	return slot.entry.streamID
+}
+
+func toolResultText(result *mcp.CallToolResult, err error) (string, bool) {
+	if err != nil {
+		return err.Error(), true
+	}
+	if result == nil {
+		return "", false
+	}
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n"), result.IsError
+}
+
+// ============ Events Endpoint ============
+
+// streamEventsHandler serves GET <prefix>/<stream_id> as Server-Sent Events,
+// replaying events after Last-Event-ID (header or last_event_id query) and
+// following the run until it finishes
+func streamEventsHandler(registry *runStreamRegistry, prefix string) http.Handler {
+	prefix = normalizeHTTPPath(prefix)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		sm := registry.get(id)
+		if id == "" || sm == nil {
+			http.Error(w, "unknown or expired stream", http.StatusNotFound)
+			return
+		}
+		serveStreamEvents(w, r, sm)
+	})
+}
+
+// liveStreamHandler serves GET <prefix> as a JSON list of tracked runs and
+// GET <prefix>/<run_id> as Server-Sent Events for that run, with the same
+// replay and follow behaviour as the events endpoint
+func liveStreamHandler(registry *runStreamRegistry, prefix string) http.Handler {
+	prefix = normalizeHTTPPath(prefix)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		runID := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if runID == "" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"runs": registry.listRuns()})
+			return
+		}
+		sm := registry.getRun(runID)
+		if sm == nil {
+			http.Error(w, "unknown or expired run", http.StatusNotFound)
+			return
+		}
+		serveStreamEvents(w, r, sm)
+	})
+}
+
+// serveStreamEvents writes a run's events after Last-Event-ID, then follows
+// the run until it finishes or the client goes away
+func serveStreamEvents(w http.ResponseWriter, r *http.Request, sm *StreamingManager) {
+	lastID := int64(0)
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw != "" {
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastID = n
+	}
+
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		events, updated, done := sm.EventsAfter(lastID)
+		for _, event := range events {
+			writeSSEEvent(w, event)
+			lastID = event.ID
+		}
+		if done {
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			flush()
+			return
+		}
+		flush()
+
+		select {
+		case <-updated:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, event StreamEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
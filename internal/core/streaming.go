@@ -1,4 +1,4 @@
-package main
+package core
 
 import (
 	"context"
@@ -61,24 +61,41 @@ const (
 	EventTypeProgress   = "progress"
 	EventTypeToken      = "token"
 	EventTypeTool       = "tool"
+	EventTypeStepStart  = "step_start" // Token stream for a step begins
+	EventTypeStepEnd    = "step_end"   // Token stream for a step ends
+	EventTypeResult     = "result"     // Final tool result, for clients resuming a stream
 )
 
+// isStepBoundary reports whether an event delimits one step's token stream
+func isStepBoundary(eventType string) bool {
+	return eventType == EventTypeStepStart || eventType == EventTypeStepEnd
+}
+
 // StreamingManager handles progress streaming for reasoning operations
 type StreamingManager struct {
 	buffer    []StreamEvent
 	mu        sync.RWMutex
 	startTime time.Time
 	toolName  string
+
+	// Resumption state: event IDs keep increasing across Clear, updated is
+	// closed whenever an event is added, and done is set once the run ends
+	streamID string
+	lastID   int64
+	updated  chan struct{}
+	done     bool
 }
 
 // StreamEvent represents a single streaming event
 type StreamEvent struct {
+	ID          int64     `json:"id"` // Increasing per run; the SSE event ID when resuming
 	Timestamp   time.Time `json:"timestamp"`
 	Type        string    `json:"type"` // thought, evaluation, merge, solution, error, progress
 	NodeID      string    `json:"node_id,omitempty"`
 	Content     string    `json:"content"`
 	Score       float64   `json:"score,omitempty"`
 	Depth       int       `json:"depth,omitempty"`
+	Step        int       `json:"step,omitempty"`
 	TotalNodes  int       `json:"total_nodes,omitempty"`
 	IsSolution  bool      `json:"is_solution,omitempty"`
 	FinalAnswer string    `json:"final_answer,omitempty"`
@@ -91,9 +108,21 @@ func NewStreamingManager(toolName string) *StreamingManager {
 		buffer:    []StreamEvent{},
 		startTime: time.Now(),
 		toolName:  toolName,
+		updated:   make(chan struct{}),
 	}
 }
 
+// appendLocked assigns the next event ID, buffers the event and wakes any
+// resuming readers. The caller holds sm.mu.
+func (sm *StreamingManager) appendLocked(event StreamEvent) int64 {
+	sm.lastID++
+	event.ID = sm.lastID
+	sm.buffer = append(sm.buffer, event)
+	close(sm.updated)
+	sm.updated = make(chan struct{})
+	return event.ID
+}
+
 // AddEvent adds a new event to the stream
 func (sm *StreamingManager) AddEvent(eventType, content string) {
 	sm.mu.Lock()
@@ -105,14 +134,26 @@ func (sm *StreamingManager) AddEvent(eventType, content string) {
 		Content:   content,
 		ElapsedMs: time.Since(sm.startTime).Milliseconds(),
 	}
-	sm.buffer = append(sm.buffer, event)
+	sm.appendLocked(event)
 }
 
 // AddProgressEvent adds a progress update event
 func (sm *StreamingManager) AddProgressEvent(update ProgressUpdate) {
+	sm.addProgressEvent(update)
+}
+
+// addProgressEvent adds a progress update event and returns its ID
+func (sm *StreamingManager) addProgressEvent(update ProgressUpdate) int64 {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	event := progressStreamEvent(update)
+	event.ElapsedMs = time.Since(sm.startTime).Milliseconds()
+	return sm.appendLocked(event)
+}
+
+// progressStreamEvent is the stream event of a progress update
+func progressStreamEvent(update ProgressUpdate) StreamEvent {
 	event := StreamEvent{
 		Timestamp:   time.Now(),
 		Type:        update.Type,
@@ -120,21 +161,25 @@ func (sm *StreamingManager) AddProgressEvent(update ProgressUpdate) {
 		Content:     update.Message,
 		Score:       update.Score,
 		Depth:       update.Depth,
+		Step:        update.Step,
 		TotalNodes:  update.TotalNodes,
 		IsSolution:  update.IsSolution,
 		FinalAnswer: update.FinalAnswer,
-		ElapsedMs:   time.Since(sm.startTime).Milliseconds(),
 	}
 
 	if event.Content == "" && update.Thought != "" {
 		event.Content = update.Thought
 	}
-
-	sm.buffer = append(sm.buffer, event)
+	return event
 }
 
 // AddTokenEvent adds a token streaming event
 func (sm *StreamingManager) AddTokenEvent(token, accumulated string) {
+	sm.addTokenEvent(token)
+}
+
+// addTokenEvent adds a token streaming event and returns its ID
+func (sm *StreamingManager) addTokenEvent(token string) int64 {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -144,7 +189,51 @@ func (sm *StreamingManager) AddTokenEvent(token, accumulated string) {
 		Content:   token,
 		ElapsedMs: time.Since(sm.startTime).Milliseconds(),
 	}
-	sm.buffer = append(sm.buffer, event)
+	return sm.appendLocked(event)
+}
+
+// EventsAfter returns the buffered events with an ID above lastID, a channel
+// closed when the next event arrives, and whether the run has finished
+func (sm *StreamingManager) EventsAfter(lastID int64) ([]StreamEvent, <-chan struct{}, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var events []StreamEvent
+	for _, e := range sm.buffer {
+		if e.ID > lastID {
+			events = append(events, e)
+		}
+	}
+	return events, sm.updated, sm.done
+}
+
+// Finish records the run's final result and marks the stream complete.
+// A failed run's result is recorded as an error event.
+func (sm *StreamingManager) Finish(result string, isError bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.done {
+		return
+	}
+
+	eventType := EventTypeResult
+	if isError {
+		eventType = EventTypeError
+	}
+	sm.appendLocked(StreamEvent{
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Content:   result,
+		ElapsedMs: time.Since(sm.startTime).Milliseconds(),
+	})
+	sm.done = true
+}
+
+// StreamID returns the ID the run's events can be resumed under, or ""
+func (sm *StreamingManager) StreamID() string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.streamID
 }
 
 // GetEvents returns all events
@@ -348,16 +437,18 @@ func formatDuration(ms int64) string {
 
 // StreamingResult wraps a result with its streaming data
 type StreamingResult struct {
-	Result  interface{}      `json:"result"`
-	Stream  []StreamEvent    `json:"stream,omitempty"`
-	Summary StreamingSummary `json:"summary"`
+	StreamID string           `json:"stream_id,omitempty"` // Resume the event stream from <events-path>/<stream_id>
+	Result   interface{}      `json:"result"`
+	Stream   []StreamEvent    `json:"stream,omitempty"`
+	Summary  StreamingSummary `json:"summary"`
 }
 
 // WrapWithStreaming wraps any result with streaming data
 func WrapWithStreaming(result interface{}, sm *StreamingManager, includeEvents bool) StreamingResult {
 	wrapped := StreamingResult{
-		Result:  result,
-		Summary: sm.GetSummary(),
+		StreamID: sm.StreamID(),
+		Result:   result,
+		Summary:  sm.GetSummary(),
 	}
 	if includeEvents {
 		wrapped.Stream = sm.GetEvents()
@@ -442,6 +533,7 @@ func (ls *LiveStream) SendProgress(update ProgressUpdate, startTime time.Time) {
 		NodeID:      update.NodeID,
 		Score:       update.Score,
 		Depth:       update.Depth,
+		Step:        update.Step,
 		TotalNodes:  update.TotalNodes,
 		IsSolution:  update.IsSolution,
 		FinalAnswer: update.FinalAnswer,
@@ -487,9 +579,10 @@ type MCPNotifier struct {
 	mcpServer    *server.MCPServer
 	logger       string
 	streamMode   StreamMode
-	stderrStream bool // Enable real-time stderr output for tokens
-	mcpLogging   bool // Enable MCP logging notifications
-	mcpProgress  bool // Enable MCP progress notifications
+	stderrStream bool   // Enable real-time stderr output for tokens
+	mcpLogging   bool   // Enable MCP logging notifications
+	mcpProgress  bool   // Enable MCP progress notifications
+	streamID     string // Resumable stream ID tagged onto notifications, if any
 }
 
 // NotifierConfig holds configuration for the MCPNotifier
@@ -520,7 +613,25 @@ func (n *MCPNotifier) IsActive() bool {
 
 // SendProgress sends a structured progress notification via MCP logging
 func (n *MCPNotifier) SendProgress(update ProgressUpdate) {
-	if n.mcpServer == nil || !n.mcpLogging || !n.streamMode.ShouldStreamEvents() {
+	n.sendProgress(update, 0)
+}
+
+// sendProgress sends a progress notification tagged with its buffered event ID
+func (n *MCPNotifier) sendProgress(update ProgressUpdate, eventID int64) {
+	// Step boundaries delimit the token stream, so they go out with tokens
+	// as well as with events
+	boundary := isStepBoundary(update.Type)
+	if boundary && n.stderrStream {
+		if update.Type == EventTypeStepStart {
+			fmt.Fprintf(os.Stderr, "\n--- step %d ---\n", update.Step)
+		} else {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+	if n.mcpServer == nil || !n.mcpLogging {
+		return
+	}
+	if !n.streamMode.ShouldStreamEvents() && !(boundary && n.streamMode.ShouldStreamTokens()) {
 		return
 	}
 
@@ -543,6 +654,9 @@ func (n *MCPNotifier) SendProgress(update ProgressUpdate) {
 	if update.Depth > 0 {
 		data["depth"] = update.Depth
 	}
+	if update.Step > 0 {
+		data["step"] = update.Step
+	}
 	if update.TotalNodes > 0 {
 		data["total_nodes"] = update.TotalNodes
 	}
@@ -557,6 +671,10 @@ func (n *MCPNotifier) SendProgress(update ProgressUpdate) {
 		data["tool_input"] = update.ToolInput
 		data["tool_output"] = update.ToolOutput
 	}
+	if update.ToolBudgetRemaining != nil {
+		data["tool_budget_remaining"] = *update.ToolBudgetRemaining
+	}
+	n.tagEvent(data, eventID)
 
 	n.mcpServer.SendLogMessageToClient(n.ctx, mcp.LoggingMessageNotification{
 		Params: mcp.LoggingMessageNotificationParams{
@@ -569,6 +687,11 @@ func (n *MCPNotifier) SendProgress(update ProgressUpdate) {
 
 // SendToken sends a token notification
 func (n *MCPNotifier) SendToken(token string) {
+	n.sendToken(token, 0)
+}
+
+// sendToken sends a token notification tagged with its buffered event ID
+func (n *MCPNotifier) sendToken(token string, eventID int64) {
 	// Write to stderr if enabled (real-time terminal output)
 	if n.stderrStream {
 		fmt.Fprint(os.Stderr, token)
@@ -580,6 +703,7 @@ func (n *MCPNotifier) SendToken(token string) {
 			"type":  EventTypeToken,
 			"token": token,
 		}
+		n.tagEvent(data, eventID)
 
 		n.mcpServer.SendLogMessageToClient(n.ctx, mcp.LoggingMessageNotification{
 			Params: mcp.LoggingMessageNotificationParams{
@@ -591,6 +715,16 @@ func (n *MCPNotifier) SendToken(token string) {
 	}
 }
 
+// tagEvent adds the stream and event IDs a client needs to resume from this
+// notification over the events endpoint
+func (n *MCPNotifier) tagEvent(data map[string]interface{}, eventID int64) {
+	if n.streamID == "" || eventID == 0 {
+		return
+	}
+	data["stream_id"] = n.streamID
+	data["event_id"] = eventID
+}
+
 // SendText sends a simple text notification (for backward compatibility)
 func (n *MCPNotifier) SendText(message string) {
 	if n.mcpServer == nil {
@@ -629,6 +763,7 @@ func (n *MCPNotifier) SendProgressNotification(progressToken interface{}, progre
 
 // StreamingContext holds all streaming components for a tool execution
 type StreamingContext struct {
+	Bus           *EventBus // The run's events; Manager and Notifier subscribe
 	Manager       *StreamingManager
 	Notifier      *MCPNotifier
 	Mode          StreamMode
@@ -644,6 +779,38 @@ func (sc *StreamingContext) SetProgressTotal(total int) {
 	sc.progressToken = fmt.Sprintf("%s-%d", sc.Manager.toolName, time.Now().UnixNano())
 }
 
+// Progress records a progress update in the event buffer and notifies the client
+func (sc *StreamingContext) Progress(update ProgressUpdate) {
+	id := sc.Manager.addProgressEvent(update)
+	sc.Notifier.sendProgress(update, id)
+}
+
+// Token records a streamed token in the event buffer and notifies the client
+func (sc *StreamingContext) Token(token string) {
+	id := sc.Manager.addTokenEvent(token)
+	sc.Notifier.sendToken(token, id)
+}
+
+// record streams an event: it is buffered and sent to the client
+func (sc *StreamingContext) record(e Event) {
+	switch e := e.(type) {
+	case TokenStreamed:
+		sc.Token(e.Token)
+	case progressEvent:
+		sc.Progress(e.progress())
+	}
+}
+
+// StepOn sends a progress notification step for each progress update that
+// matches
+func (sc *StreamingContext) StepOn(match func(ProgressUpdate) bool) {
+	sc.Bus.Subscribe(func(e Event) {
+		if pe, ok := e.(progressEvent); ok && match(pe.progress()) {
+			sc.SendProgressStep(pe.progress().Message)
+		}
+	})
+}
+
 // SendProgressStep sends a progress notification for the current step
 func (sc *StreamingContext) SendProgressStep(message string) {
 	sc.currentStep++
@@ -675,11 +842,19 @@ func SetupStreaming(ctx context.Context, args map[string]interface{}, toolName s
 		MCPProgress:  determineBoolFlag(args, "mcp_progress", "MCP_PROGRESS_STREAM"),
 	}
 
-	return &StreamingContext{
+	bus := eventBusFromContext(ctx)
+	if bus == nil {
+		bus = newRunEventBus(ctx, toolName)
+	}
+	sc := &StreamingContext{
+		Bus:      bus,
 		Manager:  NewStreamingManager(toolName),
 		Notifier: NewMCPNotifier(ctx, toolName, mode, config),
 		Mode:     mode,
 	}
+	sc.Notifier.streamID = registerRunStream(ctx, sc.Manager, mode != StreamModeNone)
+	bus.Subscribe(sc.record)
+	return sc
 }
 
 // determineBoolFlag checks a boolean flag from args then environment variable
@@ -0,0 +1,469 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestStreamingManager_Clear(t *testing.T) {
+	sm := NewStreamingManager("test_tool")
+
+	// Add some events
+	sm.AddEvent("thought", "Test thought 1")
+	sm.AddEvent("thought", "Test thought 2")
+	sm.AddEvent("evaluation", "Test evaluation")
+
+	// Verify events were added
+	events := sm.GetEvents()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	// Clear the buffer
+	sm.Clear()
+
+	// Verify buffer is empty
+	events = sm.GetEvents()
+	if len(events) != 0 {
+		t.Errorf("Expected 0 events after Clear, got %d", len(events))
+	}
+}
+
+func TestStreamingManager_ClearConcurrency(t *testing.T) {
+	sm := NewStreamingManager("test_tool")
+
+	// Add initial events
+	for i := 0; i < 100; i++ {
+		sm.AddEvent("thought", "Test thought")
+	}
+
+	// Verify events were added
+	events := sm.GetEvents()
+	if len(events) != 100 {
+		t.Fatalf("Expected 100 events, got %d", len(events))
+	}
+
+	// Test concurrent clear and add
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	// Goroutine 1: Keep adding events
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				sm.AddEvent("thought", "Concurrent thought")
+				time.Sleep(1 * time.Millisecond)
+			}
+		}
+	}()
+
+	// Goroutine 2: Clear buffer
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(10 * time.Millisecond)
+		sm.Clear()
+		close(done)
+	}()
+
+	wg.Wait()
+
+	// Verify buffer was cleared (may have some events added after clear)
+	eventsAfter := sm.GetEvents()
+	if len(eventsAfter) > 50 {
+		t.Errorf("Expected at most 50 events after concurrent clear, got %d", len(eventsAfter))
+	}
+}
+
+func TestStreamingManager_ClearAndReuse(t *testing.T) {
+	sm := NewStreamingManager("test_tool")
+
+	// First session
+	sm.AddEvent("thought", "Session 1 thought")
+	sm.AddEvent("solution", "Session 1 solution")
+
+	if len(sm.GetEvents()) != 2 {
+		t.Fatalf("Expected 2 events in first session, got %d", len(sm.GetEvents()))
+	}
+
+	// Clear for reuse
+	sm.Clear()
+
+	// Second session
+	sm.AddEvent("thought", "Session 2 thought")
+	sm.AddEvent("evaluation", "Session 2 evaluation")
+	sm.AddEvent("solution", "Session 2 solution")
+
+	if len(sm.GetEvents()) != 3 {
+		t.Fatalf("Expected 3 events in second session, got %d", len(sm.GetEvents()))
+	}
+
+	// Verify content is from second session only
+	events := sm.GetEvents()
+	if events[0].Content != "Session 2 thought" {
+		t.Errorf("Expected 'Session 2 thought', got '%s'", events[0].Content)
+	}
+}
+
+func TestStreamingManager_ClearEmpty(t *testing.T) {
+	sm := NewStreamingManager("test_tool")
+
+	// Clear an empty buffer (should not panic)
+	sm.Clear()
+
+	if len(sm.GetEvents()) != 0 {
+		t.Errorf("Expected 0 events after clearing empty buffer, got %d", len(sm.GetEvents()))
+	}
+}
+
+func TestStreamingManager_ClearMultipleTimes(t *testing.T) {
+	sm := NewStreamingManager("test_tool")
+
+	// Add events
+	sm.AddEvent("thought", "Test thought")
+
+	// Clear multiple times (should not panic)
+	sm.Clear()
+	sm.Clear()
+	sm.Clear()
+
+	if len(sm.GetEvents()) != 0 {
+		t.Errorf("Expected 0 events after multiple clears, got %d", len(sm.GetEvents()))
+	}
+}
+
+func TestEnvGetter_ResetFunctionality(t *testing.T) {
+	// Save original function
+	originalGetter := envGetter
+
+	// Mock envGetter to return a fixed value
+	envGetter = func(key string) string {
+		return "mocked_value"
+	}
+
+	// Verify mock is working
+	result := getEnv("ANY_KEY")
+	if result != "mocked_value" {
+		t.Errorf("Expected 'mocked_value', got '%s'", result)
+	}
+
+	// Reset using the new function
+	resetEnvGetter()
+
+	// Verify it's restored to os.Getenv behavior
+	// (REAL_ENV_TEST_* keys should not exist, so should return empty string)
+	result = getEnv("REAL_ENV_TEST_NONEXISTENT_KEY")
+	if result != "" {
+		t.Errorf("Expected empty string for non-existent env var after reset, got '%s'", result)
+	}
+
+	// Also verify by checking envGetter points to os.Getenv by setting a real env var
+	t.Setenv("REAL_ENV_TEST_KEY", "test_value")
+	result = getEnv("REAL_ENV_TEST_KEY")
+	if result != "test_value" {
+		t.Errorf("Expected 'test_value' from real env var after reset, got '%s'", result)
+	}
+
+	// Restore original getter to prevent affecting other tests
+	envGetter = originalGetter
+}
+
+func TestEnvGetter_IsolationBetweenTests(t *testing.T) {
+	// This test verifies that resetEnvGetter allows tests to be isolated
+	// First, ensure we start with a clean state
+	resetEnvGetter()
+
+	// Simulate Test 1: Mock envGetter
+	envGetter = func(key string) string {
+		if key == "TEST1_VAR" {
+			return "test1_value"
+		}
+		return ""
+	}
+
+	result1 := getEnv("TEST1_VAR")
+	if result1 != "test1_value" {
+		t.Errorf("Test 1 failed: expected 'test1_value', got '%s'", result1)
+	}
+
+	// Simulate test cleanup - reset for next test
+	resetEnvGetter()
+
+	// Simulate Test 2: Should NOT see Test 1's mock
+	// Using t.Setenv to verify we're using real os.Getenv
+	t.Setenv("TEST2_VAR", "test2_value")
+
+	result2 := getEnv("TEST2_VAR")
+	if result2 != "test2_value" {
+		t.Errorf("Test 2 failed: expected 'test2_value' from real env, got '%s'", result2)
+	}
+
+	// Verify Test 1's mock is gone
+	result1After := getEnv("TEST1_VAR")
+	if result1After != "" {
+		t.Errorf("Test 2 isolation failed: Test 1's mock still active, got '%s'", result1After)
+	}
+
+	resetEnvGetter()
+}
+
+// tokenStreamProvider streams a two-step sequential run one token at a time
+type tokenStreamProvider struct {
+	calls int
+}
+
+func (p *tokenStreamProvider) Name() string { return "tokens" }
+
+func (p *tokenStreamProvider) Chat(ctx context.Context, messages []ChatMessage, opts ChatOptions) (string, error) {
+	return p.ChatStream(ctx, messages, opts, func(string) {})
+}
+
+func (p *tokenStreamProvider) SupportsStreaming() bool { return true }
+
+func (p *tokenStreamProvider) ChatStream(_ context.Context, _ []ChatMessage, _ ChatOptions, onToken TokenCallback) (string, error) {
+	p.calls++
+	response := `{"thought_number": 1, "total_thoughts": 2, "thought": "first", "next_thought_needed": true}`
+	if p.calls > 1 {
+		response = `{"thought_number": 2, "total_thoughts": 2, "thought": "second", "next_thought_needed": false, "final_answer": "done"}`
+	}
+	for _, token := range strings.SplitAfter(response, ",") {
+		onToken(token)
+	}
+	return response, nil
+}
+
+func TestSequential_StepBoundariesAroundTokens(t *testing.T) {
+	sm := NewStreamingManager("sequential_thinking")
+	client := &SequentialClient{provider: &tokenStreamProvider{}}
+	client.SetProgressCallback(sm.AddProgressEvent)
+	client.SetTokenCallback(func(token string) { sm.AddTokenEvent(token, "") })
+	client.SetEnableStreaming(true)
+
+	if _, err := client.Think(context.Background(), "Think twice", 5); err != nil {
+		t.Fatalf("Think failed: %v", err)
+	}
+
+	// Every token must fall between the start and end of its own step
+	step := 0
+	for _, e := range sm.GetEvents() {
+		switch e.Type {
+		case EventTypeStepStart:
+			if step != 0 || e.Step == 0 {
+				t.Fatalf("Unexpected step_start %+v inside step %d", e, step)
+			}
+			step = e.Step
+		case EventTypeStepEnd:
+			if e.Step != step {
+				t.Fatalf("step_end %d does not close step %d", e.Step, step)
+			}
+			step = 0
+		case EventTypeToken:
+			if step == 0 {
+				t.Fatalf("Token %q outside a step", e.Content)
+			}
+		}
+	}
+
+	var starts []int
+	for _, e := range sm.GetEvents() {
+		if e.Type == EventTypeStepStart {
+			starts = append(starts, e.Step)
+		}
+	}
+	if len(starts) != 2 || starts[0] != 1 || starts[1] != 2 {
+		t.Errorf("Expected step_start events for steps 1 and 2, got %v", starts)
+	}
+
+	// Without token streaming there is nothing to delimit
+	plain := NewStreamingManager("sequential_thinking")
+	client = &SequentialClient{provider: &tokenStreamProvider{}}
+	client.SetProgressCallback(plain.AddProgressEvent)
+	if _, err := client.Think(context.Background(), "Think twice", 5); err != nil {
+		t.Fatalf("Think failed: %v", err)
+	}
+	for _, e := range plain.GetEvents() {
+		if isStepBoundary(e.Type) {
+			t.Errorf("Expected no step boundaries without token streaming, got %+v", e)
+		}
+	}
+}
+
+func TestStreamEventsHandler_ResumesFromLastEventID(t *testing.T) {
+	registry := newRunStreamRegistry(time.Minute, 10)
+	sm := NewStreamingManager("graph_of_thoughts")
+	id := registry.register(sm)
+	sm.AddEvent("thought", "first")
+	sm.AddEvent("thought", "second")
+	sm.AddEvent("thought", "third")
+
+	srv := httptest.NewServer(streamEventsHandler(registry, "/events"))
+	defer srv.Close()
+
+	body := make(chan string, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events/"+id, nil)
+		req.Header.Set("Last-Event-ID", "2")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	// The reader follows the live run until it finishes
+	time.Sleep(50 * time.Millisecond)
+	sm.AddEvent("thought", "fourth")
+	sm.Finish(`{"answer": 42}`, false)
+
+	var got string
+	select {
+	case got = <-body:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the stream to end")
+	}
+	if strings.Contains(got, "first") || strings.Contains(got, "second") {
+		t.Errorf("Expected events up to Last-Event-ID to be skipped, got %q", got)
+	}
+	for _, want := range []string{"id: 3\nevent: thought\n", "fourth", "id: 5\nevent: result\n", "event: end"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in stream, got %q", want, got)
+		}
+	}
+
+	for path, status := range map[string]int{
+		"/events/unknown":                         http.StatusNotFound,
+		"/events/" + id + "?last_event_id=latest": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("GET %s: expected %d, got %d", path, status, resp.StatusCode)
+		}
+	}
+}
+
+func TestStreamResumeMiddleware_RegistersAndFinishes(t *testing.T) {
+	streamRegistry = newRunStreamRegistry(time.Minute, 10)
+	defer func() { streamRegistry = nil }()
+
+	var streamID string
+	handler := streamResumeMiddleware(func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sc := SetupStreaming(ctx, map[string]interface{}{"stream_mode": "events"}, "reflexion")
+		sc.Progress(ProgressUpdate{Type: "thought", Message: "thinking"})
+		streamID = sc.Manager.StreamID()
+		return mcp.NewToolResultText("final answer"), nil
+	})
+	if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sm := streamRegistry.get(streamID)
+	if streamID == "" || sm == nil {
+		t.Fatalf("Expected the run to be registered, got stream %q", streamID)
+	}
+	events, _, done := sm.EventsAfter(0)
+	if !done || len(events) != 2 || events[1].Type != EventTypeResult || events[1].Content != "final answer" {
+		t.Errorf("Expected the progress event then the tool result, got %+v (done=%v)", events, done)
+	}
+
+	// Runs without streaming have nothing to resume
+	handler = streamResumeMiddleware(func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if sc := SetupStreaming(ctx, map[string]interface{}{"stream_mode": "none"}, "reflexion"); sc.Manager.StreamID() != "" {
+			t.Error("Expected no stream ID without streaming")
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+	handler(context.Background(), mcp.CallToolRequest{})
+	if len(streamRegistry.streams) != 1 {
+		t.Errorf("Expected only the streaming run registered, got %d", len(streamRegistry.streams))
+	}
+}
+
+func TestRunStreamRegistry_Pruning(t *testing.T) {
+	registry := newRunStreamRegistry(time.Millisecond, 2)
+	finished := registry.register(NewStreamingManager("a"))
+	registry.finish(finished)
+	time.Sleep(5 * time.Millisecond)
+	if registry.get(finished) != nil {
+		t.Error("Expected a finished stream to expire after the TTL")
+	}
+
+	first := registry.register(NewStreamingManager("b"))
+	time.Sleep(time.Millisecond)
+	registry.register(NewStreamingManager("c"))
+	registry.register(NewStreamingManager("d"))
+	if registry.get(first) != nil || len(registry.streams) != 2 {
+		t.Errorf("Expected the oldest stream evicted at the cap, got %d streams", len(registry.streams))
+	}
+}
+
+func TestLiveStreamHandler_FollowsRunByID(t *testing.T) {
+	streamRegistry = newRunStreamRegistry(time.Minute, 10)
+	defer func() { streamRegistry = nil }()
+
+	var runID string
+	handler := streamResumeMiddleware(queueRunMiddleware(func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sc := SetupStreaming(ctx, map[string]interface{}{"stream_mode": "none"}, "dialectic_reason")
+		sc.Progress(ProgressUpdate{Type: "thesis", Message: "Cats are better"})
+		runID = queueRunFromContext(ctx).ID
+		return mcp.NewToolResultText("synthesis"), nil
+	}))
+	handler(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Name: "dialectic_reason"}})
+
+	if len(streamRegistry.streams) != 0 {
+		t.Errorf("Expected a non-streaming run not to be resumable, got %d streams", len(streamRegistry.streams))
+	}
+
+	srv := httptest.NewServer(liveStreamHandler(streamRegistry, "/stream"))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream/" + runID)
+	if err != nil {
+		t.Fatalf("GET run stream: %v", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{"Cats are better", "event: result\n", "synthesis", "event: end"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("Expected %q in run stream, got %q", want, b)
+		}
+	}
+
+	resp, err = http.Get(srv.URL + "/stream")
+	if err != nil {
+		t.Fatalf("GET run list: %v", err)
+	}
+	b, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(b), `"run_id":"`+runID+`"`) || !strings.Contains(string(b), `"tool":"dialectic_reason"`) || !strings.Contains(string(b), `"finished"`) {
+		t.Errorf("Expected the finished run listed, got %s", b)
+	}
+
+	resp, err = http.Get(srv.URL + "/stream/run-unknown")
+	if err != nil {
+		t.Fatalf("GET unknown run: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown run, got %d", resp.StatusCode)
+	}
+}
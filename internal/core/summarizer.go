@@ -0,0 +1,206 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"reasoning-tools/utils"
+)
+
+// ============ History Summarization ============
+//
+// Long reasoning histories are compressed instead of cut short: once a
+// history is over its MaxHistoryTokens, the older part is folded into a
+// rolling summary written by the LLM and the latest part is kept word for
+// word. Reflexion summarizes the older turns of an attempt's conversation,
+// GoT the older steps of the paths it shows the model and dialectic the
+// older rounds. Summaries are cached by the items they cover, so a longer
+// history rolls the summary of its prefix forward instead of starting over.
+// When no summary can be written, each older item is truncated instead.
+
+// defaultMaxHistoryTokens is the history size that starts summarization
+const defaultMaxHistoryTokens = 6000
+
+// historySummaryPrompt asks for a summary of the older part of a history,
+// rolling an earlier summary forward
+const historySummaryPrompt = `You condense the earlier part of a reasoning history so it can be continued from notes.
+Keep every result, number, tool output, decision, rejected idea and open question later steps may rely on. Merge the summary so far, if any, with the new items. Respond with dense bullet points only.`
+
+// HistorySummaryUsage reports the history summaries of a run
+type HistorySummaryUsage struct {
+	HistorySummaries       int `json:"history_summaries,omitempty"`        // Summaries written of older history
+	HistorySummaryFailures int `json:"history_summary_failures,omitempty"` // Older history truncated instead
+}
+
+// historySummarizer compresses a strategy's histories to maxTokens
+type historySummarizer struct {
+	provider  Provider
+	maxTokens int // 0 = never summarize
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]string // Summary by the items it covers
+	usage HistorySummaryUsage
+}
+
+func newHistorySummarizer(provider Provider, maxTokens int) *historySummarizer {
+	return &historySummarizer{provider: provider, maxTokens: maxTokens, cache: make(map[[sha256.Size]byte]string)}
+}
+
+// summarizeHistory asks provider to fold items into the summary so far
+func summarizeHistory(ctx context.Context, provider Provider, previous string, items []string) (string, error) {
+	var sb strings.Builder
+	if previous != "" {
+		fmt.Fprintf(&sb, "Summary so far:\n%s\n\n", previous)
+	}
+	sb.WriteString("New items:\n")
+	for _, item := range items {
+		fmt.Fprintf(&sb, "%s\n\n", item)
+	}
+	summary, err := provider.Chat(ctx, []ChatMessage{
+		{Role: "system", Content: historySummaryPrompt},
+		{Role: "user", Content: sb.String()},
+	}, ChatOptions{Temperature: 0.2, MaxTokens: 800})
+	if err != nil {
+		return "", err
+	}
+	if summary = strings.TrimSpace(utils.StripChainOfThought(summary)); summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
+
+// summarize returns a summary of items, rolling forward the cached summary of
+// their longest summarized prefix
+func (s *historySummarizer) summarize(ctx context.Context, items []string) string {
+	keys := make([][sha256.Size]byte, len(items))
+	h := sha256.New()
+	for i, item := range items {
+		h.Write([]byte(item))
+		h.Write([]byte{0})
+		copy(keys[i][:], h.Sum(nil))
+	}
+
+	s.mu.Lock()
+	start, previous := 0, ""
+	for k := len(items); k > 0; k-- {
+		if summary, ok := s.cache[keys[k-1]]; ok {
+			start, previous = k, summary
+			break
+		}
+	}
+	s.mu.Unlock()
+	if start == len(items) {
+		return previous
+	}
+
+	summary, err := summarizeHistory(ctx, s.provider, previous, items[start:])
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.usage.HistorySummaryFailures++
+		fmt.Fprintf(os.Stderr, "[WARNING] history summary failed, truncating %d items instead: %v\n", len(items)-start, err)
+		truncated := make([]string, 0, len(items)-start+1)
+		if previous != "" {
+			truncated = append(truncated, previous)
+		}
+		for _, item := range items[start:] {
+			truncated = append(truncated, utils.TruncateStr(item, 200))
+		}
+		return strings.Join(truncated, "\n")
+	}
+	s.usage.HistorySummaries++
+	s.cache[keys[len(items)-1]] = summary
+	return summary
+}
+
+// recentFrom returns the index from which items fit half the history budget,
+// keeping at least the last item. ok is false when everything fits the whole
+// budget and nothing needs summarizing.
+func (s *historySummarizer) recentFrom(tokens []int) (from int, ok bool) {
+	total := 0
+	for _, n := range tokens {
+		total += n
+	}
+	if s == nil || s.maxTokens <= 0 || total <= s.maxTokens {
+		return 0, false
+	}
+	from, kept := len(tokens)-1, tokens[len(tokens)-1]
+	for from > 0 && kept+tokens[from-1] <= s.maxTokens/2 {
+		from--
+		kept += tokens[from]
+	}
+	return from, from > 0
+}
+
+// compactSteps returns steps, with the older ones replaced by one summary
+// line when they are over the budget. label names the steps in that line,
+// e.g. "Steps" or "Rounds".
+func (s *historySummarizer) compactSteps(ctx context.Context, steps []string, label string) []string {
+	tokens := make([]int, len(steps))
+	for i, step := range steps {
+		tokens[i] = estimateTokens(step)
+	}
+	from, ok := s.recentFrom(tokens)
+	if !ok {
+		return steps
+	}
+	summary := s.summarize(ctx, steps[:from])
+	compacted := []string{fmt.Sprintf("(%s 1-%d, summarized) %s", label, from, summary)}
+	return append(compacted, steps[from:]...)
+}
+
+// compactMessages returns a conversation to send in place of messages: the
+// leading system messages and the task, with a summary of the older turns
+// appended to the task, then the latest turns from an assistant turn on, so
+// a tool reply never loses its call. messages itself is left whole, so the
+// next call rolls the summary forward.
+func (s *historySummarizer) compactMessages(ctx context.Context, messages []ChatMessage) []ChatMessage {
+	head := 0
+	for head < len(messages) && messages[head].Role == "system" {
+		head++
+	}
+	head++ // The task
+	if head >= len(messages) {
+		return messages
+	}
+	turns := messages[head:]
+	tokens := make([]int, len(turns))
+	for i := range turns {
+		tokens[i] = estimateMessageTokens(turns[i : i+1])
+	}
+	from, ok := s.recentFrom(tokens)
+	for ok && from > 0 && turns[from].Role != "assistant" {
+		from--
+	}
+	if !ok || from == 0 {
+		return messages
+	}
+
+	older := make([]string, from)
+	for i, m := range turns[:from] {
+		older[i] = fmt.Sprintf("%s: %s", m.Role, m.Content)
+		for _, tc := range m.ToolCalls {
+			older[i] += fmt.Sprintf(" [called %s(%s)]", tc.Function.Name, tc.Function.Arguments)
+		}
+	}
+	summary := s.summarize(ctx, older)
+
+	compacted := append([]ChatMessage(nil), messages[:head]...)
+	task := &compacted[head-1]
+	task.Content += fmt.Sprintf("\n\n[Summary of your first %d messages of reasoning]\n%s", from, summary)
+	return append(compacted, turns[from:]...)
+}
+
+// report returns the run's history summary counts
+func (s *historySummarizer) report() HistorySummaryUsage {
+	if s == nil {
+		return HistorySummaryUsage{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
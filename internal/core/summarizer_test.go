@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// summaryProvider writes a summary naming how many items it folded in and
+// records the summary requests it gets; err fails them instead
+type summaryProvider struct {
+	mu       sync.Mutex
+	err      error
+	requests []string
+}
+
+func (p *summaryProvider) Name() string { return "summary" }
+
+func (p *summaryProvider) Chat(_ context.Context, messages []ChatMessage, _ ChatOptions) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if messages[0].Content != historySummaryPrompt {
+		return "ok", nil
+	}
+	p.requests = append(p.requests, messages[1].Content)
+	if p.err != nil {
+		return "", p.err
+	}
+	return fmt.Sprintf("- summary %d: tried 17 * 23 = 391", len(p.requests)), nil
+}
+
+func TestHistorySummarizer_CompactMessages(t *testing.T) {
+	provider := &summaryProvider{}
+	s := newHistorySummarizer(provider, 1500)
+
+	messages := reflexionConversation(20)
+	compacted := s.compactMessages(context.Background(), messages)
+	if n := estimateMessageTokens(compacted); n > 1500 {
+		t.Errorf("Expected at most 1500 tokens, got %d", n)
+	}
+	if compacted[0].Role != "system" || !strings.HasPrefix(compacted[1].Content, "Problem: What is 17 * 23?") || !strings.Contains(compacted[1].Content, "summary 1: tried 17 * 23 = 391") {
+		t.Errorf("Expected the summary appended to the task, got %q", compacted[1].Content)
+	}
+	if compacted[2].Role != "assistant" || compacted[len(compacted)-1].Content != messages[len(messages)-1].Content {
+		t.Errorf("Expected the latest turns from an assistant turn on, got %+v", compacted[2])
+	}
+	if messages[1].Content != "Problem: What is 17 * 23?" {
+		t.Errorf("Expected the conversation itself to stay whole, got %q", messages[1].Content)
+	}
+
+	// The next step rolls the first summary forward instead of starting over
+	messages = append(messages, reflexionConversation(4)[2:]...)
+	s.compactMessages(context.Background(), messages)
+	if len(provider.requests) != 2 || !strings.Contains(provider.requests[1], "Summary so far:\n- summary 1") || strings.Contains(provider.requests[1], "Thought 5:") {
+		t.Errorf("Expected the second summary to extend the first, got %d requests", len(provider.requests))
+	}
+	if usage := s.report(); usage.HistorySummaries != 2 || usage.HistorySummaryFailures != 0 {
+		t.Errorf("Expected 2 summaries, got %+v", usage)
+	}
+
+	// A short conversation is sent as it is
+	short := reflexionConversation(2)
+	if compacted := s.compactMessages(context.Background(), short); len(compacted) != len(short) {
+		t.Errorf("Expected a short conversation to be unchanged, got %d messages", len(compacted))
+	}
+}
+
+func TestHistorySummarizer_CompactSteps(t *testing.T) {
+	var steps []string
+	for i := 1; i <= 12; i++ {
+		steps = append(steps, fmt.Sprintf("%d. (0.80) %s", i, strings.Repeat("multiply the tens ", 80)))
+	}
+	s := newHistorySummarizer(&summaryProvider{}, 2000)
+	compacted := s.compactSteps(context.Background(), steps, "Steps")
+	if len(compacted) >= len(steps) || !strings.HasPrefix(compacted[0], fmt.Sprintf("(Steps 1-%d, summarized) - summary 1", len(steps)-len(compacted)+1)) {
+		t.Fatalf("Expected the older steps summarized, got %q", compacted[0])
+	}
+	if compacted[len(compacted)-1] != steps[len(steps)-1] {
+		t.Errorf("Expected the latest step word for word")
+	}
+
+	// A sibling path sharing the summarized steps reuses their summary
+	sibling := append(steps[:len(steps)-1:len(steps)-1], "12. (0.60) "+strings.Repeat("add the units ", 80))
+	s.compactSteps(context.Background(), sibling, "Steps")
+	if usage := s.report(); usage.HistorySummaries != 1 {
+		t.Errorf("Expected the cached summary to be reused, got %+v", usage)
+	}
+
+	// Without a summary, the older steps are truncated
+	failing := newHistorySummarizer(&summaryProvider{err: errors.New("rate limited")}, 2000)
+	compacted = failing.compactSteps(context.Background(), steps, "Steps")
+	if !strings.Contains(compacted[0], "1. (0.80) multiply") || len(compacted[0]) > len(steps[0])*len(steps)/2 {
+		t.Errorf("Expected truncated older steps, got %q", compacted[0])
+	}
+	if usage := failing.report(); usage.HistorySummaryFailures != 1 {
+		t.Errorf("Expected the failure to be counted, got %+v", usage)
+	}
+
+	// 0 turns summarization off
+	if off := newHistorySummarizer(&summaryProvider{}, 0).compactSteps(context.Background(), steps, "Steps"); len(off) != len(steps) {
+		t.Errorf("Expected max_history_tokens 0 to keep every step")
+	}
+}
+
+func TestDialecticBuildContext_KeepsFullRounds(t *testing.T) {
+	d := NewDialecticalReasoner(&summaryProvider{}, DefaultDialecticConfig())
+	d.history = newHistorySummarizer(d.provider, d.config.MaxHistoryTokens)
+	thesis := "The product is 391 because " + strings.Repeat("17 * 20 = 340 and 17 * 3 = 51, ", 10)
+	built := d.buildContext(context.Background(), []DialecticStep{{
+		Round:      1,
+		Thesis:     Claim{Content: thesis},
+		Antithesis: Claim{Content: "Check 17 * 3."},
+		Synthesis:  Claim{Content: "391"},
+	}})
+	if !strings.Contains(built, thesis) {
+		t.Errorf("Expected the whole thesis in the context, got %q", built)
+	}
+}
@@ -0,0 +1,2251 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ============ Symbolic Math Tool ============
+//
+// symbolic_math is a small embedded computer algebra system for the work
+// the numeric calculator cannot do: simplifying and expanding expressions,
+// differentiating, solving equations and linear systems, and matrix
+// algebra. Numbers are exact rationals, and irrational results stay
+// symbolic (sqrt(2), pi) with a decimal approximation alongside. Like
+// string_ops it takes 'operation:argument'.
+
+type symKind int
+
+const (
+	symNum symKind = iota
+	symVar
+	symAdd
+	symMul
+	symPow
+	symFunc
+)
+
+// sym is an expression tree node. Nodes are never modified once built.
+type sym struct {
+	kind symKind
+	num  *big.Rat // symNum
+	name string   // symVar and symFunc
+	args []*sym   // symAdd and symMul operands, symPow base and exponent, symFunc argument
+}
+
+const (
+	maxSymTerms       = 2000 // Terms an expansion may produce
+	maxSymPowExp      = 64   // Largest power of a sum expanded
+	maxSymBits        = 4096 // Largest exact number kept, in bits
+	maxPolyDegree     = 30
+	maxSymbolicMatrix = 4 // Symbolic determinants and inverses are done by cofactors
+	numericSolveRange = 100.0
+)
+
+// symFunctions are the functions the parser knows. sqrt and exp are
+// rewritten as powers, and log means the natural logarithm.
+var symFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "asin": true, "acos": true, "atan": true,
+	"sinh": true, "cosh": true, "tanh": true, "ln": true, "log": true, "log10": true,
+	"abs": true, "sqrt": true, "exp": true,
+}
+
+var (
+	symOne  = big.NewRat(1, 1)
+	symHalf = big.NewRat(1, 2)
+)
+
+func symRat(r *big.Rat) *sym       { return &sym{kind: symNum, num: r} }
+func symInt(n int64) *sym          { return symRat(big.NewRat(n, 1)) }
+func symVariable(name string) *sym { return &sym{kind: symVar, name: name} }
+func symSum(args ...*sym) *sym     { return &sym{kind: symAdd, args: args} }
+func symProduct(args ...*sym) *sym {
+	return &sym{kind: symMul, args: args}
+}
+func symPower(base, exp *sym) *sym       { return &sym{kind: symPow, args: []*sym{base, exp}} }
+func symCall(name string, arg *sym) *sym { return &sym{kind: symFunc, name: name, args: []*sym{arg}} }
+func symNeg(e *sym) *sym                 { return symProduct(symInt(-1), e) }
+
+func (e *sym) isNum(r *big.Rat) bool { return e.kind == symNum && e.num.Cmp(r) == 0 }
+func (e *sym) isZero() bool          { return e.kind == symNum && e.num.Sign() == 0 }
+
+// symDivByZero marks an expression that divides by zero. Simplification
+// keeps it over anything built on it, so 0*(1/0) or 1/0 - 1/0 never fold
+// to a number, and the operations report it as an error.
+func symDivByZero() *sym { return symPower(symInt(0), symInt(-1)) }
+
+func (e *sym) isDivByZero() bool {
+	return e.kind == symPow && e.args[0].isZero() && e.args[1].kind == symNum && e.args[1].num.Sign() < 0
+}
+
+func anyDivByZero(args []*sym) bool {
+	for _, a := range args {
+		if a.isDivByZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// errDivByZero is returned for results that divide by zero
+var errDivByZero = errors.New("division by zero")
+
+// checkDivByZero returns e, or errDivByZero when e divides by zero
+func checkDivByZero(e *sym) (*sym, error) {
+	if e.isDivByZero() {
+		return nil, errDivByZero
+	}
+	return e, nil
+}
+
+func (e *sym) isVar(name string) bool {
+	return e.kind == symVar && e.name == name
+}
+
+// ============ Parsing ============
+
+type symParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseSym parses an expression such as '3x^2 - sin(x)/2'. Multiplication
+// may be implicit (2x, x(x+1)) and ** is accepted for ^.
+func parseSym(s string) (*sym, error) {
+	tokens, err := tokenizeSym(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	p := &symParser{tokens: tokens}
+	e, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+func tokenizeSym(s string) ([]string, error) {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			// Scientific notation (1e-3, 2.5E+4) is one number, not a
+			// product with Euler's e
+			if j < len(runes) && (runes[j] == 'e' || runes[j] == 'E') {
+				k := j + 1
+				if k < len(runes) && (runes[k] == '+' || runes[k] == '-') {
+					k++
+				}
+				if k < len(runes) && unicode.IsDigit(runes[k]) {
+					for k < len(runes) && unicode.IsDigit(runes[k]) {
+						k++
+					}
+					j = k
+				}
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			tokens = append(tokens, "^")
+			i += 2
+		case strings.ContainsRune("+-*/^()", r):
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+func (p *symParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *symParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *symParser) expr() (*sym, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.next()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if op == "-" {
+			right = symNeg(right)
+		}
+		left = symSum(left, right)
+	}
+	return left, nil
+}
+
+func (p *symParser) term() (*sym, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		switch {
+		case tok == "*" || tok == "/":
+			p.next()
+			right, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			if tok == "/" {
+				right = symPower(right, symInt(-1))
+			}
+			left = symProduct(left, right)
+		case tok == "(" || (tok != "" && !strings.Contains("+-*/^)", tok)):
+			// Implicit multiplication
+			right, err := p.power()
+			if err != nil {
+				return nil, err
+			}
+			left = symProduct(left, right)
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *symParser) unary() (*sym, error) {
+	switch p.peek() {
+	case "-":
+		p.next()
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return symNeg(e), nil
+	case "+":
+		p.next()
+		return p.unary()
+	}
+	return p.power()
+}
+
+func (p *symParser) power() (*sym, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == "^" {
+		p.next()
+		exp, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return symPower(base, exp), nil
+	}
+	return base, nil
+}
+
+func (p *symParser) primary() (*sym, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case tok == "(":
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return e, nil
+	case unicode.IsDigit(rune(tok[0])) || tok[0] == '.':
+		if _, exp, ok := strings.Cut(strings.ToLower(tok), "e"); ok {
+			// Numbers are exact, so the exponent is bounded like their size
+			// (10^n takes about 3.32n bits)
+			if n, err := strconv.Atoi(exp); err != nil || abs64(int64(n))*332/100 > maxSymBits {
+				return nil, fmt.Errorf("exponent of %q out of range", tok)
+			}
+		}
+		r, ok := new(big.Rat).SetString(tok)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", tok)
+		}
+		return symRat(r), nil
+	case unicode.IsLetter(rune(tok[0])) || tok[0] == '_':
+		name := strings.ToLower(tok)
+		if !symFunctions[name] || p.peek() != "(" {
+			return symVariable(tok), nil
+		}
+		p.next()
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ')' after %s(", name)
+		}
+		switch name {
+		case "sqrt":
+			return symPower(arg, symRat(symHalf)), nil
+		case "exp":
+			return symPower(symVariable("e"), arg), nil
+		case "log":
+			name = "ln"
+		}
+		return symCall(name, arg), nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
+
+// ============ Simplification ============
+
+// simplifySym puts an expression in canonical form: like terms and equal
+// bases are combined, numbers are folded and terms are sorted
+func simplifySym(e *sym) *sym {
+	switch e.kind {
+	case symAdd, symMul:
+		args := make([]*sym, len(e.args))
+		for i, a := range e.args {
+			args[i] = simplifySym(a)
+		}
+		if e.kind == symAdd {
+			return simplifyAdd(args)
+		}
+		return simplifyMul(args)
+	case symPow:
+		return simplifyPow(simplifySym(e.args[0]), simplifySym(e.args[1]))
+	case symFunc:
+		return simplifyFunc(e.name, simplifySym(e.args[0]))
+	}
+	return e
+}
+
+// splitCoef separates a term's numeric coefficient; rest is nil for numbers
+func splitCoef(e *sym) (*big.Rat, *sym) {
+	switch {
+	case e.kind == symNum:
+		return e.num, nil
+	case e.kind == symMul && e.args[0].kind == symNum:
+		if len(e.args) == 2 {
+			return e.args[0].num, e.args[1]
+		}
+		return e.args[0].num, symProduct(e.args[1:]...)
+	}
+	return symOne, e
+}
+
+// withCoef rebuilds a canonical term from a coefficient and the rest
+func withCoef(coef *big.Rat, rest *sym) *sym {
+	if rest == nil {
+		return symRat(coef)
+	}
+	if coef.Cmp(symOne) == 0 {
+		return rest
+	}
+	factors := []*sym{symRat(coef)}
+	if rest.kind == symMul {
+		factors = append(factors, rest.args...)
+	} else {
+		factors = append(factors, rest)
+	}
+	return symProduct(factors...)
+}
+
+func simplifyAdd(args []*sym) *sym {
+	if anyDivByZero(args) {
+		return symDivByZero()
+	}
+	type like struct {
+		coef *big.Rat
+		rest *sym
+	}
+	constant := new(big.Rat)
+	groups := make(map[string]*like)
+	var order []string
+	var add func(a *sym)
+	add = func(a *sym) {
+		if a.kind == symAdd {
+			for _, t := range a.args {
+				add(t)
+			}
+			return
+		}
+		coef, rest := splitCoef(a)
+		if rest == nil {
+			constant.Add(constant, coef)
+			return
+		}
+		key := rest.String()
+		if g, ok := groups[key]; ok {
+			g.coef.Add(g.coef, coef)
+			return
+		}
+		groups[key] = &like{coef: new(big.Rat).Set(coef), rest: rest}
+		order = append(order, key)
+	}
+	for _, a := range args {
+		add(a)
+	}
+
+	var terms []*sym
+	for _, key := range order {
+		if g := groups[key]; g.coef.Sign() != 0 {
+			terms = append(terms, withCoef(g.coef, g.rest))
+		}
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		di, dj := symDegree(terms[i]), symDegree(terms[j])
+		if di != dj {
+			return di > dj
+		}
+		_, ri := splitCoef(terms[i])
+		_, rj := splitCoef(terms[j])
+		return ri.String() < rj.String()
+	})
+	if constant.Sign() != 0 {
+		terms = append(terms, symRat(constant))
+	}
+	switch len(terms) {
+	case 0:
+		return symInt(0)
+	case 1:
+		return terms[0]
+	}
+	return symSum(terms...)
+}
+
+// symDegree is a term's total degree in its variables, for ordering terms
+func symDegree(e *sym) float64 {
+	switch e.kind {
+	case symVar:
+		if e.name == "e" || e.name == "pi" {
+			return 0
+		}
+		return 1
+	case symPow:
+		if e.args[1].kind == symNum {
+			f, _ := e.args[1].num.Float64()
+			return f * symDegree(e.args[0])
+		}
+	case symMul:
+		total := 0.0
+		for _, f := range e.args {
+			total += symDegree(f)
+		}
+		return total
+	}
+	return 0
+}
+
+func flattenMul(args []*sym) []*sym {
+	var out []*sym
+	for _, a := range args {
+		if a.kind == symMul {
+			out = append(out, flattenMul(a.args)...)
+		} else {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// factorOrder sorts numeric surds first, then variables, functions and sums
+func factorOrder(f *sym) string {
+	base := f
+	if f.kind == symPow {
+		base = f.args[0]
+	}
+	rank := map[symKind]string{symNum: "0", symVar: "1", symFunc: "2", symPow: "2", symMul: "3", symAdd: "3"}[base.kind]
+	return rank + base.String()
+}
+
+func simplifyMul(args []*sym) *sym {
+	factors := flattenMul(args)
+	for pass := 0; ; pass++ {
+		if anyDivByZero(factors) {
+			return symDivByZero()
+		}
+		type group struct{ base, exp *sym }
+		coef := big.NewRat(1, 1)
+		groups := make(map[string]*group)
+		var order []string
+		for _, f := range factors {
+			if f.kind == symNum {
+				coef.Mul(coef, f.num)
+				continue
+			}
+			base, exp := f, symInt(1)
+			if f.kind == symPow {
+				base, exp = f.args[0], f.args[1]
+			}
+			key := base.String()
+			if g, ok := groups[key]; ok {
+				g.exp = simplifyAdd([]*sym{g.exp, exp})
+				continue
+			}
+			groups[key] = &group{base: base, exp: exp}
+			order = append(order, key)
+		}
+		if coef.Sign() == 0 {
+			return symInt(0)
+		}
+
+		rebuilt := []*sym{symRat(coef)}
+		changed := false
+		for _, key := range order {
+			p := simplifyPow(groups[key].base, groups[key].exp)
+			if p.kind == symNum || p.kind == symMul {
+				changed = true
+			}
+			rebuilt = append(rebuilt, p)
+		}
+		if anyDivByZero(rebuilt) {
+			return symDivByZero()
+		}
+		factors = flattenMul(rebuilt)
+		if changed && pass < 2 {
+			continue
+		}
+
+		coef = big.NewRat(1, 1)
+		var rest []*sym
+		for _, f := range factors {
+			if f.kind == symNum {
+				coef.Mul(coef, f.num)
+			} else {
+				rest = append(rest, f)
+			}
+		}
+		if coef.Sign() == 0 {
+			return symInt(0)
+		}
+		sort.SliceStable(rest, func(i, j int) bool { return factorOrder(rest[i]) < factorOrder(rest[j]) })
+		switch {
+		case len(rest) == 0:
+			return symRat(coef)
+		case len(rest) == 1 && coef.Cmp(symOne) == 0:
+			return rest[0]
+		case coef.Cmp(symOne) == 0:
+			return symProduct(rest...)
+		}
+		return symProduct(append([]*sym{symRat(coef)}, rest...)...)
+	}
+}
+
+func simplifyPow(base, exp *sym) *sym {
+	if base.isDivByZero() || exp.isDivByZero() || base.isZero() && exp.kind == symNum && exp.num.Sign() < 0 {
+		return symDivByZero()
+	}
+	if exp.isZero() {
+		return symInt(1)
+	}
+	if exp.isNum(symOne) {
+		return base
+	}
+	if base.kind == symNum {
+		if base.isNum(symOne) {
+			return symInt(1)
+		}
+		if base.isZero() && exp.kind == symNum && exp.num.Sign() > 0 {
+			return symInt(0)
+		}
+		if exp.kind == symNum {
+			if r, ok := ratPower(base.num, exp.num); ok {
+				return r
+			}
+		}
+	}
+	if base.isVar("e") && exp.kind == symFunc && exp.name == "ln" {
+		return exp.args[0]
+	}
+	if exp.kind == symNum {
+		switch {
+		case base.kind == symPow && (exp.num.IsInt() || base.args[1].kind == symNum && !base.args[1].num.IsInt()):
+			return simplifyPow(base.args[0], simplifyMul([]*sym{base.args[1], exp}))
+		case base.kind == symMul && exp.num.IsInt():
+			factors := make([]*sym, len(base.args))
+			for i, f := range base.args {
+				factors[i] = simplifyPow(f, exp)
+			}
+			return simplifyMul(factors)
+		}
+	}
+	return symPower(base, exp)
+}
+
+// ratPower raises a rational to a rational power exactly, pulling perfect
+// powers out of roots (sqrt(8) = 2*sqrt(2)). ok is false when the result
+// is not real or would be too large.
+func ratPower(b, x *big.Rat) (*sym, bool) {
+	if !x.Num().IsInt64() || !x.Denom().IsInt64() {
+		return nil, false
+	}
+	p, q := x.Num().Int64(), x.Denom().Int64()
+	if q > 64 || (b.Sign() < 0 && q > 1) || (b.Sign() == 0 && p < 0) {
+		return nil, false
+	}
+	// b^(p/q) = b^k * b^(r/q) with 0 <= r < q
+	k := p / q
+	if p%q != 0 && p < 0 {
+		k--
+	}
+	r := p - k*q
+	if int64(b.Num().BitLen()+b.Denom().BitLen())*abs64(k) > maxSymBits {
+		return nil, false
+	}
+	outer := ratPowInt(b, k)
+	if r == 0 {
+		return symRat(outer), true
+	}
+
+	// (n/d)^(r/q) = (n^r * d^(r(q-1)))^(1/q) / d^r
+	n := new(big.Int).Exp(b.Num(), big.NewInt(r), nil)
+	dr := new(big.Int).Exp(b.Denom(), big.NewInt(r), nil)
+	inside := new(big.Int).Mul(n, new(big.Int).Exp(b.Denom(), big.NewInt(r*(q-1)), nil))
+	if inside.BitLen() > maxSymBits {
+		return nil, false
+	}
+	out, in := extractRoot(inside, q)
+	coef := new(big.Rat).Mul(outer, new(big.Rat).SetFrac(out, dr))
+	if in.Cmp(big.NewInt(1)) == 0 {
+		return symRat(coef), true
+	}
+	root := symPower(symRat(new(big.Rat).SetInt(in)), symRat(big.NewRat(1, q)))
+	if coef.Cmp(symOne) == 0 {
+		return root, true
+	}
+	return symProduct(symRat(coef), root), true
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ratPowInt raises a rational to an integer power
+func ratPowInt(b *big.Rat, n int64) *big.Rat {
+	e := big.NewInt(abs64(n))
+	num := new(big.Int).Exp(b.Num(), e, nil)
+	den := new(big.Int).Exp(b.Denom(), e, nil)
+	if n < 0 {
+		num, den = den, num
+		if num.Sign() < 0 {
+			num.Neg(num)
+			den.Neg(den)
+		}
+	}
+	return new(big.Rat).SetFrac(num, den)
+}
+
+// extractRoot splits a positive integer n into out^q * in, with in holding
+// no factor that is a q-th power below 10^5
+func extractRoot(n *big.Int, q int64) (out, in *big.Int) {
+	out, in = big.NewInt(1), big.NewInt(1)
+	m := new(big.Int).Set(n)
+	for p := int64(2); p < 100000; p++ {
+		bp := big.NewInt(p)
+		if new(big.Int).Mul(bp, bp).Cmp(m) > 0 {
+			break
+		}
+		count := int64(0)
+		for new(big.Int).Mod(m, bp).Sign() == 0 {
+			m.Div(m, bp)
+			count++
+		}
+		out.Mul(out, new(big.Int).Exp(bp, big.NewInt(count/q), nil))
+		in.Mul(in, new(big.Int).Exp(bp, big.NewInt(count%q), nil))
+	}
+	// What is left is prime, or a product of large factors; it may still be
+	// a perfect power
+	if m.IsInt64() {
+		f, _ := new(big.Float).SetInt(m).Float64()
+		root := big.NewInt(int64(math.Round(math.Pow(f, 1/float64(q)))))
+		if root.Cmp(big.NewInt(1)) > 0 && new(big.Int).Exp(root, big.NewInt(q), nil).Cmp(m) == 0 {
+			return out.Mul(out, root), in
+		}
+	}
+	return out, in.Mul(in, m)
+}
+
+func simplifyFunc(name string, a *sym) *sym {
+	switch {
+	case a.isDivByZero():
+		return a
+	case name == "ln" && a.isNum(symOne):
+		return symInt(0)
+	case name == "ln" && a.isVar("e"):
+		return symInt(1)
+	case name == "ln" && a.kind == symPow && a.args[0].isVar("e"):
+		return a.args[1]
+	case name == "abs" && a.kind == symNum:
+		return symRat(new(big.Rat).Abs(a.num))
+	case a.isZero():
+		switch name {
+		case "sin", "tan", "asin", "atan", "sinh", "tanh":
+			return symInt(0)
+		case "cos", "cosh":
+			return symInt(1)
+		}
+	case a.isVar("pi"):
+		switch name {
+		case "sin", "tan":
+			return symInt(0)
+		case "cos":
+			return symInt(-1)
+		}
+	}
+	return symCall(name, a)
+}
+
+// expandSym multiplies out products of sums and integer powers of sums
+func expandSym(e *sym) (*sym, error) {
+	switch e.kind {
+	case symAdd:
+		terms := make([]*sym, len(e.args))
+		for i, a := range e.args {
+			t, err := expandSym(a)
+			if err != nil {
+				return nil, err
+			}
+			terms[i] = t
+		}
+		return simplifyAdd(terms), nil
+	case symMul:
+		product := []*sym{symInt(1)}
+		for _, f := range e.args {
+			f, err := expandSym(f)
+			if err != nil {
+				return nil, err
+			}
+			terms := []*sym{f}
+			if f.kind == symAdd {
+				terms = f.args
+			}
+			if len(product)*len(terms) > maxSymTerms {
+				return nil, fmt.Errorf("expansion too large (over %d terms)", maxSymTerms)
+			}
+			var next []*sym
+			for _, t := range product {
+				for _, u := range terms {
+					next = append(next, simplifyMul([]*sym{t, u}))
+				}
+			}
+			product = next
+		}
+		return simplifyAdd(product), nil
+	case symPow:
+		base, err := expandSym(e.args[0])
+		if err != nil {
+			return nil, err
+		}
+		exp := simplifySym(e.args[1])
+		if base.kind == symAdd && exp.kind == symNum && exp.num.IsInt() && exp.num.Sign() > 0 {
+			n := exp.num.Num().Int64()
+			if n > maxSymPowExp {
+				return nil, fmt.Errorf("power too large to expand (over %d)", maxSymPowExp)
+			}
+			acc := base
+			for i := int64(1); i < n; i++ {
+				if acc, err = expandSym(symProduct(acc, base)); err != nil {
+					return nil, err
+				}
+			}
+			return acc, nil
+		}
+		return simplifyPow(base, exp), nil
+	case symFunc:
+		arg, err := expandSym(e.args[0])
+		if err != nil {
+			return nil, err
+		}
+		return simplifyFunc(e.name, arg), nil
+	}
+	return e, nil
+}
+
+// simplifyBest returns the shortest of the simplified, expanded and
+// cancelled forms, so (x+1)^2 stays factored but (x+1)^2 - x^2 becomes
+// 2*x + 1
+func simplifyBest(e *sym) *sym {
+	simplified := simplifySym(e)
+	best := simplified
+	if expanded, err := expandSym(simplified); err == nil && len(expanded.String()) < len(best.String()) {
+		best = expanded
+	}
+	if cancelled, ok := cancelRational(simplified); ok && len(cancelled.String()) <= len(best.String()) {
+		best = cancelled
+	}
+	return best
+}
+
+// ============ Printing ============
+
+func (e *sym) String() string {
+	switch e.kind {
+	case symNum:
+		if e.num.IsInt() {
+			return e.num.Num().String()
+		}
+		return e.num.RatString()
+	case symVar:
+		return e.name
+	case symFunc:
+		return e.name + "(" + e.args[0].String() + ")"
+	case symAdd:
+		var sb strings.Builder
+		for i, t := range e.args {
+			if i == 0 {
+				sb.WriteString(t.String())
+				continue
+			}
+			if coef, rest := splitCoef(t); coef.Sign() < 0 {
+				sb.WriteString(" - ")
+				sb.WriteString(withCoef(new(big.Rat).Neg(coef), rest).String())
+				continue
+			}
+			sb.WriteString(" + ")
+			sb.WriteString(t.String())
+		}
+		return sb.String()
+	case symMul:
+		return mulString(e.args)
+	case symPow:
+		return powString(e.args[0], e.args[1])
+	}
+	return "?"
+}
+
+// factorString parenthesizes sums inside products
+func factorString(f *sym) string {
+	if f.kind == symAdd {
+		return "(" + f.String() + ")"
+	}
+	return f.String()
+}
+
+func mulString(factors []*sym) string {
+	coef := symOne
+	var num, den []string
+	for _, f := range factors {
+		switch {
+		case f.kind == symNum:
+			coef = f.num
+		case f.kind == symPow && f.args[1].kind == symNum && f.args[1].num.Sign() < 0:
+			den = append(den, factorString(simplifyPow(f.args[0], symRat(new(big.Rat).Neg(f.args[1].num)))))
+		default:
+			num = append(num, factorString(f))
+		}
+	}
+	sign := ""
+	if coef.Sign() < 0 {
+		sign, coef = "-", new(big.Rat).Neg(coef)
+	}
+	if !coef.Num().IsInt64() || coef.Num().Int64() != 1 || len(num) == 0 {
+		num = append([]string{coef.Num().String()}, num...)
+	}
+	if !coef.IsInt() {
+		den = append([]string{coef.Denom().String()}, den...)
+	}
+	s := sign + strings.Join(num, "*")
+	switch len(den) {
+	case 0:
+	case 1:
+		s += "/" + den[0]
+	default:
+		s += "/(" + strings.Join(den, "*") + ")"
+	}
+	return s
+}
+
+func powString(base, exp *sym) string {
+	if exp.isNum(symHalf) {
+		return "sqrt(" + base.String() + ")"
+	}
+	if exp.kind == symNum && exp.num.Sign() < 0 {
+		return "1/" + factorString(simplifyPow(base, symRat(new(big.Rat).Neg(exp.num))))
+	}
+	bs := base.String()
+	if base.kind == symAdd || base.kind == symMul || base.kind == symPow || (base.kind == symNum && (base.num.Sign() < 0 || !base.num.IsInt())) {
+		bs = "(" + bs + ")"
+	}
+	xs := exp.String()
+	if exp.kind != symVar && !(exp.kind == symNum && exp.num.IsInt()) {
+		xs = "(" + xs + ")"
+	}
+	return bs + "^" + xs
+}
+
+// ============ Evaluation ============
+
+// containsVar reports whether x occurs in e
+func containsVar(e *sym, x string) bool {
+	if e.kind == symVar {
+		return e.name == x
+	}
+	for _, a := range e.args {
+		if containsVar(a, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// freeVars lists the variables in e other than the constants pi and e
+func freeVars(e *sym, seen map[string]bool) {
+	if e.kind == symVar && e.name != "pi" && e.name != "e" {
+		seen[e.name] = true
+	}
+	for _, a := range e.args {
+		freeVars(a, seen)
+	}
+}
+
+func sortedVars(exprs ...*sym) []string {
+	seen := make(map[string]bool)
+	for _, e := range exprs {
+		freeVars(e, seen)
+	}
+	vars := make([]string, 0, len(seen))
+	for v := range seen {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// substitute replaces variables with expressions
+func substitute(e *sym, values map[string]*sym) *sym {
+	if e.kind == symVar {
+		if v, ok := values[e.name]; ok {
+			return v
+		}
+		return e
+	}
+	if len(e.args) == 0 {
+		return e
+	}
+	args := make([]*sym, len(e.args))
+	for i, a := range e.args {
+		args[i] = substitute(a, values)
+	}
+	return &sym{kind: e.kind, num: e.num, name: e.name, args: args}
+}
+
+// evalSym evaluates e numerically with the given variable values
+func evalSym(e *sym, env map[string]float64) (float64, error) {
+	switch e.kind {
+	case symNum:
+		f, _ := e.num.Float64()
+		return f, nil
+	case symVar:
+		if v, ok := env[e.name]; ok {
+			return v, nil
+		}
+		switch e.name {
+		case "pi":
+			return math.Pi, nil
+		case "e":
+			return math.E, nil
+		}
+		return 0, fmt.Errorf("no value for %s", e.name)
+	case symAdd, symMul:
+		total := 0.0
+		if e.kind == symMul {
+			total = 1
+		}
+		for _, a := range e.args {
+			v, err := evalSym(a, env)
+			if err != nil {
+				return 0, err
+			}
+			if e.kind == symAdd {
+				total += v
+			} else {
+				total *= v
+			}
+		}
+		return total, nil
+	case symPow:
+		b, err := evalSym(e.args[0], env)
+		if err != nil {
+			return 0, err
+		}
+		x, err := evalSym(e.args[1], env)
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(b, x), nil
+	}
+	v, err := evalSym(e.args[0], env)
+	if err != nil {
+		return 0, err
+	}
+	fn := map[string]func(float64) float64{
+		"sin": math.Sin, "cos": math.Cos, "tan": math.Tan, "asin": math.Asin, "acos": math.Acos, "atan": math.Atan,
+		"sinh": math.Sinh, "cosh": math.Cosh, "tanh": math.Tanh, "ln": math.Log, "log10": math.Log10, "abs": math.Abs,
+	}[e.name]
+	if fn == nil {
+		return 0, fmt.Errorf("unknown function %s", e.name)
+	}
+	return fn(v), nil
+}
+
+// withApprox appends a decimal value to exact results that are not integers
+func withApprox(e *sym) string {
+	s := e.String()
+	if e.kind == symNum && e.num.IsInt() {
+		return s
+	}
+	if v, err := evalSym(e, nil); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+		return s + " ≈ " + formatQuantity(v)
+	}
+	return s
+}
+
+// ============ Differentiation ============
+
+func diffSym(e *sym, x string) *sym {
+	if !containsVar(e, x) {
+		return symInt(0)
+	}
+	switch e.kind {
+	case symVar:
+		return symInt(1)
+	case symAdd:
+		terms := make([]*sym, len(e.args))
+		for i, a := range e.args {
+			terms[i] = diffSym(a, x)
+		}
+		return simplifyAdd(terms)
+	case symMul:
+		// Product rule
+		var terms []*sym
+		for i := range e.args {
+			factors := append([]*sym(nil), e.args...)
+			factors[i] = diffSym(e.args[i], x)
+			terms = append(terms, simplifyMul(factors))
+		}
+		return simplifyAdd(terms)
+	case symPow:
+		base, exp := e.args[0], e.args[1]
+		if !containsVar(exp, x) {
+			return simplifyMul([]*sym{exp, simplifyPow(base, simplifyAdd([]*sym{exp, symInt(-1)})), diffSym(base, x)})
+		}
+		// d(b^n) = b^n * (n' ln b + n b' / b)
+		return simplifyMul([]*sym{e, simplifyAdd([]*sym{
+			simplifyMul([]*sym{diffSym(exp, x), simplifyFunc("ln", base)}),
+			simplifyMul([]*sym{exp, diffSym(base, x), simplifyPow(base, symInt(-1))}),
+		})})
+	}
+
+	u := e.args[0]
+	var outer *sym
+	switch e.name {
+	case "sin":
+		outer = symCall("cos", u)
+	case "cos":
+		outer = symNeg(symCall("sin", u))
+	case "tan":
+		outer = symPower(symCall("cos", u), symInt(-2))
+	case "ln":
+		outer = symPower(u, symInt(-1))
+	case "log10":
+		outer = symPower(symProduct(u, symCall("ln", symInt(10))), symInt(-1))
+	case "asin":
+		outer = symPower(symSum(symInt(1), symNeg(symPower(u, symInt(2)))), symRat(big.NewRat(-1, 2)))
+	case "acos":
+		outer = symNeg(symPower(symSum(symInt(1), symNeg(symPower(u, symInt(2)))), symRat(big.NewRat(-1, 2))))
+	case "atan":
+		outer = symPower(symSum(symInt(1), symPower(u, symInt(2))), symInt(-1))
+	case "sinh":
+		outer = symCall("cosh", u)
+	case "cosh":
+		outer = symCall("sinh", u)
+	case "tanh":
+		outer = symSum(symInt(1), symNeg(symPower(symCall("tanh", u), symInt(2))))
+	case "abs":
+		outer = symProduct(u, symPower(symCall("abs", u), symInt(-1)))
+	}
+	return simplifySym(symProduct(outer, diffSym(u, x)))
+}
+
+// ============ Polynomials ============
+
+// polyCoeffs reads f as a polynomial in x, allowing negative powers;
+// coefficients may contain other variables. ok is false when x appears
+// other than as an integer power.
+func polyCoeffs(f *sym, x string) (coeffs map[int]*sym, minDeg, maxDeg int, ok bool) {
+	coeffs = make(map[int]*sym)
+	terms := []*sym{f}
+	if f.kind == symAdd {
+		terms = f.args
+	}
+	first := true
+	for _, term := range terms {
+		factors := []*sym{term}
+		if term.kind == symMul {
+			factors = term.args
+		}
+		deg := 0
+		var rest []*sym
+		for _, factor := range factors {
+			switch {
+			case factor.isVar(x):
+				deg++
+			case factor.kind == symPow && factor.args[0].isVar(x) && factor.args[1].kind == symNum && factor.args[1].num.IsInt():
+				deg += int(factor.args[1].num.Num().Int64())
+			case containsVar(factor, x):
+				return nil, 0, 0, false
+			default:
+				rest = append(rest, factor)
+			}
+		}
+		coef := simplifyMul(append([]*sym{symInt(1)}, rest...))
+		if c, ok := coeffs[deg]; ok {
+			coef = simplifyAdd([]*sym{c, coef})
+		}
+		coeffs[deg] = coef
+		if first || deg < minDeg {
+			minDeg = deg
+		}
+		if first || deg > maxDeg {
+			maxDeg = deg
+		}
+		first = false
+	}
+	return coeffs, minDeg, maxDeg, true
+}
+
+// ratPoly holds coefficients in ascending order of degree
+type ratPoly []*big.Rat
+
+func (p ratPoly) degree() int {
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i].Sign() != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p ratPoly) eval(x *big.Rat) *big.Rat {
+	result := new(big.Rat)
+	for i := len(p) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, p[i])
+	}
+	return result
+}
+
+// divMod divides p by d, both with rational coefficients
+func (p ratPoly) divMod(d ratPoly) (quot, rem ratPoly) {
+	rem = make(ratPoly, len(p))
+	for i := range p {
+		rem[i] = new(big.Rat).Set(p[i])
+	}
+	dd := d.degree()
+	if rem.degree() < dd {
+		return ratPoly{new(big.Rat)}, rem
+	}
+	quot = make(ratPoly, rem.degree()-dd+1)
+	for i := range quot {
+		quot[i] = new(big.Rat)
+	}
+	for rd := rem.degree(); rd >= dd && rd >= 0; rd = rem.degree() {
+		factor := new(big.Rat).Quo(rem[rd], d[dd])
+		quot[rd-dd] = factor
+		for i := 0; i <= dd; i++ {
+			rem[rd-dd+i].Sub(rem[rd-dd+i], new(big.Rat).Mul(factor, d[i]))
+		}
+	}
+	return quot, rem
+}
+
+// gcd returns the monic greatest common divisor
+func (p ratPoly) gcd(q ratPoly) ratPoly {
+	a, b := p, q
+	for b.degree() >= 0 {
+		_, r := a.divMod(b)
+		a, b = b, r
+	}
+	lead := a[a.degree()]
+	out := make(ratPoly, a.degree()+1)
+	for i := range out {
+		out[i] = new(big.Rat).Quo(a[i], lead)
+	}
+	return out
+}
+
+func (p ratPoly) toSym(x string) *sym {
+	var terms []*sym
+	for i := p.degree(); i >= 0; i-- {
+		if p[i].Sign() != 0 {
+			terms = append(terms, simplifyMul([]*sym{symRat(p[i]), simplifyPow(symVariable(x), symInt(int64(i)))}))
+		}
+	}
+	return simplifyAdd(terms)
+}
+
+// numericPoly reads f as a polynomial in x with rational coefficients and
+// non-negative powers
+func numericPoly(f *sym, x string) (ratPoly, bool) {
+	coeffs, minDeg, maxDeg, ok := polyCoeffs(f, x)
+	if !ok || minDeg < 0 || maxDeg > maxPolyDegree {
+		return nil, false
+	}
+	p := make(ratPoly, maxDeg+1)
+	for i := range p {
+		p[i] = new(big.Rat)
+		if c, ok := coeffs[i]; ok {
+			if c.kind != symNum {
+				return nil, false
+			}
+			p[i].Set(c.num)
+		}
+	}
+	return p, true
+}
+
+// cancelRational cancels common polynomial factors of a one-variable
+// fraction: (x^2 - 1)/(x - 1) becomes x + 1
+func cancelRational(e *sym) (*sym, bool) {
+	if e.kind != symMul {
+		return nil, false
+	}
+	var num, den []*sym
+	for _, f := range e.args {
+		if f.kind == symPow && f.args[1].kind == symNum && f.args[1].num.Sign() < 0 {
+			den = append(den, simplifyPow(f.args[0], symRat(new(big.Rat).Neg(f.args[1].num))))
+		} else {
+			num = append(num, f)
+		}
+	}
+	vars := sortedVars(e)
+	if len(den) == 0 || len(vars) != 1 {
+		return nil, false
+	}
+	n, err := expandSym(simplifyMul(append([]*sym{symInt(1)}, num...)))
+	if err != nil {
+		return nil, false
+	}
+	d, err := expandSym(simplifyMul(append([]*sym{symInt(1)}, den...)))
+	if err != nil {
+		return nil, false
+	}
+	np, ok1 := numericPoly(n, vars[0])
+	dp, ok2 := numericPoly(d, vars[0])
+	if !ok1 || !ok2 || np.degree() < 0 || dp.degree() < 1 {
+		return nil, false
+	}
+	g := np.gcd(dp)
+	if g.degree() < 1 {
+		return nil, false
+	}
+	nq, _ := np.divMod(g)
+	dq, _ := dp.divMod(g)
+	return simplifyMul([]*sym{nq.toSym(vars[0]), simplifyPow(dq.toSym(vars[0]), symInt(-1))}), true
+}
+
+// ============ Solving ============
+
+// solveSym solves 'lhs = rhs[; lhs = rhs...], var[, var...]'
+func solveSym(arg string) (string, error) {
+	parts := splitTopLevel(arg, ',')
+	var eqs, dens []*sym
+	for _, text := range strings.Split(parts[0], ";") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lhs, rhs, _ := strings.Cut(text, "=")
+		if strings.TrimSpace(rhs) == "" {
+			rhs = "0"
+		}
+		l, err := parseSym(lhs)
+		if err != nil {
+			return "", fmt.Errorf("left side of %q: %w", strings.TrimSpace(text), err)
+		}
+		r, err := parseSym(rhs)
+		if err != nil {
+			return "", fmt.Errorf("right side of %q: %w", strings.TrimSpace(text), err)
+		}
+		f, err := expandSym(simplifySym(symSum(l, symNeg(r))))
+		if err == nil {
+			f, err = checkDivByZero(f)
+		}
+		if err != nil {
+			return "", err
+		}
+		eqs = append(eqs, f)
+		dens = symDenominators(l, symDenominators(r, dens))
+	}
+	if len(eqs) == 0 {
+		return "", fmt.Errorf("use 'solve:<equation>, <variable>', e.g. solve:x^2 - 5x + 6 = 0, x")
+	}
+
+	var vars []string
+	for _, v := range parts[1:] {
+		if v = strings.TrimSpace(v); v != "" {
+			vars = append(vars, v)
+		}
+	}
+	if len(vars) == 0 {
+		vars = sortedVars(eqs...)
+		if len(eqs) == 1 && len(vars) > 1 {
+			return "", fmt.Errorf("name the variable to solve for (one of %s)", strings.Join(vars, ", "))
+		}
+	}
+	if len(vars) == 0 {
+		return "", fmt.Errorf("nothing to solve for")
+	}
+
+	if len(eqs) == 1 && len(vars) == 1 {
+		solutions, err := solveSingle(eqs[0], vars[0])
+		if err != nil {
+			return "", err
+		}
+		// Simplifying x^2/x to x brings back x = 0, which the equation as
+		// written excludes
+		var kept []string
+		for _, s := range solutions {
+			if !zeroesDenominator(s, vars[0], dens) {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == 0 {
+			kept = []string{"no solution"}
+		}
+		return strings.Join(kept, "\n"), nil
+	}
+	return solveLinearSystem(eqs, vars)
+}
+
+// symDenominators appends the bases of e's negative powers to out: the
+// denominators of the equation as written, which a solution must not zero
+func symDenominators(e *sym, out []*sym) []*sym {
+	if e.kind == symPow && e.args[1].kind == symNum && e.args[1].num.Sign() < 0 {
+		out = append(out, e.args[0])
+	}
+	for _, a := range e.args {
+		out = symDenominators(a, out)
+	}
+	return out
+}
+
+// zeroesDenominator reports whether a solution line of solveSingle, such as
+// "x = 0" or "x ≈ 1.5 (multiplicity 2)", makes one of dens zero. Lines it
+// cannot read, like complex roots, are kept.
+func zeroesDenominator(solution, x string, dens []*sym) bool {
+	value, exact := strings.CutPrefix(solution, x+" = ")
+	if !exact {
+		var ok bool
+		if value, ok = strings.CutPrefix(solution, x+" ≈ "); !ok {
+			return false
+		}
+	}
+	value, _, _ = strings.Cut(value, " (")
+	value, _, _ = strings.Cut(value, " ≈ ")
+	v, err := parseSym(value)
+	if err != nil || containsVar(v, "i") {
+		return false
+	}
+	for _, d := range dens {
+		at := simplifyBest(substitute(d, map[string]*sym{x: v}))
+		if exact && at.isZero() {
+			return true
+		}
+		if n, err := evalSym(at, nil); !exact && err == nil && math.Abs(n) < 1e-9 {
+			return true
+		}
+	}
+	return false
+}
+
+func solveSingle(f *sym, x string) ([]string, error) {
+	coeffs, minDeg, maxDeg, ok := polyCoeffs(f, x)
+	if !ok {
+		return solveNumeric(f, x)
+	}
+	if minDeg < 0 {
+		// Multiply through by x^-minDeg; x = 0 is then excluded below
+		shifted := make(map[int]*sym)
+		for deg, c := range coeffs {
+			shifted[deg-minDeg] = c
+		}
+		coeffs, maxDeg = shifted, maxDeg-minDeg
+	}
+	if maxDeg > maxPolyDegree {
+		return nil, fmt.Errorf("degree %d is above the supported %d", maxDeg, maxPolyDegree)
+	}
+	solutions, err := solvePolynomial(coeffs, maxDeg, x)
+	if err != nil || minDeg >= 0 {
+		return solutions, err
+	}
+	var kept []string
+	for _, s := range solutions {
+		if s != x+" = 0" && !strings.HasPrefix(s, x+" = 0 (") {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		return []string{"no solution"}, nil
+	}
+	return kept, nil
+}
+
+func coeffAt(coeffs map[int]*sym, deg int) *sym {
+	if c, ok := coeffs[deg]; ok {
+		return c
+	}
+	return symInt(0)
+}
+
+func solvePolynomial(coeffs map[int]*sym, degree int, x string) ([]string, error) {
+	for degree > 0 && coeffAt(coeffs, degree).isZero() {
+		degree--
+	}
+	switch degree {
+	case 0:
+		if coeffAt(coeffs, 0).isZero() {
+			return []string{"every value of " + x + " is a solution"}, nil
+		}
+		if coeffAt(coeffs, 0).kind == symNum {
+			return []string{"no solution"}, nil
+		}
+		return []string{"no solution unless " + coeffAt(coeffs, 0).String() + " = 0"}, nil
+	case 1:
+		root := simplifyBest(symProduct(symInt(-1), coeffAt(coeffs, 0), symPower(coeffAt(coeffs, 1), symInt(-1))))
+		return []string{x + " = " + withApprox(root)}, nil
+	case 2:
+		return solveQuadratic(coeffAt(coeffs, 2), coeffAt(coeffs, 1), coeffAt(coeffs, 0), x), nil
+	}
+
+	p := make(ratPoly, degree+1)
+	for i := range p {
+		c := coeffAt(coeffs, i)
+		if c.kind != symNum {
+			return nil, fmt.Errorf("degree %d equations need numeric coefficients", degree)
+		}
+		p[i] = c.num
+	}
+	return solveRatPoly(p, x), nil
+}
+
+func solveQuadratic(a, b, c *sym, x string) []string {
+	disc := simplifyBest(symSum(symPower(b, symInt(2)), symProduct(symInt(-4), a, c)))
+	twoA := symPower(symProduct(symInt(2), a), symInt(-1))
+	if disc.isZero() {
+		return []string{x + " = " + withApprox(simplifyBest(symProduct(symInt(-1), b, twoA))) + " (double root)"}
+	}
+	if disc.kind == symNum && disc.num.Sign() < 0 {
+		re := simplifyBest(symProduct(symInt(-1), b, twoA))
+		im := simplifyBest(symProduct(simplifyPow(symRat(new(big.Rat).Neg(disc.num)), symRat(symHalf)), twoA))
+		if im.kind == symNum && im.num.Sign() < 0 {
+			im = symRat(new(big.Rat).Neg(im.num))
+		} else if coef, _ := splitCoef(im); coef.Sign() < 0 {
+			im = simplifySym(symNeg(im))
+		}
+		return []string{complexSolution(x, re, im, "+"), complexSolution(x, re, im, "-")}
+	}
+	root := simplifyPow(disc, symRat(symHalf))
+	var solutions []string
+	for _, sign := range []int64{1, -1} {
+		r := simplifyBest(symProduct(symSum(symNeg(b), symProduct(symInt(sign), root)), twoA))
+		solutions = append(solutions, x+" = "+withApprox(r))
+	}
+	return solutions
+}
+
+func complexSolution(x string, re, im *sym, sign string) string {
+	imag := "i"
+	switch {
+	case im.kind == symNum && !im.isNum(symOne):
+		imag = im.String() + "i"
+	case im.kind != symNum:
+		imag = "i*" + factorString(im)
+	}
+	s := x + " = " + re.String() + " " + sign + " " + imag
+	if re.isZero() {
+		s = x + " = " + strings.TrimPrefix(sign, "+") + imag
+	}
+	rev, err1 := evalSym(re, nil)
+	imv, err2 := evalSym(im, nil)
+	if err1 == nil && err2 == nil && !(im.kind == symNum && re.kind == symNum) {
+		s += fmt.Sprintf(" ≈ %s %s %si", formatQuantity(rev), sign, formatQuantity(imv))
+	}
+	return s
+}
+
+// solveRatPoly finds rational roots exactly, then the rest of the roots of
+// what remains numerically
+func solveRatPoly(p ratPoly, x string) []string {
+	var solutions []string
+	counts := make(map[string]int)
+	addRoot := func(s string) {
+		if counts[s] == 0 {
+			solutions = append(solutions, s)
+		}
+		counts[s]++
+	}
+
+	for _, r := range rationalRootCandidates(p) {
+		for p.degree() > 0 && p.eval(r).Sign() == 0 {
+			addRoot(x + " = " + withApprox(symRat(r)))
+			p, _ = p.divMod(ratPoly{new(big.Rat).Neg(r), big.NewRat(1, 1)})
+		}
+	}
+
+	switch d := p.degree(); {
+	case d == 1 || d == 2:
+		coeffs := make(map[int]*sym)
+		for i := 0; i <= d; i++ {
+			coeffs[i] = symRat(p[i])
+		}
+		rest, _ := solvePolynomial(coeffs, d, x)
+		for _, s := range rest {
+			addRoot(s)
+		}
+	case d > 2:
+		for _, s := range numericPolyRoots(p, x) {
+			addRoot(s)
+		}
+	}
+
+	for i, s := range solutions {
+		if counts[s] > 1 {
+			solutions[i] = fmt.Sprintf("%s (multiplicity %d)", s, counts[s])
+		}
+	}
+	return solutions
+}
+
+// rationalRootCandidates lists ±p/q for p dividing the constant term and q
+// the leading coefficient, after clearing denominators
+func rationalRootCandidates(p ratPoly) []*big.Rat {
+	lcm := big.NewInt(1)
+	for _, c := range p {
+		g := new(big.Int).GCD(nil, nil, lcm, c.Denom())
+		lcm.Mul(lcm, new(big.Int).Div(c.Denom(), g))
+	}
+	ints := make([]*big.Int, len(p))
+	for i, c := range p {
+		ints[i] = new(big.Int).Div(new(big.Int).Mul(c.Num(), lcm), c.Denom())
+	}
+	candidates := []*big.Rat{new(big.Rat)} // Zero
+	low := 0
+	for low < len(ints) && ints[low].Sign() == 0 {
+		low++
+	}
+	high := p.degree()
+	if low >= high || !ints[low].IsInt64() || !ints[high].IsInt64() {
+		return candidates
+	}
+	a0, an := abs64(ints[low].Int64()), abs64(ints[high].Int64())
+	if a0 > 1e9 || an > 1e9 {
+		return candidates
+	}
+	seen := make(map[string]bool)
+	for _, num := range divisors(a0) {
+		for _, den := range divisors(an) {
+			for _, sign := range []int64{1, -1} {
+				r := big.NewRat(sign*num, den)
+				if !seen[r.String()] {
+					seen[r.String()] = true
+					candidates = append(candidates, r)
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+func divisors(n int64) []int64 {
+	var small, large []int64
+	for i := int64(1); i*i <= n; i++ {
+		if n%i == 0 {
+			small = append(small, i)
+			if i != n/i {
+				large = append([]int64{n / i}, large...)
+			}
+		}
+	}
+	return append(small, large...)
+}
+
+// numericPolyRoots finds all roots with the Durand-Kerner method
+func numericPolyRoots(p ratPoly, x string) []string {
+	n := p.degree()
+	lead, _ := p[n].Float64()
+	coeffs := make([]complex128, n+1)
+	for i := 0; i <= n; i++ {
+		f, _ := p[i].Float64()
+		coeffs[i] = complex(f/lead, 0)
+	}
+	eval := func(z complex128) complex128 {
+		result := complex(0, 0)
+		for i := n; i >= 0; i-- {
+			result = result*z + coeffs[i]
+		}
+		return result
+	}
+	roots := make([]complex128, n)
+	for i := range roots {
+		roots[i] = cmplx.Pow(complex(0.4, 0.9), complex(float64(i), 0))
+	}
+	for iter := 0; iter < 1000; iter++ {
+		moved := 0.0
+		for i := range roots {
+			denom := complex(1, 0)
+			for j := range roots {
+				if i != j {
+					denom *= roots[i] - roots[j]
+				}
+			}
+			delta := eval(roots[i]) / denom
+			roots[i] -= delta
+			moved = math.Max(moved, cmplx.Abs(delta))
+		}
+		if moved < 1e-14 {
+			break
+		}
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		ri, rj := math.Abs(imag(roots[i])) < 1e-8, math.Abs(imag(roots[j])) < 1e-8
+		if ri != rj {
+			return ri
+		}
+		// Conjugate pairs differ in their real parts only by rounding
+		if a, b := math.Round(real(roots[i])*1e9), math.Round(real(roots[j])*1e9); a != b {
+			return a < b
+		}
+		return imag(roots[i]) < imag(roots[j])
+	})
+	var solutions []string
+	for _, r := range roots {
+		if math.Abs(imag(r)) < 1e-8*math.Max(1, math.Abs(real(r))) {
+			solutions = append(solutions, fmt.Sprintf("%s ≈ %s", x, formatQuantity(real(r))))
+			continue
+		}
+		sign, im := "+", imag(r)
+		if im < 0 {
+			sign, im = "-", -im
+		}
+		solutions = append(solutions, fmt.Sprintf("%s ≈ %s %s %si", x, formatQuantity(real(r)), sign, formatQuantity(im)))
+	}
+	return solutions
+}
+
+// solveNumeric finds real roots of a non-polynomial equation by scanning
+// for sign changes and bisecting
+func solveNumeric(f *sym, x string) ([]string, error) {
+	if others := sortedVars(f); len(others) > 1 {
+		return nil, fmt.Errorf("cannot solve for %s symbolically; give values for %s", x, strings.Join(others, ", "))
+	}
+	g := func(v float64) float64 {
+		y, err := evalSym(f, map[string]float64{x: v})
+		if err != nil {
+			return math.NaN()
+		}
+		return y
+	}
+	const steps = 20000
+	var roots []float64
+	addRoot := func(r float64) {
+		for _, existing := range roots {
+			if math.Abs(existing-r) < 1e-7*math.Max(1, math.Abs(r)) {
+				return
+			}
+		}
+		roots = append(roots, r)
+	}
+	step := 2 * numericSolveRange / steps
+	prevX, prevY := -numericSolveRange, g(-numericSolveRange)
+	for i := 1; i <= steps; i++ {
+		curX := -numericSolveRange + float64(i)*step
+		curY := g(curX)
+		switch {
+		case curY == 0:
+			addRoot(curX)
+		case !math.IsNaN(prevY) && !math.IsNaN(curY) && !math.IsInf(prevY, 0) && !math.IsInf(curY, 0) && prevY*curY < 0:
+			lo, hi, loY := prevX, curX, prevY
+			for j := 0; j < 100; j++ {
+				mid := (lo + hi) / 2
+				midY := g(mid)
+				if (midY < 0) == (loY < 0) {
+					lo, loY = mid, midY
+				} else {
+					hi = mid
+				}
+			}
+			root := (lo + hi) / 2
+			// A sign change across a pole is not a root
+			if math.Abs(g(root)) < 1e-6 {
+				addRoot(root)
+			}
+		}
+		prevX, prevY = curX, curY
+	}
+	if len(roots) == 0 {
+		return []string{fmt.Sprintf("no real solution found for %s in [-%g, %g]", x, numericSolveRange, numericSolveRange)}, nil
+	}
+	solutions := make([]string, 0, len(roots))
+	for _, r := range roots {
+		solutions = append(solutions, fmt.Sprintf("%s ≈ %s", x, formatQuantity(r)))
+	}
+	if len(solutions) > 20 {
+		solutions = append(solutions[:20], fmt.Sprintf("...(%d more)", len(solutions)-20))
+	}
+	return append(solutions, fmt.Sprintf("(numeric search in [-%g, %g])", numericSolveRange, numericSolveRange)), nil
+}
+
+// solveLinearSystem solves equations linear in vars by Gauss-Jordan elimination
+func solveLinearSystem(eqs []*sym, vars []string) (string, error) {
+	index := make(map[string]int, len(vars))
+	for i, v := range vars {
+		index[v] = i
+	}
+	m := len(vars)
+	rows := make([][]*big.Rat, len(eqs))
+	for r, f := range eqs {
+		row := make([]*big.Rat, m+1)
+		for i := range row {
+			row[i] = new(big.Rat)
+		}
+		terms := []*sym{f}
+		if f.kind == symAdd {
+			terms = f.args
+		}
+		for _, term := range terms {
+			coef, rest := splitCoef(term)
+			switch {
+			case rest == nil:
+				row[m].Sub(row[m], coef)
+			case rest.kind == symVar && containsKey(index, rest.name):
+				row[index[rest.name]].Add(row[index[rest.name]], coef)
+			default:
+				return "", fmt.Errorf("only linear systems with numeric coefficients are supported (term %s)", term)
+			}
+		}
+		rows[r] = row
+	}
+
+	rank, _, pivots := ratRowReduce(rows, m)
+	for _, row := range rows[rank:] {
+		if row[m].Sign() != 0 {
+			return "no solution (the equations are inconsistent)", nil
+		}
+	}
+	if rank < m {
+		return fmt.Sprintf("infinitely many solutions (%s for %s)", plural(rank, "independent equation", "independent equations"), plural(m, "unknown", "unknowns")), nil
+	}
+	lines := make([]string, m)
+	for i, col := range pivots {
+		lines[col] = vars[col] + " = " + withApprox(symRat(rows[i][m]))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func containsKey(m map[string]int, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// ratRowReduce brings the first cols columns of rows to reduced row echelon
+// form in place. det is the determinant when rows is square in those columns.
+func ratRowReduce(rows [][]*big.Rat, cols int) (rank int, det *big.Rat, pivots []int) {
+	det = big.NewRat(1, 1)
+	for col := 0; col < cols && rank < len(rows); col++ {
+		pivot := -1
+		for r := rank; r < len(rows); r++ {
+			if rows[r][col].Sign() != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			det = new(big.Rat)
+			continue
+		}
+		if pivot != rank {
+			rows[pivot], rows[rank] = rows[rank], rows[pivot]
+			det.Neg(det)
+		}
+		lead := new(big.Rat).Set(rows[rank][col])
+		det.Mul(det, lead)
+		for c := range rows[rank] {
+			rows[rank][c].Quo(rows[rank][c], lead)
+		}
+		for r := range rows {
+			if r == rank || rows[r][col].Sign() == 0 {
+				continue
+			}
+			factor := new(big.Rat).Set(rows[r][col])
+			for c := range rows[r] {
+				rows[r][c].Sub(rows[r][c], new(big.Rat).Mul(factor, rows[rank][c]))
+			}
+		}
+		pivots = append(pivots, col)
+		rank++
+	}
+	if rank < cols {
+		det = new(big.Rat)
+	}
+	return rank, det, pivots
+}
+
+// ============ Matrices ============
+
+type symMatrix [][]*sym
+
+// parseMatrices reads one or more matrices written as [[1, 2], [3, 4]];
+// a single bracketed list is a row vector
+func parseMatrices(s string) ([]symMatrix, error) {
+	var matrices []symMatrix
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == ',' || c == '*' || c == '+' || c == '\t' || c == '\n':
+			i++
+			continue
+		case c != '[':
+			return nil, fmt.Errorf("expected a matrix like [[1, 2], [3, 4]] at %q", s[i:])
+		}
+		end := matchingBracket(s, i)
+		if end < 0 {
+			return nil, fmt.Errorf("unbalanced brackets")
+		}
+		m, err := parseMatrix(s[i+1 : end])
+		if err != nil {
+			return nil, err
+		}
+		matrices = append(matrices, m)
+		i = end + 1
+	}
+	return matrices, nil
+}
+
+func matchingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseMatrix(inner string) (symMatrix, error) {
+	items := splitTopLevel(inner, ',')
+	rowTexts := items
+	if !strings.HasPrefix(strings.TrimSpace(items[0]), "[") {
+		rowTexts = []string{"[" + inner + "]"}
+	}
+	var m symMatrix
+	for _, rowText := range rowTexts {
+		rowText = strings.TrimSpace(rowText)
+		if !strings.HasPrefix(rowText, "[") || !strings.HasSuffix(rowText, "]") {
+			return nil, fmt.Errorf("rows must be bracketed lists, got %q", rowText)
+		}
+		var row []*sym
+		for _, entry := range splitTopLevel(rowText[1:len(rowText)-1], ',') {
+			e, err := parseSym(entry)
+			if err != nil {
+				return nil, fmt.Errorf("entry %q: %w", strings.TrimSpace(entry), err)
+			}
+			row = append(row, simplifySym(e))
+		}
+		if len(m) > 0 && len(row) != len(m[0]) {
+			return nil, fmt.Errorf("rows have different lengths (%d and %d)", len(m[0]), len(row))
+		}
+		m = append(m, row)
+	}
+	return m, nil
+}
+
+// splitTopLevel splits s at sep outside parentheses and brackets
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func (m symMatrix) String() string {
+	rows := make([]string, len(m))
+	for i, row := range m {
+		entries := make([]string, len(row))
+		for j, e := range row {
+			entries[j] = e.String()
+		}
+		rows[i] = "[" + strings.Join(entries, ", ") + "]"
+	}
+	return "[" + strings.Join(rows, ", ") + "]"
+}
+
+// rats returns the matrix as rationals when every entry is a number
+func (m symMatrix) rats() ([][]*big.Rat, bool) {
+	out := make([][]*big.Rat, len(m))
+	for i, row := range m {
+		out[i] = make([]*big.Rat, len(row))
+		for j, e := range row {
+			if e.kind != symNum {
+				return nil, false
+			}
+			out[i][j] = new(big.Rat).Set(e.num)
+		}
+	}
+	return out, true
+}
+
+func (m symMatrix) minor(row, col int) symMatrix {
+	var out symMatrix
+	for i, r := range m {
+		if i == row {
+			continue
+		}
+		var nr []*sym
+		for j, e := range r {
+			if j != col {
+				nr = append(nr, e)
+			}
+		}
+		out = append(out, nr)
+	}
+	return out
+}
+
+// cofactorDet expands the determinant along the first row
+func (m symMatrix) cofactorDet() *sym {
+	if len(m) == 1 {
+		return m[0][0]
+	}
+	var terms []*sym
+	for j := range m[0] {
+		sign := int64(1)
+		if j%2 == 1 {
+			sign = -1
+		}
+		terms = append(terms, symProduct(symInt(sign), m[0][j], m.minor(0, j).cofactorDet()))
+	}
+	return symSum(terms...)
+}
+
+func matrixOp(arg string) (string, error) {
+	op, rest, _ := strings.Cut(strings.TrimSpace(arg), " ")
+	op = strings.ToLower(op)
+	matrices, err := parseMatrices(strings.TrimSpace(rest))
+	if err != nil {
+		return "", err
+	}
+	want := 1
+	if op == "multiply" || op == "mul" || op == "add" || op == "subtract" {
+		want = 2
+	}
+	if len(matrices) != want {
+		return "", fmt.Errorf("matrix %s takes %s, got %d", op, plural(want, "matrix", "matrices"), len(matrices))
+	}
+	a := matrices[0]
+	square := len(a) == len(a[0])
+
+	switch op {
+	case "transpose":
+		t := make(symMatrix, len(a[0]))
+		for j := range t {
+			t[j] = make([]*sym, len(a))
+			for i := range a {
+				t[j][i] = a[i][j]
+			}
+		}
+		return t.String(), nil
+	case "add", "subtract":
+		b := matrices[1]
+		if len(a) != len(b) || len(a[0]) != len(b[0]) {
+			return "", fmt.Errorf("cannot %s a %dx%d and a %dx%d matrix", op, len(a), len(a[0]), len(b), len(b[0]))
+		}
+		sum := make(symMatrix, len(a))
+		for i := range a {
+			sum[i] = make([]*sym, len(a[i]))
+			for j := range a[i] {
+				other := b[i][j]
+				if op == "subtract" {
+					other = symNeg(other)
+				}
+				sum[i][j] = simplifyBest(symSum(a[i][j], other))
+			}
+		}
+		return sum.String(), nil
+	case "multiply", "mul":
+		b := matrices[1]
+		if len(a[0]) != len(b) {
+			return "", fmt.Errorf("cannot multiply a %dx%d by a %dx%d matrix", len(a), len(a[0]), len(b), len(b[0]))
+		}
+		product := make(symMatrix, len(a))
+		for i := range a {
+			product[i] = make([]*sym, len(b[0]))
+			for j := range b[0] {
+				var terms []*sym
+				for k := range b {
+					terms = append(terms, symProduct(a[i][k], b[k][j]))
+				}
+				product[i][j] = simplifyBest(symSum(terms...))
+			}
+		}
+		return product.String(), nil
+	case "trace":
+		if !square {
+			return "", fmt.Errorf("trace needs a square matrix")
+		}
+		var diag []*sym
+		for i := range a {
+			diag = append(diag, a[i][i])
+		}
+		return withApprox(simplifyBest(symSum(diag...))), nil
+	case "det", "determinant", "inverse", "inv", "rank":
+	default:
+		return "", fmt.Errorf("unknown matrix operation %q (use det, inverse, rank, transpose, trace, multiply, add or subtract)", op)
+	}
+
+	if op == "rank" {
+		rats, ok := a.rats()
+		if !ok {
+			return "", fmt.Errorf("rank needs numeric entries")
+		}
+		rank, _, _ := ratRowReduce(rats, len(a[0]))
+		return fmt.Sprint(rank), nil
+	}
+	if !square {
+		return "", fmt.Errorf("%s needs a square matrix, got %dx%d", op, len(a), len(a[0]))
+	}
+	n := len(a)
+	rats, numeric := a.rats()
+	if !numeric && n > maxSymbolicMatrix {
+		return "", fmt.Errorf("matrices with symbolic entries are supported up to %dx%d", maxSymbolicMatrix, maxSymbolicMatrix)
+	}
+
+	if op == "det" || op == "determinant" {
+		if numeric {
+			_, det, _ := ratRowReduce(rats, n)
+			return withApprox(symRat(det)), nil
+		}
+		return simplifyBest(a.cofactorDet()).String(), nil
+	}
+
+	if numeric {
+		aug := make([][]*big.Rat, n)
+		for i := range rats {
+			aug[i] = append(rats[i], make([]*big.Rat, n)...)
+			for j := 0; j < n; j++ {
+				aug[i][n+j] = new(big.Rat)
+				if i == j {
+					aug[i][n+j].SetInt64(1)
+				}
+			}
+		}
+		if rank, _, _ := ratRowReduce(aug, n); rank < n {
+			return "", fmt.Errorf("the matrix is singular (determinant 0)")
+		}
+		inv := make(symMatrix, n)
+		for i := range aug {
+			inv[i] = make([]*sym, n)
+			for j := 0; j < n; j++ {
+				inv[i][j] = symRat(aug[i][n+j])
+			}
+		}
+		return inv.String(), nil
+	}
+	det := simplifyBest(a.cofactorDet())
+	if det.isZero() {
+		return "", fmt.Errorf("the matrix is singular (determinant 0)")
+	}
+	inv := make(symMatrix, n)
+	for i := range inv {
+		inv[i] = make([]*sym, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			cofactor := symInt(1)
+			if n > 1 {
+				cofactor = a.minor(i, j).cofactorDet()
+			}
+			if (i+j)%2 == 1 {
+				cofactor = symNeg(cofactor)
+			}
+			inv[j][i] = simplifyBest(symProduct(cofactor, symPower(det, symInt(-1))))
+		}
+	}
+	return inv.String(), nil
+}
+
+// ============ Tool ============
+
+// SymbolicMathTool does exact algebra the calculator cannot
+type SymbolicMathTool struct{}
+
+func (t *SymbolicMathTool) Name() string {
+	return "symbolic_math"
+}
+
+func (t *SymbolicMathTool) Description() string {
+	return "Exact symbolic algebra. Input format: 'operation:argument'. Operations: simplify:(x^2 - 1)/(x - 1), expand:(x + 2)^3, " +
+		"diff:x^2*sin(x), x (optionally ', 2' for the second derivative), solve:x^2 - 5x + 6 = 0, x (systems: solve:x + y = 3; x - y = 1, x, y), " +
+		"eval:x^2 + y, x=3, y=1/2, matrix:det [[1, 2], [3, 4]] (also inverse, rank, transpose, trace, multiply A B, add A B). " +
+		"Functions: sqrt, exp, ln, log10, sin, cos, tan, asin, acos, atan, sinh, cosh, tanh, abs; constants pi and e."
+}
+
+func (t *SymbolicMathTool) Execute(ctx context.Context, input string) (string, error) {
+	op, arg, ok := strings.Cut(input, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid format, use 'operation:argument'")
+	}
+	arg = strings.TrimSpace(arg)
+
+	switch strings.ToLower(strings.TrimSpace(op)) {
+	case "simplify":
+		e, err := parseSym(arg)
+		if err != nil {
+			return "", err
+		}
+		simplified, err := checkDivByZero(simplifyBest(e))
+		if err != nil {
+			return "", err
+		}
+		return simplified.String(), nil
+	case "expand":
+		e, err := parseSym(arg)
+		if err != nil {
+			return "", err
+		}
+		expanded, err := expandSym(e)
+		if err == nil {
+			expanded, err = checkDivByZero(expanded)
+		}
+		if err != nil {
+			return "", err
+		}
+		return expanded.String(), nil
+	case "diff", "derivative":
+		return diffOp(arg)
+	case "solve":
+		return solveSym(arg)
+	case "eval", "subs":
+		return evalOp(arg)
+	case "matrix":
+		return matrixOp(arg)
+	default:
+		return "", fmt.Errorf("unknown operation: %s (use simplify, expand, diff, solve, eval or matrix)", op)
+	}
+}
+
+// diffOp handles '<expr>[, <var>[, <order>]]'
+func diffOp(arg string) (string, error) {
+	parts := splitTopLevel(arg, ',')
+	e, err := parseSym(parts[0])
+	if err != nil {
+		return "", err
+	}
+	var x string
+	if len(parts) > 1 {
+		x = strings.TrimSpace(parts[1])
+	} else if vars := sortedVars(e); len(vars) == 1 {
+		x = vars[0]
+	} else {
+		return "", fmt.Errorf("name the variable to differentiate by, e.g. diff:%s, x", strings.TrimSpace(parts[0]))
+	}
+	order := 1
+	if len(parts) > 2 {
+		if _, err := fmt.Sscan(strings.TrimSpace(parts[2]), &order); err != nil || order < 1 || order > 10 {
+			return "", fmt.Errorf("the derivative order must be 1 to 10")
+		}
+	}
+	d, err := checkDivByZero(simplifySym(e))
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < order; i++ {
+		d = diffSym(d, x)
+	}
+	return simplifyBest(d).String(), nil
+}
+
+// evalOp handles '<expr>, name=value, ...'
+func evalOp(arg string) (string, error) {
+	parts := splitTopLevel(arg, ',')
+	e, err := parseSym(parts[0])
+	if err != nil {
+		return "", err
+	}
+	values := make(map[string]*sym)
+	for _, part := range parts[1:] {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return "", fmt.Errorf("use name=value, got %q", strings.TrimSpace(part))
+		}
+		v, err := parseSym(value)
+		if err != nil {
+			return "", fmt.Errorf("value of %s: %w", strings.TrimSpace(name), err)
+		}
+		values[strings.TrimSpace(name)] = v
+	}
+	value, err := checkDivByZero(simplifyBest(substitute(e, values)))
+	if err != nil {
+		return "", err
+	}
+	return withApprox(value), nil
+}
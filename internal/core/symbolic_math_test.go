@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSymbolicMath(t *testing.T) {
+	tool := &SymbolicMathTool{}
+	for _, tc := range []struct{ input, want string }{
+		{"simplify:(x^2 - 1)/(x - 1)", "x + 1"},
+		{"simplify:(x+1)^2 - x^2", "2*x + 1"},
+		{"simplify:(x+1)^2", "(x + 1)^2"},
+		{"simplify:2x + 3x - x", "4*x"},
+		{"simplify:sqrt(8) + 1/2 + 1/3", "2*sqrt(2) + 5/6"},
+		{"simplify:ln(exp(3x))", "3*x"},
+		{"expand:(x + 2)^3", "x^3 + 6*x^2 + 12*x + 8"},
+		{"expand:(a+b)(a-b)", "a^2 - b^2"},
+		{"diff:x^2*sin(x), x", "x^2*cos(x) + 2*x*sin(x)"},
+		{"diff:ln(x^2+1)", "2*x/(x^2 + 1)"},
+		{"diff:x^3, x, 2", "6*x"},
+		{"diff:sqrt(x)", "1/(2*sqrt(x))"},
+		{"solve:x^2 - 5x + 6 = 0, x", "x = 3\nx = 2"},
+		{"solve:x^2 = 2", "x = sqrt(2) ≈ 1.41421\nx = -sqrt(2) ≈ -1.41421"},
+		{"solve:x^2 + 2x + 5 = 0", "x = -1 + 2i\nx = -1 - 2i"},
+		{"solve:x^2 - 2x + 1 = 0", "x = 1 (double root)"},
+		{"solve:x^3 - 6x^2 + 11x - 6 = 0", "x = 1\nx = 2\nx = 3"},
+		{"solve:x^5 - x - 1 = 0", "x ≈ 1.1673\nx ≈ -0.764884 - 0.352472i\nx ≈ -0.764884 + 0.352472i\nx ≈ 0.181232 - 1.08395i\nx ≈ 0.181232 + 1.08395i"},
+		{"solve:a*x + b = 0, x", "x = -b/a"},
+		{"solve:1/x = 4", "x = 1/4 ≈ 0.25"},
+		{"solve:x + y = 3; x - y = 1", "x = 2\ny = 1"},
+		{"solve:x + y = 3; 2x + 2y = 6", "infinitely many solutions (1 independent equation for 2 unknowns)"},
+		{"eval:x^2 + y, x=3, y=1/2", "19/2 ≈ 9.5"},
+		{"matrix:det [[a,b],[c,d]]", "a*d - b*c"},
+		{"matrix:inverse [[1,2],[3,4]]", "[[-2, 1], [3/2, -1/2]]"},
+		{"matrix:multiply [[1,2],[3,4]] [[5],[6]]", "[[17], [39]]"},
+		{"matrix:rank [[1,2],[2,4]]", "1"},
+		{"eval:1e-3*x, x=2", "1/500 ≈ 0.002"},
+		{"simplify:2e3", "2000"},
+		{"simplify:2.5E-1x", "x/4"},
+		{"simplify:2e", "2*e"},
+		{"simplify:1e400", "1" + strings.Repeat("0", 400)},
+		{"solve:x^2/x = 0, x", "no solution"},
+		{"solve:x^3/x - x = 0, x", "x = 1"},
+	} {
+		if got, err := tool.Execute(context.Background(), tc.input); err != nil || got != tc.want {
+			t.Errorf("%s = %q, %v; want %q", tc.input, got, err, tc.want)
+		}
+	}
+
+	got, err := tool.Execute(context.Background(), "solve:cos(x) = x")
+	if err != nil || !strings.HasPrefix(got, "x ≈ 0.739085\n(numeric search") {
+		t.Errorf("Expected a numeric root of cos(x) = x, got %q, %v", got, err)
+	}
+
+	for _, tc := range []struct{ input, want string }{
+		{"simplify:(x + ", "unexpected end"},
+		{"solve:x + y = 3", "name the variable"},
+		{"solve:x^2 + y^2 = 1; x - y = 0", "only linear systems"},
+		{"matrix:inverse [[1,2],[2,4]]", "singular"},
+		{"matrix:multiply [[1,2]] [[1,2]]", "cannot multiply a 1x2 by a 1x2"},
+		{"factor:x^2 - 1", "unknown operation"},
+		{"simplify:1e99999", "out of range"},
+		{"simplify:0/0", "division by zero"},
+		{"eval:0/0", "division by zero"},
+		{"simplify:0*(1/0)", "division by zero"},
+		{"simplify:1/0", "division by zero"},
+		{"simplify:1/(x - x)", "division by zero"},
+		{"eval:1/x, x=0", "division by zero"},
+		{"solve:1/0 = x, x", "division by zero"},
+	} {
+		if _, err := tool.Execute(context.Background(), tc.input); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.input, tc.want, err)
+		}
+	}
+}
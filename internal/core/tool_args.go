@@ -0,0 +1,330 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Strategy configs built from tool arguments, shared by the tool handlers and
+// reasoning_pipeline stages. Numeric and boolean arguments are declared in
+// strategyArgSpecs and validated by parseArgs.
+
+// Argument schemas of the strategy tools. Arguments that set a config field
+// have no default here, so an omitted one keeps the strategy's
+// Default*Config value.
+var (
+	sequentialArgSpecs = []argSpec{
+		{Name: "max_thoughts", Kind: argInt, Default: 10, Min: 1, Max: 100},
+	}
+	gotArgSpecs = []argSpec{
+		{Name: "branching_factor", Kind: argInt, Min: 1, Max: 10},
+		{Name: "max_nodes", Kind: argInt, Min: 1, Max: 1000},
+		{Name: "max_depth", Kind: argInt, Min: 1, Max: 100},
+		{Name: "enable_merging", Kind: argBool},
+		{Name: "enable_tools", Kind: argBool},
+		{Name: "max_tool_calls", Kind: argInt, Min: 0, Max: 100},
+		{Name: "similarity_prefilter", Kind: argNumber, Min: 0, Max: 1},
+		{Name: "similarity_band", Kind: argNumber, Min: 0, Max: 1},
+		{Name: "beam_width", Kind: argInt, Min: 1, Max: 100},
+		{Name: "enable_aggregation", Kind: argBool},
+		{Name: "aggregation_interval", Kind: argInt, Min: 1, Max: 100},
+		{Name: "aggregation_size", Kind: argInt, Min: 2, Max: 3},
+		{Name: "max_refinements", Kind: argInt, Min: 0, Max: 100},
+		{Name: "max_history_tokens", Kind: argInt, Min: 0, Max: noArgLimit},
+	}
+	gotContinueArgSpecs = []argSpec{
+		{Name: "additional_nodes", Kind: argInt, Default: 30, Min: 1, Max: 1000},
+		{Name: "max_depth", Kind: argInt, Min: 1, Max: 100},
+		{Name: "beam_width", Kind: argInt, Min: 1, Max: 100},
+		{Name: "enable_aggregation", Kind: argBool},
+		{Name: "max_refinements", Kind: argInt, Min: 0, Max: 100},
+		{Name: "max_history_tokens", Kind: argInt, Min: 0, Max: noArgLimit},
+	}
+	reflexionArgSpecs = []argSpec{
+		{Name: "max_attempts", Kind: argInt, Min: 1, Max: 20},
+		{Name: "learn_from_past", Kind: argBool},
+		{Name: "enable_tools", Kind: argBool},
+		{Name: "max_tool_calls", Kind: argInt, Min: 0, Max: 100},
+		{Name: "force_diversity", Kind: argBool},
+		{Name: "attempt_max_tokens", Kind: argInt, Min: 0, Max: noArgLimit},
+		{Name: "attempt_timeout_seconds", Kind: argNumber, Min: 0, Max: noArgLimit},
+		{Name: "evaluator_votes", Kind: argInt, Min: 1, Max: maxEvaluatorVotes},
+		{Name: "max_history_tokens", Kind: argInt, Min: 0, Max: noArgLimit},
+	}
+	dialecticArgSpecs = []argSpec{
+		{Name: "max_rounds", Kind: argInt, Min: 1, Max: 20},
+		{Name: "confidence_target", Kind: argNumber, Min: 0, Max: 1},
+		{Name: "num_challengers", Kind: argInt, Min: 1, Max: float64(len(challengerPerspectives))},
+		{Name: "rebuttals", Kind: argBool},
+		{Name: "checkpoints", Kind: argBool},
+		{Name: "fast_mode", Kind: argBool},
+		{Name: "max_tokens", Kind: argInt, Min: 1, Max: noArgLimit},
+		{Name: "enable_tools", Kind: argBool},
+		{Name: "max_tool_calls", Kind: argInt, Min: 0, Max: 100},
+		{Name: "max_tool_calls_per_phase", Kind: argInt, Min: 0, Max: 100},
+		{Name: "cache_verifications", Kind: argBool},
+		{Name: "open_questions", Kind: argBool},
+		{Name: "early_stop", Kind: argBool},
+		{Name: "max_history_tokens", Kind: argInt, Min: 0, Max: noArgLimit},
+	}
+	decomposeArgSpecs = []argSpec{
+		{Name: "max_subproblems", Kind: argInt, Min: 1, Max: 20},
+	}
+	planExecuteArgSpecs = []argSpec{
+		{Name: "max_steps", Kind: argInt, Min: 1, Max: 100},
+		{Name: "max_replans", Kind: argInt, Min: 0, Max: 20},
+		{Name: "enable_tools", Kind: argBool},
+		{Name: "max_tool_calls", Kind: argInt, Min: 0, Max: 100},
+	}
+)
+
+// strategyArgSpecs maps each strategy to its argument schema
+var strategyArgSpecs = map[string][]argSpec{
+	"sequential_thinking": sequentialArgSpecs,
+	"graph_of_thoughts":   gotArgSpecs,
+	"reflexion":           reflexionArgSpecs,
+	"dialectic_reason":    dialecticArgSpecs,
+	"decompose_solve":     decomposeArgSpecs,
+	"plan_execute":        planExecuteArgSpecs,
+}
+
+// maxThoughtsFromArgs returns sequential_thinking's step limit
+func maxThoughtsFromArgs(args map[string]interface{}) (int, error) {
+	parsed, err := parseArgs(args, sequentialArgSpecs)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Int("max_thoughts"), nil
+}
+
+// enabledToolsFromArgs splits enabled_tools, dropping unknown tool names
+func enabledToolsFromArgs(args map[string]interface{}) ([]string, bool) {
+	tools, ok := args["enabled_tools"].(string)
+	if !ok || tools == "" {
+		return nil, false
+	}
+	toolList := strings.Split(tools, ",")
+	for i := range toolList {
+		toolList[i] = strings.TrimSpace(toolList[i])
+	}
+	return validateToolNames(toolList, getAvailableToolNames()), true
+}
+
+// gotConfigFromArgs builds a Graph of Thoughts config from tool arguments
+func gotConfigFromArgs(args map[string]interface{}) (GoTConfig, error) {
+	config := DefaultGoTConfig()
+	parsed, err := parseArgs(args, gotArgSpecs)
+	if err != nil {
+		return config, err
+	}
+	parsed.SetInt(&config.BranchingFactor, "branching_factor")
+	parsed.SetInt(&config.MaxNodes, "max_nodes")
+	parsed.SetInt(&config.MaxDepth, "max_depth")
+	parsed.SetBool(&config.EnableMerging, "enable_merging")
+	parsed.SetBool(&config.EnableTools, "enable_tools")
+	parsed.SetInt(&config.MaxToolCalls, "max_tool_calls")
+	if tools, ok := enabledToolsFromArgs(args); ok {
+		config.EnabledTools = tools
+	}
+	if backend := getStringArgOrEnv(args, "similarity_backend", "GOT_SIMILARITY_BACKEND"); backend != "" {
+		config.SimilarityBackend = backend
+	}
+	parsed.SetFloat(&config.SimilarityPrefilter, "similarity_prefilter")
+	parsed.SetFloat(&config.SimilarityBand, "similarity_band")
+	if policy := getStringArgOrEnv(args, "expansion_policy", "GOT_EXPANSION_POLICY"); policy != "" {
+		config.ExpansionPolicy = policy
+	}
+	parsed.SetInt(&config.BeamWidth, "beam_width")
+	parsed.SetBool(&config.EnableAggregation, "enable_aggregation")
+	parsed.SetInt(&config.AggregationInterval, "aggregation_interval")
+	parsed.SetInt(&config.AggregationSize, "aggregation_size")
+	parsed.SetInt(&config.MaxRefinements, "max_refinements")
+	config.MaxHistoryTokens = maxHistoryTokensFromArgs(parsed, "graph_of_thoughts", config.MaxHistoryTokens)
+	config.Calibration = calibrationConfigFromArgs(args, "graph_of_thoughts")
+	return config, nil
+}
+
+// reflexionConfigFromArgs builds a Reflexion config from tool arguments.
+// Attempt providers are wrapped with llmCalls so rotations share the run's cap.
+func reflexionConfigFromArgs(args map[string]interface{}, llmCalls *LLMCallCounter) (ReflexionConfig, error) {
+	config := DefaultReflexionConfig()
+	parsed, err := parseArgs(args, reflexionArgSpecs)
+	if err != nil {
+		return config, err
+	}
+	parsed.SetInt(&config.MaxAttempts, "max_attempts")
+	parsed.SetBool(&config.LearnFromPast, "learn_from_past")
+	if mc, ok := args["memory_category"].(string); ok {
+		category, err := parseCategoryFilter(mc)
+		if err != nil {
+			return config, err
+		}
+		config.MemoryCategory = category
+	}
+	if ns, ok := args["memory_namespace"].(string); ok {
+		namespace, err := parseMemoryNamespace(ns)
+		if err != nil {
+			return config, err
+		}
+		config.MemoryNamespace = namespace
+	}
+	parsed.SetBool(&config.EnableTools, "enable_tools")
+	parsed.SetInt(&config.MaxToolCalls, "max_tool_calls")
+	if tools, ok := enabledToolsFromArgs(args); ok {
+		config.EnabledTools = tools
+	}
+	if rotation := getStringArgOrEnv(args, "attempt_providers", toolEnvKey("reflexion", "ATTEMPT_PROVIDERS")); rotation != "" {
+		providers, err := ParseAttemptProviders(rotation)
+		if err != nil {
+			return config, fmt.Errorf("Provider error: %v", err)
+		}
+		for i := range providers {
+			providers[i].Provider = llmCalls.Wrap(providers[i].Provider)
+		}
+		config.AttemptProviders = providers
+	}
+	parsed.SetBool(&config.ForceDiversity, "force_diversity")
+	config.AttemptMaxTokens = parseEnvInt(toolEnvKey("reflexion", "ATTEMPT_MAX_TOKENS"), 0)
+	parsed.SetInt(&config.AttemptMaxTokens, "attempt_max_tokens")
+	config.AttemptTimeout = time.Duration(parseEnvInt(toolEnvKey("reflexion", "ATTEMPT_TIMEOUT"), 0)) * time.Second
+	if parsed.Has("attempt_timeout_seconds") {
+		config.AttemptTimeout = time.Duration(parsed.Float("attempt_timeout_seconds") * float64(time.Second))
+	}
+	config.MaxHistoryTokens = maxHistoryTokensFromArgs(parsed, "reflexion", config.MaxHistoryTokens)
+	if tc, ok := args["test_cases"].(string); ok && strings.TrimSpace(tc) != "" {
+		if os.Getenv("CODE_EXEC_ENABLED") != "true" && os.Getenv("CODE_EXEC_ENABLED") != "1" {
+			return config, fmt.Errorf("test_cases requires code execution; set CODE_EXEC_ENABLED=true")
+		}
+		tests, err := ParseHiddenTests(tc)
+		if err != nil {
+			return config, err
+		}
+		config.HiddenTests = tests
+	}
+	if sc, ok := args["success_criteria"].(string); ok && strings.TrimSpace(sc) != "" {
+		criteria, err := ParseSuccessCriteria(sc)
+		if err != nil {
+			return config, err
+		}
+		if strings.TrimSpace(criteria.Script) != "" && os.Getenv("CODE_EXEC_ENABLED") != "true" && os.Getenv("CODE_EXEC_ENABLED") != "1" {
+			return config, fmt.Errorf("a success_criteria script requires code execution; set CODE_EXEC_ENABLED=true")
+		}
+		config.SuccessCriteria = criteria
+	}
+	strictness, err := parseStrictness(getStringArgOrEnv(args, "evaluator_strictness", toolEnvKey("reflexion", "EVALUATOR_STRICTNESS")))
+	if err != nil {
+		return config, err
+	}
+	config.EvaluatorStrictness = strictness
+	config.EvaluatorVotes = parseEnvInt(toolEnvKey("reflexion", "EVALUATOR_VOTES"), config.EvaluatorVotes)
+	parsed.SetInt(&config.EvaluatorVotes, "evaluator_votes")
+	if panel := getStringArgOrEnv(args, "evaluators", toolEnvKey("reflexion", "EVALUATORS")); panel != "" {
+		evaluators, err := ParseAttemptProviders(panel)
+		if err != nil {
+			return config, fmt.Errorf("Provider error: evaluators: %v", err)
+		}
+		for i := range evaluators {
+			evaluators[i].Provider = llmCalls.Wrap(evaluators[i].Provider)
+		}
+		config.Evaluators = evaluators
+	}
+	if config.EvaluatorVotes > maxEvaluatorVotes {
+		config.EvaluatorVotes = maxEvaluatorVotes
+	}
+	config.Calibration = calibrationConfigFromArgs(args, "reflexion")
+	return config, nil
+}
+
+// verificationPhases are the tool budget phases of claim verification: the
+// debate's claim types and verify_claim's single claim
+var verificationPhases = []string{"thesis", "antithesis", "rebuttal", "synthesis", "claim"}
+
+// phaseToolBudgets caps each verification phase at perPhase tool calls
+func phaseToolBudgets(perPhase int) map[string]int {
+	budgets := make(map[string]int, len(verificationPhases))
+	for _, phase := range verificationPhases {
+		budgets[phase] = perPhase
+	}
+	return budgets
+}
+
+// dialecticConfigFromArgs builds a dialectic config from tool arguments
+func dialecticConfigFromArgs(args map[string]interface{}) (DialecticConfig, error) {
+	config := DefaultDialecticConfig()
+	parsed, err := parseArgs(args, dialecticArgSpecs)
+	if err != nil {
+		return config, err
+	}
+	parsed.SetInt(&config.MaxRounds, "max_rounds")
+	parsed.SetFloat(&config.ConfidenceTarget, "confidence_target")
+	parsed.SetInt(&config.NumChallengers, "num_challengers")
+	parsed.SetBool(&config.Rebuttals, "rebuttals")
+	parsed.SetBool(&config.Checkpoints, "checkpoints")
+	parsed.SetBool(&config.FastMode, "fast_mode")
+	if parsed.Has("max_tokens") {
+		config.MaxTokens = clampMaxTokens(parsed.Int("max_tokens"))
+	}
+	parsed.SetBool(&config.EnableTools, "enable_tools")
+	parsed.SetInt(&config.MaxToolCalls, "max_tool_calls")
+	parsed.SetBool(&config.CacheVerifications, "cache_verifications")
+	parsed.SetBool(&config.OpenQuestions, "open_questions")
+	parsed.SetBool(&config.EarlyStop, "early_stop")
+	config.MaxHistoryTokens = maxHistoryTokensFromArgs(parsed, "dialectic_reason", config.MaxHistoryTokens)
+	if parsed.Has("max_tool_calls_per_phase") {
+		config.PhaseToolBudgets = phaseToolBudgets(parsed.Int("max_tool_calls_per_phase"))
+	}
+	if tools, ok := enabledToolsFromArgs(args); ok {
+		config.EnabledTools = tools
+	}
+
+	if model := getStringArgOrEnv(args, "thesis_model", toolEnvKey("dialectic_reason", "THESIS_MODEL")); model != "" {
+		config.ThesisModel = model
+	}
+	if model := getStringArgOrEnv(args, "antithesis_model", toolEnvKey("dialectic_reason", "ANTITHESIS_MODEL")); model != "" {
+		config.AntithesisModel = model
+	}
+	if model := getStringArgOrEnv(args, "synthesis_model", toolEnvKey("dialectic_reason", "SYNTHESIS_MODEL")); model != "" {
+		config.SynthesisModel = model
+	}
+	config.Calibration = calibrationConfigFromArgs(args, "dialectic_reason")
+	return config, nil
+}
+
+// maxHistoryTokensFromArgs returns a strategy's history summarization
+// threshold: max_history_tokens, else <TOOL>_MAX_HISTORY_TOKENS, else def
+func maxHistoryTokensFromArgs(parsed parsedArgs, toolName string, def int) int {
+	if parsed.Has("max_history_tokens") {
+		return parsed.Int("max_history_tokens")
+	}
+	return parseEnvInt(toolEnvKey(toolName, "MAX_HISTORY_TOKENS"), def)
+}
+
+// decomposeConfigFromArgs builds a decompose_solve config from tool arguments
+func decomposeConfigFromArgs(args map[string]interface{}) (DecomposeConfig, error) {
+	config := DefaultDecomposeConfig()
+	parsed, err := parseArgs(args, decomposeArgSpecs)
+	if err != nil {
+		return config, err
+	}
+	parsed.SetInt(&config.MaxSubproblems, "max_subproblems")
+	return config, nil
+}
+
+// planExecuteConfigFromArgs builds a plan_execute config from tool arguments
+func planExecuteConfigFromArgs(args map[string]interface{}) (PlanExecuteConfig, error) {
+	config := DefaultPlanExecuteConfig()
+	parsed, err := parseArgs(args, planExecuteArgSpecs)
+	if err != nil {
+		return config, err
+	}
+	parsed.SetInt(&config.MaxSteps, "max_steps")
+	parsed.SetInt(&config.MaxReplans, "max_replans")
+	parsed.SetBool(&config.EnableTools, "enable_tools")
+	parsed.SetInt(&config.MaxToolCalls, "max_tool_calls")
+	if tools, ok := enabledToolsFromArgs(args); ok {
+		config.EnabledTools = tools
+	}
+	return config, nil
+}
@@ -0,0 +1,313 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"reasoning-tools/utils"
+)
+
+// ============ Tool Audit Log ============
+//
+// With TOOL_AUDIT=true (or TOOL_AUDIT_PATH set), every ToolRegistry.Execute
+// call is appended to a JSONL audit log: when it ran, in which strategy run
+// and session, the tool and input, the output cut to TOOL_AUDIT_OUTPUT_CHARS
+// characters (default 500), the duration and whether it succeeded. The
+// tool_audit tool queries the log for compliance review.
+
+const (
+	defaultToolAuditOutputChars = 500
+	maxToolAuditInputChars      = 4000
+	defaultToolAuditLimit       = 50
+	maxToolAuditLimit           = 1000
+)
+
+// ToolAuditRecord is one audited tool call
+type ToolAuditRecord struct {
+	Time        time.Time `json:"time"`
+	Instance    string    `json:"instance"`           // Server process; run IDs restart with each process
+	RunID       string    `json:"run_id,omitempty"`   // Strategy run the call belonged to
+	Strategy    string    `json:"strategy,omitempty"` // MCP tool of that run
+	SessionID   string    `json:"session_id,omitempty"`
+	Tool        string    `json:"tool"`
+	Input       string    `json:"input"`
+	Output      string    `json:"output,omitempty"`
+	OutputChars int       `json:"output_chars"`
+	DurationMs  int64     `json:"duration_ms"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error,omitempty"`
+	Reused      bool      `json:"reused,omitempty"` // Answered from the run's scratchpad
+}
+
+// ToolAuditLog is an append-only JSONL store of tool calls, rotated to
+// <path>.1 once it grows past maxBytes
+type ToolAuditLog struct {
+	mu          sync.Mutex
+	path        string
+	maxBytes    int64
+	outputChars int
+}
+
+// NewToolAuditLog creates an audit log at path
+func NewToolAuditLog(path string, maxBytes int64, outputChars int) (*ToolAuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create tool audit directory: %w", err)
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultRunHistoryMaxBytes
+	}
+	return &ToolAuditLog{path: path, maxBytes: maxBytes, outputChars: outputChars}, nil
+}
+
+// Append adds a record to the log
+func (l *ToolAuditLog) Append(rec ToolAuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if info, err := os.Stat(l.path); err == nil && info.Size()+int64(len(line)) > l.maxBytes {
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate tool audit log: %w", err)
+		}
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load returns the records matching filter, oldest first. Unparseable lines
+// are skipped.
+func (l *ToolAuditLog) Load(filter ToolAuditFilter) ([]ToolAuditRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var records []ToolAuditRecord
+	for _, path := range []string{l.path + ".1", l.path} {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for scanner.Scan() {
+			var rec ToolAuditRecord
+			if json.Unmarshal(scanner.Bytes(), &rec) != nil {
+				continue
+			}
+			if filter.matches(rec) {
+				records = append(records, rec)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// ToolAuditFilter selects audit records; zero fields match everything
+type ToolAuditFilter struct {
+	Since, Until time.Time
+	RunID        string
+	Tool         string
+	Strategy     string
+	SessionID    string
+	FailuresOnly bool
+}
+
+func (f ToolAuditFilter) matches(rec ToolAuditRecord) bool {
+	switch {
+	case !f.Since.IsZero() && rec.Time.Before(f.Since),
+		!f.Until.IsZero() && !rec.Time.Before(f.Until),
+		f.RunID != "" && rec.RunID != f.RunID,
+		f.Tool != "" && rec.Tool != f.Tool,
+		f.Strategy != "" && rec.Strategy != f.Strategy,
+		f.SessionID != "" && rec.SessionID != f.SessionID,
+		f.FailuresOnly && rec.Success:
+		return false
+	}
+	return true
+}
+
+var (
+	toolAuditLog      *ToolAuditLog
+	toolAuditLogOnce  sync.Once
+	toolAuditInstance = fmt.Sprintf("%s-%d", time.Now().UTC().Format("20060102T150405"), os.Getpid())
+)
+
+// getToolAuditLog returns the shared audit log, or nil unless TOOL_AUDIT=true
+// or TOOL_AUDIT_PATH is set. TOOL_AUDIT_MAX_MB sets the rotation size.
+func getToolAuditLog() *ToolAuditLog {
+	toolAuditLogOnce.Do(func() {
+		path := os.Getenv("TOOL_AUDIT_PATH")
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("TOOL_AUDIT"))) {
+		case "false", "0", "off":
+			return
+		case "true", "1", "on":
+		default:
+			if path == "" {
+				return
+			}
+		}
+		if path == "" {
+			homeDir, _ := os.UserHomeDir()
+			path = filepath.Join(homeDir, ".local", "share", "reasoning-tools", "tool_audit.jsonl")
+		}
+
+		log, err := NewToolAuditLog(path, int64(parseEnvInt("TOOL_AUDIT_MAX_MB", 0))<<20,
+			parseEnvInt("TOOL_AUDIT_OUTPUT_CHARS", defaultToolAuditOutputChars))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (tool calls will not be audited)\n", err)
+			return
+		}
+		toolAuditLog = log
+	})
+	return toolAuditLog
+}
+
+// auditToolCall records a finished tool call in log
+func auditToolCall(ctx context.Context, log *ToolAuditLog, result ToolResult, start time.Time) {
+	if log == nil {
+		return
+	}
+	rec := ToolAuditRecord{
+		Time:        start.UTC(),
+		Instance:    toolAuditInstance,
+		Tool:        result.Tool,
+		Input:       utils.TruncateStr(result.Input, maxToolAuditInputChars),
+		OutputChars: len(result.Output),
+		DurationMs:  time.Since(start).Milliseconds(),
+		Success:     result.Success,
+		Error:       result.Error,
+		Reused:      result.Reused,
+	}
+	if log.outputChars > 0 {
+		rec.Output = utils.TruncateStr(result.Output, log.outputChars)
+	}
+	if run := queueRunFromContext(ctx); run != nil {
+		rec.RunID, rec.Strategy, rec.SessionID = run.ID, run.Tool, run.SessionID
+	}
+	if err := log.Append(rec); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to audit %s call: %v\n", result.Tool, err)
+	}
+}
+
+// ToolAuditSummary aggregates the matching calls of one tool
+type ToolAuditSummary struct {
+	Calls         int     `json:"calls"`
+	Failures      int     `json:"failures"`
+	Reused        int     `json:"reused,omitempty"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+	MaxDurationMs int64   `json:"max_duration_ms"`
+}
+
+// ToolAuditReport is the tool_audit result
+type ToolAuditReport struct {
+	Total    int                          `json:"total"`
+	Returned int                          `json:"returned"`
+	ByTool   map[string]*ToolAuditSummary `json:"by_tool"`
+	Records  []ToolAuditRecord            `json:"records"` // Newest first
+}
+
+func buildToolAuditReport(records []ToolAuditRecord, limit int) ToolAuditReport {
+	report := ToolAuditReport{Total: len(records), ByTool: make(map[string]*ToolAuditSummary)}
+	totals := make(map[string]int64)
+	for _, rec := range records {
+		s := report.ByTool[rec.Tool]
+		if s == nil {
+			s = &ToolAuditSummary{}
+			report.ByTool[rec.Tool] = s
+		}
+		s.Calls++
+		if !rec.Success {
+			s.Failures++
+		}
+		if rec.Reused {
+			s.Reused++
+		}
+		totals[rec.Tool] += rec.DurationMs
+		s.MaxDurationMs = max(s.MaxDurationMs, rec.DurationMs)
+	}
+	for tool, s := range report.ByTool {
+		s.AvgDurationMs = roundTo(float64(totals[tool])/float64(s.Calls), 1)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.After(records[j].Time) })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	report.Records = records
+	report.Returned = len(records)
+	return report
+}
+
+func handleToolAudit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		args = map[string]interface{}{}
+	}
+
+	var filter ToolAuditFilter
+	var err error
+	since, _ := args["since"].(string)
+	if filter.Since, err = parseAnalyticsTime(since); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("since: %v", err)), nil
+	}
+	until, _ := args["until"].(string)
+	if filter.Until, err = parseAnalyticsTime(until); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("until: %v", err)), nil
+	}
+	if len(strings.TrimSpace(until)) == len("2006-01-02") {
+		filter.Until = filter.Until.AddDate(0, 0, 1) // A date bound includes the whole day
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return mcp.NewToolResultError("since must be before until"), nil
+	}
+	filter.RunID, _ = args["run_id"].(string)
+	filter.Tool, _ = args["tool"].(string)
+	filter.Strategy, _ = args["strategy"].(string)
+	filter.SessionID, _ = args["session_id"].(string)
+	filter.FailuresOnly, _ = args["failures_only"].(bool)
+
+	limit := defaultToolAuditLimit
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = min(int(l), maxToolAuditLimit)
+	}
+
+	log := getToolAuditLog()
+	if log == nil {
+		return mcp.NewToolResultError("the tool audit log is off; set TOOL_AUDIT=true or TOOL_AUDIT_PATH to record tool calls"), nil
+	}
+	records, err := log.Load(filter)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read tool audit log: %v", err)), nil
+	}
+
+	output, err := json.MarshalIndent(buildToolAuditReport(records, limit), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize tool audit: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
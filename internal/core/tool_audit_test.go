@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToolAudit_RecordsExecuteCalls(t *testing.T) {
+	log, err := NewToolAuditLog(filepath.Join(t.TempDir(), "tool_audit.jsonl"), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := NewToolRegistry()
+	registry.audit = log
+
+	ctx := context.WithValue(context.Background(), queueRunKey{},
+		&queueRun{ID: "run-7", Tool: "reflexion", SessionID: "s1", Started: time.Now()})
+	registry.Execute(ctx, "string_ops", "upper:abcdefghijklmnop")
+	registry.Execute(ctx, "nope", "x")
+	registry.Execute(context.Background(), "calculator", "2 + 3")
+
+	records, err := log.Load(ToolAuditFilter{RunID: "run-7"})
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected the run's 2 calls, got %v, %v", records, err)
+	}
+	rec := records[0]
+	if rec.Tool != "string_ops" || !rec.Success || rec.Strategy != "reflexion" || rec.SessionID != "s1" || rec.Instance == "" {
+		t.Errorf("Unexpected record: %+v", rec)
+	}
+	if rec.OutputChars != 16 || rec.Output != "ABCDEFGHIJ..." {
+		t.Errorf("Expected the output cut to 10 chars from %d, got %q", rec.OutputChars, rec.Output)
+	}
+	if records[1].Success || !strings.Contains(records[1].Error, "unknown tool") {
+		t.Errorf("Expected the unknown tool call recorded as a failure, got %+v", records[1])
+	}
+
+	if failures, _ := log.Load(ToolAuditFilter{FailuresOnly: true}); len(failures) != 1 || failures[0].Tool != "nope" {
+		t.Errorf("Expected only the failed call, got %v", failures)
+	}
+	if calc, _ := log.Load(ToolAuditFilter{Tool: "calculator"}); len(calc) != 1 || calc[0].RunID != "" {
+		t.Errorf("Expected one calculator call outside any run, got %v", calc)
+	}
+}
+
+func TestBuildToolAuditReport(t *testing.T) {
+	base := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	records := []ToolAuditRecord{
+		{Time: base, Tool: "code_exec", DurationMs: 100, Success: true},
+		{Time: base.Add(time.Minute), Tool: "code_exec", DurationMs: 300},
+		{Time: base.Add(2 * time.Minute), Tool: "calculator", DurationMs: 1, Success: true, Reused: true},
+	}
+
+	report := buildToolAuditReport(records, 2)
+	if report.Total != 3 || report.Returned != 2 || report.Records[0].Tool != "calculator" {
+		t.Errorf("Expected the 2 newest of 3 records, got %+v", report)
+	}
+	exec := report.ByTool["code_exec"]
+	if exec == nil || exec.Calls != 2 || exec.Failures != 1 || exec.AvgDurationMs != 200 || exec.MaxDurationMs != 300 {
+		t.Errorf("Unexpected code_exec totals: %+v", exec)
+	}
+	if calc := report.ByTool["calculator"]; calc == nil || calc.Reused != 1 {
+		t.Errorf("Unexpected calculator totals: %+v", calc)
+	}
+}
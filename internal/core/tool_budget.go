@@ -0,0 +1,109 @@
+package core
+
+import "sync"
+
+// ToolBudget tracks tool calls against a total limit with optional per-phase
+// sub-budgets. A phase call consumes from both its sub-budget and the total.
+// It is safe for concurrent use.
+type ToolBudget struct {
+	mu          sync.Mutex
+	total       int // Negative means unlimited
+	used        int
+	phaseLimits map[string]int
+	phaseUsed   map[string]int
+}
+
+// NewToolBudget creates a budget allowing total calls (negative = unlimited)
+func NewToolBudget(total int) *ToolBudget {
+	return &ToolBudget{
+		total:       total,
+		phaseLimits: make(map[string]int),
+		phaseUsed:   make(map[string]int),
+	}
+}
+
+// SetPhaseLimit caps how many calls a phase may make (negative removes the cap)
+func (b *ToolBudget) SetPhaseLimit(phase string, limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if limit < 0 {
+		delete(b.phaseLimits, phase)
+		return
+	}
+	b.phaseLimits[phase] = limit
+}
+
+// SetUsed records calls already made, e.g. when resuming a saved run
+func (b *ToolBudget) SetUsed(used int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used = used
+}
+
+// TryConsume reserves one call for phase, returning false if either the
+// phase sub-budget or the total budget is exhausted
+func (b *ToolBudget) TryConsume(phase string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remainingLocked(phase) == 0 {
+		return false
+	}
+	b.used++
+	b.phaseUsed[phase]++
+	return true
+}
+
+// Available reports whether phase can make at least one more call
+func (b *ToolBudget) Available(phase string) bool {
+	return b.Remaining(phase) != 0
+}
+
+// Remaining returns the calls left for phase, bounded by the total budget.
+// Returns -1 when neither limit applies.
+func (b *ToolBudget) Remaining(phase string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remainingLocked(phase)
+}
+
+func (b *ToolBudget) remainingLocked(phase string) int {
+	remaining := -1
+	if b.total >= 0 {
+		remaining = max(0, b.total-b.used)
+	}
+	if limit, ok := b.phaseLimits[phase]; ok {
+		phaseRemaining := max(0, limit-b.phaseUsed[phase])
+		if remaining < 0 || phaseRemaining < remaining {
+			remaining = phaseRemaining
+		}
+	}
+	return remaining
+}
+
+// Used returns the total number of calls made
+func (b *ToolBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// PhaseUsage returns calls made per phase
+func (b *ToolBudget) PhaseUsage() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	usage := make(map[string]int, len(b.phaseUsed))
+	for phase, n := range b.phaseUsed {
+		usage[phase] = n
+	}
+	return usage
+}
+
+// remainingPtr returns the remaining budget for phase in the form used by
+// ProgressUpdate, or nil when the budget is unlimited
+func (b *ToolBudget) remainingPtr(phase string) *int {
+	remaining := b.Remaining(phase)
+	if remaining < 0 {
+		return nil
+	}
+	return &remaining
+}
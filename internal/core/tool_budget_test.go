@@ -0,0 +1,89 @@
+package core
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestToolBudget_TotalAndPhaseLimits(t *testing.T) {
+	b := NewToolBudget(3)
+	b.SetPhaseLimit("thesis", 1)
+
+	if !b.TryConsume("thesis") {
+		t.Fatal("Expected first thesis call to succeed")
+	}
+	if b.TryConsume("thesis") {
+		t.Error("Expected thesis sub-budget to be exhausted")
+	}
+	if got := b.Remaining("thesis"); got != 0 {
+		t.Errorf("Expected 0 remaining for thesis, got %d", got)
+	}
+
+	// Phases without a cap draw only from the total
+	if !b.TryConsume("synthesis") || !b.TryConsume("synthesis") {
+		t.Fatal("Expected synthesis calls within total to succeed")
+	}
+	if b.TryConsume("synthesis") {
+		t.Error("Expected total budget to be exhausted")
+	}
+	if b.Used() != 3 {
+		t.Errorf("Expected 3 calls used, got %d", b.Used())
+	}
+
+	usage := b.PhaseUsage()
+	if usage["thesis"] != 1 || usage["synthesis"] != 2 {
+		t.Errorf("Unexpected phase usage: %v", usage)
+	}
+}
+
+func TestToolBudget_Unlimited(t *testing.T) {
+	b := NewToolBudget(-1)
+	if got := b.Remaining("any"); got != -1 {
+		t.Errorf("Expected -1 remaining for unlimited budget, got %d", got)
+	}
+	if b.remainingPtr("any") != nil {
+		t.Error("Expected nil remaining pointer for unlimited budget")
+	}
+
+	b.SetPhaseLimit("attempt_1", 2)
+	if p := b.remainingPtr("attempt_1"); p == nil || *p != 2 {
+		t.Errorf("Expected phase cap to apply under unlimited total, got %v", p)
+	}
+}
+
+func TestToolBudget_ZeroTotal(t *testing.T) {
+	b := NewToolBudget(0)
+	if b.Available("expansion") || b.TryConsume("expansion") {
+		t.Error("Expected zero budget to allow no calls")
+	}
+}
+
+func TestToolBudget_SetUsed(t *testing.T) {
+	b := NewToolBudget(5)
+	b.SetUsed(4)
+	if got := b.Remaining("expansion"); got != 1 {
+		t.Errorf("Expected 1 remaining after restoring usage, got %d", got)
+	}
+}
+
+func TestToolBudget_Concurrent(t *testing.T) {
+	b := NewToolBudget(50)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	granted := 0
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b.TryConsume("p") {
+				mu.Lock()
+				granted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if granted != 50 || b.Used() != 50 {
+		t.Errorf("Expected exactly 50 grants, got %d (used %d)", granted, b.Used())
+	}
+}
@@ -0,0 +1,330 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value     string
+	expiresAt time.Time
+	createdAt time.Time
+}
+
+type ToolCache struct {
+	mu         sync.Mutex
+	items      map[string]cacheEntry
+	ttl        time.Duration
+	maxEntries int
+	shared     *RedisCache // Optional layer shared by replicas, behind the in-memory map
+	disk       *DiskCache  // Optional persistent layer behind the shared one
+	hits       int
+	sharedHits int
+	diskHits   int
+	misses     int
+}
+
+// ToolCacheStats reports cache size and hit rates
+type ToolCacheStats struct {
+	Enabled       bool            `json:"enabled"`
+	TTLSeconds    int             `json:"ttl_seconds,omitempty"`
+	MemoryEntries int             `json:"memory_entries"`
+	MaxEntries    int             `json:"max_entries,omitempty"`
+	Hits          int             `json:"hits"`
+	Shared        bool            `json:"shared,omitempty"` // Backed by Redis, shared with other replicas
+	SharedHits    int             `json:"shared_hits,omitempty"`
+	DiskHits      int             `json:"disk_hits"`
+	Misses        int             `json:"misses"`
+	Disk          *DiskCacheStats `json:"disk,omitempty"`
+}
+
+const (
+	defaultToolCacheMaxEntries = 256
+)
+
+func NewToolCache(ttl time.Duration, maxEntries int) *ToolCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultToolCacheMaxEntries
+	}
+	return &ToolCache{
+		items:      make(map[string]cacheEntry),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *ToolCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.items[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(c.items, key)
+		ok = false
+	}
+	if ok {
+		c.hits++
+		return entry.value, true
+	}
+
+	if c.shared != nil {
+		if value, ok := c.shared.Get(key); ok {
+			c.sharedHits++
+			c.setLocked(key, value)
+			return value, true
+		}
+	}
+	if c.disk != nil {
+		if entry, ok := c.disk.lookup(key); ok {
+			// The entry keeps the expiry it was written with, so a restart
+			// or a memory eviction never extends its life
+			c.diskHits++
+			c.storeLocked(key, cacheEntry{value: entry.Value, createdAt: entry.CreatedAt, expiresAt: entry.ExpiresAt})
+			return entry.Value, true
+		}
+	}
+	c.misses++
+	return "", false
+}
+
+func (c *ToolCache) Set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setLocked(key, value)
+	if c.shared != nil {
+		if err := c.shared.Set(key, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to share cache entry: %v\n", err)
+		}
+	}
+	if c.disk != nil {
+		if err := c.disk.Set(key, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to persist cache entry: %v\n", err)
+		}
+	}
+}
+
+func (c *ToolCache) setLocked(key, value string) {
+	now := time.Now()
+	c.storeLocked(key, cacheEntry{value: value, createdAt: now, expiresAt: now.Add(c.ttl)})
+}
+
+// storeLocked puts entry in memory, evicting the oldest entries over maxEntries
+func (c *ToolCache) storeLocked(key string, entry cacheEntry) {
+	c.items[key] = entry
+
+	if c.maxEntries > 0 && len(c.items) > c.maxEntries {
+		c.evictOldest(len(c.items) - c.maxEntries)
+	}
+}
+
+// Similar returns the unexpired in-memory result whose problem shares the
+// most words with problem, at or above minSimilarity. It needs no provider,
+// so degraded results can use it while providers are down.
+func (c *ToolCache) Similar(problem string, minSimilarity float64) (string, float64, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var best cacheEntry
+	bestScore := 0.0
+	now := time.Now()
+	for _, entry := range c.items {
+		if now.After(entry.expiresAt) {
+			continue
+		}
+		cached, _ := runResultFields(entry.value)["problem"].(string)
+		if score := stringSimilarity(cached, problem); score >= minSimilarity && score > bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	return best.value, bestScore, best.createdAt, bestScore > 0
+}
+
+// Stats returns entry counts and hit/miss totals since startup
+func (c *ToolCache) Stats() ToolCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := ToolCacheStats{
+		Enabled:       true,
+		TTLSeconds:    int(c.ttl.Seconds()),
+		MemoryEntries: len(c.items),
+		MaxEntries:    c.maxEntries,
+		Hits:          c.hits,
+		Shared:        c.shared != nil,
+		SharedHits:    c.sharedHits,
+		DiskHits:      c.diskHits,
+		Misses:        c.misses,
+	}
+	if c.disk != nil {
+		ds := c.disk.Stats()
+		stats.Disk = &ds
+	}
+	return stats
+}
+
+// Clear drops all entries from memory and disk, returning how many were removed
+// from each
+func (c *ToolCache) Clear() (memory, disk int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	memory = len(c.items)
+	c.items = make(map[string]cacheEntry)
+	if c.disk != nil {
+		disk = c.disk.Clear()
+	}
+	return memory, disk
+}
+
+// ClearShared drops the entries shared with other replicas, returning how
+// many were removed
+func (c *ToolCache) ClearShared() int {
+	if c.shared == nil {
+		return 0
+	}
+	return c.shared.Clear()
+}
+
+func (c *ToolCache) evictOldest(count int) {
+	if count <= 0 {
+		return
+	}
+	type kv struct {
+		key       string
+		createdAt time.Time
+	}
+	var items []kv
+	for k, v := range c.items {
+		items = append(items, kv{key: k, createdAt: v.createdAt})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].createdAt.Before(items[j].createdAt)
+	})
+	for i := 0; i < count && i < len(items); i++ {
+		delete(c.items, items[i].key)
+	}
+}
+
+var (
+	toolCache     *ToolCache
+	toolCacheOnce sync.Once
+)
+
+func getToolCache() *ToolCache {
+	toolCacheOnce.Do(func() {
+		ttlSeconds := parseEnvInt("TOOL_CACHE_TTL", 0)
+		if ttlSeconds <= 0 {
+			toolCache = nil
+			return
+		}
+		maxEntries := parseEnvInt("TOOL_CACHE_MAX", defaultToolCacheMaxEntries)
+		ttl := time.Duration(ttlSeconds) * time.Second
+		toolCache = NewToolCache(ttl, maxEntries)
+		toolCache.shared = redisCacheFromEnv(ttl)
+		toolCache.disk = diskCacheFromEnv(ttl)
+	})
+	return toolCache
+}
+
+func parseEnvInt(key string, def int) int {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func buildToolCacheKey(toolName string, providerName string, args map[string]interface{}) string {
+	sanitized := sanitizeToolCacheArgs(args)
+	normalized := canonicalJSON(sanitized)
+	payload := fmt.Sprintf("%s|%s|%s", toolName, providerName, normalized)
+	sum := sha256.Sum256([]byte(payload))
+	return fmt.Sprintf("%x", sum)
+}
+
+func sanitizeToolCacheArgs(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return map[string]interface{}{}
+	}
+	out := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		switch k {
+		case "stream", "stream_mode", "stderr_stream", "mcp_logging", "mcp_progress":
+			continue
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func canonicalJSON(v interface{}) string {
+	var buf bytes.Buffer
+	writeCanonical(&buf, v)
+	return buf.String()
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		data, _ := json.Marshal(t)
+		buf.Write(data)
+	case bool:
+		if t {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case int:
+		buf.WriteString(strconv.Itoa(t))
+	case int64:
+		buf.WriteString(strconv.FormatInt(t, 10))
+	case float32:
+		buf.WriteString(strconv.FormatFloat(float64(t), 'f', -1, 32))
+	case float64:
+		buf.WriteString(strconv.FormatFloat(t, 'f', -1, 64))
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonical(buf, item)
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			keyBytes, _ := json.Marshal(k)
+			buf.Write(keyBytes)
+			buf.WriteByte(':')
+			writeCanonical(buf, t[k])
+		}
+		buf.WriteByte('}')
+	default:
+		buf.WriteString(fmt.Sprintf("%q", fmt.Sprintf("%v", t)))
+	}
+}
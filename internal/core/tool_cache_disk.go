@@ -0,0 +1,234 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDiskCacheMaxMB = 64
+	diskCacheExt          = ".json"
+)
+
+var diskCacheKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// DiskCache persists cached responses as content-addressed files, one per
+// key, so identical requests stay cached across server restarts. Entries
+// expire after ttl and the oldest are evicted once the directory exceeds
+// maxBytes.
+type DiskCache struct {
+	mu       sync.Mutex
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+}
+
+type diskCacheEntry struct {
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DiskCacheStats summarizes the files on disk
+type DiskCacheStats struct {
+	Dir      string `json:"dir"`
+	Entries  int    `json:"entries"`
+	Bytes    int64  `json:"bytes"`
+	MaxBytes int64  `json:"max_bytes"`
+}
+
+// NewDiskCache creates the cache directory if needed
+func NewDiskCache(dir string, ttl time.Duration, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir %s: %w", dir, err)
+	}
+	return &DiskCache{dir: dir, ttl: ttl, maxBytes: maxBytes}, nil
+}
+
+func (c *DiskCache) pathFor(key string) (string, bool) {
+	if !diskCacheKeyPattern.MatchString(key) {
+		return "", false
+	}
+	return filepath.Join(c.dir, key+diskCacheExt), true
+}
+
+// Get returns a cached value, removing it if it has expired
+func (c *DiskCache) Get(key string) (string, bool) {
+	entry, ok := c.lookup(key)
+	return entry.Value, ok
+}
+
+// lookup returns a cached entry with its times, removing it if it has expired
+func (c *DiskCache) lookup(key string) (diskCacheEntry, bool) {
+	path, ok := c.pathFor(key)
+	if !ok {
+		return diskCacheEntry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diskCacheEntry{}, false
+	}
+	var entry diskCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Now().After(entry.ExpiresAt) {
+		os.Remove(path)
+		return diskCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Set writes a value and evicts old entries if the size limit is exceeded
+func (c *DiskCache) Set(key, value string) error {
+	path, ok := c.pathFor(key)
+	if !ok {
+		return fmt.Errorf("invalid cache key")
+	}
+
+	now := time.Now()
+	data, err := json.Marshal(diskCacheEntry{Value: value, CreatedAt: now, ExpiresAt: now.Add(c.ttl)})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Write to a temp file first so readers never see a partial entry
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to finalize cache entry: %w", err)
+	}
+
+	c.evict()
+	return nil
+}
+
+type diskCacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *DiskCache) files() []diskCacheFile {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil
+	}
+	var files []diskCacheFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), diskCacheExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, diskCacheFile{
+			path:    filepath.Join(c.dir, e.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files
+}
+
+// evict removes expired entries, then the oldest until under maxBytes
+func (c *DiskCache) evict() {
+	files := c.files()
+	expiredBefore := time.Now().Add(-c.ttl)
+
+	var kept []diskCacheFile
+	var total int64
+	for _, f := range files {
+		if f.modTime.Before(expiredBefore) {
+			os.Remove(f.path)
+			continue
+		}
+		kept = append(kept, f)
+		total += f.size
+	}
+	if c.maxBytes <= 0 || total <= c.maxBytes {
+		return
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].modTime.Before(kept[j].modTime)
+	})
+	for _, f := range kept {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+}
+
+// Stats reports the number and total size of entries on disk
+func (c *DiskCache) Stats() DiskCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := DiskCacheStats{Dir: c.dir, MaxBytes: c.maxBytes}
+	for _, f := range c.files() {
+		stats.Entries++
+		stats.Bytes += f.size
+	}
+	return stats
+}
+
+// Clear removes all entries, returning how many were deleted
+func (c *DiskCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for _, f := range c.files() {
+		if err := os.Remove(f.path); err == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// diskCacheFromEnv returns the disk layer when TOOL_CACHE_DISK is enabled
+func diskCacheFromEnv(ttl time.Duration) *DiskCache {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("TOOL_CACHE_DISK"))) {
+	case "true", "1", "on":
+	default:
+		return nil
+	}
+
+	dir := os.Getenv("TOOL_CACHE_DIR")
+	if dir == "" {
+		// Honors XDG_CACHE_HOME, falling back to ~/.cache
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (responses will only be cached in memory)\n", err)
+			return nil
+		}
+		dir = filepath.Join(cacheDir, "reasoning-tools", "responses")
+	}
+
+	maxBytes := int64(parseEnvInt("TOOL_CACHE_DISK_MAX_MB", defaultDiskCacheMaxMB)) * 1024 * 1024
+	disk, err := NewDiskCache(dir, ttl, maxBytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (responses will only be cached in memory)\n", err)
+		return nil
+	}
+	return disk
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToolCache_DiskSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	key := buildToolCacheKey("dialectic_reason", "openai", map[string]interface{}{"problem": "p"})
+
+	disk, err := NewDiskCache(dir, time.Hour, 1024*1024)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	first := NewToolCache(time.Hour, 10)
+	first.disk = disk
+	first.Set(key, "cached answer")
+
+	// A fresh in-memory cache over the same directory simulates a restart
+	second := NewToolCache(time.Hour, 10)
+	second.disk = disk
+	value, ok := second.Get(key)
+	if !ok || value != "cached answer" {
+		t.Fatalf("Expected disk hit after restart, got %q, %v", value, ok)
+	}
+	if _, ok := second.Get(key); !ok {
+		t.Fatal("Expected promoted entry in memory")
+	}
+	written, _ := disk.lookup(key)
+	if promoted := second.items[key]; !promoted.expiresAt.Equal(written.ExpiresAt) || !promoted.createdAt.Equal(written.CreatedAt) {
+		t.Errorf("Expected the promoted entry to keep its disk expiry %v, got %v", written.ExpiresAt, promoted.expiresAt)
+	}
+
+	stats := second.Stats()
+	if stats.DiskHits != 1 || stats.Hits != 1 || stats.Disk == nil || stats.Disk.Entries != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	memory, removed := second.Clear()
+	if memory != 1 || removed != 1 {
+		t.Errorf("Expected 1 memory and 1 disk entry removed, got %d and %d", memory, removed)
+	}
+	if _, ok := second.Get(key); ok {
+		t.Error("Expected miss after clear")
+	}
+}
+
+func TestDiskCache_ExpiryAndSizeEviction(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := NewDiskCache(dir, time.Hour, 600)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+
+	keys := []string{strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)}
+	for i, key := range keys {
+		if err := disk.Set(key, strings.Repeat("x", 200)); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		// Make modification order deterministic
+		ts := time.Now().Add(time.Duration(i-len(keys)) * time.Minute)
+		os.Chtimes(filepath.Join(dir, key+diskCacheExt), ts, ts)
+	}
+	disk.evict()
+
+	if _, ok := disk.Get(keys[0]); ok {
+		t.Error("Expected oldest entry to be evicted over the size limit")
+	}
+	if _, ok := disk.Get(keys[2]); !ok {
+		t.Error("Expected newest entry to be kept")
+	}
+
+	expired, _ := NewDiskCache(dir, -time.Second, 0)
+	if err := expired.Set(keys[1], "stale"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, ok := expired.Get(keys[1]); ok {
+		t.Error("Expected expired entry to be a miss")
+	}
+
+	if err := disk.Set("../escape", "x"); err == nil {
+		t.Error("Expected non-hash keys to be rejected")
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Per-Tool Environment Defaults ============
+//
+// Every argument a tool declares can be defaulted with a TOOLNAME_ARGNAME
+// environment variable (toolEnvKey), e.g. GRAPH_OF_THOUGHTS_MAX_NODES=40 or
+// REFLEXION_MAX_ATTEMPTS=5. toolEnvDefaultsMiddleware fills them in inside
+// the session defaults and profile middlewares, so call arguments, session
+// defaults and profiles all win over the environment, and the environment
+// wins over the handlers' own defaults.
+
+// toolEnvDefaultsMiddleware fills unset arguments from TOOLNAME_ARGNAME
+func toolEnvDefaultsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		srv := server.ServerFromContext(ctx)
+		if srv == nil {
+			return next(ctx, request)
+		}
+		tool := srv.GetTool(request.Params.Name)
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if tool == nil || !ok && request.Params.Arguments != nil {
+			return next(ctx, request)
+		}
+		merged, err := applyToolEnvDefaults(request.Params.Name, args, tool.Tool.InputSchema.Properties)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		request.Params.Arguments = merged
+		return next(ctx, request)
+	}
+}
+
+// applyToolEnvDefaults returns args with the environment defaults of the
+// declared parameters the caller left unset
+func applyToolEnvDefaults(toolName string, args map[string]interface{}, declared map[string]interface{}) (map[string]interface{}, error) {
+	merged := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		merged[k] = v
+	}
+	for param, schema := range declared {
+		if _, set := merged[param]; set || perCallParams[param] {
+			continue
+		}
+		key := toolEnvKey(toolName, strings.ToUpper(param))
+		raw := strings.TrimSpace(os.Getenv(key))
+		if raw == "" {
+			continue
+		}
+		value, err := parseToolEnvValue(schema, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		merged[param] = value
+	}
+	return merged, nil
+}
+
+// parseToolEnvValue converts an environment value to the parameter's JSON
+// schema type, as a client would have sent it
+func parseToolEnvValue(schema interface{}, raw string) (interface{}, error) {
+	typ, _ := schema.(map[string]interface{})["type"].(string)
+	switch typ {
+	case "number", "integer":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", raw)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", raw)
+		}
+		return b, nil
+	case "array", "object":
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, fmt.Errorf("expected JSON, got %q", raw)
+		}
+		return v, nil
+	}
+	return raw, nil
+}
+
+// toolEnvDefaults lists the TOOLNAME_ARGNAME variables set for the
+// registered tools, for the startup log
+func toolEnvDefaults(srv *server.MCPServer) []string {
+	var keys []string
+	for name, tool := range srv.ListTools() {
+		for param := range tool.Tool.InputSchema.Properties {
+			if key := toolEnvKey(name, strings.ToUpper(param)); strings.TrimSpace(os.Getenv(key)) != "" && !perCallParams[param] {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
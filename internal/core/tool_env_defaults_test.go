@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func envDefaultsServer() *server.MCPServer {
+	s := server.NewMCPServer(serverName, serverVersion, server.WithToolHandlerMiddleware(toolEnvDefaultsMiddleware))
+	s.AddTool(mcp.NewTool("graph_of_thoughts",
+		mcp.WithString("problem"),
+		mcp.WithNumber("max_nodes"),
+		mcp.WithBoolean("enable_aggregation"),
+		mcp.WithString("expansion_policy"),
+		mcp.WithArray("stages"),
+		mcp.WithString("idempotency_key"),
+	), func(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		data, _ := json.Marshal(request.Params.Arguments)
+		return mcp.NewToolResultText(string(data)), nil
+	})
+	return s
+}
+
+func TestToolEnvDefaults_FillsUnsetArguments(t *testing.T) {
+	t.Setenv("GRAPH_OF_THOUGHTS_MAX_NODES", "40")
+	t.Setenv("GRAPH_OF_THOUGHTS_ENABLE_AGGREGATION", "true")
+	t.Setenv("GRAPH_OF_THOUGHTS_EXPANSION_POLICY", "best_first")
+	t.Setenv("GRAPH_OF_THOUGHTS_STAGES", `["a", "b"]`)
+	t.Setenv("GRAPH_OF_THOUGHTS_IDEMPOTENCY_KEY", "same-for-every-call")
+	s := envDefaultsServer()
+
+	args := callTool(t, s, "graph_of_thoughts", map[string]interface{}{"problem": "p", "expansion_policy": "bfs"})
+	if args["max_nodes"] != 40.0 || args["enable_aggregation"] != true {
+		t.Errorf("Expected typed environment defaults, got %v", args)
+	}
+	if stages, _ := args["stages"].([]interface{}); len(stages) != 2 {
+		t.Errorf("Expected the JSON array default, got %v", args["stages"])
+	}
+	if args["expansion_policy"] != "bfs" {
+		t.Errorf("Expected the argument to win over the environment, got %v", args["expansion_policy"])
+	}
+	if _, ok := args["idempotency_key"]; ok {
+		t.Error("Expected per-call parameters to have no environment default")
+	}
+
+	if keys := toolEnvDefaults(s); len(keys) != 4 || keys[0] != "GRAPH_OF_THOUGHTS_ENABLE_AGGREGATION" {
+		t.Errorf("Unexpected startup list %v", keys)
+	}
+}
+
+func TestToolEnvDefaults_InvalidValue(t *testing.T) {
+	t.Setenv("GRAPH_OF_THOUGHTS_MAX_NODES", "lots")
+	s := envDefaultsServer()
+
+	if msg := callToolError(t, s, "graph_of_thoughts", map[string]interface{}{"problem": "p"}); !strings.Contains(msg, "GRAPH_OF_THOUGHTS_MAX_NODES") {
+		t.Errorf("Expected the variable to be named in the error, got %q", msg)
+	}
+	// An explicit argument doesn't read the variable
+	callTool(t, s, "graph_of_thoughts", map[string]interface{}{"problem": "p", "max_nodes": 8})
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Machine-readable codes of tool errors. The code leads the error text, e.g.
+// "[E_LIMIT_EXCEEDED] problem is ...", and is repeated with the error's
+// details under the result's _meta.error.
+const (
+	errCodeInvalidArgument = "E_INVALID_ARGUMENT"
+	errCodeLimitExceeded   = "E_LIMIT_EXCEEDED"
+)
+
+// toolError is a tool failure with a machine-readable code
+type toolError struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Fields  []string `json:"fields,omitempty"` // Arguments at fault
+	Limit   int      `json:"limit,omitempty"`  // With E_LIMIT_EXCEEDED
+	Actual  int      `json:"actual,omitempty"`
+}
+
+func (e *toolError) Error() string {
+	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
+}
+
+// toolErrorResult returns err as an error result, with the code and details
+// of a wrapped toolError under _meta.error
+func toolErrorResult(err error) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(err.Error())
+	var te *toolError
+	if errors.As(err, &te) {
+		result.Meta = mcp.NewMetaFromMap(map[string]any{"error": te})
+	}
+	return result
+}
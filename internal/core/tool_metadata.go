@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Tool Annotations and Output Schemas ============
+//
+// Every registered tool carries MCP annotations, so clients can tell the
+// reasoning tools (which call LLM providers) from the read-only inspection
+// tools and the ones that delete state, and an output schema describing the
+// JSON object it returns. structuredOutputMiddleware then sends that object
+// as the result's structured content.
+
+// reasoningToolHints marks a strategy tool: it calls LLM providers, and
+// records memory, runs and caches without deleting anything
+func reasoningToolHints(title string) mcp.ToolOption {
+	return toolHints(title, false, false, false, true)
+}
+
+// readOnlyToolHints marks a tool that only reports server state
+func readOnlyToolHints(title string) mcp.ToolOption {
+	return toolHints(title, true, false, true, false)
+}
+
+// updateToolHints marks a tool that adds to or changes server state
+func updateToolHints(title string, idempotent bool) mcp.ToolOption {
+	return toolHints(title, false, false, idempotent, false)
+}
+
+// destructiveToolHints marks a tool that deletes server state
+func destructiveToolHints(title string) mcp.ToolOption {
+	return toolHints(title, false, true, true, false)
+}
+
+func toolHints(title string, readOnly, destructive, idempotent, openWorld bool) mcp.ToolOption {
+	return mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		Title:           title,
+		ReadOnlyHint:    mcp.ToBoolPtr(readOnly),
+		DestructiveHint: mcp.ToBoolPtr(destructive),
+		IdempotentHint:  mcp.ToBoolPtr(idempotent),
+		OpenWorldHint:   mcp.ToBoolPtr(openWorld),
+	})
+}
+
+// outputSchema declares the result as a JSON object with these top-level
+// properties, given as JSON types. Arrays and objects may be null when empty.
+// None is required: streaming wrappers, strategy explanations and degraded
+// results reshape the object.
+func outputSchema(properties map[string]string) mcp.ToolOption {
+	props := make(map[string]interface{}, len(properties))
+	for name, typ := range properties {
+		switch typ {
+		case "array", "object":
+			props[name] = map[string]interface{}{"type": []string{typ, "null"}}
+		default:
+			props[name] = map[string]interface{}{"type": typ}
+		}
+	}
+	data, _ := json.Marshal(map[string]interface{}{"type": "object", "properties": props})
+	return mcp.WithRawOutputSchema(data)
+}
+
+// reasoningOutputSchema declares a strategy result: the fields every
+// strategy reports, and the strategy's own
+func reasoningOutputSchema(properties map[string]string) mcp.ToolOption {
+	all := map[string]string{
+		"problem":      "string",
+		"final_answer": "string",
+		"success":      "boolean",
+		"provider":     "string",
+		"stream_id":    "string",
+		"summary":      "object",
+	}
+	for name, typ := range properties {
+		all[name] = typ
+	}
+	return outputSchema(all)
+}
+
+// gotOutputSchema is shared by graph_of_thoughts and got_continue
+var gotOutputSchema = reasoningOutputSchema(map[string]string{
+	"run_id": "string", "category": "string", "best_path": "array", "graph": "object",
+	"total_nodes": "integer", "decisions": "array", "lessons_learned": "array",
+})
+
+// structuredOutputMiddleware sends the JSON object a tool with an output
+// schema returned as its structured content, next to the text
+func structuredOutputMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.IsError || result.StructuredContent != nil {
+			return result, err
+		}
+		srv := server.ServerFromContext(ctx)
+		if srv == nil {
+			return result, err
+		}
+		if tool := srv.GetTool(request.Params.Name); tool == nil || tool.Tool.RawOutputSchema == nil {
+			return result, err
+		}
+		var structured map[string]interface{}
+		if json.Unmarshal([]byte(resultText(result)), &structured) == nil {
+			result.StructuredContent = structured
+		}
+		return result, err
+	}
+}
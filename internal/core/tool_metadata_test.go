@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestOutputSchema_NullableContainers(t *testing.T) {
+	tool := mcp.NewTool("t", outputSchema(map[string]string{"answer": "string", "steps": "array"}))
+	var schema struct {
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(tool.RawOutputSchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Type != "object" {
+		t.Errorf("Expected an object schema, got %q", schema.Type)
+	}
+	if got := string(schema.Properties["answer"]); got != `{"type":"string"}` {
+		t.Errorf("Unexpected answer schema %s", got)
+	}
+	if got := string(schema.Properties["steps"]); got != `{"type":["array","null"]}` {
+		t.Errorf("Expected steps to allow null, got %s", got)
+	}
+}
+
+func TestStructuredOutputMiddleware(t *testing.T) {
+	s := server.NewMCPServer(serverName, serverVersion, server.WithToolHandlerMiddleware(structuredOutputMiddleware))
+	reply := func(text string) server.ToolHandlerFunc {
+		return func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(text), nil
+		}
+	}
+	s.AddTool(mcp.NewTool("with_schema", readOnlyToolHints("With Schema"), outputSchema(map[string]string{"answer": "string"})), reply(`{"answer": "42"}`))
+	s.AddTool(mcp.NewTool("plain_text", outputSchema(map[string]string{"answer": "string"})), reply("42"))
+	s.AddTool(mcp.NewTool("no_schema"), reply(`{"answer": "42"}`))
+
+	structured := func(name string) map[string]interface{} {
+		var resp struct {
+			Result struct {
+				StructuredContent map[string]interface{} `json:"structuredContent"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(promptRequest(t, s, "tools/call", map[string]interface{}{"name": name, "arguments": map[string]interface{}{}}), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Result.StructuredContent
+	}
+	if got := structured("with_schema"); got["answer"] != "42" {
+		t.Errorf("Expected the JSON result as structured content, got %v", got)
+	}
+	if got := structured("plain_text"); got != nil {
+		t.Errorf("Expected no structured content for a text result, got %v", got)
+	}
+	if got := structured("no_schema"); got != nil {
+		t.Errorf("Expected no structured content without an output schema, got %v", got)
+	}
+
+	tool := s.GetTool("with_schema").Tool
+	if !*tool.Annotations.ReadOnlyHint || *tool.Annotations.DestructiveHint || *tool.Annotations.OpenWorldHint || tool.Annotations.Title != "With Schema" {
+		t.Errorf("Unexpected read-only annotations %+v", tool.Annotations)
+	}
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+func getProviderFromArgsForTool(args map[string]interface{}, toolName string) (Provider, error) {
+	providerType := providerTypeFromArgs(args, toolName)
+
+	model := ""
+	if m, ok := args["model"].(string); ok && m != "" {
+		model = m
+	}
+	if model == "" {
+		if env := os.Getenv(toolEnvKey(toolName, "MODEL")); env != "" {
+			model = env
+		}
+	}
+
+	primary, err := buildProvider(providerType, model)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbackTypes := parseFallbackProviders(args, toolName)
+	if len(fallbackTypes) == 0 {
+		return primary, nil
+	}
+
+	var providers []Provider
+	providers = append(providers, primary)
+	for _, fallbackType := range fallbackTypes {
+		if strings.EqualFold(fallbackType, providerType) {
+			continue
+		}
+		fallbackProvider, err := buildProvider(fallbackType, "")
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, fallbackProvider)
+	}
+
+	return NewFallbackProvider(providers), nil
+}
+
+// providerTypeFromArgs resolves the generator provider type: the provider
+// argument, then <TOOL>_PROVIDER, then LLM_PROVIDER, then the first provider
+// with an API key
+func providerTypeFromArgs(args map[string]interface{}, toolName string) string {
+	if p, ok := args["provider"].(string); ok && p != "" {
+		return p
+	}
+	if env := os.Getenv(toolEnvKey(toolName, "PROVIDER")); env != "" {
+		return env
+	}
+	if env := os.Getenv("LLM_PROVIDER"); env != "" {
+		return env
+	}
+	return detectProviderFromEnv()
+}
+
+// getEvaluatorProviderFromArgs returns the critic provider for evaluation
+// calls, from evaluator_provider/evaluator_model, then
+// <TOOL>_EVALUATOR_PROVIDER/_MODEL, then EVALUATOR_PROVIDER/_MODEL. A model
+// without a provider runs on the generator's provider type. It returns nil
+// when neither is set, leaving the generator to judge its own output.
+func getEvaluatorProviderFromArgs(args map[string]interface{}, toolName string) (Provider, error) {
+	providerType := getStringArgOrEnv(args, "evaluator_provider", toolEnvKey(toolName, "EVALUATOR_PROVIDER"))
+	if providerType == "" {
+		providerType = os.Getenv("EVALUATOR_PROVIDER")
+	}
+	model := getStringArgOrEnv(args, "evaluator_model", toolEnvKey(toolName, "EVALUATOR_MODEL"))
+	if model == "" {
+		model = os.Getenv("EVALUATOR_MODEL")
+	}
+	if providerType == "" && model == "" {
+		return nil, nil
+	}
+	if providerType == "" {
+		providerType = providerTypeFromArgs(args, toolName)
+	}
+	evaluator, err := buildProvider(providerType, model)
+	if err != nil {
+		return nil, fmt.Errorf("evaluator: %v", err)
+	}
+	return evaluator, nil
+}
+
+func buildProvider(providerType, model string) (Provider, error) {
+	cfg := ProviderConfig{
+		Type:    providerType,
+		APIKey:  getAPIKeyForProvider(providerType),
+		BaseURL: os.Getenv("LLM_BASE_URL"),
+		Model:   model,
+	}
+	applyProviderEnv(&cfg)
+
+	return NewProvider(cfg)
+}
+
+func parseFallbackProviders(args map[string]interface{}, toolName string) []string {
+	var raw string
+	if val, ok := args["fallback_providers"].(string); ok && val != "" {
+		raw = val
+	} else if env := os.Getenv(toolEnvKey(toolName, "FALLBACKS")); env != "" {
+		raw = env
+	} else if env := os.Getenv("LLM_FALLBACKS"); env != "" {
+		raw = env
+	}
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	var providers []string
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+func toolEnvKey(toolName, suffix string) string {
+	key := strings.ToUpper(toolName)
+	re := regexp.MustCompile(`[^A-Z0-9]+`)
+	key = re.ReplaceAllString(key, "_")
+	key = strings.Trim(key, "_")
+	return fmt.Sprintf("%s_%s", key, suffix)
+}
+
+// ParseAttemptProviders builds a provider rotation from a comma-separated list
+// of "provider[:model]" entries, e.g. "groq:llama-3.1-8b-instant,anthropic,openai:gpt-4o".
+// Only the first colon separates provider and model, so Ollama tags such as
+// "ollama:llama3.1:8b" work.
+func ParseAttemptProviders(raw string) ([]AttemptProvider, error) {
+	var rotation []AttemptProvider
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		providerType, model, _ := strings.Cut(entry, ":")
+		provider, err := buildProvider(strings.TrimSpace(providerType), strings.TrimSpace(model))
+		if err != nil {
+			return nil, fmt.Errorf("attempt provider %q: %w", entry, err)
+		}
+		rotation = append(rotation, AttemptProvider{Label: entry, Provider: provider})
+	}
+	return rotation, nil
+}
+
+// pipelineStageProviders resolves the generator and optional evaluator for
+// each pipeline stage. A stage provider replaces the pipeline's provider and
+// model; a stage model only replaces the model. Evaluators come from the
+// pipeline's or the stage params' evaluator_provider/evaluator_model. Every
+// provider shares the run's LLM call cap.
+func pipelineStageProviders(args map[string]interface{}, spec PipelineSpec, llmCalls *LLMCallCounter) ([]Provider, []Provider, error) {
+	providers := make([]Provider, len(spec.Stages))
+	evaluators := make([]Provider, len(spec.Stages))
+	for i, stage := range spec.Stages {
+		stageArgs := map[string]interface{}{}
+		for _, key := range []string{"provider", "model", "fallback_providers", "evaluator_provider", "evaluator_model"} {
+			if v, ok := args[key]; ok {
+				stageArgs[key] = v
+			}
+		}
+		if stage.Provider != "" {
+			stageArgs["provider"] = stage.Provider
+			delete(stageArgs, "model")
+		}
+		if stage.Model != "" {
+			stageArgs["model"] = stage.Model
+		}
+		for _, key := range []string{"evaluator_provider", "evaluator_model"} {
+			if v, ok := stage.Params[key].(string); ok && v != "" {
+				stageArgs[key] = v
+			}
+		}
+		provider, err := getProviderFromArgsForTool(stageArgs, "reasoning_pipeline")
+		if err != nil {
+			return nil, nil, fmt.Errorf("stage %s: %v", stage.Name, err)
+		}
+		evaluator, err := getEvaluatorProviderFromArgs(stageArgs, "reasoning_pipeline")
+		if err != nil {
+			return nil, nil, fmt.Errorf("stage %s: %v", stage.Name, err)
+		}
+		providers[i] = llmCalls.Wrap(provider)
+		evaluators[i] = llmCalls.Wrap(evaluator)
+	}
+	return providers, evaluators, nil
+}
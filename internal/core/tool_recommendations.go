@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Strategy Recommendations ============
+//
+// tools/list appends "Recommended for: ..." to the description of each
+// strategy that has the best recorded success rate for some problem
+// category, so clients choosing between tools lean on past results. The
+// hints are recomputed from run history when they go stale and whenever
+// analytics runs.
+
+const (
+	defaultRecommendationTTL    = 10 * time.Minute
+	defaultRecommendationWindow = 30 * 24 * time.Hour
+)
+
+// toolRecommender caches per-tool recommendation hints
+type toolRecommender struct {
+	mu       sync.Mutex
+	history  func() *RunHistory
+	ttl      time.Duration
+	window   time.Duration // How far back runs count
+	computed time.Time
+	hints    map[string]string // Tool -> hint
+}
+
+var (
+	toolRecommendations     *toolRecommender
+	toolRecommendationsOnce sync.Once
+)
+
+// getToolRecommender returns the shared recommender, or nil when
+// TOOL_RECOMMENDATIONS=false. TOOL_RECOMMENDATIONS_TTL (seconds) sets how
+// long hints are cached and TOOL_RECOMMENDATIONS_DAYS how many days of runs
+// they cover.
+func getToolRecommender() *toolRecommender {
+	toolRecommendationsOnce.Do(func() {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("TOOL_RECOMMENDATIONS"))) {
+		case "false", "0", "off":
+			return
+		}
+		r := &toolRecommender{history: getRunHistory, ttl: defaultRecommendationTTL, window: defaultRecommendationWindow}
+		if ttl := parseEnvInt("TOOL_RECOMMENDATIONS_TTL", 0); ttl > 0 {
+			r.ttl = time.Duration(ttl) * time.Second
+		}
+		if days := parseEnvInt("TOOL_RECOMMENDATIONS_DAYS", 0); days > 0 {
+			r.window = time.Duration(days) * 24 * time.Hour
+		}
+		toolRecommendations = r
+	})
+	return toolRecommendations
+}
+
+// get returns the current hints, recomputing them when stale
+func (r *toolRecommender) get(now time.Time) map[string]string {
+	r.mu.Lock()
+	stale := r.computed.IsZero() || now.Sub(r.computed) >= r.ttl
+	hints := r.hints
+	r.mu.Unlock()
+	if stale {
+		hints, _ = r.refresh(now)
+	}
+	return hints
+}
+
+// refresh recomputes the hints from run history and reports whether they
+// changed
+func (r *toolRecommender) refresh(now time.Time) (map[string]string, bool) {
+	hints := make(map[string]string)
+	if history := r.history(); history != nil {
+		records, err := history.Load(now.Add(-r.window), time.Time{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read run history for recommendations: %v\n", err)
+		}
+		hints = recommendationHints(buildAnalytics(records, nil, AnalyticsFilter{}).ByCategory)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := len(hints) != len(r.hints)
+	for tool, hint := range hints {
+		if r.hints[tool] != hint {
+			changed = true
+		}
+	}
+	r.hints, r.computed = hints, now
+	return hints, changed
+}
+
+// recommendationHints turns the best strategy per category into one hint
+// per tool
+func recommendationHints(rows []*StrategyStats) map[string]string {
+	parts := make(map[string][]string)
+	for _, s := range bestStrategies(rows) {
+		if s.Category == CategoryGeneral {
+			continue
+		}
+		parts[s.Tool] = append(parts[s.Tool], fmt.Sprintf("%s problems (%.0f%% success over %d runs)", s.Category, *s.SuccessRate*100, s.judged))
+	}
+	hints := make(map[string]string, len(parts))
+	for tool, p := range parts {
+		hints[tool] = "Recommended for: " + strings.Join(p, ", ") + "."
+	}
+	return hints
+}
+
+// refreshToolRecommendations recomputes the hints and tells clients to
+// re-list tools when they changed
+func refreshToolRecommendations(ctx context.Context) {
+	recommender := getToolRecommender()
+	if recommender == nil {
+		return
+	}
+	if _, changed := recommender.refresh(time.Now()); changed {
+		if srv := server.ServerFromContext(ctx); srv != nil {
+			srv.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+		}
+	}
+}
+
+// recommendationToolFilter appends recommendation hints to tool descriptions
+// in tools/list
+func recommendationToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	recommender := getToolRecommender()
+	if recommender == nil {
+		return tools
+	}
+	hints := recommender.get(time.Now())
+	if len(hints) == 0 {
+		return tools
+	}
+	out := make([]mcp.Tool, len(tools))
+	for i, tool := range tools {
+		if hint, ok := hints[tool.Name]; ok {
+			tool.Description = strings.TrimSpace(tool.Description) + " " + hint
+		}
+		out[i] = tool
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestToolRecommendations_AppendedToDescriptions(t *testing.T) {
+	history, _ := NewRunHistory(filepath.Join(t.TempDir(), "runs.jsonl"), 0)
+	now := time.Now()
+	record := func(tool, category string, success bool, age time.Duration) {
+		history.Append(RunRecord{Time: now.Add(-age), Tool: tool, Category: category, Success: &success})
+	}
+	for i := 0; i < 3; i++ {
+		record("graph_of_thoughts", CategoryMath, true, time.Hour)
+		record("reflexion", CategoryMath, i == 0, time.Hour)
+		record("reflexion", CategoryCoding, true, time.Hour)
+		record("dialectic_reason", CategoryCreative, true, 60*24*time.Hour) // Outside the window
+	}
+
+	getToolRecommender()
+	saved := toolRecommendations
+	recommender := &toolRecommender{history: func() *RunHistory { return history }, ttl: time.Hour, window: 30 * 24 * time.Hour}
+	toolRecommendations = recommender
+	t.Cleanup(func() { toolRecommendations = saved })
+
+	s := server.NewMCPServer(serverName, serverVersion, server.WithToolFilter(recommendationToolFilter))
+	noop := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	for _, name := range []string{"graph_of_thoughts", "reflexion", "dialectic_reason"} {
+		s.AddTool(mcp.NewTool(name, mcp.WithDescription("Reasons.")), noop)
+	}
+
+	listDescriptions := func() map[string]string {
+		raw, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)))
+		var resp struct {
+			Result mcp.ListToolsResult `json:"result"`
+		}
+		json.Unmarshal(raw, &resp)
+		descriptions := make(map[string]string)
+		for _, tool := range resp.Result.Tools {
+			descriptions[tool.Name] = tool.Description
+		}
+		return descriptions
+	}
+
+	descriptions := listDescriptions()
+	if want := "Reasons. Recommended for: math problems (100% success over 3 runs)."; descriptions["graph_of_thoughts"] != want {
+		t.Errorf("graph_of_thoughts: got %q, want %q", descriptions["graph_of_thoughts"], want)
+	}
+	if !strings.Contains(descriptions["reflexion"], "coding problems") || strings.Contains(descriptions["reflexion"], "math") {
+		t.Errorf("Expected reflexion recommended for coding only, got %q", descriptions["reflexion"])
+	}
+	if descriptions["dialectic_reason"] != "Reasons." {
+		t.Errorf("Expected runs outside the window ignored, got %q", descriptions["dialectic_reason"])
+	}
+
+	// Hints are cached until refreshed
+	for i := 0; i < 3; i++ {
+		record("dialectic_reason", CategoryCreative, true, time.Minute)
+	}
+	if listDescriptions()["dialectic_reason"] != "Reasons." {
+		t.Error("Expected cached hints before a refresh")
+	}
+	if _, changed := recommender.refresh(time.Now()); !changed {
+		t.Error("Expected the refresh to report changed hints")
+	}
+	if !strings.Contains(listDescriptions()["dialectic_reason"], "creative problems") {
+		t.Error("Expected the refreshed hint for dialectic_reason")
+	}
+}
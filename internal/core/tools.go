@@ -1,4 +1,4 @@
-package main
+package core
 
 import (
 	"bytes"
@@ -15,8 +15,11 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Tool represents an executable tool available during reasoning
@@ -40,12 +43,16 @@ type ToolResult struct {
 	Output  string `json:"output"`
 	Success bool   `json:"success"`
 	Error   string `json:"error,omitempty"`
+
+	Artifact string `json:"artifact,omitempty"` // Scratchpad artifact holding the output
+	Reused   bool   `json:"reused,omitempty"`   // Output came from an identical earlier call in the run
 }
 
 // ToolRegistry manages available tools
 type ToolRegistry struct {
 	tools   map[string]ToolExecutor
 	enabled map[string]bool
+	audit   *ToolAuditLog // Records every Execute call; nil when auditing is off
 }
 
 // ToolExecutor is the interface for tool implementations
@@ -60,6 +67,7 @@ func NewToolRegistry() *ToolRegistry {
 	registry := &ToolRegistry{
 		tools:   make(map[string]ToolExecutor),
 		enabled: make(map[string]bool),
+		audit:   getToolAuditLog(),
 	}
 
 	// Register built-in tools
@@ -67,6 +75,14 @@ func NewToolRegistry() *ToolRegistry {
 	registry.Register(&CodeExecutorTool{})
 	registry.Register(&WebFetchTool{})
 	registry.Register(&StringTool{})
+	registry.Register(&UnitsTimeTool{})
+	registry.Register(&SymbolicMathTool{})
+	if vs := newVectorSearchTool(); vs != nil {
+		registry.Register(vs)
+	}
+	for _, tool := range newFileTools() {
+		registry.Register(tool)
+	}
 
 	// Enable tools by default, EXCEPT code_exec which requires explicit opt-in
 	// due to security implications
@@ -80,9 +96,38 @@ func NewToolRegistry() *ToolRegistry {
 		}
 	}
 
+	for name := range registry.tools {
+		if executorDisabled(name) {
+			registry.enabled[name] = false
+		}
+	}
+
 	return registry
 }
 
+// disabledExecutors are the built-in tools admin_config turned off; new
+// registries leave them disabled
+var disabledExecutors = struct {
+	sync.RWMutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+func executorDisabled(name string) bool {
+	disabledExecutors.RLock()
+	defer disabledExecutors.RUnlock()
+	return disabledExecutors.names[name]
+}
+
+func setExecutorDisabled(name string, disabled bool) {
+	disabledExecutors.Lock()
+	defer disabledExecutors.Unlock()
+	if disabled {
+		disabledExecutors.names[name] = true
+	} else {
+		delete(disabledExecutors.names, name)
+	}
+}
+
 // Register adds a tool to the registry
 func (r *ToolRegistry) Register(tool ToolExecutor) {
 	r.tools[tool.Name()] = tool
@@ -108,7 +153,7 @@ func (r *ToolRegistry) SetEnabled(names []string) {
 	}
 	// Enable specified tools (except code_exec which requires env var)
 	for _, name := range names {
-		if _, exists := r.tools[name]; exists {
+		if _, exists := r.tools[name]; exists && !executorDisabled(name) {
 			// code_exec requires explicit environment variable opt-in
 			if name == "code_exec" {
 				if os.Getenv("CODE_EXEC_ENABLED") != "true" && os.Getenv("CODE_EXEC_ENABLED") != "1" {
@@ -122,6 +167,13 @@ func (r *ToolRegistry) SetEnabled(names []string) {
 
 // Execute runs a tool by name
 func (r *ToolRegistry) Execute(ctx context.Context, name, input string) ToolResult {
+	start := time.Now()
+	result := r.execute(ctx, name, input)
+	auditToolCall(ctx, r.audit, result, start)
+	return result
+}
+
+func (r *ToolRegistry) execute(ctx context.Context, name, input string) ToolResult {
 	result := ToolResult{
 		Tool:  name,
 		Input: input,
@@ -138,6 +190,25 @@ func (r *ToolRegistry) Execute(ctx context.Context, name, input string) ToolResu
 		return result
 	}
 
+	// The run's scratchpad fills in artifact references and answers
+	// repeated calls from the artifact the first one saved
+	pad := scratchpadFromContext(ctx)
+	if name == "scratchpad" {
+		pad = nil
+	}
+	if pad != nil {
+		expanded, err := pad.expand(input)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if output, artifact, ok := pad.reuse(name, expanded); ok {
+			result.Output, result.Artifact, result.Reused, result.Success = output, artifact, true, true
+			return result
+		}
+		input = expanded
+	}
+
 	output, err := tool.Execute(ctx, input)
 	if err != nil {
 		result.Error = err.Error()
@@ -146,6 +217,9 @@ func (r *ToolRegistry) Execute(ctx context.Context, name, input string) ToolResu
 
 	result.Output = output
 	result.Success = true
+	if pad != nil {
+		result.Artifact = pad.saveToolOutput(name, input, output)
+	}
 	return result
 }
 
@@ -193,6 +267,35 @@ func (r *ToolRegistry) GetToolsPrompt() string {
 	return sb.String()
 }
 
+// GetToolSchemas returns JSON schemas of enabled tools for native function calling
+func (r *ToolRegistry) GetToolSchemas() []ToolSchema {
+	tools := r.GetAvailableTools()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	schemas := make([]ToolSchema, 0, len(tools))
+	for _, tool := range tools {
+		schemas = append(schemas, ToolSchema{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"input": map[string]interface{}{
+						"type":        "string",
+						"description": "Input for the tool, in the format given in its description",
+					},
+					"reason": map[string]interface{}{
+						"type":        "string",
+						"description": "Why this tool call helps",
+					},
+				},
+				"required": []string{"input"},
+			},
+		})
+	}
+	return schemas
+}
+
 // ParseToolCalls extracts tool calls from LLM response
 func ParseToolCalls(response string) []ToolCall {
 	var calls []ToolCall
@@ -456,6 +559,12 @@ func (t *CodeExecutorTool) Name() string {
 }
 
 func (t *CodeExecutorTool) Description() string {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("CODE_EXEC_BACKEND"))) {
+	case CodeExecBackendDocker:
+		return "Execute Python code and return the output. Input: Python code snippet. Runs in an isolated Docker container with no network access, limited memory/CPU and a read-only filesystem. Print results to see them."
+	case CodeExecBackendWASM:
+		return "Execute Python code and return the output. Input: Python code snippet. Runs in a WebAssembly sandbox with no network, subprocess or host filesystem access. Only the standard library is available. Print results to see them."
+	}
 	return "Execute Python code and return the output. Input: Python code snippet. WARNING: This tool executes code on the host system without full sandboxing. Only use in trusted environments. The code runs with a 10s timeout and basic restrictions. Print results to see them."
 }
 
@@ -465,9 +574,17 @@ func (t *CodeExecutorTool) Execute(ctx context.Context, input string) (string, e
 		return "", fmt.Errorf("empty code")
 	}
 
-	// Security: Validate input against dangerous patterns
-	if err := validatePythonCode(ctx, input); err != nil {
-		return "", fmt.Errorf("code validation failed: %w", err)
+	backend, err := codeExecBackendFromEnv()
+	if err != nil {
+		return "", err
+	}
+
+	// Security: Validate input against dangerous patterns. Sandboxed backends
+	// enforce isolation themselves, so the blocklist only guards the host runner.
+	if !backend.Sandboxed() {
+		if err := validatePythonCode(ctx, input); err != nil {
+			return "", fmt.Errorf("code validation failed: %w", err)
+		}
 	}
 
 	// Get configurable timeout from global config
@@ -477,30 +594,18 @@ func (t *CodeExecutorTool) Execute(ctx context.Context, input string) (string, e
 	execCtx, cancel := context.WithTimeout(ctx, config.CodeExecTimeout)
 	defer cancel()
 
-	// Try Python3 first, then Python (Python 3 is the modern standard)
-	pythonCmd := "python3"
-	if _, err := exec.LookPath("python3"); err != nil {
-		pythonCmd = "python"
-	}
-
 	// Audit log: log code execution for security tracking
 	// Log to stderr so it's visible but doesn't interfere with stdout capture
-	fmt.Fprintf(os.Stderr, "[AUDIT] code_exec: executing Python code (%d chars)\n", len(input))
-
-	cmd := exec.CommandContext(execCtx, pythonCmd, "-c", input)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	fmt.Fprintf(os.Stderr, "[AUDIT] code_exec: executing Python code (%d chars, backend=%s)\n", len(input), backend.Name())
 
-	err := cmd.Run()
+	stdout, stderr, err := backend.Run(execCtx, input)
 
-	output := stdout.String()
-	if stderr.Len() > 0 {
+	output := stdout
+	if stderr != "" {
 		if output != "" {
 			output += "\n"
 		}
-		output += "STDERR: " + stderr.String()
+		output += "STDERR: " + stderr
 	}
 
 	if err != nil {
@@ -557,6 +662,11 @@ func (t *WebFetchTool) Execute(ctx context.Context, input string) (string, error
 }
 
 func (t *WebFetchTool) fetch(ctx context.Context, urlStr string) (string, error) {
+	return t.fetchText(ctx, urlStr, 5000)
+}
+
+// fetchText fetches a page as text with HTML stripped, truncated to maxChars
+func (t *WebFetchTool) fetchText(ctx context.Context, urlStr string, maxChars int) (string, error) {
 	// Validate URL
 	if !strings.HasPrefix(urlStr, "http://") && !strings.HasPrefix(urlStr, "https://") {
 		urlStr = "https://" + urlStr
@@ -594,14 +704,34 @@ func (t *WebFetchTool) fetch(ctx context.Context, urlStr string) (string, error)
 	content := stripHTML(string(body))
 
 	// Truncate if too long
-	if len(content) > 5000 {
-		content = content[:5000] + "\n...(truncated)"
+	if len(content) > maxChars {
+		content = content[:maxChars] + "\n...(truncated)"
 	}
 
 	return content, nil
 }
 
+// search tries the configured search APIs in order and falls back to
+// scraping DuckDuckGo HTML when none is configured or all fail
 func (t *WebFetchTool) search(ctx context.Context, query string) (string, error) {
+	for _, backend := range searchBackendsFromEnv() {
+		results, err := backend.Search(ctx, t.client, query)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", fmt.Errorf("search failed: %w", ctx.Err())
+			}
+			fmt.Fprintf(os.Stderr, "[WARNING] web_fetch: %s search failed, trying next backend: %v\n", backend.Name(), err)
+			continue
+		}
+		if len(results) > 0 {
+			return formatSearchResults(results), nil
+		}
+	}
+
+	return t.searchDuckDuckGo(ctx, query)
+}
+
+func (t *WebFetchTool) searchDuckDuckGo(ctx context.Context, query string) (string, error) {
 	// Use DuckDuckGo HTML search (no API key needed)
 	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", url.QueryEscape(query))
 
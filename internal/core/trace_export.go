@@ -0,0 +1,550 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Trace Export ============
+//
+// Every strategy run collects a trace: its arguments and result, each LLM
+// call with its messages and reply, and its stream events. The most recent
+// traces stay in memory for the export_trace tool, and a run given
+// trace_export_path also writes its trace to a file. Traces export as
+// LangSmith runs, a Weights & Biases trace tree, or generic JSONL, for
+// existing observability and eval stacks.
+
+const defaultTraceKeep = 20
+
+// Trace export formats
+const (
+	TraceFormatJSONL     = "jsonl"
+	TraceFormatLangSmith = "langsmith"
+	TraceFormatWandb     = "wandb"
+)
+
+// RunTrace is everything recorded about one strategy run
+type RunTrace struct {
+	ID        string
+	Tool      string
+	SessionID string
+	Arguments map[string]interface{}
+	Start     time.Time
+	End       time.Time
+	Result    string
+	IsError   bool
+	LLMCalls  []TraceLLMCall
+	Events    []StreamEvent
+
+	mu sync.Mutex
+}
+
+// TraceLLMCall is one provider call of a run
+type TraceLLMCall struct {
+	Kind      string           `json:"kind"`
+	Provider  string           `json:"provider"`
+	Model     string           `json:"model"`
+	Messages  []ChatMessage    `json:"messages"`
+	Options   ChatOptions      `json:"-"`
+	Response  string           `json:"response,omitempty"`
+	ToolCalls []NativeToolCall `json:"tool_calls,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	Start     time.Time        `json:"start_time"`
+	End       time.Time        `json:"end_time"`
+}
+
+type runTraceKey struct{}
+
+func traceFromContext(ctx context.Context) *RunTrace {
+	t, _ := ctx.Value(runTraceKey{}).(*RunTrace)
+	return t
+}
+
+// subscribe records the stream events published to bus in the trace
+func (t *RunTrace) subscribe(bus *EventBus) {
+	bus.Subscribe(func(e Event) {
+		var event StreamEvent
+		switch e := e.(type) {
+		case TokenStreamed:
+			event = StreamEvent{Timestamp: time.Now(), Type: EventTypeToken, Content: e.Token}
+		case progressEvent:
+			event = progressStreamEvent(e.progress())
+		default:
+			return
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		event.ID = int64(len(t.Events) + 1)
+		event.ElapsedMs = event.Timestamp.Sub(t.Start).Milliseconds()
+		t.Events = append(t.Events, event)
+	})
+}
+
+func (t *RunTrace) addLLMCall(call TraceLLMCall) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.LLMCalls = append(t.LLMCalls, call)
+}
+
+// finish records the result and orders the LLM calls by start time
+func (t *RunTrace) finish(result string, isError bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.End = time.Now()
+	t.Result, t.IsError = result, isError
+	sort.SliceStable(t.LLMCalls, func(i, j int) bool { return t.LLMCalls[i].Start.Before(t.LLMCalls[j].Start) })
+}
+
+// WithTracing records each chat, stream and tool call in the run's trace
+func WithTracing() ProviderMiddleware {
+	return Intercept(func(ctx context.Context, call *ProviderCall, next ProviderNext) error {
+		trace := traceFromContext(ctx)
+		if trace == nil || call.Kind == CallEmbed {
+			return next(ctx)
+		}
+		entry := TraceLLMCall{Kind: call.Kind, Provider: call.Provider, Model: call.Model, Messages: call.Messages, Options: call.Opts, Start: time.Now()}
+		err := next(ctx)
+		entry.End = time.Now()
+		entry.Response = call.Content
+		if call.Response != nil {
+			entry.Response, entry.ToolCalls = call.Response.Content, call.Response.ToolCalls
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		trace.addLLMCall(entry)
+		return err
+	})
+}
+
+// traceStore keeps the most recent traces
+type traceStore struct {
+	mu     sync.Mutex
+	keep   int
+	traces []*RunTrace // Oldest first
+}
+
+var (
+	recentTraces     *traceStore
+	recentTracesOnce sync.Once
+)
+
+// getTraceStore returns the trace store, keeping TRACE_KEEP traces (default
+// 20), or nil when TRACE_KEEP is 0
+func getTraceStore() *traceStore {
+	recentTracesOnce.Do(func() {
+		if keep := parseEnvInt("TRACE_KEEP", defaultTraceKeep); keep > 0 {
+			recentTraces = &traceStore{keep: keep}
+		}
+	})
+	return recentTraces
+}
+
+func (s *traceStore) add(t *RunTrace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.traces = append(s.traces, t)
+	if len(s.traces) > s.keep {
+		s.traces = s.traces[len(s.traces)-s.keep:]
+	}
+}
+
+// find returns the trace of run id, or with no id the latest trace of the
+// session (any session when sessionID is "")
+func (s *traceStore) find(id, sessionID string) *RunTrace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.traces) - 1; i >= 0; i-- {
+		t := s.traces[i]
+		if id != "" && t.ID == id || id == "" && (sessionID == "" || t.SessionID == sessionID) {
+			return t
+		}
+	}
+	return nil
+}
+
+func traceExportOption() mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		mcp.WithString("trace_export_path",
+			mcp.Description("Also write the run's trace (LLM calls, stream events, result) to this file, relative to TRACE_EXPORT_DIR"),
+		)(t)
+		mcp.WithString("trace_export_format",
+			mcp.Description("Format of trace_export_path: 'jsonl' (default), 'langsmith' (runs for the LangSmith batch API) or 'wandb' (Weights & Biases trace tree)"),
+		)(t)
+	}
+}
+
+// traceMiddleware collects the trace of every strategy run
+func traceMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !runHistoryTools[request.Params.Name] || traceFromContext(ctx) != nil {
+			return next(ctx, request)
+		}
+		args, _ := request.Params.Arguments.(map[string]interface{})
+		path, _ := args["trace_export_path"].(string)
+		store := getTraceStore()
+		if store == nil && path == "" {
+			return next(ctx, request)
+		}
+		format, _ := args["trace_export_format"].(string)
+		if path != "" {
+			if _, err := traceExportFile(path); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if _, err := traceFormat(format); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		trace := &RunTrace{ID: "trace-" + uuid.NewString()[:8], Tool: request.Params.Name, Arguments: args, Start: time.Now()}
+		if run := queueRunFromContext(ctx); run != nil {
+			trace.ID, trace.SessionID = run.ID, run.SessionID
+		}
+		result, err := next(context.WithValue(ctx, runTraceKey{}, trace), request)
+		trace.finish(toolResultText(result, err))
+		if store != nil {
+			store.add(trace)
+			notifyRunResources(ctx, trace)
+		}
+		if path != "" {
+			if written, writeErr := writeTraceFile(trace, path, format); writeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to export trace of %s: %v\n", trace.ID, writeErr)
+			} else {
+				fmt.Fprintf(os.Stderr, "[TRACE] %s written to %s\n", trace.ID, written)
+			}
+		}
+		return result, err
+	}
+}
+
+// traceExportDir is TRACE_EXPORT_DIR, by default
+// ~/.local/share/reasoning-tools/traces
+func traceExportDir() string {
+	if dir := os.Getenv("TRACE_EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local", "share", "reasoning-tools", "traces")
+}
+
+// traceExportFile resolves a client-given path inside the export directory
+func traceExportFile(path string) (string, error) {
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("trace_export_path must be a file path relative to TRACE_EXPORT_DIR, got %q", path)
+	}
+	return filepath.Join(traceExportDir(), clean), nil
+}
+
+// writeTraceFile exports a trace to path under the export directory and
+// returns the file written
+func writeTraceFile(t *RunTrace, path, format string) (string, error) {
+	file, err := traceExportFile(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := exportTrace(t, format)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return "", err
+	}
+	return file, os.WriteFile(file, data, 0600)
+}
+
+// traceFormat normalizes a format name, defaulting to jsonl
+func traceFormat(format string) (string, error) {
+	switch f := strings.ToLower(withDefault(strings.TrimSpace(format), TraceFormatJSONL)); f {
+	case TraceFormatJSONL, TraceFormatLangSmith, TraceFormatWandb:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown trace format %q: use jsonl, langsmith or wandb", format)
+}
+
+// exportTrace renders a trace in a format
+func exportTrace(t *RunTrace, format string) ([]byte, error) {
+	f, err := traceFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	switch f {
+	case TraceFormatLangSmith:
+		return json.MarshalIndent(traceLangSmith(t), "", "  ")
+	case TraceFormatWandb:
+		return json.MarshalIndent(map[string]interface{}{"root_span": traceWandbSpan(t)}, "", "  ")
+	}
+	return traceJSONL(t), nil
+}
+
+// traceOutput is a run's result as JSON, or wrapped as {"output": text}
+func traceOutput(t *RunTrace) interface{} {
+	var v map[string]interface{}
+	if json.Unmarshal([]byte(t.Result), &v) == nil {
+		return v
+	}
+	return map[string]interface{}{"output": t.Result}
+}
+
+// traceJSONL writes one line for the run, then a line per LLM call and
+// stream event in time order
+func traceJSONL(t *RunTrace) []byte {
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		line, _ := json.Marshal(v)
+		buf.Write(append(line, '\n'))
+	}
+	run := map[string]interface{}{
+		"trace_id":   t.ID,
+		"type":       "run",
+		"tool":       t.Tool,
+		"arguments":  t.Arguments,
+		"start_time": t.Start,
+		"end_time":   t.End,
+		"result":     traceOutput(t),
+	}
+	if t.IsError {
+		run["error"] = t.Result
+		delete(run, "result")
+	}
+	write(run)
+
+	calls, events := t.LLMCalls, t.Events
+	for len(calls) > 0 || len(events) > 0 {
+		if len(calls) > 0 && (len(events) == 0 || !events[0].Timestamp.Before(calls[0].Start)) {
+			write(struct {
+				TraceID string `json:"trace_id"`
+				Type    string `json:"type"`
+				TraceLLMCall
+			}{t.ID, "llm_call", calls[0]})
+			calls = calls[1:]
+		} else {
+			write(struct {
+				TraceID string      `json:"trace_id"`
+				Type    string      `json:"type"`
+				Event   StreamEvent `json:"event"`
+			}{t.ID, "event", events[0]})
+			events = events[1:]
+		}
+	}
+	return buf.Bytes()
+}
+
+// LangSmithRun is a run in the LangSmith batch ingestion format
+type LangSmithRun struct {
+	ID          string                   `json:"id"`
+	TraceID     string                   `json:"trace_id"`
+	ParentRunID string                   `json:"parent_run_id,omitempty"`
+	DottedOrder string                   `json:"dotted_order"`
+	Name        string                   `json:"name"`
+	RunType     string                   `json:"run_type"` // chain or llm
+	StartTime   string                   `json:"start_time"`
+	EndTime     string                   `json:"end_time"`
+	Inputs      map[string]interface{}   `json:"inputs"`
+	Outputs     interface{}              `json:"outputs,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+	Extra       map[string]interface{}   `json:"extra,omitempty"`
+	Events      []map[string]interface{} `json:"events,omitempty"`
+	SessionName string                   `json:"session_name"` // The LangSmith project
+}
+
+// langSmithTime formats times as LangSmith's dotted_order expects
+func langSmithTime(t time.Time) string {
+	t = t.UTC()
+	return t.Format("20060102T150405") + fmt.Sprintf("%06dZ", t.Nanosecond()/1000)
+}
+
+// traceLangSmith converts a trace to {"post": [runs]}, the body of
+// LangSmith's POST /runs/batch: a chain run for the strategy with an llm
+// child run per call, and the stream events as the chain's events
+func traceLangSmith(t *RunTrace) map[string][]LangSmithRun {
+	project := withDefault(os.Getenv("LANGSMITH_PROJECT"), serverName)
+	rootID := uuid.NewString()
+	rootOrder := langSmithTime(t.Start) + rootID
+	root := LangSmithRun{
+		ID:          rootID,
+		TraceID:     rootID,
+		DottedOrder: rootOrder,
+		Name:        t.Tool,
+		RunType:     "chain",
+		StartTime:   t.Start.UTC().Format(time.RFC3339Nano),
+		EndTime:     t.End.UTC().Format(time.RFC3339Nano),
+		Inputs:      t.Arguments,
+		Extra:       map[string]interface{}{"metadata": map[string]interface{}{"run_id": t.ID, "server": serverName, "version": serverVersion}},
+		SessionName: project,
+	}
+	if t.IsError {
+		root.Error = t.Result
+	} else {
+		root.Outputs = traceOutput(t)
+	}
+	for _, e := range t.Events {
+		if e.Type == EventTypeToken {
+			continue
+		}
+		root.Events = append(root.Events, map[string]interface{}{"name": e.Type, "time": e.Timestamp.UTC().Format(time.RFC3339Nano), "kwargs": e})
+	}
+
+	runs := []LangSmithRun{root}
+	for _, call := range t.LLMCalls {
+		id := uuid.NewString()
+		messages := make([]map[string]string, len(call.Messages))
+		for i, m := range call.Messages {
+			messages[i] = map[string]string{"role": m.Role, "content": m.Content}
+		}
+		run := LangSmithRun{
+			ID:          id,
+			TraceID:     rootID,
+			ParentRunID: rootID,
+			DottedOrder: rootOrder + "." + langSmithTime(call.Start) + id,
+			Name:        call.Provider + "/" + call.Model,
+			RunType:     "llm",
+			StartTime:   call.Start.UTC().Format(time.RFC3339Nano),
+			EndTime:     call.End.UTC().Format(time.RFC3339Nano),
+			Inputs:      map[string]interface{}{"messages": messages},
+			Error:       call.Error,
+			Extra: map[string]interface{}{
+				"invocation_params": map[string]interface{}{"model": call.Model, "temperature": call.Options.Temperature, "max_tokens": call.Options.MaxTokens},
+				"metadata":          map[string]interface{}{"ls_provider": call.Provider, "ls_model_name": call.Model, "kind": call.Kind},
+			},
+			SessionName: project,
+		}
+		if call.Error == "" {
+			message := map[string]interface{}{"role": "assistant", "content": call.Response}
+			if len(call.ToolCalls) > 0 {
+				message["tool_calls"] = call.ToolCalls
+			}
+			run.Outputs = map[string]interface{}{"choices": []interface{}{map[string]interface{}{"message": message}}}
+		}
+		runs = append(runs, run)
+	}
+	return map[string][]LangSmithRun{"post": runs}
+}
+
+// WandbSpan is a span of a Weights & Biases trace tree
+type WandbSpan struct {
+	SpanID        string                 `json:"span_id"`
+	Name          string                 `json:"name"`
+	SpanKind      string                 `json:"span_kind"` // CHAIN or LLM
+	StartTimeMS   int64                  `json:"start_time_ms"`
+	EndTimeMS     int64                  `json:"end_time_ms"`
+	StatusCode    string                 `json:"status_code"` // SUCCESS or ERROR
+	StatusMessage string                 `json:"status_message,omitempty"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Results       []WandbResult          `json:"results,omitempty"`
+	ChildSpans    []WandbSpan            `json:"child_spans,omitempty"`
+}
+
+// WandbResult is a span's inputs and outputs
+type WandbResult struct {
+	Inputs  map[string]interface{} `json:"inputs"`
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
+}
+
+// traceWandbSpan converts a trace to a CHAIN span with an LLM child span per
+// call. Stream events become the root span's events attribute.
+func traceWandbSpan(t *RunTrace) WandbSpan {
+	status := func(isError bool, message string) (string, string) {
+		if isError {
+			return "ERROR", message
+		}
+		return "SUCCESS", ""
+	}
+	root := WandbSpan{
+		SpanID:      t.ID,
+		Name:        t.Tool,
+		SpanKind:    "CHAIN",
+		StartTimeMS: t.Start.UnixMilli(),
+		EndTimeMS:   t.End.UnixMilli(),
+		Attributes:  map[string]interface{}{"server": serverName, "version": serverVersion},
+	}
+	root.StatusCode, root.StatusMessage = status(t.IsError, t.Result)
+	result := WandbResult{Inputs: t.Arguments}
+	if !t.IsError {
+		result.Outputs, _ = traceOutput(t).(map[string]interface{})
+	}
+	root.Results = []WandbResult{result}
+	var events []StreamEvent
+	for _, e := range t.Events {
+		if e.Type != EventTypeToken {
+			events = append(events, e)
+		}
+	}
+	if len(events) > 0 {
+		root.Attributes["events"] = events
+	}
+
+	for i, call := range t.LLMCalls {
+		prompt := make([]string, len(call.Messages))
+		for j, m := range call.Messages {
+			prompt[j] = m.Role + ": " + m.Content
+		}
+		span := WandbSpan{
+			SpanID:      fmt.Sprintf("%s-llm-%d", t.ID, i+1),
+			Name:        call.Provider + "/" + call.Model,
+			SpanKind:    "LLM",
+			StartTimeMS: call.Start.UnixMilli(),
+			EndTimeMS:   call.End.UnixMilli(),
+			Attributes:  map[string]interface{}{"provider": call.Provider, "model": call.Model, "kind": call.Kind, "temperature": call.Options.Temperature},
+			Results:     []WandbResult{{Inputs: map[string]interface{}{"prompt": strings.Join(prompt, "\n\n")}}},
+		}
+		span.StatusCode, span.StatusMessage = status(call.Error != "", call.Error)
+		if call.Error == "" {
+			span.Results[0].Outputs = map[string]interface{}{"response": call.Response}
+		}
+		root.ChildSpans = append(root.ChildSpans, span)
+	}
+	return root
+}
+
+// handleExportTrace exports a recent run's trace
+func handleExportTrace(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	store := getTraceStore()
+	if store == nil {
+		return mcp.NewToolResultError("traces are not kept (TRACE_KEEP=0)"), nil
+	}
+	runID, _ := args["run_id"].(string)
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil && runID == "" {
+		sessionID = session.SessionID()
+	}
+	trace := store.find(runID, sessionID)
+	if trace == nil {
+		if runID != "" {
+			return mcp.NewToolResultError(fmt.Sprintf("no trace for run %s (only the last %d runs are kept)", runID, store.keep)), nil
+		}
+		return mcp.NewToolResultError("no strategy run has been traced yet"), nil
+	}
+
+	format, _ := args["format"].(string)
+	if path, _ := args["path"].(string); path != "" {
+		file, err := writeTraceFile(trace, path, format)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to export trace: %v", err)), nil
+		}
+		output, _ := json.MarshalIndent(map[string]interface{}{"run_id": trace.ID, "tool": trace.Tool, "path": file, "llm_calls": len(trace.LLMCalls), "events": len(trace.Events)}, "", "  ")
+		return mcp.NewToolResultText(string(output)), nil
+	}
+	data, err := exportTrace(trace, format)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	format, _ = traceFormat(format)
+	return mcp.NewToolResultStructured(map[string]interface{}{"run_id": trace.ID, "tool": trace.Tool, "format": format, "content": string(data)}, string(data)), nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestTraceMiddleware_ExportFormats(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	dir := t.TempDir()
+	t.Setenv("TRACE_EXPORT_DIR", dir)
+	s := integrationServer(t, traceMiddleware)
+	s.AddTool(mcp.NewTool("export_trace"), handleExportTrace)
+
+	callTool(t, s, "sequential_thinking", map[string]interface{}{
+		"problem": "What is 2 + 2?", "provider": "mock",
+		"trace_export_path": "runs/sequential.jsonl",
+	})
+	data, err := os.ReadFile(filepath.Join(dir, "runs", "sequential.jsonl"))
+	if err != nil {
+		t.Fatalf("Expected the trace file: %v", err)
+	}
+	types := map[string]int{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Invalid trace line %s: %v", scanner.Text(), err)
+		}
+		types[line["type"].(string)]++
+	}
+	if types["run"] != 1 || types["llm_call"] == 0 || types["event"] == 0 {
+		t.Errorf("Expected a run line, LLM calls and events, got %v", types)
+	}
+
+	text := callToolOn(context.Background(), s, "export_trace", map[string]interface{}{"format": "langsmith"}).Text
+	var batch struct {
+		Post []LangSmithRun `json:"post"`
+	}
+	if err := json.Unmarshal([]byte(text), &batch); err != nil || len(batch.Post) < 2 {
+		t.Fatalf("Unexpected LangSmith export: %v\n%s", err, text)
+	}
+	root := batch.Post[0]
+	if root.RunType != "chain" || root.ParentRunID != "" || root.Name != "sequential_thinking" {
+		t.Errorf("Unexpected root run: %+v", root)
+	}
+	for _, run := range batch.Post[1:] {
+		if run.RunType != "llm" || run.ParentRunID != root.ID || run.TraceID != root.ID || !strings.HasPrefix(run.DottedOrder, root.DottedOrder+".") {
+			t.Errorf("Expected an llm child of the root, got %+v", run)
+		}
+	}
+
+	text = callToolOn(context.Background(), s, "export_trace", map[string]interface{}{"format": "wandb"}).Text
+	var tree struct {
+		RootSpan WandbSpan `json:"root_span"`
+	}
+	if err := json.Unmarshal([]byte(text), &tree); err != nil || tree.RootSpan.SpanKind != "CHAIN" || len(tree.RootSpan.ChildSpans) != len(batch.Post)-1 {
+		t.Errorf("Unexpected W&B export: %v\n%s", err, text)
+	}
+}
+
+func TestTraceMiddleware_PathConfined(t *testing.T) {
+	t.Setenv("MOCK_FIXTURES", "")
+	t.Setenv("TRACE_EXPORT_DIR", t.TempDir())
+	s := integrationServer(t, traceMiddleware)
+
+	for _, path := range []string{"../escape.jsonl", "/tmp/trace.jsonl"} {
+		if msg := callToolError(t, s, "sequential_thinking", map[string]interface{}{"problem": "2 + 2", "provider": "mock", "trace_export_path": path}); !strings.Contains(msg, "relative to TRACE_EXPORT_DIR") {
+			t.Errorf("Expected %s to be rejected, got %q", path, msg)
+		}
+	}
+	if msg := callToolError(t, s, "sequential_thinking", map[string]interface{}{"problem": "2 + 2", "provider": "mock", "trace_export_path": "t.json", "trace_export_format": "xml"}); !strings.Contains(msg, "unknown trace format") {
+		t.Errorf("Expected the format to be rejected, got %q", msg)
+	}
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============ Transport Diagnostics ============
+
+// maxDiagSessions bounds the per-session records kept for transport_diag.
+// Streamable HTTP unregisters a session whenever its GET stream closes, so
+// records are evicted oldest-first rather than on unregister.
+const maxDiagSessions = 256
+
+var serverStartTime = time.Now()
+
+var transportDiag = &transportDiagnostics{sessions: make(map[string]*sessionDiag)}
+
+type transportDiagnostics struct {
+	mu        sync.Mutex
+	transport string // Transport the server was started with
+	sessions  map[string]*sessionDiag
+}
+
+// sessionDiag is what the server has seen of one client session
+type sessionDiag struct {
+	ProtocolVersion string    `json:"protocol_version,omitempty"`
+	ClientName      string    `json:"client_name,omitempty"`
+	ClientVersion   string    `json:"client_version,omitempty"`
+	InitializedAt   time.Time `json:"initialized_at,omitempty"`
+	Pings           int       `json:"pings"`
+	LastPingAt      time.Time `json:"last_ping_at,omitempty"`
+}
+
+// TransportDiagResult is the output of the transport_diag tool
+type TransportDiagResult struct {
+	Transport        string              `json:"transport"`
+	SessionTransport string              `json:"session_transport"`
+	SessionID        string              `json:"session_id,omitempty"`
+	ProtocolVersion  string              `json:"protocol_version,omitempty"`
+	Client           *mcp.Implementation `json:"client,omitempty"`
+	ServerName       string              `json:"server_name"`
+	ServerVersion    string              `json:"server_version"`
+	Uptime           string              `json:"uptime"`
+	UptimeMs         int64               `json:"uptime_ms"`
+	ServerTimeMs     int64               `json:"server_time_ms"`
+	Latency          *DiagLatency        `json:"latency,omitempty"`
+	Pings            int                 `json:"pings"`
+	LastPingAgoMs    *int64              `json:"last_ping_ago_ms,omitempty"`
+	ResumableStreams bool                `json:"resumable_streams"`
+}
+
+// DiagLatency reports latency derived from the client's send timestamp.
+// The client gets the round trip by subtracting client_timestamp_ms from its
+// clock when the response arrives; the one-way figure depends on clock skew.
+type DiagLatency struct {
+	ClientTimestampMs int64 `json:"client_timestamp_ms"`
+	ClientToServerMs  int64 `json:"client_to_server_ms"`
+}
+
+func (d *transportDiagnostics) setTransport(transport string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.transport = transport
+}
+
+// sessionLocked returns the record for a session, creating it if needed.
+// The caller holds d.mu.
+func (d *transportDiagnostics) sessionLocked(id string) *sessionDiag {
+	if s, ok := d.sessions[id]; ok {
+		return s
+	}
+	if len(d.sessions) >= maxDiagSessions {
+		var oldestID string
+		var oldest time.Time
+		for sid, s := range d.sessions {
+			if oldestID == "" || s.InitializedAt.Before(oldest) {
+				oldestID, oldest = sid, s.InitializedAt
+			}
+		}
+		delete(d.sessions, oldestID)
+	}
+	s := &sessionDiag{}
+	d.sessions[id] = s
+	return s
+}
+
+func (d *transportDiagnostics) recordInitialize(id string, request *mcp.InitializeRequest, result *mcp.InitializeResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.sessionLocked(id)
+	s.InitializedAt = time.Now()
+	if result != nil {
+		s.ProtocolVersion = result.ProtocolVersion
+	}
+	if request != nil {
+		s.ClientName = request.Params.ClientInfo.Name
+		s.ClientVersion = request.Params.ClientInfo.Version
+	}
+}
+
+func (d *transportDiagnostics) recordPing(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.sessionLocked(id)
+	s.Pings++
+	s.LastPingAt = time.Now()
+}
+
+func (d *transportDiagnostics) snapshot(id string) (string, sessionDiag) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if s, ok := d.sessions[id]; ok {
+		return d.transport, *s
+	}
+	return d.transport, sessionDiag{}
+}
+
+// diagnosticsHooks records negotiated protocol versions, client info and
+// pings per session for transport_diag
+func diagnosticsHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterInitialize(func(ctx context.Context, _ any, request *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			transportDiag.recordInitialize(session.SessionID(), request, result)
+		}
+	})
+	hooks.AddBeforePing(func(ctx context.Context, _ any, _ *mcp.PingRequest) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			transportDiag.recordPing(session.SessionID())
+		}
+	})
+	return hooks
+}
+
+// sessionTransportName names the transport a session arrived on, which in
+// dual mode tells SSE and streamable HTTP clients apart
+func sessionTransportName(session server.ClientSession) string {
+	if session == nil {
+		return "unknown"
+	}
+	// mcp-go's session types are unexported, so match on the type name
+	name := fmt.Sprintf("%T", session)
+	switch name {
+	case "*server.streamableHttpSession":
+		return "streamable-http"
+	case "*server.sseSession":
+		return "sse"
+	case "*server.stdioSession":
+		return "stdio"
+	case "*server.InProcessSession":
+		return "in-process"
+	}
+	return name
+}
+
+func handleTransportDiag(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, _ := request.Params.Arguments.(map[string]interface{})
+	now := time.Now()
+
+	session := server.ClientSessionFromContext(ctx)
+	sessionID := ""
+	if session != nil {
+		sessionID = session.SessionID()
+	}
+	transport, diag := transportDiag.snapshot(sessionID)
+	uptime := now.Sub(serverStartTime)
+
+	result := TransportDiagResult{
+		Transport:        transport,
+		SessionTransport: sessionTransportName(session),
+		SessionID:        sessionID,
+		ProtocolVersion:  diag.ProtocolVersion,
+		ServerName:       serverName,
+		ServerVersion:    serverVersion,
+		Uptime:           uptime.Round(time.Second).String(),
+		UptimeMs:         uptime.Milliseconds(),
+		ServerTimeMs:     now.UnixMilli(),
+		Pings:            diag.Pings,
+		ResumableStreams: streamRegistry != nil,
+	}
+	if withInfo, ok := session.(server.SessionWithClientInfo); ok {
+		if info := withInfo.GetClientInfo(); info.Name != "" {
+			result.Client = &info
+		}
+	}
+	if result.Client == nil && diag.ClientName != "" {
+		result.Client = &mcp.Implementation{Name: diag.ClientName, Version: diag.ClientVersion}
+	}
+	if !diag.LastPingAt.IsZero() {
+		ago := now.Sub(diag.LastPingAt).Milliseconds()
+		result.LastPingAgoMs = &ago
+	}
+	if sent, ok := args["client_timestamp_ms"].(float64); ok && sent > 0 {
+		result.Latency = &DiagLatency{
+			ClientTimestampMs: int64(sent),
+			ClientToServerMs:  now.UnixMilli() - int64(sent),
+		}
+	}
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize diagnostics: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
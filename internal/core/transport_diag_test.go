@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestTransportDiag_ReportsSession(t *testing.T) {
+	s := server.NewMCPServer(serverName, serverVersion, server.WithHooks(diagnosticsHooks()))
+	ctx := s.WithContext(context.Background(), server.NewInProcessSession("diag-session", nil))
+
+	s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {
+		"protocolVersion": "2025-03-26", "capabilities": {}, "clientInfo": {"name": "diag-client", "version": "0.1"}}}`))
+	s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc": "2.0", "id": 2, "method": "ping"}`))
+
+	sent := time.Now().Add(-20 * time.Millisecond).UnixMilli()
+	res, err := handleTransportDiag(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+		Arguments: map[string]interface{}{"client_timestamp_ms": float64(sent)},
+	}})
+	if err != nil || res.IsError {
+		t.Fatalf("Unexpected failure: %v %+v", err, res)
+	}
+
+	var diag TransportDiagResult
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &diag); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if diag.SessionID != "diag-session" || diag.SessionTransport != "in-process" {
+		t.Errorf("Unexpected session: %q over %q", diag.SessionID, diag.SessionTransport)
+	}
+	if diag.ProtocolVersion != "2025-03-26" || diag.Client == nil || diag.Client.Name != "diag-client" {
+		t.Errorf("Expected the negotiated version and client info, got %q %+v", diag.ProtocolVersion, diag.Client)
+	}
+	if diag.Pings != 1 || diag.LastPingAgoMs == nil {
+		t.Errorf("Expected one recorded ping, got %d", diag.Pings)
+	}
+	if diag.Latency == nil || diag.Latency.ClientToServerMs < 20 {
+		t.Errorf("Expected latency from the client timestamp, got %+v", diag.Latency)
+	}
+	if diag.ServerVersion != serverVersion || diag.UptimeMs < 0 {
+		t.Errorf("Unexpected server info: %+v", diag)
+	}
+}
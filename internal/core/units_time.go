@@ -0,0 +1,479 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Time zones work on hosts without a zoneinfo database
+)
+
+// ============ Units and Time ============
+//
+// units_time does the conversions and calendar arithmetic that models get
+// wrong in their heads: units, currencies, time zones, dates and durations.
+// Like string_ops it takes 'operation:argument'. Currency rates come from a
+// static table, which UNITS_CURRENCY_RATES (a JSON object of units per US
+// dollar) overrides or extends.
+
+// unitKind groups units that convert into each other
+type unitKind string
+
+const (
+	unitLength      unitKind = "length"
+	unitMass        unitKind = "mass"
+	unitVolume      unitKind = "volume"
+	unitData        unitKind = "data"
+	unitTemperature unitKind = "temperature"
+)
+
+type unitDef struct {
+	name   string // Canonical symbol shown in results
+	kind   unitKind
+	factor float64 // Base units (m, kg, L, B) per unit; unused for temperature
+}
+
+// unitTable maps lower-case names and aliases to units; currencies are
+// looked up in currencyRates instead
+var unitTable = map[string]unitDef{}
+
+func defineUnit(kind unitKind, factor float64, name string, aliases ...string) {
+	def := unitDef{name: name, kind: kind, factor: factor}
+	unitTable[strings.ToLower(name)] = def
+	for _, alias := range aliases {
+		unitTable[alias] = def
+	}
+}
+
+func init() {
+	defineUnit(unitLength, 1, "m", "meter", "meters", "metre", "metres")
+	defineUnit(unitLength, 1000, "km", "kilometer", "kilometers", "kilometre", "kilometres")
+	defineUnit(unitLength, 0.01, "cm", "centimeter", "centimeters", "centimetre", "centimetres")
+	defineUnit(unitLength, 0.001, "mm", "millimeter", "millimeters", "millimetre", "millimetres")
+	defineUnit(unitLength, 1e-6, "um", "µm", "micrometer", "micrometers", "micron", "microns")
+	defineUnit(unitLength, 1e-9, "nm", "nanometer", "nanometers")
+	defineUnit(unitLength, 1609.344, "mi", "mile", "miles")
+	defineUnit(unitLength, 0.9144, "yd", "yard", "yards")
+	defineUnit(unitLength, 0.3048, "ft", "foot", "feet")
+	defineUnit(unitLength, 0.0254, "in", "inch", "inches")
+	defineUnit(unitLength, 1852, "nmi", "nautical_mile", "nautical_miles")
+
+	defineUnit(unitMass, 1, "kg", "kilogram", "kilograms", "kilo", "kilos")
+	defineUnit(unitMass, 0.001, "g", "gram", "grams")
+	defineUnit(unitMass, 1e-6, "mg", "milligram", "milligrams")
+	defineUnit(unitMass, 1000, "t", "tonne", "tonnes", "metric_ton", "metric_tons")
+	defineUnit(unitMass, 0.45359237, "lb", "lbs", "pound", "pounds")
+	defineUnit(unitMass, 0.028349523125, "oz", "ounce", "ounces")
+	defineUnit(unitMass, 6.35029318, "st", "stone", "stones")
+
+	defineUnit(unitVolume, 1, "L", "l", "liter", "liters", "litre", "litres")
+	defineUnit(unitVolume, 0.001, "mL", "ml", "milliliter", "milliliters", "millilitre", "millilitres")
+	defineUnit(unitVolume, 1000, "m3", "m^3", "cubic_meter", "cubic_meters")
+	defineUnit(unitVolume, 3.785411784, "gal", "gallon", "gallons")
+	defineUnit(unitVolume, 0.946352946, "qt", "quart", "quarts")
+	defineUnit(unitVolume, 0.473176473, "pt", "pint", "pints")
+	defineUnit(unitVolume, 0.2365882365, "cup", "cups")
+	defineUnit(unitVolume, 0.0295735295625, "floz", "fl_oz", "fluid_ounce", "fluid_ounces")
+
+	defineUnit(unitData, 1, "B", "b", "byte", "bytes")
+	defineUnit(unitData, 1e3, "KB", "kb", "kilobyte", "kilobytes")
+	defineUnit(unitData, 1e6, "MB", "mb", "megabyte", "megabytes")
+	defineUnit(unitData, 1e9, "GB", "gb", "gigabyte", "gigabytes")
+	defineUnit(unitData, 1e12, "TB", "tb", "terabyte", "terabytes")
+	defineUnit(unitData, 1<<10, "KiB", "kib", "kibibyte", "kibibytes")
+	defineUnit(unitData, 1<<20, "MiB", "mib", "mebibyte", "mebibytes")
+	defineUnit(unitData, 1<<30, "GiB", "gib", "gibibyte", "gibibytes")
+	defineUnit(unitData, 1<<40, "TiB", "tib", "tebibyte", "tebibytes")
+
+	defineUnit(unitTemperature, 0, "°C", "c", "celsius", "degc")
+	defineUnit(unitTemperature, 0, "°F", "f", "fahrenheit", "degf")
+	defineUnit(unitTemperature, 0, "K", "k", "kelvin")
+}
+
+// staticCurrencyRates are approximate units per US dollar, as of mid-2024
+var staticCurrencyRates = map[string]float64{
+	"USD": 1, "EUR": 0.92, "GBP": 0.79, "JPY": 157, "CNY": 7.25, "INR": 83.5,
+	"CAD": 1.37, "AUD": 1.5, "CHF": 0.89, "MXN": 18, "BRL": 5.4, "KRW": 1380,
+	"SEK": 10.5, "NOK": 10.6, "DKK": 6.9, "PLN": 4, "SGD": 1.35, "HKD": 7.81,
+	"NZD": 1.63, "ZAR": 18.3, "TRY": 32.5,
+}
+
+var (
+	conversionPattern = regexp.MustCompile(`(?i)^(-?[\d.,]+(?:e-?\d+)?)\s*([^\s\d]\S*)\s+(?:to|in|into|as)\s+(\S+)$`)
+	dateOffsetPattern = regexp.MustCompile(`(?i)([+-])\s*(\d+)\s*(years?|y|months?|mo|weeks?|w|days?|d|hours?|h|minutes?|mins?|seconds?|secs?|s)\b`)
+)
+
+// dateLayouts are the date and time formats the tool reads
+var dateLayouts = []string{
+	time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02",
+}
+
+// UnitsTimeTool converts units and does date and time arithmetic
+type UnitsTimeTool struct {
+	now func() time.Time // Overridden in tests
+}
+
+func (t *UnitsTimeTool) Name() string {
+	return "units_time"
+}
+
+func (t *UnitsTimeTool) Description() string {
+	return "Unit, currency, time zone, date and duration math. Input format: 'operation:argument'. " +
+		"Operations: convert:5 km to mi (length, mass, volume, data, temperature, currency codes like USD to EUR), " +
+		"tz:2024-03-10 14:00 America/New_York to Asia/Tokyo, date:2024-01-31 + 1 month - 2 days (dates may be 'today' or 'now'), " +
+		"between:2024-01-01 and 2024-12-25, duration:3h45m + 2h30m - 15m, weekday:2024-07-04"
+}
+
+func (t *UnitsTimeTool) Execute(ctx context.Context, input string) (string, error) {
+	op, arg, ok := strings.Cut(input, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid format, use 'operation:argument'")
+	}
+	arg = strings.TrimSpace(arg)
+
+	switch strings.ToLower(strings.TrimSpace(op)) {
+	case "convert":
+		return convertUnits(arg)
+	case "tz", "timezone":
+		return t.convertTimeZone(arg)
+	case "date":
+		return t.dateArithmetic(arg)
+	case "between", "diff":
+		return t.between(arg)
+	case "duration":
+		return durationMath(arg)
+	case "weekday":
+		d, err := t.parseTime(arg, time.UTC)
+		if err != nil {
+			return "", err
+		}
+		return d.Weekday().String(), nil
+	default:
+		return "", fmt.Errorf("unknown operation: %s (use convert, tz, date, between, duration or weekday)", op)
+	}
+}
+
+// convertUnits handles '<value> <unit> to <unit>'
+func convertUnits(arg string) (string, error) {
+	m := conversionPattern.FindStringSubmatch(strings.TrimSpace(arg))
+	if m == nil {
+		return "", fmt.Errorf("use 'convert:<value> <unit> to <unit>', e.g. convert:5 km to mi")
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %q", m[1])
+	}
+
+	if rates := currencyRates(); rates[strings.ToUpper(m[2])] != 0 || rates[strings.ToUpper(m[3])] != 0 {
+		from, to := strings.ToUpper(m[2]), strings.ToUpper(m[3])
+		for _, code := range []string{from, to} {
+			if rates[code] == 0 {
+				return "", fmt.Errorf("no rate for %s; known currencies: %s", code, strings.Join(sortedKeys(rates), ", "))
+			}
+		}
+		result := value / rates[from] * rates[to]
+		note := "static rates"
+		if os.Getenv("UNITS_CURRENCY_RATES") != "" {
+			note = "UNITS_CURRENCY_RATES"
+		}
+		return fmt.Sprintf("%s %s = %s %s (%s, 1 %s = %s %s)", formatQuantity(value), from, strconv.FormatFloat(roundTo(result, 2), 'f', 2, 64), to, note, from, formatQuantity(rates[to]/rates[from]), to), nil
+	}
+
+	from, ok := lookupUnit(m[2])
+	if !ok {
+		return "", fmt.Errorf("unknown unit %q", m[2])
+	}
+	to, ok := lookupUnit(m[3])
+	if !ok {
+		return "", fmt.Errorf("unknown unit %q", m[3])
+	}
+	if from.kind != to.kind {
+		return "", fmt.Errorf("cannot convert %s (%s) to %s (%s)", from.name, from.kind, to.name, to.kind)
+	}
+
+	var result float64
+	if from.kind == unitTemperature {
+		kelvin := toKelvin(value, from.name)
+		if kelvin < 0 {
+			return "", fmt.Errorf("%s %s is below absolute zero", formatQuantity(value), from.name)
+		}
+		result = fromKelvin(kelvin, to.name)
+	} else {
+		result = value * from.factor / to.factor
+	}
+	return fmt.Sprintf("%s %s = %s %s", formatQuantity(value), from.name, formatQuantity(result), to.name), nil
+}
+
+func lookupUnit(name string) (unitDef, bool) {
+	if def, ok := unitTable[name]; ok {
+		return def, true
+	}
+	def, ok := unitTable[strings.ToLower(strings.TrimSuffix(name, "."))]
+	return def, ok
+}
+
+func toKelvin(v float64, unit string) float64 {
+	switch unit {
+	case "°C":
+		return v + 273.15
+	case "°F":
+		return (v-32)*5/9 + 273.15
+	}
+	return v
+}
+
+func fromKelvin(k float64, unit string) float64 {
+	switch unit {
+	case "°C":
+		return k - 273.15
+	case "°F":
+		return (k-273.15)*9/5 + 32
+	}
+	return k
+}
+
+// currencyRates returns the static rates with UNITS_CURRENCY_RATES applied
+func currencyRates() map[string]float64 {
+	rates := make(map[string]float64, len(staticCurrencyRates))
+	for code, rate := range staticCurrencyRates {
+		rates[code] = rate
+	}
+	if raw := os.Getenv("UNITS_CURRENCY_RATES"); raw != "" {
+		var override map[string]float64
+		if err := json.Unmarshal([]byte(raw), &override); err != nil {
+			fmt.Fprintf(os.Stderr, "[WARNING] units_time: ignoring invalid UNITS_CURRENCY_RATES: %v\n", err)
+			return rates
+		}
+		for code, rate := range override {
+			if rate > 0 {
+				rates[strings.ToUpper(code)] = rate
+			}
+		}
+	}
+	return rates
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatQuantity prints up to 6 significant digits without exponents for
+// everyday magnitudes
+func formatQuantity(v float64) string {
+	if v != 0 && (math.Abs(v) >= 1e15 || math.Abs(v) < 1e-4) {
+		return strconv.FormatFloat(v, 'g', 6, 64)
+	}
+	digits := 5 - int(math.Floor(math.Log10(math.Abs(v))))
+	if v == 0 || digits < 0 {
+		digits = 0
+	}
+	return strconv.FormatFloat(roundTo(v, digits), 'f', -1, 64)
+}
+
+// parseTime reads a date, date-time, 'today' or 'now' in loc
+func (t *UnitsTimeTool) parseTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	switch strings.ToLower(s) {
+	case "now":
+		return now().In(loc), nil
+	case "today":
+		n := now().In(loc)
+		return time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, loc), nil
+	}
+	for _, layout := range dateLayouts {
+		if d, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot read date %q (use YYYY-MM-DD, 'YYYY-MM-DD HH:MM', RFC 3339, today or now)", s)
+}
+
+// formatTime drops the clock when it is midnight and no zone matters
+func formatTime(d time.Time, withZone bool) string {
+	if withZone {
+		return d.Format("2006-01-02 15:04 MST")
+	}
+	if d.Hour() == 0 && d.Minute() == 0 && d.Second() == 0 {
+		return d.Format("2006-01-02 (Monday)")
+	}
+	return d.Format("2006-01-02 15:04:05 (Monday)")
+}
+
+// convertTimeZone handles '<date-time> <zone> to <zone>'
+func (t *UnitsTimeTool) convertTimeZone(arg string) (string, error) {
+	left, toZone, ok := strings.Cut(arg, " to ")
+	if !ok {
+		return "", fmt.Errorf("use 'tz:<date-time> <zone> to <zone>', e.g. tz:2024-03-10 14:00 America/New_York to Asia/Tokyo")
+	}
+	i := strings.LastIndex(strings.TrimSpace(left), " ")
+	if i < 0 {
+		return "", fmt.Errorf("missing source time zone")
+	}
+	left = strings.TrimSpace(left)
+	fromLoc, err := time.LoadLocation(left[i+1:])
+	if err != nil {
+		return "", fmt.Errorf("unknown time zone %q", left[i+1:])
+	}
+	toLoc, err := time.LoadLocation(strings.TrimSpace(toZone))
+	if err != nil {
+		return "", fmt.Errorf("unknown time zone %q", strings.TrimSpace(toZone))
+	}
+	d, err := t.parseTime(left[:i], fromLoc)
+	if err != nil {
+		return "", err
+	}
+	converted := d.In(toLoc)
+	out := fmt.Sprintf("%s (%s) = %s (%s)", formatTime(d, true), fromLoc, formatTime(converted, true), toLoc)
+	if days := calendarDays(d, converted); days != 0 {
+		out += fmt.Sprintf(", %+d day", days)
+	}
+	return out, nil
+}
+
+// calendarDays is the difference between two times' calendar dates
+func calendarDays(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// addMonths adds months, clamping to the end of shorter months, so
+// January 31 + 1 month is the last day of February rather than March 2
+func addMonths(d time.Time, months int) time.Time {
+	first := time.Date(d.Year(), d.Month(), 1, d.Hour(), d.Minute(), d.Second(), d.Nanosecond(), d.Location()).AddDate(0, months, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(d.Day(), lastDay)-1)
+}
+
+// dateArithmetic handles '<date> +/- <n> <unit> ...'
+func (t *UnitsTimeTool) dateArithmetic(arg string) (string, error) {
+	loc := dateOffsetPattern.FindStringIndex(arg)
+	if loc == nil {
+		return "", fmt.Errorf("use 'date:<date> + <n> <unit>', e.g. date:2024-01-31 + 1 month - 2 days")
+	}
+	d, err := t.parseTime(arg[:loc[0]], time.UTC)
+	if err != nil {
+		return "", err
+	}
+	if rest := strings.TrimSpace(dateOffsetPattern.ReplaceAllString(arg[loc[0]:], "")); rest != "" {
+		return "", fmt.Errorf("cannot read %q as an offset", rest)
+	}
+	for _, m := range dateOffsetPattern.FindAllStringSubmatch(arg[loc[0]:], -1) {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+		switch unit := strings.ToLower(m[3]); {
+		case strings.HasPrefix(unit, "y"):
+			d = addMonths(d, 12*n)
+		case strings.HasPrefix(unit, "mo"):
+			d = addMonths(d, n)
+		case strings.HasPrefix(unit, "w"):
+			d = d.AddDate(0, 0, 7*n)
+		case strings.HasPrefix(unit, "d"):
+			d = d.AddDate(0, 0, n)
+		case strings.HasPrefix(unit, "h"):
+			d = d.Add(time.Duration(n) * time.Hour)
+		case strings.HasPrefix(unit, "mi"):
+			d = d.Add(time.Duration(n) * time.Minute)
+		default:
+			d = d.Add(time.Duration(n) * time.Second)
+		}
+	}
+	return formatTime(d, false), nil
+}
+
+// between handles '<date> and <date>'
+func (t *UnitsTimeTool) between(arg string) (string, error) {
+	left, right, ok := strings.Cut(arg, " and ")
+	if !ok {
+		left, right, ok = strings.Cut(arg, " to ")
+	}
+	if !ok {
+		return "", fmt.Errorf("use 'between:<date> and <date>'")
+	}
+	from, err := t.parseTime(left, time.UTC)
+	if err != nil {
+		return "", err
+	}
+	to, err := t.parseTime(right, time.UTC)
+	if err != nil {
+		return "", err
+	}
+	sign := ""
+	if to.Before(from) {
+		from, to, sign = to, from, "-"
+	}
+
+	// Calendar breakdown: whole years and months, then the remaining days
+	years, months := 0, 0
+	for !addMonths(from, 12*(years+1)).After(to) {
+		years++
+	}
+	for !addMonths(from, 12*years+months+1).After(to) {
+		months++
+	}
+	rest := to.Sub(addMonths(from, 12*years+months))
+	days := int(rest.Hours() / 24)
+
+	diff := to.Sub(from)
+	var parts []string
+	if years > 0 {
+		parts = append(parts, plural(years, "year", "years"))
+	}
+	if months > 0 {
+		parts = append(parts, plural(months, "month", "months"))
+	}
+	if days > 0 || len(parts) == 0 {
+		parts = append(parts, plural(days, "day", "days"))
+	}
+	out := fmt.Sprintf("%s%s (%s%s total", sign, strings.Join(parts, ", "), sign, plural(int(diff.Hours()/24), "day", "days"))
+	if clock := diff % (24 * time.Hour); clock != 0 {
+		out += fmt.Sprintf(" and %s; %s%s hours", clock, sign, formatQuantity(diff.Hours()))
+	} else {
+		out += fmt.Sprintf(", %s%s weeks", sign, formatQuantity(diff.Hours()/24/7))
+	}
+	return out + ")", nil
+}
+
+// durationMath adds and subtracts Go-style durations: '3h45m + 2h30m - 15m'
+func durationMath(arg string) (string, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return "", fmt.Errorf("use 'duration:3h45m + 2h30m - 15m'")
+	}
+	var total time.Duration
+	sign := time.Duration(1)
+	for _, field := range strings.Fields(strings.NewReplacer("+", " + ", " - ", " - ").Replace(" " + arg)) {
+		switch field {
+		case "+":
+			sign = 1
+			continue
+		case "-":
+			sign = -1
+			continue
+		}
+		d, err := time.ParseDuration(strings.ToLower(field))
+		if err != nil {
+			return "", fmt.Errorf("cannot read duration %q (use units like 1h30m, 45m, 90s)", field)
+		}
+		total += sign * d
+		sign = 1
+	}
+	return fmt.Sprintf("%s (%s minutes, %s hours)", total, formatQuantity(total.Minutes()), formatQuantity(total.Hours())), nil
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnitsTime(t *testing.T) {
+	tool := &UnitsTimeTool{now: func() time.Time { return time.Date(2024, 2, 28, 22, 30, 0, 0, time.UTC) }}
+	ctx := context.Background()
+
+	for _, tc := range []struct{ input, want string }{
+		{"convert:5 km to mi", "5 km = 3.10686 mi"},
+		{"convert:1,500 lbs in kg", "1500 lb = 680.389 kg"},
+		{"convert:98.6 F to C", "98.6 °F = 37 °C"},
+		{"convert:0 celsius to kelvin", "0 °C = 273.15 K"},
+		{"convert:2 GiB to MB", "2 GiB = 2147.48 MB"},
+		{"convert:1 gal to mL", "1 gal = 3785.41 mL"},
+		{"convert:100 usd to EUR", "100 USD = 92.00 EUR (static rates, 1 USD = 0.92 EUR)"},
+		{"tz:2024-03-10 14:00 America/New_York to Asia/Tokyo", "2024-03-10 14:00 EDT (America/New_York) = 2024-03-11 03:00 JST (Asia/Tokyo), +1 day"},
+		{"tz:2024-01-15 09:30 UTC to Europe/Paris", "2024-01-15 09:30 UTC (UTC) = 2024-01-15 10:30 CET (Europe/Paris)"},
+		{"date:2024-01-31 + 1 month", "2024-02-29 (Thursday)"},
+		{"date:2024-03-31 - 1 month + 2 days", "2024-03-02 (Saturday)"},
+		{"date:today + 90 days", "2024-05-28 (Tuesday)"},
+		{"date:2024-02-28T23:00 + 2 hours", "2024-02-29 01:00:00 (Thursday)"},
+		{"between:2024-01-01 and 2024-12-25", "11 months, 24 days (359 days total, 51.2857 weeks)"},
+		{"between:2024-12-25 and 2024-01-01", "-11 months, 24 days (-359 days total, -51.2857 weeks)"},
+		{"between:2024-01-01 08:00 and 2024-01-02 20:30", "1 day (1 day total and 12h30m0s; 36.5 hours)"},
+		{"duration:3h45m + 2h30m - 15m", "6h0m0s (360 minutes, 6 hours)"},
+		{"weekday:2024-07-04", "Thursday"},
+	} {
+		if got, err := tool.Execute(ctx, tc.input); err != nil || got != tc.want {
+			t.Errorf("%s = %q, %v; want %q", tc.input, got, err, tc.want)
+		}
+	}
+
+	for _, tc := range []struct{ input, want string }{
+		{"convert:5 km to kg", "cannot convert km (length) to kg (mass)"},
+		{"convert:-500 C to F", "below absolute zero"},
+		{"convert:10 USD to XYZ", "no rate for XYZ"},
+		{"tz:2024-03-10 14:00 Mars/Olympus to UTC", "unknown time zone"},
+		{"date:March 3rd + 1 day", "cannot read date"},
+		{"speed:5", "unknown operation"},
+	} {
+		if _, err := tool.Execute(ctx, tc.input); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.input, tc.want, err)
+		}
+	}
+}
+
+func TestUnitsTime_CurrencyOverride(t *testing.T) {
+	t.Setenv("UNITS_CURRENCY_RATES", `{"eur": 0.5, "BTC": 0.00002}`)
+	got, err := (&UnitsTimeTool{}).Execute(context.Background(), "convert:10 EUR to USD")
+	if err != nil || got != "10 EUR = 20.00 USD (UNITS_CURRENCY_RATES, 1 EUR = 2 USD)" {
+		t.Errorf("Unexpected conversion: %q, %v", got, err)
+	}
+	if _, err := (&UnitsTimeTool{}).Execute(context.Background(), "convert:1 BTC to USD"); err != nil {
+		t.Errorf("Expected an added currency to convert, got %v", err)
+	}
+}
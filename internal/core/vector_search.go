@@ -0,0 +1,298 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ============ Vector Search ============
+//
+// vector_search retrieves passages from an organization's own vector
+// database, so GoT thoughts and dialectic verification can draw on
+// knowledge the model does not have. The query is embedded with
+// VECTOR_EMBEDDING_PROVIDER (default openai) and VECTOR_EMBEDDING_MODEL,
+// and the VECTOR_SEARCH_TOP_K nearest passages are returned. The tool is
+// registered only when VECTOR_DB_URL and VECTOR_DB_COLLECTION are set.
+
+// Vector store names accepted in VECTOR_DB
+const (
+	VectorStoreQdrant = "qdrant"
+	VectorStoreChroma = "chroma"
+)
+
+const (
+	defaultVectorSearchTopK = 5
+	maxVectorSearchTopK     = 20
+	maxVectorPassageChars   = 1500
+)
+
+// VectorPassage is a single vector search hit
+type VectorPassage struct {
+	ID     string  `json:"id"`
+	Score  float64 `json:"score"` // Similarity, or 1 - distance for stores that report distances
+	Text   string  `json:"text"`
+	Source string  `json:"source,omitempty"`
+}
+
+// VectorStore queries a vector database collection
+type VectorStore interface {
+	Name() string
+	Query(ctx context.Context, client *http.Client, vector []float64, k int) ([]VectorPassage, error)
+}
+
+// vectorStoreFromEnv builds the store named by VECTOR_DB (default qdrant),
+// or nil when VECTOR_DB_URL or VECTOR_DB_COLLECTION is unset
+func vectorStoreFromEnv() VectorStore {
+	base := strings.TrimRight(strings.TrimSpace(os.Getenv("VECTOR_DB_URL")), "/")
+	collection := strings.TrimSpace(os.Getenv("VECTOR_DB_COLLECTION"))
+	if base == "" || collection == "" {
+		return nil
+	}
+	apiKey := os.Getenv("VECTOR_DB_API_KEY")
+	textField := withDefault(os.Getenv("VECTOR_DB_TEXT_FIELD"), "text")
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("VECTOR_DB"))); name {
+	case "", VectorStoreQdrant:
+		return &qdrantStore{baseURL: base, collection: collection, apiKey: apiKey, textField: textField}
+	case VectorStoreChroma:
+		return &chromaStore{baseURL: base, collection: collection, apiKey: apiKey}
+	default:
+		fmt.Fprintf(os.Stderr, "[WARNING] vector_search: unknown VECTOR_DB %q (use qdrant or chroma), tool disabled\n", name)
+		return nil
+	}
+}
+
+// postVectorJSON sends body as JSON and decodes the JSON response into out;
+// a nil body sends a GET
+func postVectorJSON(ctx context.Context, client *http.Client, reqURL string, headers map[string]string, body, out interface{}) error {
+	method := "GET"
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		method, reader = "POST", bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		if v != "" {
+			req.Header.Set(k, v)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, out)
+}
+
+// passageText picks the passage text and source out of a payload or metadata
+func passageText(fields map[string]interface{}, textField string) (text, source string) {
+	text, _ = fields[textField].(string)
+	for _, key := range []string{"source", "url", "title", "file"} {
+		if s, ok := fields[key].(string); ok && s != "" {
+			source = s
+			break
+		}
+	}
+	return text, source
+}
+
+// ============ Qdrant ============
+
+type qdrantStore struct {
+	baseURL    string
+	collection string
+	apiKey     string
+	textField  string // Payload field holding the passage text
+}
+
+func (s *qdrantStore) Name() string { return VectorStoreQdrant }
+
+func (s *qdrantStore) Query(ctx context.Context, client *http.Client, vector []float64, k int) ([]VectorPassage, error) {
+	var resp struct {
+		Result []struct {
+			ID      interface{}            `json:"id"`
+			Score   float64                `json:"score"`
+			Payload map[string]interface{} `json:"payload"`
+		} `json:"result"`
+	}
+	reqURL := fmt.Sprintf("%s/collections/%s/points/search", s.baseURL, url.PathEscape(s.collection))
+	body := map[string]interface{}{"vector": vector, "limit": k, "with_payload": true}
+	if err := postVectorJSON(ctx, client, reqURL, map[string]string{"api-key": s.apiKey}, body, &resp); err != nil {
+		return nil, err
+	}
+	passages := make([]VectorPassage, 0, len(resp.Result))
+	for _, r := range resp.Result {
+		text, source := passageText(r.Payload, s.textField)
+		passages = append(passages, VectorPassage{ID: fmt.Sprint(r.ID), Score: r.Score, Text: text, Source: source})
+	}
+	return passages, nil
+}
+
+// ============ Chroma ============
+
+type chromaStore struct {
+	baseURL      string
+	collection   string // Collection name
+	apiKey       string
+	collectionID string // Resolved from the name on first use
+}
+
+func (s *chromaStore) Name() string { return VectorStoreChroma }
+
+func (s *chromaStore) Query(ctx context.Context, client *http.Client, vector []float64, k int) ([]VectorPassage, error) {
+	headers := map[string]string{"X-Chroma-Token": s.apiKey}
+	if s.collectionID == "" {
+		var collection struct {
+			ID string `json:"id"`
+		}
+		if err := postVectorJSON(ctx, client, s.baseURL+"/api/v1/collections/"+url.PathEscape(s.collection), headers, nil, &collection); err != nil {
+			return nil, fmt.Errorf("collection %s: %w", s.collection, err)
+		}
+		s.collectionID = collection.ID
+	}
+
+	var resp struct {
+		IDs       [][]string                 `json:"ids"`
+		Documents [][]string                 `json:"documents"`
+		Metadatas [][]map[string]interface{} `json:"metadatas"`
+		Distances [][]float64                `json:"distances"`
+	}
+	body := map[string]interface{}{
+		"query_embeddings": [][]float64{vector},
+		"n_results":        k,
+		"include":          []string{"documents", "metadatas", "distances"},
+	}
+	if err := postVectorJSON(ctx, client, s.baseURL+"/api/v1/collections/"+s.collectionID+"/query", headers, body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.IDs) == 0 {
+		return nil, nil
+	}
+	passages := make([]VectorPassage, 0, len(resp.IDs[0]))
+	for i, id := range resp.IDs[0] {
+		p := VectorPassage{ID: id}
+		if len(resp.Documents) > 0 && i < len(resp.Documents[0]) {
+			p.Text = resp.Documents[0][i]
+		}
+		if len(resp.Metadatas) > 0 && i < len(resp.Metadatas[0]) {
+			_, p.Source = passageText(resp.Metadatas[0][i], "")
+		}
+		if len(resp.Distances) > 0 && i < len(resp.Distances[0]) {
+			p.Score = 1 - resp.Distances[0][i]
+		}
+		passages = append(passages, p)
+	}
+	return passages, nil
+}
+
+// ============ Tool ============
+
+// VectorSearchTool retrieves passages from the configured vector store
+type VectorSearchTool struct {
+	store    VectorStore
+	embedder EmbeddingProvider // Built from VECTOR_EMBEDDING_PROVIDER on first use when nil
+	model    string
+	topK     int
+	client   *http.Client
+}
+
+// newVectorSearchTool returns the tool for the configured store, or nil
+func newVectorSearchTool() *VectorSearchTool {
+	store := vectorStoreFromEnv()
+	if store == nil {
+		return nil
+	}
+	return &VectorSearchTool{
+		store: store,
+		model: withDefault(os.Getenv("VECTOR_EMBEDDING_MODEL"), "text-embedding-3-small"),
+		topK:  min(max(parseEnvInt("VECTOR_SEARCH_TOP_K", defaultVectorSearchTopK), 1), maxVectorSearchTopK),
+	}
+}
+
+func (t *VectorSearchTool) Name() string {
+	return "vector_search"
+}
+
+func (t *VectorSearchTool) Description() string {
+	return fmt.Sprintf("Search the organization's knowledge base (%s collection) for passages relevant to a question. Input: a natural-language query. Returns the %d closest passages with their sources.", t.store.Name(), t.topK)
+}
+
+func (t *VectorSearchTool) Execute(ctx context.Context, input string) (string, error) {
+	query := strings.TrimSpace(input)
+	if query == "" {
+		return "", fmt.Errorf("empty query")
+	}
+	if t.client == nil {
+		t.client = &http.Client{Timeout: GetConfig().WebFetchTimeout}
+	}
+	if t.embedder == nil {
+		providerType := withDefault(os.Getenv("VECTOR_EMBEDDING_PROVIDER"), "openai")
+		p, err := NewProvider(ProviderConfig{Type: providerType, APIKey: getAPIKeyForProvider(providerType)})
+		if err != nil {
+			return "", fmt.Errorf("embedding provider: %w", err)
+		}
+		embedder, ok := p.(EmbeddingProvider)
+		if !ok {
+			return "", fmt.Errorf("provider %s does not support embeddings (set VECTOR_EMBEDDING_PROVIDER)", providerType)
+		}
+		t.embedder = embedder
+	}
+
+	vectors, err := t.embedder.Embed(ctx, []string{query}, t.model)
+	if err != nil {
+		return "", fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return "", fmt.Errorf("embedding failed: no vector returned")
+	}
+	passages, err := t.store.Query(ctx, t.client, vectors[0], t.topK)
+	if err != nil {
+		return "", fmt.Errorf("%s query failed: %w", t.store.Name(), err)
+	}
+	if len(passages) == 0 {
+		return "No passages found.", nil
+	}
+	return formatVectorPassages(passages), nil
+}
+
+// formatVectorPassages renders passages as a numbered list
+func formatVectorPassages(passages []VectorPassage) string {
+	var parts []string
+	for i, p := range passages {
+		entry := fmt.Sprintf("%d. [score %.3f] %s", i+1, p.Score, withDefault(p.Source, p.ID))
+		if text := strings.TrimSpace(p.Text); text != "" {
+			if len(text) > maxVectorPassageChars {
+				text = text[:maxVectorPassageChars] + "...(truncated)"
+			}
+			entry += "\n   " + strings.ReplaceAll(text, "\n", "\n   ")
+		}
+		parts = append(parts, entry)
+	}
+	return strings.Join(parts, "\n\n")
+}
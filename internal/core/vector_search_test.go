@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVectorSearch_Qdrant(t *testing.T) {
+	var body map[string]interface{}
+	db := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/collections/handbook/points/search" || r.Header.Get("api-key") != "secret" {
+			http.Error(w, "unexpected request "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"result": [
+			{"id": 7, "score": 0.91, "payload": {"content": "Refunds need manager approval above $500.", "source": "policies/refunds.md"}},
+			{"id": "b2", "score": 0.42, "payload": {"content": "The office closes at 6pm."}}
+		]}`)
+	}))
+	defer db.Close()
+	t.Setenv("VECTOR_DB_URL", db.URL+"/")
+	t.Setenv("VECTOR_DB_COLLECTION", "handbook")
+	t.Setenv("VECTOR_DB_API_KEY", "secret")
+	t.Setenv("VECTOR_DB_TEXT_FIELD", "content")
+	t.Setenv("VECTOR_SEARCH_TOP_K", "2")
+
+	registry := NewToolRegistry()
+	tool, ok := registry.tools["vector_search"].(*VectorSearchTool)
+	if !ok || !registry.enabled["vector_search"] {
+		t.Fatal("Expected vector_search registered and enabled when VECTOR_DB_URL is set")
+	}
+	tool.embedder = &vectorProvider{vectors: map[string][]float64{"refund approval": {0.5, 0.5}}}
+
+	res := registry.Execute(context.Background(), "vector_search", "refund approval")
+	if !res.Success {
+		t.Fatalf("vector_search failed: %s", res.Error)
+	}
+	want := "1. [score 0.910] policies/refunds.md\n   Refunds need manager approval above $500.\n\n2. [score 0.420] b2\n   The office closes at 6pm."
+	if res.Output != want {
+		t.Errorf("Unexpected output:\n%s", res.Output)
+	}
+	if fmt.Sprint(body["vector"]) != "[0.5 0.5]" || body["limit"] != 2.0 || body["with_payload"] != true {
+		t.Errorf("Unexpected search body: %v", body)
+	}
+}
+
+func TestVectorSearch_Chroma(t *testing.T) {
+	lookups := 0
+	db := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/collections/handbook":
+			lookups++
+			fmt.Fprint(w, `{"id": "c-123", "name": "handbook"}`)
+		case "/api/v1/collections/c-123/query":
+			fmt.Fprint(w, `{"ids": [["d1"]], "documents": [["Badges are issued by security."]], "metadatas": [[{"title": "Onboarding"}]], "distances": [[0.25]]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer db.Close()
+	t.Setenv("VECTOR_DB", "chroma")
+	t.Setenv("VECTOR_DB_URL", db.URL)
+	t.Setenv("VECTOR_DB_COLLECTION", "handbook")
+
+	tool := newVectorSearchTool()
+	if tool == nil {
+		t.Fatal("Expected a chroma-backed tool")
+	}
+	tool.embedder = &vectorProvider{vectors: map[string][]float64{"badges": {1, 0}}}
+	for i := 0; i < 2; i++ {
+		out, err := tool.Execute(context.Background(), "badges")
+		if err != nil || out != "1. [score 0.750] Onboarding\n   Badges are issued by security." {
+			t.Fatalf("Unexpected result: %q, %v", out, err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("Expected the collection id resolved once, got %d lookups", lookups)
+	}
+}
+
+func TestVectorSearch_NotConfigured(t *testing.T) {
+	t.Setenv("VECTOR_DB_URL", "")
+	if _, ok := NewToolRegistry().tools["vector_search"]; ok {
+		t.Error("Expected vector_search absent without VECTOR_DB_URL")
+	}
+	t.Setenv("VECTOR_DB_URL", "http://localhost:6333")
+	t.Setenv("VECTOR_DB_COLLECTION", "handbook")
+	t.Setenv("VECTOR_DB", "pinecone")
+	if tool := newVectorSearchTool(); tool != nil {
+		t.Error("Expected an unknown VECTOR_DB to disable the tool")
+	}
+	if _, err := (&VectorSearchTool{store: &qdrantStore{}}).Execute(context.Background(), " "); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("Expected an empty query rejected, got %v", err)
+	}
+}
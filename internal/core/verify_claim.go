@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ============ Claim Verification ============
+//
+// verify_claim runs the claim verification of dialectic_reason on its own:
+// with tools enabled the evaluator first picks up to two tool calls that
+// fact-check the claim, then judges the claim against its context and the
+// evidence. Clients that only need a claim checked get the Verification
+// without paying for a debate.
+
+// verifyClaimNoContext stands in for the problem of a claim verified without context
+const verifyClaimNoContext = "Decide whether the claim below is true and well supported."
+
+var verifyClaimArgSpecs = []argSpec{
+	{Name: "enable_tools", Kind: argBool, Default: true},
+	{Name: "max_tool_calls_per_phase", Kind: argInt, Min: 0, Max: 100},
+	{Name: "max_tokens", Kind: argInt, Min: 1, Max: noArgLimit},
+}
+
+// ClaimVerificationResult is the verification of one claim
+type ClaimVerificationResult struct {
+	Claim   string `json:"claim"`
+	Context string `json:"context,omitempty"`
+	Verification
+	Model      string         `json:"model,omitempty"`      // Provider/models that verified the claim
+	Provenance *Provenance    `json:"provenance,omitempty"` // Provider/models asked and answering
+	ToolsUsed  map[string]int `json:"tools_used,omitempty"`
+	Provider   string         `json:"provider"`
+	Evaluator  string         `json:"evaluator,omitempty"` // Set when a separate critic verified the claim
+	JSONRepairUsage
+	LLMCallUsage
+}
+
+// VerifyClaim verifies a claim outside a debate, against claimContext when
+// it is not empty. Only provider errors are returned as errors; a reply that
+// cannot be parsed gives an unverified result.
+func (d *DialecticalReasoner) VerifyClaim(ctx context.Context, claim, claimContext string) (*ClaimVerificationResult, error) {
+	d.resetToolBudget()
+	d.verifyCache = nil
+	d.calibration = newCalibrationRecorder(d.config.Calibration)
+	d.jsonRepair = newJSONRepairer()
+
+	problem := strings.TrimSpace(claimContext)
+	if problem == "" {
+		problem = verifyClaimNoContext
+	}
+	verifyCtx, verifiedBy := withModelCalls(ctx)
+	v, err := d.verify(verifyCtx, problem, claim, "claim")
+	if err != nil && v.Status == "" {
+		return nil, err
+	}
+	if err != nil && v.ErrorReason == "" {
+		v.ErrorReason = fmt.Sprintf("verification error: %v", err)
+	}
+
+	result := &ClaimVerificationResult{
+		Claim:           claim,
+		Context:         claimContext,
+		Verification:    v,
+		Model:           verifiedBy.label(),
+		Provenance:      verifiedBy.provenance(requestedModel(d.evaluator(), "")),
+		Provider:        d.provider.Name(),
+		Evaluator:       providerName(d.config.Evaluator),
+		JSONRepairUsage: d.jsonRepair.report(),
+	}
+	for _, tr := range v.ToolResults {
+		if result.ToolsUsed == nil {
+			result.ToolsUsed = make(map[string]int)
+		}
+		result.ToolsUsed[tr.Tool]++
+	}
+	return result, nil
+}
+
+// verifyClaimConfigFromArgs builds the verifier's config from verify_claim arguments
+func verifyClaimConfigFromArgs(args map[string]interface{}) (DialecticConfig, error) {
+	config := DefaultDialecticConfig()
+	parsed, err := parseArgs(args, verifyClaimArgSpecs)
+	if err != nil {
+		return config, err
+	}
+	config.EnableTools = parsed.Bool("enable_tools")
+	if parsed.Has("max_tokens") {
+		config.MaxTokens = clampMaxTokens(parsed.Int("max_tokens"))
+	}
+	if parsed.Has("max_tool_calls_per_phase") {
+		config.PhaseToolBudgets = phaseToolBudgets(parsed.Int("max_tool_calls_per_phase"))
+	}
+	if tools, ok := enabledToolsFromArgs(args); ok {
+		config.EnabledTools = tools
+	}
+	config.Calibration = calibrationConfigFromArgs(args, "verify_claim")
+	return config, nil
+}
+
+func handleVerifyClaim(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments format"), nil
+	}
+	claim, _ := args["claim"].(string)
+	if strings.TrimSpace(claim) == "" {
+		return mcp.NewToolResultError("claim parameter is required"), nil
+	}
+	claimContext, _ := args["context"].(string)
+
+	provider, err := getProviderFromArgsForTool(args, "verify_claim")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Provider error: %v", err)), nil
+	}
+	llmCalls := llmCallCounterFromArgs(args, "verify_claim")
+	provider = llmCalls.Wrap(provider)
+
+	config, err := verifyClaimConfigFromArgs(args)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+	evaluator, err := getEvaluatorProviderFromArgs(args, "verify_claim")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Provider error: %v", err)), nil
+	}
+	config.Evaluator = llmCalls.Wrap(evaluator)
+
+	result, err := NewDialecticalReasoner(provider, config).VerifyClaim(ctx, claim, claimContext)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Claim verification failed: %v", err)), nil
+	}
+	result.LLMCallUsage = runLLMUsage(ctx, llmCalls)
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize response: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
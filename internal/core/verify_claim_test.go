@@ -0,0 +1,59 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyClaim(t *testing.T) {
+	fake := newFakeOpenAI(t, func(req fakeChatRequest) string {
+		if strings.Contains(req.user(), "What tool calls would help") {
+			return `[{"tool": "calculator", "input": "17 * 23"}]`
+		}
+		return solveResponder(req)
+	})
+	useFakeProvider(t, "openai", fake)
+	s := integrationServer(t)
+
+	result := callTool(t, s, "verify_claim", map[string]interface{}{"claim": "17 * 23 = 391", "context": "What is 17 * 23?"})
+	if result["is_valid"] != true || result["score"] != 0.95 || result["status"] != string(StatusVerified) {
+		t.Errorf("Expected a verified claim, got %v", result)
+	}
+	tools, _ := result["tool_results"].([]interface{})
+	if len(tools) != 1 || !strings.Contains(tools[0].(map[string]interface{})["output"].(string), "391") {
+		t.Errorf("Expected the calculator evidence, got %v", result["tool_results"])
+	}
+	if used, _ := result["tools_used"].(map[string]interface{}); used["calculator"] != float64(1) {
+		t.Errorf("Expected the calculator counted, got %v", result["tools_used"])
+	}
+	if result["provenance"] == nil {
+		t.Error("Expected the provenance of the verification")
+	}
+	requests := fake.received()
+	if last := requests[len(requests)-1].user(); !strings.Contains(last, "Tool-gathered evidence") || !strings.Contains(last, "What is 17 * 23?") {
+		t.Errorf("Expected the evidence and context in the verification prompt, got %q", last)
+	}
+
+	before := len(fake.received())
+	result = callTool(t, s, "verify_claim", map[string]interface{}{"claim": "17 * 23 = 391", "enable_tools": false})
+	if result["tool_results"] != nil || len(fake.received())-before != 1 {
+		t.Errorf("Expected one verification call without tools, got %v", result["tool_results"])
+	}
+
+	// The claim phase is capped like the phases of a debate
+	before = len(fake.received())
+	result = callTool(t, s, "verify_claim", map[string]interface{}{"claim": "17 * 23 = 391", "max_tool_calls_per_phase": 0})
+	if result["tool_results"] != nil || len(fake.received())-before != 1 {
+		t.Errorf("Expected max_tool_calls_per_phase 0 to skip the evidence, got %v", result["tool_results"])
+	}
+	if config, err := dialecticConfigFromArgs(map[string]interface{}{"max_tool_calls_per_phase": 3.0}); err != nil || config.PhaseToolBudgets["rebuttal"] != 3 || config.PhaseToolBudgets["claim"] != 3 {
+		t.Errorf("Expected every verification phase capped, got %v (%v)", config.PhaseToolBudgets, err)
+	}
+
+	if text := callToolError(t, s, "verify_claim", map[string]interface{}{"context": "What is 17 * 23?"}); !strings.Contains(text, "claim") {
+		t.Errorf("Expected a missing claim error, got %q", text)
+	}
+	if text := callToolError(t, s, "verify_claim", map[string]interface{}{"claim": "x", "enable_tools": "yes"}); !strings.Contains(text, errCodeInvalidArgument) {
+		t.Errorf("Expected an invalid argument error, got %q", text)
+	}
+}
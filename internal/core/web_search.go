@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Search backend names accepted in WEB_SEARCH_BACKENDS
+const (
+	SearchBackendSerpAPI    = "serpapi"
+	SearchBackendBrave      = "brave"
+	SearchBackendSearx      = "searx"
+	SearchBackendDuckDuckGo = "duckduckgo"
+)
+
+const maxSearchResults = 10
+
+// SearchResult is a single web search hit
+type SearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchBackend queries a web search API
+type SearchBackend interface {
+	Name() string
+	Search(ctx context.Context, client *http.Client, query string) ([]SearchResult, error)
+}
+
+// searchBackendsFromEnv returns the API backends to try in order. By default
+// every backend with credentials configured is used (SerpAPI, Brave, SearxNG);
+// WEB_SEARCH_BACKENDS overrides the order as a comma-separated list. The
+// DuckDuckGo HTML scraper is not included: it is always the last resort.
+func searchBackendsFromEnv() []SearchBackend {
+	names := []string{SearchBackendSerpAPI, SearchBackendBrave, SearchBackendSearx}
+	explicit := false
+	if v := strings.TrimSpace(os.Getenv("WEB_SEARCH_BACKENDS")); v != "" {
+		names = strings.Split(v, ",")
+		explicit = true
+	}
+
+	var backends []SearchBackend
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		var backend SearchBackend
+		var missing string
+		switch name {
+		case SearchBackendSerpAPI:
+			if key := os.Getenv("SERPAPI_API_KEY"); key != "" {
+				backend = &serpAPISearch{apiKey: key, baseURL: withDefault(os.Getenv("SERPAPI_BASE_URL"), "https://serpapi.com")}
+			}
+			missing = "SERPAPI_API_KEY"
+		case SearchBackendBrave:
+			if key := os.Getenv("BRAVE_SEARCH_API_KEY"); key != "" {
+				backend = &braveSearch{apiKey: key, baseURL: withDefault(os.Getenv("BRAVE_SEARCH_BASE_URL"), "https://api.search.brave.com")}
+			}
+			missing = "BRAVE_SEARCH_API_KEY"
+		case SearchBackendSearx, "searxng":
+			if base := os.Getenv("SEARXNG_URL"); base != "" {
+				backend = &searxSearch{baseURL: strings.TrimRight(base, "/")}
+			}
+			missing = "SEARXNG_URL"
+		case SearchBackendDuckDuckGo, "":
+			continue
+		default:
+			fmt.Fprintf(os.Stderr, "[WARNING] web_fetch: unknown search backend %q, skipping\n", name)
+			continue
+		}
+		if backend == nil {
+			if explicit {
+				fmt.Fprintf(os.Stderr, "[WARNING] web_fetch: search backend %s requires %s, skipping\n", name, missing)
+			}
+			continue
+		}
+		backends = append(backends, backend)
+	}
+	return backends
+}
+
+// getSearchJSON performs a GET request and decodes a JSON response into out
+func getSearchJSON(ctx context.Context, client *http.Client, reqURL string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; ReasoningBot/1.0)")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// formatSearchResults renders results as a numbered list
+func formatSearchResults(results []SearchResult) string {
+	var parts []string
+	for i, r := range results {
+		entry := fmt.Sprintf("%d. %s", i+1, strings.TrimSpace(r.Title))
+		if r.URL != "" {
+			entry += "\n   " + r.URL
+		}
+		if snippet := strings.TrimSpace(stripHTML(r.Snippet)); snippet != "" {
+			entry += "\n   " + snippet
+		}
+		parts = append(parts, entry)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ============ SerpAPI ============
+
+type serpAPISearch struct {
+	apiKey  string
+	baseURL string
+}
+
+func (s *serpAPISearch) Name() string { return SearchBackendSerpAPI }
+
+func (s *serpAPISearch) Search(ctx context.Context, client *http.Client, query string) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("engine", "google")
+	params.Set("q", query)
+	params.Set("num", fmt.Sprintf("%d", maxSearchResults))
+	params.Set("api_key", s.apiKey)
+
+	var resp struct {
+		Error          string `json:"error"`
+		OrganicResults []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"organic_results"`
+	}
+	if err := getSearchJSON(ctx, client, s.baseURL+"/search.json?"+params.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+
+	var results []SearchResult
+	for _, r := range resp.OrganicResults {
+		results = append(results, SearchResult{Title: r.Title, URL: r.Link, Snippet: r.Snippet})
+	}
+	return results, nil
+}
+
+// ============ Brave Search ============
+
+type braveSearch struct {
+	apiKey  string
+	baseURL string
+}
+
+func (s *braveSearch) Name() string { return SearchBackendBrave }
+
+func (s *braveSearch) Search(ctx context.Context, client *http.Client, query string) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("count", fmt.Sprintf("%d", maxSearchResults))
+
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	headers := map[string]string{"X-Subscription-Token": s.apiKey}
+	if err := getSearchJSON(ctx, client, s.baseURL+"/res/v1/web/search?"+params.Encode(), headers, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range resp.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return results, nil
+}
+
+// ============ SearxNG ============
+
+// searxSearch queries a self-hosted SearxNG instance (the json output format
+// must be enabled in its settings.yml)
+type searxSearch struct {
+	baseURL string
+}
+
+func (s *searxSearch) Name() string { return SearchBackendSearx }
+
+func (s *searxSearch) Search(ctx context.Context, client *http.Client, query string) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getSearchJSON(ctx, client, s.baseURL+"/search?"+params.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range resp.Results {
+		if len(results) >= maxSearchResults {
+			break
+		}
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func clearSearchEnv(t *testing.T) {
+	for _, key := range []string{"WEB_SEARCH_BACKENDS", "SERPAPI_API_KEY", "SERPAPI_BASE_URL", "BRAVE_SEARCH_API_KEY", "BRAVE_SEARCH_BASE_URL", "SEARXNG_URL"} {
+		t.Setenv(key, "")
+	}
+}
+
+func backendNames(backends []SearchBackend) string {
+	var names []string
+	for _, b := range backends {
+		names = append(names, b.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSearchBackendsFromEnv(t *testing.T) {
+	clearSearchEnv(t)
+	if got := backendNames(searchBackendsFromEnv()); got != "" {
+		t.Errorf("Expected no API backends without credentials, got %q", got)
+	}
+
+	t.Setenv("SEARXNG_URL", "http://searx.local/")
+	t.Setenv("BRAVE_SEARCH_API_KEY", "brave-key")
+	if got := backendNames(searchBackendsFromEnv()); got != "brave,searx" {
+		t.Errorf("Expected configured backends in default order, got %q", got)
+	}
+
+	t.Setenv("WEB_SEARCH_BACKENDS", "searx, serpapi, duckduckgo, brave")
+	if got := backendNames(searchBackendsFromEnv()); got != "searx,brave" {
+		t.Errorf("Expected explicit order skipping unconfigured backends, got %q", got)
+	}
+}
+
+func TestBraveSearch_SendsTokenAndParses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Subscription-Token") != "brave-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/res/v1/web/search" || r.URL.Query().Get("q") != "golang" {
+			t.Errorf("Unexpected request: %s", r.URL)
+		}
+		w.Write([]byte(`{"web":{"results":[{"title":"The Go Programming Language","url":"https://go.dev","description":"Build <strong>simple</strong> software"}]}}`))
+	}))
+	defer server.Close()
+
+	b := &braveSearch{apiKey: "brave-key", baseURL: server.URL}
+	results, err := b.Search(context.Background(), server.Client(), "golang")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].URL != "https://go.dev" {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	if out := formatSearchResults(results); !strings.Contains(out, "Build simple software") {
+		t.Errorf("Expected HTML stripped from snippet, got %q", out)
+	}
+}
+
+func TestWebFetchSearch_FallsThroughFailingBackend(t *testing.T) {
+	serp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":"Invalid API key"}`))
+	}))
+	defer serp.Close()
+	searx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") != "json" {
+			t.Errorf("Expected json format, got %s", r.URL)
+		}
+		w.Write([]byte(`{"results":[{"title":"SearxNG","url":"https://searx.example","content":"Privacy-respecting metasearch"}]}`))
+	}))
+	defer searx.Close()
+
+	clearSearchEnv(t)
+	t.Setenv("SERPAPI_API_KEY", "bad-key")
+	t.Setenv("SERPAPI_BASE_URL", serp.URL)
+	t.Setenv("SEARXNG_URL", searx.URL)
+
+	tool := &WebFetchTool{client: http.DefaultClient}
+	out, err := tool.Execute(context.Background(), "search: metasearch")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if !strings.Contains(out, "1. SearxNG") || !strings.Contains(out, "https://searx.example") {
+		t.Errorf("Expected SearxNG results after SerpAPI error, got %q", out)
+	}
+}
@@ -0,0 +1,435 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============ Worker Pool ============
+//
+// By default one process serves MCP and runs the strategies (role "all").
+// For heavy batch use the two can be split: "api" front ends put every
+// strategy call on a durable work queue in Redis and wait for its result,
+// and "worker" processes, which serve no transport, take calls off the
+// queue and run them through the full tool stack. Front ends and workers
+// then scale independently; runs share state through the Redis backends.
+//
+// A worker holds a lease on each call it runs. A call whose lease runs out
+// (its worker died) is put back on the queue, up to maxWorkAttempts times.
+// A front end whose caller goes away cancels the call on the worker.
+
+const (
+	roleAll    = "all"
+	roleAPI    = "api"
+	roleWorker = "worker"
+
+	defaultWorkerConcurrency = 4
+	workLease                = 30 * time.Second
+	workResultTTL            = time.Hour
+	workPollInterval         = 200 * time.Millisecond
+	maxWorkAttempts          = 3
+)
+
+// serverRole is the process's role, set from -role or MCP_ROLE
+var serverRole = roleAll
+
+// WorkItem is a strategy call on the work queue
+type WorkItem struct {
+	ID         string                 `json:"id"`
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments"`
+	Deadline   *time.Time             `json:"deadline,omitempty"` // The front end's deadline for the call
+	EnqueuedAt time.Time              `json:"enqueued_at"`
+	Attempt    int                    `json:"attempt"` // 1 for the first worker to take it
+}
+
+// WorkResult is a finished call's tool result
+type WorkResult struct {
+	Text    string `json:"text"`
+	IsError bool   `json:"is_error"`
+}
+
+// WorkQueueStatus is the work queue's part of queue_status
+type WorkQueueStatus struct {
+	Role       string `json:"role"`
+	Pending    int64  `json:"pending"`
+	Processing int64  `json:"processing"`
+}
+
+// RedisWorkQueue is the work queue. Pending call IDs wait in one list and
+// move atomically, with a lease, to a processing list when a worker takes
+// them; each call's item and result live in a hash.
+type RedisWorkQueue struct {
+	client *redis.Client
+}
+
+func (q *RedisWorkQueue) pendingKey() string    { return redisKey("work", "pending") }
+func (q *RedisWorkQueue) processingKey() string { return redisKey("work", "processing") }
+func (q *RedisWorkQueue) itemKey(id string) string {
+	return redisKey("work", "item", id)
+}
+func (q *RedisWorkQueue) leaseKey(id string) string {
+	return redisKey("work", "lease", id)
+}
+func (q *RedisWorkQueue) cancelKey(id string) string {
+	return redisKey("work", "cancel", id)
+}
+
+var (
+	workQueue     *RedisWorkQueue
+	workQueueOnce sync.Once
+)
+
+// getWorkQueue returns the work queue, or nil without Redis
+func getWorkQueue() *RedisWorkQueue {
+	workQueueOnce.Do(func() {
+		if client := getRedis(); client != nil {
+			workQueue = &RedisWorkQueue{client: client}
+		}
+	})
+	return workQueue
+}
+
+// Enqueue adds a call to the queue
+func (q *RedisWorkQueue) Enqueue(ctx context.Context, item WorkItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	pipe := q.client.TxPipeline()
+	pipe.HSet(ctx, q.itemKey(item.ID), "item", data, "status", "queued")
+	pipe.Expire(ctx, q.itemKey(item.ID), 24*time.Hour)
+	pipe.LPush(ctx, q.pendingKey(), item.ID)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Await waits for a call's result. When ctx ends first the call is
+// cancelled, wherever it is.
+func (q *RedisWorkQueue) Await(ctx context.Context, id string) (*WorkResult, error) {
+	for {
+		fields, err := q.client.HMGet(ctx, q.itemKey(id), "status", "result", "is_error").Result()
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if err == nil && fields[0] == "done" {
+			text, _ := fields[1].(string)
+			return &WorkResult{Text: text, IsError: fields[2] == "1"}, nil
+		}
+		select {
+		case <-ctx.Done():
+			cancelCtx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+			q.client.Set(cancelCtx, q.cancelKey(id), "1", workResultTTL)
+			cancel()
+			return nil, ctx.Err()
+		case <-time.After(workPollInterval):
+		}
+	}
+}
+
+// redisTakeWorkScript moves the oldest pending ID to the processing list
+// and leases it, atomically so no reaper can see it unleased
+var redisTakeWorkScript = redis.NewScript(`
+local id = redis.call('RPOP', KEYS[1])
+if not id then
+	return false
+end
+redis.call('LPUSH', KEYS[2], id)
+redis.call('SET', ARGV[1] .. id, ARGV[2], 'PX', ARGV[3])
+return id
+`)
+
+// Next waits for a call to run and leases it to the caller
+func (q *RedisWorkQueue) Next(ctx context.Context, worker string) (*WorkItem, error) {
+	for {
+		id, err := redisTakeWorkScript.Run(ctx, q.client, []string{q.pendingKey(), q.processingKey()},
+			redisKey("work", "lease", ""), worker, workLease.Milliseconds()).Text()
+		if err == nil {
+			item, err := q.claim(ctx, id, worker)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: dropping unreadable queued call %s: %v\n", id, err)
+				q.client.LRem(ctx, q.processingKey(), 1, id)
+				continue
+			}
+			return item, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "Warning: work queue unavailable: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(workPollInterval):
+		}
+	}
+}
+
+// claim records that worker is running call id and returns its item
+func (q *RedisWorkQueue) claim(ctx context.Context, id, worker string) (*WorkItem, error) {
+	data, err := q.client.HGet(ctx, q.itemKey(id), "item").Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var item WorkItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	attempt, err := q.client.HIncrBy(ctx, q.itemKey(id), "attempts", 1).Result()
+	if err != nil {
+		return nil, err
+	}
+	item.Attempt = int(attempt)
+	q.client.HSet(ctx, q.itemKey(id), "status", "running", "worker", worker)
+	return &item, nil
+}
+
+// Renew extends the lease on a running call
+func (q *RedisWorkQueue) Renew(ctx context.Context, id, worker string) error {
+	return q.client.Set(ctx, q.leaseKey(id), worker, workLease).Err()
+}
+
+// Cancelled reports whether the caller of a call has gone away
+func (q *RedisWorkQueue) Cancelled(ctx context.Context, id string) bool {
+	n, _ := q.client.Exists(ctx, q.cancelKey(id)).Result()
+	return n > 0
+}
+
+// Complete stores a call's result and takes it off the processing list
+func (q *RedisWorkQueue) Complete(ctx context.Context, id string, result WorkResult) error {
+	isError := "0"
+	if result.IsError {
+		isError = "1"
+	}
+	pipe := q.client.TxPipeline()
+	pipe.HSet(ctx, q.itemKey(id), "status", "done", "result", result.Text, "is_error", isError)
+	pipe.Expire(ctx, q.itemKey(id), workResultTTL)
+	pipe.LRem(ctx, q.processingKey(), 1, id)
+	pipe.Del(ctx, q.leaseKey(id))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// redisExpiredWorkScript takes calls whose lease ran out off the
+// processing list and returns their IDs
+var redisExpiredWorkScript = redis.NewScript(`
+local expired = {}
+for _, id in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	if redis.call('EXISTS', ARGV[1] .. id) == 0 then
+		redis.call('LREM', KEYS[1], 1, id)
+		table.insert(expired, id)
+	end
+end
+return expired
+`)
+
+// Requeue puts calls abandoned by dead workers back on the queue, failing
+// those that have used up their attempts. It returns how many it requeued.
+func (q *RedisWorkQueue) Requeue(ctx context.Context) (int, error) {
+	ids, err := redisExpiredWorkScript.Run(ctx, q.client, []string{q.processingKey()}, redisKey("work", "lease", "")).StringSlice()
+	if err != nil {
+		return 0, err
+	}
+	requeued := 0
+	for _, id := range ids {
+		attempts, _ := q.client.HGet(ctx, q.itemKey(id), "attempts").Int()
+		if attempts >= maxWorkAttempts {
+			q.Complete(ctx, id, WorkResult{Text: fmt.Sprintf("run abandoned: its worker stopped responding %d times", attempts), IsError: true})
+			continue
+		}
+		q.client.HSet(ctx, q.itemKey(id), "status", "queued")
+		if err := q.client.LPush(ctx, q.pendingKey(), id).Err(); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
+// Status counts pending and running calls
+func (q *RedisWorkQueue) Status(ctx context.Context) WorkQueueStatus {
+	status := WorkQueueStatus{Role: serverRole}
+	status.Pending, _ = q.client.LLen(ctx, q.pendingKey()).Result()
+	status.Processing, _ = q.client.LLen(ctx, q.processingKey()).Result()
+	return status
+}
+
+// workQueueMiddleware sends strategy calls to the workers on api front ends
+func workQueueMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return newWorkQueueMiddleware(func() *RedisWorkQueue {
+		if serverRole != roleAPI {
+			return nil
+		}
+		return getWorkQueue()
+	})(next)
+}
+
+func newWorkQueueMiddleware(queue func() *RedisWorkQueue) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			q := queue()
+			if q == nil || !runHistoryTools[request.Params.Name] {
+				return next(ctx, request)
+			}
+			args, _ := request.Params.Arguments.(map[string]interface{})
+			item := WorkItem{ID: "work_" + newRedisHolderID(), Tool: request.Params.Name, Arguments: args, EnqueuedAt: time.Now()}
+			if deadline, ok := ctx.Deadline(); ok {
+				item.Deadline = &deadline
+			}
+			if err := q.Enqueue(ctx, item); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to queue the run: %v", err)), nil
+			}
+			result, err := q.Await(ctx, item.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("run %s did not finish: %v", item.ID, err)), nil
+			}
+			if result.IsError {
+				return mcp.NewToolResultError(result.Text), nil
+			}
+			return mcp.NewToolResultText(result.Text), nil
+		}
+	}
+}
+
+// runWorker runs queued calls on s, concurrency at a time, until ctx ends.
+// Calls already running are finished first.
+func runWorker(ctx context.Context, s *server.MCPServer, q *RedisWorkQueue, concurrency int) {
+	host, _ := os.Hostname()
+	worker := fmt.Sprintf("%s-%d-%s", host, os.Getpid(), newRedisHolderID()[:6])
+	log.Printf("[WORKER] %s taking runs from the work queue, %d at a time", worker, concurrency)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(workLease / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := q.Requeue(ctx); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to requeue abandoned runs: %v\n", err)
+				} else if n > 0 {
+					log.Printf("[WORKER] Requeued %d run(s) abandoned by other workers", n)
+				}
+			}
+		}
+	}()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, err := q.Next(ctx, worker)
+				if err != nil {
+					return
+				}
+				runWorkItem(s, q, item, worker)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runWorkItem runs one call on s, renewing its lease and watching for
+// cancellation, and stores the result
+func runWorkItem(s *server.MCPServer, q *RedisWorkQueue, item *WorkItem, worker string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if item.Deadline != nil {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithDeadline(ctx, *item.Deadline)
+		defer cancelDeadline()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		renew := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				opCtx, opCancel := context.WithTimeout(context.Background(), redisOpTimeout)
+				if q.Cancelled(opCtx, item.ID) {
+					cancel()
+				}
+				if time.Since(renew) >= workLease/3 {
+					q.Renew(opCtx, item.ID, worker)
+					renew = time.Now()
+				}
+				opCancel()
+			}
+		}
+	}()
+
+	start := time.Now()
+	result := callToolOn(ctx, s, item.Tool, item.Arguments)
+	close(done)
+	log.Printf("[WORKER] %s %s (attempt %d) finished in %s", item.ID, item.Tool, item.Attempt, time.Since(start).Round(time.Millisecond))
+
+	opCtx, opCancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer opCancel()
+	if err := q.Complete(opCtx, item.ID, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to store the result of %s: %v\n", item.ID, err)
+	}
+}
+
+// callToolOn calls a tool on s as an MCP client would
+func callToolOn(ctx context.Context, s *server.MCPServer, tool string, args map[string]interface{}) WorkResult {
+	params, err := json.Marshal(map[string]interface{}{"name": tool, "arguments": args})
+	if err != nil {
+		return WorkResult{Text: err.Error(), IsError: true}
+	}
+	msg := `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": ` + string(params) + `}`
+	raw, err := json.Marshal(s.HandleMessage(ctx, json.RawMessage(msg)))
+	if err != nil {
+		return WorkResult{Text: err.Error(), IsError: true}
+	}
+
+	var resp struct {
+		Result *struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return WorkResult{Text: fmt.Sprintf("unreadable tool response: %v", err), IsError: true}
+	}
+	if resp.Result == nil {
+		text := "tool call failed"
+		if resp.Error != nil {
+			text = resp.Error.Message
+		}
+		return WorkResult{Text: text, IsError: true}
+	}
+	var text string
+	if len(resp.Result.Content) > 0 {
+		text = resp.Result.Content[0].Text
+	}
+	return WorkResult{Text: text, IsError: resp.Result.IsError}
+}
+
+// workerConcurrency is WORKER_CONCURRENCY, the calls a worker runs at once
+func workerConcurrency() int {
+	return max(parseEnvInt("WORKER_CONCURRENCY", defaultWorkerConcurrency), 1)
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestWorkQueue_ApiAndWorker(t *testing.T) {
+	_, client := useMiniredis(t)
+	t.Setenv("MOCK_FIXTURES", "")
+	queue := &RedisWorkQueue{client: client}
+
+	// The front end has the tool registered but must never run it
+	front := server.NewMCPServer(serverName, serverVersion,
+		server.WithToolHandlerMiddleware(newWorkQueueMiddleware(func() *RedisWorkQueue { return queue })))
+	front.AddTool(mcp.NewTool("dialectic_reason"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		t.Error("Expected the front end to queue the run, not run it")
+		return mcp.NewToolResultError("ran locally"), nil
+	})
+
+	worker := integrationServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		runWorker(ctx, worker, queue, 2)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	result := callTool(t, front, "dialectic_reason", map[string]interface{}{"problem": "What is the capital of France?", "provider": "mock", "max_rounds": 1})
+	if answer, _ := result["final_answer"].(string); !strings.Contains(answer, "mock answer") {
+		t.Errorf("Expected the worker's answer, got %v", result["final_answer"])
+	}
+	if status := queue.Status(context.Background()); status.Pending != 0 || status.Processing != 0 {
+		t.Errorf("Expected an empty queue afterwards, got %+v", status)
+	}
+}
+
+func TestWorkQueue_RequeuesAbandonedRuns(t *testing.T) {
+	mr, client := useMiniredis(t)
+	queue := &RedisWorkQueue{client: client}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := queue.Enqueue(ctx, WorkItem{ID: "work_1", Tool: "reflexion", EnqueuedAt: time.Now()}); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	for attempt := 1; attempt <= maxWorkAttempts; attempt++ {
+		item, err := queue.Next(ctx, "dead-worker")
+		if err != nil || item.ID != "work_1" || item.Attempt != attempt {
+			t.Fatalf("Expected attempt %d of work_1, got %+v, %v", attempt, item, err)
+		}
+		if n, _ := queue.Requeue(ctx); n != 0 {
+			t.Fatalf("Expected a leased run to stay put, got %d requeued", n)
+		}
+		mr.FastForward(workLease + time.Second)
+		want := 1
+		if attempt == maxWorkAttempts {
+			want = 0 // Out of attempts: failed instead
+		}
+		if n, err := queue.Requeue(ctx); err != nil || n != want {
+			t.Fatalf("Attempt %d: expected %d requeued, got %d, %v", attempt, want, n, err)
+		}
+	}
+
+	result, err := queue.Await(ctx, "work_1")
+	if err != nil || !result.IsError || !strings.Contains(result.Text, "abandoned") {
+		t.Errorf("Expected the run failed after %d attempts, got %+v, %v", maxWorkAttempts, result, err)
+	}
+}
@@ -0,0 +1,46 @@
+// Package providers exposes the LLM providers used by the reasoning
+// strategies. Any type implementing Provider can drive them, so programs can
+// plug in their own client as well as the built-in HTTP providers.
+package providers
+
+import "reasoning-tools/internal/core"
+
+type (
+	// Provider is an LLM backend
+	Provider = core.Provider
+	// StreamingProvider is a Provider that can stream tokens
+	StreamingProvider = core.StreamingProvider
+	// ToolCallingProvider is a Provider with native function calling
+	ToolCallingProvider = core.ToolCallingProvider
+	// EmbeddingProvider embeds text for similarity checks
+	EmbeddingProvider = core.EmbeddingProvider
+
+	ChatMessage   = core.ChatMessage
+	ChatOptions   = core.ChatOptions
+	TokenCallback = core.TokenCallback
+
+	ToolSchema     = core.ToolSchema
+	NativeToolCall = core.NativeToolCall
+	ChatResponse   = core.ChatResponse
+
+	// Config selects a built-in provider: openai, anthropic, groq, ollama,
+	// deepseek, openrouter, zai, together or mock
+	Config = core.ProviderConfig
+)
+
+// New creates a built-in provider wrapped in the default retry, rate limit
+// and concurrency middleware
+func New(cfg Config) (Provider, error) {
+	return core.NewProvider(cfg)
+}
+
+// FromEnv creates the provider named by LLM_PROVIDER (or detected from the
+// API keys set), configured from the environment as the server does
+func FromEnv() (Provider, error) {
+	return core.NewProviderFromEnv()
+}
+
+// WithFallbacks tries each provider in order until one succeeds
+func WithFallbacks(providers ...Provider) Provider {
+	return core.NewFallbackProvider(providers)
+}
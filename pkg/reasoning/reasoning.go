@@ -0,0 +1,239 @@
+// Package reasoning embeds the reasoning strategies of reasoning-tools in
+// other Go programs, without running the MCP server:
+//
+//	provider, err := providers.FromEnv()
+//	if err != nil {
+//		return err
+//	}
+//	result, err := reasoning.NewGraphOfThoughts(provider, reasoning.DefaultGoTConfig()).Solve(ctx, problem)
+//
+// Settings the server reads from the environment, such as provider timeouts
+// and the reflexion memory path, are read the same way here.
+package reasoning
+
+import (
+	"reasoning-tools/internal/core"
+	"reasoning-tools/pkg/providers"
+)
+
+// ProgressUpdate is a progress event reported by every strategy
+type ProgressUpdate = core.ProgressUpdate
+
+// ============ Events ============
+
+type (
+	ReasonerBase     = core.ReasonerBase
+	EventBus         = core.EventBus
+	Event            = core.Event
+	ThoughtGenerated = core.ThoughtGenerated
+	NodeScored       = core.NodeScored
+	ToolExecuted     = core.ToolExecuted
+	ProgressReported = core.ProgressReported
+	TokenStreamed    = core.TokenStreamed
+	RoundCompleted   = core.RoundCompleted
+	RunFinished      = core.RunFinished
+)
+
+// NewEventBus creates a bus to pass to SetEventBus
+func NewEventBus() *EventBus {
+	return core.NewEventBus()
+}
+
+// ============ Shared Settings and Reports ============
+
+type (
+	CalibrationConfig   = core.CalibrationConfig
+	CalibrationReport   = core.CalibrationReport
+	ScoreSamples        = core.ScoreSamples
+	SharedMemoryConfig  = core.SharedMemoryConfig
+	Provenance          = core.Provenance
+	JSONRepairUsage     = core.JSONRepairUsage
+	LLMCallUsage        = core.LLMCallUsage
+	HistorySummaryUsage = core.HistorySummaryUsage
+)
+
+// DefaultCalibrationConfig returns calibration off; raise Samples to sample
+// every evaluator score
+func DefaultCalibrationConfig() CalibrationConfig {
+	return core.DefaultCalibrationConfig()
+}
+
+// DefaultSharedMemoryConfig returns episodic memory unused, in the default
+// namespace
+func DefaultSharedMemoryConfig() SharedMemoryConfig {
+	return core.DefaultSharedMemoryConfig()
+}
+
+// ============ Sequential Thinking ============
+
+type (
+	SequentialClient = core.SequentialClient
+	ThinkingStep     = core.ThinkingStep
+	ThinkingResult   = core.ThinkingResult
+	SequentialChain  = core.SequentialChain
+)
+
+// NewSequentialClient creates a linear chain-of-thought reasoner
+func NewSequentialClient(provider providers.Provider) *SequentialClient {
+	return core.NewSequentialClient(provider)
+}
+
+// NewSequentialChain starts a thinking chain for SequentialClient.Extend
+func NewSequentialChain(problem string) *SequentialChain {
+	return core.NewSequentialChain(problem)
+}
+
+// ============ Graph of Thoughts ============
+
+type (
+	GraphOfThoughts = core.GraphOfThoughts
+	GoTConfig       = core.GoTConfig
+	GoTNode         = core.GoTNode
+	GoTDecision     = core.GoTDecision
+	GoTResult       = core.GoTResult
+	GoTRunState     = core.GoTRunState
+	GoTRunStore     = core.GoTRunStore
+	GraphRendering  = core.GraphRendering
+	GraphExport     = core.GraphExport
+	GraphExportNode = core.GraphExportNode
+	GraphExportEdge = core.GraphExportEdge
+	SimilarityStats = core.SimilarityStats
+)
+
+// DefaultGoTConfig returns the Graph of Thoughts defaults
+func DefaultGoTConfig() GoTConfig {
+	return core.DefaultGoTConfig()
+}
+
+// NewGraphOfThoughts creates a Graph of Thoughts reasoner
+func NewGraphOfThoughts(provider providers.Provider, config GoTConfig) *GraphOfThoughts {
+	return core.NewGraphOfThoughts(provider, config)
+}
+
+// NewGoTRunStore creates a store for GraphOfThoughts.SetRunStore rooted at
+// dir, keeping at most maxRuns runs (0 = unlimited)
+func NewGoTRunStore(dir string, maxRuns int) (*GoTRunStore, error) {
+	return core.NewGoTRunStore(dir, maxRuns)
+}
+
+// ============ Reflexion ============
+
+type (
+	Reflexion       = core.Reflexion
+	ReflexionConfig = core.ReflexionConfig
+	ReflexionResult = core.ReflexionResult
+	Attempt         = core.Attempt
+	AttemptBudget   = core.AttemptBudget
+	AttemptProvider = core.AttemptProvider
+	EvaluationVotes = core.EvaluationVotes
+	EvaluatorVote   = core.EvaluatorVote
+	HiddenTests     = core.HiddenTests
+	TestCase        = core.TestCase
+	TestCaseResult  = core.TestCaseResult
+	SuccessCriteria = core.SuccessCriteria
+)
+
+// DefaultReflexionConfig returns the Reflexion defaults
+func DefaultReflexionConfig() ReflexionConfig {
+	return core.DefaultReflexionConfig()
+}
+
+// NewReflexion creates a Reflexion reasoner
+func NewReflexion(provider providers.Provider, config ReflexionConfig) *Reflexion {
+	return core.NewReflexion(provider, config)
+}
+
+// ParseAttemptProviders builds an attempt or evaluator rotation from
+// "provider[:model]" entries separated by commas
+func ParseAttemptProviders(raw string) ([]AttemptProvider, error) {
+	return core.ParseAttemptProviders(raw)
+}
+
+// ParseHiddenTests reads a JSON array of {"input", "expected"} pairs or a
+// Python test snippet
+func ParseHiddenTests(raw string) (*HiddenTests, error) {
+	return core.ParseHiddenTests(raw)
+}
+
+// ParseSuccessCriteria reads a JSON object of criteria, or plain text used
+// as the rubric
+func ParseSuccessCriteria(raw string) (*SuccessCriteria, error) {
+	return core.ParseSuccessCriteria(raw)
+}
+
+// NewSuccessCriteria checks criteria and compiles their regex
+func NewSuccessCriteria(criteria SuccessCriteria) (*SuccessCriteria, error) {
+	return core.NewSuccessCriteria(criteria)
+}
+
+// ============ Dialectic ============
+
+type (
+	DialecticalReasoner = core.DialecticalReasoner
+	DialecticConfig     = core.DialecticConfig
+	DialecticStep       = core.DialecticStep
+	DialecticResult     = core.DialecticResult
+	DialecticCheckpoint = core.DialecticCheckpoint
+	CheckpointDecision  = core.CheckpointDecision
+	CheckpointHandler   = core.CheckpointHandler
+	Claim               = core.Claim
+	Challenge           = core.Challenge
+	Verification        = core.Verification
+	VerificationStatus  = core.VerificationStatus
+	OpenQuestion        = core.OpenQuestion
+	RoundConfidence     = core.RoundConfidence
+
+	ClaimVerificationResult = core.ClaimVerificationResult
+)
+
+// Verification statuses
+const (
+	StatusVerified   = core.StatusVerified
+	StatusUnverified = core.StatusUnverified
+	StatusSkipped    = core.StatusSkipped
+)
+
+// DefaultDialecticConfig returns the dialectic defaults
+func DefaultDialecticConfig() DialecticConfig {
+	return core.DefaultDialecticConfig()
+}
+
+// NewDialecticalReasoner creates a thesis-antithesis-synthesis reasoner
+func NewDialecticalReasoner(provider providers.Provider, config DialecticConfig) *DialecticalReasoner {
+	return core.NewDialecticalReasoner(provider, config)
+}
+
+// ============ Decompose and Plan-Execute ============
+
+type (
+	DecomposeSolver   = core.DecomposeSolver
+	DecomposeConfig   = core.DecomposeConfig
+	DecomposeResult   = core.DecomposeResult
+	PlanExecutor      = core.PlanExecutor
+	PlanExecuteConfig = core.PlanExecuteConfig
+	PlanExecuteResult = core.PlanExecuteResult
+	Subproblem        = core.Subproblem
+	PlanStep          = core.PlanStep
+	StepLog           = core.StepLog
+	Replan            = core.Replan
+)
+
+// DefaultDecomposeConfig returns the Least-to-Most defaults
+func DefaultDecomposeConfig() DecomposeConfig {
+	return core.DefaultDecomposeConfig()
+}
+
+// NewDecomposeSolver creates a Least-to-Most reasoner
+func NewDecomposeSolver(provider providers.Provider, config DecomposeConfig) *DecomposeSolver {
+	return core.NewDecomposeSolver(provider, config)
+}
+
+// DefaultPlanExecuteConfig returns the plan-and-execute defaults
+func DefaultPlanExecuteConfig() PlanExecuteConfig {
+	return core.DefaultPlanExecuteConfig()
+}
+
+// NewPlanExecutor creates a plan-and-execute agent
+func NewPlanExecutor(provider providers.Provider, config PlanExecuteConfig) *PlanExecutor {
+	return core.NewPlanExecutor(provider, config)
+}
@@ -0,0 +1,98 @@
+package reasoning_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"reasoning-tools/pkg/providers"
+	"reasoning-tools/pkg/reasoning"
+)
+
+func mockProvider(t *testing.T) providers.Provider {
+	t.Helper()
+	t.Setenv("REFLEXION_MEMORY_PATH", filepath.Join(t.TempDir(), "memory.json"))
+	provider, err := providers.New(providers.Config{Type: "mock"})
+	if err != nil {
+		t.Fatalf("mock provider: %v", err)
+	}
+	return provider
+}
+
+func TestEmbeddedStrategies(t *testing.T) {
+	provider := mockProvider(t)
+	ctx := context.Background()
+
+	thinking, err := reasoning.NewSequentialClient(provider).Think(ctx, "What is 2 + 2?", 3)
+	if err != nil || !thinking.Success || thinking.FinalAnswer == "" {
+		t.Errorf("sequential thinking: %+v, %v", thinking, err)
+	}
+
+	var events int
+	got := reasoning.NewGraphOfThoughts(provider, reasoning.DefaultGoTConfig())
+	got.SetProgressCallback(func(reasoning.ProgressUpdate) { events++ })
+	graph, err := got.Solve(ctx, "What is 2 + 2?")
+	if err != nil || !graph.Success || events == 0 {
+		t.Errorf("graph of thoughts: %+v (%d events), %v", graph, events, err)
+	}
+
+	config := reasoning.DefaultReflexionConfig()
+	config.MaxAttempts = 1
+	reflexion, err := reasoning.NewReflexion(provider, config).Reason(ctx, "What is 2 + 2?")
+	if err != nil || reflexion.FinalAnswer == "" {
+		t.Errorf("reflexion: %+v, %v", reflexion, err)
+	}
+
+	dialectic := reasoning.DefaultDialecticConfig()
+	dialectic.MaxRounds = 1
+	debate, err := reasoning.NewDialecticalReasoner(provider, dialectic).Reason(ctx, "Should we use Go?")
+	if err != nil || debate.FinalAnswer == "" {
+		t.Errorf("dialectic: %+v, %v", debate, err)
+	}
+}
+
+// TestConfigFieldsSettable sets every config field whose type lives in the
+// internal package, so a missing alias or constructor fails to compile
+func TestConfigFieldsSettable(t *testing.T) {
+	provider := mockProvider(t)
+
+	tests, err := reasoning.ParseHiddenTests(`[{"input": "add(2, 3)", "expected": "5"}]`)
+	if err != nil || tests.Cases[0] != (reasoning.TestCase{Input: "add(2, 3)", Expected: "5"}) {
+		t.Fatalf("ParseHiddenTests: %+v, %v", tests, err)
+	}
+	criteria, err := reasoning.NewSuccessCriteria(reasoning.SuccessCriteria{Regex: `^\d+$`})
+	if err != nil || !criteria.Programmatic() {
+		t.Fatalf("NewSuccessCriteria: %+v, %v", criteria, err)
+	}
+	rotation := []reasoning.AttemptProvider{{Label: "mock", Provider: provider}}
+	calibration := reasoning.DefaultCalibrationConfig()
+	calibration.Samples = 2
+	memory := reasoning.DefaultSharedMemoryConfig()
+	memory.LearnFromPast = true
+
+	reflexion := reasoning.DefaultReflexionConfig()
+	reflexion.HiddenTests = tests
+	reflexion.SuccessCriteria = criteria
+	reflexion.AttemptProviders = rotation
+	reflexion.Evaluators = rotation
+	reflexion.Calibration = calibration
+
+	got := reasoning.DefaultGoTConfig()
+	got.Calibration = calibration
+	got.Memory = memory
+
+	dialectic := reasoning.DefaultDialecticConfig()
+	dialectic.Calibration = calibration
+	dialectic.Memory = memory
+
+	store, err := reasoning.NewGoTRunStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewGoTRunStore: %v", err)
+	}
+	graph := reasoning.NewGraphOfThoughts(provider, got)
+	graph.SetRunStore(store)
+	graph.SetEventBus(reasoning.NewEventBus())
+	_ = reasoning.NewReflexion(provider, reflexion)
+	_ = reasoning.NewDialecticalReasoner(provider, dialectic)
+	_ = reasoning.NewSequentialChain("What is 2 + 2?")
+}
@@ -0,0 +1,32 @@
+// Package tools exposes the tool registry the reasoning strategies call
+// during reasoning: calculator, code execution, web fetch, string, units
+// and symbolic math tools, plus any registered Executor.
+package tools
+
+import "reasoning-tools/internal/core"
+
+type (
+	// Registry holds the available tools and which are enabled
+	Registry = core.ToolRegistry
+	// Executor is a tool implementation
+	Executor = core.ToolExecutor
+	// Tool describes an available tool
+	Tool = core.Tool
+	// Call is a request to run a tool
+	Call = core.ToolCall
+	// Result is the outcome of running a tool
+	Result = core.ToolResult
+	// Budget caps tool calls across a run, optionally per phase
+	Budget = core.ToolBudget
+)
+
+// NewRegistry creates a registry with the built-in tools. Every tool except
+// code_exec, which must be enabled explicitly, starts enabled.
+func NewRegistry() *Registry {
+	return core.NewToolRegistry()
+}
+
+// NewBudget creates a budget of total tool calls (0 is unlimited)
+func NewBudget(total int) *Budget {
+	return core.NewToolBudget(total)
+}
@@ -0,0 +1,31 @@
+package tools_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"reasoning-tools/pkg/tools"
+)
+
+// shout is a custom tool registered alongside the built-ins
+type shout struct{}
+
+func (shout) Name() string        { return "shout" }
+func (shout) Description() string { return "Upper-cases its input" }
+func (shout) Execute(_ context.Context, input string) (string, error) {
+	return strings.ToUpper(input), nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := tools.NewRegistry()
+	if res := registry.Execute(context.Background(), "calculator", "17 * 23"); !res.Success || !strings.Contains(res.Output, "391") {
+		t.Errorf("Expected the built-in calculator to work, got %+v", res)
+	}
+
+	registry.Register(shout{})
+	registry.Enable("shout")
+	if res := registry.Execute(context.Background(), "shout", "hi"); res.Output != "HI" {
+		t.Errorf("Expected the custom tool to run, got %+v", res)
+	}
+}